@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -66,3 +67,79 @@ func GetAccessTokenForCLISession(id string) (CLISessionAuth, error) {
 
 	return result, nil
 }
+
+// DeviceAuth - a device code login, completed by entering UserCode at
+// VerificationURL from any browser
+type DeviceAuth struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	// ExpiresIn and Interval are in seconds
+	ExpiresIn int `json:"expires_in"`
+	Interval  int `json:"interval"`
+}
+
+// ErrAuthorizationPending - the device code hasn't been entered yet
+var ErrAuthorizationPending = errors.New("authorization pending")
+
+// StartDeviceAuth starts a device code login, for machines without a browser
+func StartDeviceAuth(machineName string) (DeviceAuth, error) {
+	var result DeviceAuth
+
+	postData, _ := json.Marshal(map[string]interface{}{
+		"name": machineName,
+	})
+
+	url := fmt.Sprintf("%s/api/v1/cli_sessions/device", baseURL)
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(postData))
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		return result, ErrUnknown
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// GetAccessTokenForDevice obtains the access token once the device code has
+// been entered, returning ErrAuthorizationPending until then
+func GetAccessTokenForDevice(deviceCode string) (string, error) {
+	postData, _ := json.Marshal(map[string]interface{}{
+		"device_code": deviceCode,
+	})
+
+	url := fmt.Sprintf("%s/api/v1/cli_sessions/device/token", baseURL)
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(postData))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+	case 202, 428:
+		return "", ErrAuthorizationPending
+	case 404, 410:
+		return "", ErrNotFound
+	default:
+		return "", ErrUnknown
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.AccessToken, nil
+}
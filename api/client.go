@@ -3,6 +3,8 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +12,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/machinebox/graphql"
 	"github.com/superfly/flyctl/flyname"
@@ -30,12 +33,20 @@ func SetErrorLog(log bool) {
 
 // Client - API client encapsulating the http and GraphQL clients
 type Client struct {
-	httpClient  *http.Client
-	client      *graphql.Client
+	httpClient *http.Client
+	client     *graphql.Client
+	userAgent  string
+
+	authMu      sync.Mutex
 	accessToken string
-	userAgent   string
+	reauth      ReauthFunc
+	reauthErr   error
 }
 
+// ReauthFunc returns a new access token after the API rejected the current
+// one, by logging in again
+type ReauthFunc func(ctx context.Context) (string, error)
+
 // NewClient - creates a new Client, takes an access token
 func NewClient(accessToken string, version string) *Client {
 
@@ -45,7 +56,56 @@ func NewClient(accessToken string, version string) *Client {
 
 	client := graphql.NewClient(url, graphql.WithHTTPClient(httpClient))
 	userAgent := fmt.Sprintf("%s/%s", flyname.Name(), version)
-	return &Client{httpClient, client, accessToken, userAgent}
+	return &Client{
+		httpClient:  httpClient,
+		client:      client,
+		accessToken: accessToken,
+		userAgent:   userAgent,
+	}
+}
+
+// SetReauth - renews the access token with reauth when the API rejects it,
+// retrying the rejected request once with the new token
+func (c *Client) SetReauth(reauth ReauthFunc) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.reauth = reauth
+}
+
+func (c *Client) token() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.accessToken
+}
+
+// reauthenticate renews rejected, the token a request was rejected with.
+// Requests rejected at the same time share one renewal, and a failed one
+// isn't tried again, so there's only ever one prompt to log in.
+func (c *Client) reauthenticate(ctx context.Context, rejected string) (string, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.accessToken != rejected {
+		return c.accessToken, nil
+	}
+	if c.reauth == nil {
+		return "", errors.New("no way to renew the access token")
+	}
+	if c.reauthErr != nil {
+		return "", c.reauthErr
+	}
+
+	token, err := c.reauth(ctx)
+	if err == nil && (token == "" || token == rejected) {
+		err = errors.New("the access token wasn't renewed")
+	}
+	if err != nil {
+		c.reauthErr = err
+		return "", err
+	}
+
+	c.accessToken = token
+	return token, nil
 }
 
 // NewRequest - creates a new GraphQL request
@@ -59,15 +119,59 @@ func (c *Client) Run(req *graphql.Request) (Query, error) {
 	return c.RunWithContext(context.Background(), req)
 }
 
-// RunWithContext - Runs a GraphQL request within a Go context
+// RunWithContext - Runs a GraphQL request within a Go context. When the
+// access token is rejected, the request is retried once after renewing it.
 func (c *Client) RunWithContext(ctx context.Context, req *graphql.Request) (Query, error) {
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
 	req.Header.Set("User-Agent", c.userAgent)
 
+	// mutations are retried with the same key, so the API can tell a retry
+	// from a new mutation and apply it only once
+	if isMutation(req.Query()) {
+		ctx = context.WithValue(ctx, contextKeyMutation, true)
+		if req.Header.Get(headerIdempotencyKey) == "" {
+			if key := newIdempotencyKey(); key != "" {
+				req.Header.Set(headerIdempotencyKey, key)
+			}
+		}
+	}
+
+	token := c.token()
+	resp, err := c.run(ctx, req, token)
+	if IsNotAuthenticatedError(err) && c.hasReauth() {
+		if token, reauthErr := c.reauthenticate(ctx, token); reauthErr == nil {
+			return c.run(ctx, req, token)
+		}
+	}
+
+	return resp, err
+}
+
+func (c *Client) hasReauth() bool {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.reauth != nil
+}
+
+func (c *Client) run(ctx context.Context, req *graphql.Request, token string) (Query, error) {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	if _, ok := ctx.Deadline(); !ok && retryConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, retryConfig.Timeout)
+		defer cancel()
+	}
+
 	var resp Query
 	err := c.client.Run(ctx, req, &resp)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return resp, fmt.Errorf("API request timed out after %s: %w", retryConfig.Timeout, ctx.Err())
+	}
 	if err != nil && strings.HasPrefix(err.Error(), "graphql: ") {
-		return resp, errors.New(strings.TrimPrefix(err.Error(), "graphql: "))
+		message := strings.TrimPrefix(err.Error(), "graphql: ")
+		if isNotAuthenticatedMessage(message) {
+			return resp, &ApiError{Message: message, Status: 401}
+		}
+		return resp, errors.New(message)
 	}
 
 	if resp.Errors != nil && errorLog {
@@ -77,6 +181,28 @@ func (c *Client) RunWithContext(ctx context.Context, req *graphql.Request) (Quer
 	return resp, err
 }
 
+// isNotAuthenticatedMessage is true for the errors the API answers with when
+// the access token is missing, expired or revoked, as a GraphQL error or a
+// 401 status
+func isNotAuthenticatedMessage(message string) bool {
+	return strings.HasPrefix(message, "You must be authenticated") ||
+		strings.HasSuffix(message, "non-200 status code: 401")
+}
+
+const headerIdempotencyKey = "Idempotency-Key"
+
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
+
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(key)
+}
+
 var compactPattern = regexp.MustCompile(`\s+`)
 
 func compactQueryString(q string) string {
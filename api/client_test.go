@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer answers GraphQL requests authorized with token, and rejects
+// others as the API does
+func authServer(t *testing.T, token *string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+*token {
+			w.Write([]byte(`{"data":null,"errors":[{"message":"You must be authenticated to view this."}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"currentUser":{"email":"me@example.com"}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	prevBaseURL := baseURL
+	SetBaseURL(server.URL)
+	t.Cleanup(func() { SetBaseURL(prevBaseURL) })
+	return server
+}
+
+func TestReauthRetriesRejectedRequest(t *testing.T) {
+	valid := "new-token"
+	authServer(t, &valid)
+
+	client := NewClient("expired-token", "test")
+	reauths := 0
+	client.SetReauth(func(context.Context) (string, error) {
+		reauths++
+		return "new-token", nil
+	})
+
+	for i := 0; i < 2; i++ {
+		user, err := client.GetCurrentUser()
+		require.NoError(t, err)
+		assert.Equal(t, "me@example.com", user.Email)
+	}
+	assert.Equal(t, 1, reauths)
+}
+
+func TestReauthOnlyTriedOnce(t *testing.T) {
+	valid := "new-token"
+	authServer(t, &valid)
+
+	client := NewClient("expired-token", "test")
+	reauths := 0
+	client.SetReauth(func(context.Context) (string, error) {
+		reauths++
+		return "", errors.New("declined")
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetCurrentUser()
+		assert.True(t, IsNotAuthenticatedError(err), "%v", err)
+	}
+	assert.Equal(t, 1, reauths, "a failed renewal isn't asked for again")
+}
@@ -3,8 +3,11 @@ package api
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/PuerkitoBio/rehttp"
@@ -14,21 +17,62 @@ import (
 
 var retryErrors = []string{"INTERNAL_ERROR", "read: connection reset by peer"}
 
+// RetryConfig - how API requests are retried and timed out
+type RetryConfig struct {
+	// MaxRetries - how many times a request is retried after a server error,
+	// rate limit or network error
+	MaxRetries int
+	// MinDelay and MaxDelay bound the jittered backoff between retries
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Timeout - how long a request may take, including retries
+	Timeout time.Duration
+	// AttemptTimeout - how long to wait for a response before retrying
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryConfig - the retry config used unless SetRetryConfig is called
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     3,
+		MinDelay:       100 * time.Millisecond,
+		MaxDelay:       5 * time.Second,
+		Timeout:        2 * time.Minute,
+		AttemptTimeout: 30 * time.Second,
+	}
+}
+
+var retryConfig = DefaultRetryConfig()
+
+// SetRetryConfig - Sets how API requests are retried and timed out
+func SetRetryConfig(config RetryConfig) {
+	retryConfig = config
+}
+
+// maxRetryAfter caps how long a Retry-After header can make us wait
+const maxRetryAfter = 30 * time.Second
+
 func newHTTPClient() (*http.Client, error) {
+	baseTransport := http.DefaultTransport.(*http.Transport).Clone()
+	baseTransport.ResponseHeaderTimeout = retryConfig.AttemptTimeout
+
 	retryTransport := rehttp.NewTransport(
-		http.DefaultTransport,
+		&attemptTransport{innerTransport: baseTransport},
 		rehttp.RetryAll(
-			rehttp.RetryMaxRetries(3),
+			rehttp.RetryMaxRetries(retryConfig.MaxRetries),
 			rehttp.RetryAny(
 				rehttp.RetryTemporaryErr(),
-				rehttp.RetryStatuses(502, 503),
+				rehttp.RetryStatuses(http.StatusTooManyRequests),
+				retryServerError,
 			),
 		),
-		rehttp.ExpJitterDelay(100*time.Millisecond, 1*time.Second),
+		retryDelay(retryConfig.MinDelay, retryConfig.MaxDelay),
 	)
 
 	transport := &LoggingTransport{
-		innerTransport: retryTransport,
+		innerTransport: &TracingTransport{
+			innerTransport: &proxyErrorTransport{innerTransport: retryTransport, proxy: baseTransport.Proxy},
+		},
 	}
 
 	httpClient := &http.Client{
@@ -38,6 +82,70 @@ func newHTTPClient() (*http.Client, error) {
 	return httpClient, nil
 }
 
+// retryDelay backs off exponentially with jitter, or waits as long as a rate
+// limited response's Retry-After asks
+func retryDelay(min, max time.Duration) rehttp.DelayFn {
+	backoff := rehttp.ExpJitterDelay(min, max)
+
+	return func(attempt rehttp.Attempt) time.Duration {
+		if attempt.Response != nil && attempt.Response.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(attempt.Response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				delay := time.Duration(seconds) * time.Second
+				if delay > maxRetryAfter {
+					delay = maxRetryAfter
+				}
+				terminal.Debugf("Rate limited, retrying in %s\n", delay)
+				return delay
+			}
+		}
+
+		delay := backoff(attempt)
+		if delay < min {
+			delay = min
+		}
+		terminal.Debugf("Request failed, retry %d in %s\n", attempt.Index+1, delay)
+		return delay
+	}
+}
+
+// retryServerError retries requests that failed with a server error. A
+// mutation is only retried when a gateway failed to reach the API, as after
+// any other server error it may already have been applied, and not every
+// mutation honors its idempotency key.
+func retryServerError(attempt rehttp.Attempt) bool {
+	if attempt.Response == nil {
+		return false
+	}
+
+	status := attempt.Response.StatusCode
+	if mutation, _ := attempt.Request.Context().Value(contextKeyMutation).(bool); mutation {
+		switch status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return status >= 500 && status < 600
+}
+
+// proxyErrorTransport names the proxy a failed request went through, as
+// errors from a proxy are otherwise hard to tell from the API's own
+type proxyErrorTransport struct {
+	innerTransport http.RoundTripper
+	proxy          func(*http.Request) (*url.URL, error)
+}
+
+func (t *proxyErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.innerTransport.RoundTrip(req)
+	if err != nil && t.proxy != nil {
+		if proxyURL, proxyErr := t.proxy(req); proxyErr == nil && proxyURL != nil {
+			err = fmt.Errorf("through proxy %s from HTTPS_PROXY or HTTP_PROXY, add %s to NO_PROXY to bypass it: %w", proxyURL.Host, req.URL.Hostname(), err)
+		}
+	}
+	return resp, err
+}
+
 type LoggingTransport struct {
 	innerTransport http.RoundTripper
 }
@@ -48,6 +156,9 @@ type contextKey struct {
 
 var contextKeyRequestStart = &contextKey{"RequestStart"}
 
+// contextKeyMutation marks the requests that run a GraphQL mutation
+var contextKeyMutation = &contextKey{"Mutation"}
+
 func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	ctx := context.WithValue(req.Context(), contextKeyRequestStart, time.Now())
 	req = req.WithContext(ctx)
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PromSample - a single value of a Prometheus series
+type PromSample struct {
+	Time  time.Time
+	Value float64
+}
+
+// PromSeries - a Prometheus series and its labels
+type PromSeries struct {
+	Labels  map[string]string
+	Samples []PromSample
+}
+
+type promResponse struct {
+	Status    string
+	ErrorType string
+	Error     string
+	Data      struct {
+		ResultType string
+		Result     []struct {
+			Metric map[string]string
+			Value  []interface{}
+			Values [][]interface{}
+		}
+	}
+}
+
+// PromQueryRange - runs a PromQL range query against an organization's metrics
+func (c *Client) PromQueryRange(ctx context.Context, orgSlug string, query string, start, end time.Time, step time.Duration) ([]PromSeries, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	return c.promQuery(ctx, orgSlug, "query_range", params)
+}
+
+// PromQuery - runs a PromQL instant query against an organization's metrics
+func (c *Client) PromQuery(ctx context.Context, orgSlug string, query string, at time.Time) ([]PromSeries, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	if !at.IsZero() {
+		params.Set("time", strconv.FormatInt(at.Unix(), 10))
+	}
+
+	return c.promQuery(ctx, orgSlug, "query", params)
+}
+
+// PrometheusURL - the Prometheus compatible endpoint for an organization's metrics, suitable for Grafana data sources
+func PrometheusURL(orgSlug string) string {
+	return fmt.Sprintf("%s/prometheus/%s", baseURL, url.PathEscape(orgSlug))
+}
+
+func (c *Client) promQuery(ctx context.Context, orgSlug string, endpoint string, params url.Values) ([]PromSeries, error) {
+	reqURL := fmt.Sprintf("%s/api/v1/%s?%s", PrometheusURL(orgSlug), endpoint, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token()))
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data promResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&data)
+
+	if resp.StatusCode >= 400 {
+		apiErr := ErrorFromResp(resp)
+		if data.Error != "" {
+			apiErr.Message = data.Error
+		}
+		return nil, apiErr
+	}
+
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	if data.Status != "success" {
+		return nil, fmt.Errorf("metrics query failed: %s", data.Error)
+	}
+
+	out := []PromSeries{}
+
+	for _, result := range data.Data.Result {
+		series := PromSeries{Labels: result.Metric}
+
+		values := result.Values
+		if result.Value != nil {
+			values = [][]interface{}{result.Value}
+		}
+
+		for _, v := range values {
+			sample, err := parsePromSample(v)
+			if err != nil {
+				return nil, err
+			}
+			series.Samples = append(series.Samples, sample)
+		}
+
+		out = append(out, series)
+	}
+
+	return out, nil
+}
+
+func parsePromSample(v []interface{}) (PromSample, error) {
+	if len(v) != 2 {
+		return PromSample{}, fmt.Errorf("unexpected metrics sample %v", v)
+	}
+
+	ts, ok := v[0].(float64)
+	if !ok {
+		return PromSample{}, fmt.Errorf("unexpected metrics timestamp %v", v[0])
+	}
+
+	raw, ok := v[1].(string)
+	if !ok {
+		return PromSample{}, fmt.Errorf("unexpected metrics value %v", v[1])
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return PromSample{}, err
+	}
+
+	sec := int64(ts)
+	return PromSample{
+		Time:  time.Unix(sec, int64((ts-float64(sec))*float64(time.Second))),
+		Value: value,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromQueryRange(t *testing.T) {
+	var path, query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{
+			"status": "success",
+			"data": {
+				"resultType": "matrix",
+				"result": [
+					{
+						"metric": {"instance": "a1b2c3d4", "region": "ord"},
+						"values": [[1614600000, "0.5"], [1614600060.5, "NaN"]]
+					},
+					{
+						"metric": {"instance": "e5f6a7b8", "region": "ams"},
+						"values": []
+					}
+				]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	defer SetBaseURL(baseURL)
+	SetBaseURL(server.URL)
+
+	client := NewClient("token", "test")
+	start := time.Unix(1614600000, 0)
+	series, err := client.PromQueryRange(context.Background(), "personal", "fly_instance_up", start, start.Add(time.Minute), time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/prometheus/personal/api/v1/query_range", path)
+	assert.Equal(t, "fly_instance_up", query)
+
+	require.Len(t, series, 2)
+	assert.Equal(t, map[string]string{"instance": "a1b2c3d4", "region": "ord"}, series[0].Labels)
+	require.Len(t, series[0].Samples, 2)
+	assert.Equal(t, start, series[0].Samples[0].Time)
+	assert.Equal(t, 0.5, series[0].Samples[0].Value)
+	assert.Equal(t, start.Add(60500*time.Millisecond), series[0].Samples[1].Time)
+	assert.True(t, math.IsNaN(series[0].Samples[1].Value))
+	assert.Empty(t, series[1].Samples)
+}
+
+func TestPromQueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status": "error", "errorType": "bad_data", "error": "parse error at char 4"}`))
+	}))
+	defer server.Close()
+
+	defer SetBaseURL(baseURL)
+	SetBaseURL(server.URL)
+
+	client := NewClient("token", "test")
+	_, err := client.PromQuery(context.Background(), "personal", "up{", time.Time{})
+	assert.EqualError(t, err, "parse error at char 4")
+}
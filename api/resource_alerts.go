@@ -0,0 +1,87 @@
+package api
+
+func (client *Client) GetAlertRules(appName string) ([]AlertRule, error) {
+	q := `
+		query($appName: String!) {
+			app(name: $appName) {
+				alertRules {
+					nodes {
+						id
+						name
+						kind
+						threshold
+						windowSeconds
+						handlers {
+							name
+							type
+						}
+						state
+						createdAt
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(q)
+	req.Var("appName", appName)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.AlertRules.Nodes, nil
+}
+
+func (client *Client) CreateAlertRule(input CreateAlertRuleInput) (*AlertRule, error) {
+	q := `
+		mutation($input: CreateAlertRuleInput!) {
+			createAlertRule(input: $input) {
+				alertRule {
+					id
+					name
+					kind
+					threshold
+					windowSeconds
+					handlers {
+						name
+						type
+					}
+					state
+					createdAt
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(q)
+	req.Var("input", input)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.CreateAlertRule.AlertRule, nil
+}
+
+func (client *Client) DeleteAlertRule(appName string, name string) error {
+	q := `
+		mutation($input: DeleteAlertRuleInput!) {
+			deleteAlertRule(input: $input) {
+				clientMutationId
+			}
+		}
+	`
+
+	req := client.NewRequest(q)
+	req.Var("input", map[string]string{
+		"appId": appName,
+		"name":  name,
+	})
+
+	_, err := client.Run(req)
+
+	return err
+}
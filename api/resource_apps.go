@@ -1,5 +1,7 @@
 package api
 
+import "strings"
+
 func (client *Client) GetApps(role *string) ([]App, error) {
 	query := `
 		query($role: String) {
@@ -101,6 +103,78 @@ func (client *Client) GetApp(appName string) (*App, error) {
 	return &data.App, nil
 }
 
+// GetAppInventory lists the resources destroyed along with an app
+func (client *Client) GetAppInventory(appName string) (*AppInventory, error) {
+	query := `
+		query ($appName: String!) {
+			app(name: $appName) {
+				volumes {
+					nodes {
+						id
+						name
+						sizeGb
+						region
+						encrypted
+						attachedAllocation {
+							idShort
+						}
+					}
+				}
+				ipAddresses {
+					nodes {
+						id
+						address
+						type
+						region
+					}
+				}
+				certificates {
+					nodes {
+						hostname
+						clientStatus
+					}
+				}
+				secrets {
+					name
+				}
+				postgresAppRole: role {
+					name
+					... on PostgresClusterAppRole {
+						databases {
+							name
+							users
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("appName", appName)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &AppInventory{
+		Volumes:      data.App.Volumes.Nodes,
+		IPAddresses:  data.App.IPAddresses.Nodes,
+		Certificates: data.App.Certificates.Nodes,
+	}
+	for _, secret := range data.App.Secrets {
+		if strings.HasSuffix(secret.Name, "DATABASE_URL") {
+			inventory.DatabaseSecrets = append(inventory.DatabaseSecrets, secret.Name)
+		}
+	}
+	if role := data.App.PostgresAppRole; role != nil && role.Databases != nil {
+		inventory.Databases = *role.Databases
+	}
+
+	return inventory, nil
+}
+
 func (client *Client) GetAppCompact(appName string) (*AppCompact, error) {
 	query := `
 		query ($appName: String!) {
@@ -186,6 +260,26 @@ func (client *Client) CreateApp(name string, orgId string, preferredRegionCode *
 	return &data.CreateApp.App, nil
 }
 
+// AppNameAvailable - whether an app can be created with name, as names are
+// unique across every organization
+func (client *Client) AppNameAvailable(name string) (bool, error) {
+	query := `
+		query($name: String!) {
+			appNameAvailable(name: $name)
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("name", name)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return false, err
+	}
+
+	return data.AppNameAvailable, nil
+}
+
 func (client *Client) DeleteApp(appName string) error {
 	query := `
 			mutation($appId: ID!) {
@@ -0,0 +1,60 @@
+package api
+
+import "time"
+
+// GetAuditLogPage returns up to limit entries of an organization's audit log
+// matching filter, newest first, starting after the cursor of a previous page
+// when after is set
+func (c *Client) GetAuditLogPage(slug string, filter AuditLogFilter, limit int, after *string) ([]AuditLogEntry, *PageInfo, error) {
+	query := `
+		query($slug: String!, $limit: Int!, $after: String, $since: ISO8601DateTime, $until: ISO8601DateTime, $types: [String!]) {
+			organization(slug: $slug) {
+				auditLogs(first: $limit, after: $after, since: $since, until: $until, types: $types) {
+					nodes {
+						id
+						type
+						action
+						description
+						appName
+						actor {
+							type
+							name
+							email
+						}
+						ipAddress
+						metadata
+						createdAt
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("slug", slug)
+	req.Var("limit", limit)
+	req.Var("after", after)
+	if filter.Since != nil {
+		req.Var("since", filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		req.Var("until", filter.Until.UTC().Format(time.RFC3339))
+	}
+	if len(filter.Types) > 0 {
+		req.Var("types", filter.Types)
+	}
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data.Organization == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	return data.Organization.AuditLogs.Nodes, &data.Organization.AuditLogs.PageInfo, nil
+}
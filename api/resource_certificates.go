@@ -162,3 +162,46 @@ func (c *Client) DeleteCertificate(appName, hostname string) (*DeleteCertificate
 
 	return &data.DeleteCertificate, nil
 }
+
+// GetOrganizationCertificates - every app in an organization with its certificates
+func (c *Client) GetOrganizationCertificates(slug string) ([]App, error) {
+	query := `
+		query($slug: String!) {
+			organization(slug: $slug) {
+				apps {
+					nodes {
+						name
+						certificates {
+							nodes {
+								hostname
+								clientStatus
+								createdAt
+								configured
+								acmeDnsConfigured
+								acmeAlpnConfigured
+								isApex
+								isWildcard
+								issued {
+									nodes {
+										type
+										expiresAt
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("slug", slug)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Organization.Apps.Nodes, nil
+}
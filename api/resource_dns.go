@@ -99,3 +99,90 @@ func (c *Client) ImportDNSRecords(domainId string, zonefile string) ([]ImportDns
 
 	return data.ImportDnsZone.Warnings, data.ImportDnsZone.Changes, nil
 }
+
+func (c *Client) CreateDNSRecord(input CreateDNSRecordInput) (*DNSRecord, error) {
+	query := `
+		mutation($input: CreateDnsRecordInput!) {
+			createDnsRecord(input: $input) {
+				record {
+					id
+					fqdn
+					name
+					type
+					ttl
+					rdata
+					isApex
+					isWildcard
+					isSystem
+					createdAt
+					updatedAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", input)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreateDnsRecord.Record, nil
+}
+
+func (c *Client) UpdateDNSRecord(input UpdateDNSRecordInput) (*DNSRecord, error) {
+	query := `
+		mutation($input: UpdateDnsRecordInput!) {
+			updateDnsRecord(input: $input) {
+				record {
+					id
+					fqdn
+					name
+					type
+					ttl
+					rdata
+					isApex
+					isWildcard
+					isSystem
+					createdAt
+					updatedAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", input)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.UpdateDnsRecord.Record, nil
+}
+
+func (c *Client) DeleteDNSRecord(recordID string) error {
+	query := `
+		mutation($input: DeleteDnsRecordInput!) {
+			deleteDnsRecord(input: $input) {
+				domain {
+					id
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", map[string]interface{}{
+		"recordId": recordID,
+	})
+
+	_, err := c.Run(req)
+	return err
+}
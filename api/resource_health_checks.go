@@ -15,6 +15,7 @@ func (client *Client) GetAppHealthChecks(appName string, checkName *string, limi
 						serviceName
 						output(limit: $limitOutput, compact: $compactOutput)
 						type
+						statusChangedAt
 						updatedAt
 					}
 				}
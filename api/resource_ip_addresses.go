@@ -9,9 +9,11 @@ func (c *Client) GetIPAddresses(appName string) ([]IPAddress, error) {
 						id
 						address
 						type
+						region
 						createdAt
 					}
 				}
+				sharedIpAddress
 			}
 		}
 	`
@@ -24,7 +26,12 @@ func (c *Client) GetIPAddresses(appName string) ([]IPAddress, error) {
 		return nil, err
 	}
 
-	return data.App.IPAddresses.Nodes, nil
+	ips := data.App.IPAddresses.Nodes
+	if data.App.SharedIPAddress != "" {
+		ips = append(ips, IPAddress{Address: data.App.SharedIPAddress, Type: IPAddressSharedV4})
+	}
+
+	return ips, nil
 }
 
 func (c *Client) FindIPAddress(appName string, address string) (*IPAddress, error) {
@@ -35,6 +42,7 @@ func (c *Client) FindIPAddress(appName string, address string) (*IPAddress, erro
 					id
 					address
 					type
+					region
 					createdAt
 				}
 			}
@@ -54,7 +62,9 @@ func (c *Client) FindIPAddress(appName string, address string) (*IPAddress, erro
 	return data.App.IPAddress, nil
 }
 
-func (c *Client) AllocateIPAddress(appName string, addrType string) (*IPAddress, error) {
+// AllocateIPAddress - allocates an address of type v4, shared_v4, v6 or
+// private_v6, in a region when one is given
+func (c *Client) AllocateIPAddress(appName string, addrType string, region string) (*IPAddress, error) {
 	query := `
 		mutation($input: AllocateIPAddressInput!) {
 			allocateIpAddress(input: $input) {
@@ -62,25 +72,43 @@ func (c *Client) AllocateIPAddress(appName string, addrType string) (*IPAddress,
 					id
 					address
 					type
+					region
 					createdAt
 				}
+				app {
+					sharedIpAddress
+				}
 			}
 		}
 	`
 
 	req := c.NewRequest(query)
 
-	req.Var("input", AllocateIPAddressInput{AppID: appName, Type: addrType})
+	req.Var("input", AllocateIPAddressInput{AppID: appName, Type: addrType, Region: region})
 
 	data, err := c.Run(req)
 	if err != nil {
 		return nil, err
 	}
 
+	// shared addresses belong to the app rather than being allocated to it
+	if addrType == IPAddressSharedV4 {
+		return &IPAddress{Address: data.AllocateIPAddress.App.SharedIPAddress, Type: IPAddressSharedV4}, nil
+	}
+
 	return &data.AllocateIPAddress.IPAddress, nil
 }
 
 func (c *Client) ReleaseIPAddress(id string) error {
+	return c.releaseIPAddress(ReleaseIPAddressInput{IPAddressID: id})
+}
+
+// ReleaseSharedIPAddress - stops an app using its shared IPv4 address
+func (c *Client) ReleaseSharedIPAddress(appName string, address string) error {
+	return c.releaseIPAddress(ReleaseIPAddressInput{AppID: appName, IP: address})
+}
+
+func (c *Client) releaseIPAddress(input ReleaseIPAddressInput) error {
 	query := `
 		mutation($input: ReleaseIPAddressInput!) {
 			releaseIpAddress(input: $input) {
@@ -91,7 +119,7 @@ func (c *Client) ReleaseIPAddress(id string) error {
 
 	req := c.NewRequest(query)
 
-	req.Var("input", ReleaseIPAddressInput{IPAddressID: id})
+	req.Var("input", input)
 
 	_, err := c.Run(req)
 	if err != nil {
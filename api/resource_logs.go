@@ -35,7 +35,7 @@ func (c *Client) GetAppLogs(appName string, nextToken string, region string, ins
 	if err != nil {
 		return entries, "", err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token()))
 
 	var result getLogsResponse
 
@@ -0,0 +1,277 @@
+package api
+
+import "fmt"
+
+func (client *Client) LaunchMachine(input LaunchMachineInput) (*Machine, error) {
+	query := `
+		mutation($input: LaunchMachineInput!) {
+			launchMachine(input: $input) {
+				machine {
+					id
+					name
+					state
+					region
+					createdAt
+					config
+					ips {
+						nodes {
+							family
+							kind
+							ip
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", input)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.LaunchMachine.Machine, nil
+}
+
+func (client *Client) ListMachines(appName string, state string) ([]Machine, error) {
+	query := `
+		query($appName: String!, $state: String) {
+			app(name: $appName) {
+				machines(state: $state) {
+					nodes {
+						id
+						name
+						state
+						region
+						createdAt
+						config
+						ips {
+							nodes {
+								family
+								kind
+								ip
+							}
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("appName", appName)
+	if state != "" {
+		req.Var("state", state)
+	}
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.Machines.Nodes, nil
+}
+
+func (client *Client) GetMachine(appName string, machineID string) (*Machine, error) {
+	query := `
+		query($appName: String!, $id: String!) {
+			app(name: $appName) {
+				machine(id: $id) {
+					id
+					name
+					state
+					region
+					createdAt
+					config
+					ips {
+						nodes {
+							family
+							kind
+							ip
+						}
+					}
+					checks {
+						name
+						status
+					}
+					exitCode
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("appName", appName)
+	req.Var("id", machineID)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.Machine, nil
+}
+
+func (client *Client) UpdateMachine(input UpdateMachineInput) (*Machine, error) {
+	query := `
+		mutation($input: UpdateMachineInput!) {
+			updateMachine(input: $input) {
+				machine {
+					id
+					name
+					state
+					region
+					createdAt
+					config
+					ips {
+						nodes {
+							family
+							kind
+							ip
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", input)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.UpdateMachine.Machine, nil
+}
+
+func (client *Client) StopMachine(appName string, machineID string) error {
+	query := `
+		mutation($input: StopMachineInput!) {
+			stopMachine(input: $input) {
+				machine {
+					id
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]string{
+		"appId": appName,
+		"id":    machineID,
+	})
+
+	_, err := client.Run(req)
+	return err
+}
+
+func (client *Client) StartMachine(appName string, machineID string) error {
+	query := `
+		mutation($input: StartMachineInput!) {
+			startMachine(input: $input) {
+				machine {
+					id
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]string{
+		"appId": appName,
+		"id":    machineID,
+	})
+
+	_, err := client.Run(req)
+	return err
+}
+
+func (client *Client) RemoveMachine(appName string, machineID string, kill bool) error {
+	query := `
+		mutation($input: RemoveMachineInput!) {
+			removeMachine(input: $input) {
+				machine {
+					id
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"appId": appName,
+		"id":    machineID,
+		"kill":  kill,
+	})
+
+	_, err := client.Run(req)
+	return err
+}
+
+func (client *Client) ExecMachine(appName string, machineID string, cmd []string, timeoutSeconds int) (*MachineExecResult, error) {
+	query := `
+		mutation($input: ExecMachineInput!) {
+			execMachine(input: $input) {
+				exitCode
+				stdout
+				stderr
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"appId":   appName,
+		"id":      machineID,
+		"cmd":     cmd,
+		"timeout": timeoutSeconds,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.ExecMachine, nil
+}
+
+// GetMachineRuns - returns the most recent runs of a machine, newest first
+func (client *Client) GetMachineRuns(appName string, machineID string, limit int) ([]MachineRun, error) {
+	query := `
+		query($appName: String!, $id: String!, $limit: Int!) {
+			app(name: $appName) {
+				machine(id: $id) {
+					runs(last: $limit) {
+						nodes {
+							id
+							status
+							exitCode
+							startedAt
+							finishedAt
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("appName", appName)
+	req.Var("id", machineID)
+	req.Var("limit", limit)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+	if data.App.Machine == nil {
+		return nil, fmt.Errorf("machine %s not found", machineID)
+	}
+
+	return data.App.Machine.Runs.Nodes, nil
+}
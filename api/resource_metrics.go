@@ -0,0 +1,80 @@
+package api
+
+// CreateMetricsToken - mints a read-only token for an organization's metrics endpoint
+func (c *Client) CreateMetricsToken(org *Organization, name string) (*MetricsToken, error) {
+	query := `
+		mutation($input: CreateMetricsTokenInput!) {
+			createMetricsToken(input: $input) {
+				metricsToken {
+					id
+					name
+					token
+					createdAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"organizationId": org.ID,
+		"name":           name,
+	})
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreateMetricsToken.MetricsToken, nil
+}
+
+// DeleteMetricsToken - revokes a metrics token by name
+func (c *Client) DeleteMetricsToken(org *Organization, name string) error {
+	query := `
+		mutation($input: DeleteMetricsTokenInput!) {
+			deleteMetricsToken(input: $input) {
+				organization {
+					id
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"organizationId": org.ID,
+		"name":           name,
+	})
+
+	_, err := c.Run(req)
+
+	return err
+}
+
+// GetMetricsTokens - lists the metrics tokens for an organization. Token values are not returned.
+func (c *Client) GetMetricsTokens(slug string) ([]MetricsToken, error) {
+	query := `
+		query($slug: String!) {
+			organization(slug: $slug) {
+				metricsTokens {
+					nodes {
+						id
+						name
+						createdAt
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("slug", slug)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Organization.MetricsTokens.Nodes, nil
+}
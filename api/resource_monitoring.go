@@ -40,6 +40,7 @@ func (c *Client) GetAppStatus(appName string, showCompleted bool) (*AppStatus, e
 					updatedAt
 					canary
 					region
+					taskName
 					restarts
 					healthy
 					privateIP
@@ -84,6 +85,7 @@ func (c *Client) GetAllocationStatus(appName string, allocID string, logLimit in
 					updatedAt
 					canary
 					region
+					taskName
 					restarts
 					privateIP
 					checks {
@@ -91,11 +93,14 @@ func (c *Client) GetAllocationStatus(appName string, allocID string, logLimit in
 						output
 						name
 						serviceName
+						type
+						statusChangedAt
 					}
 					events {
 						timestamp
 						type
 						message
+						exitCode
 					}
 					recentLogs(limit: $logLimit) {
 						id
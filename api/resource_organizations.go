@@ -109,6 +109,14 @@ func (client *Client) GetOrganizationBySlug(slug string) (*OrganizationDetails,
 					role
 				}
 		  }
+		  invitations {
+				nodes {
+					id
+					email
+					createdAt
+					redeemed
+				}
+		  }
 		}
 	  }
 	`
@@ -177,7 +185,9 @@ func (c *Client) DeleteOrganization(id string) (deletedid string, err error) {
 	return data.DeleteOrganization.DeletedOrganizationId, nil
 }
 
-func (c *Client) CreateOrganizationInvite(id, email string) (*Invitation, error) {
+// CreateOrganizationInvite - invites email to the organization, as a member
+// unless role is given
+func (c *Client) CreateOrganizationInvite(id, email, role string) (*Invitation, error) {
 	query := `
 	mutation($input: CreateOrganizationInvitationInput!){
 		createOrganizationInvitation(input: $input){
@@ -196,10 +206,14 @@ func (c *Client) CreateOrganizationInvite(id, email string) (*Invitation, error)
 
 	req := c.NewRequest(query)
 
-	req.Var("input", map[string]string{
+	input := map[string]string{
 		"organizationId": id,
 		"email":          email,
-	})
+	}
+	if role != "" {
+		input["role"] = role
+	}
+	req.Var("input", input)
 
 	data, err := c.Run(req)
 	if err != nil {
@@ -208,3 +222,67 @@ func (c *Client) CreateOrganizationInvite(id, email string) (*Invitation, error)
 
 	return &data.CreateOrganizationInvitation.Invitation, nil
 }
+
+func (c *Client) DeleteOrganizationInvite(id string) error {
+	query := `
+		mutation($input: DeleteOrganizationInvitationInput!) {
+			deleteOrganizationInvitation(input: $input) {
+				deletedInvitationId
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", map[string]string{
+		"invitationId": id,
+	})
+
+	_, err := c.Run(req)
+	return err
+}
+
+func (c *Client) DeleteOrganizationMembership(orgID, userID string) error {
+	query := `
+		mutation($input: DeleteOrganizationMembershipInput!) {
+			deleteOrganizationMembership(input: $input) {
+				organization {
+					id
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", map[string]string{
+		"organizationId": orgID,
+		"userId":         userID,
+	})
+
+	_, err := c.Run(req)
+	return err
+}
+
+func (c *Client) UpdateOrganizationMembership(orgID, userID, role string) error {
+	query := `
+		mutation($input: UpdateOrganizationMembershipInput!) {
+			updateOrganizationMembership(input: $input) {
+				organization {
+					id
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("input", map[string]string{
+		"organizationId": orgID,
+		"userId":         userID,
+		"role":           role,
+	})
+
+	_, err := c.Run(req)
+	return err
+}
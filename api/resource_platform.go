@@ -8,6 +8,8 @@ func (c *Client) PlatformRegions() ([]Region, *Region, error) {
 				regions {
 					name
 					code
+					latitude
+					longitude
 					gatewayAvailable
 				}
 			}
@@ -60,6 +62,34 @@ func (c *Client) PlatformRegionsAll() ([]Region, error) {
 	return data.Platform.Regions, nil
 }
 
+// PlatformRegionsCapacity - returns regions along with GPU and performance VM availability and capacity hints
+func (c *Client) PlatformRegionsCapacity() ([]Region, error) {
+	query := `
+		query {
+			platform {
+				regions {
+					name
+					code
+					gatewayAvailable
+					gpuAvailable
+					gpuKinds
+					performanceAvailable
+					capacity
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Platform.Regions, nil
+}
+
 func (c *Client) PlatformVMSizes() ([]VMSize, error) {
 	query := `
 		query {
@@ -72,6 +102,8 @@ func (c *Client) PlatformVMSizes() ([]VMSize, error) {
 					memoryIncrementsMb
 					priceMonth
 					priceSecond
+					gpuKind
+					gpuCount
 				}
 			}
 		}
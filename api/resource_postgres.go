@@ -1,5 +1,7 @@
 package api
 
+import "fmt"
+
 func (client *Client) CreatePostgresCluster(input CreatePostgresClusterInput) (*CreatePostgresClusterPayload, error) {
 	query := `
 		mutation($input: CreatePostgresClusterInput!) {
@@ -158,57 +160,123 @@ func (client *Client) ListPostgresUsers(appName string) ([]PostgresClusterUser,
 	return *data.App.PostgresAppRole.Users, nil
 }
 
-// func (client *Client) CreatePostgresDatabase(name string) (*PostgresClusterUser, error) {
-// 	query := `
-// 		mutation($input: CreatePostgresClusterUserInput!) {
-// 			createPostgresClusterUser(input: $input) {
-// 				user {
-// 					username
-// 				}
-// 			}
-// 		}
-// 		`
-
-// 	req := client.NewRequest(query)
-// 	req.Var("input", map[string]interface{}{
-// 		"username":  username,
-// 		"password":  password,
-// 		"superuser": superuser,
-// 	})
-
-// 	data, err := client.Run(req)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return *data.App.PostgresAppRole.Users, nil
-// }
-
-// func (client *Client) CreatePostgresDatabase(database string) (PostgresClusterDatabase, error) {
-// 	query := `
-// 		mutation($appName: String!) {
-// 			app(name: $appName) {
-// 				postgresAppRole: role {
-// 					name
-// 					... on PostgresClusterAppRole {
-// 						users {
-// 							username
-// 							isSuperuser
-// 							databases
-// 						}
-// 					}
-// 				}
-// 			}
-// 		}
-// 		`
-
-// 	req := client.NewRequest(query)
-// 	req.Var("appName", appName)
-
-// 	data, err := client.Run(req)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return *data.App.PostgresAppRole.Users, nil
-// }
+func (client *Client) CreatePostgresDatabase(appName string, databaseName string) (*PostgresClusterDatabase, error) {
+	query := `
+		mutation($input: CreatePostgresClusterDatabaseInput!) {
+			createPostgresClusterDatabase(input: $input) {
+				database {
+					name
+					users
+				}
+			}
+		}
+		`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"appName":      appName,
+		"databaseName": databaseName,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreatePostgresClusterDatabase.Database, nil
+}
+
+func (client *Client) CreatePostgresUser(appName string, username string, password string, superuser bool) (*PostgresClusterUser, error) {
+	query := `
+		mutation($input: CreatePostgresClusterUserInput!) {
+			createPostgresClusterUser(input: $input) {
+				user {
+					username
+					isSuperuser
+					databases
+				}
+			}
+		}
+		`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"appName":   appName,
+		"username":  username,
+		"password":  password,
+		"superuser": superuser,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreatePostgresClusterUser.User, nil
+}
+
+func (client *Client) ListPostgresMembers(appName string) ([]PostgresClusterMember, error) {
+	query := `
+		query($appName: String!) {
+			app(name: $appName) {
+				postgresAppRole: role {
+					name
+					... on PostgresClusterAppRole {
+						members {
+							id
+							idShort
+							region
+							role
+							healthy
+							replicationLag
+						}
+					}
+				}
+			}
+		}
+		`
+
+	req := client.NewRequest(query)
+	req.Var("appName", appName)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.App.PostgresAppRole == nil || data.App.PostgresAppRole.Members == nil {
+		return nil, fmt.Errorf("%s is not a postgres cluster", appName)
+	}
+
+	return *data.App.PostgresAppRole.Members, nil
+}
+
+func (client *Client) FailoverPostgresCluster(appName string, allocationID string) (*PostgresClusterMember, error) {
+	query := `
+		mutation($input: FailoverPostgresClusterInput!) {
+			failoverPostgresCluster(input: $input) {
+				leader {
+					id
+					idShort
+					region
+					role
+					healthy
+					replicationLag
+				}
+			}
+		}
+		`
+
+	req := client.NewRequest(query)
+	req.Var("input", map[string]string{
+		"appName":      appName,
+		"allocationId": allocationID,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.FailoverPostgresCluster.Leader, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+func (client *Client) GetRedisDatabases() ([]RedisDatabase, error) {
+	query := `
+		query {
+			redisDatabases {
+				nodes {
+					id
+					name
+					region
+					status
+					createdAt
+					organization {
+						slug
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.RedisDatabases.Nodes, nil
+}
+
+func (client *Client) GetRedisDatabase(name string) (*RedisDatabase, error) {
+	query := `
+		query($name: String!) {
+			redisDatabase(name: $name) {
+				id
+				name
+				region
+				status
+				privateUrl
+				publicUrl
+				createdAt
+				organization {
+					id
+					slug
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("name", name)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.RedisDatabase, nil
+}
+
+func (client *Client) CreateRedisDatabase(input CreateRedisDatabaseInput) (*RedisDatabase, error) {
+	query := `
+		mutation($input: CreateRedisDatabaseInput!) {
+			createRedisDatabase(input: $input) {
+				redisDatabase {
+					id
+					name
+					region
+					status
+					privateUrl
+					publicUrl
+					createdAt
+					organization {
+						id
+						slug
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", input)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreateRedisDatabase.RedisDatabase, nil
+}
@@ -1,10 +1,17 @@
 package api
 
 func (c *Client) GetAppReleases(appName string, limit int) ([]Release, error) {
+	releases, _, err := c.GetAppReleasesPage(appName, limit, nil)
+	return releases, err
+}
+
+// GetAppReleasesPage returns up to limit releases, newest first, starting
+// after the cursor of a previous page when after is set
+func (c *Client) GetAppReleasesPage(appName string, limit int, after *string) ([]Release, *PageInfo, error) {
 	query := `
-		query ($appName: String!, $limit: Int!) {
+		query ($appName: String!, $limit: Int!, $after: String) {
 			app(name: $appName) {
-				releases(first: $limit) {
+				releases(first: $limit, after: $after) {
 					nodes {
 						id
 						version
@@ -13,6 +20,9 @@ func (c *Client) GetAppReleases(appName string, limit int) ([]Release, error) {
 						reason
 						status
 						stable
+						imageRef
+						commitSha
+						annotations
 						user {
 							id
 							email
@@ -20,6 +30,10 @@ func (c *Client) GetAppReleases(appName string, limit int) ([]Release, error) {
 						}	
 						createdAt
 					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
 				}
 			}
 		}
@@ -29,11 +43,88 @@ func (c *Client) GetAppReleases(appName string, limit int) ([]Release, error) {
 
 	req.Var("appName", appName)
 	req.Var("limit", limit)
+	req.Var("after", after)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data.App.Releases.Nodes, &data.App.Releases.PageInfo, nil
+}
+
+// GetAppRelease returns the release with version, including its config and
+// the names of the secrets it was deployed with
+func (c *Client) GetAppRelease(appName string, version int) (*Release, error) {
+	query := `
+		query ($appName: String!, $version: Int!) {
+			app(name: $appName) {
+				release(version: $version) {
+					id
+					version
+					reason
+					description
+					status
+					stable
+					imageRef
+					commitSha
+					annotations
+					config {
+						definition
+					}
+					secrets {
+						name
+					}
+					user {
+						id
+						email
+						name
+					}
+					createdAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("appName", appName)
+	req.Var("version", version)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.Release, nil
+}
+
+func (c *Client) GetAppCurrentRelease(appName string) (*Release, error) {
+	query := `
+		query ($appName: String!) {
+			app(name: $appName) {
+				currentRelease {
+					id
+					version
+					status
+					stable
+					imageRef
+					commitSha
+					annotations
+					createdAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("appName", appName)
 
 	data, err := c.Run(req)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.App.Releases.Nodes, nil
+	return data.App.CurrentRelease, nil
 }
@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 func (client *Client) EnsureRemoteBuilderForApp(appName string) (string, *App, error) {
 	query := `
 		mutation($input: EnsureRemoteBuilderInput!) {
@@ -51,3 +53,117 @@ func (client *Client) EnsureRemoteBuilderForOrg(orgID string) (string, *App, err
 
 	return data.EnsureRemoteBuilder.URL, data.EnsureRemoteBuilder.App, nil
 }
+
+// GetPinnedRemoteBuilder - the builder app an organization's builds are
+// pinned to, or nil when builds use the builder ensureRemoteBuilder picks
+func (client *Client) GetPinnedRemoteBuilder(slug string) (*App, error) {
+	query := `
+		query($slug: String!) {
+			organization(slug: $slug) {
+				remoteBuilderApp {
+					name
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("slug", slug)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Organization.RemoteBuilderApp, nil
+}
+
+// PinRemoteBuilder pins an organization's builds to the builder app named
+// appName, or unpins them when appName is nil
+func (client *Client) PinRemoteBuilder(orgID string, appName *string) (*Organization, error) {
+	query := `
+		mutation($input: PinRemoteBuilderInput!) {
+			pinRemoteBuilder(input: $input) {
+				organization {
+					id
+					slug
+					remoteBuilderApp {
+						name
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", PinRemoteBuilderInput{
+		OrganizationID: orgID,
+		AppName:        appName,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.PinRemoteBuilder.Organization, nil
+}
+
+// KeepRemoteBuilderWarm keeps the builder app named appName from scaling to
+// zero until until, or lets it scale to zero again when until is nil
+func (client *Client) KeepRemoteBuilderWarm(appName string, until *time.Time) (*RemoteBuilderKeepWarm, error) {
+	query := `
+		mutation($input: KeepRemoteBuilderWarmInput!) {
+			keepRemoteBuilderWarm(input: $input) {
+				app {
+					name
+				}
+				warmUntil
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", KeepRemoteBuilderWarmInput{
+		AppName: appName,
+		Until:   until,
+	})
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.KeepRemoteBuilderWarm, nil
+}
+
+// GetRemoteBuilderStats - daily build activity on an organization's remote builders since the given time
+func (client *Client) GetRemoteBuilderStats(slug string, since time.Time) ([]RemoteBuilderDailyStats, error) {
+	query := `
+		query($slug: String!, $since: ISO8601DateTime!) {
+			organization(slug: $slug) {
+				remoteBuilderStats(since: $since) {
+					nodes {
+						date
+						builds
+						failedBuilds
+						averageDurationSeconds
+						cacheHitRate
+						averageQueueSeconds
+					}
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("slug", slug)
+	req.Var("since", since.UTC().Format(time.RFC3339))
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Organization.RemoteBuilderStats.Nodes, nil
+}
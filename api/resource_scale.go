@@ -39,6 +39,8 @@ func (c *Client) UpdateAutoscaleConfig(input UpdateAutoscaleConfigInput) (*Autos
 						minCount
 						maxCount
 						balanceRegions
+						targetConcurrency
+						concurrencyType
 						regions {
 							code
 							minCount
@@ -71,6 +73,8 @@ func (c *Client) AppAutoscalingConfig(appName string) (*AutoscalingConfig, error
 					minCount
 					maxCount
 					balanceRegions
+					targetConcurrency
+					concurrencyType
 					regions {
 						code
 						minCount
@@ -104,6 +108,8 @@ func (c *Client) AppVMResources(appName string) (VMSize, []TaskGroupCount, error
 					memoryMb
 					priceMonth
 					priceSecond
+					gpuKind
+					gpuCount
 				}
 				taskGroupCounts {
 					name
@@ -125,7 +131,7 @@ func (c *Client) AppVMResources(appName string) (VMSize, []TaskGroupCount, error
 	return data.App.VMSize, data.App.TaskGroupCounts, nil
 }
 
-func (c *Client) SetAppVMSize(appID string, sizeName string, memoryMb int64) (VMSize, error) {
+func (c *Client) SetAppVMSize(input SetVMSizeInput) (VMSize, error) {
 	query := `
 		mutation ($input: SetVMSizeInput!) {
 			setVmSize(input: $input) {
@@ -136,6 +142,8 @@ func (c *Client) SetAppVMSize(appID string, sizeName string, memoryMb int64) (VM
 					memoryMb
 					priceMonth
 					priceSecond
+					gpuKind
+					gpuCount
 				}
 			}
 		}
@@ -143,7 +151,7 @@ func (c *Client) SetAppVMSize(appID string, sizeName string, memoryMb int64) (VM
 
 	req := c.NewRequest(query)
 
-	req.Var("input", SetVMSizeInput{AppID: appID, SizeName: sizeName, MemoryMb: memoryMb})
+	req.Var("input", input)
 
 	data, err := c.Run(req)
 	if err != nil {
@@ -153,6 +161,42 @@ func (c *Client) SetAppVMSize(appID string, sizeName string, memoryMb int64) (VM
 	return *data.SetVMSize.VMSize, nil
 }
 
+// AppProcessGroups - returns each of the app's process groups with its count and VM size
+func (c *Client) AppProcessGroups(appName string) ([]ProcessGroup, error) {
+	query := `
+		query($appName: String!) {
+			app(name: $appName) {
+				processGroups {
+					name
+					command
+					count
+					vmSize {
+						name
+						cpuCores
+						memoryGb
+						memoryMb
+						priceMonth
+						priceSecond
+						gpuKind
+						gpuCount
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("appName", appName)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.ProcessGroups, nil
+}
+
 func (c *Client) GetAppVMCount(appID string) ([]TaskGroupCount, error) {
 	query := `
 		query ($appName: String!) {
@@ -180,6 +224,13 @@ func (c *Client) GetAppVMCount(appID string) ([]TaskGroupCount, error) {
 }
 
 func (c *Client) SetAppVMCount(appID string, count int) ([]TaskGroupCount, []string, error) {
+	return c.SetAppVMGroupCounts(appID, []VMCountInput{
+		{Group: "app", Count: count},
+	})
+}
+
+// SetAppVMGroupCounts - sets the VM count of each of the app's process groups
+func (c *Client) SetAppVMGroupCounts(appID string, counts []VMCountInput) ([]TaskGroupCount, []string, error) {
 	query := `
 		mutation ($input: SetVMCountInput!) {
 			setVmCount(input: $input) {
@@ -195,10 +246,9 @@ func (c *Client) SetAppVMCount(appID string, count int) ([]TaskGroupCount, []str
 	req := c.NewRequest(query)
 
 	req.Var("input", SetVMCountInput{
-		AppID: appID,
-		GroupCounts: []VMCountInput{
-			{Group: "app", Count: count},
-		}})
+		AppID:       appID,
+		GroupCounts: counts,
+	})
 
 	data, err := c.Run(req)
 	if err != nil {
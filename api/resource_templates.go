@@ -0,0 +1,85 @@
+package api
+
+func (client *Client) SearchAppTemplates(search string) ([]AppTemplate, error) {
+	query := `
+		query($search: String) {
+			appTemplates(search: $search, first: 50) {
+				nodes {
+					id
+					name
+					description
+					author
+					version
+					downloads
+					updatedAt
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	if search != "" {
+		req.Var("search", search)
+	}
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.AppTemplates.Nodes, nil
+}
+
+func (client *Client) GetAppTemplate(name string) (*AppTemplate, error) {
+	query := `
+		query($name: String!) {
+			appTemplate(name: $name) {
+				id
+				name
+				description
+				author
+				version
+				downloads
+				updatedAt
+				files {
+					path
+					content
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("name", name)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.AppTemplate, nil
+}
+
+func (client *Client) PublishAppTemplate(input PublishAppTemplateInput) (*AppTemplate, error) {
+	query := `
+		mutation($input: PublishAppTemplateInput!) {
+			publishAppTemplate(input: $input) {
+				appTemplate {
+					id
+					name
+					version
+				}
+			}
+		}
+	`
+
+	req := client.NewRequest(query)
+	req.Var("input", input)
+
+	data, err := client.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.PublishAppTemplate.AppTemplate, nil
+}
@@ -0,0 +1,83 @@
+package api
+
+// CreateAccessToken - mints a scoped token. The token value is only returned here.
+func (c *Client) CreateAccessToken(input CreateAccessTokenInput) (*AccessToken, error) {
+	query := `
+		mutation($input: CreateAccessTokenInput!) {
+			createAccessToken(input: $input) {
+				accessToken {
+					id
+					name
+					type
+					appName
+					token
+					createdAt
+					expiresAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("input", input)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreateAccessToken.AccessToken, nil
+}
+
+// GetAccessTokens - lists the unexpired scoped tokens of an organization. Token values are not returned.
+func (c *Client) GetAccessTokens(slug string) ([]AccessToken, error) {
+	query := `
+		query($slug: String!) {
+			organization(slug: $slug) {
+				accessTokens {
+					nodes {
+						id
+						name
+						type
+						appName
+						createdAt
+						expiresAt
+						lastUsedAt
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("slug", slug)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Organization.AccessTokens.Nodes, nil
+}
+
+// DeleteAccessToken - revokes a scoped token
+func (c *Client) DeleteAccessToken(id string) error {
+	query := `
+		mutation($input: DeleteAccessTokenInput!) {
+			deleteAccessToken(input: $input) {
+				organization {
+					id
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("input", map[string]interface{}{
+		"accessTokenId": id,
+	})
+
+	_, err := c.Run(req)
+
+	return err
+}
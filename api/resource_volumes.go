@@ -13,6 +13,7 @@ func (c *Client) GetVolumes(appName string) ([]Volume, error) {
 					encrypted
 					createdAt
 					attachedAllocation {
+						id
 						idShort
 					}
 				}
@@ -33,7 +34,7 @@ func (c *Client) GetVolumes(appName string) ([]Volume, error) {
 	return data.App.Volumes.Nodes, nil
 }
 
-func (c *Client) CreateVolume(appName string, volname string, region string, sizeGb int, encrypted bool) (*Volume, error) {
+func (c *Client) CreateVolume(appName string, volname string, region string, sizeGb int, encrypted bool, snapshotID *string) (*Volume, error) {
 	query := `
 		mutation($input: CreateVolumeInput!) {
 			createVolume(input: $input) {
@@ -52,7 +53,7 @@ func (c *Client) CreateVolume(appName string, volname string, region string, siz
 		}
 	`
 
-	input := CreateVolumeInput{AppID: appName, Name: volname, Region: region, SizeGb: sizeGb, Encrypted: encrypted}
+	input := CreateVolumeInput{AppID: appName, Name: volname, Region: region, SizeGb: sizeGb, Encrypted: encrypted, SnapshotID: snapshotID}
 
 	req := c.NewRequest(query)
 
@@ -102,6 +103,10 @@ func (c *Client) GetVolume(volID string) (Volume *Volume, err error) {
 				region
 				encrypted
 				createdAt
+				attachedAllocation {
+					id
+					idShort
+				}
 			}
 		}
 	}`
@@ -117,3 +122,85 @@ func (c *Client) GetVolume(volID string) (Volume *Volume, err error) {
 
 	return &data.Volume, nil
 }
+
+func (c *Client) GetVolumeSnapshots(volID string) ([]VolumeSnapshot, error) {
+	query := `
+	query($id: ID!) {
+		volume: node(id: $id) {
+			... on Volume {
+				snapshots {
+					nodes {
+						id
+						size
+						digest
+						createdAt
+					}
+				}
+			}
+		}
+	}`
+
+	req := c.NewRequest(query)
+
+	req.Var("id", volID)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Volume.Snapshots.Nodes, nil
+}
+
+func (c *Client) CreateVolumeSnapshot(volID string) error {
+	query := `
+		mutation($input: CreateVolumeSnapshotInput!) {
+			createVolumeSnapshot(input: $input) {
+				volume {
+					id
+				}
+			}
+		}
+	`
+
+	input := CreateVolumeSnapshotInput{VolumeID: volID}
+
+	req := c.NewRequest(query)
+
+	req.Var("input", input)
+
+	_, err := c.Run(req)
+
+	return err
+}
+
+func (c *Client) ExtendVolume(volID string, sizeGb int) (*Volume, bool, error) {
+	query := `
+		mutation($input: ExtendVolumeInput!) {
+			extendVolume(input: $input) {
+				volume {
+					id
+					name
+					region
+					sizeGb
+					encrypted
+					createdAt
+				}
+				needsRestart
+			}
+		}
+	`
+
+	input := ExtendVolumeInput{VolumeID: volID, SizeGb: sizeGb}
+
+	req := c.NewRequest(query)
+
+	req.Var("input", input)
+
+	data, err := c.Run(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &data.ExtendVolume.Volume, data.ExtendVolume.NeedsRestart, nil
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	traceWriter io.Writer
+	traceMu     sync.Mutex
+)
+
+// SetTraceWriter - Sets where API requests are traced to, nil to stop tracing
+func SetTraceWriter(w io.Writer) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	traceWriter = w
+}
+
+func tracing() bool {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	return traceWriter != nil
+}
+
+func trace(format string, v ...interface{}) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if traceWriter != nil {
+		fmt.Fprintf(traceWriter, "%s %s", time.Now().Format("15:04:05.000"), fmt.Sprintf(format, v...))
+	}
+}
+
+var contextKeyAttempts = &contextKey{"Attempts"}
+
+// TracingTransport - logs each request's GraphQL operation, sanitized
+// variables, timing, attempts and response size while tracing is on
+type TracingTransport struct {
+	innerTransport http.RoundTripper
+}
+
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !tracing() {
+		return t.innerTransport.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	attempts := 0
+	req = req.WithContext(context.WithValue(req.Context(), contextKeyAttempts, &attempts))
+
+	operation, query, variables := describeRequest(req, body)
+	request := fmt.Sprintf("--> %s\n", operation)
+	if query != "" {
+		request += fmt.Sprintf("    query: %s\n", query)
+	}
+	if variables != "" {
+		request += fmt.Sprintf("    variables: %s\n", variables)
+	}
+	trace("%s", request)
+
+	start := time.Now()
+	resp, err := t.innerTransport.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		trace("<-- %s failed after %s, %d attempts: %v\n", operation, elapsed, attempts, err)
+		return resp, err
+	}
+
+	// read the body so its size and the time to receive it are included
+	data, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	elapsed = time.Since(start).Round(time.Millisecond)
+
+	if readErr != nil {
+		trace("<-- %s %d after %s, %d attempts, reading body failed: %v\n", operation, resp.StatusCode, elapsed, attempts, readErr)
+		return resp, readErr
+	}
+
+	trace("<-- %s %d in %s, %d attempts, %d bytes\n", operation, resp.StatusCode, elapsed, attempts, len(data))
+
+	return resp, nil
+}
+
+// attemptTransport counts the attempts the retry transport makes
+type attemptTransport struct {
+	innerTransport http.RoundTripper
+}
+
+func (t *attemptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if attempts, ok := req.Context().Value(contextKeyAttempts).(*int); ok {
+		*attempts++
+	}
+	return t.innerTransport.RoundTrip(req)
+}
+
+var operationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)?\s*(\w*)[^{]*\{\s*(\w+)`)
+
+// describeRequest - the GraphQL operation, like "mutation deployImage", its
+// query, and its variables with secrets redacted. Other requests are described
+// by their method and URL.
+func describeRequest(req *http.Request, body []byte) (operation string, query string, variables string) {
+	var gql struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &gql); err != nil || gql.Query == "" {
+		return fmt.Sprintf("%s %s", req.Method, req.URL), "", ""
+	}
+
+	operation = "query"
+	if m := operationPattern.FindStringSubmatch(gql.Query); m != nil {
+		if m[1] != "" {
+			operation = m[1]
+		}
+		operation += " " + m[3]
+	}
+
+	if len(gql.Variables) > 0 {
+		data, _ := json.Marshal(redactVariables(gql.Variables))
+		variables = string(data)
+	}
+
+	return operation, compactQueryString(gql.Query), variables
+}
+
+var sensitiveVariable = regexp.MustCompile(`(?i)(token|password|passphrase|private|^secret$|^value$|^values$|^otp$)`)
+
+func redactVariables(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if sensitiveVariable.MatchString(key) && value != nil {
+				out[key] = "[REDACTED]"
+			} else {
+				out[key] = redactVariables(value)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = redactVariables(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
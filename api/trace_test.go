@@ -0,0 +1,48 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"setSecrets":{"release":{"id":"r1"}}}}`))
+	}))
+	defer server.Close()
+
+	defer SetBaseURL(baseURL)
+	SetBaseURL(server.URL)
+
+	var out bytes.Buffer
+	SetTraceWriter(&out)
+	defer SetTraceWriter(nil)
+
+	client := NewClient("token", "test")
+	req := client.NewRequest(`
+		mutation($input: SetSecretsInput!) {
+			setSecrets(input: $input) { release { id } }
+		}
+	`)
+	req.Var("input", map[string]interface{}{
+		"appId":   "web",
+		"secrets": []map[string]string{{"key": "DATABASE_URL", "value": "postgres://u:p@h/db"}},
+	})
+
+	_, err := client.Run(req)
+	require.NoError(t, err)
+
+	trace := out.String()
+	assert.Contains(t, trace, "--> mutation setSecrets\n")
+	assert.Contains(t, trace, "query: mutation($input: SetSecretsInput!) { setSecrets(input: $input) { release { id } } }")
+	assert.Contains(t, trace, `"key":"DATABASE_URL","value":"[REDACTED]"`)
+	assert.NotContains(t, trace, "postgres://")
+	assert.Contains(t, trace, "<-- mutation setSecrets 200 in ")
+	assert.True(t, strings.HasSuffix(trace, "1 attempts, 47 bytes\n"), trace)
+}
@@ -15,6 +15,7 @@ type Query struct {
 	AppCompact           AppCompact
 	AppStatus            AppStatus
 	AppCertsCompact      AppCertsCompact
+	AppNameAvailable     bool
 	CurrentUser          User
 	PersonalOrganization Organization
 	Organizations        struct {
@@ -71,6 +72,12 @@ type Query struct {
 		Release Release
 	}
 
+	PinRemoteBuilder struct {
+		Organization Organization
+	}
+
+	KeepRemoteBuilderWarm RemoteBuilderKeepWarm
+
 	CreateSignedUrl SignedUrls
 
 	StartBuild struct {
@@ -153,11 +160,25 @@ type Query struct {
 		Warnings []ImportDnsWarning
 		Changes  []ImportDnsChange
 	}
+
+	CreateDnsRecord struct {
+		Record DNSRecord
+	}
+
+	UpdateDnsRecord struct {
+		Record DNSRecord
+	}
+
+	DeleteDnsRecord struct {
+		Domain Domain
+	}
 	CreateOrganization CreateOrganizationPayload
 	DeleteOrganization DeleteOrganizationPayload
 
-	CreateVolume CreateVolumePayload
-	DeleteVolume DeleteVolumePayload
+	CreateVolume         CreateVolumePayload
+	DeleteVolume         DeleteVolumePayload
+	CreateVolumeSnapshot CreateVolumeSnapshotPayload
+	ExtendVolume         ExtendVolumePayload
 
 	AddWireGuardPeer              CreatedWireGuardPeer
 	EstablishSSHKey               SSHCertificate
@@ -165,6 +186,14 @@ type Query struct {
 	CreateDelegatedWireGuardToken DelegatedWireGuardToken
 	DeleteDelegatedWireGuardToken DelegatedWireGuardToken
 
+	CreateMetricsToken struct {
+		MetricsToken MetricsToken
+	}
+
+	CreateAccessToken struct {
+		AccessToken AccessToken
+	}
+
 	RemoveWireGuardPeer struct {
 		Organization Organization
 	}
@@ -177,10 +206,50 @@ type Query struct {
 		Handler *HealthCheckHandler
 	}
 
+	CreateAlertRule *struct {
+		AlertRule *AlertRule
+	}
+
 	CreatePostgresCluster *CreatePostgresClusterPayload
 
 	AttachPostgresCluster *AttachPostgresClusterPayload
 
+	AppTemplates struct {
+		Nodes []AppTemplate
+	}
+	AppTemplate        *AppTemplate
+	PublishAppTemplate struct {
+		AppTemplate AppTemplate
+	}
+
+	CreatePostgresClusterDatabase struct {
+		Database PostgresClusterDatabase
+	}
+
+	LaunchMachine struct {
+		Machine Machine
+	}
+	UpdateMachine struct {
+		Machine Machine
+	}
+	ExecMachine *MachineExecResult
+
+	RedisDatabases struct {
+		Nodes []RedisDatabase
+	}
+	RedisDatabase       *RedisDatabase
+	CreateRedisDatabase struct {
+		RedisDatabase RedisDatabase
+	}
+
+	CreatePostgresClusterUser struct {
+		User PostgresClusterUser
+	}
+
+	FailoverPostgresCluster struct {
+		Leader PostgresClusterMember
+	}
+
 	CreateOrganizationInvitation CreateOrganizationInvitation
 }
 
@@ -194,6 +263,43 @@ type DelegatedWireGuardToken struct {
 	Token string
 }
 
+type MetricsToken struct {
+	ID        string
+	Name      string
+	Token     string
+	CreatedAt time.Time
+}
+
+// Access token types, from the narrowest scope to the widest
+const (
+	AccessTokenTypeDeploy   = "deploy"
+	AccessTokenTypeReadOnly = "read_only"
+	AccessTokenTypeBuilder  = "builder"
+)
+
+// AccessToken - a scoped token that can be used in place of a personal
+// access token, for example by CI
+type AccessToken struct {
+	ID   string
+	Name string
+	Type string
+	// AppName - the app a deploy token is limited to
+	AppName    string
+	Token      string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+type CreateAccessTokenInput struct {
+	OrganizationID string `json:"organizationId"`
+	AppID          string `json:"appId,omitempty"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	// ExpiresIn - seconds until the token expires
+	ExpiresIn int `json:"expiresIn"`
+}
+
 type DelegatedWireGuardTokenHandle /* whatever */ struct {
 	Name string
 }
@@ -228,18 +334,23 @@ type App struct {
 	Secrets        []Secret
 	CurrentRelease *Release
 	Releases       struct {
-		Nodes []Release
+		Nodes    []Release
+		PageInfo PageInfo
 	}
 	IPAddresses struct {
 		Nodes []IPAddress
 	}
-	IPAddress *IPAddress
-	Builds    struct {
+	IPAddress       *IPAddress
+	SharedIPAddress string
+	Builds          struct {
 		Nodes []Build
 	}
 	Changes struct {
 		Nodes []AppChange
 	}
+	AlertRules struct {
+		Nodes []AlertRule
+	}
 	Certificates struct {
 		Nodes []AppCertificate
 	}
@@ -256,22 +367,47 @@ type App struct {
 	Volumes          struct {
 		Nodes []Volume
 	}
+	Machines struct {
+		Nodes []Machine
+	}
+	Machine         *Machine
 	TaskGroupCounts []TaskGroupCount
+	ProcessGroups   []ProcessGroup
 	HealthChecks    *struct {
 		Nodes []CheckState
 	}
 	PostgresAppRole *struct {
 		Databases *[]PostgresClusterDatabase
 		Users     *[]PostgresClusterUser
+		Members   *[]PostgresClusterMember
 	}
 	Image *Image
 }
 
+// AppInventory - the resources destroyed along with an app
+type AppInventory struct {
+	Volumes      []Volume
+	IPAddresses  []IPAddress
+	Certificates []AppCertificate
+	// Databases - the databases in the app, when it's a Postgres cluster
+	Databases []PostgresClusterDatabase
+	// DatabaseSecrets - secrets connecting the app to attached databases,
+	// which aren't destroyed with it
+	DatabaseSecrets []string
+}
+
 type TaskGroupCount struct {
 	Name  string
 	Count int
 }
 
+type ProcessGroup struct {
+	Name    string
+	Command string
+	Count   int
+	VMSize  VMSize
+}
+
 type Volume struct {
 	ID                 string `json:"id"`
 	App                string
@@ -281,14 +417,25 @@ type Volume struct {
 	Encrypted          bool
 	CreatedAt          time.Time
 	AttachedAllocation *AllocationStatus
+	Snapshots          struct {
+		Nodes []VolumeSnapshot
+	}
+}
+
+type VolumeSnapshot struct {
+	ID        string `json:"id"`
+	Size      string
+	Digest    string
+	CreatedAt time.Time
 }
 
 type CreateVolumeInput struct {
-	AppID     string `json:"appId"`
-	Name      string `json:"name"`
-	Region    string `json:"region"`
-	SizeGb    int    `json:"sizeGb"`
-	Encrypted bool   `json:"encrypted"`
+	AppID      string  `json:"appId"`
+	Name       string  `json:"name"`
+	Region     string  `json:"region"`
+	SizeGb     int     `json:"sizeGb"`
+	Encrypted  bool    `json:"encrypted"`
+	SnapshotID *string `json:"snapshotId,omitempty"`
 }
 
 type CreateVolumePayload struct {
@@ -296,6 +443,24 @@ type CreateVolumePayload struct {
 	Volume Volume
 }
 
+type ExtendVolumeInput struct {
+	VolumeID string `json:"volumeId"`
+	SizeGb   int    `json:"sizeGb"`
+}
+
+type ExtendVolumePayload struct {
+	Volume       Volume
+	NeedsRestart bool
+}
+
+type CreateVolumeSnapshotInput struct {
+	VolumeID string `json:"volumeId"`
+}
+
+type CreateVolumeSnapshotPayload struct {
+	Volume Volume
+}
+
 type DeleteVolumeInput struct {
 	VolumeID string `json:"volumeId"`
 }
@@ -382,6 +547,24 @@ type Organization struct {
 		}
 	}
 
+	MetricsTokens struct {
+		Nodes []MetricsToken
+	}
+
+	Apps struct {
+		Nodes []App
+	}
+
+	AccessTokens struct {
+		Nodes []AccessToken
+	}
+
+	RemoteBuilderStats struct {
+		Nodes []RemoteBuilderDailyStats
+	}
+
+	RemoteBuilderApp *App
+
 	HealthCheckHandlers *struct {
 		Nodes []HealthCheckHandler
 	}
@@ -393,6 +576,11 @@ type Organization struct {
 	LoggedCertificates *struct {
 		Nodes []LoggedCertificate
 	}
+
+	AuditLogs struct {
+		Nodes    []AuditLogEntry
+		PageInfo PageInfo
+	}
 }
 
 type OrganizationDetails struct {
@@ -407,6 +595,9 @@ type OrganizationDetails struct {
 	Members struct {
 		Edges []OrganizationMembershipEdge
 	}
+	Invitations struct {
+		Nodes []Invitation
+	}
 }
 
 type OrganizationMembershipEdge struct {
@@ -442,9 +633,13 @@ type IPAddress struct {
 	ID        string
 	Address   string
 	Type      string
+	Region    string
 	CreatedAt time.Time
 }
 
+// IPAddressSharedV4 - the type of an IPv4 address shared with other apps
+const IPAddressSharedV4 = "shared_v4"
+
 type User struct {
 	ID    string
 	Name  string
@@ -521,8 +716,20 @@ type Release struct {
 	Description        string
 	Status             string
 	DeploymentStrategy string
-	User               User
-	CreatedAt          time.Time
+	ImageRef           string
+	CommitSHA          string
+	Annotations        map[string]string
+	// Config and Secrets are only fetched for a single release
+	Config    *AppConfig `json:",omitempty"`
+	Secrets   []Secret   `json:",omitempty"`
+	User      User
+	CreatedAt time.Time
+}
+
+// PageInfo - where a page of a paginated list ends
+type PageInfo struct {
+	HasNextPage bool
+	EndCursor   string
 }
 
 type Build struct {
@@ -541,6 +748,36 @@ type SignedUrls struct {
 	PutUrl string
 }
 
+// AuditLogEntry - an action taken in an organization, such as a deploy or a
+// change to secrets, scaling or members
+type AuditLogEntry struct {
+	ID string
+	// Type - the kind of action, e.g. deploy, secrets, scale or members
+	Type string
+	// Action - what was done, e.g. secrets.set
+	Action      string
+	Description string
+	AppName     string
+	Actor       AuditLogActor
+	IPAddress   string
+	Metadata    map[string]interface{}
+	CreatedAt   time.Time
+}
+
+// AuditLogActor - who took an audited action, a user or a token
+type AuditLogActor struct {
+	Type  string
+	Name  string
+	Email string
+}
+
+// AuditLogFilter - which entries of an organization's audit log to list
+type AuditLogFilter struct {
+	Since *time.Time
+	Until *time.Time
+	Types []string
+}
+
 type AppChange struct {
 	ID        string
 	CreatedAt time.Time
@@ -622,6 +859,10 @@ type DeployImageInput struct {
 	Services   *[]Service  `json:"services"`
 	Definition *Definition `json:"definition"`
 	Strategy   *string     `json:"strategy"`
+	// CommitSHA - the git commit the release was deployed from
+	CommitSHA *string `json:"commitSha,omitempty"`
+	// Annotations - metadata recorded on the release
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 type Service struct {
@@ -656,12 +897,15 @@ type HTTPHeader struct {
 }
 
 type AllocateIPAddressInput struct {
-	AppID string `json:"appId"`
-	Type  string `json:"type"`
+	AppID  string `json:"appId"`
+	Type   string `json:"type"`
+	Region string `json:"region,omitempty"`
 }
 
 type ReleaseIPAddressInput struct {
-	IPAddressID string `json:"ipAddressId"`
+	IPAddressID string `json:"ipAddressId,omitempty"`
+	AppID       string `json:"appId,omitempty"`
+	IP          string `json:"ip,omitempty"`
 }
 
 type ScaleAppInput struct {
@@ -690,6 +934,7 @@ type AllocationStatus struct {
 	IDShort            string
 	Version            int
 	Region             string
+	TaskName           string
 	Status             string
 	DesiredStatus      string
 	Healthy            bool
@@ -716,32 +961,43 @@ type AllocationEvent struct {
 	Timestamp time.Time
 	Type      string
 	Message   string
+	// ExitCode - exit code of the task's process, for events of it exiting
+	ExitCode *int
 }
 
 type CheckState struct {
-	Name        string
-	Status      string
-	Output      string
-	ServiceName string
-	Allocation  *AllocationStatus
-	Type        string
-	UpdatedAt   time.Time
+	Name            string
+	Status          string
+	Output          string
+	ServiceName     string
+	Allocation      *AllocationStatus
+	Type            string
+	StatusChangedAt time.Time
+	UpdatedAt       time.Time
 }
 
 type Region struct {
-	Code             string
-	Name             string
-	Latitude         float32
-	Longitude        float32
-	GatewayAvailable bool
+	Code                 string
+	Name                 string
+	Latitude             float32
+	Longitude            float32
+	GatewayAvailable     bool
+	GpuAvailable         bool
+	PerformanceAvailable bool
+	// GPUKinds - the GPU models that can be attached to VMs in the region
+	GPUKinds []string
+	// Capacity - soft hint of how much capacity the region has for new VMs: high, medium or low
+	Capacity string
 }
 
 type AutoscalingConfig struct {
-	BalanceRegions bool
-	Enabled        bool
-	MaxCount       int
-	MinCount       int
-	Regions        []AutoscalingRegionConfig
+	BalanceRegions    bool
+	Enabled           bool
+	MaxCount          int
+	MinCount          int
+	TargetConcurrency int
+	ConcurrencyType   string
+	Regions           []AutoscalingRegionConfig
 }
 
 type AutoscalingRegionConfig struct {
@@ -751,13 +1007,15 @@ type AutoscalingRegionConfig struct {
 }
 
 type UpdateAutoscaleConfigInput struct {
-	AppID          string                       `json:"appId"`
-	Enabled        *bool                        `json:"enabled"`
-	MinCount       *int                         `json:"minCount"`
-	MaxCount       *int                         `json:"maxCount"`
-	BalanceRegions *bool                        `json:"balanceRegions"`
-	ResetRegions   *bool                        `json:"resetRegions"`
-	Regions        []AutoscaleRegionConfigInput `json:"regions"`
+	AppID             string                       `json:"appId"`
+	Enabled           *bool                        `json:"enabled"`
+	MinCount          *int                         `json:"minCount"`
+	MaxCount          *int                         `json:"maxCount"`
+	BalanceRegions    *bool                        `json:"balanceRegions"`
+	TargetConcurrency *int                         `json:"targetConcurrency,omitempty"`
+	ConcurrencyType   *string                      `json:"concurrencyType,omitempty"`
+	ResetRegions      *bool                        `json:"resetRegions"`
+	Regions           []AutoscaleRegionConfigInput `json:"regions"`
 }
 
 type AutoscaleRegionConfigInput struct {
@@ -775,12 +1033,18 @@ type VMSize struct {
 	PriceMonth         float32
 	PriceSecond        float32
 	MemoryIncrementsMB []int
+	GPUKind            string
+	GPUCount           int
 }
 
 type SetVMSizeInput struct {
 	AppID    string `json:"appId"`
 	SizeName string `json:"sizeName"`
 	MemoryMb int64  `json:"memoryMb"`
+	GPUKind  string `json:"gpuKind,omitempty"`
+	GPUCount int    `json:"gpuCount,omitempty"`
+	// Group - the process group to size, all groups when empty
+	Group string `json:"group,omitempty"`
 }
 
 type SetVMCountInput struct {
@@ -869,6 +1133,20 @@ type DNSRecord struct {
 	UpdatedAt  time.Time
 }
 
+type CreateDNSRecordInput struct {
+	DomainID string `json:"domainId"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	TTL      int    `json:"ttl"`
+	RData    string `json:"rdata"`
+}
+
+type UpdateDNSRecordInput struct {
+	RecordID string `json:"recordId"`
+	TTL      int    `json:"ttl,omitempty"`
+	RData    string `json:"rdata,omitempty"`
+}
+
 type ImportDnsChange struct {
 	Action  string
 	OldText string
@@ -912,6 +1190,33 @@ type HealthCheckHandler struct {
 	Type string
 }
 
+// AlertRule - a threshold alert on an app, evaluated by the platform, which
+// notifies health check handlers when it fires
+type AlertRule struct {
+	ID   string
+	Name string
+	// Kind - INSTANCE_DOWN, RESTART_LOOP, CHECK_FLAPPING or MEMORY_NEAR_LIMIT
+	Kind string
+	// Threshold - the count or percentage the rule fires at
+	Threshold int
+	// WindowSeconds - how long the condition has to hold, or the period
+	// counted over
+	WindowSeconds int
+	Handlers      []HealthCheckHandler
+	// State - OK or FIRING
+	State     string
+	CreatedAt time.Time
+}
+
+type CreateAlertRuleInput struct {
+	AppID         string   `json:"appId"`
+	Name          string   `json:"name"`
+	Kind          string   `json:"kind"`
+	Threshold     int      `json:"threshold"`
+	WindowSeconds int      `json:"windowSeconds"`
+	HandlerNames  []string `json:"handlerNames"`
+}
+
 type SetSlackHandlerInput struct {
 	OrganizationID  string  `json:"organizationId"`
 	Name            string  `json:"name"`
@@ -965,17 +1270,170 @@ type AttachPostgresClusterPayload struct {
 	EnvironmentVariableName string
 }
 
+type RemoteBuilderDailyStats struct {
+	Date                   time.Time
+	Builds                 int
+	FailedBuilds           int
+	AverageDurationSeconds float64
+	CacheHitRate           float64
+	AverageQueueSeconds    float64
+}
+
 type EnsureRemoteBuilderInput struct {
 	AppName        *string `json:"appName"`
 	OrganizationID *string `json:"organizationId"`
 }
 
+type PinRemoteBuilderInput struct {
+	OrganizationID string  `json:"organizationId"`
+	AppName        *string `json:"appName"`
+}
+
+type KeepRemoteBuilderWarmInput struct {
+	AppName string     `json:"appName"`
+	Until   *time.Time `json:"until"`
+}
+
+// RemoteBuilderKeepWarm - a builder kept from scaling to zero until
+// WarmUntil, which is nil once it may scale to zero again
+type RemoteBuilderKeepWarm struct {
+	App       *App
+	WarmUntil *time.Time
+}
+
+type AppTemplate struct {
+	ID          string
+	Name        string
+	Description string
+	Author      string
+	Version     int
+	Downloads   int
+	UpdatedAt   time.Time
+	Files       []AppTemplateFile
+}
+
+type AppTemplateFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+type PublishAppTemplateInput struct {
+	OrganizationID string            `json:"organizationId"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Files          []AppTemplateFile `json:"files"`
+}
+
+type Machine struct {
+	ID        string
+	Name      string
+	State     string
+	Region    string
+	CreatedAt time.Time
+	Config    MachineConfig
+	IPs       struct {
+		Nodes []MachineIP
+	}
+	Checks []MachineCheck
+	// ExitCode - exit code of the machine's main process once it has stopped
+	ExitCode *int
+	Runs     struct {
+		Nodes []MachineRun
+	}
+}
+
+type MachineCheck struct {
+	Name   string
+	Status string
+}
+
+type MachineIP struct {
+	Family string
+	Kind   string
+	IP     string
+}
+
+type MachineConfig struct {
+	Image   string            `json:"image"`
+	Cmd     []string          `json:"cmd,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Guest   *MachineGuest     `json:"guest,omitempty"`
+	Restart *MachineRestart   `json:"restart,omitempty"`
+	// Schedule - cron expression the machine is started on, it runs once when empty
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// MachineRun - one run of a machine, from start to exit
+type MachineRun struct {
+	ID         string
+	Status     string
+	ExitCode   *int
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+type MachineRestart struct {
+	// Policy - always, on-failure or no
+	Policy string `json:"policy"`
+}
+
+type MachineGuest struct {
+	CPUKind  string `json:"cpu_kind,omitempty"`
+	CPUs     int    `json:"cpus,omitempty"`
+	MemoryMB int    `json:"memory_mb,omitempty"`
+}
+
+type LaunchMachineInput struct {
+	AppID  string        `json:"appId"`
+	Name   string        `json:"name,omitempty"`
+	Region string        `json:"region,omitempty"`
+	Config MachineConfig `json:"config"`
+}
+
+type UpdateMachineInput struct {
+	AppID  string        `json:"appId"`
+	ID     string        `json:"id"`
+	Config MachineConfig `json:"config"`
+}
+
+type MachineExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+type RedisDatabase struct {
+	ID           string
+	Name         string
+	Region       string
+	Status       string
+	PrivateURL   string
+	PublicURL    string
+	Organization Organization
+	CreatedAt    time.Time
+}
+
+type CreateRedisDatabaseInput struct {
+	OrganizationID string `json:"organizationId"`
+	Name           string `json:"name"`
+	Region         string `json:"region"`
+}
+
 type PostgresClusterUser struct {
 	Username    string
 	IsSuperuser bool
 	Databases   []string
 }
 
+type PostgresClusterMember struct {
+	ID             string
+	IDShort        string
+	Region         string
+	Role           string
+	Healthy        bool
+	ReplicationLag int64
+}
+
 type PostgresClusterDatabase struct {
 	Name  string
 	Users []string
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// alertKind - a condition alert rules can fire on
+type alertKind struct {
+	Name             string
+	APIName          string
+	DefaultThreshold int
+	DefaultWindow    time.Duration
+	// Describe - the rule's condition in words
+	Describe func(threshold int, window time.Duration) string
+}
+
+var alertKinds = []alertKind{
+	{
+		Name:             "instance-down",
+		APIName:          "INSTANCE_DOWN",
+		DefaultThreshold: 1,
+		DefaultWindow:    5 * time.Minute,
+		Describe: func(threshold int, window time.Duration) string {
+			return fmt.Sprintf("%d or more instances down for %s", threshold, window)
+		},
+	},
+	{
+		Name:             "restart-loop",
+		APIName:          "RESTART_LOOP",
+		DefaultThreshold: 3,
+		DefaultWindow:    10 * time.Minute,
+		Describe: func(threshold int, window time.Duration) string {
+			return fmt.Sprintf("an instance restarts %d times within %s", threshold, window)
+		},
+	},
+	{
+		Name:             "check-flapping",
+		APIName:          "CHECK_FLAPPING",
+		DefaultThreshold: 4,
+		DefaultWindow:    10 * time.Minute,
+		Describe: func(threshold int, window time.Duration) string {
+			return fmt.Sprintf("a health check changes status %d times within %s", threshold, window)
+		},
+	},
+	{
+		Name:             "memory",
+		APIName:          "MEMORY_NEAR_LIMIT",
+		DefaultThreshold: 90,
+		DefaultWindow:    5 * time.Minute,
+		Describe: func(threshold int, window time.Duration) string {
+			return fmt.Sprintf("an instance uses over %d%% of its memory for %s", threshold, window)
+		},
+	},
+}
+
+func alertKindNames() []string {
+	names := make([]string, 0, len(alertKinds))
+	for _, kind := range alertKinds {
+		names = append(names, kind.Name)
+	}
+	return names
+}
+
+// findAlertKind looks a kind up by its flag or API name
+func findAlertKind(name string) (alertKind, bool) {
+	for _, kind := range alertKinds {
+		if strings.EqualFold(kind.Name, name) || kind.APIName == name {
+			return kind, true
+		}
+	}
+	return alertKind{}, false
+}
+
+func newAlertsCommand(client *client.Client) *Command {
+	alertsStrings := docstrings.Get("alerts")
+	cmd := BuildCommandKS(nil, nil, alertsStrings, client, requireSession)
+
+	listStrings := docstrings.Get("alerts.list")
+	BuildCommandKS(cmd, runAlertsList, listStrings, client, requireSession, requireAppName)
+
+	createStrings := docstrings.Get("alerts.create")
+	createCmd := BuildCommandKS(cmd, runAlertsCreate, createStrings, client, requireSession, requireAppName)
+	createCmd.Args = cobra.ExactArgs(1)
+	createCmd.AddStringFlag(StringFlagOpts{Name: "kind", Shorthand: "k", Description: "What to alert on: " + strings.Join(alertKindNames(), ", ")})
+	createCmd.AddIntFlag(IntFlagOpts{Name: "threshold", Description: "The count or percentage to alert at. Defaults depend on the kind"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "for", Description: "How long the condition has to hold, or the period counted over, e.g. 10m. Defaults depend on the kind"})
+	createCmd.AddStringSliceFlag(StringSliceFlagOpts{Name: "notify", Shorthand: "n", Description: "Health check handlers to notify, by name. Can be specified multiple times"})
+
+	deleteStrings := docstrings.Get("alerts.delete")
+	deleteCmd := BuildCommandKS(cmd, runAlertsDelete, deleteStrings, client, requireSession, requireAppName)
+	deleteCmd.Aliases = []string{"rm"}
+	deleteCmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runAlertsList(ctx *cmdctx.CmdContext) error {
+	rules, err := ctx.Client.API().GetAlertRules(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(rules)
+		return nil
+	}
+
+	if len(rules) == 0 {
+		fmt.Fprintf(ctx.Out, "No alerts for %s, create one with flyctl alerts create\n", ctx.AppName)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Kind", "Condition", "Notify", "State", "Created"})
+	for _, rule := range rules {
+		table.Append([]string{
+			rule.Name,
+			formatAlertKind(rule.Kind),
+			describeAlertRule(rule),
+			strings.Join(alertHandlerNames(rule.Handlers), ", "),
+			rule.State,
+			presenters.FormatRelativeTime(rule.CreatedAt),
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runAlertsCreate(ctx *cmdctx.CmdContext) error {
+	apiClient := ctx.Client.API()
+	name := ctx.Args[0]
+
+	kindName := ctx.Config.GetString("kind")
+	if kindName == "" {
+		return flyerr.New(flyerr.InvalidArgument, "give what to alert on with --kind: "+strings.Join(alertKindNames(), ", "))
+	}
+	kind, ok := findAlertKind(kindName)
+	if !ok {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("unknown --kind %s, use one of %s", kindName, strings.Join(alertKindNames(), ", ")))
+	}
+
+	threshold := kind.DefaultThreshold
+	if ctx.Config.IsSet("threshold") {
+		threshold = ctx.Config.GetInt("threshold")
+	}
+	if threshold < 1 || (kind.Name == "memory" && threshold > 100) {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --threshold %d for %s alerts", threshold, kind.Name))
+	}
+
+	window := kind.DefaultWindow
+	if value := ctx.Config.GetString("for"); value != "" {
+		var err error
+		if window, err = time.ParseDuration(value); err != nil || window < time.Minute {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --for %s, use a duration of at least 1m", value))
+		}
+	}
+
+	notify := ctx.Config.GetStringSlice("notify")
+	if len(notify) == 0 {
+		return flyerr.New(flyerr.InvalidArgument, "give the health check handlers to notify with --notify")
+	}
+	if err := checkAlertHandlers(apiClient, ctx.AppName, notify); err != nil {
+		return err
+	}
+
+	rule, err := apiClient.CreateAlertRule(api.CreateAlertRuleInput{
+		AppID:         ctx.AppName,
+		Name:          name,
+		Kind:          kind.APIName,
+		Threshold:     threshold,
+		WindowSeconds: int(window.Seconds()),
+		HandlerNames:  notify,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(rule)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Created alert %s: notify %s when %s\n", rule.Name, strings.Join(alertHandlerNames(rule.Handlers), ", "), describeAlertRule(*rule))
+
+	return nil
+}
+
+// checkAlertHandlers fails when any of names isn't a health check handler
+// of the app's organization
+func checkAlertHandlers(client *api.Client, appName string, names []string) error {
+	app, err := client.GetApp(appName)
+	if err != nil {
+		return err
+	}
+
+	handlers, err := client.GetHealthCheckHandlers(app.Organization.Slug)
+	if err != nil {
+		return err
+	}
+	known := alertHandlerNames(handlers)
+
+	for _, name := range names {
+		found := false
+		for _, handler := range known {
+			if handler == name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s has no health check handler named %s", app.Organization.Slug, name)
+		if len(known) == 0 {
+			msg += ", create one with flyctl checks handlers create"
+		} else {
+			msg += ", use one of " + strings.Join(known, ", ")
+		}
+		return flyerr.New(flyerr.NotFound, msg)
+	}
+
+	return nil
+}
+
+func runAlertsDelete(ctx *cmdctx.CmdContext) error {
+	name := ctx.Args[0]
+
+	if !confirm(fmt.Sprintf("Delete alert %s from %s?", name, ctx.AppName)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteAlertRule(ctx.AppName, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Alert %s deleted\n", name)
+
+	return nil
+}
+
+func describeAlertRule(rule api.AlertRule) string {
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	if kind, ok := findAlertKind(rule.Kind); ok {
+		return kind.Describe(rule.Threshold, window)
+	}
+	return fmt.Sprintf("threshold %d over %s", rule.Threshold, window)
+}
+
+func formatAlertKind(apiName string) string {
+	if kind, ok := findAlertKind(apiName); ok {
+		return kind.Name
+	}
+	return strings.ToLower(apiName)
+}
+
+func alertHandlerNames(handlers []api.HealthCheckHandler) []string {
+	names := make([]string, 0, len(handlers))
+	for _, handler := range handlers {
+		names = append(names, handler.Name)
+	}
+	return names
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newAliasCommand(client *client.Client) *Command {
+	aliasStrings := docstrings.Get("alias")
+	cmd := BuildCommandKS(nil, nil, aliasStrings, client)
+
+	setStrings := docstrings.Get("alias.set")
+	setCmd := BuildCommandKS(cmd, runAliasSet, setStrings, client)
+	setCmd.Args = cobra.ExactArgs(2)
+
+	listStrings := docstrings.Get("alias.list")
+	BuildCommandKS(cmd, runAliasList, listStrings, client)
+
+	removeStrings := docstrings.Get("alias.remove")
+	removeCmd := BuildCommandKS(cmd, runAliasRemove, removeStrings, client)
+	removeCmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runAliasSet(ctx *cmdctx.CmdContext) error {
+	name, expansion := ctx.Args[0], ctx.Args[1]
+
+	if strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+
+	if err := flyctl.SetAlias(name, expansion); err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias %s set to %q\n", name, expansion)
+
+	return nil
+}
+
+func runAliasList(ctx *cmdctx.CmdContext) error {
+	cliConfig := flyctl.UserCLIConfig()
+	if appConfig := loadWorkingDirAppConfig(); appConfig != nil {
+		cliConfig = cliConfig.Merge(appConfig.CLI)
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(cliConfig.Aliases)
+		return nil
+	}
+
+	if len(cliConfig.Aliases) == 0 {
+		fmt.Println("No aliases defined")
+		return nil
+	}
+
+	names := make([]string, 0, len(cliConfig.Aliases))
+	for name := range cliConfig.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Alias", "Command"})
+	for _, name := range names {
+		table.Append([]string{name, cliConfig.Aliases[name]})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runAliasRemove(ctx *cmdctx.CmdContext) error {
+	if err := flyctl.RemoveAlias(ctx.Args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias %s removed\n", ctx.Args[0])
+
+	return nil
+}
+
+// loadWorkingDirAppConfig loads fly.toml, or fly.yaml or fly.json, from the
+// working directory, if there is one
+func loadWorkingDirAppConfig() *flyctl.AppConfig {
+	configPath, err := flyctl.ResolveConfigFileFromPath(".")
+	if err != nil || !helpers.FileExists(configPath) {
+		return nil
+	}
+
+	appConfig, err := flyctl.LoadAppConfig(configPath)
+	if err != nil {
+		terminal.Debug("error loading app config for aliases:", err)
+		return nil
+	}
+
+	return appConfig
+}
+
+// ExpandAliases rewrites args that start with an alias from the user's config
+// or the [cli] section of fly.toml. Aliases never shadow real commands.
+func ExpandAliases(root *cobra.Command, args []string) ([]string, error) {
+	cliConfig := flyctl.UserCLIConfig()
+	if appConfig := loadWorkingDirAppConfig(); appConfig != nil {
+		cliConfig = cliConfig.Merge(appConfig.CLI)
+	}
+
+	if len(cliConfig.Aliases) == 0 {
+		return args, nil
+	}
+
+	isCommand := func(name string) bool {
+		if strings.HasPrefix(name, "-") {
+			return true
+		}
+		for _, c := range root.Commands() {
+			if c.Name() == name || c.HasAlias(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// global flags can come before the alias, as in flyctl -a myapp shipit
+	start := leadingFlags(root, args)
+	expanded, err := flyctl.ExpandAlias(args[start:], cliConfig.Aliases, isCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]string{}, args[:start]...), expanded...), nil
+}
+
+// leadingFlags returns how many of args are root's flags, with their values,
+// before the first argument that isn't one
+func leadingFlags(root *cobra.Command, args []string) int {
+	flags := root.PersistentFlags()
+
+	i := 0
+	for i < len(args) {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+		i++
+
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		var flag *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			flag = flags.Lookup(strings.TrimPrefix(arg, "--"))
+		} else if len(arg) == 2 {
+			flag = flags.ShorthandLookup(arg[1:])
+		}
+		// as when cobra finds the command, flags other than booleans, and
+		// flags it doesn't know, like a command's -a, take the next argument
+		if flag == nil || flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+
+	return len(args)
+}
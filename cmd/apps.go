@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
 
@@ -57,22 +59,35 @@ func newAppsCommand(client *client.Client) *Command {
 		Description: "Never write a fly.toml file",
 	})
 
+	create.AddBoolFlag(BoolFlagOpts{
+		Name:        "generate-name",
+		Description: "Generate a random, available name for the app",
+	})
+
+	appsCheckNameStrings := docstrings.Get("apps.check-name")
+	checkName := BuildCommand(cmd, runAppsCheckName, appsCheckNameStrings.Usage, appsCheckNameStrings.Short, appsCheckNameStrings.Long, client, requireSession)
+	checkName.Args = cobra.ExactArgs(1)
+
 	appsDestroyStrings := docstrings.Get("apps.destroy")
 	destroy := BuildCommand(cmd, runDestroy, appsDestroyStrings.Usage, appsDestroyStrings.Short, appsDestroyStrings.Long, client, requireSession)
 	destroy.Args = cobra.ExactArgs(1)
 	// TODO: Move flag descriptions into the docStrings
-	destroy.AddBoolFlag(BoolFlagOpts{Name: "yes", Shorthand: "y", Description: "Accept all confirmations"})
+	destroy.AddBoolFlag(BoolFlagOpts{Name: "dry-run", Description: "List what would be destroyed, without destroying it"})
 
 	appsMoveStrings := docstrings.Get("apps.move")
 	move := BuildCommand(cmd, runMove, appsMoveStrings.Usage, appsMoveStrings.Short, appsMoveStrings.Long, client, requireSession)
 	move.Args = cobra.ExactArgs(1)
 	// TODO: Move flag descriptions into the docStrings
-	move.AddBoolFlag(BoolFlagOpts{Name: "yes", Shorthand: "y", Description: "Accept all confirmations"})
 	move.AddStringFlag(StringFlagOpts{
 		Name:        "org",
 		Description: `The organization to move the app to`,
 	})
 
+	appsRenameStrings := docstrings.Get("apps.rename")
+	rename := BuildCommandKS(cmd, runAppsRename, appsRenameStrings, client, requireSession, requireAppName)
+	rename.Args = cobra.ExactArgs(1)
+	rename.AddBoolFlag(BoolFlagOpts{Name: "dry-run", Description: "Report what would be copied, without copying it"})
+
 	appsSuspendStrings := docstrings.Get("apps.suspend")
 	appsSuspendCmd := BuildCommand(cmd, runSuspend, appsSuspendStrings.Usage, appsSuspendStrings.Short, appsSuspendStrings.Long, client, requireSession, requireAppNameAsArg)
 	appsSuspendCmd.Args = cobra.RangeArgs(0, 1)
@@ -96,3 +111,19 @@ func runAppsList(ctx *cmdctx.CmdContext) error {
 
 	return ctx.Render(&presenters.Apps{Apps: listapps})
 }
+
+func runAppsCheckName(ctx *cmdctx.CmdContext) error {
+	name := ctx.Args[0]
+
+	if err := checkAppName(ctx.Client.API(), name); err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(map[string]interface{}{"name": name, "available": true})
+		return nil
+	}
+
+	fmt.Printf("App name %s is available\n", name)
+	return nil
+}
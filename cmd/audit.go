@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// auditLogTypes - the kinds of action recorded in an organization's audit log
+var auditLogTypes = []string{"deploy", "secrets", "scale", "members"}
+
+// auditLogPageSize - how many entries are fetched at a time
+const auditLogPageSize = 100
+
+func newAuditCommand(client *client.Client) *Command {
+	auditStrings := docstrings.Get("audit")
+	cmd := BuildCommandKS(nil, runAudit, auditStrings, client, requireSession)
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "org",
+		Shorthand:   "o",
+		Description: "The organization whose audit log to show",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "since",
+		Description: "Show entries since this time, as RFC3339 or a duration ago (e.g. 24h)",
+		Default:     "24h",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "until",
+		Description: "Show entries until this time, as RFC3339 or a duration ago. Defaults to now",
+	})
+	cmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "type",
+		Description: "Only show entries of these types: " + strings.Join(auditLogTypes, ", "),
+	})
+	cmd.AddIntFlag(IntFlagOpts{
+		Name:        "limit",
+		Description: "How many entries to show, 0 for all of them",
+		Default:     100,
+	})
+
+	return cmd
+}
+
+func runAudit(cmdCtx *cmdctx.CmdContext) error {
+	apiClient := cmdCtx.Client.API()
+
+	filter, err := auditLogFilter(cmdCtx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	limit := cmdCtx.Config.GetInt("limit")
+	if limit < 0 {
+		return flyerr.New(flyerr.InvalidArgument, "--limit can't be negative")
+	}
+
+	org, err := selectOrganization(apiClient, cmdCtx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	entries := []api.AuditLogEntry{}
+	var after *string
+	for limit == 0 || len(entries) < limit {
+		page, pageInfo, err := apiClient.GetAuditLogPage(org.Slug, filter, auditLogPageSize, after)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, page...)
+
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+		after = api.StringPointer(pageInfo.EndCursor)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(entries)
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(cmdCtx.Out, "No audit log entries for %s\n", org.Slug)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(cmdCtx.Out, []string{"Time", "Type", "Action", "App", "Actor", "Description"})
+	for _, entry := range entries {
+		table.Append([]string{
+			entry.CreatedAt.Local().Format(time.RFC3339),
+			entry.Type,
+			entry.Action,
+			entry.AppName,
+			formatAuditActor(entry.Actor),
+			entry.Description,
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+// auditLogFilter builds the filter for the --since, --until and --type flags
+func auditLogFilter(cmdCtx *cmdctx.CmdContext, now time.Time) (api.AuditLogFilter, error) {
+	var filter api.AuditLogFilter
+
+	if value := cmdCtx.Config.GetString("since"); value != "" {
+		since, err := parseTimeArg(value, now)
+		if err != nil {
+			return filter, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --since %s: use RFC3339 or a duration like 24h", value))
+		}
+		filter.Since = &since
+	}
+
+	if value := cmdCtx.Config.GetString("until"); value != "" {
+		until, err := parseTimeArg(value, now)
+		if err != nil {
+			return filter, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --until %s: use RFC3339 or a duration like 1h", value))
+		}
+		filter.Until = &until
+	}
+
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return filter, flyerr.New(flyerr.InvalidArgument, "--until must be after --since")
+	}
+
+	for _, t := range cmdCtx.Config.GetStringSlice("type") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !isAuditLogType(t) {
+			return filter, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("unknown --type %s, use one of %s", t, strings.Join(auditLogTypes, ", ")))
+		}
+		filter.Types = append(filter.Types, t)
+	}
+
+	return filter, nil
+}
+
+func formatAuditActor(actor api.AuditLogActor) string {
+	name := actor.Email
+	if name == "" {
+		name = actor.Name
+	}
+	if actor.Type != "" && actor.Type != "User" {
+		return fmt.Sprintf("%s (%s)", name, strings.ToLower(actor.Type))
+	}
+	return name
+}
+
+func isAuditLogType(t string) bool {
+	for _, known := range auditLogTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/superfly/flyctl/cmdctx"
@@ -16,9 +18,10 @@ import (
 	"github.com/briandowns/spinner"
 	"github.com/logrusorgru/aurora"
 	"github.com/skratchdot/open-golang/open"
-	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/terminal"
 )
 
@@ -32,7 +35,11 @@ func newAuthCommand(client *client.Client) *Command {
 	BuildCommand(cmd, runWhoami, authWhoamiStrings.Usage, authWhoamiStrings.Short, authWhoamiStrings.Long, client, requireSession)
 
 	authTokenStrings := docstrings.Get("auth.token")
-	BuildCommand(cmd, runAuthToken, authTokenStrings.Usage, authTokenStrings.Short, authTokenStrings.Long, client, requireSession)
+	token := BuildCommand(cmd, runAuthToken, authTokenStrings.Usage, authTokenStrings.Short, authTokenStrings.Long, client, requireSession)
+	token.AddBoolFlag(BoolFlagOpts{
+		Name:        "reveal",
+		Description: "Print the whole token, after confirming",
+	})
 
 	authLoginStrings := docstrings.Get("auth.login")
 	login := BuildCommand(cmd, runLogin, authLoginStrings.Usage, authLoginStrings.Short, authLoginStrings.Long, client)
@@ -58,10 +65,17 @@ func newAuthCommand(client *client.Client) *Command {
 		Name:        "otp",
 		Description: "One time password",
 	})
+	login.AddBoolFlag(BoolFlagOpts{
+		Name:        "device",
+		Description: "Log in with a code entered in a browser on another device, for machines without a browser",
+	})
 
 	authLogoutStrings := docstrings.Get("auth.logout")
 	BuildCommand(cmd, runLogout, authLogoutStrings.Usage, authLogoutStrings.Short, authLogoutStrings.Long, client, requireSession)
 
+	authProfilesStrings := docstrings.Get("auth.profiles")
+	BuildCommandKS(cmd, runAuthProfiles, authProfilesStrings, client)
+
 	authSignupStrings := docstrings.Get("auth.signup")
 	BuildCommand(cmd, runSignup, authSignupStrings.Usage, authSignupStrings.Short, authSignupStrings.Long, client)
 
@@ -74,10 +88,22 @@ func runWhoami(ctx *cmdctx.CmdContext) error {
 		return err
 	}
 	fmt.Printf("Current user: %s\n", user.Email)
+	if profile := flyctl.CurrentProfile(); profile != "" {
+		fmt.Printf("Profile: %s\n", profile)
+	}
 	return nil
 }
 
 func runLogin(ctx *cmdctx.CmdContext) error {
+	if profile := flyctl.CurrentProfile(); profile != "" {
+		if err := flyctl.ValidateProfileName(profile); err != nil {
+			return flyerr.Wrap(flyerr.InvalidArgument, err)
+		}
+	}
+
+	if ctx.Config.GetBool("device") {
+		return runDeviceLogin(ctx)
+	}
 	if ctx.Config.GetBool("interactive") {
 		return runInteractiveLogin(ctx)
 	}
@@ -99,11 +125,21 @@ func runSignup(ctx *cmdctx.CmdContext) error {
 }
 
 func runWebLogin(ctx *cmdctx.CmdContext, signup bool) error {
+	token, err := webLogin(signup)
+	if err != nil {
+		return err
+	}
+
+	return saveLogin(ctx, token)
+}
+
+// webLogin logs in in a browser, returning the new access token
+func webLogin(signup bool) (string, error) {
 	name, _ := os.Hostname()
 
 	cliAuth, err := api.StartCLISessionWebAuth(name, signup)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	//fmt.Fprintln(ctx.Out, "Opening browser to url", aurora.Bold(cliAuth.AuthURL))
@@ -114,33 +150,133 @@ func runWebLogin(ctx *cmdctx.CmdContext, signup bool) error {
 
 	select {
 	case <-time.After(15 * time.Minute):
-		return errors.New("Login expired, please try again")
+		return "", errors.New("Login expired, please try again")
 	case cliAuth = <-waitForCLISession(cliAuth.ID):
 	}
 
 	if cliAuth.AccessToken == "" {
-		return errors.New("Unable to log in, please try again")
+		return "", errors.New("Unable to log in, please try again")
 	}
 
-	viper.Set(flyctl.ConfigAPIToken, cliAuth.AccessToken)
-	if err := flyctl.SaveConfig(); err != nil {
+	return cliAuth.AccessToken, nil
+}
+
+func runDeviceLogin(ctx *cmdctx.CmdContext) error {
+	name, _ := os.Hostname()
+
+	deviceAuth, err := api.StartDeviceAuth(name)
+	if err != nil {
 		return err
 	}
 
-	if !ctx.Client.InitApi() {
-		return client.ErrNoAuthToken
+	fmt.Fprintf(ctx.Out, "Open %s in a browser on any device and enter the code %s\n", aurora.Bold(deviceAuth.VerificationURL), aurora.Bold(deviceAuth.UserCode))
+
+	interval := time.Duration(deviceAuth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiresIn := time.Duration(deviceAuth.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
 	}
+	expired := time.After(expiresIn)
+
+	cancelCtx := createCancellableContext()
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Writer = os.Stderr
+	s.Prefix = "Waiting for the code to be entered..."
+	s.Start()
+	defer s.Stop()
+
+	for {
+		select {
+		case <-cancelCtx.Done():
+			return ErrAbort
+		case <-expired:
+			return flyerr.New(flyerr.Timeout, "The code expired before it was entered, please try again")
+		case <-time.After(interval):
+		}
 
-	user, err := ctx.Client.API().GetCurrentUser()
+		token, err := api.GetAccessTokenForDevice(deviceAuth.DeviceCode)
+		switch {
+		case err == api.ErrAuthorizationPending:
+			continue
+		case err == api.ErrNotFound:
+			return flyerr.New(flyerr.Unauthorized, "The login was denied or expired, please try again")
+		case err != nil:
+			terminal.Debugf("error polling device login: %v\n", err)
+			continue
+		}
+
+		s.Stop()
+		return saveLogin(ctx, token)
+	}
+}
+
+// saveLogin verifies a new access token and stores it as the default
+// credentials, or in the profile selected with --profile or FLY_PROFILE
+func saveLogin(ctx *cmdctx.CmdContext, accessToken string) error {
+	user, err := api.NewClient(accessToken, flyctl.Version).GetCurrentUser()
 	if err != nil {
 		return err
 	}
 
+	profile := flyctl.CurrentProfile()
+	if profile != "" {
+		if err := flyctl.SaveProfile(profile, flyctl.Profile{AccessToken: accessToken, Email: user.Email}); err != nil {
+			return err
+		}
+	} else {
+		where, err := flyctl.StoreAPIToken(accessToken)
+		if err != nil {
+			return err
+		}
+		terminal.Debugf("Stored the access token in %s\n", where)
+	}
+
+	if !ctx.Client.InitApi() {
+		return flyerr.Wrap(flyerr.Unauthorized, client.ErrNoAuthToken)
+	}
+
+	if profile != "" {
+		fmt.Println("Successfully logged in as", aurora.Bold(user.Email), "in profile", aurora.Bold(profile))
+		fmt.Printf("Use it with --profile %s or FLY_PROFILE=%s\n", profile, profile)
+		return nil
+	}
+
 	fmt.Println("Successfully logged in as", aurora.Bold(user.Email))
 
 	return nil
 }
 
+// reauthenticate offers to log in again in a browser when the API rejects
+// the access token part way through a command, saving the new token. Tokens
+// from the environment or --access-token are left for the user to replace.
+func reauthenticate(cmd *Command, ctx *cmdctx.CmdContext) api.ReauthFunc {
+	return func(context.Context) (string, error) {
+		if source := flyctl.GetAPITokenSource(); strings.HasPrefix(source, "FLY_") {
+			return "", fmt.Errorf("the access token in %s was rejected", source)
+		}
+		if cmd.Root().PersistentFlags().Changed("access-token") {
+			return "", errors.New("the access token passed with --access-token was rejected")
+		}
+
+		if !offer("Your access token was rejected, it may have expired. Log in again to continue?") {
+			return "", errors.New("not logged in again")
+		}
+
+		token, err := webLogin(false)
+		if err != nil {
+			return "", err
+		}
+		if err := saveLogin(ctx, token); err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+}
+
 func waitForCLISession(id string) <-chan api.CLISessionAuth {
 	done := make(chan api.CLISessionAuth)
 
@@ -172,10 +308,11 @@ func runInteractiveLogin(ctx *cmdctx.CmdContext) error {
 		prompt := &survey.Input{
 			Message: "Email:",
 		}
-		if err := survey.AskOne(prompt, &email, survey.WithValidator(survey.Required)); err != nil {
+		if err := ask(prompt, &email, survey.WithValidator(survey.Required)); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -184,10 +321,11 @@ func runInteractiveLogin(ctx *cmdctx.CmdContext) error {
 		prompt := &survey.Password{
 			Message: "Password:",
 		}
-		if err := survey.AskOne(prompt, &password, survey.WithValidator(survey.Required)); err != nil {
+		if err := ask(prompt, &password, survey.WithValidator(survey.Required)); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -196,7 +334,7 @@ func runInteractiveLogin(ctx *cmdctx.CmdContext) error {
 		prompt := &survey.Password{
 			Message: "One Time Password (if any):",
 		}
-		if err := survey.AskOne(prompt, &otp); err != nil {
+		if err := ask(prompt, &otp); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
@@ -209,15 +347,24 @@ func runInteractiveLogin(ctx *cmdctx.CmdContext) error {
 		return err
 	}
 
-	viper.Set(flyctl.ConfigAPIToken, accessToken)
-
-	return flyctl.SaveConfig()
+	return saveLogin(ctx, accessToken)
 }
 
 func runLogout(ctx *cmdctx.CmdContext) error {
-	viper.Set(flyctl.ConfigAPIToken, "")
+	if profile := flyctl.CurrentProfile(); profile != "" {
+		removed, err := flyctl.RemoveProfile(profile)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return flyerr.New(flyerr.NotFound, fmt.Sprintf("There is no profile named %s", profile))
+		}
+
+		fmt.Printf("Profile %s removed\n", profile)
+		return nil
+	}
 
-	if err := flyctl.SaveConfig(); err != nil {
+	if err := flyctl.ClearAPIToken(); err != nil {
 		return err
 	}
 
@@ -240,18 +387,52 @@ func runLogout(ctx *cmdctx.CmdContext) error {
 	return nil
 }
 
+// runAuthToken shows the token in use, masked on a terminal unless --reveal
+// is confirmed, so it isn't left in terminal scrollback by accident. Output
+// that isn't a terminal, as in $(flyctl auth token), gets the whole token.
 func runAuthToken(ctx *cmdctx.CmdContext) error {
 	token := flyctl.GetAPIToken()
+	source := flyctl.GetAPITokenSource()
+	onTerminal := ctx.IO.IsStdoutTTY()
+	reveal := ctx.Config.GetBool("reveal") || !onTerminal
+
+	if onTerminal && reveal && !autoConfirmed() {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: "Print your access token? Anyone who sees it can act as you",
+		}
+		if err := ask(prompt, &confirm); err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+
+	if !reveal {
+		token = maskToken(token)
+	}
 
 	if ctx.OutputJSON() {
-		ctx.WriteJSON(map[string]string{"flyctlAuthToken": token})
+		ctx.WriteJSON(map[string]string{"flyctlAuthToken": token, "source": source})
 		return nil
 	}
 	fmt.Fprintln(ctx.Out, token)
+	if !reveal {
+		fmt.Fprintf(ctx.IO.ErrOut, "From %s, use --reveal to print the whole token\n", source)
+	}
 
 	return nil
 }
 
+// maskToken hides all but the last four characters of token
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+}
+
 func runAuthDocker(ctx *cmdctx.CmdContext) error {
 	cc := createCancellableContext()
 
@@ -292,3 +473,43 @@ func runAuthDocker(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+func runAuthProfiles(ctx *cmdctx.CmdContext) error {
+	profiles, err := flyctl.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	current := flyctl.CurrentProfile()
+
+	if ctx.OutputJSON() {
+		type profileInfo struct {
+			Name    string
+			Email   string
+			Current bool
+		}
+		out := []profileInfo{}
+		for _, name := range flyctl.ProfileNames(profiles) {
+			out = append(out, profileInfo{Name: name, Email: profiles[name].Email, Current: name == current})
+		}
+		ctx.WriteJSON(out)
+		return nil
+	}
+
+	if len(profiles) == 0 {
+		fmt.Fprintln(ctx.Out, "No profiles. Log in to one with 'flyctl auth login --profile <name>'")
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"", "Name", "Email"})
+	for _, name := range flyctl.ProfileNames(profiles) {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		table.Append([]string{marker, name, profiles[name].Email})
+	}
+	table.Render()
+
+	return nil
+}
@@ -3,11 +3,16 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/docstrings"
@@ -27,18 +32,19 @@ func newAutoscaleCommand(client *client.Client) *Command {
 
 	balanceCmdStrings := docstrings.Get("autoscale.balanced")
 	balanceCmd := BuildCommand(cmd, runBalanceScale, balanceCmdStrings.Usage, balanceCmdStrings.Short, balanceCmdStrings.Long, client, requireSession, requireAppName)
-	balanceCmd.Args = cobra.RangeArgs(0, 2)
+	balanceCmd.Args = cobra.ArbitraryArgs
 
 	standardCmdStrings := docstrings.Get("autoscale.standard")
 	standardCmd := BuildCommand(cmd, runStandardScale, standardCmdStrings.Usage, standardCmdStrings.Short, standardCmdStrings.Long, client, requireSession, requireAppName)
-	standardCmd.Args = cobra.RangeArgs(0, 2)
+	standardCmd.Args = cobra.ArbitraryArgs
 
 	setCmdStrings := docstrings.Get("autoscale.set")
 	setCmd := BuildCommand(cmd, runSetParamsOnly, setCmdStrings.Usage, setCmdStrings.Short, setCmdStrings.Long, client, requireSession, requireAppName)
-	setCmd.Args = cobra.RangeArgs(0, 2)
+	setCmd.Args = cobra.ArbitraryArgs
 
 	showCmdStrings := docstrings.Get("autoscale.show")
-	BuildCommand(cmd, runAutoscalingShow, showCmdStrings.Usage, showCmdStrings.Short, showCmdStrings.Long, client, requireSession, requireAppName)
+	showCmd := BuildCommand(cmd, runAutoscalingShow, showCmdStrings.Usage, showCmdStrings.Short, showCmdStrings.Long, client, requireSession, requireAppName)
+	showCmd.AddBoolFlag(BoolFlagOpts{Name: "toml", Description: "print the configuration as an [autoscaling] section for fly.toml"})
 
 	return cmd
 }
@@ -52,7 +58,66 @@ func runStandardScale(commandContext *cmdctx.CmdContext) error {
 }
 
 func runSetParamsOnly(commandContext *cmdctx.CmdContext) error {
-	return actualScale(commandContext, false, true)
+	if len(commandContext.Args) > 0 {
+		return actualScale(commandContext, false, true)
+	}
+
+	if commandContext.AppConfig == nil || commandContext.AppConfig.Autoscaling == nil {
+		return flyerr.New(flyerr.InvalidArgument, "Nothing to set: pass parameters, like min=2, or add an [autoscaling] section to fly.toml")
+	}
+
+	// applying fly.toml replaces per-region settings, so it isn't done by accident
+	if !autoConfirmed() {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Apply the [autoscaling] section of %s to %s, replacing its current settings?", filepath.Base(commandContext.ConfigFile), commandContext.AppName),
+		}
+		if err := ask(prompt, &confirm); err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+
+	return applyAutoscalingConfig(commandContext, commandContext.AppConfig.Autoscaling)
+}
+
+// applyAutoscalingConfig applies the [autoscaling] section of fly.toml
+func applyAutoscalingConfig(commandContext *cmdctx.CmdContext, as *flyctl.Autoscaling) error {
+	newcfg := api.UpdateAutoscaleConfigInput{
+		AppID:             commandContext.AppName,
+		Enabled:           api.BoolPointer(true),
+		MinCount:          as.MinCount,
+		MaxCount:          as.MaxCount,
+		BalanceRegions:    as.BalanceRegions,
+		TargetConcurrency: as.TargetConcurrency,
+		// regions missing from fly.toml lose their overrides
+		ResetRegions: api.BoolPointer(true),
+	}
+
+	if as.ConcurrencyType != "" {
+		newcfg.ConcurrencyType = api.StringPointer(as.ConcurrencyType)
+	}
+
+	for code, region := range as.Regions {
+		newcfg.Regions = append(newcfg.Regions, api.AutoscaleRegionConfigInput{
+			Code:     code,
+			MinCount: region.MinCount,
+			Weight:   region.Weight,
+		})
+	}
+
+	fmt.Fprintf(commandContext.Out, "Applying autoscaling configuration from %s\n", commandContext.ConfigFile)
+
+	cfg, err := commandContext.Client.API().UpdateAutoscaleConfig(newcfg)
+	if err != nil {
+		return err
+	}
+
+	printScaleConfig(commandContext, cfg)
+
+	return nil
 }
 
 func runDisableAutoscaling(commandContext *cmdctx.CmdContext) error {
@@ -76,6 +141,10 @@ func actualScale(commandContext *cmdctx.CmdContext, balanceRegions bool, setPara
 
 	newcfg := api.UpdateAutoscaleConfigInput{AppID: commandContext.AppName}
 
+	if setParamsOnly {
+		balanceRegions = currentcfg.BalanceRegions
+	}
+
 	newcfg.BalanceRegions = &balanceRegions
 	newcfg.MinCount = &currentcfg.MinCount
 	newcfg.MaxCount = &currentcfg.MaxCount
@@ -118,6 +187,33 @@ func actualScale(commandContext *cmdctx.CmdContext, balanceRegions bool, setPara
 		delete(kvargs, "max")
 	}
 
+	targetval, found := kvargs["target"]
+
+	if found {
+		target, err := strconv.Atoi(targetval)
+		if err != nil {
+			return errors.New("could not parse target concurrency value")
+		}
+		newcfg.TargetConcurrency = &target
+		delete(kvargs, "target")
+	}
+
+	typeval, found := kvargs["type"]
+
+	if found {
+		if typeval != "connections" && typeval != "requests" {
+			return errors.New("type must be connections or requests")
+		}
+		newcfg.ConcurrencyType = &typeval
+		delete(kvargs, "type")
+	}
+
+	regions, err := parseAutoscaleRegionArgs(kvargs)
+	if err != nil {
+		return err
+	}
+	newcfg.Regions = regions
+
 	if len(kvargs) != 0 {
 		unusedkeys := ""
 		for k := range kvargs {
@@ -140,12 +236,60 @@ func actualScale(commandContext *cmdctx.CmdContext, balanceRegions bool, setPara
 	return nil
 }
 
+// parseAutoscaleRegionArgs removes region.min=int and region.weight=int
+// arguments from kvargs, returning them as per-region settings
+func parseAutoscaleRegionArgs(kvargs map[string]string) ([]api.AutoscaleRegionConfigInput, error) {
+	byRegion := map[string]*api.AutoscaleRegionConfigInput{}
+	codes := []string{}
+
+	for key, value := range kvargs {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) != 2 || (parts[1] != "min" && parts[1] != "weight") {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s value", key)
+		}
+
+		code := parts[0]
+		region, ok := byRegion[code]
+		if !ok {
+			region = &api.AutoscaleRegionConfigInput{Code: code}
+			byRegion[code] = region
+			codes = append(codes, code)
+		}
+
+		if parts[1] == "min" {
+			region.MinCount = &n
+		} else {
+			region.Weight = &n
+		}
+
+		delete(kvargs, key)
+	}
+
+	sort.Strings(codes)
+
+	regions := []api.AutoscaleRegionConfigInput{}
+	for _, code := range codes {
+		regions = append(regions, *byRegion[code])
+	}
+
+	return regions, nil
+}
+
 func runAutoscalingShow(commandContext *cmdctx.CmdContext) error {
 	cfg, err := commandContext.Client.API().AppAutoscalingConfig(commandContext.AppName)
 	if err != nil {
 		return err
 	}
 
+	if commandContext.Config.GetBool("toml") {
+		return printAutoscalingTOML(commandContext, cfg)
+	}
+
 	printScaleConfig(commandContext, cfg)
 
 	return nil
@@ -172,6 +316,31 @@ func printScaleConfig(commandContext *cmdctx.CmdContext, cfg *api.AutoscalingCon
 		if cfg.Enabled {
 			fmt.Fprintf(commandContext.Out, "%15s: %d\n", "Min Count", cfg.MinCount)
 			fmt.Fprintf(commandContext.Out, "%15s: %d\n", "Max Count", cfg.MaxCount)
+			if cfg.TargetConcurrency > 0 {
+				fmt.Fprintf(commandContext.Out, "%15s: %d %s\n", "Target", cfg.TargetConcurrency, cfg.ConcurrencyType)
+			}
+			for _, region := range cfg.Regions {
+				fmt.Fprintf(commandContext.Out, "%15s: min %d, weight %d\n", "Region "+region.Code, region.MinCount, region.Weight)
+			}
 		}
 	}
 }
+
+func printAutoscalingTOML(commandContext *cmdctx.CmdContext, cfg *api.AutoscalingConfig) error {
+	as := &flyctl.Autoscaling{
+		MinCount:       &cfg.MinCount,
+		MaxCount:       &cfg.MaxCount,
+		BalanceRegions: &cfg.BalanceRegions,
+		Regions:        map[string]flyctl.AutoscalingRegion{},
+	}
+	if cfg.TargetConcurrency > 0 {
+		as.TargetConcurrency = &cfg.TargetConcurrency
+		as.ConcurrencyType = cfg.ConcurrencyType
+	}
+	for i := range cfg.Regions {
+		region := cfg.Regions[i]
+		as.Regions[region.Code] = flyctl.AutoscalingRegion{MinCount: &region.MinCount, Weight: &region.Weight}
+	}
+
+	return as.WriteTOML(commandContext.Out)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/client"
+)
+
+func newBuildCommand(client *client.Client) *Command {
+	buildStrings := docstrings.Get("build")
+	cmd := BuildCommandKS(nil, nil, buildStrings, client)
+
+	planStrings := docstrings.Get("build.plan")
+	planCmd := BuildCommandKS(cmd, runBuildPlan, planStrings, client, workingDirectoryFromArg(0), requireAppName)
+	planCmd.Args = cobra.MaximumNArgs(1)
+	planCmd.AddBoolFlag(BoolFlagOpts{Name: "explain", Description: "Explain how the builder was chosen and what it builds with"})
+	planCmd.AddBoolFlag(BoolFlagOpts{Name: "refresh", Description: "Discard the cached plan and resolve a new one"})
+	planCmd.AddStringFlag(StringFlagOpts{Name: "dockerfile", Description: "Path to a Dockerfile. Defaults to the Dockerfile in the working directory."})
+	planCmd.AddStringSliceFlag(StringSliceFlagOpts{Name: "build-arg", Description: "Set of build time variables in the form of NAME=VALUE pairs. Can be specified multiple times."})
+	planCmd.AddStringFlag(StringFlagOpts{Name: "builder", Description: "Build with this Cloud Native Buildpacks builder image instead of the one in the [build] section"})
+	planCmd.AddStringSliceFlag(StringSliceFlagOpts{Name: "buildpack", Description: "Buildpack to build with, instead of those in the [build] section. Can be specified multiple times."})
+	planCmd.AddStringFlag(StringFlagOpts{Name: "build-context", Description: "Directory to send to the builder as the build context. Defaults to the working directory."})
+	planCmd.AddStringFlag(StringFlagOpts{Name: "build-target", Description: "Set the target build stage to build if the Dockerfile has more than one stage"})
+
+	return cmd
+}
+
+func runBuildPlan(cmdCtx *cmdctx.CmdContext) error {
+	opts, err := buildImageOptions(cmdCtx)
+	if err != nil {
+		return err
+	}
+
+	if cmdCtx.Config.GetBool("refresh") {
+		if err := imgsrc.ClearPlan(flyctl.ConfigDir(), opts.WorkingDir); err != nil {
+			return err
+		}
+	}
+
+	previous, err := imgsrc.LoadPlan(flyctl.ConfigDir(), opts.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	plan, cached, err := imgsrc.CachedPlan(flyctl.ConfigDir(), opts)
+	if err != nil {
+		return err
+	}
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(plan)
+		return nil
+	}
+
+	switch {
+	case cached:
+		fmt.Fprintln(cmdCtx.Out, "Using the cached build plan, its inputs haven't changed")
+	case previous != nil:
+		fmt.Fprintln(cmdCtx.Out, "The cached build plan was out of date, so a new one was resolved")
+	default:
+		fmt.Fprintln(cmdCtx.Out, "Resolved a new build plan")
+	}
+
+	if !cmdCtx.Config.GetBool("explain") {
+		fmt.Fprintf(cmdCtx.Out, "%s builder for %s\n", plan.Strategy, plan.WorkingDir)
+		return nil
+	}
+
+	fmt.Fprintln(cmdCtx.Out)
+	for _, line := range plan.Explain() {
+		fmt.Fprintf(cmdCtx.Out, "  %s\n", line)
+	}
+
+	return nil
+}
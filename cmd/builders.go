@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func newBuildersCommand(client *client.Client) *Command {
+	buildersStrings := docstrings.Get("builders")
+	cmd := BuildCommandKS(nil, nil, buildersStrings, client, requireSession)
+
+	statsStrings := docstrings.Get("builders.stats")
+	statsCmd := BuildCommandKS(cmd, runBuilderStats, statsStrings, client, requireSession)
+	statsCmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "the organization whose builders to report on"})
+	statsCmd.AddIntFlag(IntFlagOpts{Name: "days", Description: "number of days to report on", Default: 30})
+
+	pinStrings := docstrings.Get("builders.pin")
+	pinCmd := BuildCommandKS(cmd, runBuilderPin, pinStrings, client, requireSession)
+	pinCmd.Args = cobra.RangeArgs(0, 1)
+	pinCmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "the organization whose builds to pin"})
+
+	unpinStrings := docstrings.Get("builders.unpin")
+	unpinCmd := BuildCommandKS(cmd, runBuilderUnpin, unpinStrings, client, requireSession)
+	unpinCmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "the organization whose builds to unpin"})
+
+	keepWarmStrings := docstrings.Get("builders.keep-warm")
+	keepWarmCmd := BuildCommandKS(cmd, runBuilderKeepWarm, keepWarmStrings, client, requireSession)
+	keepWarmCmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "the organization whose builder to keep warm"})
+	keepWarmCmd.AddStringFlag(StringFlagOpts{Name: "builder-app", Description: "the builder app to keep warm, in place of the organization's pinned or default builder"})
+	keepWarmCmd.AddStringFlag(StringFlagOpts{Name: "duration", Description: "how long to keep the builder warm, up to 12h", Default: "2h"})
+	keepWarmCmd.AddBoolFlag(BoolFlagOpts{Name: "stop", Description: "let the builder scale to zero again now"})
+
+	return cmd
+}
+
+// maxKeepWarm - the longest a builder can be kept warm for at once
+const maxKeepWarm = 12 * time.Hour
+
+func runBuilderKeepWarm(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	org, err := selectOrganization(client, ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	var until *time.Time
+	if !ctx.Config.GetBool("stop") {
+		duration, err := time.ParseDuration(ctx.Config.GetString("duration"))
+		if err != nil || duration < time.Minute || duration > maxKeepWarm {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid duration %q, use a duration from 1m to %s like 2h", ctx.Config.GetString("duration"), maxKeepWarm))
+		}
+		t := time.Now().Add(duration).UTC()
+		until = &t
+	}
+
+	builderApp, err := orgRemoteBuilder(client, org, ctx.Config.GetString("builder-app"))
+	if err != nil {
+		return err
+	}
+
+	warm, err := client.KeepRemoteBuilderWarm(builderApp, until)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(warm)
+		return nil
+	}
+
+	if warm.WarmUntil == nil {
+		fmt.Fprintf(ctx.Out, "Remote builder %s can scale to zero again when it's idle\n", builderApp)
+		return nil
+	}
+	fmt.Fprintf(ctx.Out, "Keeping remote builder %s warm until %s (%s)\n", builderApp, warm.WarmUntil.Local().Format("15:04 MST"), humanize.Time(*warm.WarmUntil))
+	fmt.Fprintln(ctx.Out, "Stop early with flyctl builders keep-warm --stop")
+	return nil
+}
+
+// orgRemoteBuilder returns builderApp after checking it's in org, or else
+// the builder org's builds are pinned to, or else org's default builder,
+// which is created when it doesn't exist yet
+func orgRemoteBuilder(client *api.Client, org *api.Organization, builderApp string) (string, error) {
+	if builderApp != "" {
+		builder, err := client.GetApp(builderApp)
+		if err != nil {
+			return "", err
+		}
+		if builder.Organization.Slug != org.Slug {
+			return "", flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is in the %s organization, not %s", builderApp, builder.Organization.Slug, org.Slug))
+		}
+		return builderApp, nil
+	}
+
+	pinned, err := client.GetPinnedRemoteBuilder(org.Slug)
+	if err != nil {
+		return "", err
+	}
+	if pinned != nil {
+		return pinned.Name, nil
+	}
+
+	_, builder, err := client.EnsureRemoteBuilderForOrg(org.ID)
+	if err != nil {
+		return "", fmt.Errorf("could not create remote builder: %w", err)
+	}
+	return builder.Name, nil
+}
+
+func runBuilderPin(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	org, err := selectOrganization(client, ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	if len(ctx.Args) == 0 {
+		pinned, err := client.GetPinnedRemoteBuilder(org.Slug)
+		if err != nil {
+			return err
+		}
+		if ctx.OutputJSON() {
+			ctx.WriteJSON(map[string]interface{}{"organization": org.Slug, "remote_builder_app": pinned})
+			return nil
+		}
+		if pinned == nil {
+			fmt.Fprintf(ctx.Out, "%s's builds aren't pinned, they use the builder flyctl picks\n", org.Slug)
+			return nil
+		}
+		fmt.Fprintf(ctx.Out, "%s's builds are pinned to %s\n", org.Slug, pinned.Name)
+		return nil
+	}
+
+	builderApp, err := orgRemoteBuilder(client, org, ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.PinRemoteBuilder(org.ID, &builderApp); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Pinned %s's builds to %s\n", org.Slug, builderApp)
+	return nil
+}
+
+func runBuilderUnpin(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	org, err := selectOrganization(client, ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.PinRemoteBuilder(org.ID, nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Unpinned %s's builds, they use the builder flyctl picks\n", org.Slug)
+	return nil
+}
+
+func runBuilderStats(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	org, err := selectOrganization(client, ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	days := ctx.Config.GetInt("days")
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+
+	stats, err := client.GetRemoteBuilderStats(org.Slug, since)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(stats)
+		return nil
+	}
+
+	if len(stats) == 0 {
+		fmt.Printf("No builds on %s's remote builders in the past %d days\n", org.Slug, days)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Date", "Builds", "Failed", "Avg Duration", "Cache Hits", "Avg Queue"})
+	for _, day := range stats {
+		table.Append([]string{
+			day.Date.Format("2006-01-02"),
+			strconv.Itoa(day.Builds),
+			strconv.Itoa(day.FailedBuilds),
+			formatSeconds(day.AverageDurationSeconds),
+			formatPercent(day.CacheHitRate),
+			formatSeconds(day.AverageQueueSeconds),
+		})
+	}
+	table.Render()
+
+	total := summarizeBuilderStats(stats)
+
+	fmt.Println()
+	fmt.Printf("  Builds:        %d (%.1f per day, %d failed)\n", total.Builds, float64(total.Builds)/float64(days), total.FailedBuilds)
+	fmt.Printf("  Avg Duration:  %s\n", formatSeconds(total.AverageDurationSeconds))
+	fmt.Printf("  Cache Hits:    %s\n", formatPercent(total.CacheHitRate))
+	fmt.Printf("  Avg Queue:     %s\n", formatSeconds(total.AverageQueueSeconds))
+
+	return nil
+}
+
+// summarizeBuilderStats totals daily stats, weighting each day's averages by its number of builds
+func summarizeBuilderStats(stats []api.RemoteBuilderDailyStats) api.RemoteBuilderDailyStats {
+	var total api.RemoteBuilderDailyStats
+
+	for _, day := range stats {
+		total.Builds += day.Builds
+		total.FailedBuilds += day.FailedBuilds
+		total.AverageDurationSeconds += day.AverageDurationSeconds * float64(day.Builds)
+		total.CacheHitRate += day.CacheHitRate * float64(day.Builds)
+		total.AverageQueueSeconds += day.AverageQueueSeconds * float64(day.Builds)
+	}
+
+	if total.Builds > 0 {
+		n := float64(total.Builds)
+		total.AverageDurationSeconds /= n
+		total.CacheHitRate /= n
+		total.AverageQueueSeconds /= n
+	}
+
+	return total
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func formatPercent(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
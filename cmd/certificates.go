@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
 
 	"github.com/superfly/flyctl/docstrings"
@@ -34,7 +37,6 @@ func newCertificatesCommand(client *client.Client) *Command {
 	deleteCmd := BuildCommandKS(cmd, runCertDelete, certsDeleteStrings, client, requireSession, requireAppName)
 	deleteCmd.Aliases = []string{"delete"}
 	deleteCmd.Command.Args = cobra.ExactArgs(1)
-	deleteCmd.AddBoolFlag(BoolFlagOpts{Name: "yes", Shorthand: "y", Description: "accept all confirmations"})
 
 	certsShowStrings := docstrings.Get("certs.show")
 	show := BuildCommandKS(cmd, runCertShow, certsShowStrings, client, requireSession, requireAppName)
@@ -44,6 +46,10 @@ func newCertificatesCommand(client *client.Client) *Command {
 	check := BuildCommandKS(cmd, runCertCheck, certsCheckStrings, client, requireSession, requireAppName)
 	check.Command.Args = cobra.ExactArgs(1)
 
+	for _, c := range []*Command{createCmd, show, check} {
+		c.AddBoolFlag(BoolFlagOpts{Name: "watch", Description: "wait until the certificate has been issued"})
+	}
+
 	return cmd
 }
 
@@ -64,15 +70,7 @@ func runCertShow(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	if cert.ClientStatus == "Ready" {
-		commandContext.Statusf("certs", cmdctx.STITLE, "The certificate for %s has been issued.\n\n", hostname)
-		printCertificate(commandContext, cert)
-		return nil
-	}
-	commandContext.Statusf("certs", cmdctx.STITLE, "The certificate for %s has not been issued yet.\n\n", hostname)
-	printCertificate(commandContext, cert)
-	return reportNextStepCert(commandContext, hostname, cert, hostcheck)
-
+	return reportCertificate(commandContext, hostname, cert, hostcheck, true)
 }
 
 func runCertCheck(commandContext *cmdctx.CmdContext) error {
@@ -83,17 +81,7 @@ func runCertCheck(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	if cert.ClientStatus == "Ready" {
-		// A certificate has been issued
-		commandContext.Statusf("certs", cmdctx.SINFO, "The certificate for %s has been issued.\n", hostname)
-		printCertificate(commandContext, cert)
-		// Details should go here
-		return nil
-	}
-
-	commandContext.Statusf("certs", cmdctx.SINFO, "The certificate for %s has not been issued yet.\n", hostname)
-
-	return reportNextStepCert(commandContext, hostname, cert, hostcheck)
+	return reportCertificate(commandContext, hostname, cert, hostcheck, false)
 }
 
 func runCertAdd(commandContext *cmdctx.CmdContext) error {
@@ -104,18 +92,18 @@ func runCertAdd(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	return reportNextStepCert(commandContext, hostname, cert, hostcheck)
+	return reportCertificate(commandContext, hostname, cert, hostcheck, false)
 }
 
 func runCertDelete(commandContext *cmdctx.CmdContext) error {
 	hostname := commandContext.Args[0]
 
-	if !commandContext.Config.GetBool("yes") {
+	if !autoConfirmed() {
 		confirm := false
 		prompt := &survey.Confirm{
 			Message: fmt.Sprintf("Remove certificate %s from app %s?", hostname, commandContext.AppName),
 		}
-		err := survey.AskOne(prompt, &confirm)
+		err := ask(prompt, &confirm)
 		if err != nil {
 			return err
 		}
@@ -135,148 +123,207 @@ func runCertDelete(commandContext *cmdctx.CmdContext) error {
 	return nil
 }
 
-func reportNextStepCert(commandContext *cmdctx.CmdContext, hostname string, cert *api.AppCertificate, hostcheck *api.HostnameCheck) error {
-	// These are the IPs we have for the app
+// certDNSRecord is a DNS record the app's certificate needs, for routing
+// traffic to the app or validating ownership with an ACME challenge
+type certDNSRecord struct {
+	Type       string
+	Name       string
+	Value      string
+	Purpose    string
+	Required   bool
+	Configured bool
+}
+
+// reportCertificate prints the certificate's status, its details once it's
+// issued, or before then too with details, and the DNS records still to be
+// added, polling until it is issued when --watch is set
+func reportCertificate(commandContext *cmdctx.CmdContext, hostname string, cert *api.AppCertificate, hostcheck *api.HostnameCheck, details bool) error {
 	ips, err := commandContext.Client.API().GetIPAddresses(commandContext.AppName)
 	if err != nil {
 		return err
 	}
 
-	var ipV4 api.IPAddress
-	var ipV6 api.IPAddress
-	var configuredipV4 bool
-	var configuredipV6 bool
-
-	// Extract the v4 and v6 addresses we have allocated
-	for _, x := range ips {
-		if x.Type == "v4" {
-			ipV4 = x
-		} else if x.Type == "v6" {
-			ipV6 = x
-		}
+	records := certDNSRecords(commandContext.AppName, cert, hostcheck, ips)
+
+	if commandContext.OutputJSON() {
+		commandContext.WriteJSON(map[string]interface{}{
+			"Certificate": cert,
+			"Check":       hostcheck,
+			"DNSRecords":  records,
+		})
+		return nil
+	}
+
+	if cert.ClientStatus == "Ready" {
+		commandContext.Statusf("certs", cmdctx.STITLE, "The certificate for %s has been issued.\n\n", hostname)
+		printCertificate(commandContext, cert)
+		return nil
 	}
 
-	// Do we have A records
-	if len(hostcheck.ARecords) > 0 {
-		// Let's check the first A record against our recorded addresses
-		if !net.ParseIP(hostcheck.ARecords[0]).Equal(net.ParseIP(ipV4.Address)) {
-			commandContext.Statusf("certs", cmdctx.SWARN, "A Record (%s) does not match app's IP (%s)\n", hostcheck.ARecords[0], ipV4.Address)
-		} else {
-			configuredipV4 = true
+	commandContext.Statusf("certs", cmdctx.STITLE, "The certificate for %s has not been issued yet.\n\n", hostname)
+	if details {
+		printCertificate(commandContext, cert)
+	}
+	if hostcheck != nil {
+		for _, address := range hostcheck.ResolvedAddresses {
+			if !isAppIP(address, ips) {
+				commandContext.Statusf("certs", cmdctx.SWARN, "%s resolves to %s, which is not one of the app's IP addresses\n\n", hostname, address)
+			}
 		}
 	}
+	printCertDNSRecords(commandContext, hostname, cert, records, ips)
+
+	if !commandContext.Config.GetBool("watch") {
+		return nil
+	}
 
-	if len(hostcheck.AAAARecords) > 0 {
-		// Let's check the first A record against our recorded addresses
-		if !net.ParseIP(hostcheck.AAAARecords[0]).Equal(net.ParseIP(ipV6.Address)) {
-			commandContext.Statusf("certs", cmdctx.SWARN, "AAAA Record (%s) does not match app's IP (%s)\n", hostcheck.AAAARecords[0], ipV6.Address)
-		} else {
-			configuredipV6 = true
+	return watchCertificate(commandContext, hostname, cert)
+}
+
+// certDNSRecords lists the records for the hostname: an A and AAAA record for
+// apex and wildcard domains or a CNAME to the app for subdomains, and the
+// _acme-challenge CNAME, which wildcard certificates can't be issued without
+func certDNSRecords(appName string, cert *api.AppCertificate, hostcheck *api.HostnameCheck, ips []api.IPAddress) []certDNSRecord {
+	var ipV4, ipV6 string
+	for _, ip := range ips {
+		// dedicated addresses are listed before a shared one
+		if (ip.Type == "v4" || ip.Type == api.IPAddressSharedV4) && ipV4 == "" {
+			ipV4 = ip.Address
+		} else if ip.Type == "v6" && ipV6 == "" {
+			ipV6 = ip.Address
 		}
 	}
 
-	if len(hostcheck.ResolvedAddresses) > 0 {
-		for _, address := range hostcheck.ResolvedAddresses {
-			if net.ParseIP(address).Equal(net.ParseIP(ipV4.Address)) {
-				configuredipV4 = true
-			} else if net.ParseIP(address).Equal(net.ParseIP(ipV6.Address)) {
-				configuredipV6 = true
-			} else {
-				commandContext.Statusf("certs", cmdctx.SWARN, "Address resolution (%s) does not match app's IP (%s/%s)\n", address, ipV4.Address, ipV6.Address)
+	resolves := func(address string) bool {
+		if address == "" || hostcheck == nil {
+			return false
+		}
+		for _, set := range [][]string{hostcheck.ARecords, hostcheck.AAAARecords, hostcheck.ResolvedAddresses} {
+			for _, a := range set {
+				if net.ParseIP(a).Equal(net.ParseIP(address)) {
+					return true
+				}
 			}
 		}
+		return false
 	}
 
-	if cert.IsApex {
-		// If this is an apex domain we should guide towards creating A and AAAA records
-		addArecord := !configuredipV4
-		addAAAArecord := !cert.AcmeALPNConfigured
-
-		if addArecord || addAAAArecord {
-			stepcnt := 1
-			commandContext.Statusf("certs", cmdctx.SINFO, "You are creating a certificate for %s\n", hostname)
-			commandContext.Statusf("certs", cmdctx.SINFO, "We are using %s for this certificate.\n\n", cert.CertificateAuthority)
-			if addArecord {
-				commandContext.Statusf("certs", cmdctx.SINFO, "You can direct traffic to %s by:\n\n", hostname)
-				commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an A record to your DNS service which reads\n", stepcnt)
-				commandContext.Statusf("certs", cmdctx.SINFO, "\n    A @ %s\n\n", ipV4.Address)
-				stepcnt = stepcnt + 1
-			}
-			if addAAAArecord {
-				commandContext.Statusf("certs", cmdctx.SINFO, "You can validate your ownership of %s by:\n\n", hostname)
-				commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an AAAA record to your DNS service which reads:\n\n", stepcnt)
-				commandContext.Statusf("certs", cmdctx.SINFO, "    AAAA @ %s\n\n", ipV6.Address)
-				commandContext.Statusf("certs", cmdctx.SINFO, " OR \n\n")
-				commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an CNAME record to your DNS service which reads:\n\n", stepcnt)
-				commandContext.Statusf("certs", cmdctx.SINFO, "    %s\n", cert.DNSValidationInstructions)
-				// stepcnt = stepcnt + 1 Uncomment if more steps
+	name := relativeDNSName(cert.Hostname)
+	records := []certDNSRecord{}
 
-			}
-		} else {
-			if cert.ClientStatus == "Ready" {
-				commandContext.Statusf("certs", cmdctx.SINFO, "Your certificate for %s has been issued\n", hostname)
-			} else {
-				commandContext.Statusf("certs", cmdctx.SINFO, "Your certificate for %s is being issued. Status is %s.\n", hostname, cert.ClientStatus)
+	if cert.IsApex || cert.IsWildcard {
+		if ipV4 != "" {
+			records = append(records, certDNSRecord{Type: "A", Name: name, Value: ipV4, Purpose: "routing", Required: true, Configured: resolves(ipV4)})
+		}
+		if ipV6 != "" {
+			records = append(records, certDNSRecord{Type: "AAAA", Name: name, Value: ipV6, Purpose: "routing", Required: true, Configured: resolves(ipV6)})
+		}
+	} else {
+		target := fmt.Sprintf("%s.fly.dev", appName)
+		configured := resolves(ipV4) && (ipV6 == "" || resolves(ipV6))
+		if hostcheck != nil {
+			for _, cname := range hostcheck.CNAMERecords {
+				if strings.TrimSuffix(cname, ".") == target {
+					configured = true
+				}
 			}
 		}
-	} else if cert.IsWildcard {
-		// If this is an wildcard domain we should guide towards creating A and AAAA records
-		addArecord := !configuredipV4
-		addAAAArecord := !cert.AcmeALPNConfigured
-
-		stepcnt := 1
-		commandContext.Statusf("certs", cmdctx.SINFO, "You are creating a wildcard certificate for %s\n", hostname)
-		commandContext.Statusf("certs", cmdctx.SINFO, "We are using %s for this certificate.\n\n", cert.CertificateAuthority)
-		if addArecord {
-			commandContext.Statusf("certs", cmdctx.SINFO, "You can direct traffic to %s by:\n\n", hostname)
-			commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an A record to your DNS service which reads\n", stepcnt)
-			stepcnt = stepcnt + 1
-			commandContext.Statusf("certs", cmdctx.SINFO, "\n    A @ %s\n\n", ipV4.Address)
+		records = append(records, certDNSRecord{Type: "CNAME", Name: name, Value: target, Purpose: "routing", Required: true, Configured: configured})
+	}
+
+	if cert.DNSValidationHostname != "" && cert.DNSValidationTarget != "" {
+		records = append(records, certDNSRecord{
+			Type:       "CNAME",
+			Name:       relativeDNSName(cert.DNSValidationHostname),
+			Value:      cert.DNSValidationTarget,
+			Purpose:    "acme challenge",
+			Required:   cert.IsWildcard,
+			Configured: cert.AcmeDNSConfigured,
+		})
+	}
+
+	return records
+}
+
+func isAppIP(address string, ips []api.IPAddress) bool {
+	for _, ip := range ips {
+		if net.ParseIP(address).Equal(net.ParseIP(ip.Address)) {
+			return true
 		}
+	}
+	return false
+}
+
+// relativeDNSName returns the name relative to its registered domain, as DNS
+// providers expect it, with @ for the domain itself
+func relativeDNSName(hostname string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimPrefix(hostname, "*."))
+	if err != nil || hostname == domain {
+		return "@"
+	}
+	return strings.TrimSuffix(hostname, "."+domain)
+}
+
+func printCertDNSRecords(commandContext *cmdctx.CmdContext, hostname string, cert *api.AppCertificate, records []certDNSRecord, ips []api.IPAddress) {
+	if len(ips) == 0 && (cert.IsApex || cert.IsWildcard) {
+		commandContext.Statusf("certs", cmdctx.SWARN, "%s has no IP addresses, allocate them with `flyctl ips allocate-v4` and `flyctl ips allocate-v6`\n\n", commandContext.AppName)
+	}
 
-		if addAAAArecord {
-			commandContext.Statusf("certs", cmdctx.SINFO, "You can validate your ownership of %s by:\n\n", hostname)
-			commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an AAAA record to your DNS service which reads:\n\n", stepcnt)
-			commandContext.Statusf("certs", cmdctx.SINFO, "    AAAA @ %s\n\n", ipV6.Address)
-			commandContext.Statusf("certs", cmdctx.SINFO, " OR \n\n")
-			commandContext.Statusf("certs", cmdctx.SINFO, "%d: Adding an CNAME record to your DNS service which reads:\n\n", stepcnt)
-			commandContext.Statusf("certs", cmdctx.SINFO, "    %s\n", cert.DNSValidationInstructions)
-			// stepcnt = stepcnt + 1 Uncomment if more steps
+	commandContext.Statusf("certs", cmdctx.SINFO, "We are using %s for this certificate.\n", readableCertAuthority(cert.CertificateAuthority))
+	if cert.DNSProvider != "" {
+		commandContext.Statusf("certs", cmdctx.SINFO, "Add these records for %s at your DNS provider (%s):\n\n", hostname, cert.DNSProvider)
+	} else {
+		commandContext.Statusf("certs", cmdctx.SINFO, "Add these records for %s at your DNS provider:\n\n", hostname)
+	}
+
+	table := helpers.MakeSimpleTable(commandContext.Out, []string{"Type", "Name", "Value", "Purpose", "Status"})
+	for _, record := range records {
+		status := "missing"
+		if record.Configured {
+			status = "configured"
+		} else if !record.Required {
+			status = "optional"
 		}
+		table.Append([]string{record.Type, record.Name, record.Value, record.Purpose, status})
+	}
+	table.Render()
+
+	if cert.IsWildcard {
+		commandContext.Statusf("certs", cmdctx.SINFO, "\nWildcard certificates are only issued once the acme challenge record is in place.\n")
 	} else {
-		// This is not an apex domain
-		// If A and AAAA record is not configured offer CNAME
+		commandContext.Statusf("certs", cmdctx.SINFO, "\nThe acme challenge record lets the certificate be issued before traffic is routed to the app.\n")
+	}
+}
 
-		nothingConfigured := !(configuredipV4 && configuredipV6)
-		onlyV4Configured := configuredipV4 && !configuredipV6
+// watchCertificate polls the certificate until it is issued or the command is interrupted
+func watchCertificate(commandContext *cmdctx.CmdContext, hostname string, cert *api.AppCertificate) error {
+	ctx := createCancellableContext()
+	status := cert.ClientStatus
 
-		if nothingConfigured || onlyV4Configured {
-			commandContext.Statusf("certs", cmdctx.SINFO, "You are creating a certificate for %s\n", hostname)
-			commandContext.Statusf("certs", cmdctx.SINFO, "We are using %s for this certificate.\n\n", readableCertAuthority(cert.CertificateAuthority))
+	commandContext.Statusf("certs", cmdctx.SBEGIN, "Waiting for the certificate for %s to be issued (%s)\n", hostname, status)
 
-			if nothingConfigured {
-				commandContext.Statusf("certs", cmdctx.SINFO, "You can configure your DNS for %s by:\n\n", hostname)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(10 * time.Second):
+		}
 
-				eTLD, _ := publicsuffix.EffectiveTLDPlusOne(hostname)
-				subdomainname := strings.TrimSuffix(hostname, eTLD)
-				commandContext.Statusf("certs", cmdctx.SINFO, "1: Adding an CNAME record to your DNS service which reads:\n")
-				commandContext.Statusf("certs", cmdctx.SINFO, "\n    CNAME %s %s.fly.dev\n", subdomainname, commandContext.AppName)
-			} else if onlyV4Configured {
-				commandContext.Statusf("certs", cmdctx.SINFO, "You can validate your ownership of %s by:\n\n", hostname)
+		cert, _, err := commandContext.Client.API().CheckAppCertificate(commandContext.AppName, hostname)
+		if err != nil {
+			return err
+		}
 
-				commandContext.Statusf("certs", cmdctx.SINFO, "1: Adding an CNAME record to your DNS service which reads:\n")
-				commandContext.Statusf("certs", cmdctx.SINFO, "    %s\n", cert.DNSValidationInstructions)
-			}
-		} else {
-			if cert.ClientStatus == "Ready" {
-				commandContext.Statusf("certs", cmdctx.SINFO, "Your certificate for %s has been issued\n", hostname)
-			} else {
-				commandContext.Statusf("certs", cmdctx.SINFO, "Your certificate for %s is being issued. Status is %s.\n", hostname, cert.ClientStatus)
-			}
+		if cert.ClientStatus == "Ready" {
+			commandContext.Statusf("certs", cmdctx.SDONE, "The certificate for %s has been issued\n", hostname)
+			return nil
 		}
-	}
 
-	return nil
+		if cert.ClientStatus != status {
+			status = cert.ClientStatus
+			commandContext.Statusf("certs", cmdctx.SINFO, "Certificate status is %s\n", status)
+		}
+	}
 }
 
 func printCertificate(commandContext *cmdctx.CmdContext, cert *api.AppCertificate) {
@@ -311,9 +358,10 @@ func readableCertAuthority(ca string) string {
 }
 
 func printCertificates(commandContext *cmdctx.CmdContext, certs []api.AppCertificateCompact) error {
-	if commandContext.OutputJSON() {
-		commandContext.WriteJSON(certs)
-		return nil
+	if commandContext.OutputJSON() || commandContext.OutputCSV() {
+		return commandContext.Frender(cmdctx.PresenterOption{
+			Presentable: &presenters.Certificates{Certificates: certs},
+		})
 	}
 
 	commandContext.Statusf("certs", cmdctx.STITLE, "%-25s %-20s %s\n", "Host Name", "Added", "Status")
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -22,12 +24,17 @@ func newChecksCommand(client *client.Client) *Command {
 
 	handlersListStrings := docstrings.Get("checks.handlers.list")
 	listHandlersCmd := BuildCommandKS(handlersCmd, runListChecksHandlers, handlersListStrings, client, requireSession)
-	listHandlersCmd.Args = cobra.ExactArgs(1)
+	listHandlersCmd.Args = cobra.MaximumNArgs(1)
+	listHandlersCmd.AddStringFlag(StringFlagOpts{Name: "organization", Shorthand: "o", Description: "The organization to list handlers for"})
 
 	handlersCreateStrings := docstrings.Get("checks.handlers.create")
 	createHandlersCmd := BuildCommandKS(handlersCmd, runCreateChecksHandler, handlersCreateStrings, client, requireSession)
 	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "type", Description: "The type of handler to create, can be slack or pagerduty"})
 	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "organization", Shorthand: "o", Description: "The organization to add the handler to"})
+	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "The name of the handler"})
+	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "webhook-url", Description: "The Slack webhook URL to post to"})
+	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "slack-channel", Description: "The Slack channel to post to, defaults to the webhook's channel"})
+	createHandlersCmd.AddStringFlag(StringFlagOpts{Name: "pagerduty-token", Description: "The PagerDuty integration key to trigger incidents with"})
 
 	handlersDeleteStrings := docstrings.Get("checks.handlers.delete")
 	deleteHandlerCmd := BuildCommandKS(handlersCmd, runDeleteChecksHandler, handlersDeleteStrings, client, requireSession)
@@ -36,12 +43,24 @@ func newChecksCommand(client *client.Client) *Command {
 	checksListStrings := docstrings.Get("checks.list")
 	listChecksCmd := BuildCommandKS(cmd, runAppCheckList, checksListStrings, client, requireSession, requireAppName)
 	listChecksCmd.AddStringFlag(StringFlagOpts{Name: "check-name", Description: "Filter checks by name"})
+	listChecksCmd.AddStringFlag(StringFlagOpts{Name: "status", Description: "Filter checks by status: passing, warning or critical"})
+	listChecksCmd.AddBoolFlag(BoolFlagOpts{Name: "full-output", Description: "Show the checks' complete last output rather than a summary"})
 
 	return cmd
 }
 
 func runListChecksHandlers(ctx *cmdctx.CmdContext) error {
-	slug := ctx.Args[0]
+	slug := ctx.Config.GetString("organization")
+	if len(ctx.Args) > 0 {
+		slug = ctx.Args[0]
+	}
+	if slug == "" {
+		org, err := selectOrganization(ctx.Client.API(), "", nil)
+		if err != nil {
+			return err
+		}
+		slug = org.Slug
+	}
 
 	handlers, err := ctx.Client.API().GetHealthCheckHandlers(slug)
 	if err != nil {
@@ -92,10 +111,11 @@ func runCreateChecksHandler(ctx *cmdctx.CmdContext) error {
 		prompt := &survey.Input{
 			Message: "Name:",
 		}
-		if err := survey.AskOne(prompt, &name, survey.WithValidator(survey.Required)); err != nil {
+		if err := ask(prompt, &name, survey.WithValidator(survey.Required)); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -108,10 +128,11 @@ func setSlackChecksHandler(ctx *cmdctx.CmdContext, org *api.Organization, name s
 		prompt := &survey.Input{
 			Message: "Webhook URL:",
 		}
-		if err := survey.AskOne(prompt, &webhookURL, survey.WithValidator(survey.Required)); err != nil {
+		if err := ask(prompt, &webhookURL, survey.WithValidator(survey.Required)); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -120,10 +141,11 @@ func setSlackChecksHandler(ctx *cmdctx.CmdContext, org *api.Organization, name s
 		prompt := &survey.Input{
 			Message: "Slack Channel (defaults to webhook's configured channel):",
 		}
-		if err := survey.AskOne(prompt, &slackChannel); err != nil {
+		if err := ask(prompt, &slackChannel); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -183,10 +205,11 @@ func setPagerDutyChecksHandler(ctx *cmdctx.CmdContext, org *api.Organization, na
 		prompt := &survey.Input{
 			Message: "PagerDuty Token:",
 		}
-		if err := survey.AskOne(prompt, &pagerDutyToken, survey.WithValidator(survey.Required)); err != nil {
+		if err := ask(prompt, &pagerDutyToken, survey.WithValidator(survey.Required)); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	}
 
@@ -232,15 +255,32 @@ func runAppCheckList(ctx *cmdctx.CmdContext) error {
 		nameFilter = api.StringPointer(val)
 	}
 
-	checks, err := ctx.Client.API().GetAppHealthChecks(ctx.AppName, nameFilter, nil, api.BoolPointer(true))
+	compact := !ctx.Config.GetBool("full-output")
+
+	checks, err := ctx.Client.API().GetAppHealthChecks(ctx.AppName, nameFilter, nil, api.BoolPointer(compact))
 	if err != nil {
 		return err
 	}
 
-	if err != nil {
-		return err
+	if status := ctx.Config.GetString("status"); status != "" {
+		filtered := []api.CheckState{}
+		for _, check := range checks {
+			if strings.EqualFold(check.Status, status) {
+				filtered = append(filtered, check)
+			}
+		}
+		checks = filtered
 	}
 
+	// group each instance's checks together
+	sort.SliceStable(checks, func(i, j int) bool {
+		a, b := checkAllocationID(checks[i]), checkAllocationID(checks[j])
+		if a != b {
+			return a < b
+		}
+		return checks[i].Name < checks[j].Name
+	})
+
 	if ctx.OutputJSON() {
 		ctx.WriteJSON(checks)
 		return nil
@@ -248,13 +288,28 @@ func runAppCheckList(ctx *cmdctx.CmdContext) error {
 
 	fmt.Fprintf(ctx.Out, "Health Checks for %s\n", ctx.AppName)
 
-	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Status", "Allocation", "Region", "Type", "Last Updated", "Output"})
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Status", "Allocation", "Region", "Type", "Since", "Last Updated", "Output"})
 
 	for _, check := range checks {
-		table.Append([]string{check.Name, check.Status, check.Allocation.IDShort, check.Allocation.Region, check.Type, presenters.FormatRelativeTime(check.UpdatedAt), check.Output})
+		region := ""
+		if check.Allocation != nil {
+			region = check.Allocation.Region
+		}
+		since := ""
+		if !check.StatusChangedAt.IsZero() {
+			since = presenters.FormatRelativeTime(check.StatusChangedAt)
+		}
+		table.Append([]string{check.Name, check.Status, checkAllocationID(check), region, check.Type, since, presenters.FormatRelativeTime(check.UpdatedAt), check.Output})
 	}
 
 	table.Render()
 
 	return nil
 }
+
+func checkAllocationID(check api.CheckState) string {
+	if check.Allocation == nil {
+		return ""
+	}
+	return check.Allocation.IDShort
+}
@@ -7,7 +7,9 @@ import (
 	"os/signal"
 	"path"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
@@ -18,6 +20,8 @@ import (
 	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdlog"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/terminal"
 )
 
@@ -190,7 +194,7 @@ func BuildCommand(parent *Command, fn RunFn, usageText string, shortHelpText str
 	}
 
 	if fn != nil {
-		flycmd.RunE = func(cmd *cobra.Command, args []string) error {
+		run := func(cmd *cobra.Command, args []string) error {
 			ctx, err := cmdctx.NewCmdContext(client, namespace(cmd), args)
 			if err != nil {
 				return err
@@ -204,6 +208,14 @@ func BuildCommand(parent *Command, fn RunFn, usageText string, shortHelpText str
 				}
 			}
 
+			if err := applyFlagDefaults(cmd, ctx.AppConfig); err != nil {
+				return err
+			}
+
+			if ctx.Verbosity() >= cmdctx.VerbosityDebug && !terminal.IsDebug() {
+				terminal.SetLogLevel(terminal.LevelDebug)
+			}
+
 			terminal.Debugf("Working Directory: %s\n", ctx.WorkingDir)
 			terminal.Debugf("App Config File: %s\n", ctx.ConfigFile)
 
@@ -217,19 +229,135 @@ func BuildCommand(parent *Command, fn RunFn, usageText string, shortHelpText str
 
 			return fn(ctx)
 		}
+
+		flycmd.RunE = func(cmd *cobra.Command, args []string) error {
+			start := time.Now()
+			err := run(cmd, args)
+			logCommand(cmd, start, err)
+			return err
+		}
 	}
 
 	return flycmd
 }
 
+// logCommand records the command in the log included in support bundles
+func logCommand(cmd *cobra.Command, start time.Time, err error) {
+	entry := cmdlog.Entry{
+		Time:     start,
+		Version:  flyctl.Version,
+		Command:  cmd.CommandPath(),
+		Args:     os.Args[1:],
+		Duration: time.Since(start).Round(time.Millisecond),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ExitCode = flyerr.ExitCode(err)
+	}
+
+	if err := cmdlog.Append(flyctl.ConfigDir(), entry); err != nil {
+		terminal.Debug("error writing command log:", err)
+	}
+}
+
 const defaultConfigFilePath = "./fly.toml"
 
+// configDefaultFlags - the flags [cli.flags] can set defaults for, by
+// command. Flags with the same name mean different things across commands,
+// such as --region on launch and on logs, so only these take defaults.
+var configDefaultFlags = map[string][]string{
+	"deploy":          {"remote-only", "local-only", "strategy", "detach", "dockerfile", "build-target", "build-arg", "env", "builder", "image-label", "remote-builder-app", "remote-builder-size", "remote-builder-memory", "remote-builder-disk", "sbom", "sbom-format"},
+	"build":           {"remote-only", "local-only", "dockerfile", "build-target", "build-arg", "builder"},
+	"launch":          {"org", "region", "remote-only", "local-only"},
+	"apps create":     {"org"},
+	"volumes create":  {"region", "size", "encrypted"},
+	"postgres create": {"organization", "region", "vm-size", "volume-size"},
+	"redis create":    {"organization", "region"},
+}
+
+// conflictingFlags - groups of flags that choose between the same options.
+// A default for one of them isn't applied when another was given, as the
+// flag given on the command line wins.
+var conflictingFlags = [][]string{
+	{"remote-only", "local-only", "build-mode", "remote-builder-app"},
+}
+
+// conflictingFlagGiven returns the flag given on cmd's command line that
+// conflicts with name, if there is one
+func conflictingFlagGiven(cmd *cobra.Command, name string) string {
+	for _, group := range conflictingFlags {
+		inGroup := false
+		for _, flag := range group {
+			inGroup = inGroup || flag == name
+		}
+		if !inGroup {
+			continue
+		}
+
+		for _, flag := range group {
+			if flag != name && cmd.Flags().Changed(flag) {
+				return flag
+			}
+		}
+	}
+	return ""
+}
+
+// acceptsConfigDefault is true when cmd's flag name is in configDefaultFlags
+func acceptsConfigDefault(cmd *cobra.Command, name string) bool {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	for _, flag := range configDefaultFlags[path] {
+		if flag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFlagDefaults sets flags that weren't given on the command line from the
+// [cli] section of the app config and then the user's config
+func applyFlagDefaults(cmd *cobra.Command, appConfig *flyctl.AppConfig) error {
+	cliConfig := flyctl.UserCLIConfig()
+	if appConfig != nil {
+		cliConfig = cliConfig.Merge(appConfig.CLI)
+	}
+
+	for name, value := range cliConfig.Flags {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if !acceptsConfigDefault(cmd, name) {
+			terminal.Debugf("Not using default --%s=%s from config, %s doesn't take a default for it\n", name, value, cmd.CommandPath())
+			continue
+		}
+		if given := conflictingFlagGiven(cmd, name); given != "" {
+			terminal.Debugf("Not using default --%s=%s from config, --%s was given\n", name, value, given)
+			continue
+		}
+
+		terminal.Debugf("Using default --%s=%s from config\n", name, value)
+
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("invalid default for --%s in config: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 func requireSession(cmd *Command) Initializer {
 	return Initializer{
 		PreRun: func(ctx *cmdctx.CmdContext) error {
-			if !ctx.Client.Authenticated() {
-				return client.ErrNoAuthToken
+			// credentials can come from flags, which are parsed after the
+			// client is created
+			if !ctx.Client.InitApi() {
+				if profile := flyctl.CurrentProfile(); profile != "" {
+					return flyerr.New(flyerr.Unauthorized, fmt.Sprintf("Not logged in to profile %s. Log in with 'flyctl auth login --profile %s'", profile, profile))
+				}
+				return flyerr.Wrap(flyerr.Unauthorized, client.ErrNoAuthToken)
 			}
+			ctx.Client.Reauthenticate = reauthenticate(cmd, ctx)
 			return nil
 		},
 	}
@@ -255,7 +383,7 @@ func requireAppName(cmd *Command) Initializer {
 	return Initializer{
 		Setup: func(ctx *cmdctx.CmdContext) error {
 			// resolve the config file path
-			configPath := ctx.Config.GetString("config")
+			configPath := appConfigPath(cmd, ctx)
 			if configPath == "" {
 				configPath = defaultConfigFilePath
 			}
@@ -277,7 +405,7 @@ func requireAppName(cmd *Command) Initializer {
 				terminal.Debug("Loading app config from", ctx.ConfigFile)
 				appConfig, err := flyctl.LoadAppConfig(ctx.ConfigFile)
 				if err != nil {
-					return err
+					return flyerr.Wrap(flyerr.InvalidConfig, err)
 				}
 				ctx.AppConfig = appConfig
 			} else {
@@ -296,7 +424,7 @@ func requireAppName(cmd *Command) Initializer {
 		},
 		PreRun: func(ctx *cmdctx.CmdContext) error {
 			if ctx.AppName == "" {
-				return fmt.Errorf("No app specified. Specify an app or create an app with '" + flyname.Name() + " init'")
+				return flyerr.New(flyerr.InvalidArgument, "No app specified. Specify an app or create an app with '"+flyname.Name()+" init'")
 			}
 
 			if ctx.AppConfig == nil {
@@ -316,6 +444,16 @@ func requireAppName(cmd *Command) Initializer {
 	}
 }
 
+// appConfigPath returns the config file given with --config or
+// FLY_APP_CONFIG. When neither is given it returns the working directory, so
+// its fly.toml, or else fly.yaml, fly.yml or fly.json, is used.
+func appConfigPath(cmd *Command, ctx *cmdctx.CmdContext) string {
+	if cmd.Flags().Changed("config") || os.Getenv("FLY_APP_CONFIG") != "" {
+		return ctx.Config.GetString("config")
+	}
+	return ctx.WorkingDir
+}
+
 func requireAppNameAsArg(cmd *Command) Initializer {
 	cmd.AddStringFlag(StringFlagOpts{
 		Name:        "app",
@@ -335,7 +473,7 @@ func requireAppNameAsArg(cmd *Command) Initializer {
 	return Initializer{
 		Setup: func(ctx *cmdctx.CmdContext) error {
 			// resolve the config file path
-			configPath := ctx.Config.GetString("config")
+			configPath := appConfigPath(cmd, ctx)
 			if configPath == "" {
 				configPath = defaultConfigFilePath
 			}
@@ -357,7 +495,7 @@ func requireAppNameAsArg(cmd *Command) Initializer {
 				terminal.Debug("Loading app config from", ctx.ConfigFile)
 				appConfig, err := flyctl.LoadAppConfig(ctx.ConfigFile)
 				if err != nil {
-					return err
+					return flyerr.Wrap(flyerr.InvalidConfig, err)
 				}
 				ctx.AppConfig = appConfig
 			} else {
@@ -380,7 +518,7 @@ func requireAppNameAsArg(cmd *Command) Initializer {
 			}
 
 			if ctx.AppName == "" {
-				return fmt.Errorf("No app specified")
+				return flyerr.New(flyerr.InvalidArgument, "No app specified")
 			}
 
 			if ctx.AppConfig == nil {
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/flyctl"
+)
+
+func newDeployFlagsCommand() *cobra.Command {
+	root := &cobra.Command{Use: "flyctl"}
+	deploy := &cobra.Command{Use: "deploy"}
+	deploy.Flags().Bool("remote-only", false, "")
+	deploy.Flags().Bool("local-only", false, "")
+	deploy.Flags().String("build-mode", "", "")
+	deploy.Flags().String("strategy", "", "")
+	root.AddCommand(deploy)
+	return deploy
+}
+
+func TestApplyFlagDefaultsSkipsConflictingDefaults(t *testing.T) {
+	appConfig := &flyctl.AppConfig{CLI: &flyctl.CLIConfig{Flags: map[string]string{
+		"remote-only": "true",
+		"strategy":    "rolling",
+	}}}
+
+	deploy := newDeployFlagsCommand()
+	require.NoError(t, deploy.Flags().Parse([]string{"--local-only"}))
+	require.NoError(t, applyFlagDefaults(deploy, appConfig))
+
+	remoteOnly, _ := deploy.Flags().GetBool("remote-only")
+	assert.False(t, remoteOnly)
+	strategy, _ := deploy.Flags().GetString("strategy")
+	assert.Equal(t, "rolling", strategy)
+
+	deploy = newDeployFlagsCommand()
+	require.NoError(t, deploy.Flags().Parse([]string{"--build-mode", "local"}))
+	require.NoError(t, applyFlagDefaults(deploy, appConfig))
+
+	remoteOnly, _ = deploy.Flags().GetBool("remote-only")
+	assert.False(t, remoteOnly)
+
+	deploy = newDeployFlagsCommand()
+	require.NoError(t, deploy.Flags().Parse(nil))
+	require.NoError(t, applyFlagDefaults(deploy, appConfig))
+
+	remoteOnly, _ = deploy.Flags().GetBool("remote-only")
+	assert.True(t, remoteOnly)
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/catalog"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func newCompletionCommand(client *client.Client) *Command {
+	completionStrings := docstrings.Get("completion")
+	cmd := BuildCommandKS(nil, nil, completionStrings, client)
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.ValidArgs = []string{"bash", "zsh", "fish", "powershell"}
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		root := c.Root()
+
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return root.GenZshCompletion(os.Stdout)
+		case "fish":
+			return root.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return root.GenPowerShellCompletionWithDesc(os.Stdout)
+		default:
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("unsupported shell %q, use bash, zsh, fish or powershell", args[0]))
+		}
+	}
+
+	return cmd
+}
+
+// completionTimeout - how long completions wait for the API before giving up,
+// so a slow network doesn't hang the shell
+const completionTimeout = 3 * time.Second
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// registerCompletions adds dynamic completion of apps, organizations, regions
+// and VM sizes to the --app, --org and --region flags, and to positional
+// arguments named after them in command usage, throughout the command tree
+func registerCompletions(root *cobra.Command, client *client.Client) {
+	completers := map[string]completionFunc{
+		"app":    completeApps(client),
+		"org":    completeOrgs(client),
+		"region": completeRegions(client),
+		"size":   completeVMSizes(client),
+	}
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, flag := range []string{"app", "org", "region"} {
+			if f := cmd.LocalNonPersistentFlags().Lookup(flag); f != nil && f.Value.Type() != "bool" {
+				cmd.RegisterFlagCompletionFunc(flag, completers[flag])
+			}
+		}
+
+		if cmd.ValidArgsFunction == nil && len(cmd.ValidArgs) == 0 {
+			if placeholders := usagePlaceholders(cmd); len(placeholders) > 0 {
+				cmd.ValidArgsFunction = positionalCompletion(placeholders, completers)
+			}
+		}
+
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+// usagePlaceholders - what kind of value each positional argument takes,
+// from the placeholders in the command's usage, like "add [org] REGION ...".
+// Arguments naming something new, such as the app for "apps create", aren't
+// completed.
+func usagePlaceholders(cmd *cobra.Command) []string {
+	fields := strings.Fields(cmd.Use)
+	if len(fields) < 2 {
+		return nil
+	}
+	creates := cmd.Name() == "create" || cmd.Name() == "init" || cmd.Name() == "check-name"
+
+	kinds := []string{}
+	found := false
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "-") {
+			break
+		}
+		if field == "..." {
+			if len(kinds) > 0 {
+				kinds = append(kinds, "...")
+			}
+			continue
+		}
+
+		kind := ""
+		switch strings.ToLower(strings.Trim(field, "[]<>")) {
+		case "org", "organization":
+			if !creates || cmd.Parent().Name() != "orgs" {
+				kind = "org"
+			}
+		case "region":
+			kind = "region"
+		case "appname":
+			if !creates {
+				kind = "app"
+			}
+		case "sizename":
+			kind = "size"
+		}
+		if kind != "" {
+			found = true
+		}
+		kinds = append(kinds, kind)
+	}
+
+	if !found {
+		return nil
+	}
+	return kinds
+}
+
+func positionalCompletion(kinds []string, completers map[string]completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		kind := ""
+		switch {
+		case len(args) < len(kinds) && kinds[len(args)] != "...":
+			kind = kinds[len(args)]
+		case kinds[len(kinds)-1] == "..." && len(kinds) > 1:
+			// the last placeholder repeats
+			kind = kinds[len(kinds)-2]
+		}
+
+		if completer, ok := completers[kind]; ok {
+			return completer(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeWithTimeout runs lookup, returning no completions if it fails or
+// takes longer than completionTimeout
+func completeWithTimeout(lookup func() ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	type result struct {
+		values []string
+		err    error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		values, err := lookup()
+		done <- result{values, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			cobra.CompDebugln(r.err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return r.values, cobra.ShellCompDirectiveNoFileComp
+	case <-time.After(completionTimeout):
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completeApps(client *client.Client) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWithTimeout(func() ([]string, error) {
+			if !client.InitApi() {
+				return nil, nil
+			}
+
+			apps, err := client.API().GetApps(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			names := []string{}
+			for _, app := range apps {
+				if strings.HasPrefix(app.Name, toComplete) {
+					names = append(names, fmt.Sprintf("%s\t%s", app.Name, app.Organization.Slug))
+				}
+			}
+			return names, nil
+		})
+	}
+}
+
+func completeOrgs(client *client.Client) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWithTimeout(func() ([]string, error) {
+			if !client.InitApi() {
+				return nil, nil
+			}
+
+			orgs, err := client.API().GetOrganizations(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			slugs := []string{}
+			for _, org := range orgs {
+				if strings.HasPrefix(org.Slug, toComplete) {
+					slugs = append(slugs, fmt.Sprintf("%s\t%s", org.Slug, org.Name))
+				}
+			}
+			return slugs, nil
+		})
+	}
+}
+
+// completionCatalog - the cached platform catalog, only fetched from the API
+// if there's no cached copy at all, so completion works offline
+func completionCatalog(client *client.Client) (*catalog.Catalog, error) {
+	c, err := catalog.Load(flyctl.ConfigDir())
+	if err != nil || c != nil {
+		return c, err
+	}
+
+	if !client.InitApi() {
+		return nil, nil
+	}
+	return catalog.Get(flyctl.ConfigDir(), client.API(), catalog.DefaultTTL)
+}
+
+func completeRegions(client *client.Client) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWithTimeout(func() ([]string, error) {
+			c, err := completionCatalog(client)
+			if err != nil || c == nil {
+				return nil, err
+			}
+
+			codes := []string{}
+			for _, region := range c.Regions {
+				if strings.HasPrefix(region.Code, toComplete) && !containsString(args, region.Code) {
+					codes = append(codes, fmt.Sprintf("%s\t%s", region.Code, region.Name))
+				}
+			}
+			return codes, nil
+		})
+	}
+}
+
+func completeVMSizes(client *client.Client) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWithTimeout(func() ([]string, error) {
+			c, err := completionCatalog(client)
+			if err != nil || c == nil {
+				return nil, err
+			}
+
+			names := []string{}
+			for _, size := range c.VMSizes {
+				if strings.HasPrefix(size.Name, toComplete) {
+					names = append(names, fmt.Sprintf("%s\t%s", size.Name, vmSizeDescription(size)))
+				}
+			}
+			return names, nil
+		})
+	}
+}
+
+func vmSizeDescription(size api.VMSize) string {
+	return fmt.Sprintf("%g CPU, %d MB", size.CPUCores, size.MemoryMB)
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/secretlint"
 
 	"github.com/superfly/flyctl/docstrings"
 
@@ -14,6 +18,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/terminal"
 )
 
 func newConfigCommand(client *client.Client) *Command {
@@ -25,6 +30,9 @@ func newConfigCommand(client *client.Client) *Command {
 	configDisplayStrings := docstrings.Get("config.display")
 	BuildCommandKS(cmd, runDisplayConfig, configDisplayStrings, client, requireSession, requireAppName)
 
+	configShowStrings := docstrings.Get("config.show")
+	BuildCommandKS(cmd, runShowConfig, configShowStrings, client, requireSession, requireAppName)
+
 	configSaveStrings := docstrings.Get("config.save")
 	BuildCommandKS(cmd, runSaveConfig, configSaveStrings, client, requireSession, requireAppName)
 
@@ -34,6 +42,20 @@ func newConfigCommand(client *client.Client) *Command {
 	configEnvStrings := docstrings.Get("config.env")
 	BuildCommandKS(cmd, runEnvConfig, configEnvStrings, client, requireSession, requireAppName)
 
+	configListStrings := docstrings.Get("config.list")
+	BuildCommandKS(cmd, runListConfigs, configListStrings, client)
+
+	configConvertStrings := docstrings.Get("config.convert")
+	convertCmd := BuildCommandKS(cmd, runConvertConfig, configConvertStrings, client, requireAppName)
+	convertCmd.AddStringFlag(StringFlagOpts{
+		Name:        "to",
+		Description: "The format to convert to: toml, yaml or json",
+	})
+	convertCmd.AddStringFlag(StringFlagOpts{
+		Name:        "file",
+		Description: "The file to write. Defaults to the config file's name with the new format's extension",
+	})
+
 	return cmd
 }
 
@@ -50,13 +72,49 @@ func runDisplayConfig(ctx *cmdctx.CmdContext) error {
 	return nil
 }
 
-func runSaveConfig(ctx *cmdctx.CmdContext) error {
-	configfilename, err := flyctl.ResolveConfigFileFromPath(ctx.WorkingDir)
+func runShowConfig(ctx *cmdctx.CmdContext) error {
+	cfg, err := ctx.Client.API().GetConfig(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(cfg.Definition)
+		return nil
+	}
+
+	appConfig := flyctl.NewAppConfig()
+	appConfig.AppName = ctx.AppName
+	appConfig.Definition = cfg.Definition
+
+	if err := appConfig.WriteTo(ctx.Out, flyctl.TOMLFormat); err != nil {
+		return err
+	}
 
+	statics, err := appConfig.Statics()
 	if err != nil {
 		return err
 	}
 
+	fmt.Fprintln(ctx.Out)
+	if len(statics) == 0 {
+		fmt.Fprintln(ctx.Out, "No statics, every request is served by the app")
+		return nil
+	}
+
+	fmt.Fprintln(ctx.Out, "Statics served by Fly's proxy:")
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"URL Prefix", "Guest Path"})
+	for _, static := range statics {
+		table.Append([]string{static.URLPrefix, static.GuestPath})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runSaveConfig(ctx *cmdctx.CmdContext) error {
+	configfilename := ctx.ConfigFile
+
 	if helpers.FileExists(configfilename) {
 		ctx.Status("create", cmdctx.SERROR, "An existing configuration file has been found.")
 		confirmation := confirm(fmt.Sprintf("Overwrite file '%s'", configfilename))
@@ -65,19 +123,35 @@ func runSaveConfig(ctx *cmdctx.CmdContext) error {
 		}
 	}
 
-	if ctx.AppConfig == nil {
-		ctx.AppConfig = flyctl.NewAppConfig()
+	serverCfg, err := ctx.Client.API().GetConfig(ctx.AppName)
+	if err != nil {
+		return err
 	}
-	ctx.AppConfig.AppName = ctx.AppName
 
-	serverCfg, err := ctx.Client.API().GetConfig(ctx.AppName)
+	appConfig, err := flyctl.AppConfigFromDefinition(ctx.AppName, serverCfg.Definition)
 	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+
+	// sections only flyctl reads aren't deployed, so they're kept from the
+	// existing file
+	if local := ctx.AppConfig; local != nil {
+		if appConfig.Build == nil {
+			appConfig.Build = local.Build
+		}
+		if appConfig.CLI == nil {
+			appConfig.CLI = local.CLI
+		}
+	}
+
+	if err := appConfig.WriteCanonicalToFile(ctx.ConfigFile); err != nil {
 		return err
 	}
+	ctx.AppConfig = appConfig
 
-	ctx.AppConfig.Definition = serverCfg.Definition
+	fmt.Println("Wrote config file", helpers.PathRelativeToCWD(ctx.ConfigFile))
 
-	return writeAppConfig(ctx.ConfigFile, ctx.AppConfig)
+	return nil
 }
 
 func runValidateConfig(commandContext *cmdctx.CmdContext) error {
@@ -87,12 +161,45 @@ func runValidateConfig(commandContext *cmdctx.CmdContext) error {
 
 	commandContext.Status("config", cmdctx.STITLE, "Validating", commandContext.ConfigFile)
 
+	if err := commandContext.AppConfig.ValidateProcesses(); err != nil {
+		return err
+	}
+
+	gpu, err := commandContext.AppConfig.GPU()
+	if err != nil {
+		return err
+	}
+	if gpu != nil {
+		if err := validateGPURegions(commandContext.Client.API(), commandContext.AppName, gpu.Kind); err != nil {
+			return err
+		}
+	}
+
+	if _, err := commandContext.AppConfig.Statics(); err != nil {
+		return err
+	}
+
+	services, err := commandContext.AppConfig.ServiceConfigs()
+	if err != nil {
+		return err
+	}
+	warnServiceProblems(commandContext, services)
+
+	dockerfilePath := ""
+	if build := commandContext.AppConfig.Build; build != nil && build.Dockerfile != "" {
+		dockerfilePath = build.Dockerfile
+		if !filepath.IsAbs(dockerfilePath) {
+			dockerfilePath = filepath.Join(filepath.Dir(commandContext.ConfigFile), dockerfilePath)
+		}
+	}
+	warnSecretLikeVariables(commandContext, dockerfilePath)
+
 	serverCfg, err := commandContext.Client.API().ParseConfig(commandContext.AppName, commandContext.AppConfig.Definition)
 	if err != nil {
 		return err
 	}
 
-	if commandContext.GlobalConfig.GetBool("verbose") {
+	if commandContext.Verbosity() >= cmdctx.VerbosityVerbose {
 		commandContext.WriteJSON(serverCfg.Definition)
 	}
 
@@ -106,6 +213,29 @@ func runValidateConfig(commandContext *cmdctx.CmdContext) error {
 	return errors.New("App configuration is not valid")
 }
 
+// warnSecretLikeVariables warns about env entries in the config and ENV or ARG
+// lines in the Dockerfile that look like secrets. An empty dockerfilePath
+// checks the Dockerfile in the working directory, if there is one.
+func warnSecretLikeVariables(ctx *cmdctx.CmdContext, dockerfilePath string) {
+	findings := secretlint.Check(filepath.Base(ctx.ConfigFile)+" [env]", ctx.AppConfig.EnvVariables())
+
+	if dockerfilePath == "" {
+		dockerfilePath = filepath.Join(ctx.WorkingDir, "Dockerfile")
+	}
+	if helpers.FileExists(dockerfilePath) {
+		dockerfileFindings, err := secretlint.CheckDockerfile(dockerfilePath)
+		if err != nil {
+			terminal.Debugf("error checking %s for secrets: %v\n", dockerfilePath, err)
+		}
+		findings = append(findings, dockerfileFindings...)
+	}
+
+	for _, finding := range findings {
+		ctx.Statusf("config", cmdctx.SWARN, "%s\n", finding)
+		ctx.Statusf("config", cmdctx.SWARN, "  Variables are stored in plain text. Use `flyctl secrets set %s=...` instead\n", finding.Name)
+	}
+}
+
 func runEnvConfig(ctx *cmdctx.CmdContext) error {
 	secrets, err := ctx.Client.API().GetAppSecrets(ctx.AppName)
 	if err != nil {
@@ -161,3 +291,131 @@ func writeAppConfig(path string, appConfig *flyctl.AppConfig) error {
 
 	return nil
 }
+
+// configFormats - the formats config convert writes, by --to
+var configFormats = map[string]flyctl.ConfigFormat{
+	"toml": flyctl.TOMLFormat,
+	"yaml": flyctl.YAMLFormat,
+	"yml":  flyctl.YAMLFormat,
+	"json": flyctl.JSONFormat,
+}
+
+func runConvertConfig(ctx *cmdctx.CmdContext) error {
+	if ctx.AppConfig == nil {
+		return errors.New("App config file not found")
+	}
+
+	to := strings.ToLower(ctx.Config.GetString("to"))
+	format, ok := configFormats[to]
+	if !ok {
+		return flyerr.New(flyerr.InvalidArgument, "--to must be one of toml, yaml or json")
+	}
+
+	outputPath := ctx.Config.GetString("file")
+	if outputPath == "" {
+		base := filepath.Base(ctx.ConfigFile)
+		outputPath = filepath.Join(filepath.Dir(ctx.ConfigFile), strings.TrimSuffix(base, filepath.Ext(base))+string(format))
+	} else if flyctl.ConfigFormatFromPath(outputPath) != format {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s doesn't have a %s extension", outputPath, format))
+	} else if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(ctx.WorkingDir, outputPath)
+	}
+
+	if outputPath == ctx.ConfigFile {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is already in %s format", helpers.PathRelativeToCWD(ctx.ConfigFile), to))
+	}
+
+	if helpers.FileExists(outputPath) {
+		if !confirm(fmt.Sprintf("Overwrite file '%s'", helpers.PathRelativeToCWD(outputPath))) {
+			return nil
+		}
+	}
+
+	if err := writeAppConfig(outputPath, ctx.AppConfig); err != nil {
+		return err
+	}
+
+	// a directory's config is the first of fly.toml, fly.yaml, fly.yml and
+	// fly.json, so a new default config is only read once the old one is removed
+	outputBase := filepath.Base(outputPath)
+	if strings.TrimSuffix(outputBase, filepath.Ext(outputBase)) == "fly" {
+		resolved, err := flyctl.ResolveConfigFileFromPath(filepath.Dir(outputPath))
+		if err == nil && resolved == ctx.ConfigFile {
+			fmt.Fprintf(ctx.Out, "%s is read before %s while both exist. Remove it to use the new file\n",
+				helpers.PathRelativeToCWD(resolved), helpers.PathRelativeToCWD(outputPath))
+		}
+	}
+
+	return nil
+}
+
+type configFileSummary struct {
+	Path      string
+	App       string
+	Build     string
+	Processes []string
+}
+
+func runListConfigs(ctx *cmdctx.CmdContext) error {
+	files, err := flyctl.FindConfigFiles(ctx.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	summaries := []configFileSummary{}
+	for _, file := range files {
+		summary := configFileSummary{Path: file, Processes: []string{}}
+		if rel, err := filepath.Rel(ctx.WorkingDir, file); err == nil {
+			summary.Path = rel
+		}
+
+		appConfig, err := flyctl.LoadAppConfig(file)
+		if err != nil {
+			terminal.Debugf("error loading %s: %v\n", file, err)
+			summary.App = "(invalid config)"
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		summary.App = appConfig.AppName
+		summary.Build = describeBuild(appConfig.Build)
+		summary.Processes = append(summary.Processes, appConfig.ProcessNames()...)
+
+		summaries = append(summaries, summary)
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(summaries)
+		return nil
+	}
+
+	if len(summaries) == 0 {
+		fmt.Printf("No fly*.toml, fly*.yaml or fly*.json files found in %s\n", ctx.WorkingDir)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Config", "App", "Build", "Processes"})
+	for _, summary := range summaries {
+		table.Append([]string{summary.Path, summary.App, summary.Build, strings.Join(summary.Processes, ", ")})
+	}
+	table.Render()
+
+	return nil
+}
+
+// describeBuild summarizes how a config's image is produced
+func describeBuild(build *flyctl.Build) string {
+	switch {
+	case build == nil:
+		return "Dockerfile"
+	case build.Image != "":
+		return "image " + build.Image
+	case build.Builder != "":
+		return "builder " + build.Builder
+	case build.Builtin != "":
+		return "builtin " + build.Builtin
+	case build.Dockerfile != "":
+		return build.Dockerfile
+	}
+	return "Dockerfile"
+}
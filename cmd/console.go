@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/wireguard"
+	"github.com/superfly/flyctl/pkg/wg"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newConsoleCommand(client *client.Client) *Command {
+	consoleStrings := docstrings.Get("console")
+	cmd := BuildCommandKS(nil, runConsole, consoleStrings, client, requireSession, requireAppName)
+	cmd.AddStringFlag(StringFlagOpts{Name: "image", Shorthand: "i", Description: "the image to run. defaults to the image of the app's current release"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "the region to run the console in"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "command", Shorthand: "C", Description: "the command to run in the console", Default: "/bin/sh"})
+	cmd.AddIntFlag(IntFlagOpts{Name: "cpus", Description: "number of CPUs"})
+	cmd.AddIntFlag(IntFlagOpts{Name: "memory", Description: "memory in MB"})
+
+	return cmd
+}
+
+func runConsole(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	app, err := client.GetApp(ctx.AppName)
+	if err != nil {
+		return fmt.Errorf("get app: %w", err)
+	}
+
+	image := ctx.Config.GetString("image")
+	if image == "" {
+		release, err := client.GetAppCurrentRelease(ctx.AppName)
+		if err != nil {
+			return fmt.Errorf("get current release: %w", err)
+		}
+		if release == nil || release.ImageRef == "" {
+			return fmt.Errorf("%s has no released image, pass one with --image", ctx.AppName)
+		}
+		image = release.ImageRef
+	}
+
+	input := api.LaunchMachineInput{
+		AppID:  ctx.AppName,
+		Name:   fmt.Sprintf("console-%d", time.Now().Unix()),
+		Region: ctx.Config.GetString("region"),
+		Config: api.MachineConfig{
+			Image: image,
+			// keep the machine alive for as long as the console is attached
+			Cmd: []string{"sleep", "inf"},
+		},
+	}
+
+	if cpus, memory := ctx.Config.GetInt("cpus"), ctx.Config.GetInt("memory"); cpus != 0 || memory != 0 {
+		input.Config.Guest = &api.MachineGuest{CPUs: cpus, MemoryMB: memory}
+	}
+
+	fmt.Printf("Launching console machine with %s\n", image)
+
+	machine, err := client.LaunchMachine(input)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := client.RemoveMachine(ctx.AppName, machine.ID, true); err != nil {
+			terminal.Warnf("Failed to remove console machine %s, remove it with `flyctl machine remove --force %s`: %v\n", machine.ID, machine.ID, err)
+			return
+		}
+		fmt.Printf("Console machine %s removed\n", machine.ID)
+	}()
+
+	cancelCtx := createCancellableContext()
+	if err := waitForMachineHealthy(cancelCtx, client, ctx.AppName, machine.ID, "", 2*time.Minute, false); err != nil {
+		if isCancelledError(err) {
+			return nil
+		}
+		return fmt.Errorf("console machine %s didn't start: %w", machine.ID, err)
+	}
+
+	addr := machinePrivateIP(machine)
+	if addr == "" {
+		return fmt.Errorf("console machine %s has no private IP", machine.ID)
+	}
+
+	state, err := wireguard.StateForOrg(client, &app.Organization, ctx.Config.GetString("region"), "")
+	if err != nil {
+		return fmt.Errorf("create wireguard config: %w", err)
+	}
+
+	terminal.Debugf("Establishing WireGuard connection (%s)\n", state.Name)
+
+	tunnel, err := wg.Connect(*state.TunnelConfig())
+	if err != nil {
+		return fmt.Errorf("connect wireguard: %w", err)
+	}
+	defer tunnel.Close()
+
+	return sshConnect(&SSHParams{
+		Ctx:    ctx,
+		Org:    &app.Organization,
+		Tunnel: tunnel,
+		App:    ctx.AppName,
+		Cmd:    ctx.Config.GetString("command"),
+	}, fmt.Sprintf("[%s]", addr))
+}
+
+// machinePrivateIP returns the machine's address on the organization's private network
+func machinePrivateIP(machine *api.Machine) string {
+	for _, ip := range machine.IPs.Nodes {
+		if ip.Kind == "privatenet" && ip.Family == "v6" {
+			return ip.IP
+		}
+	}
+	return ""
+}
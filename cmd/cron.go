@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/cron"
+)
+
+func newCronCommand(client *client.Client) *Command {
+	cronStrings := docstrings.Get("cron")
+	cmd := BuildCommandKS(nil, nil, cronStrings, client, requireSession)
+
+	createStrings := docstrings.Get("cron.create")
+	createCmd := BuildCommandKS(cmd, runCronCreate, createStrings, client, requireSession, requireAppName)
+	createCmd.Args = cobra.ExactArgs(1)
+	createCmd.AddStringFlag(StringFlagOpts{Name: "command", Shorthand: "C", Description: "the command to run on each run"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the scheduled machine"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "image", Shorthand: "i", Description: "the image to run. defaults to the image of the app's current release"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "the region to run in"})
+	createCmd.AddIntFlag(IntFlagOpts{Name: "cpus", Description: "number of CPUs"})
+	createCmd.AddIntFlag(IntFlagOpts{Name: "memory", Description: "memory in MB"})
+	createCmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "env",
+		Shorthand:   "e",
+		Description: "Set of environment variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+	})
+
+	listStrings := docstrings.Get("cron.list")
+	BuildCommandKS(cmd, runCronList, listStrings, client, requireSession, requireAppName)
+
+	historyStrings := docstrings.Get("cron.history")
+	historyCmd := BuildCommandKS(cmd, runCronHistory, historyStrings, client, requireSession, requireAppName)
+	historyCmd.Args = cobra.ExactArgs(1)
+	historyCmd.AddIntFlag(IntFlagOpts{Name: "limit", Description: "number of runs to show", Default: 10})
+	historyCmd.AddBoolFlag(BoolFlagOpts{Name: "logs", Description: "show recent logs of the scheduled machine"})
+
+	deleteStrings := docstrings.Get("cron.delete")
+	deleteCmd := BuildCommandKS(cmd, runCronDelete, deleteStrings, client, requireSession, requireAppName)
+	deleteCmd.Aliases = []string{"rm"}
+	deleteCmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runCronCreate(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	schedule, err := cron.Parse(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	command, err := shlex.Split(ctx.Config.GetString("command"))
+	if err != nil {
+		return fmt.Errorf("invalid command: %w", err)
+	}
+	if len(command) == 0 {
+		return fmt.Errorf("a command is required, pass one with --command")
+	}
+
+	image := ctx.Config.GetString("image")
+	if image == "" {
+		release, err := client.GetAppCurrentRelease(ctx.AppName)
+		if err != nil {
+			return fmt.Errorf("get current release: %w", err)
+		}
+		if release == nil || release.ImageRef == "" {
+			return fmt.Errorf("%s has no released image, pass one with --image", ctx.AppName)
+		}
+		image = release.ImageRef
+	}
+
+	input := api.LaunchMachineInput{
+		AppID:  ctx.AppName,
+		Name:   ctx.Config.GetString("name"),
+		Region: ctx.Config.GetString("region"),
+		Config: api.MachineConfig{
+			Image:    image,
+			Cmd:      command,
+			Restart:  &api.MachineRestart{Policy: "no"},
+			Schedule: schedule.Expr,
+		},
+	}
+	if input.Name == "" {
+		input.Name = fmt.Sprintf("cron-%d", time.Now().Unix())
+	}
+
+	if env := ctx.Config.GetStringSlice("env"); len(env) > 0 {
+		parsedEnv, err := cmdutil.ParseKVStringsToMap(env)
+		if err != nil {
+			return fmt.Errorf("invalid env: %w", err)
+		}
+		input.Config.Env = parsedEnv
+	}
+
+	if cpus, memory := ctx.Config.GetInt("cpus"), ctx.Config.GetInt("memory"); cpus != 0 || memory != 0 {
+		input.Config.Guest = &api.MachineGuest{CPUs: cpus, MemoryMB: memory}
+	}
+
+	machine, err := client.LaunchMachine(input)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(machine)
+		return nil
+	}
+
+	fmt.Printf("Scheduled machine %s created in %s\n", machine.ID, machine.Region)
+	fmt.Printf("  Name:      %s\n", machine.Name)
+	fmt.Printf("  Schedule:  %s\n", schedule.Expr)
+	fmt.Printf("  Command:   %s\n", strings.Join(command, " "))
+	fmt.Printf("  Image:     %s\n", image)
+	fmt.Printf("  Next run:  %s\n", formatNextRun(schedule))
+
+	return nil
+}
+
+func runCronList(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	machines, err := client.ListMachines(ctx.AppName, "")
+	if err != nil {
+		return err
+	}
+
+	scheduled := []api.Machine{}
+	for _, machine := range machines {
+		if machine.Config.Schedule != "" && machine.State != "destroyed" {
+			scheduled = append(scheduled, machine)
+		}
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(scheduled)
+		return nil
+	}
+
+	if len(scheduled) == 0 {
+		fmt.Printf("No scheduled machines found for %s\n", ctx.AppName)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"ID", "Name", "Schedule", "Command", "Next Run", "Last Run", "Last Status"})
+	for _, machine := range scheduled {
+		nextRun := ""
+		if schedule, err := cron.Parse(machine.Config.Schedule); err == nil {
+			nextRun = formatNextRun(schedule)
+		}
+
+		lastRun, lastStatus := "never", ""
+		runs, err := client.GetMachineRuns(ctx.AppName, machine.ID, 1)
+		if err != nil {
+			return err
+		}
+		if len(runs) > 0 {
+			lastRun = humanize.Time(runs[0].StartedAt)
+			lastStatus = formatMachineRunStatus(runs[0])
+		}
+
+		table.Append([]string{
+			machine.ID,
+			machine.Name,
+			machine.Config.Schedule,
+			strings.Join(machine.Config.Cmd, " "),
+			nextRun,
+			lastRun,
+			lastStatus,
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runCronHistory(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	machine, err := getScheduledMachine(client, ctx.AppName, ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	runs, err := client.GetMachineRuns(ctx.AppName, machine.ID, ctx.Config.GetInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(runs)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Runs of %s (%s) on %s\n", machine.ID, machine.Name, machine.Config.Schedule)
+
+	if len(runs) == 0 {
+		fmt.Fprintln(ctx.Out, "No runs yet")
+	} else {
+		table := helpers.MakeSimpleTable(ctx.Out, []string{"Started", "Duration", "Status", "Exit Code"})
+		for _, run := range runs {
+			duration := ""
+			if run.FinishedAt != nil {
+				duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String()
+			}
+			exitCode := ""
+			if run.ExitCode != nil {
+				exitCode = strconv.Itoa(*run.ExitCode)
+			}
+			table.Append([]string{run.StartedAt.Format(time.RFC3339), duration, formatMachineRunStatus(run), exitCode})
+		}
+		table.Render()
+	}
+
+	if ctx.Config.GetBool("logs") {
+		entries, _, err := client.GetAppLogs(ctx.AppName, "", "", machine.ID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(ctx.Out)
+		logPresenter := presenters.LogPresenter{HideAllocID: true}
+		logPresenter.FPrint(ctx.Out, false, entries)
+	}
+
+	return nil
+}
+
+func runCronDelete(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	machine, err := getScheduledMachine(client, ctx.AppName, ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Delete scheduled machine %s (%s) running on %s?", machine.ID, machine.Name, machine.Config.Schedule)) {
+		return nil
+	}
+
+	if err := client.RemoveMachine(ctx.AppName, machine.ID, true); err != nil {
+		return err
+	}
+
+	fmt.Printf("Scheduled machine %s deleted\n", machine.ID)
+
+	return nil
+}
+
+// getScheduledMachine returns the machine, failing if it isn't run on a schedule
+func getScheduledMachine(client *api.Client, appName string, machineID string) (*api.Machine, error) {
+	machine, err := client.GetMachine(appName, machineID)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, fmt.Errorf("machine %s not found", machineID)
+	}
+	if machine.Config.Schedule == "" {
+		return nil, fmt.Errorf("machine %s is not scheduled, manage it with `flyctl machine`", machineID)
+	}
+
+	return machine, nil
+}
+
+func formatNextRun(schedule *cron.Schedule) string {
+	next := schedule.Next(time.Now().UTC())
+	if next.IsZero() {
+		return "never"
+	}
+	return next.Format(time.RFC3339)
+}
+
+func formatMachineRunStatus(run api.MachineRun) string {
+	if run.FinishedAt == nil {
+		return "running"
+	}
+	if run.ExitCode != nil && *run.ExitCode == 0 {
+		return "succeeded"
+	}
+	return "failed"
+}
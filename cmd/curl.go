@@ -55,7 +55,7 @@ type TimingRequest struct {
 }
 
 func TimeRegions(ctx *cmdctx.CmdContext, url string, includeNoGateway bool) ([]api.Region, <-chan TimingResponse, error) {
-	regions, _, err := ctx.Client.API().PlatformRegions()
+	regions, _, err := platformRegions(ctx.Client.API())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2,9 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/logrusorgru/aurora"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdfmt"
 
 	"github.com/superfly/flyctl/docstrings"
 
@@ -17,7 +25,22 @@ func newDashboardCommand(client *client.Client) *Command {
 	dashboardCmd.Aliases = []string{"dash"}
 
 	dashMetricsStrings := docstrings.Get("dashboard.metrics")
-	BuildCommandKS(dashboardCmd, runDashboardMetrics, dashMetricsStrings, client, requireSession, requireAppName)
+	dashMetricsCmd := BuildCommandKS(dashboardCmd, runDashboardMetrics, dashMetricsStrings, client, requireSession, requireAppName)
+	dashMetricsCmd.AddStringFlag(StringFlagOpts{
+		Name:        "window",
+		Shorthand:   "w",
+		Description: "Time window to chart, e.g. 15m, 1h, 24h",
+		Default:     "1h",
+	})
+	dashMetricsCmd.AddStringFlag(StringFlagOpts{
+		Name:        "instance",
+		Shorthand:   "i",
+		Description: "Filter by instance ID",
+	})
+	dashMetricsCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "web",
+		Description: "Open the metrics dashboard in a web browser instead",
+	})
 
 	return dashboardCmd
 }
@@ -32,15 +55,158 @@ func runDashboard(ctx *cmdctx.CmdContext) error {
 	return runDashboardOpen(ctx, dashURL)
 }
 
+// metricsChartPoints - number of points plotted for each series
+const metricsChartPoints = 60
+
+type metricsChart struct {
+	Name   string
+	Title  string
+	Query  string
+	Format func(float64) string
+}
+
+func appMetricsCharts(appName string, rateWindow string) []metricsChart {
+	formatBytes := func(v float64) string { return humanize.Bytes(uint64(v)) }
+	formatRate := func(v float64) string { return humanize.Bytes(uint64(v)) + "/s" }
+
+	return []metricsChart{
+		{
+			Name:   "cpu",
+			Title:  "CPU (cores)",
+			Query:  fmt.Sprintf(`sum by (instance, region) (rate(fly_instance_cpu{app="%s", mode!="idle"}[%s])) / 100`, appName, rateWindow),
+			Format: func(v float64) string { return fmt.Sprintf("%.2f", v) },
+		},
+		{
+			Name:   "memory",
+			Title:  "Memory (RSS)",
+			Query:  fmt.Sprintf(`sum by (instance, region) (fly_instance_memory_mem_total{app="%s"} - fly_instance_memory_mem_available{app="%s"})`, appName, appName),
+			Format: formatBytes,
+		},
+		{
+			Name:   "net_recv",
+			Title:  "Network received",
+			Query:  fmt.Sprintf(`sum by (instance, region) (rate(fly_instance_net_recv_bytes{app="%s", device="eth0"}[%s]))`, appName, rateWindow),
+			Format: formatRate,
+		},
+		{
+			Name:   "net_sent",
+			Title:  "Network sent",
+			Query:  fmt.Sprintf(`sum by (instance, region) (rate(fly_instance_net_sent_bytes{app="%s", device="eth0"}[%s]))`, appName, rateWindow),
+			Format: formatRate,
+		},
+	}
+}
+
 func runDashboardMetrics(ctx *cmdctx.CmdContext) error {
 	app, err := ctx.Client.API().GetApp(ctx.AppName)
 	if err != nil {
 		return err
 	}
 
-	metricsURL := "https://fly.io/apps/" + app.Name + "/metrics"
+	if ctx.Config.GetBool("web") {
+		metricsURL := "https://fly.io/apps/" + app.Name + "/metrics"
+		return runDashboardOpen(ctx, metricsURL)
+	}
+
+	return chartAppMetrics(ctx, app, appMetricsCharts)
+}
+
+// chartAppMetrics queries and charts each of the app's metrics over the --window
+// and --instance flags of the command
+func chartAppMetrics(ctx *cmdctx.CmdContext, app *api.App, chartsFn func(appName string, rateWindow string) []metricsChart) error {
+	window, err := time.ParseDuration(ctx.Config.GetString("window"))
+	if err != nil {
+		return fmt.Errorf("invalid window: %s", err)
+	}
+
+	step := window / metricsChartPoints
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	rateWindow := step
+	if rateWindow < time.Minute {
+		rateWindow = time.Minute
+	}
+
+	end := time.Now()
+	start := end.Add(-window)
+	instanceFilter := ctx.Config.GetString("instance")
+	cancelCtx := createCancellableContext()
+
+	results := map[string][]api.PromSeries{}
+	charts := chartsFn(app.Name, fmt.Sprintf("%ds", int(rateWindow.Seconds())))
+
+	for _, chart := range charts {
+		series, err := ctx.Client.API().PromQueryRange(cancelCtx, app.Organization.Slug, chart.Query, start, end, step)
+		if err != nil {
+			return err
+		}
+
+		filtered := []api.PromSeries{}
+		for _, s := range series {
+			if instanceFilter == "" || s.Labels["instance"] == instanceFilter {
+				filtered = append(filtered, s)
+			}
+		}
+		sort.Slice(filtered, func(i, j int) bool { return filtered[i].Labels["instance"] < filtered[j].Labels["instance"] })
+
+		results[chart.Name] = filtered
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(results)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "%s %s %s\n\n", aurora.Bold(app.Name), aurora.Italic("last"), aurora.Bold(window))
+
+	for _, chart := range charts {
+		fmt.Fprintln(ctx.Out, aurora.Bold(chart.Title))
+
+		series := results[chart.Name]
+		if len(series) == 0 {
+			fmt.Fprintln(ctx.Out, "No data")
+			fmt.Fprintln(ctx.Out)
+			continue
+		}
+
+		table := helpers.MakeSimpleTable(ctx.Out, []string{"Instance", "Region", "", "Last", "Max"})
+		for _, s := range series {
+			values := bucketSamples(s.Samples, start, step, metricsChartPoints)
+
+			var last, max float64
+			for _, sample := range s.Samples {
+				last = sample.Value
+				max = math.Max(max, sample.Value)
+			}
+
+			table.Append([]string{s.Labels["instance"], s.Labels["region"], cmdfmt.Sparkline(values), chart.Format(last), chart.Format(max)})
+		}
+		table.Render()
+		fmt.Fprintln(ctx.Out)
+	}
+
+	return nil
+}
+
+// bucketSamples spreads samples over a fixed number of points starting at start, leaving gaps as NaN
+func bucketSamples(samples []api.PromSample, start time.Time, step time.Duration, points int) []float64 {
+	values := make([]float64, points)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+
+	for _, sample := range samples {
+		idx := int(sample.Time.Sub(start) / step)
+		if idx >= points {
+			idx = points - 1
+		}
+		if idx >= 0 {
+			values[idx] = sample.Value
+		}
+	}
 
-	return runDashboardOpen(ctx, metricsURL)
+	return values
 }
 
 func runDashboardOpen(ctx *cmdctx.CmdContext, url string) error {
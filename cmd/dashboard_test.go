@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestBucketSamples(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	step := time.Minute
+
+	assert.Len(t, bucketSamples(nil, start, step, 3), 3)
+	for _, v := range bucketSamples(nil, start, step, 3) {
+		assert.True(t, math.IsNaN(v))
+	}
+
+	values := bucketSamples([]api.PromSample{
+		{Time: start.Add(-time.Minute), Value: 9},
+		{Time: start, Value: 1},
+		{Time: start.Add(90 * time.Second), Value: 2},
+		{Time: start.Add(10 * time.Minute), Value: 4},
+	}, start, step, 4)
+
+	assert.Equal(t, 1.0, values[0])
+	assert.Equal(t, 2.0, values[1])
+	assert.True(t, math.IsNaN(values[2]))
+	// samples past the last point are kept in it
+	assert.Equal(t, 4.0, values[3])
+}
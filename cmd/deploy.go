@@ -2,43 +2,79 @@ package cmd
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/briandowns/spinner"
 	"github.com/dustin/go-humanize"
+	"github.com/google/shlex"
 	"github.com/logrusorgru/aurora"
 	"github.com/morikuni/aec"
+	dockerparser "github.com/novln/docker-parser"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/client"
 	"github.com/superfly/flyctl/internal/cmdfmt"
 	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/cosign"
 	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/ghactions"
+	"github.com/superfly/flyctl/internal/gitinfo"
 	"github.com/superfly/flyctl/internal/monitor"
+	"github.com/superfly/flyctl/internal/registry"
+	"github.com/superfly/flyctl/internal/sbom"
+	"github.com/superfly/flyctl/pkg/iostreams"
 	"github.com/superfly/flyctl/terminal"
 	"golang.org/x/sync/errgroup"
 )
 
 func newDeployCommand(client *client.Client) *Command {
 	deployStrings := docstrings.Get("deploy")
-	cmd := BuildCommandKS(nil, runDeploy, deployStrings, client, workingDirectoryFromArg(0), requireSession, requireAppName)
+	cmd := BuildCommandKS(nil, runDeploy, deployStrings, client, workingDirectoryFromArg(0), requireSession, requireAppNameUnlessMultiApp)
 	cmd.AddStringFlag(StringFlagOpts{
 		Name:        "image",
 		Shorthand:   "i",
 		Description: "Image tag or id to deploy",
 	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "workspace",
+		Description: "Deploy the apps listed in a workspace file, or a directory containing fly.workspace.toml",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "verify-signature",
+		Description: "Path to a cosign public key. The image given with --image must be signed with it to be deployed",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "sign-with",
+		Description: "Path to a cosign private key to sign the image with once it's pushed. Encrypted keys are decrypted with $COSIGN_PASSWORD.",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "sbom",
+		Description: "Generate an SBOM of the image with syft once it's pushed, and attach it to the image",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "sbom-format",
+		Description: "Format of the SBOM generated with --sbom: cyclonedx or spdx",
+		Default:     "cyclonedx",
+	})
 	cmd.AddBoolFlag(BoolFlagOpts{
 		Name:        "detach",
 		Description: "Return immediately instead of monitoring deployment progress",
@@ -47,13 +83,45 @@ func newDeployCommand(client *client.Client) *Command {
 		Name:   "build-only",
 		Hidden: true,
 	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "build-mode",
+		Description: "Where to build: local, remote, or auto to build locally when docker is running and remotely otherwise",
+		Default:     imgsrc.BuildModeAuto,
+	})
 	cmd.AddBoolFlag(BoolFlagOpts{
 		Name:        "remote-only",
-		Description: "Perform builds remotely without using the local docker daemon",
+		Description: "Perform builds remotely without using the local docker daemon, the same as --build-mode remote",
 	})
 	cmd.AddBoolFlag(BoolFlagOpts{
 		Name:        "local-only",
-		Description: "Only perform builds locally using the local docker daemon",
+		Description: "Only perform builds locally using the local docker daemon, the same as --build-mode local",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "remote-builder-app",
+		Description: "Build remotely on this builder app, in place of the organization's pinned or default builder",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "remote-builder-timeout",
+		Description: "How long to wait for the remote builder to start, such as 10m",
+		Default:     "5m",
+		EnvName:     "FLY_REMOTE_BUILDER_TIMEOUT",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "remote-builder-size",
+		Description: "Resize the remote builder's VM to this size, such as performance-4x, before building",
+	})
+	cmd.AddIntFlag(IntFlagOpts{
+		Name:        "remote-builder-memory",
+		Description: "Resize the remote builder's memory to this many MB before building",
+	})
+	cmd.AddIntFlag(IntFlagOpts{
+		Name:        "remote-builder-disk",
+		Description: "Grow the remote builder's volume to at least this many GB before building",
+	})
+	cmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "forward-registry-auth",
+		Description: "Registries, such as ghcr.io, whose docker credentials the remote builder may use to pull private base images during the build. Can be specified multiple times",
+		EnvName:     "FLY_FORWARD_REGISTRY_AUTH",
 	})
 	cmd.AddStringFlag(StringFlagOpts{
 		Name:        "strategy",
@@ -63,10 +131,22 @@ func newDeployCommand(client *client.Client) *Command {
 		Name:        "dockerfile",
 		Description: "Path to a Dockerfile. Defaults to the Dockerfile in the working directory.",
 	})
+	cmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "only-if-changed",
+		Description: "Only deploy when files under these paths, or the app config, have changed since the commit of the current release",
+	})
 	cmd.AddStringSliceFlag(StringSliceFlagOpts{
 		Name:        "build-arg",
 		Description: "Set of build time variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
 	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "builder",
+		Description: "Build with this Cloud Native Buildpacks builder image instead of the one in the [build] section",
+	})
+	cmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "buildpack",
+		Description: "Buildpack to build with, instead of those in the [build] section. Can be specified multiple times.",
+	})
 	cmd.AddStringSliceFlag(StringSliceFlagOpts{
 		Name:        "env",
 		Shorthand:   "e",
@@ -76,10 +156,36 @@ func newDeployCommand(client *client.Client) *Command {
 		Name:        "image-label",
 		Description: "Image label to use when tagging and pushing to the fly registry. Defaults to \"deployment-{timestamp}\".",
 	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "build-context",
+		Description: "Directory to send to the builder as the build context. Defaults to the working directory.",
+	})
 	cmd.AddStringFlag(StringFlagOpts{
 		Name:        "build-target",
 		Description: "Set the target build stage to build if the Dockerfile has more than one stage",
 	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "record-to",
+		Description: "Write a deploy manifest to this directory (or .json file), or commit it to a branch with git:<branch>[:<dir>], after the release is created",
+		EnvName:     "FLY_RECORD_TO",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "resume",
+		Description: "Retry the release of the image pushed by the last interrupted deploy instead of building a new one",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "plan",
+		Description: "Print the changes the deploy would make as a JSON plan, without pushing or releasing anything",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "apply",
+		Description: "Deploy the plan written by --plan to the given file",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "quiet",
+		Shorthand:   "q",
+		Description: "Only print the result of the deployment and any errors",
+	})
 	cmd.AddBoolFlag(BoolFlagOpts{
 		Name:        "no-cache",
 		Description: "Do not use the cache when building the image",
@@ -92,10 +198,62 @@ func newDeployCommand(client *client.Client) *Command {
 }
 
 func runDeploy(cmdCtx *cmdctx.CmdContext) error {
-	ctx := createCancellableContext()
+	if err := checkPlanFlags(cmdCtx); err != nil {
+		return err
+	}
+	if err := checkRecordTo(cmdCtx); err != nil {
+		return err
+	}
+	if isMultiAppDeploy(cmdCtx) {
+		return runMultiAppDeploy(cmdCtx)
+	}
+	return annotateDeployError(cmdCtx, deployApp(createCancellableContext(), cmdCtx, nil))
+}
+
+// deployApp builds or resolves the app's image and releases it. imageReady,
+// when given, is called with the image once it's pushed, for other apps to
+// deploy it too.
+func deployApp(ctx context.Context, cmdCtx *cmdctx.CmdContext, imageReady func(*imgsrc.DeploymentImage)) error {
+	// build and push output is written to phaseIO so it can be silenced by --quiet
+	phaseIO := cmdCtx.IO
+	resultOut := cmdCtx.Out
+	if cmdCtx.Verbosity() == cmdctx.VerbosityQuiet {
+		quietIO := *cmdCtx.IO
+		quietIO.Out = ioutil.Discard
+		quietIO.ErrOut = ioutil.Discard
+		phaseIO = &quietIO
+		cmdCtx.Out = ioutil.Discard
+	} else if cmdCtx.Config.GetBool("plan") {
+		// the plan is written to stdout, so progress goes to stderr
+		planIO := *cmdCtx.IO
+		planIO.Out = cmdCtx.IO.ErrOut
+		phaseIO = &planIO
+		cmdCtx.Out = cmdCtx.IO.ErrOut
+	}
+
+	var applying *deployment.Plan
+	if path := cmdCtx.Config.GetString("apply"); path != "" {
+		plan, err := loadDeployPlan(cmdCtx, path)
+		if err != nil {
+			return err
+		}
+		applying = plan
+	}
 
 	cmdCtx.Status("deploy", cmdctx.STITLE, "Deploying", cmdCtx.AppName)
 
+	if paths := cmdCtx.Config.GetStringSlice("only-if-changed"); len(paths) > 0 && !cmdCtx.Config.GetBool("resume") {
+		changed, reason, err := changedSinceRelease(cmdCtx, paths)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			fmt.Fprintln(resultOut, reason)
+			return nil
+		}
+		cmdCtx.Status("deploy", cmdctx.SDETAIL, reason)
+	}
+
 	cmdfmt.PrintBegin(cmdCtx.Out, "Validating app configuration")
 
 	if cmdCtx.AppConfig == nil {
@@ -110,6 +268,35 @@ func runDeploy(cmdCtx *cmdctx.CmdContext) error {
 		cmdCtx.AppConfig.SetEnvVariables(parsedEnv)
 	}
 
+	if err := cmdCtx.AppConfig.ValidateProcesses(); err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+
+	// check GPUs against the platform before the server normalizes the definition
+	gpu, err := cmdCtx.AppConfig.GPU()
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+	if gpu != nil {
+		if err := validateGPURegions(cmdCtx.Client.API(), cmdCtx.AppName, gpu.Kind); err != nil {
+			return err
+		}
+	}
+
+	// read hooks and statics before the server normalizes the definition too
+	hooks, err := cmdCtx.AppConfig.DeployHooks()
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+	statics, err := cmdCtx.AppConfig.Statics()
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+	services, err := cmdCtx.AppConfig.ServiceConfigs()
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+
 	parsedCfg, err := cmdCtx.Client.API().ParseConfig(cmdCtx.AppName, cmdCtx.AppConfig.Definition)
 	if err != nil {
 		if parsedCfg == nil {
@@ -119,18 +306,37 @@ func runDeploy(cmdCtx *cmdctx.CmdContext) error {
 		for _, error := range parsedCfg.Errors {
 			//	fmt.Println("   ", aurora.Red("✘").String(), error)
 			cmdCtx.Status("deploy", cmdctx.SERROR, "   ", aurora.Red("✘").String(), error)
+			annotateConfigError(cmdCtx, error)
 		}
-		return err
+		if len(parsedCfg.Errors) > 0 {
+			return &ghactions.AnnotatedError{Err: flyerr.Wrap(flyerr.InvalidConfig, err)}
+		}
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
 	}
 	cmdCtx.AppConfig.Definition = parsedCfg.Definition
+	if applying != nil {
+		if configHash, err := deployment.PlanConfigHash(cmdCtx.AppConfig.Definition, cmdCtx.AppConfig.Build); err != nil || configHash != applying.ConfigHash {
+			return flyerr.New(flyerr.InvalidConfig, "the app's configuration has changed since the plan was made, make a new plan with flyctl deploy --plan")
+		}
+	}
 	cmdfmt.PrintDone(cmdCtx.Out, "Validating app configuration done")
 
+	warnServiceProblems(cmdCtx, services)
+
 	if parsedCfg.Valid && len(parsedCfg.Services) > 0 {
-		cmdfmt.PrintServicesList(cmdCtx.IO, parsedCfg.Services)
+		cmdfmt.PrintServicesList(phaseIO, parsedCfg.Services)
+	}
+
+	builder, err := remoteBuilderOptions(cmdCtx)
+	if err != nil {
+		return err
 	}
 
-	daemonType := imgsrc.NewDockerDaemonType(!cmdCtx.Config.GetBool("remote-only"), !cmdCtx.Config.GetBool("local-only"))
-	resolver := imgsrc.NewResolver(daemonType, cmdCtx.Client.API(), cmdCtx.AppName, cmdCtx.IO)
+	daemonType, err := buildDaemonType(cmdCtx, builder)
+	if err != nil {
+		return err
+	}
+	resolver := imgsrc.NewResolver(daemonType, cmdCtx.Client.API(), cmdCtx.AppName, builder, phaseIO)
 
 	var img *imgsrc.DeploymentImage
 
@@ -141,7 +347,205 @@ func runDeploy(cmdCtx *cmdctx.CmdContext) error {
 		imageRef = ref
 	}
 
-	if imageRef != "" {
+	if keyPath := cmdCtx.Config.GetString("verify-signature"); keyPath != "" && !cmdCtx.Config.GetBool("resume") {
+		if imageRef == "" {
+			return flyerr.New(flyerr.InvalidArgument, "--verify-signature needs a pre-built image, given with --image or in the [build] section")
+		}
+		if imageRef, err = verifyImageSignature(ctx, cmdCtx, imageRef, keyPath); err != nil {
+			return err
+		}
+	}
+
+	if cmdCtx.Config.GetString("sign-with") != "" && cmdCtx.Config.GetBool("build-only") {
+		return flyerr.New(flyerr.InvalidArgument, "--sign-with signs the pushed image, so it can't be used with --build-only")
+	}
+	if cmdCtx.Config.GetBool("sbom") {
+		if cmdCtx.Config.GetBool("build-only") {
+			return flyerr.New(flyerr.InvalidArgument, "--sbom attaches the SBOM to the pushed image, so it can't be used with --build-only")
+		}
+		if !sbom.Available() {
+			return flyerr.New(flyerr.InvalidArgument, "--sbom generates SBOMs with syft, but it isn't installed. See https://github.com/anchore/syft#installation")
+		}
+	}
+
+	if cmdCtx.Config.GetBool("plan") {
+		return writeDeployPlan(cmdCtx, resultOut, imageRef)
+	}
+
+	img, err = resolveDeployImage(ctx, cmdCtx, phaseIO, resolver, applying, imageRef)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.Out, "Image: %s\n", img.Tag)
+	fmt.Fprintf(cmdCtx.Out, "Image size: %s\n", humanize.Bytes(uint64(img.Size)))
+	if cmdCtx.Verbosity() >= cmdctx.VerbosityVerbose && img.ID != "" {
+		fmt.Fprintf(cmdCtx.Out, "Image ID: %s\n", img.ID)
+	}
+
+	if len(statics) > 0 {
+		if err := checkStatics(ctx, cmdCtx, resolver, img, statics); err != nil {
+			return err
+		}
+	}
+
+	if cmdCtx.Config.GetBool("build-only") {
+		if cmdCtx.Verbosity() == cmdctx.VerbosityQuiet {
+			fmt.Fprintln(resultOut, img.Tag)
+		}
+		return nil
+	}
+
+	if imageReady != nil {
+		imageReady(img)
+	}
+
+	if keyPath := cmdCtx.Config.GetString("sign-with"); keyPath != "" && !cmdCtx.Config.GetBool("resume") {
+		if err := signImage(ctx, cmdCtx, img.Tag, keyPath); err != nil {
+			return err
+		}
+	}
+
+	if cmdCtx.Config.GetBool("sbom") && !cmdCtx.Config.GetBool("resume") {
+		if err := attachSBOM(ctx, cmdCtx, img.Tag, cmdCtx.Config.GetString("sbom-format")); err != nil {
+			return err
+		}
+	}
+
+	if !cmdCtx.Config.GetBool("resume") {
+		if err := savePendingDeploy(cmdCtx, img); err != nil {
+			terminal.Debug("could not save pending deploy:", err)
+		}
+	}
+
+	cmdfmt.PrintBegin(cmdCtx.Out, "Creating release")
+
+	var input api.DeployImageInput
+	if applying != nil {
+		if input, err = plannedDeployImageInput(cmdCtx, applying, img); err != nil {
+			return err
+		}
+	} else {
+		input = newDeployImageInput(cmdCtx, img.Tag)
+	}
+
+	release, releaseCommand, err := cmdCtx.Client.API().DeployImage(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.Out, "Release v%d created\n", release.Version)
+	if cmdCtx.Verbosity() >= cmdctx.VerbosityVerbose {
+		fmt.Fprintf(cmdCtx.Out, "Release ID: %s\n", release.ID)
+		fmt.Fprintf(cmdCtx.Out, "Deployment strategy: %s\n", strings.ToLower(release.DeploymentStrategy))
+	}
+
+	if dest := cmdCtx.Config.GetString("record-to"); dest != "" {
+		if err := recordDeployManifest(cmdCtx, dest, release, img); err != nil {
+			return errors.Wrap(err, "failed to record deploy manifest")
+		}
+	}
+
+	if ghactions.Enabled() {
+		if err := writeReleaseOutputs(cmdCtx, release, img); err != nil {
+			cmdCtx.Status("deploy", cmdctx.SWARN, "Could not write the release to $GITHUB_OUTPUT:", err)
+		}
+	}
+
+	counts, sizes := processGroupScaling(cmdCtx.AppName, cmdCtx.AppConfig)
+	if applying != nil {
+		if counts, sizes, err = plannedScaling(applying); err != nil {
+			return err
+		}
+	}
+	if err := applyProcessGroupScaling(cmdCtx, counts, sizes); err != nil {
+		return errors.Wrap(err, "failed to scale process groups")
+	}
+
+	if releaseCommand != nil {
+		fmt.Fprintf(cmdCtx.Out, "Release command detected: this new release will not be available until the command succeeds.\n")
+	}
+
+	if cmdCtx.Config.GetBool("detach") {
+		clearPendingDeploy(cmdCtx)
+		if hooks != nil && hooks.PostDeploy != "" {
+			cmdCtx.Status("deploy", cmdctx.SINFO, "The post-deploy hook doesn't run with --detach")
+		}
+		if cmdCtx.Verbosity() == cmdctx.VerbosityQuiet {
+			fmt.Fprintf(resultOut, "Release v%d created\n", release.Version)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(cmdCtx.Out)
+	cmdCtx.Status("deploy", cmdctx.SDETAIL, "You can detach the terminal anytime without stopping the deployment")
+
+	if releaseCommand != nil {
+		cmdfmt.PrintBegin(cmdCtx.Out, "Release command")
+		fmt.Fprintf(cmdCtx.Out, "Command: %s\n", releaseCommand.Command)
+
+		ghactions.StartGroup(cmdCtx.Out, "Release command")
+		err = watchReleaseCommand(ctx, cmdCtx, cmdCtx.Client.API(), releaseCommand.ID)
+		ghactions.EndGroup(cmdCtx.Out)
+		if err != nil {
+			return err
+		}
+	}
+
+	if release.DeploymentStrategy == "IMMEDIATE" {
+		terminal.Debug("immediate deployment strategy, nothing to monitor")
+		clearPendingDeploy(cmdCtx)
+		if hooks != nil && hooks.PostDeploy != "" {
+			if err := runPostDeployHook(ctx, cmdCtx, hooks.PostDeploy, release, img.Tag); err != nil {
+				return err
+			}
+		}
+		if cmdCtx.Verbosity() == cmdctx.VerbosityQuiet {
+			fmt.Fprintf(resultOut, "Release v%d created\n", release.Version)
+		}
+		return nil
+	}
+
+	ghactions.StartGroup(cmdCtx.Out, "Deployment")
+	err = watchDeployment(ctx, cmdCtx)
+	ghactions.EndGroup(cmdCtx.Out)
+	if err != nil {
+		cmdCtx.Status("deploy", cmdctx.SINFO, "Run flyctl deploy --resume to retry the release without rebuilding the image")
+		return err
+	}
+
+	clearPendingDeploy(cmdCtx)
+
+	if hooks != nil && hooks.PostDeploy != "" {
+		if err := runPostDeployHook(ctx, cmdCtx, hooks.PostDeploy, release, img.Tag); err != nil {
+			return err
+		}
+	}
+
+	if cmdCtx.Verbosity() == cmdctx.VerbosityQuiet {
+		fmt.Fprintf(resultOut, "v%d deployed successfully\n", release.Version)
+	}
+
+	return nil
+}
+
+// resolveDeployImage builds the app's image, or resolves the one it's
+// deployed from, in the Image output group
+func resolveDeployImage(ctx context.Context, cmdCtx *cmdctx.CmdContext, phaseIO *iostreams.IOStreams, resolver *imgsrc.Resolver, applying *deployment.Plan, imageRef string) (*imgsrc.DeploymentImage, error) {
+	ghactions.StartGroup(cmdCtx.Out, "Image")
+	defer ghactions.EndGroup(cmdCtx.Out)
+
+	var img *imgsrc.DeploymentImage
+	var err error
+
+	if applying != nil && applying.Image.Build == nil {
+		img = &imgsrc.DeploymentImage{Tag: applying.Image.Ref, Size: applying.Image.Size}
+	} else if cmdCtx.Config.GetBool("resume") {
+		img, err = resumePendingDeploy(cmdCtx)
+		if err != nil {
+			return nil, err
+		}
+	} else if imageRef != "" {
 		opts := imgsrc.RefOptions{
 			AppName:    cmdCtx.AppName,
 			WorkingDir: cmdCtx.WorkingDir,
@@ -151,59 +555,391 @@ func runDeploy(cmdCtx *cmdctx.CmdContext) error {
 			ImageLabel: cmdCtx.Config.GetString("image-label"),
 		}
 
-		img, err = resolver.ResolveReference(ctx, cmdCtx.IO, opts)
+		img, err = resolver.ResolveReference(ctx, phaseIO, opts)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
-		opts := imgsrc.ImageOptions{
-			AppName:    cmdCtx.AppName,
-			WorkingDir: cmdCtx.WorkingDir,
-			AppConfig:  cmdCtx.AppConfig,
-			Publish:    !cmdCtx.Config.GetBool("build-only"),
-			ImageLabel: cmdCtx.Config.GetString("image-label"),
-			Target:     cmdCtx.Config.GetString("build-target"),
-			NoCache:    cmdCtx.Config.GetBool("no-cache"),
+		opts, err := buildImageOptions(cmdCtx)
+		if err != nil {
+			return nil, err
 		}
-		if dockerfilePath := cmdCtx.Config.GetString("dockerfile"); dockerfilePath != "" {
-			dockerfilePath, err := filepath.Abs(dockerfilePath)
-			if err != nil {
-				return err
+
+		warnSecretLikeVariables(cmdCtx, opts.DockerfilePath)
+
+		if applying != nil {
+			if err := checkPlannedSource(cmdCtx, applying.Image.Build); err != nil {
+				return nil, err
 			}
-			opts.DockerfilePath = dockerfilePath
 		}
 
-		extraArgs, err := cmdutil.ParseKVStringsToMap(cmdCtx.Config.GetStringSlice("build-arg"))
+		plan, cached, err := imgsrc.CachedPlan(flyctl.ConfigDir(), opts)
 		if err != nil {
-			return errors.Wrap(err, "invalid build-arg")
+			return nil, err
 		}
-		opts.ExtraBuildArgs = extraArgs
+		if cached {
+			cmdCtx.Statusf("deploy", cmdctx.SDETAIL, "Using the cached build plan (%s builder), see flyctl build plan --explain\n", plan.Strategy)
+		}
+		opts.Plan = plan
 
-		img, err = resolver.BuildImage(ctx, cmdCtx.IO, opts)
+		img, err = resolver.BuildImage(ctx, phaseIO, opts)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if img == nil {
-			return errors.New("could not find an image to deploy")
+			return nil, errors.New("could not find an image to deploy")
 		}
 	}
 
 	if img == nil {
-		return errors.New("could not find an image to deploy")
+		return nil, errors.New("could not find an image to deploy")
 	}
 
-	fmt.Fprintf(cmdCtx.Client.IO.Out, "Image: %s\n", img.Tag)
-	fmt.Fprintf(cmdCtx.Client.IO.Out, "Image size: %s\n", humanize.Bytes(uint64(img.Size)))
+	return img, nil
+}
 
-	if cmdCtx.Config.GetBool("build-only") {
+// checkStatics fails when a [[statics]] guest path doesn't exist in the image.
+// Images that aren't on a docker daemon, such as those pulled straight from a
+// registry, can't be inspected and are released unchecked.
+func checkStatics(ctx context.Context, cmdCtx *cmdctx.CmdContext, resolver *imgsrc.Resolver, img *imgsrc.DeploymentImage, statics []flyctl.Static) error {
+	paths := make([]string, 0, len(statics))
+	for _, static := range statics {
+		paths = append(paths, static.GuestPath)
+	}
+
+	missing, err := resolver.MissingPaths(ctx, img, paths)
+	if err != nil {
+		terminal.Debugf("could not inspect image for statics: %v\n", err)
+		cmdCtx.Status("deploy", cmdctx.SWARN, "Could not check the [[statics]] guest paths exist in the image")
 		return nil
 	}
+	if len(missing) > 0 {
+		return flyerr.New(flyerr.InvalidConfig, fmt.Sprintf("[[statics]] guest paths missing from the image: %s", strings.Join(missing, ", ")))
+	}
 
-	cmdfmt.PrintBegin(cmdCtx.Out, "Creating release")
+	return nil
+}
+
+// changedSinceRelease checks whether files under paths, relative to the
+// working directory, or the app config have changed since the commit the
+// current release was deployed from, with the reason to report
+func changedSinceRelease(cmdCtx *cmdctx.CmdContext, paths []string) (bool, string, error) {
+	if _, err := gitinfo.Current(cmdCtx.WorkingDir); err != nil {
+		return false, "", flyerr.Wrap(flyerr.InvalidArgument, fmt.Errorf("--only-if-changed compares against the last deployed commit, but %s: %w", cmdCtx.WorkingDir, err))
+	}
+
+	release, err := cmdCtx.Client.API().GetAppCurrentRelease(cmdCtx.AppName)
+	if err != nil {
+		return false, "", err
+	}
+	if release == nil || release.CommitSHA == "" {
+		return true, "The current release has no recorded commit, deploying", nil
+	}
+
+	if helpers.FileExists(cmdCtx.ConfigFile) {
+		if rel, err := filepath.Rel(cmdCtx.WorkingDir, cmdCtx.ConfigFile); err == nil {
+			paths = append(paths, rel)
+		}
+	}
+
+	short := release.CommitSHA
+	if len(short) > 7 {
+		short = short[:7]
+	}
+
+	changed, err := gitinfo.ChangedFiles(cmdCtx.WorkingDir, release.CommitSHA, paths)
+	if errors.Is(err, gitinfo.ErrUnknownCommit) {
+		return true, fmt.Sprintf("Commit %s of v%d isn't in this repository, deploying", short, release.Version), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(changed) == 0 {
+		return false, fmt.Sprintf("No changes to %s since v%d (%s), skipping deploy", strings.Join(paths, ", "), release.Version, short), nil
+	}
+	return true, fmt.Sprintf("%d files changed since v%d (%s), deploying", len(changed), release.Version, short), nil
+}
+
+// verifyImageSignature checks the image has a cosign signature made with the
+// key at keyPath, returning the image's reference pinned to the digest which
+// was verified, so the image can't change before it's released
+func verifyImageSignature(ctx context.Context, cmdCtx *cmdctx.CmdContext, imageRef string, keyPath string) (string, error) {
+	key, err := cosign.LoadPublicKey(keyPath)
+	if err != nil {
+		return "", flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+
+	ref, err := dockerparser.Parse(imageRef)
+	if err != nil {
+		return "", flyerr.Wrap(flyerr.InvalidArgument, fmt.Errorf("invalid image reference %s: %w", imageRef, err))
+	}
+
+	client := newRegistryClient()
 
+	digest, err := client.Digest(ctx, ref.Registry(), ref.ShortName(), ref.Tag())
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref.Tag(), "sha256:") && digest != ref.Tag() {
+		return "", flyerr.New(flyerr.SignatureInvalid, fmt.Sprintf("the registry returned %s for %s", digest, imageRef))
+	}
+
+	if err := cosign.Verify(ctx, client, ref.Registry(), ref.ShortName(), digest, key); err != nil {
+		return "", flyerr.Wrap(flyerr.SignatureInvalid, fmt.Errorf("couldn't verify the signature of %s: %w", imageRef, err))
+	}
+
+	pinned := fmt.Sprintf("%s@%s", ref.Repository(), digest)
+	cmdCtx.Statusf("deploy", cmdctx.SINFO, "Verified the signature of %s\n", pinned)
+
+	return pinned, nil
+}
+
+// signImage signs the pushed image with the cosign private key at keyPath,
+// storing the signature in the image's registry
+func signImage(ctx context.Context, cmdCtx *cmdctx.CmdContext, imageRef string, keyPath string) error {
+	key, err := loadSigningKey(cmdCtx, keyPath)
+	if err != nil {
+		return err
+	}
+
+	ref, err := dockerparser.Parse(imageRef)
+	if err != nil {
+		return fmt.Errorf("invalid image reference %s: %w", imageRef, err)
+	}
+
+	client := newRegistryClient()
+
+	digest, err := client.Digest(ctx, ref.Registry(), ref.ShortName(), ref.Tag())
+	if err != nil {
+		return errors.Wrap(err, "could not resolve the digest of the pushed image")
+	}
+
+	cmdfmt.PrintBegin(cmdCtx.Out, "Signing image")
+	if err := cosign.Sign(ctx, client, ref.Registry(), ref.ShortName(), digest, key); err != nil {
+		return errors.Wrapf(err, "could not sign %s", imageRef)
+	}
+	cmdfmt.PrintDone(cmdCtx.Out, fmt.Sprintf("Signed %s@%s", ref.Repository(), digest))
+
+	return nil
+}
+
+// loadSigningKey loads a cosign private key, decrypting it with
+// $COSIGN_PASSWORD or a password prompted for
+func loadSigningKey(cmdCtx *cmdctx.CmdContext, keyPath string) (*ecdsa.PrivateKey, error) {
+	encrypted, err := cosign.KeyEncrypted(keyPath)
+	if err != nil {
+		return nil, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+
+	password, set := os.LookupEnv("COSIGN_PASSWORD")
+	if encrypted && !set {
+		if !cmdCtx.IO.IsInteractive() {
+			return nil, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is encrypted, set COSIGN_PASSWORD to its password", keyPath))
+		}
+		prompt := &survey.Password{Message: fmt.Sprintf("Password for %s:", keyPath)}
+		if err := ask(prompt, &password); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := cosign.LoadPrivateKey(keyPath, []byte(password))
+	if err != nil {
+		return nil, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+	return key, nil
+}
+
+// newRegistryClient returns a registry client which authenticates with
+// the Fly registry using the API token, and docker's stored credentials for
+// others
+func newRegistryClient() *registry.Client {
+	client := registry.New()
+	client.Credentials = func(host string) (string, string) {
+		if host == flyRegistry {
+			return "x", flyctl.GetAPIToken()
+		}
+		return registry.DockerCredentials(host)
+	}
+	return client
+}
+
+// remoteBuilderOptions - the builder app from --remote-builder-app, how long
+// to wait for it, and its size from [build.remote_builder] overridden by the
+// --remote-builder flags
+func remoteBuilderOptions(cmdCtx *cmdctx.CmdContext) (imgsrc.RemoteBuilderOptions, error) {
+	builder := imgsrc.RemoteBuilderOptions{
+		App: cmdCtx.Config.GetString("remote-builder-app"),
+		Size: cmdCtx.AppConfig.RemoteBuilderSize().Merge(flyctl.RemoteBuilderSize{
+			VMSize:   cmdCtx.Config.GetString("remote-builder-size"),
+			MemoryMB: cmdCtx.Config.GetInt("remote-builder-memory"),
+			DiskGB:   cmdCtx.Config.GetInt("remote-builder-disk"),
+		}),
+	}
+
+	if cmdCtx.Config.GetBool("local-only") || cmdCtx.Config.GetString("build-mode") == imgsrc.BuildModeLocal {
+		if builder.App != "" {
+			return builder, flyerr.New(flyerr.InvalidArgument, "--remote-builder-app builds remotely, so it can't be used with local builds")
+		}
+		// [build.remote_builder] doesn't apply to local builds
+		builder.Size = flyctl.RemoteBuilderSize{}
+	}
+	if builder.Size.MemoryMB < 0 || builder.Size.DiskGB < 0 {
+		return builder, flyerr.New(flyerr.InvalidArgument, "--remote-builder-memory and --remote-builder-disk must be positive")
+	}
+
+	timeout, err := time.ParseDuration(cmdCtx.Config.GetString("remote-builder-timeout"))
+	if err != nil || timeout <= 0 {
+		return builder, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --remote-builder-timeout %q, use a duration like 10m", cmdCtx.Config.GetString("remote-builder-timeout")))
+	}
+	builder.Timeout = timeout
+
+	return builder, nil
+}
+
+// buildDaemonType - the docker daemons --build-mode allows, narrowed by
+// --local-only, --remote-only and --remote-builder-app
+func buildDaemonType(cmdCtx *cmdctx.CmdContext, builder imgsrc.RemoteBuilderOptions) (imgsrc.DockerDaemonType, error) {
+	mode := cmdCtx.Config.GetString("build-mode")
+	if _, err := imgsrc.ParseBuildMode(mode); err != nil {
+		return imgsrc.DockerDaemonTypeNone, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+
+	narrow := func(flag string, to string) error {
+		if mode != imgsrc.BuildModeAuto && mode != to {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s can't be used with --build-mode %s", flag, mode))
+		}
+		mode = to
+		return nil
+	}
+	if cmdCtx.Config.GetBool("local-only") {
+		if err := narrow("--local-only", imgsrc.BuildModeLocal); err != nil {
+			return imgsrc.DockerDaemonTypeNone, err
+		}
+	}
+	if cmdCtx.Config.GetBool("remote-only") {
+		if err := narrow("--remote-only", imgsrc.BuildModeRemote); err != nil {
+			return imgsrc.DockerDaemonTypeNone, err
+		}
+	}
+	if builder.App != "" {
+		if err := narrow("--remote-builder-app", imgsrc.BuildModeRemote); err != nil {
+			return imgsrc.DockerDaemonTypeNone, err
+		}
+	}
+
+	return imgsrc.ParseBuildMode(mode)
+}
+
+// buildImageOptions - the options for building the app's image from the
+// deploy and build flags
+func buildImageOptions(cmdCtx *cmdctx.CmdContext) (imgsrc.ImageOptions, error) {
+	opts := imgsrc.ImageOptions{
+		AppName:         cmdCtx.AppName,
+		WorkingDir:      cmdCtx.WorkingDir,
+		AppConfig:       cmdCtx.AppConfig,
+		Publish:         !cmdCtx.Config.GetBool("build-only"),
+		ImageLabel:      cmdCtx.Config.GetString("image-label"),
+		Target:          cmdCtx.Config.GetString("build-target"),
+		NoCache:         cmdCtx.Config.GetBool("no-cache"),
+		ContextWarnSize: imgsrc.DefaultContextWarnSize,
+	}
+	if viper.IsSet(flyctl.ConfigBuildContextWarnSize) {
+		opts.ContextWarnSize = int64(viper.GetInt(flyctl.ConfigBuildContextWarnSize)) * 1024 * 1024
+	}
+
+	if dockerfilePath := cmdCtx.Config.GetString("dockerfile"); dockerfilePath != "" {
+		dockerfilePath, err := filepath.Abs(dockerfilePath)
+		if err != nil {
+			return opts, err
+		}
+		opts.DockerfilePath = dockerfilePath
+	} else if cmdCtx.AppConfig.Build != nil && cmdCtx.AppConfig.Build.Dockerfile != "" {
+		// dockerfiles set in the config are relative to the config, so each
+		// config in a directory can build its own image
+		opts.DockerfilePath = cmdCtx.AppConfig.Build.Dockerfile
+		if !filepath.IsAbs(opts.DockerfilePath) {
+			opts.DockerfilePath = filepath.Join(filepath.Dir(cmdCtx.ConfigFile), opts.DockerfilePath)
+		}
+	}
+
+	if opts.Target == "" && cmdCtx.AppConfig.Build != nil {
+		opts.Target = cmdCtx.AppConfig.Build.Target
+	}
+
+	// FLY_FORWARD_REGISTRY_AUTH is comma separated, like the flag
+	for _, registries := range cmdCtx.Config.GetStringSlice("forward-registry-auth") {
+		for _, registry := range strings.Split(registries, ",") {
+			if registry = strings.TrimSpace(registry); registry != "" {
+				opts.ForwardRegistryAuth = append(opts.ForwardRegistryAuth, registry)
+			}
+		}
+	}
+
+	// the build context can be outside the working directory, such as the
+	// root of a monorepo. Like dockerfiles, a context set in the config is
+	// relative to the config
+	contextDir := cmdCtx.Config.GetString("build-context")
+	if contextDir != "" {
+		abs, err := filepath.Abs(contextDir)
+		if err != nil {
+			return opts, err
+		}
+		contextDir = abs
+	} else if cmdCtx.AppConfig.Build != nil && cmdCtx.AppConfig.Build.Context != "" {
+		contextDir = cmdCtx.AppConfig.Build.Context
+		if !filepath.IsAbs(contextDir) {
+			contextDir = filepath.Join(filepath.Dir(cmdCtx.ConfigFile), contextDir)
+		}
+	}
+	if contextDir != "" {
+		if info, err := os.Stat(contextDir); err != nil || !info.IsDir() {
+			return opts, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("build context %s is not a directory", contextDir))
+		}
+		opts.WorkingDir = contextDir
+
+		// the Dockerfile is still looked for in the working directory
+		if opts.DockerfilePath == "" && helpers.FileExists(filepath.Join(cmdCtx.WorkingDir, "Dockerfile")) {
+			opts.DockerfilePath = filepath.Join(cmdCtx.WorkingDir, "Dockerfile")
+		}
+	}
+
+	extraArgs, err := cmdutil.ParseKVStringsToMap(cmdCtx.Config.GetStringSlice("build-arg"))
+	if err != nil {
+		return opts, errors.Wrap(err, "invalid build-arg")
+	}
+	opts.ExtraBuildArgs = extraArgs
+
+	// --builder and --buildpack override the [build] section, without
+	// changing the app's config
+	builder := cmdCtx.Config.GetString("builder")
+	buildpacks := cmdCtx.Config.GetStringSlice("buildpack")
+	if builder != "" || len(buildpacks) > 0 {
+		build := flyctl.Build{}
+		if cmdCtx.AppConfig.Build != nil {
+			build = *cmdCtx.AppConfig.Build
+		}
+		if builder != "" {
+			build.Builder = builder
+		}
+		if len(buildpacks) > 0 {
+			build.Buildpacks = buildpacks
+		}
+
+		appConfig := *cmdCtx.AppConfig
+		appConfig.Build = &build
+		opts.AppConfig = &appConfig
+	}
+
+	return opts, nil
+}
+
+// newDeployImageInput - the input releasing image with the app's config, the
+// --strategy flag, and the git commit and deployer as annotations
+func newDeployImageInput(cmdCtx *cmdctx.CmdContext, image string) api.DeployImageInput {
 	input := api.DeployImageInput{
 		AppID: cmdCtx.AppName,
-		Image: img.Tag,
+		Image: image,
 	}
 	if val := cmdCtx.Config.GetString("strategy"); val != "" {
 		input.Strategy = api.StringPointer(strings.ToUpper(val))
@@ -211,45 +947,280 @@ func runDeploy(cmdCtx *cmdctx.CmdContext) error {
 	if cmdCtx.AppConfig != nil && len(cmdCtx.AppConfig.Definition) > 0 {
 		input.Definition = api.DefinitionPtr(cmdCtx.AppConfig.Definition)
 	}
+	git, err := gitinfo.Current(cmdCtx.WorkingDir)
+	if err == nil {
+		input.CommitSHA = api.StringPointer(git.SHA)
+	} else {
+		git = nil
+	}
+	input.Annotations = deployment.ReleaseAnnotations(git, currentDeployer(cmdCtx), flyctl.Version)
 
-	release, releaseCommand, err := cmdCtx.Client.API().DeployImage(input)
+	return input
+}
+
+// processGroupScaling - the counts and VM sizes given for process groups in the config
+func processGroupScaling(appName string, appConfig *flyctl.AppConfig) ([]api.VMCountInput, []api.SetVMSizeInput) {
+	counts := []api.VMCountInput{}
+	sizes := []api.SetVMSizeInput{}
+	for _, name := range appConfig.ProcessNames() {
+		group := appConfig.Processes[name]
+		if group.Count != nil {
+			counts = append(counts, api.VMCountInput{Group: name, Count: *group.Count})
+		}
+		if group.VMSize != "" {
+			input := api.SetVMSizeInput{AppID: appName, Group: name, SizeName: group.VMSize}
+			if group.MemoryMB != nil {
+				input.MemoryMb = int64(*group.MemoryMB)
+			}
+			sizes = append(sizes, input)
+		}
+	}
+	return counts, sizes
+}
+
+// applyProcessGroupScaling sets the counts and VM sizes of process groups
+func applyProcessGroupScaling(cmdCtx *cmdctx.CmdContext, counts []api.VMCountInput, sizes []api.SetVMSizeInput) error {
+	if len(counts) > 0 {
+		_, warnings, err := cmdCtx.Client.API().SetAppVMGroupCounts(cmdCtx.AppName, counts)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			cmdCtx.Status("deploy", cmdctx.SWARN, warning)
+		}
+		for _, count := range counts {
+			fmt.Fprintf(cmdCtx.Out, "Process group %s count set to %d\n", count.Group, count.Count)
+		}
+	}
+
+	for _, input := range sizes {
+		size, err := cmdCtx.Client.API().SetAppVMSize(input)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmdCtx.Out, "Process group %s VM size set to %s (%s)\n", input.Group, size.Name, formatMemory(size))
+	}
+
+	return nil
+}
+
+// currentDeployer identifies who's deploying, for the release's annotations
+func currentDeployer(cmdCtx *cmdctx.CmdContext) deployment.Deployer {
+	deployer := deployment.Deployer{
+		TokenSource: flyctl.GetAPITokenSource(),
+		CI:          deployment.DetectCI(os.Getenv),
+	}
+
+	if user, err := cmdCtx.Client.API().GetCurrentUser(); err == nil {
+		deployer.User = user.Email
+	} else {
+		terminal.Debug("could not find the current user:", err)
+	}
+
+	return deployer
+}
+
+// savePendingDeploy remembers a pushed image until it's released so --resume can skip the build
+func savePendingDeploy(cmdCtx *cmdctx.CmdContext, img *imgsrc.DeploymentImage) error {
+	configHash, err := deployment.ConfigHash(cmdCtx.AppConfig.Definition)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(cmdCtx.Out, "Release v%d created\n", release.Version)
-	if releaseCommand != nil {
-		fmt.Fprintf(cmdCtx.Out, "Release command detected: this new release will not be available until the command succeeds.\n")
+	pending := &deployment.PendingDeploy{
+		App:        cmdCtx.AppName,
+		Image:      img.Tag,
+		ImageID:    img.ID,
+		ImageSize:  img.Size,
+		ConfigHash: configHash,
+		PushedAt:   time.Now(),
 	}
 
-	if cmdCtx.Config.GetBool("detach") {
+	if image, err := cmdCtx.Client.API().ResolveImageForApp(cmdCtx.AppName, img.Tag); err == nil && image != nil {
+		pending.ImageDigest = image.Digest
+	}
+
+	return deployment.SavePendingDeploy(flyctl.ConfigDir(), pending)
+}
+
+func clearPendingDeploy(cmdCtx *cmdctx.CmdContext) {
+	if err := deployment.ClearPendingDeploy(flyctl.ConfigDir(), cmdCtx.AppName); err != nil {
+		terminal.Debug("could not clear pending deploy:", err)
+	}
+}
+
+// resumePendingDeploy returns the image pushed by the last interrupted deploy, checking it's still in the registry
+func resumePendingDeploy(cmdCtx *cmdctx.CmdContext) (*imgsrc.DeploymentImage, error) {
+	pending, err := deployment.LoadPendingDeploy(flyctl.ConfigDir(), cmdCtx.AppName)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, fmt.Errorf("no interrupted deploy to resume for %s", cmdCtx.AppName)
+	}
+
+	cmdCtx.Statusf("deploy", cmdctx.SINFO, "Resuming deploy of %s pushed %s\n", pending.Image, humanize.Time(pending.PushedAt))
+
+	if pending.ImageDigest != "" {
+		image, err := cmdCtx.Client.API().ResolveImageForApp(cmdCtx.AppName, pending.Image)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not find the pushed image")
+		}
+		if image == nil || image.Digest != pending.ImageDigest {
+			return nil, fmt.Errorf("%s no longer matches the pushed image %s, run flyctl deploy without --resume", pending.Image, pending.ImageDigest)
+		}
+	}
+
+	if configHash, err := deployment.ConfigHash(cmdCtx.AppConfig.Definition); err == nil && configHash != pending.ConfigHash {
+		cmdCtx.Status("deploy", cmdctx.SWARN, "App configuration has changed since the image was pushed, the current configuration will be released")
+	}
+
+	return &imgsrc.DeploymentImage{
+		ID:   pending.ImageID,
+		Tag:  pending.Image,
+		Size: pending.ImageSize,
+	}, nil
+}
+
+// writeReleaseOutputs sets the release as outputs of the GitHub Actions step,
+// for later steps to use without parsing the log
+func writeReleaseOutputs(cmdCtx *cmdctx.CmdContext, release *api.Release, img *imgsrc.DeploymentImage) error {
+	outputs := map[string]string{
+		"release_id":      release.ID,
+		"release_version": strconv.Itoa(release.Version),
+		"image":           img.Tag,
+	}
+
+	if image, err := cmdCtx.Client.API().ResolveImageForApp(cmdCtx.AppName, img.Tag); err == nil && image != nil {
+		outputs["image_digest"] = image.Digest
+	} else if err != nil {
+		terminal.Debug("could not resolve the image digest:", err)
+	}
+
+	if app, err := cmdCtx.Client.API().GetAppCompact(cmdCtx.AppName); err == nil {
+		outputs["app_url"] = app.AppURL
+	} else {
+		terminal.Debug("could not find the app URL:", err)
+	}
+
+	return ghactions.SetOutputs(outputs)
+}
+
+// annotateConfigError annotates a configuration error on the line of the
+// config file it refers to, or on the file itself. Annotations are written to
+// stdout even with --quiet, as that's where the runner reads them from.
+func annotateConfigError(cmdCtx *cmdctx.CmdContext, message string) {
+	if !ghactions.Enabled() {
+		return
+	}
+
+	annotation := ghactions.Annotation{Title: "Invalid app configuration", Message: message}
+	if cmdCtx.ConfigFile != "" {
+		annotation.File = ghactions.WorkspacePath(cmdCtx.ConfigFile)
+		if data, err := ioutil.ReadFile(cmdCtx.ConfigFile); err == nil {
+			annotation.Line = ghactions.ConfigLine(string(data), message)
+		}
+	}
+	ghactions.Error(os.Stdout, annotation)
+}
+
+// annotateDeployError annotates a failed deploy, with configuration errors
+// annotated on the config file
+func annotateDeployError(cmdCtx *cmdctx.CmdContext, err error) error {
+	if err == nil || !ghactions.Enabled() || cmdCtx.OutputJSON() || ghactions.IsAnnotated(err) || isCancelledError(err) {
+		return err
+	}
+
+	if flyerr.CodeOf(err) == flyerr.InvalidConfig {
+		annotateConfigError(cmdCtx, err.Error())
+	} else {
+		ghactions.Error(os.Stdout, ghactions.Annotation{Title: "Deploy of " + cmdCtx.AppName + " failed", Message: err.Error()})
+	}
+	return &ghactions.AnnotatedError{Err: err}
+}
+
+// checkRecordTo fails a deploy recording to a git branch up front, rather than
+// once it's released, when the branch can't be written
+func checkRecordTo(cmdCtx *cmdctx.CmdContext) error {
+	branch, _, ok := deployment.GitDestination(cmdCtx.Config.GetString("record-to"))
+	if !ok {
 		return nil
 	}
 
-	fmt.Println()
-	cmdCtx.Status("deploy", cmdctx.SDETAIL, "You can detach the terminal anytime without stopping the deployment")
+	if branch == "" {
+		return flyerr.New(flyerr.InvalidArgument, "--record-to git: needs a branch, as in git:deployments or git:deployments:apps/web")
+	}
+	if _, err := gitinfo.Current(cmdCtx.WorkingDir); err != nil {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("--record-to %s commits to a git branch, but %s isn't in a git repository", cmdCtx.Config.GetString("record-to"), cmdCtx.WorkingDir))
+	}
 
-	if releaseCommand != nil {
-		cmdfmt.PrintBegin(cmdCtx.Out, "Release command")
-		fmt.Printf("Command: %s\n", releaseCommand.Command)
+	return nil
+}
 
-		err = watchReleaseCommand(ctx, cmdCtx, cmdCtx.Client.API(), releaseCommand.ID)
+func recordDeployManifest(cmdCtx *cmdctx.CmdContext, dest string, release *api.Release, img *imgsrc.DeploymentImage) error {
+	manifest := &deployment.Manifest{
+		App:            cmdCtx.AppName,
+		ReleaseID:      release.ID,
+		ReleaseVersion: release.Version,
+		Image:          img.Tag,
+		Strategy:       release.DeploymentStrategy,
+		DeployedAt:     release.CreatedAt,
+	}
+
+	configHash, err := deployment.ConfigHash(cmdCtx.AppConfig.Definition)
+	if err != nil {
+		return err
+	}
+	manifest.ConfigHash = configHash
+
+	if image, err := cmdCtx.Client.API().ResolveImageForApp(cmdCtx.AppName, img.Tag); err == nil && image != nil {
+		manifest.ImageDigest = image.Digest
+	} else {
+		terminal.Debug("could not resolve image digest:", err)
+	}
+
+	if info, err := gitinfo.Current(cmdCtx.WorkingDir); err == nil {
+		manifest.GitSHA = info.SHA
+		manifest.GitBranch = info.Branch
+		manifest.GitDirty = info.Dirty
+	} else {
+		terminal.Debug("could not read git info:", err)
+	}
+
+	if branch, dir, ok := deployment.GitDestination(dest); ok {
+		files, err := deployment.ManifestFiles(dir, manifest)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Deploy %s v%d", manifest.App, manifest.ReleaseVersion)
+		commit, err := gitinfo.CommitFiles(cmdCtx.WorkingDir, branch, files, message)
 		if err != nil {
 			return err
 		}
+		fmt.Fprintf(cmdCtx.Out, "Deploy manifest committed to branch %s (%.7s)\n", branch, commit)
+		return nil
 	}
 
-	if release.DeploymentStrategy == "IMMEDIATE" {
-		terminal.Debug("immediate deployment strategy, nothing to monitor")
-		return nil
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	paths, err := deployment.WriteManifest(dest, manifest)
+	if err != nil {
+		return err
 	}
 
-	return watchDeployment(ctx, cmdCtx)
+	for _, p := range paths {
+		fmt.Fprintf(cmdCtx.Out, "Deploy manifest written to %s\n", p)
+	}
+
+	return nil
 }
 
 func watchReleaseCommand(ctx context.Context, cc *cmdctx.CmdContext, apiClient *api.Client, id string) error {
 	g, ctx := errgroup.WithContext(ctx)
-	interactive := cc.IO.IsInteractive()
+	interactive := cc.IO.IsInteractive() && cc.Verbosity() != cmdctx.VerbosityQuiet
 
 	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
 	s.Writer = os.Stderr
@@ -285,7 +1256,7 @@ func watchReleaseCommand(ctx context.Context, cc *cmdctx.CmdContext, apiClient *
 						}
 
 						for _, l := range logs {
-							fmt.Println("\t", l.Message)
+							fmt.Fprintln(cc.Out, "\t", l.Message)
 
 							// watch for the shutdown message
 							if l.Message == "Starting clean up." {
@@ -340,11 +1311,11 @@ func watchReleaseCommand(ctx context.Context, cc *cmdctx.CmdContext, apiClient *
 				startLogs(*rc.InstanceID)
 			}
 
-			if !rc.InProgress && rc.Failed {
+			if !rc.InProgress {
 				if rc.Succeeded && interactive {
 					s.FinalMSG = "Running release task...Done\n"
 				} else if rc.Failed {
-					return errors.New("Release command failed, deployment aborted")
+					return flyerr.New(flyerr.ReleaseCommandFailed, "Release command failed, deployment aborted")
 				}
 			}
 		}
@@ -355,12 +1326,90 @@ func watchReleaseCommand(ctx context.Context, cc *cmdctx.CmdContext, apiClient *
 	return g.Wait()
 }
 
+// runPostDeployHook runs the [deploy] post_deploy command in a one-off machine
+// with the released image, streaming its logs until it exits
+func runPostDeployHook(ctx context.Context, cmdCtx *cmdctx.CmdContext, command string, release *api.Release, image string) error {
+	apiClient := cmdCtx.Client.API()
+
+	args, err := shlex.Split(command)
+	if err != nil || len(args) == 0 {
+		return flyerr.New(flyerr.InvalidConfig, fmt.Sprintf("invalid deploy.post_deploy command %q", command))
+	}
+
+	cmdfmt.PrintBegin(cmdCtx.Out, "Post-deploy hook")
+	fmt.Fprintf(cmdCtx.Out, "Command: %s\n", command)
+
+	env := cmdCtx.AppConfig.EnvVariables()
+	env["FLY_RELEASE_VERSION"] = strconv.Itoa(release.Version)
+
+	machine, err := apiClient.LaunchMachine(api.LaunchMachineInput{
+		AppID: cmdCtx.AppName,
+		Name:  fmt.Sprintf("post-deploy-v%d", release.Version),
+		Config: api.MachineConfig{
+			Image:   image,
+			Cmd:     args,
+			Env:     env,
+			Restart: &api.MachineRestart{Policy: "no"},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to start the post-deploy hook")
+	}
+	defer removeJobMachine(apiClient, cmdCtx.AppName, machine.ID)
+
+	logCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+	logsDone := make(chan struct{})
+	go func() {
+		defer close(logsDone)
+		ls := monitor.NewLogStream(apiClient)
+		opts := monitor.LogOptions{MaxBackoff: 1 * time.Second, AppName: cmdCtx.AppName, VMID: machine.ID}
+		for logs := range ls.Stream(logCtx, opts) {
+			for _, l := range logs {
+				fmt.Fprintln(cmdCtx.Out, "\t", l.Message)
+			}
+		}
+	}()
+
+	for {
+		m, err := apiClient.GetMachine(cmdCtx.AppName, machine.ID)
+		if err != nil {
+			return err
+		}
+
+		switch m.State {
+		case "stopped", "destroyed":
+			stopLogs()
+			<-logsDone
+			if m.ExitCode == nil || *m.ExitCode != 0 {
+				reason := "without an exit code"
+				if m.ExitCode != nil {
+					reason = fmt.Sprintf("with code %d", *m.ExitCode)
+				}
+				return flyerr.New(flyerr.PostDeployFailed, fmt.Sprintf("Post-deploy hook exited %s, release v%d is deployed", reason, release.Version))
+			}
+			cmdfmt.PrintDone(cmdCtx.Out, "Post-deploy hook done")
+			return nil
+		case "failed":
+			return flyerr.New(flyerr.PostDeployFailed, fmt.Sprintf("Post-deploy hook machine failed, release v%d is deployed", release.Version))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
 func watchDeployment(ctx context.Context, cmdCtx *cmdctx.CmdContext) error {
 	cmdCtx.Status("deploy", cmdctx.STITLE, "Monitoring Deployment")
 
-	interactive := cmdCtx.IO.IsInteractive()
+	// verbose output lists every instance update rather than redrawing a summary line
+	interactive := cmdCtx.IO.IsInteractive() && cmdCtx.Verbosity() == cmdctx.VerbosityNormal
 
 	endmessage := ""
+	failedChecks := false
 
 	monitor := deployment.NewDeploymentMonitor(cmdCtx.Client.API(), cmdCtx.AppName)
 
@@ -398,6 +1447,12 @@ func watchDeployment(ctx context.Context, cmdCtx *cmdctx.CmdContext) error {
 			}
 		}
 
+		for _, a := range failedAllocs {
+			if a.CriticalCheckCount > 0 {
+				failedChecks = true
+			}
+		}
+
 		if len(failedAllocs) > 0 {
 			cmdCtx.Status("deploy", cmdctx.STITLE, "Failed Instances")
 
@@ -464,20 +1519,38 @@ func watchDeployment(ctx context.Context, cmdCtx *cmdctx.CmdContext) error {
 		return nil
 	}
 
+	phase := cmdCtx.IO.StartPhase("Monitoring deployment")
 	monitor.Start(ctx)
 
 	if err := monitor.Error(); err != nil {
+		phase.End(err)
 		return err
 	}
-
-	if endmessage != "" {
-		cmdCtx.Status("deploy", cmdctx.SERROR, endmessage)
+	if monitor.Success() {
+		phase.End(nil)
+	} else {
+		phase.End(errors.New("deployment failed"))
 	}
 
 	if !monitor.Success() {
 		cmdCtx.Status("deploy", cmdctx.SINFO, "Troubleshooting guide at https://fly.io/docs/getting-started/troubleshooting/")
-		return ErrAbort
+
+		if endmessage == "" {
+			endmessage = "deployment failed"
+		}
+		code := flyerr.DeployFailed
+		if failedChecks {
+			code = flyerr.HealthcheckFailed
+		}
+		return flyerr.New(code, strings.TrimSpace(endmessage))
 	}
 
 	return nil
 }
+
+// isDeploymentFailure reports whether err is from a deployment that failed,
+// rather than one that couldn't be monitored
+func isDeploymentFailure(err error) bool {
+	code := flyerr.CodeOf(err)
+	return code == flyerr.DeployFailed || code == flyerr.HealthcheckFailed
+}
@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// deployTarget - an app deployed by a multi-app deploy, with its own config
+// and working directory
+type deployTarget struct {
+	AppName    string
+	ConfigFile string
+	WorkingDir string
+	AppConfig  *flyctl.AppConfig
+}
+
+// isMultiAppDeploy is true when deploying the apps of a workspace or an
+// --app list, e.g. --app api,web
+func isMultiAppDeploy(cmdCtx *cmdctx.CmdContext) bool {
+	return cmdCtx.Config.GetString("workspace") != "" || strings.Contains(cmdCtx.AppName, ",")
+}
+
+// requireAppNameUnlessMultiApp - requireAppName, except deploys of several
+// apps each resolve their own app and config
+func requireAppNameUnlessMultiApp(cmd *Command) Initializer {
+	init := requireAppName(cmd)
+	preRun := init.PreRun
+	init.PreRun = func(ctx *cmdctx.CmdContext) error {
+		if isMultiAppDeploy(ctx) {
+			return nil
+		}
+		return preRun(ctx)
+	}
+	return init
+}
+
+// resolveDeployTargets returns the apps to deploy and how many to deploy at
+// once, 0 for all of them
+func resolveDeployTargets(cmdCtx *cmdctx.CmdContext) ([]deployTarget, int, error) {
+	var targets []deployTarget
+
+	if path := cmdCtx.Config.GetString("workspace"); path != "" {
+		if cmdCtx.Config.GetString("app") != "" {
+			return nil, 0, flyerr.New(flyerr.InvalidArgument, "--workspace deploys the apps in the workspace, it can't be used with --app")
+		}
+
+		ws, err := flyctl.LoadWorkspace(path)
+		if err != nil {
+			return nil, 0, flyerr.Wrap(flyerr.InvalidConfig, err)
+		}
+
+		for _, app := range ws.Apps {
+			configFile, err := ws.ConfigPath(app)
+			if err != nil {
+				return nil, 0, err
+			}
+			appConfig, err := flyctl.LoadAppConfig(configFile)
+			if err != nil {
+				return nil, 0, flyerr.Wrap(flyerr.InvalidConfig, err)
+			}
+
+			name := app.Name
+			if name == "" {
+				name = appConfig.AppName
+			}
+			if name == "" {
+				return nil, 0, flyerr.New(flyerr.InvalidConfig, fmt.Sprintf("%s doesn't set an app, give one with name in %s", configFile, ws.Path))
+			}
+
+			targets = append(targets, deployTarget{
+				AppName:    name,
+				ConfigFile: configFile,
+				WorkingDir: filepath.Dir(configFile),
+				AppConfig:  appConfig,
+			})
+		}
+
+		return targets, ws.Concurrency, nil
+	}
+
+	for _, name := range strings.Split(cmdCtx.AppName, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		// each app gets its own copy of the config, which deploys modify
+		appConfig := flyctl.NewAppConfig()
+		if helpers.FileExists(cmdCtx.ConfigFile) {
+			var err error
+			if appConfig, err = flyctl.LoadAppConfig(cmdCtx.ConfigFile); err != nil {
+				return nil, 0, flyerr.Wrap(flyerr.InvalidConfig, err)
+			}
+		}
+
+		targets = append(targets, deployTarget{
+			AppName:    name,
+			ConfigFile: cmdCtx.ConfigFile,
+			WorkingDir: cmdCtx.WorkingDir,
+			AppConfig:  appConfig,
+		})
+	}
+
+	return targets, 0, nil
+}
+
+// deployGroup - apps whose images are built from the same inputs, so the
+// image is built once by the first and deployed by the rest
+type deployGroup struct {
+	targets []deployTarget
+}
+
+// groupDeployTargets groups apps built from the same directory, Dockerfile,
+// [build] section and build args, keeping the targets' order
+func groupDeployTargets(cmdCtx *cmdctx.CmdContext, targets []deployTarget, out io.Writer) []*deployGroup {
+	var groups []*deployGroup
+	byKey := map[string]*deployGroup{}
+
+	for _, target := range targets {
+		key := deployBuildKey(newTargetContext(cmdCtx, target, nil, out))
+		if key == "" {
+			key = "app:" + target.AppName
+		}
+
+		group, ok := byKey[key]
+		if !ok {
+			group = &deployGroup{}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.targets = append(group.targets, target)
+	}
+
+	return groups
+}
+
+// deployBuildKey identifies the inputs of the app's image build, or is ""
+// when its image isn't built from source
+func deployBuildKey(cmdCtx *cmdctx.CmdContext) string {
+	if cmdCtx.Config.GetString("image") != "" || cmdCtx.AppConfig.Image() != "" {
+		return ""
+	}
+
+	opts, err := buildImageOptions(cmdCtx)
+	if err != nil {
+		return ""
+	}
+	plan, err := imgsrc.ResolvePlan(opts)
+	if err != nil {
+		terminal.Debug("could not resolve build plan for", cmdCtx.AppName, err)
+		return ""
+	}
+
+	return strings.Join([]string{plan.WorkingDir, plan.InputsHash, plan.DockerfileHash}, "|")
+}
+
+// newTargetContext returns a context for deploying target, writing its
+// output to out and with the flags in overrides replaced
+func newTargetContext(cmdCtx *cmdctx.CmdContext, target deployTarget, overrides map[string]interface{}, out io.Writer) *cmdctx.CmdContext {
+	streams := *cmdCtx.IO
+	streams.Out = out
+	streams.ErrOut = out
+	// output from concurrent deploys is interleaved line by line, so
+	// nothing can redraw the terminal
+	streams.SetStdoutTTY(false)
+	streams.SetStderrTTY(false)
+
+	child := *cmdCtx
+	child.IO = &streams
+	child.Out = out
+	child.AppName = target.AppName
+	child.AppConfig = target.AppConfig
+	child.ConfigFile = target.ConfigFile
+	child.WorkingDir = target.WorkingDir
+	child.Config = flyctl.ConfigWithOverrides(cmdCtx.Config, overrides)
+
+	return &child
+}
+
+// runMultiAppDeploy deploys several apps concurrently. Apps built from the
+// same inputs share one image, built by the first of them.
+func runMultiAppDeploy(cmdCtx *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	targets, concurrency, err := resolveDeployTargets(cmdCtx)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return flyerr.New(flyerr.InvalidArgument, "no apps to deploy")
+	}
+	if concurrency == 0 {
+		concurrency = len(targets)
+	}
+
+	var outMu sync.Mutex
+	width := 0
+	seen := map[string]bool{}
+	for _, target := range targets {
+		if seen[target.AppName] {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is listed more than once", target.AppName))
+		}
+		seen[target.AppName] = true
+		if len(target.AppName) > width {
+			width = len(target.AppName)
+		}
+	}
+	writers := map[string]*prefixedWriter{}
+	for _, target := range targets {
+		writers[target.AppName] = &prefixedWriter{
+			mu:     &outMu,
+			out:    cmdCtx.Out,
+			prefix: fmt.Sprintf("%-*s | ", width, target.AppName),
+		}
+	}
+
+	groups := groupDeployTargets(cmdCtx, targets, writers[targets[0].AppName])
+
+	cmdCtx.Status("deploy", cmdctx.STITLE, fmt.Sprintf("Deploying %d apps with %d images", len(targets), len(groups)))
+
+	results := make(map[string]error, len(targets))
+	var resultsMu sync.Mutex
+	record := func(app string, err error) {
+		resultsMu.Lock()
+		results[app] = err
+		resultsMu.Unlock()
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for _, group := range groups {
+		leader := group.targets[0]
+		followers := group.targets[1:]
+
+		// the leader's image, or nil when it failed before it had one
+		var image *imgsrc.DeploymentImage
+		ready := make(chan struct{})
+		var readyOnce sync.Once
+		imageReady := func(img *imgsrc.DeploymentImage) {
+			readyOnce.Do(func() {
+				image = img
+				close(ready)
+			})
+		}
+
+		wg.Add(1)
+		go func(target deployTarget) {
+			defer wg.Done()
+			defer imageReady(nil)
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			out := writers[target.AppName]
+			defer out.Flush()
+
+			err := deployApp(ctx, newTargetContext(cmdCtx, target, nil, out), imageReady)
+			if err != nil {
+				fmt.Fprintf(out, "Error: %s\n", err)
+			}
+			record(target.AppName, err)
+		}(leader)
+
+		for _, follower := range followers {
+			wg.Add(1)
+			go func(target deployTarget, leaderName string) {
+				defer wg.Done()
+
+				<-ready
+				if image == nil {
+					record(target.AppName, fmt.Errorf("not deployed, building the image with %s failed", leaderName))
+					return
+				}
+
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				out := writers[target.AppName]
+				defer out.Flush()
+
+				// the image was pushed, signed and given an SBOM by the leader
+				overrides := map[string]interface{}{
+					"image":     image.Tag,
+					"sign-with": "",
+					"sbom":      false,
+				}
+				fmt.Fprintf(out, "Deploying the image built for %s\n", leaderName)
+
+				err := deployApp(ctx, newTargetContext(cmdCtx, target, overrides, out), nil)
+				if err != nil {
+					fmt.Fprintf(out, "Error: %s\n", err)
+				}
+				record(target.AppName, err)
+			}(follower, leader.AppName)
+		}
+	}
+
+	wg.Wait()
+
+	fmt.Fprintln(cmdCtx.Out)
+	table := helpers.MakeSimpleTable(cmdCtx.Out, []string{"App", "Result"})
+	failed := 0
+	for _, target := range targets {
+		result := "deployed"
+		if err := results[target.AppName]; err != nil {
+			result = "failed: " + err.Error()
+			failed++
+		}
+		table.Append([]string{target.AppName, result})
+	}
+	table.Render()
+
+	if failed > 0 {
+		return flyerr.New(flyerr.DeployFailed, fmt.Sprintf("%d of %d apps failed to deploy", failed, len(targets)))
+	}
+	return nil
+}
+
+// prefixedWriter writes whole lines to out, each prefixed, so the output of
+// concurrent deploys is interleaved line by line. Writers to the same out
+// share mu.
+type prefixedWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any partial line left
+func (w *prefixedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n')
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixedWriter) writeLine(line []byte) error {
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/gitinfo"
+)
+
+// checkPlanFlags rejects flags that can't be used with --plan or --apply
+func checkPlanFlags(cmdCtx *cmdctx.CmdContext) error {
+	planning := cmdCtx.Config.GetBool("plan")
+	applying := cmdCtx.Config.GetString("apply") != ""
+	if !planning && !applying {
+		return nil
+	}
+
+	flag := "--plan"
+	if applying {
+		flag = "--apply"
+	}
+
+	switch {
+	case planning && applying:
+		return flyerr.New(flyerr.InvalidArgument, "--plan and --apply can't be used together, make the plan first and apply it in a separate deploy")
+	case isMultiAppDeploy(cmdCtx):
+		return flyerr.New(flyerr.InvalidArgument, flag+" deploys a single app, so it can't be used with --workspace or a list of apps")
+	case cmdCtx.Config.GetBool("resume"):
+		return flyerr.New(flyerr.InvalidArgument, flag+" can't be used with --resume")
+	case cmdCtx.Config.GetBool("build-only"):
+		return flyerr.New(flyerr.InvalidArgument, flag+" can't be used with --build-only")
+	case planning && cmdCtx.Config.GetString("sign-with") != "":
+		return flyerr.New(flyerr.InvalidArgument, "--sign-with signs the pushed image, so it can't be used with --plan")
+	case planning && cmdCtx.Config.GetBool("sbom"):
+		return flyerr.New(flyerr.InvalidArgument, "--sbom attaches the SBOM to the pushed image, so it can't be used with --plan")
+	case applying && cmdCtx.Config.GetString("image") != "":
+		return flyerr.New(flyerr.InvalidArgument, "--apply releases the plan's image, so it can't be used with --image")
+	}
+
+	return nil
+}
+
+// writeDeployPlan writes the plan of a deploy of imageRef, or of an image
+// built from source when it's empty, to out. Images in a registry are pinned
+// to their digest, images built from source are built when the plan is
+// applied, from the same git commit.
+func writeDeployPlan(cmdCtx *cmdctx.CmdContext, out io.Writer, imageRef string) error {
+	apiClient := cmdCtx.Client.API()
+
+	release, err := apiClient.GetAppCurrentRelease(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+	secrets, err := apiClient.GetAppSecrets(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+	configHash, err := deployment.PlanConfigHash(cmdCtx.AppConfig.Definition, cmdCtx.AppConfig.Build)
+	if err != nil {
+		return err
+	}
+
+	plan := &deployment.Plan{
+		FormatVersion: deployment.PlanFormatVersion,
+		App:           cmdCtx.AppName,
+		CreatedAt:     time.Now().UTC(),
+		SecretsDigest: deployment.SecretsDigest(secrets),
+		ConfigHash:    configHash,
+	}
+	if release != nil {
+		plan.CurrentRelease = release.Version
+	}
+
+	if imageRef != "" {
+		image, err := apiClient.ResolveImageForApp(cmdCtx.AppName, imageRef)
+		if err != nil {
+			return err
+		}
+		if image == nil {
+			return flyerr.New(flyerr.NotFound, fmt.Sprintf("%s isn't in a registry, so the plan can't pin it. Push it first, or plan a build from source", imageRef))
+		}
+		plan.Image = deployment.PlanImage{
+			Ref:    deployment.PinnedRef(image.Ref, image.Digest),
+			Digest: image.Digest,
+			Size:   int64(image.CompressedSize),
+		}
+	} else {
+		build, err := planBuild(cmdCtx)
+		if err != nil {
+			return err
+		}
+		plan.Image.Build = build
+	}
+
+	imageDescription := plan.Image.Ref
+	if plan.Image.Build != nil {
+		imageDescription = fmt.Sprintf("the image built with the %s builder", plan.Image.Build.Strategy)
+	}
+	if err := plan.AddMutation(deployment.MutationDeployImage, "Release "+imageDescription+" with the app's configuration", newDeployImageInput(cmdCtx, plan.Image.Ref)); err != nil {
+		return err
+	}
+
+	counts, sizes := processGroupScaling(cmdCtx.AppName, cmdCtx.AppConfig)
+	if len(counts) > 0 {
+		groups := make([]string, 0, len(counts))
+		for _, count := range counts {
+			groups = append(groups, fmt.Sprintf("%s=%d", count.Group, count.Count))
+		}
+		input := api.SetVMCountInput{AppID: cmdCtx.AppName, GroupCounts: counts}
+		if err := plan.AddMutation(deployment.MutationSetVMCount, "Set process group counts "+strings.Join(groups, ", "), input); err != nil {
+			return err
+		}
+	}
+	for _, size := range sizes {
+		description := fmt.Sprintf("Set the VM size of process group %s to %s", size.Group, size.SizeName)
+		if err := plan.AddMutation(deployment.MutationSetVMSize, description, size); err != nil {
+			return err
+		}
+	}
+
+	cmdCtx.Statusf("deploy", cmdctx.STITLE, "Plan for %s, against v%d\n", cmdCtx.AppName, plan.CurrentRelease)
+	for _, mutation := range plan.Mutations {
+		cmdCtx.Statusf("deploy", cmdctx.SDETAIL, "%s: %s\n", mutation.Name, mutation.Description)
+	}
+	cmdCtx.Status("deploy", cmdctx.SINFO, "Save the plan to a file and deploy it with flyctl deploy --apply <file>")
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, string(data))
+
+	return nil
+}
+
+// planBuild records how the app's image would be built, and the git commit
+// it would be built from
+func planBuild(cmdCtx *cmdctx.CmdContext) (*deployment.PlanBuild, error) {
+	opts, err := buildImageOptions(cmdCtx)
+	if err != nil {
+		return nil, err
+	}
+	buildPlan, _, err := imgsrc.CachedPlan(flyctl.ConfigDir(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	build := &deployment.PlanBuild{Strategy: buildPlan.Strategy, Dockerfile: buildPlan.Dockerfile}
+	if rel, err := filepath.Rel(cmdCtx.WorkingDir, build.Dockerfile); err == nil && build.Dockerfile != "" {
+		build.Dockerfile = filepath.ToSlash(rel)
+	}
+
+	git, err := gitinfo.Current(cmdCtx.WorkingDir)
+	if err != nil {
+		cmdCtx.Status("deploy", cmdctx.SWARN, "The source isn't in a git repository, so --apply can't check it's unchanged before building")
+		return build, nil
+	}
+	build.GitSHA = git.SHA
+	build.GitDirty = git.Dirty
+	if git.Dirty {
+		cmdCtx.Status("deploy", cmdctx.SWARN, "The source has uncommitted changes, which the plan can't pin. Commit them so --apply builds the same image")
+	}
+
+	return build, nil
+}
+
+// loadDeployPlan reads the plan at path, checking it's for the app and
+// that the app's release and secrets haven't changed since it was made
+func loadDeployPlan(cmdCtx *cmdctx.CmdContext, path string) (*deployment.Plan, error) {
+	plan, err := deployment.LoadPlan(path)
+	if err != nil {
+		return nil, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+	if plan.App != cmdCtx.AppName {
+		return nil, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("the plan is for %s, not %s", plan.App, cmdCtx.AppName))
+	}
+
+	apiClient := cmdCtx.Client.API()
+	release, err := apiClient.GetAppCurrentRelease(cmdCtx.AppName)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := apiClient.GetAppSecrets(cmdCtx.AppName)
+	if err != nil {
+		return nil, err
+	}
+	if reason := plan.Stale(release, secrets); reason != "" {
+		return nil, flyerr.New(flyerr.InvalidArgument, reason+", make a new plan with flyctl deploy --plan")
+	}
+
+	cmdCtx.Statusf("deploy", cmdctx.SINFO, "Applying the plan made %s\n", humanize.Time(plan.CreatedAt))
+	return plan, nil
+}
+
+// checkPlannedSource fails when the source isn't at the git commit the plan
+// was made from, as the image built from it could differ from the one planned
+func checkPlannedSource(cmdCtx *cmdctx.CmdContext, build *deployment.PlanBuild) error {
+	if build.GitSHA == "" {
+		cmdCtx.Status("deploy", cmdctx.SWARN, "The plan was made outside a git repository, so the source can't be checked against it")
+		return nil
+	}
+
+	git, err := gitinfo.Current(cmdCtx.WorkingDir)
+	if err != nil {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("the plan builds commit %s, but the source isn't in a git repository", build.GitSHA))
+	}
+	if git.SHA != build.GitSHA {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("the plan builds commit %s, but the source is at %s", build.GitSHA, git.SHA))
+	}
+	if git.Dirty || build.GitDirty {
+		cmdCtx.Status("deploy", cmdctx.SWARN, "The source has uncommitted changes, so the image may differ from the one planned")
+	}
+
+	return nil
+}
+
+// plannedDeployImageInput - the plan's release of img. Its annotations are
+// made afresh, so they record who applied the plan rather than who made it.
+func plannedDeployImageInput(cmdCtx *cmdctx.CmdContext, plan *deployment.Plan, img *imgsrc.DeploymentImage) (api.DeployImageInput, error) {
+	var inputs []api.DeployImageInput
+	if err := plan.MutationInputs(deployment.MutationDeployImage, &inputs); err != nil {
+		return api.DeployImageInput{}, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+	if len(inputs) != 1 || inputs[0].AppID != cmdCtx.AppName {
+		return api.DeployImageInput{}, flyerr.New(flyerr.InvalidArgument, "the plan must release a single image of "+cmdCtx.AppName)
+	}
+
+	input := inputs[0]
+	input.Image = img.Tag
+	input.Annotations = newDeployImageInput(cmdCtx, img.Tag).Annotations
+	return input, nil
+}
+
+// plannedScaling - the process group counts and VM sizes set by the plan
+func plannedScaling(plan *deployment.Plan) ([]api.VMCountInput, []api.SetVMSizeInput, error) {
+	var countInputs []api.SetVMCountInput
+	if err := plan.MutationInputs(deployment.MutationSetVMCount, &countInputs); err != nil {
+		return nil, nil, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+	var sizes []api.SetVMSizeInput
+	if err := plan.MutationInputs(deployment.MutationSetVMSize, &sizes); err != nil {
+		return nil, nil, flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+
+	counts := []api.VMCountInput{}
+	for _, input := range countInputs {
+		if input.AppID != plan.App {
+			return nil, nil, flyerr.New(flyerr.InvalidArgument, "the plan scales another app, "+input.AppID)
+		}
+		counts = append(counts, input.GroupCounts...)
+	}
+	for _, size := range sizes {
+		if size.AppID != plan.App {
+			return nil, nil, flyerr.New(flyerr.InvalidArgument, "the plan sizes another app, "+size.AppID)
+		}
+	}
+
+	return counts, sizes, nil
+}
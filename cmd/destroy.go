@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/terminal"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/logrusorgru/aurora"
@@ -22,29 +27,47 @@ func newDestroyCommand(client *client.Client) *Command {
 
 	destroy.Args = cobra.ExactArgs(1)
 
-	destroy.AddBoolFlag(BoolFlagOpts{Name: "yes", Shorthand: "y", Description: "Accept all confirmations"})
+	destroy.AddBoolFlag(BoolFlagOpts{Name: "dry-run", Description: "List what would be destroyed, without destroying it"})
 
 	return destroy
 }
 
 func runDestroy(ctx *cmdctx.CmdContext) error {
 	appName := ctx.Args[0]
+	dryRun := ctx.Config.GetBool("dry-run")
+	confirmed := autoConfirmed()
+
+	// nothing is destroyed without knowing what goes with it, unless
+	// confirmed up front
+	inventory, err := ctx.Client.API().GetAppInventory(appName)
+	switch {
+	case err != nil && (dryRun || !confirmed):
+		return fmt.Errorf("can't list the resources destroyed with %s: %w", appName, err)
+	case err != nil:
+		terminal.Warnf("Can't list the resources destroyed with %s: %v\n", appName, err)
+	default:
+		printAppInventory(ctx.Out, appName, inventory)
+	}
+
+	if dryRun {
+		fmt.Fprintln(ctx.Out, "Dry run, nothing was destroyed")
+		return nil
+	}
 
-	if !ctx.Config.GetBool("yes") {
+	if !confirmed {
 		fmt.Println(aurora.Red("Destroying an app is not reversible."))
 
-		confirm := false
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("Destroy app %s?", appName),
+		question := fmt.Sprintf("Type the app name, %s, to destroy it:", appName)
+		if err := checkCanPrompt(question, "Pass --yes to destroy it without typing its name"); err != nil {
+			return err
 		}
-		err := survey.AskOne(prompt, &confirm)
 
-		if err != nil {
+		typed := ""
+		if err := ask(&survey.Input{Message: question}, &typed); err != nil {
 			return err
 		}
-
-		if !confirm {
-			return nil
+		if strings.TrimSpace(typed) != appName {
+			return flyerr.New(flyerr.Cancelled, fmt.Sprintf("%q isn't the app name, nothing was destroyed", typed))
 		}
 	}
 
@@ -56,3 +79,49 @@ func runDestroy(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+// printAppInventory lists what's destroyed with an app, so volumes aren't
+// lost without warning
+func printAppInventory(out io.Writer, appName string, inventory *api.AppInventory) {
+	fmt.Fprintf(out, "Destroying %s also destroys:\n", aurora.Bold(appName))
+
+	if len(inventory.Volumes) == 0 && len(inventory.IPAddresses) == 0 && len(inventory.Certificates) == 0 && len(inventory.Databases) == 0 {
+		fmt.Fprintln(out, "  no volumes, IP addresses, certificates or databases")
+	}
+
+	if len(inventory.Volumes) > 0 {
+		fmt.Fprintln(out, aurora.Red("  Volumes, and the data on them:"))
+		for _, volume := range inventory.Volumes {
+			attached := ""
+			if volume.AttachedAllocation != nil {
+				attached = ", attached to " + volume.AttachedAllocation.IDShort
+			}
+			fmt.Fprintf(out, "    %s %s, %dGB in %s%s\n", volume.ID, volume.Name, volume.SizeGb, volume.Region, attached)
+		}
+	}
+
+	if len(inventory.Databases) > 0 {
+		fmt.Fprintln(out, aurora.Red("  Postgres databases:"))
+		for _, database := range inventory.Databases {
+			fmt.Fprintf(out, "    %s\n", database.Name)
+		}
+	}
+
+	if len(inventory.IPAddresses) > 0 {
+		fmt.Fprintln(out, "  IP addresses, which are released:")
+		for _, ip := range inventory.IPAddresses {
+			fmt.Fprintf(out, "    %s (%s)\n", ip.Address, ip.Type)
+		}
+	}
+
+	if len(inventory.Certificates) > 0 {
+		fmt.Fprintln(out, "  Certificates:")
+		for _, cert := range inventory.Certificates {
+			fmt.Fprintf(out, "    %s\n", cert.Hostname)
+		}
+	}
+
+	if len(inventory.DatabaseSecrets) > 0 {
+		fmt.Fprintf(out, "Attached databases aren't destroyed, only the secrets connecting to them: %s\n", strings.Join(inventory.DatabaseSecrets, ", "))
+	}
+}
@@ -3,11 +3,15 @@ package cmd
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/internal/client"
@@ -26,7 +30,8 @@ func newDNSCommand(client *client.Client) *Command {
 	recordsExportCmd.Args = cobra.MinimumNArgs(1)
 	recordsExportCmd.Args = cobra.MaximumNArgs(3)
 	recordsExportCmd.AddBoolFlag(BoolFlagOpts{
-		Name: "overwrite",
+		Name:        "overwrite",
+		Description: "overwrite the file if it already exists",
 	})
 
 	recordsImportStrings := docstrings.Get("dns-records.import")
@@ -34,6 +39,24 @@ func newDNSCommand(client *client.Client) *Command {
 	recordsImportCmd.Args = cobra.MaximumNArgs(3)
 	recordsImportCmd.Args = cobra.MinimumNArgs(1)
 
+	createStrings := docstrings.Get("dns-records.create")
+	createCmd := BuildCommandKS(cmd, runRecordsCreate, createStrings, client, requireSession)
+	createCmd.Args = cobra.ExactArgs(4)
+	createCmd.AddIntFlag(IntFlagOpts{Name: "ttl", Description: "time to live of the record in seconds", Default: 3600})
+
+	updateStrings := docstrings.Get("dns-records.update")
+	updateCmd := BuildCommandKS(cmd, runRecordsUpdate, updateStrings, client, requireSession)
+	updateCmd.Args = cobra.ExactArgs(2)
+	updateCmd.AddStringFlag(StringFlagOpts{Name: "type", Description: "the type of the record, when a name matches more than one"})
+	updateCmd.AddStringFlag(StringFlagOpts{Name: "value", Description: "the new value of the record"})
+	updateCmd.AddIntFlag(IntFlagOpts{Name: "ttl", Description: "the new time to live of the record in seconds"})
+
+	deleteStrings := docstrings.Get("dns-records.delete")
+	deleteCmd := BuildCommandKS(cmd, runRecordsDelete, deleteStrings, client, requireSession)
+	deleteCmd.Aliases = []string{"rm"}
+	deleteCmd.Args = cobra.ExactArgs(2)
+	deleteCmd.AddStringFlag(StringFlagOpts{Name: "type", Description: "the type of the record, when a name matches more than one"})
+
 	return cmd
 }
 
@@ -92,8 +115,8 @@ func runRecordsExport(ctx *cmdctx.CmdContext) error {
 		var filename = ctx.Args[1]
 
 		_, err := os.Stat(filename)
-		if err == nil {
-			return fmt.Errorf("File %s already exists", filename)
+		if err == nil && !ctx.Config.GetBool("overwrite") {
+			return fmt.Errorf("File %s already exists, pass --overwrite to replace it", filename)
 		}
 
 		err = ioutil.WriteFile(filename, []byte(records), 0644)
@@ -169,3 +192,189 @@ func runRecordsImport(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+func runRecordsCreate(ctx *cmdctx.CmdContext) error {
+	domainName, recordType, name := ctx.Args[0], strings.ToUpper(ctx.Args[1]), ctx.Args[2]
+
+	value, err := normalizeDNSRecordValue(recordType, ctx.Args[3])
+	if err != nil {
+		return err
+	}
+	if recordType == "CNAME" && name == "@" {
+		return fmt.Errorf("CNAME records can't be created at the apex of %s, use A and AAAA records instead", domainName)
+	}
+
+	domain, err := ctx.Client.API().GetDomain(domainName)
+	if err != nil {
+		return err
+	}
+
+	record, err := ctx.Client.API().CreateDNSRecord(api.CreateDNSRecordInput{
+		DomainID: domain.ID,
+		Name:     name,
+		Type:     recordType,
+		TTL:      ctx.Config.GetInt("ttl"),
+		RData:    value,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(record)
+		return nil
+	}
+
+	fmt.Printf("Created %s record %s (%s)\n", record.Type, record.FQDN, record.ID)
+	fmt.Printf("  Value:  %s\n", record.RData)
+	fmt.Printf("  TTL:    %d\n", record.TTL)
+
+	return nil
+}
+
+func runRecordsUpdate(ctx *cmdctx.CmdContext) error {
+	domainName := ctx.Args[0]
+
+	record, err := findDNSRecord(ctx, domainName, ctx.Args[1], ctx.Config.GetString("type"))
+	if err != nil {
+		return err
+	}
+
+	input := api.UpdateDNSRecordInput{
+		RecordID: record.ID,
+		TTL:      ctx.Config.GetInt("ttl"),
+	}
+	if value := ctx.Config.GetString("value"); value != "" {
+		if input.RData, err = normalizeDNSRecordValue(record.Type, value); err != nil {
+			return err
+		}
+	}
+	if input.RData == "" && input.TTL == 0 {
+		return fmt.Errorf("nothing to update, pass --value or --ttl")
+	}
+
+	updated, err := ctx.Client.API().UpdateDNSRecord(input)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(updated)
+		return nil
+	}
+
+	fmt.Printf("Updated %s record %s (%s)\n", updated.Type, updated.FQDN, updated.ID)
+	fmt.Printf("  Value:  %s\n", updated.RData)
+	fmt.Printf("  TTL:    %d\n", updated.TTL)
+
+	return nil
+}
+
+func runRecordsDelete(ctx *cmdctx.CmdContext) error {
+	domainName := ctx.Args[0]
+
+	record, err := findDNSRecord(ctx, domainName, ctx.Args[1], ctx.Config.GetString("type"))
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Delete %s record %s %s?", record.Type, record.FQDN, record.RData)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteDNSRecord(record.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %s record %s (%s)\n", record.Type, record.FQDN, record.ID)
+
+	return nil
+}
+
+// findDNSRecord finds a record of the domain by its ID, or by its name or FQDN
+// and, when the name has records of several types, its type
+func findDNSRecord(ctx *cmdctx.CmdContext, domainName string, ref string, recordType string) (*api.DNSRecord, error) {
+	records, err := ctx.Client.API().GetDNSRecords(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	ref = strings.TrimSuffix(ref, ".")
+	matches := []*api.DNSRecord{}
+	for _, record := range records {
+		if record.ID == ref {
+			matches = []*api.DNSRecord{record}
+			break
+		}
+		nameMatches := record.Name == ref || strings.TrimSuffix(record.FQDN, ".") == ref || (ref == "@" && record.IsApex)
+		if nameMatches && (recordType == "" || strings.EqualFold(record.Type, recordType)) {
+			matches = append(matches, record)
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no record %s found in %s", ref, domainName)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("%s matches %d records in %s, pass --type or a record ID from `flyctl dns-records list %s`", ref, len(matches), domainName, domainName)
+	case matches[0].IsSystem:
+		return nil, fmt.Errorf("%s record %s is managed by Fly and can't be changed", matches[0].Type, matches[0].FQDN)
+	}
+
+	return matches[0], nil
+}
+
+// normalizeDNSRecordValue validates a record value for its type, quoting TXT
+// values as they're written in zone files
+func normalizeDNSRecordValue(recordType string, value string) (string, error) {
+	switch recordType {
+	case "A":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			return "", fmt.Errorf("%q is not an IPv4 address", value)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+			return "", fmt.Errorf("%q is not an IPv6 address", value)
+		}
+	case "CNAME":
+		if value == "" || strings.ContainsAny(value, " \t") {
+			return "", fmt.Errorf("%q is not a hostname", value)
+		}
+	case "MX":
+		parts := strings.Fields(value)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("MX values are a priority and a hostname, like \"10 mail.example.com\"")
+		}
+		if _, err := strconv.ParseUint(parts[0], 10, 16); err != nil {
+			return "", fmt.Errorf("invalid MX priority %q", parts[0])
+		}
+		value = strings.Join(parts, " ")
+	case "TXT":
+		if strings.HasPrefix(value, "\"") {
+			return value, nil
+		}
+		// strings in TXT records are limited to 255 bytes, so long values are
+		// split, between characters rather than within one
+		chunks := []string{}
+		for len(value) > 255 {
+			end := 255
+			for end > 0 && !utf8.RuneStart(value[end]) {
+				end--
+			}
+			chunks = append(chunks, quoteZoneString(value[:end]))
+			value = value[end:]
+		}
+		chunks = append(chunks, quoteZoneString(value))
+		value = strings.Join(chunks, " ")
+	default:
+		return "", fmt.Errorf("unsupported record type %s, expected A, AAAA, CNAME, MX or TXT", recordType)
+	}
+
+	return value, nil
+}
+
+// quoteZoneString quotes s as a zone file character string, where only quotes
+// and backslashes are escaped
+func quoteZoneString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
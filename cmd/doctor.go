@@ -0,0 +1,581 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdlog"
+	"github.com/superfly/flyctl/internal/doctor"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/wireguard"
+	"github.com/superfly/flyctl/pkg/wg"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newDoctorCommand(client *client.Client) *Command {
+	doctorStrings := docstrings.Get("doctor")
+	cmd := BuildCommandKS(nil, runDoctor, doctorStrings, client)
+	cmd.Args = cobra.NoArgs
+	addDoctorFlags(cmd)
+
+	bundleStrings := docstrings.Get("doctor.bundle")
+	bundle := BuildCommandKS(cmd, runDoctorBundle, bundleStrings, client)
+	bundle.Args = cobra.NoArgs
+	addDoctorFlags(bundle)
+	bundle.AddStringFlag(StringFlagOpts{Name: "file", Description: "Path to write the bundle to. Defaults to flyctl-bundle-<time>.tar.gz in the working directory"})
+
+	return cmd
+}
+
+func addDoctorFlags(cmd *Command) {
+	cmd.AddStringFlag(StringFlagOpts{Name: "app", Shorthand: "a", Description: "App to check the .internal address of", EnvName: "FLY_APP"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "Organization to check WireGuard and the remote builder in. Defaults to the app's organization, or your personal one"})
+	cmd.AddIntFlag(IntFlagOpts{Name: "timeout", Description: "Seconds to wait for each check", Default: 30})
+}
+
+// doctorSession - state shared between checks, so later checks can use the
+// client, organization and tunnel earlier ones set up
+type doctorSession struct {
+	cmdCtx  *cmdctx.CmdContext
+	appName string
+	org     *api.Organization
+	tunnel  *wg.Tunnel
+}
+
+func runDoctor(cmdCtx *cmdctx.CmdContext) error {
+	report := runDoctorChecks(cmdCtx, !cmdCtx.OutputJSON())
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(report)
+	} else {
+		fmt.Fprintf(cmdCtx.Out, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+			report.Count(doctor.StatusPass), report.Count(doctor.StatusWarn), report.Count(doctor.StatusFail), report.Count(doctor.StatusSkip))
+	}
+
+	if !report.Passed() {
+		return fmt.Errorf("%d checks failed", report.Count(doctor.StatusFail))
+	}
+
+	return nil
+}
+
+// runDoctorChecks runs every check, printing results as they finish if show is set
+func runDoctorChecks(cmdCtx *cmdctx.CmdContext, show bool) doctor.Report {
+	session := &doctorSession{cmdCtx: cmdCtx, appName: doctorAppName(cmdCtx)}
+	defer func() {
+		if session.tunnel != nil {
+			session.tunnel.Close()
+		}
+	}()
+
+	checks := []doctor.Check{
+		{Name: "Docker", Run: session.checkDocker},
+		{Name: "Network", Run: session.checkNetwork},
+		{Name: "Proxy", Run: session.checkProxy},
+		{Name: "API", Run: session.checkAPI},
+		{Name: "Token", Requires: []string{"API"}, Run: session.checkToken},
+		{Name: "WireGuard", Requires: []string{"Token"}, Run: session.checkWireGuard},
+		{Name: "Internal DNS", Requires: []string{"WireGuard"}, Run: session.checkInternalDNS},
+		{Name: "Remote builder", Requires: []string{"WireGuard"}, Run: session.checkRemoteBuilder},
+	}
+
+	report := doctor.Report{
+		Version:   flyctl.Version,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		StartedAt: time.Now(),
+	}
+
+	ctx := createCancellableContext()
+	timeout := time.Duration(cmdCtx.Config.GetInt("timeout")) * time.Second
+
+	report.Results = doctor.Run(ctx, checks, timeout, func(result doctor.Result) {
+		if show {
+			printDoctorResult(cmdCtx, result)
+		}
+	})
+
+	return report
+}
+
+// doctorAppName - the app given with --app, or in fly.toml in the working directory
+func doctorAppName(cmdCtx *cmdctx.CmdContext) string {
+	if appName := cmdCtx.Config.GetString("app"); appName != "" {
+		return appName
+	}
+
+	if path := doctorAppConfigPath(cmdCtx); path != "" {
+		if appConfig, err := flyctl.LoadAppConfig(path); err == nil {
+			return appConfig.AppName
+		}
+	}
+
+	return ""
+}
+
+func doctorAppConfigPath(cmdCtx *cmdctx.CmdContext) string {
+	path, err := flyctl.ResolveConfigFileFromPath(cmdCtx.WorkingDir)
+	if err != nil || !helpers.FileExists(path) {
+		return ""
+	}
+	return path
+}
+
+func printDoctorResult(cmdCtx *cmdctx.CmdContext, result doctor.Result) {
+	var mark string
+	switch result.Status {
+	case doctor.StatusPass:
+		mark = aurora.Green("✓").String()
+	case doctor.StatusWarn:
+		mark = aurora.Yellow("!").String()
+	case doctor.StatusFail:
+		mark = aurora.Red("✘").String()
+	default:
+		mark = aurora.Faint("-").String()
+	}
+
+	message := result.Message
+	if result.Status == doctor.StatusSkip {
+		message = aurora.Faint("skipped, " + message).String()
+	}
+
+	fmt.Fprintf(cmdCtx.Out, "%s %-15s %s\n", mark, result.Name, message)
+	if result.Hint != "" {
+		fmt.Fprintf(cmdCtx.Out, "  %-15s %s\n", "", aurora.Faint(result.Hint))
+	}
+}
+
+func (s *doctorSession) checkDocker(ctx context.Context) (string, error) {
+	version, err := imgsrc.LocalDockerVersion(ctx)
+	if err != nil {
+		// deploys fall back to remote builders, so this isn't fatal
+		return "", doctor.Warn(fmt.Errorf("local Docker isn't available: %w", err),
+			"Start Docker to build locally. Deploys will use a remote builder until then.")
+	}
+
+	return fmt.Sprintf("running Docker %s", version), nil
+}
+
+func (s *doctorSession) checkNetwork(ctx context.Context) (string, error) {
+	ipv4, ipv6 := wg.HasIPv4Route(), wg.HasIPv6Route()
+
+	switch {
+	case ipv4 && ipv6:
+		return "routes to IPv4 and IPv6", nil
+	case ipv4:
+		return "routes to IPv4 only", nil
+	case ipv6:
+		prefix, err := wg.NAT64Prefix(ctx)
+		if err != nil {
+			return "", doctor.Warn(fmt.Errorf("routes to IPv6 only, without NAT64 to reach IPv4 WireGuard gateways: %w", err),
+				"Tunnel over WebSockets with --wg-websockets through an HTTPS_PROXY that can reach IPv4.")
+		}
+		return fmt.Sprintf("routes to IPv6 only, reaching IPv4 through NAT64 prefix %s", prefix), nil
+	}
+
+	return "", doctor.Fail(errors.New("no route to IPv4 or IPv6"), "Check your internet connection.")
+}
+
+func (s *doctorSession) checkProxy(ctx context.Context) (string, error) {
+	baseURL := viper.GetString(flyctl.ConfigAPIBaseURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("invalid proxy: %w", err),
+			"Set HTTPS_PROXY to a URL such as http://proxy.example.com:3128.")
+	}
+	if proxyURL == nil {
+		return "", doctor.Skip("no proxy set for %s", req.URL.Hostname())
+	}
+
+	host := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("can't connect to proxy %s: %w", host, err),
+			fmt.Sprintf("Check HTTPS_PROXY, or add %s to NO_PROXY if the proxy shouldn't be used for it.", req.URL.Hostname()))
+	}
+	conn.Close()
+
+	return fmt.Sprintf("API requests and WireGuard over WebSockets go through %s", host), nil
+}
+
+func (s *doctorSession) checkAPI(ctx context.Context) (string, error) {
+	baseURL := viper.GetString(flyctl.ConfigAPIBaseURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("can't reach %s: %w", baseURL, err),
+			"Check your internet connection and any HTTP proxy settings, and https://status.flyio.net for incidents.")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return "", doctor.Fail(fmt.Errorf("%s responded with %s", baseURL, resp.Status),
+			"The API is having problems. Check https://status.flyio.net for incidents.")
+	}
+
+	return fmt.Sprintf("reached %s in %s", baseURL, time.Since(start).Round(time.Millisecond)), nil
+}
+
+func (s *doctorSession) checkToken(ctx context.Context) (string, error) {
+	if !s.cmdCtx.Client.InitApi() {
+		return "", doctor.Fail(client.ErrNoAuthToken, "Log in with 'flyctl auth login', or set FLY_API_TOKEN.")
+	}
+
+	user, err := s.cmdCtx.Client.API().GetCurrentUser()
+	if err != nil {
+		if api.IsNotAuthenticatedError(err) {
+			return "", doctor.Fail(errors.New("the access token is invalid or has expired"),
+				"Log in again with 'flyctl auth login', or create a new token for FLY_API_TOKEN.")
+		}
+		return "", err
+	}
+
+	if profile := flyctl.CurrentProfile(); profile != "" {
+		return fmt.Sprintf("logged in as %s with profile %s", user.Email, profile), nil
+	}
+	return fmt.Sprintf("logged in as %s", user.Email), nil
+}
+
+func (s *doctorSession) organization() (*api.Organization, error) {
+	if s.org != nil {
+		return s.org, nil
+	}
+
+	apiClient := s.cmdCtx.Client.API()
+
+	switch slug := s.cmdCtx.Config.GetString("org"); {
+	case slug != "":
+		org, err := apiClient.FindOrganizationBySlug(slug)
+		if err != nil {
+			return nil, err
+		}
+		s.org = org
+	case s.appName != "":
+		app, err := apiClient.GetApp(s.appName)
+		if err != nil {
+			return nil, err
+		}
+		s.org = &app.Organization
+	default:
+		personal, _, err := apiClient.GetCurrentOrganizations()
+		if err != nil {
+			return nil, err
+		}
+		s.org = &personal
+	}
+
+	return s.org, nil
+}
+
+func (s *doctorSession) checkWireGuard(ctx context.Context) (string, error) {
+	org, err := s.organization()
+	if err != nil {
+		return "", err
+	}
+
+	// checks only use what's already there, a peer is created by the first
+	// command that needs one
+	state, err := wireguard.SavedStateForOrg(org)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("can't read the WireGuard peer for %s: %w", org.Slug, err),
+			"Remove stale peers with 'flyctl wireguard list' and 'flyctl wireguard remove'.")
+	}
+	if state == nil {
+		return "", doctor.Skip("no WireGuard peer for %s yet, commands like 'flyctl ssh console' create one", org.Slug)
+	}
+
+	tunnel, err := wg.ConnectContext(ctx, *state.TunnelConfig())
+	if err != nil {
+		return "", err
+	}
+	s.tunnel = tunnel
+
+	if _, err := tunnel.Resolver().LookupTXT(ctx, "_apps.internal"); err != nil {
+		return "", doctor.Fail(fmt.Errorf("no response through the tunnel to %s: %w", state.Peer.Endpointip, err),
+			"WireGuard runs over UDP port 51820. Check that your network and firewall allow outbound UDP to it, or tunnel over WebSockets with --wg-websockets.")
+	}
+
+	if tunnel.WebSockets() {
+		return fmt.Sprintf("connected to %s through %s in %s over WebSockets", org.Slug, state.Region, state.Peer.Endpointip), nil
+	}
+	return fmt.Sprintf("connected to %s through %s in %s", org.Slug, state.Region, state.Peer.Endpointip), nil
+}
+
+func (s *doctorSession) checkInternalDNS(ctx context.Context) (string, error) {
+	if s.appName == "" {
+		return "", doctor.Skip("no app given, use --app to check one")
+	}
+
+	name := s.appName + ".internal"
+
+	addrs, err := s.tunnel.Resolver().LookupHost(ctx, name)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("can't resolve %s: %w", name, err),
+			fmt.Sprintf("Check that %s is running with 'flyctl status -a %s'.", s.appName, s.appName))
+	}
+
+	return fmt.Sprintf("%s resolves to %s", name, strings.Join(addrs, ", ")), nil
+}
+
+func (s *doctorSession) checkRemoteBuilder(ctx context.Context) (string, error) {
+	org, err := s.organization()
+	if err != nil {
+		return "", err
+	}
+
+	app, err := findRemoteBuilder(s.cmdCtx.Client.API(), org)
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("can't look up the remote builder in %s: %w", org.Slug, err),
+			"Build locally with Docker until this is resolved, using 'flyctl deploy --local-only'.")
+	}
+	if app == nil {
+		return "", doctor.Skip("no remote builder in %s yet, the first remote build creates one", org.Slug)
+	}
+
+	addrs, err := s.tunnel.Resolver().LookupHost(ctx, app.Name+".internal")
+	if err != nil || len(addrs) == 0 {
+		return "", doctor.Fail(fmt.Errorf("remote builder %s isn't running", app.Name),
+			fmt.Sprintf("Restart it with 'flyctl apps restart %s', or destroy it and a new one will be created.", app.Name))
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return s.tunnel.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	url := fmt.Sprintf("http://%s/_ping", net.JoinHostPort(addrs[0], "2375"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = errors.New(resp.Status)
+		}
+	}
+	if err != nil {
+		return "", doctor.Fail(fmt.Errorf("remote builder %s isn't responding: %w", app.Name, err),
+			fmt.Sprintf("Check its logs with 'flyctl logs -a %s', or destroy it and a new one will be created.", app.Name))
+	}
+
+	return fmt.Sprintf("%s is healthy", app.Name), nil
+}
+
+// remoteBuilderPrefix - the start of the names of the builder apps
+// ensureRemoteBuilder creates
+const remoteBuilderPrefix = "fly-builder-"
+
+// findRemoteBuilder returns the builder org's builds are pinned to, or else
+// its existing default builder, or nil when it has none. Unlike
+// EnsureRemoteBuilderForOrg it never creates one.
+func findRemoteBuilder(client *api.Client, org *api.Organization) (*api.App, error) {
+	pinned, err := client.GetPinnedRemoteBuilder(org.Slug)
+	if err != nil {
+		terminal.Debugf("error looking up %s's pinned remote builder: %v\n", org.Slug, err)
+	} else if pinned != nil {
+		return pinned, nil
+	}
+
+	apps, err := client.GetApps(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range apps {
+		if app.Organization.Slug == org.Slug && strings.HasPrefix(app.Name, remoteBuilderPrefix) {
+			app := app
+			return &app, nil
+		}
+	}
+	return nil, nil
+}
+
+func runDoctorBundle(cmdCtx *cmdctx.CmdContext) error {
+	if !autoConfirmed() && !cmdCtx.IO.IsInteractive() {
+		return flyerr.New(flyerr.InvalidArgument, "the bundle can only be reviewed interactively, use --yes to write it without reviewing")
+	}
+
+	bundle := &doctor.Bundle{}
+
+	version, _ := json.MarshalIndent(map[string]string{
+		"version":    flyctl.Version,
+		"commit":     flyctl.Commit,
+		"build_date": flyctl.BuildDate,
+		"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+	}, "", "  ")
+	bundle.Add("version.json", version)
+
+	fmt.Fprintln(cmdCtx.Out, "Running diagnostic checks")
+	report := runDoctorChecks(cmdCtx, true)
+	checks, _ := json.MarshalIndent(report, "", "  ")
+	bundle.Add("doctor.json", checks)
+
+	entries, err := cmdlog.Recent(flyctl.ConfigDir(), doctorBundleCommands)
+	if err != nil {
+		terminal.Debug("error reading command log:", err)
+	}
+	var commands bytes.Buffer
+	for _, entry := range entries {
+		line, _ := json.Marshal(entry)
+		commands.Write(line)
+		commands.WriteByte('\n')
+	}
+	bundle.Add("commands.log", commands.Bytes())
+
+	if data, err := ioutil.ReadFile(filepath.Join(flyctl.ConfigDir(), "config.yml")); err == nil {
+		bundle.Add("config.yml", data)
+	}
+
+	if path := doctorAppConfigPath(cmdCtx); path != "" {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			bundle.Add(filepath.Base(path), data)
+		}
+	}
+
+	var env bytes.Buffer
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "FLY_") || strings.HasPrefix(kv, "DOCKER_") {
+			fmt.Fprintln(&env, kv)
+		}
+	}
+	bundle.Add("env.txt", env.Bytes())
+
+	fmt.Fprintln(cmdCtx.Out)
+
+	if !autoConfirmed() {
+		write, err := reviewDoctorBundle(cmdCtx, bundle)
+		if err != nil {
+			return err
+		}
+		if !write {
+			return nil
+		}
+	}
+
+	output := cmdCtx.Config.GetString("file")
+	if output == "" {
+		output = filepath.Join(cmdCtx.WorkingDir, fmt.Sprintf("flyctl-bundle-%s.tar.gz", time.Now().Format("20060102-150405")))
+	}
+
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := bundle.Write(f); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.Out, "Wrote %s with %d files. Attach it to your support request.\n", output, len(bundle.Files))
+
+	return nil
+}
+
+// doctorBundleCommands - how many recent commands are included in bundles
+const doctorBundleCommands = 50
+
+// reviewDoctorBundle lets the user look through and remove files before the
+// bundle is written, returning false if they cancel
+func reviewDoctorBundle(cmdCtx *cmdctx.CmdContext, bundle *doctor.Bundle) (bool, error) {
+	for {
+		fmt.Fprintln(cmdCtx.Out, "The bundle contains these files. Values that look like secrets have been redacted.")
+		table := helpers.MakeSimpleTable(cmdCtx.Out, []string{"File", "Size", "Redacted"})
+		for _, file := range bundle.Files {
+			table.Append([]string{file.Name, humanize.Bytes(uint64(len(file.Data))), strconv.Itoa(file.Redactions)})
+		}
+		table.Render()
+		fmt.Fprintln(cmdCtx.Out)
+
+		const (
+			write  = "Write the bundle"
+			view   = "View a file"
+			remove = "Remove a file"
+			cancel = "Cancel"
+		)
+
+		action := ""
+		if err := ask(&survey.Select{Message: "Review the bundle:", Options: []string{write, view, remove, cancel}}, &action); err != nil {
+			if isInterrupt(err) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		switch action {
+		case write:
+			return true, nil
+		case cancel:
+			return false, nil
+		}
+
+		names := []string{}
+		for _, file := range bundle.Files {
+			names = append(names, file.Name)
+		}
+
+		selected := 0
+		if err := ask(&survey.Select{Message: "File:", Options: names}, &selected); err != nil {
+			if isInterrupt(err) {
+				continue
+			}
+			return false, err
+		}
+
+		file := bundle.Files[selected]
+		if action == view {
+			fmt.Fprintf(cmdCtx.Out, "\n%s\n%s\n", aurora.Bold(file.Name), file.Data)
+		} else {
+			bundle.Remove(file.Name)
+			fmt.Fprintf(cmdCtx.Out, "Removed %s\n\n", file.Name)
+		}
+	}
+}
@@ -2,17 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/dustin/go-humanize"
-	"github.com/olekukonko/tablewriter"
+	"github.com/logrusorgru/aurora"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
 )
 
@@ -23,6 +26,7 @@ func newDomainsCommand(client *client.Client) *Command {
 	listStrings := docstrings.Get("domains.list")
 	listCmd := BuildCommandKS(cmd, runDomainsList, listStrings, client, requireSession)
 	listCmd.Args = cobra.MaximumNArgs(1)
+	listCmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "The organization to list domains and certificates for"})
 
 	showCmd := BuildCommandKS(cmd, runDomainsShow, docstrings.Get("domains.show"), client, requireSession)
 	showCmd.Args = cobra.ExactArgs(1)
@@ -37,16 +41,16 @@ func newDomainsCommand(client *client.Client) *Command {
 }
 
 func runDomainsList(ctx *cmdctx.CmdContext) error {
-	var orgSlug string
-	if len(ctx.Args) == 0 {
+	orgSlug := ctx.Config.GetString("org")
+	if len(ctx.Args) > 0 {
+		orgSlug = ctx.Args[0]
+	}
+	if orgSlug == "" {
 		org, err := selectOrganization(ctx.Client.API(), "", nil)
 		if err != nil {
 			return err
 		}
 		orgSlug = org.Slug
-	} else {
-		// TODO: Validity check on org
-		orgSlug = ctx.Args[0]
 	}
 
 	domains, err := ctx.Client.API().GetDomains(orgSlug)
@@ -54,24 +58,168 @@ func runDomainsList(ctx *cmdctx.CmdContext) error {
 		return err
 	}
 
+	apps, err := ctx.Client.API().GetOrganizationCertificates(orgSlug)
+	if err != nil {
+		return err
+	}
+
+	inventory := buildDomainInventory(domains, apps)
+
 	if ctx.OutputJSON() {
-		ctx.WriteJSON(domains)
+		ctx.WriteJSON(inventory)
 		return nil
 	}
 
-	table := tablewriter.NewWriter(ctx.Out)
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Hostname", "App", "Certificate", "Expires", "DNS", "Domain"})
+
+	expiring := 0
+	for _, entry := range inventory {
+		expires := ""
+		if entry.ExpiresAt != nil {
+			expires = presenters.FormatRelativeTime(*entry.ExpiresAt)
+			switch until := time.Until(*entry.ExpiresAt); {
+			case until < 0:
+				expires = aurora.Red(expires).String()
+				expiring++
+			case until < certificateExpiryWarning:
+				expires = aurora.Yellow(expires).String()
+				expiring++
+			}
+		}
 
-	table.SetHeader([]string{"Domain", "Registration Status", "DNS Status", "Created"})
+		dns := entry.DNSStatus
+		if entry.App != "" && !entry.DNSVerified {
+			dns = aurora.Yellow(dns).String()
+		}
 
-	for _, domain := range domains {
-		table.Append([]string{domain.Name, *domain.RegistrationStatus, *domain.DnsStatus, presenters.FormatRelativeTime(domain.CreatedAt)})
+		table.Append([]string{entry.Hostname, entry.App, entry.CertificateStatus, expires, dns, entry.DomainStatus})
 	}
-
 	table.Render()
 
+	apps = appsWithCertificates(apps)
+	fmt.Fprintf(ctx.Out, "\n%d hostnames across %d apps and %d domains in %s", len(inventory), len(apps), len(domains), orgSlug)
+	if expiring > 0 {
+		fmt.Fprintf(ctx.Out, ", %d certificates expired or expiring within %d days", expiring, int(certificateExpiryWarning.Hours()/24))
+	}
+	fmt.Fprintln(ctx.Out)
+
 	return nil
 }
 
+// certificateExpiryWarning - how soon before expiring certificates are highlighted
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// domainInventoryEntry - a hostname in an organization: the app with a
+// certificate for it and the organization's domain it belongs to
+type domainInventoryEntry struct {
+	Hostname          string
+	App               string
+	CertificateStatus string
+	ExpiresAt         *time.Time
+	DNSVerified       bool
+	DNSStatus         string
+	Domain            string
+	DomainStatus      string
+}
+
+// buildDomainInventory lists every certificate of every app, along with the
+// organization's domains that no certificate covers
+func buildDomainInventory(domains []*api.Domain, apps []api.App) []domainInventoryEntry {
+	inventory := []domainInventoryEntry{}
+	covered := map[string]bool{}
+
+	for _, app := range apps {
+		for _, cert := range app.Certificates.Nodes {
+			entry := domainInventoryEntry{
+				Hostname:          cert.Hostname,
+				App:               app.Name,
+				CertificateStatus: cert.ClientStatus,
+				DNSVerified:       cert.Configured,
+				DNSStatus:         certificateDNSStatus(cert),
+			}
+
+			for _, issued := range cert.Issued.Nodes {
+				expiresAt := issued.ExpiresAt
+				if entry.ExpiresAt == nil || expiresAt.Before(*entry.ExpiresAt) {
+					entry.ExpiresAt = &expiresAt
+				}
+			}
+
+			if domain := domainForHostname(domains, cert.Hostname); domain != nil {
+				entry.Domain = domain.Name
+				entry.DomainStatus = domainStatus(domain)
+				covered[domain.Name] = true
+			}
+
+			inventory = append(inventory, entry)
+		}
+	}
+
+	for _, domain := range domains {
+		if !covered[domain.Name] {
+			inventory = append(inventory, domainInventoryEntry{
+				Hostname:     domain.Name,
+				DNSStatus:    "no certificate",
+				Domain:       domain.Name,
+				DomainStatus: domainStatus(domain),
+			})
+		}
+	}
+
+	sort.SliceStable(inventory, func(i, j int) bool {
+		return inventory[i].Hostname < inventory[j].Hostname
+	})
+
+	return inventory
+}
+
+func certificateDNSStatus(cert api.AppCertificate) string {
+	switch {
+	case cert.Configured:
+		return "verified"
+	case cert.AcmeDNSConfigured || cert.AcmeALPNConfigured:
+		return "validated, not pointed at app"
+	default:
+		return "not configured"
+	}
+}
+
+// domainForHostname finds the most specific domain containing hostname
+func domainForHostname(domains []*api.Domain, hostname string) *api.Domain {
+	hostname = strings.TrimPrefix(hostname, "*.")
+
+	var found *api.Domain
+	for _, domain := range domains {
+		if hostname == domain.Name || strings.HasSuffix(hostname, "."+domain.Name) {
+			if found == nil || len(domain.Name) > len(found.Name) {
+				found = domain
+			}
+		}
+	}
+	return found
+}
+
+func domainStatus(domain *api.Domain) string {
+	status := []string{}
+	if domain.RegistrationStatus != nil {
+		status = append(status, strings.ToLower(*domain.RegistrationStatus))
+	}
+	if domain.DnsStatus != nil {
+		status = append(status, "dns "+strings.ToLower(*domain.DnsStatus))
+	}
+	return strings.Join(status, ", ")
+}
+
+func appsWithCertificates(apps []api.App) []api.App {
+	out := []api.App{}
+	for _, app := range apps {
+		if len(app.Certificates.Nodes) > 0 {
+			out = append(out, app)
+		}
+	}
+	return out
+}
+
 func runDomainsShow(ctx *cmdctx.CmdContext) error {
 	name := ctx.Args[0]
 
@@ -125,7 +273,7 @@ func runDomainsCreate(ctx *cmdctx.CmdContext) error {
 		}
 
 		prompt := &survey.Input{Message: "Domain name to add"}
-		err := survey.AskOne(prompt, &name)
+		err := ask(prompt, &name)
 		checkErr(err)
 
 		// TODO: Add some domain validation here
@@ -163,7 +311,7 @@ func runDomainsRegister(ctx *cmdctx.CmdContext) error {
 		}
 
 		prompt := &survey.Input{Message: "Domain name to add"}
-		err := survey.AskOne(prompt, &name)
+		err := ask(prompt, &name)
 		checkErr(err)
 		// TODO: Add some domain validation here
 	} else if len(ctx.Args) == 2 {
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	dockerparser "github.com/novln/docker-parser"
+	"github.com/pkg/errors"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdfmt"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/registry"
+	"github.com/superfly/flyctl/internal/sbom"
+)
+
+func newImageCommand(client *client.Client) *Command {
+	imageStrings := docstrings.Get("image")
+	cmd := BuildCommandKS(nil, nil, imageStrings, client)
+
+	sbomStrings := docstrings.Get("image.sbom")
+	sbomCmd := BuildCommandKS(cmd, runImageSBOM, sbomStrings, client, requireSession, requireAppNameUnlessImage)
+	sbomCmd.AddStringFlag(StringFlagOpts{
+		Name:        "image",
+		Shorthand:   "i",
+		Description: "Image to show the SBOM of. Defaults to the image of the app's current release",
+	})
+	sbomCmd.AddStringFlag(StringFlagOpts{
+		Name:        "file",
+		Description: "Write the SBOM to this file instead of printing it",
+	})
+
+	return cmd
+}
+
+// requireAppNameUnlessImage - requireAppName, except an image given with
+// --image doesn't need an app
+func requireAppNameUnlessImage(cmd *Command) Initializer {
+	init := requireAppName(cmd)
+	preRun := init.PreRun
+	init.PreRun = func(ctx *cmdctx.CmdContext) error {
+		if ctx.Config.GetString("image") != "" {
+			return nil
+		}
+		return preRun(ctx)
+	}
+	return init
+}
+
+func runImageSBOM(cmdCtx *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	imageRef := cmdCtx.Config.GetString("image")
+	if imageRef == "" {
+		release, err := cmdCtx.Client.API().GetAppCurrentRelease(cmdCtx.AppName)
+		if err != nil {
+			return err
+		}
+		if release == nil || release.ImageRef == "" {
+			return fmt.Errorf("%s has no releases, pass an image with --image", cmdCtx.AppName)
+		}
+		imageRef = release.ImageRef
+	}
+
+	client := newRegistryClient()
+
+	ref, digest, err := resolveImageDigest(ctx, client, imageRef)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := sbom.Fetch(ctx, client, ref.Registry(), ref.ShortName(), digest)
+	if errors.Is(err, registry.ErrNotFound) {
+		return flyerr.New(flyerr.NotFound, fmt.Sprintf("%s has no SBOM, deploy with --sbom to generate one", imageRef))
+	}
+	if err != nil {
+		return err
+	}
+
+	if path := cmdCtx.Config.GetString("file"); path != "" {
+		return ioutil.WriteFile(path, data, 0644)
+	}
+
+	_, err = cmdCtx.Out.Write(data)
+	return err
+}
+
+// attachSBOM generates an SBOM of the pushed image with syft and stores it
+// in the image's registry
+func attachSBOM(ctx context.Context, cmdCtx *cmdctx.CmdContext, imageRef string, formatName string) error {
+	format, err := sbom.FormatNamed(formatName)
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidArgument, err)
+	}
+
+	client := newRegistryClient()
+
+	ref, digest, err := resolveImageDigest(ctx, client, imageRef)
+	if err != nil {
+		return err
+	}
+
+	cmdfmt.PrintBegin(cmdCtx.Out, "Generating SBOM")
+
+	var creds *sbom.Credentials
+	if ref.Registry() == flyRegistry {
+		creds = &sbom.Credentials{Host: ref.Registry(), Username: "x", Password: flyctl.GetAPIToken()}
+	}
+
+	pinned := fmt.Sprintf("%s@%s", ref.Repository(), digest)
+	data, err := sbom.Generate(ctx, pinned, format, creds)
+	if err != nil {
+		return err
+	}
+
+	if err := sbom.Attach(ctx, client, ref.Registry(), ref.ShortName(), digest, format, data); err != nil {
+		return errors.Wrap(err, "could not attach the SBOM")
+	}
+
+	cmdfmt.PrintDone(cmdCtx.Out, fmt.Sprintf("Attached %s SBOM to %s", format.Name, pinned))
+
+	return nil
+}
+
+// resolveImageDigest parses imageRef, returning it and the digest of the
+// manifest it refers to
+func resolveImageDigest(ctx context.Context, client *registry.Client, imageRef string) (*dockerparser.Reference, string, error) {
+	ref, err := dockerparser.Parse(imageRef)
+	if err != nil {
+		return nil, "", flyerr.Wrap(flyerr.InvalidArgument, fmt.Errorf("invalid image reference %s: %w", imageRef, err))
+	}
+
+	digest, err := client.Digest(ctx, ref.Registry(), ref.ShortName(), ref.Tag())
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "could not resolve the digest of %s", imageRef)
+	}
+
+	return ref, digest, nil
+}
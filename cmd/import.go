@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/compose"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// composeFileNames - the compose files looked for when none is given, in the
+// order docker compose looks for them
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}
+
+func newImportCommand(client *client.Client) *Command {
+	importStrings := docstrings.Get("import")
+	cmd := BuildCommandKS(nil, nil, importStrings, client)
+
+	composeStrings := docstrings.Get("import.compose")
+	composeCmd := BuildCommandKS(cmd, runImportCompose, composeStrings, client)
+	composeCmd.Args = cobra.MaximumNArgs(1)
+	composeCmd.AddStringFlag(StringFlagOpts{
+		Name:        "prefix",
+		Description: "Prefix for the app names, which are the service names after it. Defaults to the compose file's directory name",
+	})
+	composeCmd.AddStringFlag(StringFlagOpts{
+		Name:        "dir",
+		Description: "Directory to write the config files to. Defaults to the compose file's directory",
+	})
+	composeCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "overwrite",
+		Description: "Overwrite existing config files",
+	})
+
+	return cmd
+}
+
+// importedApp - an app written by import compose, as reported with --json
+type importedApp struct {
+	Service string   `json:"service"`
+	App     string   `json:"app"`
+	Config  string   `json:"config"`
+	Secrets []string `json:"secrets"`
+	Volume  string   `json:"volume,omitempty"`
+	Notes   []string `json:"notes"`
+}
+
+func runImportCompose(cmdCtx *cmdctx.CmdContext) error {
+	composePath, err := findComposeFile(cmdCtx)
+	if err != nil {
+		return err
+	}
+	composeDir := filepath.Dir(composePath)
+
+	outDir := composeDir
+	if dir := cmdCtx.Config.GetString("dir"); dir != "" {
+		if outDir, err = filepath.Abs(dir); err != nil {
+			return err
+		}
+	}
+
+	prefix := cmdCtx.Config.GetString("prefix")
+	if !cmdCtx.Config.IsSet("prefix") {
+		prefix = filepath.Base(composeDir)
+	}
+
+	file, err := os.Open(composePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	project, err := compose.Parse(file)
+	if err != nil {
+		return flyerr.Wrap(flyerr.InvalidConfig, err)
+	}
+
+	apps, err := compose.Translate(project, compose.Options{AppPrefix: prefix, ComposeDir: composeDir, OutDir: outDir})
+	if err != nil {
+		return err
+	}
+
+	paths := map[string]string{}
+	for _, app := range apps {
+		name := "fly.toml"
+		if len(apps) > 1 {
+			name = fmt.Sprintf("fly.%s.toml", app.Service)
+		}
+		paths[app.Service] = filepath.Join(outDir, name)
+	}
+	workspacePath := filepath.Join(outDir, flyctl.WorkspaceFileName)
+
+	if !cmdCtx.Config.GetBool("overwrite") {
+		var existing []string
+		for _, app := range apps {
+			if helpers.FileExists(paths[app.Service]) {
+				existing = append(existing, helpers.PathRelativeToCWD(paths[app.Service]))
+			}
+		}
+		if len(apps) > 1 && helpers.FileExists(workspacePath) {
+			existing = append(existing, helpers.PathRelativeToCWD(workspacePath))
+		}
+		if len(existing) > 0 {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s already exist, use --overwrite to replace them", strings.Join(existing, ", ")))
+		}
+	}
+
+	report := make([]importedApp, 0, len(apps))
+	workspace := flyctl.Workspace{}
+	for _, app := range apps {
+		path := paths[app.Service]
+		if err := app.Config.WriteToFile(path); err != nil {
+			return err
+		}
+		workspace.Apps = append(workspace.Apps, flyctl.WorkspaceApp{Config: filepath.Base(path)})
+
+		report = append(report, importedApp{
+			Service: app.Service,
+			App:     app.Name,
+			Config:  helpers.PathRelativeToCWD(path),
+			Secrets: app.Secrets,
+			Volume:  app.Volume,
+			Notes:   app.Notes,
+		})
+	}
+
+	if len(apps) > 1 {
+		if err := writeWorkspace(workspacePath, workspace); err != nil {
+			return err
+		}
+	}
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(report)
+		return nil
+	}
+
+	for _, app := range report {
+		fmt.Fprintf(cmdCtx.Out, "%s: wrote %s for app %s\n", app.Service, app.Config, app.App)
+		if len(app.Secrets) > 0 {
+			fmt.Fprintf(cmdCtx.Out, "  - set secrets with flyctl secrets set -a %s %s\n", app.App, strings.Join(app.Secrets, "=... ")+"=...")
+		}
+		for _, note := range app.Notes {
+			fmt.Fprintf(cmdCtx.Out, "  - %s\n", note)
+		}
+	}
+
+	fmt.Fprintln(cmdCtx.Out)
+	if len(apps) > 1 {
+		fmt.Fprintf(cmdCtx.Out, "Wrote %s, create the apps with flyctl apps create and deploy them together with flyctl deploy --workspace %s\n", helpers.PathRelativeToCWD(workspacePath), helpers.PathRelativeToCWD(workspacePath))
+	} else {
+		fmt.Fprintln(cmdCtx.Out, "Create the app with flyctl apps create, then deploy it with flyctl deploy")
+	}
+
+	return nil
+}
+
+// findComposeFile - the compose file given as an argument, or the first of
+// composeFileNames in the working directory
+func findComposeFile(cmdCtx *cmdctx.CmdContext) (string, error) {
+	if len(cmdCtx.Args) > 0 {
+		path, err := filepath.Abs(cmdCtx.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if !helpers.FileExists(path) {
+			return "", flyerr.New(flyerr.NotFound, fmt.Sprintf("compose file %s not found", cmdCtx.Args[0]))
+		}
+		return path, nil
+	}
+
+	for _, name := range composeFileNames {
+		path := filepath.Join(cmdCtx.WorkingDir, name)
+		if helpers.FileExists(path) {
+			return path, nil
+		}
+	}
+
+	return "", flyerr.New(flyerr.NotFound, fmt.Sprintf("no compose file found, looked for %s", strings.Join(composeFileNames, ", ")))
+}
+
+func writeWorkspace(path string, workspace flyctl.Workspace) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return toml.NewEncoder(file).Encode(workspace)
+}
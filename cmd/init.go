@@ -3,9 +3,13 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/internal/appname"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -83,6 +87,11 @@ func newInitCommand(client *client.Client) *Command {
 		Description: "Always generate a name for the app", Hidden: true,
 	})
 
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "generate-name",
+		Description: "Generate a random, available name for the app",
+	})
+
 	return cmd
 }
 
@@ -128,8 +137,9 @@ func runInit(cmdCtx *cmdctx.CmdContext) error {
 	}
 
 	name := ""
+	generateName := cmdCtx.Config.GetBool("generatename") || cmdCtx.Config.GetBool("generate-name")
 
-	if !cmdCtx.Config.GetBool("generatename") {
+	if !generateName {
 		name = cmdCtx.Config.GetString("name")
 
 		if name != "" && appName != "" {
@@ -146,7 +156,7 @@ func runInit(cmdCtx *cmdctx.CmdContext) error {
 			prompt := &survey.Input{
 				Message: "App Name (leave blank to use an auto-generated name)",
 			}
-			if err := survey.AskOne(prompt, &name); err != nil {
+			if err := ask(prompt, &name); err != nil {
 				if isInterrupt(err) {
 					return nil
 				}
@@ -156,6 +166,17 @@ func runInit(cmdCtx *cmdctx.CmdContext) error {
 		}
 	}
 
+	if generateName {
+		if name, err = generateAppName(cmdCtx.Client.API()); err != nil {
+			return err
+		}
+		fmt.Printf("Generated App Name: %s\n", name)
+	} else if name != "" {
+		if err := checkAppName(cmdCtx.Client.API(), name); err != nil {
+			return err
+		}
+	}
+
 	fmt.Println()
 
 	targetOrgSlug := cmdCtx.Config.GetString("org")
@@ -215,6 +236,9 @@ func runInit(cmdCtx *cmdctx.CmdContext) error {
 	// The creation magic happens here....
 	app, err := cmdCtx.Client.API().CreateApp(name, org.ID, nil)
 	if err != nil {
+		if strings.Contains(err.Error(), "taken") {
+			return flyerr.Wrap(flyerr.NameTaken, err)
+		}
 		return err
 	}
 
@@ -303,3 +327,41 @@ func runInit(cmdCtx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+// checkAppName returns an InvalidName error if name isn't a valid app name,
+// or a NameTaken error if another app has it
+func checkAppName(client *api.Client, name string) error {
+	if err := appname.Validate(name); err != nil {
+		return flyerr.Wrap(flyerr.InvalidName, err)
+	}
+
+	available, err := client.AppNameAvailable(name)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return flyerr.New(flyerr.NameTaken, fmt.Sprintf("app name %q is already taken", name))
+	}
+
+	return nil
+}
+
+// appNameAttempts - how many generated names are tried before giving up
+const appNameAttempts = 5
+
+// generateAppName returns a random app name that isn't taken
+func generateAppName(client *api.Client) (string, error) {
+	for i := 0; i < appNameAttempts; i++ {
+		name := appname.Generate("")
+
+		available, err := client.AppNameAvailable(name)
+		if err != nil {
+			return "", err
+		}
+		if available {
+			return name, nil
+		}
+	}
+
+	return "", flyerr.New(flyerr.NameTaken, fmt.Sprintf("couldn't generate an available app name in %d attempts", appNameAttempts))
+}
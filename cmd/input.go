@@ -19,12 +19,18 @@ func isInterrupt(err error) bool {
 	return err != nil && err.Error() == "interrupt"
 }
 
+// confirm asks to confirm message, which is accepted without asking with the
+// global --yes flag. It exits when flyctl can't prompt.
 func confirm(message string) bool {
+	if autoConfirmed() {
+		return true
+	}
+
 	confirm := false
 	prompt := &survey.Confirm{
 		Message: message,
 	}
-	err := survey.AskOne(prompt, &confirm)
+	err := ask(prompt, &confirm)
 	checkErr(err)
 
 	return confirm
@@ -65,7 +71,7 @@ func selectOrganization(client *api.Client, slug string, typeFilter *api.Organiz
 		Options:  options,
 		PageSize: 15,
 	}
-	if err := survey.AskOne(prompt, &selectedOrg); err != nil {
+	if err := ask(prompt, &selectedOrg); err != nil {
 		return nil, err
 	}
 
@@ -73,7 +79,7 @@ func selectOrganization(client *api.Client, slug string, typeFilter *api.Organiz
 }
 
 func selectRegion(client *api.Client, regionCode string) (*api.Region, error) {
-	regions, requestRegion, err := client.PlatformRegions()
+	regions, requestRegion, err := platformRegionsIncluding(client, regionCode)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +111,7 @@ func selectRegion(client *api.Client, regionCode string) (*api.Region, error) {
 		prompt.Default = fmt.Sprintf("%s (%s)", requestRegion.Code, requestRegion.Name)
 	}
 
-	if err := survey.AskOne(prompt, &selectedRegion); err != nil {
+	if err := ask(prompt, &selectedRegion); err != nil {
 		return nil, err
 	}
 
@@ -113,7 +119,7 @@ func selectRegion(client *api.Client, regionCode string) (*api.Region, error) {
 }
 
 func selectVMSize(client *api.Client, vmSizeName string) (*api.VMSize, error) {
-	vmSizes, err := client.PlatformVMSizes()
+	vmSizes, err := platformVMSizes(client)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +146,7 @@ func selectVMSize(client *api.Client, vmSizeName string) (*api.VMSize, error) {
 		Options:  options,
 		PageSize: 15,
 	}
-	if err := survey.AskOne(prompt, &selectedVMSize); err != nil {
+	if err := ask(prompt, &selectedVMSize); err != nil {
 		return nil, err
 	}
 
@@ -152,7 +158,7 @@ func inputAppName(defaultName string) (name string, err error) {
 		Message: "App name:",
 		Default: defaultName,
 	}
-	if err := survey.AskOne(prompt, &name); err != nil {
+	if err := ask(prompt, &name); err != nil {
 		return name, err
 	}
 
@@ -165,7 +171,7 @@ func volumeSizeInput(client *api.Client, defaultVal int) (int, error) {
 		Message: "Volume size (GB):",
 		Default: strconv.Itoa(defaultVal),
 	}
-	if err := survey.AskOne(prompt, &volumeSize); err != nil {
+	if err := ask(prompt, &volumeSize); err != nil {
 		return 0, err
 	}
 
@@ -276,7 +282,7 @@ func selectBuildtype(commandContext *cmdctx.CmdContext) (string, bool, error) {
 		PageSize: 8,
 	}
 
-	if err := survey.AskOne(prompt, &selectedBuilder); err != nil {
+	if err := ask(prompt, &selectedBuilder); err != nil {
 		return "", false, err
 	}
 
@@ -320,7 +326,7 @@ func selectBuiltin(commandContext *cmdctx.CmdContext) (string, error) {
 		Options:  availablebuiltins,
 		PageSize: 8,
 	}
-	if err := survey.AskOne(prompt, &selectedBuiltin); err != nil {
+	if err := ask(prompt, &selectedBuiltin); err != nil {
 		return "", err
 	}
 
@@ -332,7 +338,7 @@ func selectImage(commandContext *cmdctx.CmdContext) (string, error) {
 	prompt := &survey.Input{Message: "Select Image:", Default: "flyio/hellofly:latest", Help: `The name and tag for the image you want to use.`}
 
 	sSelectedImage := ""
-	if err := survey.AskOne(prompt, &sSelectedImage /* survey.WithValidator(isIntPort) */); err != nil {
+	if err := ask(prompt, &sSelectedImage /* survey.WithValidator(isIntPort) */); err != nil {
 		return sSelectedImage, err
 	}
 
@@ -345,7 +351,7 @@ func selectPort(commandContext *cmdctx.CmdContext, defport int) (int, error) {
 If incorrectly set, health checks may fail and your application deployment will fail.`}
 
 	sSelectedPort := ""
-	if err := survey.AskOne(prompt, &sSelectedPort, survey.WithValidator(isIntPort)); err != nil {
+	if err := ask(prompt, &sSelectedPort, survey.WithValidator(isIntPort)); err != nil {
 		return -1, err
 	}
 	selectedPort, err := strconv.Atoi(sSelectedPort)
@@ -381,7 +387,7 @@ func inputUserEmail() (email string, err error) {
 	prompt := &survey.Input{
 		Message: "User email:",
 	}
-	if err := survey.AskOne(prompt, &email); err != nil {
+	if err := ask(prompt, &email); err != nil {
 		return email, err
 	}
 
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+)
+
+func newInteractiveCommand(client *client.Client) *Command {
+	interactiveStrings := docstrings.Get("interactive")
+
+	var cmd *Command
+	cmd = BuildCommandKS(nil, func(ctx *cmdctx.CmdContext) error {
+		return runInteractive(ctx, cmd.Root())
+	}, interactiveStrings, client)
+	cmd.Aliases = []string{"i"}
+	cmd.AddStringFlag(StringFlagOpts{Name: "app", Shorthand: "a", Description: "App to start with", EnvName: "FLY_APP"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "Organization to start with"})
+
+	return cmd
+}
+
+// interactiveSession is the app and org context carried between commands
+type interactiveSession struct {
+	root     *cobra.Command
+	commands []paletteCommand
+	app      string
+	org      string
+}
+
+// paletteCommand is a runnable command, by its path from the root
+type paletteCommand struct {
+	path  string
+	short string
+}
+
+func runInteractive(ctx *cmdctx.CmdContext, root *cobra.Command) error {
+	session := &interactiveSession{
+		root:     root,
+		commands: paletteCommands(root),
+		app:      ctx.Config.GetString("app"),
+		org:      ctx.Config.GetString("org"),
+	}
+
+	if session.app == "" {
+		if configPath, err := flyctl.ResolveConfigFileFromPath(ctx.WorkingDir); err == nil && helpers.FileExists(configPath) {
+			if appConfig, err := flyctl.LoadAppConfig(configPath); err == nil {
+				session.app = appConfig.AppName
+			}
+		}
+	}
+
+	fmt.Fprintln(ctx.Out, "flyctl interactive mode. Type commands without the flyctl prefix, for example `status` or `scale count 2`.")
+	fmt.Fprintln(ctx.Out, "Use `app <name>` and `org <slug>` to set context, `? <text>` to search commands, `help` for more and `exit` to leave.")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(ctx.Out, session.prompt())
+
+		if !scanner.Scan() {
+			fmt.Fprintln(ctx.Out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if done := session.handle(ctx, line); done {
+			return nil
+		}
+	}
+}
+
+func (s *interactiveSession) prompt() string {
+	context := s.app
+	if s.org != "" {
+		context = fmt.Sprintf("%s@%s", context, s.org)
+	}
+	if context == "" {
+		return "fly> "
+	}
+	return fmt.Sprintf("fly (%s)> ", context)
+}
+
+// handle runs a line of input, returning true when the session should end
+func (s *interactiveSession) handle(ctx *cmdctx.CmdContext, line string) bool {
+	if strings.HasPrefix(line, "?") {
+		s.search(ctx, strings.TrimSpace(strings.TrimPrefix(line, "?")))
+		return false
+	}
+
+	args, err := shlex.Split(line)
+	if err != nil {
+		fmt.Fprintln(ctx.Out, "Error", err)
+		return false
+	}
+	args = trimExecutableName(args)
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "app":
+		if len(args) > 1 {
+			s.app = args[1]
+		}
+		fmt.Fprintf(ctx.Out, "App: %s\n", valueOrNone(s.app))
+		return false
+	case "org":
+		if len(args) > 1 {
+			s.org = args[1]
+		}
+		fmt.Fprintf(ctx.Out, "Organization: %s\n", valueOrNone(s.org))
+		return false
+	case "search":
+		s.search(ctx, strings.Join(args[1:], " "))
+		return false
+	case "help":
+		if len(args) == 1 {
+			printInteractiveHelp(ctx)
+			return false
+		}
+		// help for a command comes from the command itself
+		args = append(args[1:], "--help")
+	}
+
+	s.run(ctx, args)
+	return false
+}
+
+// run executes a flyctl command in a child process, so flags don't carry over
+// between commands and failures don't end the session
+func (s *interactiveSession) run(ctx *cmdctx.CmdContext, args []string) {
+	found, _, err := s.root.Find(args)
+	if err != nil || found == s.root {
+		fmt.Fprintf(ctx.Out, "Unknown command %q\n", args[0])
+		if matches := searchPalette(s.commands, strings.Join(args, " ")); len(matches) > 0 {
+			fmt.Fprintln(ctx.Out, "Did you mean:")
+			for _, match := range limitPalette(matches, 5) {
+				fmt.Fprintf(ctx.Out, "  %s\n", match.path)
+			}
+		}
+		return
+	}
+
+	env := os.Environ()
+	if s.app != "" && found.Flags().Lookup("app") != nil {
+		env = append(env, "FLY_APP="+s.app)
+	}
+	if s.org != "" {
+		for _, name := range []string{"org", "organization"} {
+			if found.Flags().Lookup(name) != nil && !hasFlag(args, name, found.Flags().Lookup(name).Shorthand) {
+				args = append(args, "--"+name, s.org)
+				break
+			}
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(ctx.Out, "Error", err)
+		return
+	}
+
+	command := exec.Command(executable, args...)
+	command.Env = env
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	if err := command.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Fprintln(ctx.Out, "Error", err)
+		}
+	}
+}
+
+func (s *interactiveSession) search(ctx *cmdctx.CmdContext, query string) {
+	matches := s.commands
+	if query != "" {
+		matches = searchPalette(s.commands, query)
+	}
+
+	if len(matches) == 0 {
+		fmt.Fprintf(ctx.Out, "No commands match %q\n", query)
+		return
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Command", "Description"})
+	for _, match := range limitPalette(matches, 15) {
+		table.Append([]string{match.path, match.short})
+	}
+	table.Render()
+}
+
+func printInteractiveHelp(ctx *cmdctx.CmdContext) {
+	fmt.Fprintln(ctx.Out, "  <command> [args]   run a flyctl command, e.g. `status` or `logs`")
+	fmt.Fprintln(ctx.Out, "  app [name]         show or set the app commands run against")
+	fmt.Fprintln(ctx.Out, "  org [slug]         show or set the organization commands run against")
+	fmt.Fprintln(ctx.Out, "  ? <text>           search commands by name and description")
+	fmt.Fprintln(ctx.Out, "  help <command>     show help for a command")
+	fmt.Fprintln(ctx.Out, "  exit               leave interactive mode")
+}
+
+// paletteCommands lists every runnable, visible command under root
+func paletteCommands(root *cobra.Command) []paletteCommand {
+	commands := []paletteCommand{}
+
+	var walk func(c *cobra.Command, prefix string)
+	walk = func(c *cobra.Command, prefix string) {
+		for _, child := range c.Commands() {
+			if child.Hidden || child.Name() == "help" || child.Name() == "interactive" {
+				continue
+			}
+			path := strings.TrimSpace(prefix + " " + child.Name())
+			if child.Runnable() {
+				commands = append(commands, paletteCommand{path: path, short: child.Short})
+			}
+			walk(child, path)
+		}
+	}
+	walk(root, "")
+
+	return commands
+}
+
+// searchPalette ranks commands by how well their path, then their
+// description, fuzzily matches the query
+func searchPalette(commands []paletteCommand, query string) []paletteCommand {
+	type scored struct {
+		command paletteCommand
+		score   int
+	}
+
+	results := []scored{}
+	for _, command := range commands {
+		if score, ok := fuzzyScore(query, command.path); ok {
+			results = append(results, scored{command, score + 100})
+		} else if strings.Contains(strings.ToLower(command.short), strings.ToLower(query)) {
+			results = append(results, scored{command, 0})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	matches := make([]paletteCommand, len(results))
+	for i, result := range results {
+		matches[i] = result.command
+	}
+	return matches
+}
+
+// fuzzyScore matches the characters of query in order within candidate,
+// scoring consecutive characters and matches at word starts higher
+func fuzzyScore(query string, candidate string) (int, bool) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), ""))
+	candidate = strings.ToLower(candidate)
+
+	score, qi, streak := 0, 0, 0
+	for ci := 0; ci < len(candidate) && qi < len(query); ci++ {
+		if candidate[ci] != query[qi] {
+			streak = 0
+			continue
+		}
+
+		streak++
+		score += streak
+		if ci == 0 || candidate[ci-1] == ' ' || candidate[ci-1] == '-' {
+			score += 5
+		}
+		qi++
+	}
+
+	if qi < len(query) {
+		return 0, false
+	}
+	// prefer shorter commands among equal matches
+	return score*10 - len(candidate), true
+}
+
+func limitPalette(commands []paletteCommand, n int) []paletteCommand {
+	if len(commands) > n {
+		return commands[:n]
+	}
+	return commands
+}
+
+// trimExecutableName drops a leading flyctl or fly, typed out of habit
+func trimExecutableName(args []string) []string {
+	if len(args) > 0 {
+		name := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+		if name == "flyctl" || name == "fly" {
+			return args[1:]
+		}
+	}
+	return args
+}
+
+func hasFlag(args []string, name string, shorthand string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") || (shorthand != "" && arg == "-"+shorthand) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
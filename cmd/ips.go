@@ -5,7 +5,6 @@ import (
 	"net"
 
 	"github.com/superfly/flyctl/cmdctx"
-	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
 
 	"github.com/superfly/flyctl/docstrings"
@@ -13,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmd/presenters"
+	"github.com/superfly/flyctl/flyctl"
 )
 
 func newIPAddressesCommand(client *client.Client) *Command {
@@ -27,10 +27,14 @@ func newIPAddressesCommand(client *client.Client) *Command {
 	BuildCommandKS(cmd, runPrivateIPAddressesList, ipsPrivateListStrings, client, requireSession, requireAppName)
 
 	ipsAllocateV4Strings := docstrings.Get("ips.allocate-v4")
-	BuildCommandKS(cmd, runAllocateIPAddressV4, ipsAllocateV4Strings, client, requireSession, requireAppName)
+	allocateV4 := BuildCommandKS(cmd, runAllocateIPAddressV4, ipsAllocateV4Strings, client, requireSession, requireAppName)
+	allocateV4.AddBoolFlag(BoolFlagOpts{Name: "shared", Description: "use an IPv4 address shared with other apps instead of a dedicated one"})
+	allocateV4.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "allocate the address in a region rather than globally"})
 
 	ipsAllocateV6Strings := docstrings.Get("ips.allocate-v6")
-	BuildCommandKS(cmd, runAllocateIPAddressV6, ipsAllocateV6Strings, client, requireSession, requireAppName)
+	allocateV6 := BuildCommandKS(cmd, runAllocateIPAddressV6, ipsAllocateV6Strings, client, requireSession, requireAppName)
+	allocateV6.AddBoolFlag(BoolFlagOpts{Name: "private", Description: "allocate a private address on the organization's 6PN network"})
+	allocateV6.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "allocate the address in a region rather than globally"})
 
 	ipsReleaseStrings := docstrings.Get("ips.release")
 	release := BuildCommandKS(cmd, runReleaseIPAddress, ipsReleaseStrings, client, requireSession, requireAppName)
@@ -45,23 +49,45 @@ func runIPAddressesList(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	return commandContext.Frender(cmdctx.PresenterOption{
+	err = commandContext.Frender(cmdctx.PresenterOption{
 		Presentable: &presenters.IPAddresses{IPAddresses: ipAddresses},
 	})
+	if err != nil {
+		return err
+	}
+
+	if !commandContext.OutputJSON() && !hasDedicatedIPv4(ipAddresses) && deployedUDPServices(commandContext) {
+		fmt.Fprintln(commandContext.Out)
+		commandContext.Status("ips", cmdctx.SWARN, "UDP services are only reachable on a dedicated IPv4 address, allocate one with flyctl ips allocate-v4")
+	}
+
+	return nil
 }
 
 func runAllocateIPAddressV4(ctx *cmdctx.CmdContext) error {
+	if ctx.Config.GetBool("shared") {
+		if ctx.Config.GetString("region") != "" {
+			return fmt.Errorf("shared IPv4 addresses can't be allocated in a region")
+		}
+		if deployedUDPServices(ctx) {
+			ctx.Status("ips", cmdctx.SWARN, "Shared IPv4 addresses don't carry UDP, so the app's UDP services need a dedicated one")
+		}
+		return runAllocateIPAddress(ctx, api.IPAddressSharedV4)
+	}
 	return runAllocateIPAddress(ctx, "v4")
 }
 
 func runAllocateIPAddressV6(ctx *cmdctx.CmdContext) error {
+	if ctx.Config.GetBool("private") {
+		return runAllocateIPAddress(ctx, "private_v6")
+	}
 	return runAllocateIPAddress(ctx, "v6")
 }
 
 func runAllocateIPAddress(commandContext *cmdctx.CmdContext, addrType string) error {
 	appName := commandContext.AppName
 
-	ipAddress, err := commandContext.Client.API().AllocateIPAddress(appName, addrType)
+	ipAddress, err := commandContext.Client.API().AllocateIPAddress(appName, addrType, commandContext.Config.GetString("region"))
 	if err != nil {
 		return err
 	}
@@ -79,12 +105,28 @@ func runReleaseIPAddress(commandContext *cmdctx.CmdContext) error {
 		return fmt.Errorf("Invalid IP address: '%s'", address)
 	}
 
-	ipAddress, err := commandContext.Client.API().FindIPAddress(appName, address)
+	ipAddresses, err := commandContext.Client.API().GetIPAddresses(appName)
 	if err != nil {
 		return err
 	}
 
-	if err := commandContext.Client.API().ReleaseIPAddress(ipAddress.ID); err != nil {
+	var ipAddress *api.IPAddress
+	for i := range ipAddresses {
+		if net.ParseIP(ipAddresses[i].Address).Equal(net.ParseIP(address)) {
+			ipAddress = &ipAddresses[i]
+			break
+		}
+	}
+	if ipAddress == nil {
+		return fmt.Errorf("%s is not allocated to %s", address, appName)
+	}
+
+	if ipAddress.Type == api.IPAddressSharedV4 {
+		err = commandContext.Client.API().ReleaseSharedIPAddress(appName, ipAddress.Address)
+	} else {
+		err = commandContext.Client.API().ReleaseIPAddress(ipAddress.ID)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -93,6 +135,30 @@ func runReleaseIPAddress(commandContext *cmdctx.CmdContext) error {
 	return nil
 }
 
+// hasDedicatedIPv4 - UDP services are only reachable on a dedicated IPv4 address
+func hasDedicatedIPv4(ipAddresses []api.IPAddress) bool {
+	for _, ip := range ipAddresses {
+		if ip.Type == "v4" {
+			return true
+		}
+	}
+	return false
+}
+
+// deployedUDPServices - true when the app's deployed config has UDP services.
+// Errors are ignored as this only decides whether to show UDP guidance.
+func deployedUDPServices(ctx *cmdctx.CmdContext) bool {
+	cfg, err := ctx.Client.API().GetConfig(ctx.AppName)
+	if err != nil {
+		return false
+	}
+	appConfig := flyctl.NewAppConfig()
+	appConfig.Definition = cfg.Definition
+
+	services, err := appConfig.ServiceConfigs()
+	return err == nil && flyctl.HasUDP(services)
+}
+
 func runPrivateIPAddressesList(commandContext *cmdctx.CmdContext) error {
 	appstatus, err := commandContext.Client.API().GetAppStatus(commandContext.AppName, false)
 	if err != nil {
@@ -105,29 +171,7 @@ func runPrivateIPAddressesList(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	if commandContext.OutputJSON() {
-		commandContext.WriteJSON(appstatus.Allocations)
-		return nil
-	}
-
-	table := helpers.MakeSimpleTable(commandContext.Out, []string{"ID", "Region", "IP"})
-
-	for _, alloc := range appstatus.Allocations {
-
-		region := alloc.Region
-		if len(backupRegions) > 0 {
-			for _, r := range backupRegions {
-				if alloc.Region == r.Code {
-					region = alloc.Region + "(B)"
-					break
-				}
-			}
-		}
-
-		table.Append([]string{alloc.IDShort, region, alloc.PrivateIP})
-	}
-
-	table.Render()
-
-	return nil
+	return commandContext.Frender(cmdctx.PresenterOption{
+		Presentable: &presenters.PrivateIPs{Allocations: appstatus.Allocations, BackupRegions: backupRegions},
+	})
 }
@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newJobsCommand(client *client.Client) *Command {
+	jobsStrings := docstrings.Get("jobs")
+	cmd := BuildCommandKS(nil, nil, jobsStrings, client, requireSession)
+	cmd.Aliases = []string{"job"}
+
+	submitStrings := docstrings.Get("jobs.submit")
+	submitCmd := BuildCommandKS(cmd, runJobsSubmit, submitStrings, client, requireSession, requireAppName)
+	submitCmd.AddStringFlag(StringFlagOpts{Name: "image", Shorthand: "i", Description: "the image to run. defaults to the image of the app's current release"})
+	submitCmd.AddStringFlag(StringFlagOpts{Name: "command", Shorthand: "C", Description: "the command each item runs"})
+	submitCmd.AddIntFlag(IntFlagOpts{Name: "count", Description: "number of items to run", Default: 1})
+	submitCmd.AddIntFlag(IntFlagOpts{Name: "max-parallel", Description: "number of items to run at once", Default: 1})
+	submitCmd.AddIntFlag(IntFlagOpts{Name: "retries", Description: "times to retry a failed item", Default: 2})
+	submitCmd.AddStringFlag(StringFlagOpts{Name: "timeout", Description: "how long each attempt may run for", Default: "1h"})
+	submitCmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "the region to run items in"})
+	submitCmd.AddIntFlag(IntFlagOpts{Name: "cpus", Description: "number of CPUs"})
+	submitCmd.AddIntFlag(IntFlagOpts{Name: "memory", Description: "memory in MB"})
+	submitCmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "env",
+		Shorthand:   "e",
+		Description: "Set of environment variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+	})
+
+	return cmd
+}
+
+const (
+	jobItemPending   = "pending"
+	jobItemRunning   = "running"
+	jobItemSucceeded = "succeeded"
+	jobItemFailed    = "failed"
+)
+
+// jobItem tracks one unit of batch work across its attempts
+type jobItem struct {
+	Index     int
+	Attempts  int
+	Status    string
+	ExitCode  *int
+	MachineID string
+	Error     string
+	startedAt time.Time
+}
+
+func runJobsSubmit(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	command, err := shlex.Split(ctx.Config.GetString("command"))
+	if err != nil {
+		return fmt.Errorf("invalid command: %w", err)
+	}
+	if len(command) == 0 {
+		return fmt.Errorf("a command is required, pass one with --command")
+	}
+
+	count := ctx.Config.GetInt("count")
+	maxParallel := ctx.Config.GetInt("max-parallel")
+	retries := ctx.Config.GetInt("retries")
+	if count < 1 || maxParallel < 1 || retries < 0 {
+		return fmt.Errorf("--count and --max-parallel must be at least 1 and --retries can't be negative")
+	}
+
+	timeout, err := time.ParseDuration(ctx.Config.GetString("timeout"))
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	image := ctx.Config.GetString("image")
+	if image == "" {
+		release, err := client.GetAppCurrentRelease(ctx.AppName)
+		if err != nil {
+			return fmt.Errorf("get current release: %w", err)
+		}
+		if release == nil || release.ImageRef == "" {
+			return fmt.Errorf("%s has no released image, pass one with --image", ctx.AppName)
+		}
+		image = release.ImageRef
+	}
+
+	env := map[string]string{}
+	if envArgs := ctx.Config.GetStringSlice("env"); len(envArgs) > 0 {
+		if env, err = cmdutil.ParseKVStringsToMap(envArgs); err != nil {
+			return fmt.Errorf("invalid env: %w", err)
+		}
+	}
+
+	baseConfig := api.MachineConfig{
+		Image:   image,
+		Cmd:     command,
+		Env:     env,
+		Restart: &api.MachineRestart{Policy: "no"},
+	}
+	if cpus, memory := ctx.Config.GetInt("cpus"), ctx.Config.GetInt("memory"); cpus != 0 || memory != 0 {
+		baseConfig.Guest = &api.MachineGuest{CPUs: cpus, MemoryMB: memory}
+	}
+
+	batchID := time.Now().Unix()
+	items := make([]*jobItem, count)
+	for i := range items {
+		items[i] = &jobItem{Index: i, Status: jobItemPending}
+	}
+
+	fmt.Fprintf(ctx.Out, "Running %d items of %s with up to %d at once\n", count, image, maxParallel)
+
+	launch := func(item *jobItem) error {
+		itemEnv := map[string]string{}
+		for k, v := range baseConfig.Env {
+			itemEnv[k] = v
+		}
+		itemEnv["JOB_INDEX"] = strconv.Itoa(item.Index)
+		itemEnv["JOB_COUNT"] = strconv.Itoa(count)
+		itemEnv["JOB_ATTEMPT"] = strconv.Itoa(item.Attempts + 1)
+
+		config := baseConfig
+		config.Env = itemEnv
+
+		machine, err := client.LaunchMachine(api.LaunchMachineInput{
+			AppID:  ctx.AppName,
+			Name:   fmt.Sprintf("job-%d-%d-%d", batchID, item.Index, item.Attempts+1),
+			Region: ctx.Config.GetString("region"),
+			Config: config,
+		})
+		if err != nil {
+			return err
+		}
+
+		item.Attempts++
+		item.Status = jobItemRunning
+		item.MachineID = machine.ID
+		item.ExitCode = nil
+		item.startedAt = time.Now()
+
+		fmt.Fprintf(ctx.Out, "Item %d attempt %d started on machine %s\n", item.Index, item.Attempts, machine.ID)
+		return nil
+	}
+
+	// finish records the outcome of an attempt, queuing a retry if any are left
+	finish := func(item *jobItem, exitCode *int, reason string) {
+		removeJobMachine(client, ctx.AppName, item.MachineID)
+		item.ExitCode = exitCode
+
+		if exitCode != nil && *exitCode == 0 {
+			item.Status = jobItemSucceeded
+			item.Error = ""
+			fmt.Fprintf(ctx.Out, "Item %d succeeded\n", item.Index)
+			return
+		}
+
+		item.Error = reason
+		if item.Attempts <= retries {
+			item.Status = jobItemPending
+			fmt.Fprintf(ctx.Out, "Item %d failed (%s), retrying\n", item.Index, reason)
+			return
+		}
+
+		item.Status = jobItemFailed
+		fmt.Fprintf(ctx.Out, "Item %d failed (%s), giving up after %d attempts\n", item.Index, reason, item.Attempts)
+	}
+
+	cancelCtx := createCancellableContext()
+	err = scheduleJobItems(cancelCtx, items, maxParallel, launch, func(item *jobItem) error {
+		machine, err := client.GetMachine(ctx.AppName, item.MachineID)
+		if err != nil {
+			return err
+		}
+
+		switch machine.State {
+		case "stopped", "destroyed":
+			reason := "no exit code"
+			if machine.ExitCode != nil {
+				reason = fmt.Sprintf("exit code %d", *machine.ExitCode)
+			}
+			finish(item, machine.ExitCode, reason)
+		case "failed":
+			finish(item, nil, "machine failed")
+		default:
+			if time.Since(item.startedAt) > timeout {
+				finish(item, nil, "timed out after "+timeout.String())
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		for _, item := range items {
+			if item.Status == jobItemRunning {
+				removeJobMachine(client, ctx.AppName, item.MachineID)
+			}
+		}
+		if isCancelledError(err) {
+			return fmt.Errorf("job cancelled")
+		}
+		return err
+	}
+
+	return printJobReport(ctx, items)
+}
+
+// scheduleJobItems launches pending items while fewer than maxParallel are
+// running and polls running items until every item has succeeded or failed.
+// poll updates the status of an item once its attempt has finished.
+func scheduleJobItems(ctx context.Context, items []*jobItem, maxParallel int, launch func(*jobItem) error, poll func(*jobItem) error) error {
+	for {
+		running, done := 0, 0
+
+		for _, item := range items {
+			if item.Status != jobItemRunning {
+				continue
+			}
+			if err := poll(item); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range items {
+			switch item.Status {
+			case jobItemRunning:
+				running++
+			case jobItemSucceeded, jobItemFailed:
+				done++
+			}
+		}
+
+		if done == len(items) {
+			return nil
+		}
+
+		for _, item := range items {
+			if running >= maxParallel {
+				break
+			}
+			if item.Status != jobItemPending {
+				continue
+			}
+			if err := launch(item); err != nil {
+				return err
+			}
+			running++
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func removeJobMachine(client *api.Client, appName string, machineID string) {
+	if err := client.RemoveMachine(appName, machineID, true); err != nil {
+		terminal.Warnf("Failed to remove job machine %s, remove it with `flyctl machine remove --force %s`: %v\n", machineID, machineID, err)
+	}
+}
+
+func printJobReport(ctx *cmdctx.CmdContext, items []*jobItem) error {
+	failed := 0
+	for _, item := range items {
+		if item.Status == jobItemFailed {
+			failed++
+		}
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(items)
+	} else {
+		fmt.Fprintln(ctx.Out)
+
+		table := helpers.MakeSimpleTable(ctx.Out, []string{"Item", "Status", "Attempts", "Exit Code", "Error"})
+		for _, item := range items {
+			exitCode := ""
+			if item.ExitCode != nil {
+				exitCode = strconv.Itoa(*item.ExitCode)
+			}
+			table.Append([]string{strconv.Itoa(item.Index), item.Status, strconv.Itoa(item.Attempts), exitCode, item.Error})
+		}
+		table.Render()
+
+		fmt.Fprintf(ctx.Out, "\n%d succeeded, %d failed\n", len(items)-failed, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items failed", failed, len(items))
+	}
+
+	return nil
+}
@@ -2,16 +2,23 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/apptemplate"
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/secretgen"
 	"github.com/superfly/flyctl/internal/sourcecode"
 
 	"github.com/superfly/flyctl/docstrings"
@@ -26,12 +33,19 @@ func newLaunchCommand(client *client.Client) *Command {
 	launchCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the new app"})
 	launchCmd.AddStringFlag(StringFlagOpts{Name: "region", Description: "the region to launch the new app in"})
 	launchCmd.AddStringFlag(StringFlagOpts{Name: "image", Description: "the image to launch"})
+	launchCmd.AddStringFlag(StringFlagOpts{Name: "template", Description: "the name of a published template to launch from"})
 	launchCmd.AddBoolFlag(BoolFlagOpts{Name: "now", Description: "deploy now without confirmation", Default: false})
+	launchCmd.AddBoolFlag(BoolFlagOpts{Name: "no-deploy", Description: "create the app and its configuration without deploying it"})
+	launchCmd.AddBoolFlag(BoolFlagOpts{Name: "postgres", Description: "create and attach a Postgres database without confirmation, for apps which use one"})
 
 	return launchCmd
 }
 
 func runLaunch(cmdctx *cmdctx.CmdContext) error {
+	if cmdctx.Config.GetBool("now") && cmdctx.Config.GetBool("no-deploy") {
+		return flyerr.New(flyerr.InvalidArgument, "--now and --no-deploy can't be used together")
+	}
+
 	dir := cmdctx.Config.GetString("path")
 
 	if absDir, err := filepath.Abs(dir); err == nil {
@@ -52,7 +66,22 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 
 	var importedConfig bool
 	configFilePath := filepath.Join(dir, "fly.toml")
-	if exists, _ := flyctl.ConfigFileExistsAtPath(configFilePath); exists {
+
+	var templateManifest *apptemplate.Manifest
+	if templateName := cmdctx.Config.GetString("template"); templateName != "" {
+		manifest, err := installTemplate(cmdctx, templateName, dir)
+		if err != nil {
+			return err
+		}
+		templateManifest = manifest
+
+		cfg, err := flyctl.LoadAppConfig(configFilePath)
+		if err != nil {
+			return err
+		}
+		appConfig.Definition = cfg.Definition
+		importedConfig = true
+	} else if exists, _ := flyctl.ConfigFileExistsAtPath(configFilePath); exists {
 		cfg, err := flyctl.LoadAppConfig(configFilePath)
 		if err != nil {
 			return err
@@ -98,6 +127,14 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 			srcInfo = si
 		}
 
+		// nixpacks detects many more kinds of app, when it's installed
+		if srcInfo == nil && imgsrc.NixpacksAvailable() {
+			srcInfo = &sourcecode.SourceInfo{
+				Family:  "Nixpacks",
+				Builder: flyctl.NixpacksBuilder,
+			}
+		}
+
 		if srcInfo == nil {
 			fmt.Println("Could not find a Dockerfile or detect a buildpack from source code. Continuing with a blank app.")
 		} else {
@@ -113,6 +150,13 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 					Buildpacks: srcInfo.Buildpacks,
 				}
 			}
+
+			for _, file := range srcInfo.Files {
+				fmt.Printf("Generating %s\n", file.Path)
+			}
+			if len(srcInfo.Statics) > 0 {
+				fmt.Println("Static files will be served directly by Fly's proxy")
+			}
 		}
 	}
 
@@ -145,21 +189,47 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 	appConfig.AppName = app.Name
 	cmdctx.AppConfig = appConfig
 
-	if srcInfo != nil && (len(srcInfo.Buildpacks) > 0 || srcInfo.Builder != "") {
-		appConfig.SetInternalPort(8080)
-		appConfig.SetEnvVariable("PORT", "8080")
+	if srcInfo != nil {
+		port := srcInfo.Port
+		if port == 0 && (len(srcInfo.Buildpacks) > 0 || srcInfo.Builder != "") {
+			port = 8080
+		}
+		if port != 0 {
+			appConfig.SetInternalPort(port)
+			appConfig.SetEnvVariable("PORT", strconv.Itoa(port))
+		}
+		if srcInfo.HTTPCheckPath != "" {
+			appConfig.SetHTTPCheck(srcInfo.HTTPCheckPath)
+		}
+		if len(srcInfo.Statics) > 0 {
+			appConfig.SetStatics(srcInfo.Statics)
+		}
+		if len(srcInfo.Env) > 0 {
+			appConfig.SetEnvVariables(srcInfo.Env)
+		}
 	}
 
 	fmt.Printf("Created app %s in organization %s\n", app.Name, org.Slug)
 
-	if srcInfo != nil && len(srcInfo.Secrets) > 0 {
+	if srcInfo != nil && len(srcInfo.Secrets)+len(srcInfo.GeneratedSecrets) > 0 {
 		secrets := make(map[string]string)
 		keys := []string{}
 
+		for k, kind := range srcInfo.GeneratedSecrets {
+			values, err := secretgen.Generate(k, kind)
+			if err != nil {
+				return err
+			}
+			for name, value := range values {
+				secrets[name] = value
+				keys = append(keys, name)
+			}
+		}
+
 		for k, v := range srcInfo.Secrets {
 			val := ""
 			prompt := fmt.Sprintf("Set secret %s:", k)
-			survey.AskOne(&survey.Input{
+			ask(&survey.Input{
 				Message: prompt,
 				Help:    v,
 			}, &val)
@@ -180,6 +250,12 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 		}
 	}
 
+	if templateManifest != nil {
+		if err := provisionTemplate(cmdctx, templateManifest, app, region.Code); err != nil {
+			return err
+		}
+	}
+
 	if err := writeAppConfig(filepath.Join(dir, "fly.toml"), appConfig); err != nil {
 		return err
 	}
@@ -188,8 +264,30 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 		return nil
 	}
 
+	if err := writeSourceFiles(dir, srcInfo.Files); err != nil {
+		return err
+	}
+
+	if srcInfo.DatabaseDesired {
+		switch {
+		case cmdctx.Config.GetBool("postgres"),
+			!cmdctx.Config.GetBool("now") && offer(fmt.Sprintf("%s apps usually use a database. Would you like to set up a Postgres database now?", srcInfo.Family)):
+			if err := launchPostgres(cmdctx, app, org, region.Code); err != nil {
+				return err
+			}
+		default:
+			fmt.Printf("Set up a database later with `flyctl postgres create` and `flyctl postgres attach --postgres-app <name> -a %s`\n", app.Name)
+		}
+	}
+
 	fmt.Println("Your app is ready. Deploy with `flyctl deploy`")
 
+	suggestEdgeRegions(srcInfo, region.Code)
+
+	if cmdctx.Config.GetBool("no-deploy") {
+		return nil
+	}
+
 	if !cmdctx.Config.GetBool("now") && !confirm("Would you like to deploy now?") {
 		return nil
 	}
@@ -197,6 +295,73 @@ func runLaunch(cmdctx *cmdctx.CmdContext) error {
 	return runDeploy(cmdctx)
 }
 
+// writeSourceFiles writes files generated by the scanner, leaving existing files alone
+func writeSourceFiles(dir string, files []sourcecode.SourceFile) error {
+	for _, file := range files {
+		path := filepath.Join(dir, file.Path)
+		if helpers.FileExists(path) {
+			fmt.Printf("%s already exists, not overwriting it\n", file.Path)
+			continue
+		}
+		if err := ioutil.WriteFile(path, []byte(file.Contents), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", file.Path)
+	}
+	return nil
+}
+
+// launchPostgres creates a small Postgres cluster next to the app and attaches
+// it, which sets the app's DATABASE_URL secret
+func launchPostgres(cmdctx *cmdctx.CmdContext, app *api.App, org *api.Organization, region string) error {
+	input := api.CreatePostgresClusterInput{
+		OrganizationID: org.ID,
+		Name:           app.Name + "-db",
+		Region:         api.StringPointer(region),
+		VMSize:         api.StringPointer("shared-cpu-1x"),
+		VolumeSizeGB:   api.IntPointer(10),
+	}
+
+	fmt.Printf("Creating postgres cluster %s in organization %s\n", input.Name, org.Slug)
+
+	payload, err := cmdctx.Client.API().CreatePostgresCluster(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Username:    %s\n", payload.Username)
+	fmt.Printf("  Password:    %s\n", payload.Password)
+	fmt.Printf("  Hostname:    %s.internal\n", payload.App.Name)
+	fmt.Println(aurora.Italic("Save your credentials in a secure place, you won't be able to see them again!"))
+
+	attached, err := cmdctx.Client.API().AttachPostgresCluster(api.AttachPostgresClusterInput{
+		AppID:                app.Name,
+		PostgresClusterAppID: payload.App.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Postgres cluster %s is now attached to %s as %s\n", attached.PostgresClusterApp.Name, attached.App.Name, attached.EnvironmentVariableName)
+	return nil
+}
+
+func suggestEdgeRegions(srcInfo *sourcecode.SourceInfo, primaryRegion string) {
+	regions := []string{}
+	for _, code := range srcInfo.EdgeRegions {
+		if code != primaryRegion {
+			regions = append(regions, code)
+		}
+	}
+	if len(regions) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s apps respond fastest when they run close to users. To add more regions after deploying:\n", srcInfo.Family)
+	fmt.Printf("  flyctl regions add %s\n", strings.Join(regions, " "))
+	fmt.Printf("  flyctl scale count %d\n\n", len(regions)+1)
+}
+
 func shouldDeployExistingApp(cc *cmdctx.CmdContext, appName string) (bool, error) {
 	status, err := cc.Client.API().GetAppStatus(appName, false)
 	if err != nil {
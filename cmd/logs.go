@@ -24,11 +24,13 @@ func newLogsCommand(client *client.Client) *Command {
 		Description: "Filter by region",
 	})
 
+	newLogsShipCommand(cmd, client)
+
 	return cmd
 }
 
 func runLogs(ctx *cmdctx.CmdContext) error {
-	err := monitor.WatchLogs(ctx, ctx.Out, monitor.LogOptions{
+	err := monitor.WatchLogs(createCancellableContext(), ctx, ctx.Out, monitor.LogOptions{
 		AppName:    ctx.AppName,
 		VMID:       ctx.Config.GetString("instance"),
 		RegionCode: ctx.Config.GetString("region"),
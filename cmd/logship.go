@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/logship"
+)
+
+func newLogsShipCommand(parent *Command, client *client.Client) {
+	shipStrings := docstrings.Get("logs.ship")
+	shipCmd := BuildCommandKS(parent, nil, shipStrings, client)
+
+	setupStrings := docstrings.Get("logs.ship.setup")
+	setupCmd := BuildCommandKS(shipCmd, runLogsShipSetup, setupStrings, client, requireSession, requireAppName)
+	setupCmd.Args = cobra.MinimumNArgs(1)
+	setupCmd.Command.Example = `flyctl logs ship setup logtail LOGTAIL_TOKEN=abc123
+	flyctl logs ship setup http HTTP_URL=https://logs.example.com/ingest
+	flyctl logs ship setup datadog`
+
+	showStrings := docstrings.Get("logs.ship.show")
+	BuildCommandKS(shipCmd, runLogsShipShow, showStrings, client, requireSession, requireAppName)
+
+	sinksStrings := docstrings.Get("logs.ship.sinks")
+	BuildCommandKS(shipCmd, runLogsShipSinks, sinksStrings, client)
+
+	removeStrings := docstrings.Get("logs.ship.remove")
+	removeCmd := BuildCommandKS(shipCmd, runLogsShipRemove, removeStrings, client, requireSession, requireAppName)
+	removeCmd.Args = cobra.MaximumNArgs(1)
+}
+
+// shipperTokenExpiry - how long the log shipper's token lasts before setup
+// has to be run again
+const shipperTokenExpiry = 365 * 24 * time.Hour
+
+func runLogsShipSetup(ctx *cmdctx.CmdContext) error {
+	sink, err := logship.FindSink(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	values, err := cmdutil.ParseKVStringsToMap(ctx.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	if ctx.IO.CanPrompt() {
+		if err := promptSinkSettings(sink, values); err != nil {
+			return err
+		}
+	}
+
+	if err := sink.Validate(values); err != nil {
+		return err
+	}
+
+	app, err := ctx.Client.API().GetApp(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	shipperName := logship.ShipperAppName(ctx.AppName)
+
+	shipper, err := ctx.Client.API().GetApp(shipperName)
+	if err != nil {
+		if !api.IsNotFoundError(err) && err.Error() != "Could not resolve App" {
+			return err
+		}
+
+		ctx.Status("logs", cmdctx.SBEGIN, "Creating log shipper app", shipperName)
+		shipper, err = ctx.Client.API().CreateApp(shipperName, app.Organization.ID, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the shipper only reads logs, so it gets its own read-only token rather
+	// than the user's, which would give anyone with access to it the account
+	ctx.Status("logs", cmdctx.SBEGIN, "Creating a read-only token for", shipperName)
+	token, err := ctx.Client.API().CreateAccessToken(api.CreateAccessTokenInput{
+		OrganizationID: app.Organization.ID,
+		Name:           fmt.Sprintf("%s %s", shipperName, time.Now().Format("2006-01-02")),
+		Type:           api.AccessTokenTypeReadOnly,
+		ExpiresIn:      int(shipperTokenExpiry.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	secrets := map[string]string{
+		"ORG":          app.Organization.Slug,
+		"ACCESS_TOKEN": token.Token,
+		"SUBJECT":      logship.Subject(ctx.AppName),
+	}
+	for k, v := range values {
+		secrets[k] = v
+	}
+
+	ctx.Status("logs", cmdctx.SBEGIN, "Configuring", sink.Name, "sink")
+	if _, err := ctx.Client.API().SetSecrets(shipperName, secrets); err != nil {
+		return err
+	}
+
+	if !shipper.Deployed {
+		ctx.Status("logs", cmdctx.SBEGIN, "Deploying", logship.ShipperImage)
+		_, _, err := ctx.Client.API().DeployImage(api.DeployImageInput{
+			AppID: shipperName,
+			Image: logship.ShipperImage,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx.Statusf("logs", cmdctx.SDONE, "Logs for %s are shipped to %s by %s\n", ctx.AppName, sink.Name, shipperName)
+	fmt.Fprintf(ctx.Out, "%s holds read-only token %s for %s, which %s. Run setup again to replace it, and revoke the old one with 'flyctl tokens revoke'.\n", shipperName, aurora.Bold(token.Name), app.Organization.Slug, formatTokenExpiry(token.ExpiresAt))
+
+	return nil
+}
+
+func promptSinkSettings(sink *logship.Sink, values map[string]string) error {
+	for _, setting := range sink.Settings {
+		if !setting.Required || values[setting.Name] != "" {
+			continue
+		}
+
+		var val string
+		var prompt survey.Prompt
+		message := fmt.Sprintf("%s (%s):", setting.Name, setting.Description)
+
+		if setting.Sensitive {
+			prompt = &survey.Password{Message: message}
+		} else {
+			prompt = &survey.Input{Message: message}
+		}
+
+		if err := ask(prompt, &val); err != nil {
+			return err
+		}
+
+		values[setting.Name] = val
+	}
+
+	return nil
+}
+
+func runLogsShipShow(ctx *cmdctx.CmdContext) error {
+	shipperName := logship.ShipperAppName(ctx.AppName)
+
+	secrets, err := ctx.Client.API().GetAppSecrets(shipperName)
+	if err != nil {
+		if api.IsNotFoundError(err) || err.Error() == "Could not resolve App" {
+			fmt.Fprintf(ctx.Out, "No log shipper configured for %s\n", ctx.AppName)
+			return nil
+		}
+		return err
+	}
+
+	names := []string{}
+	for _, s := range secrets {
+		names = append(names, s.Name)
+	}
+
+	configured := logship.ConfiguredSinks(names)
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(configured)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Log shipper: %s\n\n", aurora.Bold(shipperName))
+
+	if len(configured) == 0 {
+		fmt.Fprintln(ctx.Out, "No sinks configured")
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Sink", "Description", "Settings"})
+	for _, s := range configured {
+		table.Append([]string{s.Name, s.Description, strings.Join(s.SettingNames(), ", ")})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runLogsShipSinks(ctx *cmdctx.CmdContext) error {
+	sinks := logship.Sinks()
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(sinks)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Sink", "Description", "Required", "Optional"})
+	for _, s := range sinks {
+		required := []string{}
+		optional := []string{}
+		for _, setting := range s.Settings {
+			if setting.Required {
+				required = append(required, setting.Name)
+			} else {
+				optional = append(optional, setting.Name)
+			}
+		}
+		table.Append([]string{s.Name, s.Description, strings.Join(required, ", "), strings.Join(optional, ", ")})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runLogsShipRemove(ctx *cmdctx.CmdContext) error {
+	shipperName := logship.ShipperAppName(ctx.AppName)
+
+	if len(ctx.Args) == 1 {
+		sink, err := logship.FindSink(ctx.Args[0])
+		if err != nil {
+			return err
+		}
+
+		secrets, err := ctx.Client.API().GetAppSecrets(shipperName)
+		if err != nil {
+			return err
+		}
+
+		settingNames := sink.SettingNames()
+		keys := []string{}
+		for _, s := range secrets {
+			for _, name := range settingNames {
+				if s.Name == name {
+					keys = append(keys, name)
+				}
+			}
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("%s sink is not configured for %s", sink.Name, ctx.AppName)
+		}
+
+		if _, err := ctx.Client.API().UnsetSecrets(shipperName, keys); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(ctx.Out, "Removed %s sink from %s\n", sink.Name, shipperName)
+		return nil
+	}
+
+	if !confirm(fmt.Sprintf("Destroy log shipper app %s?", shipperName)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteApp(shipperName); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Destroyed log shipper app %s\n", shipperName)
+
+	return nil
+}
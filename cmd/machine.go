@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func newMachineCommand(client *client.Client) *Command {
+	machineStrings := docstrings.Get("machine")
+	cmd := BuildCommandKS(nil, nil, machineStrings, client, requireSession)
+	cmd.Aliases = []string{"machines"}
+
+	runStrings := docstrings.Get("machine.run")
+	runCmd := BuildCommandKS(cmd, runMachineRun, runStrings, client, requireSession, requireAppName)
+	runCmd.Args = cobra.MinimumNArgs(1)
+	runCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the machine"})
+	runCmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "the region to run the machine in"})
+	runCmd.AddIntFlag(IntFlagOpts{Name: "cpus", Description: "number of CPUs"})
+	runCmd.AddIntFlag(IntFlagOpts{Name: "memory", Description: "memory in MB"})
+	runCmd.AddStringFlag(StringFlagOpts{Name: "cpu-kind", Description: "kind of CPU, shared or dedicated"})
+	runCmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "env",
+		Shorthand:   "e",
+		Description: "Set of environment variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+	})
+
+	listStrings := docstrings.Get("machine.list")
+	listCmd := BuildCommandKS(cmd, runMachineList, listStrings, client, requireSession, requireAppName)
+	listCmd.AddStringFlag(StringFlagOpts{Name: "state", Description: "only list machines in this state, e.g. started, stopped or destroyed"})
+
+	stopStrings := docstrings.Get("machine.stop")
+	stopCmd := BuildCommandKS(cmd, runMachineStop, stopStrings, client, requireSession, requireAppName)
+	stopCmd.Args = cobra.ExactArgs(1)
+
+	startStrings := docstrings.Get("machine.start")
+	startCmd := BuildCommandKS(cmd, runMachineStart, startStrings, client, requireSession, requireAppName)
+	startCmd.Args = cobra.ExactArgs(1)
+
+	removeStrings := docstrings.Get("machine.remove")
+	removeCmd := BuildCommandKS(cmd, runMachineRemove, removeStrings, client, requireSession, requireAppName)
+	removeCmd.Aliases = []string{"rm"}
+	removeCmd.Args = cobra.ExactArgs(1)
+	removeCmd.AddBoolFlag(BoolFlagOpts{Name: "force", Shorthand: "f", Description: "kill the machine if it's running"})
+
+	cloneStrings := docstrings.Get("machine.clone")
+	cloneCmd := BuildCommandKS(cmd, runMachineClone, cloneStrings, client, requireSession, requireAppName)
+	cloneCmd.Args = cobra.ExactArgs(1)
+	cloneCmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "the region to run the clone in. defaults to the source machine's region"})
+	cloneCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the clone"})
+
+	updateStrings := docstrings.Get("machine.update")
+	updateCmd := BuildCommandKS(cmd, runMachineUpdate, updateStrings, client, requireSession, requireAppName)
+	updateCmd.AddStringFlag(StringFlagOpts{Name: "image", Shorthand: "i", Description: "the image to roll out"})
+	updateCmd.AddIntFlag(IntFlagOpts{Name: "concurrency", Description: "number of machines to update at once", Default: 1})
+	updateCmd.AddStringFlag(StringFlagOpts{Name: "wait-timeout", Description: "how long to wait for each machine to become healthy", Default: "5m"})
+	updateCmd.AddBoolFlag(BoolFlagOpts{Name: "skip-health-checks", Description: "only wait for machines to start, ignoring their health checks"})
+
+	execStrings := docstrings.Get("machine.exec")
+	execCmd := BuildCommandKS(cmd, runMachineExec, execStrings, client, requireSession, requireAppName)
+	execCmd.Args = cobra.MinimumNArgs(2)
+	execCmd.AddIntFlag(IntFlagOpts{Name: "timeout", Description: "seconds to wait for the command to finish", Default: 30})
+
+	return cmd
+}
+
+func runMachineRun(ctx *cmdctx.CmdContext) error {
+	input := api.LaunchMachineInput{
+		AppID:  ctx.AppName,
+		Name:   ctx.Config.GetString("name"),
+		Region: ctx.Config.GetString("region"),
+		Config: api.MachineConfig{
+			Image: ctx.Args[0],
+			Cmd:   ctx.Args[1:],
+		},
+	}
+
+	if env := ctx.Config.GetStringSlice("env"); len(env) > 0 {
+		parsedEnv, err := cmdutil.ParseKVStringsToMap(env)
+		if err != nil {
+			return fmt.Errorf("invalid env: %w", err)
+		}
+		input.Config.Env = parsedEnv
+	}
+
+	cpus := ctx.Config.GetInt("cpus")
+	memory := ctx.Config.GetInt("memory")
+	cpuKind := ctx.Config.GetString("cpu-kind")
+	if cpus != 0 || memory != 0 || cpuKind != "" {
+		input.Config.Guest = &api.MachineGuest{
+			CPUKind:  cpuKind,
+			CPUs:     cpus,
+			MemoryMB: memory,
+		}
+	}
+
+	machine, err := ctx.Client.API().LaunchMachine(input)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(machine)
+		return nil
+	}
+
+	fmt.Printf("Machine %s launched in %s\n", machine.ID, machine.Region)
+	fmt.Printf("  Name:    %s\n", machine.Name)
+	fmt.Printf("  State:   %s\n", machine.State)
+	fmt.Printf("  Image:   %s\n", machine.Config.Image)
+	for _, ip := range machine.IPs.Nodes {
+		fmt.Printf("  IP:      %s (%s)\n", ip.IP, ip.Kind)
+	}
+
+	return nil
+}
+
+func runMachineList(ctx *cmdctx.CmdContext) error {
+	machines, err := ctx.Client.API().ListMachines(ctx.AppName, ctx.Config.GetString("state"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(machines)
+		return nil
+	}
+
+	if len(machines) == 0 {
+		fmt.Printf("No machines found for %s\n", ctx.AppName)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"ID", "Name", "State", "Region", "Image", "IP", "Created"})
+	for _, machine := range machines {
+		ips := []string{}
+		for _, ip := range machine.IPs.Nodes {
+			ips = append(ips, ip.IP)
+		}
+
+		table.Append([]string{
+			machine.ID,
+			machine.Name,
+			machine.State,
+			machine.Region,
+			machine.Config.Image,
+			strings.Join(ips, ","),
+			humanize.Time(machine.CreatedAt),
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runMachineStop(ctx *cmdctx.CmdContext) error {
+	if err := ctx.Client.API().StopMachine(ctx.AppName, ctx.Args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Machine %s is stopping\n", ctx.Args[0])
+
+	return nil
+}
+
+func runMachineStart(ctx *cmdctx.CmdContext) error {
+	if err := ctx.Client.API().StartMachine(ctx.AppName, ctx.Args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Machine %s is starting\n", ctx.Args[0])
+
+	return nil
+}
+
+func runMachineRemove(ctx *cmdctx.CmdContext) error {
+	if err := ctx.Client.API().RemoveMachine(ctx.AppName, ctx.Args[0], ctx.Config.GetBool("force")); err != nil {
+		return err
+	}
+
+	fmt.Printf("Machine %s has been removed\n", ctx.Args[0])
+
+	return nil
+}
+
+func runMachineClone(ctx *cmdctx.CmdContext) error {
+	source, err := ctx.Client.API().GetMachine(ctx.AppName, ctx.Args[0])
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return flyerr.New(flyerr.NotFound, fmt.Sprintf("machine %s not found in %s", ctx.Args[0], ctx.AppName))
+	}
+
+	region := ctx.Config.GetString("region")
+	if region == "" {
+		region = source.Region
+	}
+
+	input := api.LaunchMachineInput{
+		AppID:  ctx.AppName,
+		Name:   ctx.Config.GetString("name"),
+		Region: region,
+		Config: source.Config,
+	}
+
+	machine, err := ctx.Client.API().LaunchMachine(input)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(machine)
+		return nil
+	}
+
+	fmt.Printf("Machine %s cloned to %s in %s\n", source.ID, machine.ID, machine.Region)
+
+	return nil
+}
+
+func runMachineUpdate(ctx *cmdctx.CmdContext) error {
+	image := ctx.Config.GetString("image")
+	if image == "" {
+		return fmt.Errorf("--image is required")
+	}
+
+	concurrency := ctx.Config.GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	timeout, err := time.ParseDuration(ctx.Config.GetString("wait-timeout"))
+	if err != nil {
+		return fmt.Errorf("invalid --wait-timeout: %w", err)
+	}
+
+	client := ctx.Client.API()
+
+	machines, err := client.ListMachines(ctx.AppName, "")
+	if err != nil {
+		return err
+	}
+
+	pending := []api.Machine{}
+	for _, machine := range machines {
+		if machine.State == "destroyed" || machine.Config.Image == image {
+			continue
+		}
+		pending = append(pending, machine)
+	}
+
+	if len(pending) == 0 {
+		fmt.Printf("All machines of %s are already running %s\n", ctx.AppName, image)
+		return nil
+	}
+
+	fmt.Printf("Updating %d machines to %s, %d at a time\n", len(pending), image, concurrency)
+
+	checkHealth := !ctx.Config.GetBool("skip-health-checks")
+	updated := 0
+
+	cancelCtx := createCancellableContext()
+
+	// machines are updated in batches, and each batch must be healthy before the next starts
+	for start := 0; start < len(pending); start += concurrency {
+		end := start + concurrency
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i := range batch {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = updateMachineImage(cancelCtx, client, ctx.AppName, batch[i], image, timeout, checkHealth)
+			}(i)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("update halted after %d of %d machines, %s failed: %w", updated, len(pending), batch[i].ID, err)
+			}
+			updated++
+		}
+	}
+
+	fmt.Printf("Updated %d machines to %s\n", updated, image)
+
+	return nil
+}
+
+func updateMachineImage(ctx context.Context, client *api.Client, appName string, machine api.Machine, image string, timeout time.Duration, checkHealth bool) error {
+	config := machine.Config
+	config.Image = image
+
+	input := api.UpdateMachineInput{
+		AppID:  appName,
+		ID:     machine.ID,
+		Config: config,
+	}
+
+	// until the machine restarts with the update, it still reports the
+	// state and checks of its previous run
+	previousRun, err := latestMachineRun(client, appName, machine.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.UpdateMachine(input); err != nil {
+		return err
+	}
+
+	fmt.Printf("  %s: updated\n", machine.ID)
+
+	// stopped machines pick up the new image when they're next started
+	if machine.State != "started" {
+		return nil
+	}
+
+	if err := waitForMachineHealthy(ctx, client, appName, machine.ID, previousRun, timeout, checkHealth); err != nil {
+		return err
+	}
+
+	fmt.Printf("  %s: healthy\n", machine.ID)
+
+	return nil
+}
+
+// latestMachineRun returns the ID of the machine's latest run, empty when it
+// hasn't run yet
+func latestMachineRun(client *api.Client, appName string, machineID string) (string, error) {
+	runs, err := client.GetMachineRuns(appName, machineID, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "", nil
+	}
+	return runs[len(runs)-1].ID, nil
+}
+
+// waitForMachineHealthy waits for the machine to start a run other than
+// previousRun, then for it to be started with passing checks
+func waitForMachineHealthy(ctx context.Context, client *api.Client, appName string, machineID string, previousRun string, timeout time.Duration, checkHealth bool) error {
+	deadline := time.Now().Add(timeout)
+	restarted := false
+
+	for {
+		if !restarted {
+			run, err := latestMachineRun(client, appName, machineID)
+			if err != nil {
+				return err
+			}
+			restarted = run != "" && run != previousRun
+		}
+
+		machine, err := client.GetMachine(appName, machineID)
+		if err != nil {
+			return err
+		}
+		if machine == nil {
+			return flyerr.New(flyerr.NotFound, fmt.Sprintf("machine %s not found in %s", machineID, appName))
+		}
+
+		switch state := machine.State; {
+		case !restarted:
+			// still the previous run
+		case state == "failed" || state == "destroyed":
+			return fmt.Errorf("machine is %s", machine.State)
+		case state == "started":
+			if !checkHealth || machineChecksPassing(machine) {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if !restarted {
+				return fmt.Errorf("machine didn't restart with the update within %s", timeout)
+			}
+			return fmt.Errorf("machine wasn't healthy after %s", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func machineChecksPassing(machine *api.Machine) bool {
+	for _, check := range machine.Checks {
+		if check.Status != "passing" {
+			return false
+		}
+	}
+	return true
+}
+
+func runMachineExec(ctx *cmdctx.CmdContext) error {
+	command := ctx.Args[1:]
+
+	// a single argument is a quoted command line, e.g. "ls -la /data"
+	if len(command) == 1 {
+		parts, err := shlex.Split(command[0])
+		if err != nil {
+			return fmt.Errorf("invalid command: %w", err)
+		}
+		command = parts
+	}
+
+	result, err := ctx.Client.API().ExecMachine(ctx.AppName, ctx.Args[0], command, ctx.Config.GetInt("timeout"))
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(result)
+		return nil
+	}
+
+	fmt.Fprint(ctx.Out, result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+
+	if result.ExitCode != 0 {
+		return fmt.Errorf("command exited with code %d", result.ExitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+)
+
+func newMetricsCommand(client *client.Client) *Command {
+	metricsStrings := docstrings.Get("metrics")
+	cmd := BuildCommandKS(nil, nil, metricsStrings, client, requireSession)
+
+	exportStrings := docstrings.Get("metrics.export")
+	exportCmd := BuildCommandKS(cmd, runMetricsExport, exportStrings, client, requireSession)
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "query",
+		Shorthand:   "q",
+		Description: "PromQL query to run",
+	})
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "org",
+		Shorthand:   "o",
+		Description: "The organization whose metrics to query",
+	})
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "time",
+		Description: "Evaluate an instant query at this time, as RFC3339 or a duration ago (e.g. 10m)",
+	})
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "start",
+		Description: "Run a range query from this time, as RFC3339 or a duration ago (e.g. 1h)",
+	})
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "end",
+		Description: "End of a range query, as RFC3339 or a duration ago. Defaults to now",
+	})
+	exportCmd.AddStringFlag(StringFlagOpts{
+		Name:        "step",
+		Description: "Resolution of a range query",
+		Default:     "1m",
+	})
+
+	gpuStrings := docstrings.Get("metrics.gpu")
+	gpuCmd := BuildCommandKS(cmd, runMetricsGPU, gpuStrings, client, requireSession, requireAppName)
+	gpuCmd.AddStringFlag(StringFlagOpts{
+		Name:        "window",
+		Shorthand:   "w",
+		Description: "Time window to chart, e.g. 15m, 1h, 24h",
+		Default:     "1h",
+	})
+	gpuCmd.AddStringFlag(StringFlagOpts{
+		Name:        "instance",
+		Shorthand:   "i",
+		Description: "Filter by instance ID",
+	})
+
+	tokenStrings := docstrings.Get("metrics.token")
+	tokenCmd := BuildCommandKS(cmd, nil, tokenStrings, client, requireSession)
+
+	tokenListStrings := docstrings.Get("metrics.token.list")
+	tokenListCmd := BuildCommandKS(tokenCmd, runMetricsTokenList, tokenListStrings, client, requireSession)
+	tokenListCmd.Args = cobra.MaximumNArgs(1)
+
+	tokenCreateStrings := docstrings.Get("metrics.token.create")
+	tokenCreateCmd := BuildCommandKS(tokenCmd, runMetricsTokenCreate, tokenCreateStrings, client, requireSession)
+	tokenCreateCmd.Args = cobra.MaximumNArgs(2)
+
+	tokenDeleteStrings := docstrings.Get("metrics.token.delete")
+	tokenDeleteCmd := BuildCommandKS(tokenCmd, runMetricsTokenDelete, tokenDeleteStrings, client, requireSession)
+	tokenDeleteCmd.Args = cobra.MaximumNArgs(2)
+
+	return cmd
+}
+
+func runMetricsTokenList(ctx *cmdctx.CmdContext) error {
+	org, err := orgByArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := ctx.Client.API().GetMetricsTokens(org.Slug)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(tokens)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Created"})
+	for _, token := range tokens {
+		table.Append([]string{token.Name, presenters.FormatRelativeTime(token.CreatedAt)})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runMetricsTokenCreate(ctx *cmdctx.CmdContext) error {
+	org, err := orgByArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	name, err := argOrPrompt(ctx, 1, "Memorable name for metrics token: ")
+	if err != nil {
+		return err
+	}
+
+	token, err := ctx.Client.API().CreateMetricsToken(org, name)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(token)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Created read-only metrics token %s for %s. It cannot be shown again.\n\n", aurora.Bold(token.Name), org.Slug)
+	fmt.Fprintf(ctx.Out, "FLY_METRICS_TOKEN=%s\n\n", token.Token)
+	fmt.Fprintln(ctx.Out, "To use it as a Grafana Prometheus data source:")
+	fmt.Fprintf(ctx.Out, "    URL:           %s\n", api.PrometheusURL(org.Slug))
+	fmt.Fprintf(ctx.Out, "    Custom header: Authorization: Bearer <FLY_METRICS_TOKEN>\n")
+
+	return nil
+}
+
+func runMetricsTokenDelete(ctx *cmdctx.CmdContext) error {
+	org, err := orgByArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	name, err := argOrPrompt(ctx, 1, "Name of metrics token to delete: ")
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Delete metrics token %s from %s?", name, org.Slug)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteMetricsToken(org, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Deleted metrics token %s\n", name)
+
+	return nil
+}
+
+func runMetricsExport(ctx *cmdctx.CmdContext) error {
+	query := ctx.Config.GetString("query")
+	if query == "" {
+		return fmt.Errorf("a PromQL query is required, pass one with --query")
+	}
+
+	org, err := selectOrganization(ctx.Client.API(), ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cancelCtx := createCancellableContext()
+
+	var series []api.PromSeries
+
+	if startArg := ctx.Config.GetString("start"); startArg != "" {
+		start, err := parseTimeArg(startArg, now)
+		if err != nil {
+			return fmt.Errorf("invalid start: %s", err)
+		}
+
+		end := now
+		if endArg := ctx.Config.GetString("end"); endArg != "" {
+			if end, err = parseTimeArg(endArg, now); err != nil {
+				return fmt.Errorf("invalid end: %s", err)
+			}
+		}
+
+		step, err := time.ParseDuration(ctx.Config.GetString("step"))
+		if err != nil {
+			return fmt.Errorf("invalid step: %s", err)
+		}
+
+		series, err = ctx.Client.API().PromQueryRange(cancelCtx, org.Slug, query, start, end, step)
+		if err != nil {
+			return err
+		}
+	} else {
+		at := now
+		if timeArg := ctx.Config.GetString("time"); timeArg != "" {
+			if at, err = parseTimeArg(timeArg, now); err != nil {
+				return fmt.Errorf("invalid time: %s", err)
+			}
+		}
+
+		series, err = ctx.Client.API().PromQuery(cancelCtx, org.Slug, query, at)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(series)
+		return nil
+	}
+
+	if len(series) == 0 {
+		fmt.Fprintln(ctx.Out, "No data")
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Series", "Time", "Value"})
+	for _, s := range series {
+		labels := formatPromLabels(s.Labels)
+		for _, sample := range s.Samples {
+			table.Append([]string{labels, sample.Time.Format(time.RFC3339), strconv.FormatFloat(sample.Value, 'f', -1, 64)})
+		}
+	}
+	table.Render()
+
+	return nil
+}
+
+func runMetricsGPU(ctx *cmdctx.CmdContext) error {
+	app, err := ctx.Client.API().GetApp(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	return chartAppMetrics(ctx, app, gpuMetricsCharts)
+}
+
+func gpuMetricsCharts(appName string, rateWindow string) []metricsChart {
+	return []metricsChart{
+		{
+			Name:   "gpu_utilization",
+			Title:  "GPU utilization",
+			Query:  fmt.Sprintf(`avg by (instance, region) (avg_over_time(fly_instance_gpu_utilization{app="%s"}[%s]))`, appName, rateWindow),
+			Format: func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+		},
+		{
+			Name:   "gpu_memory",
+			Title:  "GPU memory used",
+			Query:  fmt.Sprintf(`sum by (instance, region) (fly_instance_gpu_memory_used_bytes{app="%s"})`, appName),
+			Format: func(v float64) string { return humanize.Bytes(uint64(v)) },
+		},
+	}
+}
+
+// parseTimeArg accepts an RFC3339 timestamp or a duration before now
+func parseTimeArg(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func formatPromLabels(labels map[string]string) string {
+	name := labels["__name__"]
+
+	keys := []string{}
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+
+	return name + "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -51,7 +51,7 @@ func monitorDeployment(ctx context.Context, commandContext *cmdctx.CmdContext) e
 	monitor.DeploymentUpdated = func(d *api.DeploymentStatus, updatedAllocs []*api.AllocationStatus) error {
 		commandContext.Status("monitor", cmdctx.SINFO, presenters.FormatDeploymentAllocSummary(d))
 
-		if commandContext.GlobalConfig.GetBool("verbose") {
+		if commandContext.Verbosity() >= cmdctx.VerbosityVerbose {
 			for _, alloc := range updatedAllocs {
 				commandContext.Status("monitor", cmdctx.SINFO, presenters.FormatAllocSummary(alloc))
 			}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/logrusorgru/aurora"
@@ -21,7 +22,6 @@ func newMoveCommand(client *client.Client) *Command {
 	moveCmd := BuildCommandKS(nil, runMove, moveStrings, client, requireSession)
 	moveCmd.Args = cobra.ExactArgs(1)
 	// TODO: Move flag descriptions into the docStrings
-	moveCmd.AddBoolFlag(BoolFlagOpts{Name: "yes", Shorthand: "y", Description: "Accept all confirmations"})
 	moveCmd.AddStringFlag(StringFlagOpts{
 		Name:        "org",
 		Description: `The organization to move the app to`,
@@ -50,14 +50,24 @@ func runMove(commandContext *cmdctx.CmdContext) error {
 		return fmt.Errorf("Error setting organization: %s", err)
 	}
 
-	if !commandContext.Config.GetBool("yes") {
+	if org.Slug == app.Organization.Slug {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is already in %s", appName, org.Slug))
+	}
+
+	inventory, err := commandContext.Client.API().GetAppInventory(appName)
+	if err != nil {
+		return errors.Wrap(err, "Error listing the app's resources")
+	}
+	moveReport(appName, app.Organization.Slug, org.Slug, inventory).print(commandContext.Out)
+
+	if !autoConfirmed() {
 		fmt.Println(aurora.Red("Are you sure you want to move this app?"))
 
 		confirm := false
 		prompt := &survey.Confirm{
 			Message: fmt.Sprintf("Move %s from %s to %s?", appName, app.Organization.Slug, org.Slug),
 		}
-		err = survey.AskOne(prompt, &confirm)
+		err = ask(prompt, &confirm)
 		if err != nil {
 			return err
 		}
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/flyname"
 	"github.com/superfly/flyctl/internal/client"
@@ -10,23 +14,26 @@ import (
 	"github.com/superfly/flyctl/docstrings"
 
 	"github.com/skratchdot/open-golang/open"
+	"github.com/spf13/cobra"
 )
 
 func newOpenCommand(client *client.Client) *Command {
 	ks := docstrings.Get("open")
 	opencommand := BuildCommandKS(nil, runOpen, ks, client, requireSession, requireAppName)
+	opencommand.Args = cobra.MaximumNArgs(1)
+	opencommand.AddBoolFlag(BoolFlagOpts{Name: "print", Description: "print the URL instead of opening a browser"})
+	opencommand.AddBoolFlag(BoolFlagOpts{Name: "no-check", Description: "don't check that the app responds before opening it"})
 	return opencommand
 }
 
 func runOpen(ctx *cmdctx.CmdContext) error {
 	var path = "/"
 
-	if len(ctx.Args) > 1 {
-		return fmt.Errorf("too many arguments - only one path argument allowed")
-	}
-
 	if len(ctx.Args) > 0 {
 		path = ctx.Args[0]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
 	}
 
 	app, err := ctx.Client.API().GetApp(ctx.AppName)
@@ -39,15 +46,52 @@ func runOpen(ctx *cmdctx.CmdContext) error {
 		return nil
 	}
 
-	if len(ctx.Args) > 1 {
-		return fmt.Errorf("too many arguments - only one path argument allowed")
+	certs, err := ctx.Client.API().GetAppCertificates(ctx.AppName)
+	if err != nil {
+		return err
 	}
 
-	if len(ctx.Args) > 0 {
-		path = ctx.Args[0]
+	appURL := appBaseURL(app, certs) + path
+
+	if !ctx.Config.GetBool("no-check") {
+		if err := checkAppResponds(appURL); err != nil {
+			return fmt.Errorf("%s is not responding: %w", appURL, err)
+		}
+	}
+
+	if ctx.Config.GetBool("print") {
+		fmt.Fprintln(ctx.Out, appURL)
+		return nil
+	}
+
+	fmt.Println("Opening", appURL)
+	return open.Run(appURL)
+}
+
+// appBaseURL prefers a custom domain with a ready certificate over the app's default hostname
+func appBaseURL(app *api.App, certs []api.AppCertificateCompact) string {
+	for _, cert := range certs {
+		if cert.ClientStatus == "Ready" && !strings.HasPrefix(cert.Hostname, "*") {
+			return "https://" + cert.Hostname
+		}
+	}
+
+	return "http://" + app.Hostname
+}
+
+// checkAppResponds fails if the URL can't be reached or returns a server error
+func checkAppResponds(url string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("server returned %s", resp.Status)
 	}
 
-	docsURL := "http://" + app.Hostname + path
-	fmt.Println("Opening", docsURL)
-	return open.Run(docsURL)
+	return nil
 }
@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/dustin/go-humanize"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 )
 
 func newOrgsCommand(client *client.Client) *Command {
@@ -26,6 +29,7 @@ func newOrgsCommand(client *client.Client) *Command {
 	orgsInviteStrings := docstrings.Get("orgs.invite")
 	orgsInviteCommand := BuildCommandKS(orgscmd, runOrgsInvite, orgsInviteStrings, client, requireSession)
 	orgsInviteCommand.Args = cobra.MaximumNArgs(2)
+	orgsInviteCommand.AddStringFlag(StringFlagOpts{Name: "role", Description: "Role to invite the user with: member or admin", Default: "member"})
 
 	orgsRevokeStrings := docstrings.Get("orgs.revoke")
 	orgsRevokeCommand := BuildCommandKS(orgscmd, runOrgsRevoke, orgsRevokeStrings, client, requireSession)
@@ -33,8 +37,13 @@ func newOrgsCommand(client *client.Client) *Command {
 
 	orgsRemoveStrings := docstrings.Get("orgs.remove")
 	orgsRemoveCommand := BuildCommandKS(orgscmd, runOrgsRemove, orgsRemoveStrings, client, requireSession)
+	orgsRemoveCommand.Aliases = []string{"remove-member"}
 	orgsRemoveCommand.Args = cobra.MaximumNArgs(2)
 
+	orgsSetRoleStrings := docstrings.Get("orgs.set-role")
+	orgsSetRoleCommand := BuildCommandKS(orgscmd, runOrgsSetRole, orgsSetRoleStrings, client, requireSession)
+	orgsSetRoleCommand.Args = cobra.ExactArgs(3)
+
 	orgsCreateStrings := docstrings.Get("orgs.create")
 	orgsCreateCommand := BuildCommandKS(orgscmd, runOrgsCreate, orgsCreateStrings, client, requireSession)
 	orgsCreateCommand.Args = cobra.RangeArgs(0, 1)
@@ -136,43 +145,51 @@ func runOrgsShow(ctx *cmdctx.CmdContext) error {
 	}
 	membertable.Render()
 
-	return nil
-}
+	pending := []api.Invitation{}
+	for _, in := range org.Invitations.Nodes {
+		if !in.Redeemed {
+			pending = append(pending, in)
+		}
+	}
 
-func runOrgsInvite(ctx *cmdctx.CmdContext) error {
-	var orgSlug, userEmail string
+	if len(pending) > 0 {
+		ctx.StatusLn()
 
-	orgType := api.OrganizationTypeShared
+		ctx.Statusf("orgs", cmdctx.STITLE, "Pending Invitations\n")
 
-	switch len(ctx.Args) {
-	case 0:
-		org, err := selectOrganization(ctx.Client.API(), "", &orgType)
-		if err != nil {
-			return err
-		}
-		orgSlug = org.Slug
+		invitetable := tablewriter.NewWriter(ctx.Out)
+		invitetable.SetHeader([]string{"Email", "Sent"})
 
-		userEmail, err = inputUserEmail()
-		if err != nil {
-			return err
+		for _, in := range pending {
+			invitetable.Append([]string{in.Email, humanize.Time(in.CreatedAt)})
 		}
-	case 1:
-		// TODO: Validity check on org
-		orgSlug = ctx.Args[0]
-	case 2:
-		userEmail = ctx.Args[1]
+		invitetable.Render()
 	}
 
-	org, err := ctx.Client.API().GetOrganizationBySlug(orgSlug)
+	return nil
+}
+
+func runOrgsInvite(ctx *cmdctx.CmdContext) error {
+	role, err := normalizeOrgRole(ctx.Config.GetString("role"))
 	if err != nil {
 		return err
 	}
 
-	out, err := ctx.Client.API().CreateOrganizationInvite(org.ID, userEmail)
+	org, userEmail, err := orgAndEmailArgs(ctx)
 	if err != nil {
 		return err
 	}
 
+	out, err := ctx.Client.API().CreateOrganizationInvite(org.ID, userEmail, role)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(out)
+		return nil
+	}
+
 	printInvite(*out, true)
 
 	return nil
@@ -187,10 +204,11 @@ func runOrgsCreate(ctx *cmdctx.CmdContext) error {
 		prompt := &survey.Input{
 			Message: "Enter Organization Name:",
 		}
-		if err := survey.AskOne(prompt, &orgname); err != nil {
+		if err := ask(prompt, &orgname); err != nil {
 			if isInterrupt(err) {
 				return nil
 			}
+			return err
 		}
 	} else {
 		orgname = ctx.Args[0]
@@ -211,11 +229,156 @@ func runOrgsCreate(ctx *cmdctx.CmdContext) error {
 }
 
 func runOrgsRemove(ctx *cmdctx.CmdContext) error {
-	return fmt.Errorf("Remove Not implemented")
+	org, userEmail, err := orgAndEmailArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	member, err := findOrgMember(org, userEmail)
+	if err != nil {
+		return err
+	}
+
+	if !confirm(fmt.Sprintf("Are you sure you want to remove %s from the %s organization?", userEmail, org.Slug)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteOrganizationMembership(org.ID, member.Node.ID); err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(member)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Removed %s from %s\n", userEmail, org.Slug)
+
+	return nil
 }
 
 func runOrgsRevoke(ctx *cmdctx.CmdContext) error {
-	return fmt.Errorf("Revoke Not implemented")
+	org, userEmail, err := orgAndEmailArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var invitation *api.Invitation
+	for _, in := range org.Invitations.Nodes {
+		if strings.EqualFold(in.Email, userEmail) && !in.Redeemed {
+			in := in
+			invitation = &in
+			break
+		}
+	}
+	if invitation == nil {
+		return flyerr.New(flyerr.NotFound, fmt.Sprintf("no pending invitation to %s for %s", org.Slug, userEmail))
+	}
+
+	if err := ctx.Client.API().DeleteOrganizationInvite(invitation.ID); err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(invitation)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Revoked the invitation to %s for %s\n", org.Slug, userEmail)
+
+	return nil
+}
+
+func runOrgsSetRole(ctx *cmdctx.CmdContext) error {
+	role, err := normalizeOrgRole(ctx.Args[2])
+	if err != nil {
+		return err
+	}
+
+	org, userEmail, err := orgAndEmailArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	member, err := findOrgMember(org, userEmail)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Client.API().UpdateOrganizationMembership(org.ID, member.Node.ID, role); err != nil {
+		return err
+	}
+	member.Role = strings.ToLower(role)
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(member)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "%s is now %s of %s\n", userEmail, withArticle(member.Role), org.Slug)
+
+	return nil
+}
+
+// orgAndEmailArgs - the organization and user email from the first two args,
+// prompting for any that weren't given
+func orgAndEmailArgs(ctx *cmdctx.CmdContext) (*api.OrganizationDetails, string, error) {
+	var orgSlug, userEmail string
+	if len(ctx.Args) > 0 {
+		orgSlug = ctx.Args[0]
+	}
+	if len(ctx.Args) > 1 {
+		userEmail = ctx.Args[1]
+	}
+
+	if orgSlug == "" {
+		orgType := api.OrganizationTypeShared
+		org, err := selectOrganization(ctx.Client.API(), "", &orgType)
+		if err != nil {
+			return nil, "", err
+		}
+		orgSlug = org.Slug
+	}
+
+	if userEmail == "" {
+		var err error
+		if userEmail, err = inputUserEmail(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	org, err := ctx.Client.API().GetOrganizationBySlug(orgSlug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return org, userEmail, nil
+}
+
+func findOrgMember(org *api.OrganizationDetails, email string) (*api.OrganizationMembershipEdge, error) {
+	for _, m := range org.Members.Edges {
+		if strings.EqualFold(m.Node.Email, email) {
+			m := m
+			return &m, nil
+		}
+	}
+	return nil, flyerr.New(flyerr.NotFound, fmt.Sprintf("%s is not a member of %s. See orgs revoke for pending invitations", email, org.Slug))
+}
+
+// normalizeOrgRole - the API's name for a member or admin role
+func normalizeOrgRole(role string) (string, error) {
+	switch strings.ToLower(role) {
+	case "member", "admin":
+		return strings.ToUpper(role), nil
+	}
+	return "", flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid role %q, must be member or admin", role))
+}
+
+func withArticle(word string) string {
+	if strings.HasPrefix(word, "a") {
+		return "an " + word
+	}
+	return "a " + word
 }
 
 func runOrgsDelete(ctx *cmdctx.CmdContext) error {
@@ -227,17 +390,22 @@ func runOrgsDelete(ctx *cmdctx.CmdContext) error {
 		return err
 	}
 
-	confirmed := confirm(fmt.Sprintf("Are you sure you want to delete the %s organization?", orgslug))
-
-	if !confirmed {
+	if !confirm(fmt.Sprintf("Are you sure you want to delete the %s organization?", orgslug)) {
 		return nil
 	}
 
-	_, err = ctx.Client.API().DeleteOrganization(org.ID)
+	deletedID, err := ctx.Client.API().DeleteOrganization(org.ID)
 
 	if err != nil {
 		return err
 	}
 
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(map[string]string{"DeletedOrganizationId": deletedID})
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Deleted the %s organization\n", orgslug)
+
 	return nil
 }
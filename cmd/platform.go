@@ -2,10 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/skratchdot/open-golang/open"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/catalog"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/superfly/flyctl/docstrings"
 
@@ -18,10 +24,20 @@ func newPlatformCommand(client *client.Client) *Command {
 	cmd := BuildCommandKS(nil, nil, platformStrings, client, requireAppName)
 
 	regionsStrings := docstrings.Get("platform.regions")
-	BuildCommandKS(cmd, runPlatformRegions, regionsStrings, client, requireSession)
+	regionsCmd := BuildCommandKS(cmd, runPlatformRegions, regionsStrings, client, requireSession)
+	regionsCmd.AddBoolFlag(BoolFlagOpts{Name: "gpu", Description: "Only show regions with GPU VMs available"})
+	regionsCmd.AddBoolFlag(BoolFlagOpts{Name: "performance", Description: "Only show regions with performance VMs available"})
+	regionsCmd.AddBoolFlag(BoolFlagOpts{Name: "available", Description: "Hide regions with low capacity"})
 
 	vmSizesStrings := docstrings.Get("platform.vmsizes")
-	BuildCommandKS(cmd, runPlatformVMSizes, vmSizesStrings, client, requireSession)
+	vmSizesCmd := BuildCommandKS(cmd, runPlatformVMSizes, vmSizesStrings, client, requireSession)
+	vmSizesCmd.AddBoolFlag(BoolFlagOpts{Name: "gpu", Description: "Only show GPU VM sizes"})
+	vmSizesCmd.AddBoolFlag(BoolFlagOpts{Name: "performance", Description: "Only show performance VM sizes"})
+	vmSizesCmd.AddStringFlag(StringFlagOpts{Name: "region", Shorthand: "r", Description: "Only show VM sizes available in this region"})
+	vmSizesCmd.AddBoolFlag(BoolFlagOpts{Name: "available", Description: "Hide VM sizes that are only available in regions with low capacity"})
+
+	refreshStrings := docstrings.Get("platform.refresh")
+	BuildCommandKS(cmd, runPlatformRefresh, refreshStrings, client, requireSession)
 
 	statusStrings := docstrings.Get("platform.status")
 	BuildCommandKS(cmd, runPlatformStatus, statusStrings, client, requireSession, requireAppName)
@@ -30,29 +46,182 @@ func newPlatformCommand(client *client.Client) *Command {
 }
 
 func runPlatformRegions(ctx *cmdctx.CmdContext) error {
-	regions, _, err := ctx.Client.API().PlatformRegions()
+	regions, err := ctx.Client.API().PlatformRegionsCapacity()
 	if err != nil {
 		return err
 	}
 
+	gpu := ctx.Config.GetBool("gpu")
+	performance := ctx.Config.GetBool("performance")
+	available := ctx.Config.GetBool("available")
+
+	filtered := []api.Region{}
+	for _, region := range regions {
+		if gpu && !region.GpuAvailable {
+			continue
+		}
+		if performance && !region.PerformanceAvailable {
+			continue
+		}
+		if available && strings.EqualFold(region.Capacity, "low") {
+			continue
+		}
+		filtered = append(filtered, region)
+	}
+
 	return ctx.Frender(cmdctx.PresenterOption{
-		Presentable: &presenters.Regions{Regions: regions},
+		Presentable: &presenters.Regions{Regions: filtered, ShowCapacity: true},
 	})
 }
 
 func runPlatformVMSizes(ctx *cmdctx.CmdContext) error {
-	sizes, err := ctx.Client.API().PlatformVMSizes()
+	sizes, err := platformVMSizes(ctx.Client.API())
 	if err != nil {
 		return err
 	}
 
+	regions, err := ctx.Client.API().PlatformRegionsCapacity()
+	if err != nil {
+		return err
+	}
+
+	if code := ctx.Config.GetString("region"); code != "" {
+		filtered := []api.Region{}
+		for _, region := range regions {
+			if strings.EqualFold(region.Code, code) {
+				filtered = append(filtered, region)
+			}
+		}
+		if len(filtered) == 0 {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s isn't a region, see flyctl platform regions", code))
+		}
+		regions = filtered
+	}
+
+	gpu := ctx.Config.GetBool("gpu")
+	performance := ctx.Config.GetBool("performance")
+	available := ctx.Config.GetBool("available")
+
+	filtered := []api.VMSize{}
+	sizeRegions := map[string][]api.Region{}
+	for _, size := range sizes {
+		if gpu && size.GPUKind == "" {
+			continue
+		}
+		if performance && !isPerformanceSize(size) {
+			continue
+		}
+
+		runsIn := vmSizeRegions(size, regions)
+		if len(runsIn) == 0 && ctx.Config.GetString("region") != "" {
+			continue
+		}
+		if available && (len(runsIn) == 0 || strings.EqualFold(presenters.BestCapacity(runsIn), "low")) {
+			continue
+		}
+
+		filtered = append(filtered, size)
+		sizeRegions[size.Name] = runsIn
+	}
+
 	return ctx.Frender(cmdctx.PresenterOption{
-		Presentable: &presenters.VMSizes{VMSizes: sizes},
+		Presentable: &presenters.VMSizes{VMSizes: filtered, Regions: sizeRegions},
 	})
 }
 
+func isPerformanceSize(size api.VMSize) bool {
+	return strings.HasPrefix(size.Name, "performance-")
+}
+
+// vmSizeRegions - the regions of regions size can run in. GPU sizes need a
+// region with their GPU model, and performance sizes one with performance
+// VMs.
+func vmSizeRegions(size api.VMSize, regions []api.Region) []api.Region {
+	runsIn := []api.Region{}
+	for _, region := range regions {
+		if isPerformanceSize(size) && !region.PerformanceAvailable {
+			continue
+		}
+		if size.GPUKind != "" && !regionHasGPU(region, size.GPUKind) {
+			continue
+		}
+		runsIn = append(runsIn, region)
+	}
+	return runsIn
+}
+
+func regionHasGPU(region api.Region, kind string) bool {
+	if !region.GpuAvailable {
+		return false
+	}
+	for _, k := range region.GPUKinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	// regions that don't name their GPU models may have any of them
+	return len(region.GPUKinds) == 0
+}
+
 func runPlatformStatus(ctx *cmdctx.CmdContext) error {
 	docsURL := "https://status.fly.io/"
 	fmt.Println("Opening", docsURL)
 	return open.Run(docsURL)
 }
+
+func runPlatformRefresh(ctx *cmdctx.CmdContext) error {
+	c, err := catalog.Refresh(flyctl.ConfigDir(), ctx.Client.API())
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(c)
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Refreshed the platform catalog: %d regions and %d VM sizes\n", len(c.Regions), len(c.VMSizes))
+
+	return nil
+}
+
+// platformRegions - the platform's regions and the one closest to us, from
+// the cached catalog
+func platformRegions(client *api.Client) ([]api.Region, *api.Region, error) {
+	c, err := catalog.Get(flyctl.ConfigDir(), client, catalog.DefaultTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.Regions, c.Region(c.RequestRegion), nil
+}
+
+// platformRegionsIncluding is platformRegions, refreshing the cached catalog
+// if any of codes aren't in it in case they're new regions
+func platformRegionsIncluding(client *api.Client, codes ...string) ([]api.Region, *api.Region, error) {
+	c, err := catalog.Get(flyctl.ConfigDir(), client, catalog.DefaultTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, code := range codes {
+		if code != "" && c.Region(code) == nil && !c.Fresh(time.Minute) {
+			if c, err = catalog.Refresh(flyctl.ConfigDir(), client); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+
+	return c.Regions, c.Region(c.RequestRegion), nil
+}
+
+// platformVMSizes - the VM sizes from the cached catalog
+func platformVMSizes(client *api.Client) ([]api.VMSize, error) {
+	c, err := catalog.Get(flyctl.ConfigDir(), client, catalog.DefaultTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.VMSizes, nil
+}
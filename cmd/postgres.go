@@ -3,11 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/cli/safeexec"
+	"github.com/dustin/go-humanize"
 	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
@@ -16,6 +19,7 @@ import (
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/secretgen"
 )
 
 func newPostgresCommand(client *client.Client) *Command {
@@ -54,6 +58,11 @@ func newPostgresCommand(client *client.Client) *Command {
 	listDBCmd := BuildCommandKS(dbCmd, runListPostgresDatabases, listDBStrings, client, requireSession, requireAppNameAsArg)
 	listDBCmd.Args = cobra.ExactArgs(1)
 
+	createDBStrings := docstrings.Get("postgres.db.create")
+	createDBCmd := BuildCommandKS(dbCmd, runCreatePostgresDatabase, createDBStrings, client, requireSession, requireAppNameAsArg)
+	createDBCmd.Args = cobra.ExactArgs(1)
+	createDBCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the database"})
+
 	usersStrings := docstrings.Get("postgres.users")
 	usersCmd := BuildCommandKS(cmd, nil, usersStrings, client, requireSession)
 
@@ -61,6 +70,44 @@ func newPostgresCommand(client *client.Client) *Command {
 	usersListCmd := BuildCommandKS(usersCmd, runListPostgresUsers, usersListStrings, client, requireSession, requireAppNameAsArg)
 	usersListCmd.Args = cobra.ExactArgs(1)
 
+	usersCreateStrings := docstrings.Get("postgres.users.create")
+	usersCreateCmd := BuildCommandKS(usersCmd, runCreatePostgresUser, usersCreateStrings, client, requireSession, requireAppNameAsArg)
+	usersCreateCmd.Args = cobra.ExactArgs(1)
+	usersCreateCmd.AddStringFlag(StringFlagOpts{Name: "username", Description: "the name of the user"})
+	usersCreateCmd.AddStringFlag(StringFlagOpts{Name: "password", Description: "the user's password. one will be generated for you if you leave this blank"})
+	usersCreateCmd.AddBoolFlag(BoolFlagOpts{Name: "superuser", Description: "grant the user superuser privileges"})
+
+	connectStrings := docstrings.Get("postgres.connect")
+	connectCmd := BuildCommandKS(cmd, runConnectPostgres, connectStrings, client, requireSession, requireAppNameAsArg)
+	connectCmd.Args = cobra.ExactArgs(1)
+	connectCmd.AddStringFlag(StringFlagOpts{Name: "username", Description: "the user to connect as", Default: "postgres"})
+	connectCmd.AddStringFlag(StringFlagOpts{Name: "database", Description: "the database to connect to", Default: "postgres"})
+	connectCmd.AddStringFlag(StringFlagOpts{Name: "password", Description: "the user's password. psql will prompt for it if you leave this blank"})
+
+	replicationStrings := docstrings.Get("postgres.replication")
+	replicationCmd := BuildCommandKS(cmd, runPostgresReplication, replicationStrings, client, requireSession, requireAppNameAsArg)
+	replicationCmd.Args = cobra.ExactArgs(1)
+
+	failoverStrings := docstrings.Get("postgres.failover")
+	failoverCmd := BuildCommandKS(cmd, runPostgresFailover, failoverStrings, client, requireSession, requireAppNameAsArg)
+	failoverCmd.Args = cobra.ExactArgs(1)
+	failoverCmd.AddStringFlag(StringFlagOpts{Name: "region", Description: "promote the replica in this region. defaults to the healthy replica with the least lag"})
+	failoverCmd.AddStringFlag(StringFlagOpts{Name: "max-lag", Description: "refuse to fail over to a replica lagging further behind than this", Default: "16MB"})
+
+	replicasStrings := docstrings.Get("postgres.replicas")
+	replicasCmd := BuildCommandKS(cmd, nil, replicasStrings, client, requireSession)
+
+	replicasAddStrings := docstrings.Get("postgres.replicas.add")
+	replicasAddCmd := BuildCommandKS(replicasCmd, runAddPostgresReplica, replicasAddStrings, client, requireSession, requireAppNameAsArg)
+	replicasAddCmd.Args = cobra.ExactArgs(1)
+	replicasAddCmd.AddStringFlag(StringFlagOpts{Name: "region", Description: "the region to add the replica in"})
+	replicasAddCmd.AddIntFlag(IntFlagOpts{Name: "volume-size", Description: "the size in GB for the replica's volume. defaults to the size of the existing volumes"})
+
+	replicasRemoveStrings := docstrings.Get("postgres.replicas.remove")
+	replicasRemoveCmd := BuildCommandKS(replicasCmd, runRemovePostgresReplica, replicasRemoveStrings, client, requireSession, requireAppNameAsArg)
+	replicasRemoveCmd.Args = cobra.ExactArgs(1)
+	replicasRemoveCmd.AddStringFlag(StringFlagOpts{Name: "region", Description: "the region to remove the replica from"})
+
 	return cmd
 }
 
@@ -270,3 +317,339 @@ func runListPostgresUsers(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+func runCreatePostgresDatabase(ctx *cmdctx.CmdContext) error {
+	name := ctx.Config.GetString("name")
+	if name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	database, err := ctx.Client.API().CreatePostgresDatabase(ctx.AppName, name)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(database)
+		return nil
+	}
+
+	fmt.Printf("Database %s created in %s\n", database.Name, ctx.AppName)
+
+	return nil
+}
+
+func runCreatePostgresUser(ctx *cmdctx.CmdContext) error {
+	username := ctx.Config.GetString("username")
+	if username == "" {
+		return fmt.Errorf("--username is required")
+	}
+
+	password := ctx.Config.GetString("password")
+	if password == "" {
+		p, err := secretgen.RandomHex(16)
+		if err != nil {
+			return err
+		}
+		password = p
+	}
+
+	user, err := ctx.Client.API().CreatePostgresUser(ctx.AppName, username, password, ctx.Config.GetBool("superuser"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("User %s created in %s\n", user.Username, ctx.AppName)
+	fmt.Printf("  Username:    %s\n", user.Username)
+	fmt.Printf("  Password:    %s\n", password)
+	fmt.Printf("  Superuser:   %t\n", user.IsSuperuser)
+
+	if ctx.Config.GetString("password") == "" {
+		fmt.Println(aurora.Italic("Save your credentials in a secure place, you won't be able to see them again!"))
+	}
+
+	return nil
+}
+
+func runConnectPostgres(ctx *cmdctx.CmdContext) error {
+	psql, err := safeexec.LookPath("psql")
+	if err != nil {
+		return fmt.Errorf("psql not found, install the postgres client tools to connect: %w", err)
+	}
+
+	app, err := ctx.Client.API().GetApp(ctx.AppName)
+	if err != nil {
+		return fmt.Errorf("get app: %w", err)
+	}
+
+	// psql can't use the userspace tunnel, so proxy a local port to the cluster
+	proxy, err := openPrivateProxy(ctx.Client.API(), &app.Organization, fmt.Sprintf("%s.internal", ctx.AppName), "5432")
+	if err != nil {
+		return err
+	}
+	defer proxy.Close()
+
+	psqlCmd := exec.Command(psql, "-h", "127.0.0.1", "-p", proxy.Port(), "-U", ctx.Config.GetString("username"), ctx.Config.GetString("database"))
+	psqlCmd.Env = os.Environ()
+	if password := ctx.Config.GetString("password"); password != "" {
+		psqlCmd.Env = append(psqlCmd.Env, "PGPASSWORD="+password)
+	}
+	psqlCmd.Stdin = os.Stdin
+	psqlCmd.Stdout = os.Stdout
+	psqlCmd.Stderr = os.Stderr
+
+	return psqlCmd.Run()
+}
+
+func runPostgresReplication(ctx *cmdctx.CmdContext) error {
+	members, err := ctx.Client.API().ListPostgresMembers(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(members)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Instance", "Region", "Role", "Health", "Lag"})
+
+	for _, member := range members {
+		health := "healthy"
+		if !member.Healthy {
+			health = "unhealthy"
+		}
+
+		lag := "-"
+		if member.Role != postgresLeaderRole {
+			lag = humanize.Bytes(uint64(member.ReplicationLag))
+		}
+
+		table.Append([]string{member.IDShort, member.Region, member.Role, health, lag})
+	}
+
+	table.Render()
+
+	return nil
+}
+
+const postgresLeaderRole = "leader"
+
+func postgresLeader(members []api.PostgresClusterMember) *api.PostgresClusterMember {
+	for i := range members {
+		if members[i].Role == postgresLeaderRole {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+// failoverTarget picks the replica to promote. When region is empty the
+// healthy replica with the least lag wins. Replicas lagging more than maxLag
+// bytes are never chosen, since promoting them would lose recent writes.
+func failoverTarget(members []api.PostgresClusterMember, region string, maxLag int64) (*api.PostgresClusterMember, error) {
+	var target *api.PostgresClusterMember
+
+	for i := range members {
+		member := &members[i]
+		if member.Role == postgresLeaderRole {
+			continue
+		}
+		if region != "" && member.Region != region {
+			continue
+		}
+		if !member.Healthy {
+			if region != "" {
+				return nil, fmt.Errorf("replica %s in %s is unhealthy", member.IDShort, region)
+			}
+			continue
+		}
+		if target == nil || member.ReplicationLag < target.ReplicationLag {
+			target = member
+		}
+	}
+
+	if target == nil {
+		if region != "" {
+			return nil, fmt.Errorf("no replica found in %s", region)
+		}
+		return nil, fmt.Errorf("no healthy replicas available to fail over to")
+	}
+
+	if target.ReplicationLag > maxLag {
+		return nil, fmt.Errorf("replica %s in %s is %s behind the leader, exceeding the --max-lag of %s",
+			target.IDShort, target.Region,
+			humanize.Bytes(uint64(target.ReplicationLag)), humanize.Bytes(uint64(maxLag)))
+	}
+
+	return target, nil
+}
+
+func runPostgresFailover(ctx *cmdctx.CmdContext) error {
+	maxLag, err := humanize.ParseBytes(ctx.Config.GetString("max-lag"))
+	if err != nil {
+		return fmt.Errorf("invalid --max-lag: %w", err)
+	}
+
+	members, err := ctx.Client.API().ListPostgresMembers(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	leader := postgresLeader(members)
+	if leader == nil {
+		return fmt.Errorf("%s has no leader, check its status with `flyctl status -a %s`", ctx.AppName, ctx.AppName)
+	}
+
+	target, err := failoverTarget(members, ctx.Config.GetString("region"), int64(maxLag))
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Promote %s in %s to leader, demoting %s in %s?",
+		target.IDShort, target.Region, leader.IDShort, leader.Region)
+	if !confirm(msg) {
+		return nil
+	}
+
+	newLeader, err := ctx.Client.API().FailoverPostgresCluster(ctx.AppName, target.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Failover complete, %s in %s is now the leader\n", newLeader.IDShort, newLeader.Region)
+
+	return nil
+}
+
+func postgresVMCount(client *api.Client, appName string) (int, error) {
+	groups, err := client.GetAppVMCount(appName)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, group := range groups {
+		count += group.Count
+	}
+	return count, nil
+}
+
+func runAddPostgresReplica(ctx *cmdctx.CmdContext) error {
+	region := ctx.Config.GetString("region")
+	if region == "" {
+		return fmt.Errorf("--region is required")
+	}
+
+	client := ctx.Client.API()
+
+	volumes, err := client.GetVolumes(ctx.AppName)
+	if err != nil {
+		return err
+	}
+	if len(volumes) == 0 {
+		return fmt.Errorf("%s has no volumes, is it a postgres cluster?", ctx.AppName)
+	}
+
+	sizeGb := ctx.Config.GetInt("volume-size")
+	if sizeGb == 0 {
+		sizeGb = volumes[0].SizeGb
+	}
+
+	count, err := postgresVMCount(client, ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	volume, err := client.CreateVolume(ctx.AppName, volumes[0].Name, region, sizeGb, volumes[0].Encrypted, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %dGB volume %s in %s\n", volume.SizeGb, volume.ID, volume.Region)
+
+	_, warnings, err := client.SetAppVMCount(ctx.AppName, count+1)
+	if err != nil {
+		return fmt.Errorf("volume %s was created but scaling failed, delete it with `flyctl volumes delete %s`: %w", volume.ID, volume.ID, err)
+	}
+
+	for _, warning := range warnings {
+		fmt.Println("Warning:", warning)
+	}
+
+	fmt.Printf("Scaled %s to %d instances, the new replica will sync from the leader once it starts\n", ctx.AppName, count+1)
+
+	return nil
+}
+
+func runRemovePostgresReplica(ctx *cmdctx.CmdContext) error {
+	region := ctx.Config.GetString("region")
+	if region == "" {
+		return fmt.Errorf("--region is required")
+	}
+
+	client := ctx.Client.API()
+
+	members, err := client.ListPostgresMembers(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	var replica *api.PostgresClusterMember
+	for i := range members {
+		if members[i].Region != region {
+			continue
+		}
+		if members[i].Role == postgresLeaderRole {
+			return fmt.Errorf("the leader is in %s, run `flyctl postgres failover %s` before removing it", region, ctx.AppName)
+		}
+		replica = &members[i]
+	}
+	if replica == nil {
+		return fmt.Errorf("no replica found in %s", region)
+	}
+
+	volumes, err := client.GetVolumes(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	var volume *api.Volume
+	for i := range volumes {
+		attached := volumes[i].AttachedAllocation
+		if attached != nil && (attached.ID == replica.ID || attached.IDShort == replica.IDShort) {
+			volume = &volumes[i]
+			break
+		}
+	}
+
+	msg := fmt.Sprintf("Remove replica %s in %s?", replica.IDShort, region)
+	if volume != nil {
+		msg = fmt.Sprintf("Remove replica %s in %s and delete its volume %s?", replica.IDShort, region, volume.ID)
+	}
+	if !confirm(msg) {
+		return nil
+	}
+
+	count, err := postgresVMCount(client, ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := client.SetAppVMCount(ctx.AppName, count-1); err != nil {
+		return err
+	}
+
+	if err := client.StopAllocation(ctx.AppName, replica.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Stopped replica %s in %s\n", replica.IDShort, region)
+
+	if volume != nil {
+		if _, err := client.DeleteVolume(volume.ID); err != nil {
+			return fmt.Errorf("replica stopped but volume %s could not be deleted: %w", volume.ID, err)
+		}
+		fmt.Printf("Deleted volume %s\n", volume.ID)
+	}
+
+	return nil
+}
@@ -16,7 +16,7 @@ func (p *AllocationChecks) APIStruct() interface{} {
 
 // FieldNames - returns the associated field names for check states
 func (p *AllocationChecks) FieldNames() []string {
-	return []string{"ID", "Service", "State", "Output"}
+	return []string{"ID", "Service", "Type", "State", "Since", "Output"}
 }
 
 // Records - formats check states into map
@@ -24,10 +24,17 @@ func (p *AllocationChecks) Records() []map[string]string {
 	out := []map[string]string{}
 
 	for _, check := range p.Checks {
+		since := ""
+		if !check.StatusChangedAt.IsZero() {
+			since = FormatRelativeTime(check.StatusChangedAt)
+		}
+
 		out = append(out, map[string]string{
 			"ID":      check.Name,
 			"Service": check.ServiceName,
+			"Type":    check.Type,
 			"State":   check.Status,
+			"Since":   since,
 			"Output":  check.Output,
 		})
 	}
@@ -0,0 +1,48 @@
+package presenters
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// AllocationExits - Holds the events of an allocation's process exiting
+type AllocationExits struct {
+	Events []api.AllocationEvent
+}
+
+// APIStruct - returns an interface to the exit events
+func (p *AllocationExits) APIStruct() interface{} {
+	return p.exits()
+}
+
+// FieldNames - returns the field names for an exit
+func (p *AllocationExits) FieldNames() []string {
+	return []string{"Timestamp", "Exit Code", "Message"}
+}
+
+// Records - formats exit events into a map
+func (p *AllocationExits) Records() []map[string]string {
+	out := []map[string]string{}
+
+	for _, event := range p.exits() {
+		out = append(out, map[string]string{
+			"Timestamp": event.Timestamp.Format(time.RFC3339),
+			"Exit Code": strconv.Itoa(*event.ExitCode),
+			"Message":   event.Message,
+		})
+	}
+
+	return out
+}
+
+func (p *AllocationExits) exits() []api.AllocationEvent {
+	exits := []api.AllocationEvent{}
+	for _, event := range p.Events {
+		if event.ExitCode != nil {
+			exits = append(exits, event)
+		}
+	}
+	return exits
+}
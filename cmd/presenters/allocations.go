@@ -12,6 +12,8 @@ import (
 type Allocations struct {
 	Allocations   []*api.AllocationStatus
 	BackupRegions []api.Region
+	// Changed - IDs of allocations to highlight as recently changed
+	Changed map[string]bool
 }
 
 func (p *Allocations) APIStruct() interface{} {
@@ -19,6 +21,9 @@ func (p *Allocations) APIStruct() interface{} {
 }
 
 func (p *Allocations) FieldNames() []string {
+	if hasProcessGroups(p.Allocations) {
+		return []string{"ID", "Process", "Version", "Region", "Desired", "Status", "Health Checks", "Restarts", "Created"}
+	}
 	return []string{"ID", "Version", "Region", "Desired", "Status", "Health Checks", "Restarts", "Created"}
 }
 
@@ -42,8 +47,14 @@ func (p *Allocations) Records() []map[string]string {
 			}
 		}
 
+		id := alloc.IDShort
+		if p.Changed[alloc.ID] {
+			id = aurora.Yellow(id).Bold().String()
+		}
+
 		out = append(out, map[string]string{
-			"ID":            alloc.IDShort,
+			"ID":            id,
+			"Process":       alloc.TaskName,
 			"Version":       version,
 			"Status":        formatAllocStatus(alloc),
 			"Desired":       alloc.DesiredStatus,
@@ -57,6 +68,17 @@ func (p *Allocations) Records() []map[string]string {
 	return out
 }
 
+// hasProcessGroups - whether allocations run groups other than the default app group
+func hasProcessGroups(allocations []*api.AllocationStatus) bool {
+	for _, alloc := range allocations {
+		if alloc.TaskName != "" && alloc.TaskName != "app" {
+			return true
+		}
+	}
+
+	return false
+}
+
 func hasMultipleVersions(allocations []*api.AllocationStatus) bool {
 	var v int
 	for _, alloc := range allocations {
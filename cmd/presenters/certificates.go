@@ -0,0 +1,31 @@
+package presenters
+
+import (
+	"github.com/superfly/flyctl/api"
+)
+
+type Certificates struct {
+	Certificates []api.AppCertificateCompact
+}
+
+func (p *Certificates) APIStruct() interface{} {
+	return p.Certificates
+}
+
+func (p *Certificates) FieldNames() []string {
+	return []string{"Host Name", "Added", "Status"}
+}
+
+func (p *Certificates) Records() []map[string]string {
+	out := []map[string]string{}
+
+	for _, cert := range p.Certificates {
+		out = append(out, map[string]string{
+			"Host Name": cert.Hostname,
+			"Added":     FormatRelativeTime(cert.CreatedAt),
+			"Status":    cert.ClientStatus,
+		})
+	}
+
+	return out
+}
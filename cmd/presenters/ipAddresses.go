@@ -13,19 +13,44 @@ func (p *IPAddresses) APIStruct() interface{} {
 }
 
 func (p *IPAddresses) FieldNames() []string {
-	return []string{"Type", "Address", "Created At"}
+	return []string{"Type", "Address", "Region", "Created At"}
 }
 
 func (p *IPAddresses) Records() []map[string]string {
 	out := []map[string]string{}
 
 	for _, ip := range p.IPAddresses {
+		region := ip.Region
+		if region == "" {
+			region = "global"
+		}
+
+		createdAt := ""
+		if !ip.CreatedAt.IsZero() {
+			createdAt = FormatRelativeTime(ip.CreatedAt)
+		}
+
 		out = append(out, map[string]string{
 			"Address":    ip.Address,
-			"Type":       ip.Type,
-			"Created At": FormatRelativeTime(ip.CreatedAt),
+			"Type":       formatIPAddressType(ip.Type),
+			"Region":     region,
+			"Created At": createdAt,
 		})
 	}
 
 	return out
 }
+
+func formatIPAddressType(addrType string) string {
+	switch addrType {
+	case "v4":
+		return "public v4 (dedicated)"
+	case api.IPAddressSharedV4:
+		return "public v4 (shared)"
+	case "v6":
+		return "public v6"
+	case "private_v6":
+		return "private v6"
+	}
+	return addrType
+}
@@ -1,10 +1,12 @@
 package presenters
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"github.com/logrusorgru/aurora"
 	"io"
+	"regexp"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -29,6 +31,7 @@ type Options struct {
 	HideHeader bool
 	Title      string
 	AsJSON     bool
+	AsCSV      bool
 }
 
 // Render - Renders a presenter as a field list or table
@@ -37,6 +40,10 @@ func (p *Presenter) Render() error {
 		return p.renderJSON()
 	}
 
+	if p.Opts.AsCSV {
+		return p.renderCSV()
+	}
+
 	if p.Opts.Vertical {
 		return p.renderFieldList()
 	}
@@ -106,6 +113,34 @@ func (p *Presenter) renderFieldList() error {
 	return nil
 }
 
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// renderCSV - Renders records as CSV with a header row, stripping any terminal colors
+func (p *Presenter) renderCSV() error {
+	w := csv.NewWriter(p.Out)
+
+	cols := p.Item.FieldNames()
+
+	if !p.Opts.HideHeader {
+		if err := w.Write(cols); err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range p.Item.Records() {
+		fields := []string{}
+		for _, col := range cols {
+			fields = append(fields, ansiEscape.ReplaceAllString(kv[col], ""))
+		}
+		if err := w.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
 func (p *Presenter) renderJSON() error {
 	var data = p.Item.APIStruct()
 
@@ -0,0 +1,40 @@
+package presenters
+
+import (
+	"github.com/superfly/flyctl/api"
+)
+
+type PrivateIPs struct {
+	Allocations   []*api.AllocationStatus
+	BackupRegions []api.Region
+}
+
+func (p *PrivateIPs) APIStruct() interface{} {
+	return p.Allocations
+}
+
+func (p *PrivateIPs) FieldNames() []string {
+	return []string{"ID", "Region", "IP"}
+}
+
+func (p *PrivateIPs) Records() []map[string]string {
+	out := []map[string]string{}
+
+	for _, alloc := range p.Allocations {
+		region := alloc.Region
+		for _, r := range p.BackupRegions {
+			if alloc.Region == r.Code {
+				region = alloc.Region + "(B)"
+				break
+			}
+		}
+
+		out = append(out, map[string]string{
+			"ID":     alloc.IDShort,
+			"Region": region,
+			"IP":     alloc.PrivateIP,
+		})
+	}
+
+	return out
+}
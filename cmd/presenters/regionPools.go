@@ -0,0 +1,32 @@
+package presenters
+
+import (
+	"github.com/superfly/flyctl/api"
+)
+
+// RegionPools - an app's region pool and backup region pool in one list
+type RegionPools struct {
+	Regions       []api.Region
+	BackupRegions []api.Region
+}
+
+func (p *RegionPools) APIStruct() interface{} {
+	return p.Regions
+}
+
+func (p *RegionPools) FieldNames() []string {
+	return []string{"Code", "Name", "Pool"}
+}
+
+func (p *RegionPools) Records() []map[string]string {
+	out := []map[string]string{}
+
+	for _, r := range p.Regions {
+		out = append(out, map[string]string{"Code": r.Code, "Name": r.Name, "Pool": "region"})
+	}
+	for _, r := range p.BackupRegions {
+		out = append(out, map[string]string{"Code": r.Code, "Name": r.Name, "Pool": "backup"})
+	}
+
+	return out
+}
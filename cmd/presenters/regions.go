@@ -1,11 +1,16 @@
 package presenters
 
 import (
+	"strings"
+
+	"github.com/logrusorgru/aurora"
 	"github.com/superfly/flyctl/api"
 )
 
 type Regions struct {
 	Regions []api.Region
+	// ShowCapacity - include GPU, performance and capacity columns
+	ShowCapacity bool
 }
 
 func (p *Regions) APIStruct() interface{} {
@@ -13,6 +18,9 @@ func (p *Regions) APIStruct() interface{} {
 }
 
 func (p *Regions) FieldNames() []string {
+	if p.ShowCapacity {
+		return []string{"Code", "Name", "Gateway", "GPU", "Performance", "Capacity"}
+	}
 	return []string{"Code", "Name", "Gateway"}
 }
 
@@ -20,16 +28,42 @@ func (p *Regions) Records() []map[string]string {
 	out := []map[string]string{}
 
 	for _, region := range p.Regions {
-		gateway := ""
-		if region.GatewayAvailable {
-			gateway = "✓"
-		}
+		gateway := formatAvailable(region.GatewayAvailable)
 		out = append(out, map[string]string{
-			"Code":    region.Code,
-			"Name":    region.Name,
-			"Gateway": gateway,
+			"Code":        region.Code,
+			"Name":        region.Name,
+			"Gateway":     gateway,
+			"GPU":         formatGPU(region),
+			"Performance": formatAvailable(region.PerformanceAvailable),
+			"Capacity":    formatCapacity(region.Capacity),
 		})
 	}
 
 	return out
 }
+
+func formatAvailable(available bool) string {
+	if available {
+		return "✓"
+	}
+	return ""
+}
+
+func formatGPU(region api.Region) string {
+	if len(region.GPUKinds) > 0 {
+		return strings.Join(region.GPUKinds, ", ")
+	}
+	return formatAvailable(region.GpuAvailable)
+}
+
+func formatCapacity(capacity string) string {
+	switch strings.ToLower(capacity) {
+	case "high":
+		return aurora.Green(capacity).String()
+	case "medium":
+		return aurora.Yellow(capacity).String()
+	case "low":
+		return aurora.Red(capacity).String()
+	}
+	return capacity
+}
@@ -17,7 +17,7 @@ func (p *Releases) APIStruct() interface{} {
 }
 
 func (p *Releases) FieldNames() []string {
-	return []string{"Version", "Stable", "Type", "Status", "Description", "User", "Date"}
+	return []string{"Version", "Stable", "Type", "Status", "Description", "Commit", "User", "Date"}
 }
 
 func (p *Releases) Records() []map[string]string {
@@ -34,7 +34,8 @@ func (p *Releases) Records() []map[string]string {
 			"Status":      release.Status,
 			"Type":        formatReleaseReason(release.Reason),
 			"Description": formatReleaseDescription(release),
-			"User":        release.User.Email,
+			"Commit":      formatReleaseCommit(release),
+			"User":        formatReleaseUser(release),
 			"Date":        FormatRelativeTime(release.CreatedAt),
 		})
 	}
@@ -60,3 +61,34 @@ func formatReleaseDescription(r api.Release) string {
 	}
 	return r.Description
 }
+
+// formatReleaseCommit shows the git commit and branch the release was
+// deployed from, marking commits deployed with uncommitted changes with *
+func formatReleaseCommit(r api.Release) string {
+	sha := r.Annotations["git.sha"]
+	if sha == "" {
+		sha = r.CommitSHA
+	}
+	if sha == "" {
+		return ""
+	}
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	if r.Annotations["git.dirty"] == "true" {
+		sha += "*"
+	}
+	if branch := r.Annotations["git.branch"]; branch != "" {
+		sha += " (" + branch + ")"
+	}
+	return sha
+}
+
+// formatReleaseUser falls back to the deployer recorded by flyctl when the
+// release has no user, such as when deployed with an org token
+func formatReleaseUser(r api.Release) string {
+	if r.User.Email != "" {
+		return r.User.Email
+	}
+	return r.Annotations["deployer.user"]
+}
@@ -2,30 +2,62 @@ package presenters
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/superfly/flyctl/api"
 )
 
 type VMSizes struct {
 	VMSizes []api.VMSize
+	// Regions - the regions each size can run in, by size name. When set,
+	// GPU, region and capacity columns are included.
+	Regions map[string][]api.Region
+}
+
+// vmSizeAvailability - a VM size with the regions it can run in, as
+// written to JSON
+type vmSizeAvailability struct {
+	api.VMSize
+	Regions  []string
+	Capacity string
 }
 
 func (p *VMSizes) APIStruct() interface{} {
-	return p.VMSizes
+	if p.Regions == nil {
+		return p.VMSizes
+	}
+
+	out := []vmSizeAvailability{}
+	for _, size := range p.VMSizes {
+		codes := []string{}
+		for _, region := range p.Regions[size.Name] {
+			codes = append(codes, region.Code)
+		}
+		out = append(out, vmSizeAvailability{VMSize: size, Regions: codes, Capacity: BestCapacity(p.Regions[size.Name])})
+	}
+	return out
 }
 
 func (p *VMSizes) FieldNames() []string {
-	return []string{"Name", "CPU Cores", "Memory"}
+	if p.Regions != nil {
+		return []string{"Name", "CPU Cores", "Memory", "GPU", "Price (Month)", "Regions", "Capacity"}
+	}
+	return []string{"Name", "CPU Cores", "Memory", "Price (Month)"}
 }
 
 func (p *VMSizes) Records() []map[string]string {
 	out := []map[string]string{}
 
 	for _, size := range p.VMSizes {
+		regions := p.Regions[size.Name]
 		out = append(out, map[string]string{
-			"Name":      size.Name,
-			"CPU Cores": formatCores(size),
-			"Memory":    formatMemory(size),
+			"Name":          size.Name,
+			"CPU Cores":     formatCores(size),
+			"Memory":        formatMemory(size),
+			"GPU":           formatSizeGPU(size),
+			"Price (Month)": fmt.Sprintf("$%.2f", size.PriceMonth),
+			"Regions":       formatSizeRegions(regions),
+			"Capacity":      formatCapacity(BestCapacity(regions)),
 		})
 	}
 
@@ -45,3 +77,37 @@ func formatMemory(size api.VMSize) string {
 	}
 	return fmt.Sprintf("%d GB", int(size.MemoryGB))
 }
+
+func formatSizeGPU(size api.VMSize) string {
+	if size.GPUKind == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d x %s", size.GPUCount, size.GPUKind)
+}
+
+// formatSizeRegions lists a few regions by code, and counts more
+func formatSizeRegions(regions []api.Region) string {
+	if len(regions) > 3 {
+		return fmt.Sprintf("%d regions", len(regions))
+	}
+
+	codes := []string{}
+	for _, region := range regions {
+		codes = append(codes, region.Code)
+	}
+	return strings.Join(codes, ", ")
+}
+
+// capacityRank orders capacity hints from the least to the most capacity
+var capacityRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// BestCapacity - the highest capacity hint of regions
+func BestCapacity(regions []api.Region) string {
+	best := ""
+	for _, region := range regions {
+		if capacityRank[strings.ToLower(region.Capacity)] > capacityRank[strings.ToLower(best)] {
+			best = region.Capacity
+		}
+	}
+	return best
+}
@@ -0,0 +1,41 @@
+package presenters
+
+import (
+	"strconv"
+
+	"github.com/superfly/flyctl/api"
+)
+
+type Volumes struct {
+	Volumes []api.Volume
+}
+
+func (p *Volumes) APIStruct() interface{} {
+	return p.Volumes
+}
+
+func (p *Volumes) FieldNames() []string {
+	return []string{"ID", "Name", "Size", "Region", "Attached VM", "Created At"}
+}
+
+func (p *Volumes) Records() []map[string]string {
+	out := []map[string]string{}
+
+	for _, v := range p.Volumes {
+		var attachedAllocID string
+		if v.AttachedAllocation != nil {
+			attachedAllocID = v.AttachedAllocation.IDShort
+		}
+
+		out = append(out, map[string]string{
+			"ID":          v.ID,
+			"Name":        v.Name,
+			"Size":        strconv.Itoa(v.SizeGb) + "GB",
+			"Region":      v.Region,
+			"Attached VM": attachedAllocID,
+			"Created At":  FormatRelativeTime(v.CreatedAt),
+		})
+	}
+
+	return out
+}
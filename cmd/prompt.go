@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/viper"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/pkg/iostreams"
+)
+
+// promptIO - the streams prompts are asked on, set by NewRootCmd
+var promptIO *iostreams.IOStreams
+
+// runningInCI is true when CI is set to true, as CI providers do. Prompts
+// fail straight away in CI rather than waiting for input that never comes.
+func runningInCI() bool {
+	ci, _ := strconv.ParseBool(os.Getenv("CI"))
+	return ci
+}
+
+// autoConfirmed is true when confirmations are accepted with the global --yes
+// flag, or FLY_AUTO_CONFIRM
+func autoConfirmed() bool {
+	return viper.GetBool(flyctl.ConfigAutoConfirm)
+}
+
+// checkCanPrompt returns an error explaining how to answer question without
+// a prompt when flyctl can't prompt, such as in CI or without a terminal
+func checkCanPrompt(question string, hint string) error {
+	if promptIO == nil || promptIO.CanPrompt() {
+		return nil
+	}
+
+	reason := "flyctl isn't running in a terminal"
+	if runningInCI() {
+		reason = "CI is set"
+	}
+	return flyerr.New(flyerr.PromptRequired, fmt.Sprintf("can't ask %q as %s. %s", question, reason, hint))
+}
+
+// ask asks prompt with survey, failing fast when flyctl can't prompt
+func ask(prompt survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+	question, hint := "", "Pass the answer with a flag instead, see --help"
+	switch p := prompt.(type) {
+	case *survey.Input:
+		question = p.Message
+	case *survey.Password:
+		question = p.Message
+	case *survey.Select:
+		question = p.Message
+	case *survey.Confirm:
+		question = p.Message
+		hint = "Pass --yes to accept it"
+	}
+
+	if err := checkCanPrompt(question, hint); err != nil {
+		return err
+	}
+	return survey.AskOne(prompt, response, opts...)
+}
+
+// offer asks whether to do something optional, which is declined without
+// asking when flyctl can't prompt. Unlike confirmations, offers aren't
+// accepted by --yes.
+func offer(message string) bool {
+	if promptIO != nil && !promptIO.CanPrompt() {
+		return false
+	}
+
+	accepted := false
+	err := survey.AskOne(&survey.Confirm{Message: message}, &accepted)
+	checkErr(err)
+
+	return accepted
+}
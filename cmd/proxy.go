@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/devcert"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newProxyCommand(client *client.Client) *Command {
+	proxyStrings := docstrings.Get("proxy")
+	cmd := BuildCommandKS(nil, runProxy, proxyStrings, client, requireSession)
+	cmd.AddStringFlag(StringFlagOpts{Name: "org", Shorthand: "o", Description: "the organization whose private network to connect to"})
+	cmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "tls-local",
+		Description: "Forward LOCAL:HOST:PORT, terminating TLS on the local port with a locally trusted certificate. Can be specified multiple times.",
+	})
+	cmd.AddStringFlag(StringFlagOpts{Name: "tls-cert", Description: "certificate to serve with --tls-local instead of issuing one"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "tls-key", Description: "private key of the certificate passed with --tls-cert"})
+
+	return cmd
+}
+
+type proxyForward struct {
+	localPort string
+	host      string
+	port      string
+	tls       bool
+}
+
+func runProxy(ctx *cmdctx.CmdContext) error {
+	forwards := []proxyForward{}
+	for _, spec := range ctx.Args {
+		forward, err := parseProxyForward(spec)
+		if err != nil {
+			return err
+		}
+		forwards = append(forwards, forward)
+	}
+	for _, spec := range ctx.Config.GetStringSlice("tls-local") {
+		forward, err := parseProxyForward(spec)
+		if err != nil {
+			return err
+		}
+		forward.tls = true
+		forwards = append(forwards, forward)
+	}
+	if len(forwards) == 0 {
+		return fmt.Errorf("nothing to proxy, pass [LOCAL:]HOST:PORT arguments or --tls-local LOCAL:HOST:PORT")
+	}
+
+	var tlsConfig *tls.Config
+	for _, forward := range forwards {
+		if forward.tls {
+			cert, err := proxyCertificate(ctx)
+			if err != nil {
+				return err
+			}
+			tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			break
+		}
+	}
+
+	org, err := selectOrganization(ctx.Client.API(), ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	proxy, err := connectPrivateProxy(ctx.Client.API(), org)
+	if err != nil {
+		return err
+	}
+	defer proxy.Close()
+
+	for _, forward := range forwards {
+		listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", forward.localPort))
+		if err != nil {
+			return fmt.Errorf("listen on port %s: %w", forward.localPort, err)
+		}
+
+		scheme := "tcp"
+		if forward.tls {
+			listener = tls.NewListener(listener, tlsConfig)
+			scheme = "tls"
+		}
+
+		remote, err := proxy.Forward(listener, forward.host, forward.port)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+
+		fmt.Printf("Proxying %s://%s to %s:%s (%s)\n", scheme, listener.Addr(), forward.host, forward.port, remote)
+	}
+
+	fmt.Println("Press Ctrl-C to stop")
+
+	<-createCancellableContext().Done()
+
+	return nil
+}
+
+// parseProxyForward parses LOCAL:HOST:PORT, or HOST:PORT to listen on the same port locally
+func parseProxyForward(spec string) (proxyForward, error) {
+	parts := strings.Split(spec, ":")
+
+	var forward proxyForward
+	switch len(parts) {
+	case 2:
+		forward = proxyForward{localPort: parts[1], host: parts[0], port: parts[1]}
+	case 3:
+		forward = proxyForward{localPort: parts[0], host: parts[1], port: parts[2]}
+	default:
+		return forward, fmt.Errorf("invalid proxy %q, expected [LOCAL:]HOST:PORT", spec)
+	}
+
+	for _, port := range []string{forward.localPort, forward.port} {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return forward, fmt.Errorf("invalid port %q in proxy %q", port, spec)
+		}
+	}
+	if forward.host == "" {
+		return forward, fmt.Errorf("invalid proxy %q, a host is required", spec)
+	}
+
+	return forward, nil
+}
+
+// proxyCertificate loads the certificate given with --tls-cert, or issues one
+// for localhost from mkcert's CA when installed, falling back to a CA managed
+// by flyctl
+func proxyCertificate(ctx *cmdctx.CmdContext) (tls.Certificate, error) {
+	certFile, keyFile := ctx.Config.GetString("tls-cert"), ctx.Config.GetString("tls-key")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return tls.Certificate{}, fmt.Errorf("--tls-cert and --tls-key must be used together")
+		}
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+
+	ca, err := devcert.MkcertCA()
+	if err != nil {
+		terminal.Debug("mkcert CA unavailable:", err)
+
+		ca, err = devcert.LoadOrCreateCA(filepath.Join(flyctl.ConfigDir(), "devcert"))
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("load development CA: %w", err)
+		}
+
+		fmt.Printf("Serving TLS with a certificate from the flyctl development CA. To trust it, add %s to your system or browser trust store, or install mkcert and run `mkcert -install`\n", ca.CertPath)
+	}
+
+	return ca.Issue([]string{"localhost", "127.0.0.1", "::1"})
+}
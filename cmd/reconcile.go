@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func newReconcileCommand(client *client.Client) *Command {
+	reconcileStrings := docstrings.Get("reconcile")
+	cmd := BuildCommandKS(nil, runReconcile, reconcileStrings, client, requireSession, requireAppName)
+
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "interval",
+		Description: "How often to check the app for drift, at least 30s",
+		Default:     "5m",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "manifest",
+		Description: "Deploy manifest (or directory of manifests written by deploy --record-to) holding the desired image",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "once",
+		Description: "Check and reconcile once, then exit",
+	})
+	cmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "dry-run",
+		Description: "Report drift without redeploying",
+	})
+
+	return cmd
+}
+
+// minReconcileInterval - the shortest interval the app is checked at, as
+// each check queries the API and can redeploy
+const minReconcileInterval = 30 * time.Second
+
+func runReconcile(cmdCtx *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	interval, err := time.ParseDuration(cmdCtx.Config.GetString("interval"))
+	if err != nil {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid interval: %s", err))
+	}
+	if interval < minReconcileInterval {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("--interval must be at least %s", minReconcileInterval))
+	}
+
+	once := cmdCtx.Config.GetBool("once")
+
+	cmdCtx.Statusf("reconcile", cmdctx.STITLE, "Reconciling %s every %s\n", cmdCtx.AppName, interval)
+
+	for {
+		if err := reconcileApp(ctx, cmdCtx); err != nil {
+			if once {
+				return err
+			}
+			if isCancelledError(err) {
+				return nil
+			}
+			cmdCtx.Status("reconcile", cmdctx.SERROR, "Reconcile failed:", err)
+		}
+
+		if once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// reconcileApp loads the desired state from disk, compares it to the live app and redeploys on drift
+func reconcileApp(ctx context.Context, cmdCtx *cmdctx.CmdContext) error {
+	appConfig := cmdCtx.AppConfig
+	if helpers.FileExists(cmdCtx.ConfigFile) {
+		cfg, err := flyctl.LoadAppConfig(cmdCtx.ConfigFile)
+		if err != nil {
+			return err
+		}
+		appConfig = cfg
+	}
+
+	desiredImage := appConfig.Image()
+	var desiredDigest string
+
+	if manifestPath := cmdCtx.Config.GetString("manifest"); manifestPath != "" {
+		if helpers.DirectoryExists(manifestPath) {
+			manifestPath = deployment.LatestManifestPath(manifestPath, cmdCtx.AppName)
+		}
+
+		manifest, err := deployment.LoadManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+		if manifest.App != cmdCtx.AppName {
+			return fmt.Errorf("manifest %s is for app %s, not %s", filepath.Base(manifestPath), manifest.App, cmdCtx.AppName)
+		}
+
+		desiredImage = manifest.Image
+		desiredDigest = manifest.ImageDigest
+	}
+
+	if desiredImage == "" {
+		return fmt.Errorf("no image to reconcile to, set image in the [build] section of %s or pass --manifest", filepath.Base(cmdCtx.ConfigFile))
+	}
+
+	parsedCfg, err := cmdCtx.Client.API().ParseConfig(cmdCtx.AppName, appConfig.Definition)
+	if err != nil {
+		return err
+	}
+	if !parsedCfg.Valid {
+		return fmt.Errorf("app configuration is not valid: %v", parsedCfg.Errors)
+	}
+
+	drift, err := deployment.DetectDrift(cmdCtx.Client.API(), cmdCtx.AppName, desiredImage, desiredDigest, parsedCfg.Definition)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format("15:04:05")
+
+	if !drift.HasDrift() {
+		cmdCtx.Statusf("reconcile", cmdctx.SDONE, "%s no drift detected\n", now)
+		return nil
+	}
+
+	if drift.ImageChanged {
+		cmdCtx.Statusf("reconcile", cmdctx.SWARN, "%s image drifted: live %s, desired %s\n", now, drift.LiveImage, drift.DesiredImage)
+	}
+	if drift.ConfigChanged {
+		cmdCtx.Statusf("reconcile", cmdctx.SWARN, "%s config drifted: live %s, desired %s\n", now, drift.LiveConfigHash, drift.DesiredConfigHash)
+	}
+
+	if cmdCtx.Config.GetBool("dry-run") {
+		return nil
+	}
+
+	release, _, err := cmdCtx.Client.API().DeployImage(api.DeployImageInput{
+		AppID:      cmdCtx.AppName,
+		Image:      desiredImage,
+		Definition: api.DefinitionPtr(parsedCfg.Definition),
+	})
+	if err != nil {
+		return err
+	}
+
+	cmdCtx.Statusf("reconcile", cmdctx.SBEGIN, "Release v%d created to correct drift\n", release.Version)
+
+	return watchDeployment(ctx, cmdCtx)
+}
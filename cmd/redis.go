@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+
+	"github.com/cli/safeexec"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+)
+
+func newRedisCommand(client *client.Client) *Command {
+	redisStrings := docstrings.Get("redis")
+	cmd := BuildCommandKS(nil, nil, redisStrings, client, requireSession)
+
+	createStrings := docstrings.Get("redis.create")
+	createCmd := BuildCommandKS(cmd, runCreateRedis, createStrings, client, requireSession)
+	createCmd.AddStringFlag(StringFlagOpts{Name: "organization", Shorthand: "o", Description: "the organization that will own the database"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "name", Description: "the name of the new database"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "region", Description: "the region to launch the database in"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "app", Shorthand: "a", Description: "store the database's private URL as a secret on this app"})
+	createCmd.AddStringFlag(StringFlagOpts{Name: "variable-name", Description: "the secret name used with --app", Default: "REDIS_URL"})
+
+	listStrings := docstrings.Get("redis.list")
+	BuildCommandKS(cmd, runListRedis, listStrings, client, requireSession)
+
+	statusStrings := docstrings.Get("redis.status")
+	statusCmd := BuildCommandKS(cmd, runRedisStatus, statusStrings, client, requireSession)
+	statusCmd.Args = cobra.ExactArgs(1)
+
+	connectStrings := docstrings.Get("redis.connect")
+	connectCmd := BuildCommandKS(cmd, runConnectRedis, connectStrings, client, requireSession)
+	connectCmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runCreateRedis(ctx *cmdctx.CmdContext) error {
+	name := ctx.Config.GetString("name")
+	if name == "" {
+		n, err := inputAppName("")
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+
+	org, err := selectOrganization(ctx.Client.API(), ctx.Config.GetString("organization"), nil)
+	if err != nil {
+		return err
+	}
+
+	region, err := selectRegion(ctx.Client.API(), ctx.Config.GetString("region"))
+	if err != nil {
+		return err
+	}
+
+	input := api.CreateRedisDatabaseInput{
+		OrganizationID: org.ID,
+		Name:           name,
+		Region:         region.Code,
+	}
+
+	fmt.Fprintf(ctx.Out, "Creating redis database %s in organization %s\n", name, org.Slug)
+
+	db, err := ctx.Client.API().CreateRedisDatabase(input)
+	if err != nil {
+		return err
+	}
+
+	appName := ctx.Config.GetString("app")
+	varName := ctx.Config.GetString("variable-name")
+
+	var release *api.Release
+	if appName != "" {
+		release, err = ctx.Client.API().SetSecrets(appName, map[string]string{varName: db.PrivateURL})
+		if err != nil {
+			return fmt.Errorf("database created but the secret could not be set on %s: %w", appName, err)
+		}
+	}
+
+	if ctx.OutputJSON() {
+		out := redisCreateOutput{RedisDatabase: db}
+		if release != nil {
+			out.App = appName
+			out.Secret = varName
+			out.Release = release
+		}
+		ctx.WriteJSON(out)
+		return nil
+	}
+
+	fmt.Printf("Redis database %s created in %s\n", db.Name, db.Region)
+	printRedisURLs(db)
+
+	if release != nil {
+		fmt.Printf("Set secret %s on %s, release v%d created\n", varName, appName, release.Version)
+	}
+
+	return nil
+}
+
+// redisCreateOutput - redis create's --json output, with the app and secret
+// set with --app
+type redisCreateOutput struct {
+	*api.RedisDatabase
+	App     string       `json:",omitempty"`
+	Secret  string       `json:",omitempty"`
+	Release *api.Release `json:",omitempty"`
+}
+
+func runListRedis(ctx *cmdctx.CmdContext) error {
+	dbs, err := ctx.Client.API().GetRedisDatabases()
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(dbs)
+		return nil
+	}
+
+	if len(dbs) == 0 {
+		fmt.Println("No redis databases found")
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Organization", "Region", "Status", "Created"})
+	for _, db := range dbs {
+		table.Append([]string{db.Name, db.Organization.Slug, db.Region, db.Status, humanize.Time(db.CreatedAt)})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runRedisStatus(ctx *cmdctx.CmdContext) error {
+	db, err := ctx.Client.API().GetRedisDatabase(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(db)
+		return nil
+	}
+
+	fmt.Printf("  Name:          %s\n", db.Name)
+	fmt.Printf("  Organization:  %s\n", db.Organization.Slug)
+	fmt.Printf("  Region:        %s\n", db.Region)
+	fmt.Printf("  Status:        %s\n", db.Status)
+	fmt.Printf("  Created:       %s\n", humanize.Time(db.CreatedAt))
+	printRedisURLs(db)
+
+	return nil
+}
+
+func printRedisURLs(db *api.RedisDatabase) {
+	fmt.Printf("  Private URL:   %s\n", db.PrivateURL)
+	if db.PublicURL != "" {
+		fmt.Printf("  Public URL:    %s\n", db.PublicURL)
+	}
+}
+
+func runConnectRedis(ctx *cmdctx.CmdContext) error {
+	redisCli, err := safeexec.LookPath("redis-cli")
+	if err != nil {
+		return fmt.Errorf("redis-cli not found, install redis to connect: %w", err)
+	}
+
+	db, err := ctx.Client.API().GetRedisDatabase(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(db.PrivateURL)
+	if err != nil {
+		return fmt.Errorf("invalid private URL for %s: %w", db.Name, err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+
+	proxy, err := openPrivateProxy(ctx.Client.API(), &db.Organization, u.Hostname(), port)
+	if err != nil {
+		return err
+	}
+	defer proxy.Close()
+
+	u.Host = net.JoinHostPort("127.0.0.1", proxy.Port())
+
+	cliCmd := exec.Command(redisCli, "-u", u.String())
+	cliCmd.Stdin = os.Stdin
+	cliCmd.Stdout = os.Stdout
+	cliCmd.Stderr = os.Stderr
+
+	return cliCmd.Run()
+}
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/docstrings"
@@ -18,10 +26,12 @@ func newRegionsCommand(client *client.Client) *Command {
 	addStrings := docstrings.Get("regions.add")
 	addCmd := BuildCommandKS(cmd, runRegionsAdd, addStrings, client, requireSession, requireAppName)
 	addCmd.Args = cobra.MinimumNArgs(1)
+	addCmd.AddBoolFlag(BoolFlagOpts{Name: "backup", Description: "Add to the backup regions rather than the region pool"})
 
 	removeStrings := docstrings.Get("regions.remove")
 	removeCmd := BuildCommandKS(cmd, runRegionsRemove, removeStrings, client, requireSession, requireAppName)
 	removeCmd.Args = cobra.MinimumNArgs(1)
+	removeCmd.AddBoolFlag(BoolFlagOpts{Name: "backup", Description: "Remove from the backup regions rather than the region pool"})
 
 	setStrings := docstrings.Get("regions.set")
 	setCmd := BuildCommandKS(cmd, runRegionsSet, setStrings, client, requireSession, requireAppName)
@@ -34,10 +44,21 @@ func newRegionsCommand(client *client.Client) *Command {
 	listStrings := docstrings.Get("regions.list")
 	BuildCommand(cmd, runRegionsList, listStrings.Usage, listStrings.Short, listStrings.Long, client, requireSession, requireAppName)
 
+	latencyStrings := docstrings.Get("regions.latency")
+	BuildCommandKS(cmd, runRegionsLatency, latencyStrings, client, requireSession)
+
 	return cmd
 }
 
 func runRegionsAdd(ctx *cmdctx.CmdContext) error {
+	if err := validateRegionCodes(ctx, ctx.Args); err != nil {
+		return err
+	}
+
+	if ctx.Config.GetBool("backup") {
+		return updateBackupRegions(ctx, ctx.Args, nil)
+	}
+
 	input := api.ConfigureRegionsInput{
 		AppID:        ctx.AppName,
 		AllowRegions: ctx.Args,
@@ -54,6 +75,14 @@ func runRegionsAdd(ctx *cmdctx.CmdContext) error {
 }
 
 func runRegionsRemove(ctx *cmdctx.CmdContext) error {
+	if err := validateRegionCodes(ctx, ctx.Args); err != nil {
+		return err
+	}
+
+	if ctx.Config.GetBool("backup") {
+		return updateBackupRegions(ctx, nil, ctx.Args)
+	}
+
 	input := api.ConfigureRegionsInput{
 		AppID:       ctx.AppName,
 		DenyRegions: ctx.Args,
@@ -70,6 +99,10 @@ func runRegionsRemove(ctx *cmdctx.CmdContext) error {
 }
 
 func runRegionsSet(ctx *cmdctx.CmdContext) error {
+	if err := validateRegionCodes(ctx, ctx.Args); err != nil {
+		return err
+	}
+
 	addList := make([]string, 0)
 	delList := make([]string, 0)
 
@@ -133,6 +166,10 @@ func runRegionsList(ctx *cmdctx.CmdContext) error {
 }
 
 func runBackupRegionsSet(ctx *cmdctx.CmdContext) error {
+	if err := validateRegionCodes(ctx, ctx.Args); err != nil {
+		return err
+	}
+
 	input := api.ConfigureRegionsInput{
 		AppID:         ctx.AppName,
 		BackupRegions: ctx.Args,
@@ -148,6 +185,191 @@ func runBackupRegionsSet(ctx *cmdctx.CmdContext) error {
 	return nil
 }
 
+// updateBackupRegions adds and removes regions from the app's current backup regions
+func updateBackupRegions(ctx *cmdctx.CmdContext, add []string, remove []string) error {
+	_, current, err := ctx.Client.API().ListAppRegions(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	codes := make([]string, 0)
+	for _, r := range current {
+		if !containsString(remove, r.Code) {
+			codes = append(codes, r.Code)
+		}
+	}
+	for _, code := range add {
+		if !containsString(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	input := api.ConfigureRegionsInput{
+		AppID:         ctx.AppName,
+		BackupRegions: codes,
+	}
+
+	regions, backupRegions, err := ctx.Client.API().ConfigureRegions(input)
+	if err != nil {
+		return err
+	}
+
+	printRegions(ctx, regions, backupRegions)
+
+	return nil
+}
+
+// validateRegionCodes checks codes against the platform's regions before
+// they're sent, so typos are caught with a list of what's available
+func validateRegionCodes(ctx *cmdctx.CmdContext, codes []string) error {
+	regions, _, err := platformRegionsIncluding(ctx.Client.API(), codes...)
+	if err != nil {
+		return err
+	}
+
+	unknown := []string{}
+	for _, code := range codes {
+		found := false
+		for _, r := range regions {
+			if r.Code == code {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, code)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	known := make([]string, len(regions))
+	for i, r := range regions {
+		known[i] = r.Code
+	}
+	sort.Strings(known)
+
+	return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("unknown region %s. Valid regions are %s. Run `flyctl regions latency` to compare them",
+		strings.Join(unknown, ", "), strings.Join(known, ", ")))
+}
+
+// regionLatency - a region with its estimated round trip time from the
+// region closest to the user
+type regionLatency struct {
+	api.Region
+	Geography string
+	// EstimatedRTT - in milliseconds
+	EstimatedRTT int
+	Closest      bool
+}
+
+func runRegionsLatency(ctx *cmdctx.CmdContext) error {
+	regions, requestRegion, err := platformRegions(ctx.Client.API())
+	if err != nil {
+		return err
+	}
+
+	latencies := make([]regionLatency, 0, len(regions))
+	for _, r := range regions {
+		l := regionLatency{Region: r, Geography: regionGeography(r), EstimatedRTT: -1}
+		if requestRegion != nil {
+			l.EstimatedRTT = estimatedRTT(*requestRegion, r)
+			l.Closest = r.Code == requestRegion.Code
+		}
+		latencies = append(latencies, l)
+	}
+
+	sort.SliceStable(latencies, func(i, j int) bool {
+		if latencies[i].Geography != latencies[j].Geography {
+			return geographyOrder(latencies[i].Geography) < geographyOrder(latencies[j].Geography)
+		}
+		return latencies[i].EstimatedRTT < latencies[j].EstimatedRTT
+	})
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(latencies)
+		return nil
+	}
+
+	if requestRegion != nil {
+		fmt.Fprintf(ctx.Out, "Latencies are estimated from the distance to %s (%s), the region closest to you.\n\n", requestRegion.Code, requestRegion.Name)
+	}
+
+	geography := ""
+	for _, l := range latencies {
+		if l.Geography != geography {
+			if geography != "" {
+				fmt.Fprintln(ctx.Out)
+			}
+			geography = l.Geography
+			fmt.Fprintln(ctx.Out, aurora.Bold(geography))
+		}
+
+		rtt := "-"
+		if l.EstimatedRTT >= 0 {
+			rtt = fmt.Sprintf("~%dms", l.EstimatedRTT)
+		}
+		note := ""
+		if l.Closest {
+			note = "(closest)"
+		}
+		fmt.Fprintf(ctx.Out, "  %-4s %-30s %8s %s\n", l.Code, l.Name, rtt, note)
+	}
+
+	return nil
+}
+
+var geographies = []string{"North America", "South America", "Europe", "Africa & Middle East", "Asia", "Oceania"}
+
+func geographyOrder(geography string) int {
+	for i, g := range geographies {
+		if g == geography {
+			return i
+		}
+	}
+	return len(geographies)
+}
+
+// regionGeography groups a region into a part of the world by its coordinates
+func regionGeography(r api.Region) string {
+	lat, long := float64(r.Latitude), float64(r.Longitude)
+
+	switch {
+	case long < -30 && lat >= 12:
+		return "North America"
+	case long < -30:
+		return "South America"
+	case long < 60 && lat >= 36:
+		return "Europe"
+	case long < 60:
+		return "Africa & Middle East"
+	case lat < -10 && long >= 110:
+		return "Oceania"
+	default:
+		return "Asia"
+	}
+}
+
+// estimatedRTT - a round trip time in milliseconds between two regions, from
+// the great circle distance between them. Light covers about 100km of fibre per
+// millisecond there and back, and routes are rarely direct.
+func estimatedRTT(from, to api.Region) int {
+	const earthRadiusKm = 6371
+	const routeFactor = 1.5
+
+	rad := func(deg float32) float64 { return float64(deg) * math.Pi / 180 }
+	dLat := rad(to.Latitude - from.Latitude)
+	dLong := rad(to.Longitude - from.Longitude)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(from.Latitude))*math.Cos(rad(to.Latitude))*math.Sin(dLong/2)*math.Sin(dLong/2)
+	distance := 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return int(math.Round(distance*routeFactor/100)) + 1
+}
+
 func printRegions(ctx *cmdctx.CmdContext, regions []api.Region, backupRegions []api.Region) {
 
 	if ctx.OutputJSON() {
@@ -155,7 +377,14 @@ func printRegions(ctx *cmdctx.CmdContext, regions []api.Region, backupRegions []
 		return
 	}
 
-	verbose := ctx.GlobalConfig.GetBool("verbose")
+	if ctx.OutputCSV() {
+		ctx.Frender(cmdctx.PresenterOption{
+			Presentable: &presenters.RegionPools{Regions: regions, BackupRegions: backupRegions},
+		})
+		return
+	}
+
+	verbose := ctx.Verbosity() >= cmdctx.VerbosityVerbose
 
 	if verbose {
 		ctx.Status("regions", cmdctx.STITLE, "Current Region Pool:")
@@ -185,3 +414,12 @@ func printRegions(ctx *cmdctx.CmdContext, regions []api.Region, backupRegions []
 		}
 	}
 }
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
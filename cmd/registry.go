@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cosign"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/registry"
+	"github.com/superfly/flyctl/internal/sbom"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// flyRegistry - the registry apps' images are pushed to, with a repository per app
+const flyRegistry = "registry.fly.io"
+
+func newRegistryCommand(client *client.Client) *Command {
+	registryStrings := docstrings.Get("registry")
+	cmd := BuildCommandKS(nil, nil, registryStrings, client)
+
+	tagsStrings := docstrings.Get("registry.tags")
+	BuildCommandKS(cmd, runRegistryTags, tagsStrings, client, requireSession, requireAppName)
+
+	rmStrings := docstrings.Get("registry.rm")
+	rmCmd := BuildCommandKS(cmd, runRegistryRm, rmStrings, client, requireSession, requireAppName)
+	rmCmd.Args = cobra.ArbitraryArgs
+	rmCmd.AddIntFlag(IntFlagOpts{
+		Name:        "keep-last",
+		Description: "Remove all but this many of the newest images, and the current release's",
+	})
+	rmCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "dry-run",
+		Description: "Show the images which would be removed without removing them",
+	})
+
+	return cmd
+}
+
+// registryImage - an image in an app's repository, with all of its tags
+type registryImage struct {
+	Digest  string
+	Tags    []string
+	Created time.Time
+	Size    int64
+	Signed  bool
+	SBOM    bool
+	Current bool
+}
+
+func runRegistryTags(cmdCtx *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	images, err := listRegistryImages(ctx, cmdCtx, newRegistryClient())
+	if err != nil {
+		return err
+	}
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(images)
+		return nil
+	}
+
+	if len(images) == 0 {
+		fmt.Fprintf(cmdCtx.Out, "No images in %s/%s\n", flyRegistry, cmdCtx.AppName)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(cmdCtx.Out, []string{"Tags", "Digest", "Created", "Size", "Signed", "SBOM"})
+	for _, image := range images {
+		tags := strings.Join(image.Tags, ", ")
+		if image.Current {
+			tags += " (current)"
+		}
+		table.Append([]string{
+			tags,
+			shortDigest(image.Digest),
+			humanize.Time(image.Created),
+			humanize.Bytes(uint64(image.Size)),
+			yesNo(image.Signed),
+			yesNo(image.SBOM),
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runRegistryRm(cmdCtx *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	keepLast := cmdCtx.Config.GetInt("keep-last")
+	switch {
+	case len(cmdCtx.Args) > 0 && keepLast > 0:
+		return flyerr.New(flyerr.InvalidArgument, "give either the tags or digests to remove, or --keep-last, not both")
+	case len(cmdCtx.Args) == 0 && keepLast < 1:
+		return flyerr.New(flyerr.InvalidArgument, "give the tags or digests to remove, or --keep-last with how many images to keep")
+	}
+
+	client := newRegistryClient()
+
+	images, err := listRegistryImages(ctx, cmdCtx, client)
+	if err != nil {
+		return err
+	}
+
+	var remove []*registryImage
+	if keepLast > 0 {
+		remove = imagesToPrune(images, keepLast)
+	} else {
+		if remove, err = selectRegistryImages(images, cmdCtx.Args); err != nil {
+			return err
+		}
+	}
+
+	if len(remove) == 0 {
+		fmt.Fprintln(cmdCtx.Out, "No images to remove")
+		return nil
+	}
+
+	for _, image := range remove {
+		fmt.Fprintf(cmdCtx.Out, "%s %s (%s)\n", shortDigest(image.Digest), strings.Join(image.Tags, ", "), humanize.Time(image.Created))
+	}
+
+	if cmdCtx.Config.GetBool("dry-run") {
+		fmt.Fprintf(cmdCtx.Out, "Would remove %d images\n", len(remove))
+		return nil
+	}
+
+	if !autoConfirmed() {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Remove %d images, with all of their tags?", len(remove)),
+		}
+		if err := ask(prompt, &confirm); err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+
+	for _, image := range remove {
+		if err := removeRegistryImage(ctx, client, cmdCtx.AppName, image); err != nil {
+			return errors.Wrapf(err, "could not remove %s", image.Digest)
+		}
+	}
+
+	fmt.Fprintf(cmdCtx.Out, "Removed %d images\n", len(remove))
+
+	return nil
+}
+
+// listRegistryImages lists the images in the app's repository, newest first
+func listRegistryImages(ctx context.Context, cmdCtx *cmdctx.CmdContext, client *registry.Client) ([]*registryImage, error) {
+	tags, err := client.Tags(ctx, flyRegistry, cmdCtx.AppName)
+	if errors.Is(err, registry.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := map[string]bool{}
+	byDigest := map[string]*registryImage{}
+	var images []*registryImage
+
+	for _, tag := range tags {
+		if isArtifactTag(tag) {
+			artifacts[tag] = true
+			continue
+		}
+
+		described, err := client.Describe(ctx, flyRegistry, cmdCtx.AppName, tag)
+		if errors.Is(err, registry.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		image, ok := byDigest[described.Digest]
+		if !ok {
+			image = &registryImage{Digest: described.Digest, Created: described.Created, Size: described.Size}
+			byDigest[described.Digest] = image
+			images = append(images, image)
+		}
+		image.Tags = append(image.Tags, tag)
+	}
+
+	current, err := currentReleaseDigest(ctx, cmdCtx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		image.Signed = artifacts[cosign.SignatureTag(image.Digest)]
+		image.SBOM = artifacts[sbom.Tag(image.Digest)]
+		image.Current = image.Digest == current
+	}
+
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Created.After(images[j].Created) })
+
+	return images, nil
+}
+
+// currentReleaseDigest returns the digest of the image the app's current
+// release runs, or "" when it has no releases or runs an image from another
+// registry. It errors rather than risk the image being removed.
+func currentReleaseDigest(ctx context.Context, cmdCtx *cmdctx.CmdContext, client *registry.Client) (string, error) {
+	release, err := cmdCtx.Client.API().GetAppCurrentRelease(cmdCtx.AppName)
+	if err != nil {
+		return "", errors.Wrap(err, "could not find the current release's image")
+	}
+	if release == nil || release.ImageRef == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(release.ImageRef, flyRegistry+"/") {
+		terminal.Debug("current release runs", release.ImageRef, "from another registry")
+		return "", nil
+	}
+
+	_, digest, err := resolveImageDigest(ctx, client, release.ImageRef)
+	if errors.Is(err, registry.ErrNotFound) {
+		return "", nil
+	}
+	return digest, err
+}
+
+// imagesToPrune returns the images other than the newest keep, keeping the
+// current release's image wherever it is
+func imagesToPrune(images []*registryImage, keep int) []*registryImage {
+	var prune []*registryImage
+	for i, image := range images {
+		if i >= keep && !image.Current {
+			prune = append(prune, image)
+		}
+	}
+	return prune
+}
+
+// selectRegistryImages returns the images with the tags or digests in refs
+func selectRegistryImages(images []*registryImage, refs []string) ([]*registryImage, error) {
+	var selected []*registryImage
+	seen := map[string]bool{}
+
+	for _, ref := range refs {
+		image := findRegistryImage(images, ref)
+		if image == nil {
+			return nil, flyerr.New(flyerr.NotFound, fmt.Sprintf("no image tagged or with digest %s", ref))
+		}
+		if image.Current {
+			return nil, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is the current release's image and can't be removed", ref))
+		}
+		if !seen[image.Digest] {
+			seen[image.Digest] = true
+			selected = append(selected, image)
+		}
+	}
+
+	return selected, nil
+}
+
+func findRegistryImage(images []*registryImage, ref string) *registryImage {
+	for _, image := range images {
+		if image.Digest == ref {
+			return image
+		}
+		for _, tag := range image.Tags {
+			if tag == ref {
+				return image
+			}
+		}
+	}
+	return nil
+}
+
+// removeRegistryImage deletes the image, and the signatures and SBOM stored
+// alongside it
+func removeRegistryImage(ctx context.Context, client *registry.Client, app string, image *registryImage) error {
+	var artifacts []string
+	if image.Signed {
+		artifacts = append(artifacts, cosign.SignatureTag(image.Digest))
+	}
+	if image.SBOM {
+		artifacts = append(artifacts, sbom.Tag(image.Digest))
+	}
+
+	for _, tag := range artifacts {
+		digest, err := client.Digest(ctx, flyRegistry, app, tag)
+		if err != nil {
+			return err
+		}
+		if err := client.Delete(ctx, flyRegistry, app, digest); err != nil {
+			return err
+		}
+	}
+
+	return client.Delete(ctx, flyRegistry, app, image.Digest)
+}
+
+// isArtifactTag is true for the tags signatures and SBOMs are stored under
+func isArtifactTag(tag string) bool {
+	return strings.HasPrefix(tag, "sha256-") && (strings.HasSuffix(tag, ".sig") || strings.HasSuffix(tag, ".sbom"))
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -1,8 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/superfly/flyctl/docstrings"
 
@@ -12,13 +28,469 @@ import (
 func newReleasesCommand(client *client.Client) *Command {
 	releasesStrings := docstrings.Get("releases")
 	cmd := BuildCommandKS(nil, runReleases, releasesStrings, client, requireSession, requireAppName)
+	cmd.AddIntFlag(IntFlagOpts{
+		Name:        "limit",
+		Description: "How many releases to list",
+		Default:     25,
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "before",
+		Description: "Only list releases older than this one, e.g. v42",
+	})
+	cmd.AddStringFlag(StringFlagOpts{
+		Name:        "status",
+		Description: "Only list releases with this status, e.g. failed",
+	})
+
+	diffStrings := docstrings.Get("releases.diff")
+	diffCmd := BuildCommandKS(cmd, runReleasesDiff, diffStrings, client, requireSession, requireAppName)
+	diffCmd.Args = cobra.ExactArgs(2)
+
+	bisectStrings := docstrings.Get("releases.bisect")
+	bisectCmd := BuildCommandKS(cmd, runReleasesBisect, bisectStrings, client, requireSession, requireAppName)
+	bisectCmd.AddStringFlag(StringFlagOpts{
+		Name:        "good",
+		Description: "A release known to be good, e.g. v40",
+	})
+	bisectCmd.AddStringFlag(StringFlagOpts{
+		Name:        "bad",
+		Description: "A release known to be bad, e.g. v52. Defaults to the current release",
+	})
+	bisectCmd.AddStringFlag(StringFlagOpts{
+		Name:        "check",
+		Description: "Command to test each candidate: exit 0 for good, 125 to skip, anything else for bad",
+	})
+	bisectCmd.AddStringFlag(StringFlagOpts{
+		Name:        "canary-app",
+		Description: "App to deploy candidate releases to. Defaults to <app>-bisect, created if needed",
+	})
+	bisectCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "keep",
+		Description: "Keep the canary app after bisecting",
+	})
+	bisectCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "allow-missing-secrets",
+		Description: "Bisect even when the canary app doesn't have all of the app's secrets",
+	})
+
 	return cmd
 }
 
+// releasesPageSize - how many releases are fetched at a time while filtering
+const releasesPageSize = 50
+
 func runReleases(ctx *cmdctx.CmdContext) error {
-	releases, err := ctx.Client.API().GetAppReleases(ctx.AppName, 25)
+	limit := ctx.Config.GetInt("limit")
+	if limit < 1 {
+		return flyerr.New(flyerr.InvalidArgument, "--limit must be at least 1")
+	}
+
+	before := 0
+	if value := ctx.Config.GetString("before"); value != "" {
+		var err error
+		if before, err = parseReleaseVersion(value); err != nil {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --before release: %s", err))
+		}
+	}
+	status := ctx.Config.GetString("status")
+
+	releases, err := listReleases(ctx.Client.API(), ctx.AppName, limit, func(r api.Release) bool {
+		if before > 0 && r.Version >= before {
+			return false
+		}
+		return status == "" || strings.EqualFold(r.Status, status)
+	})
 	if err != nil {
 		return err
 	}
+
 	return ctx.Render(&presenters.Releases{Releases: releases})
 }
+
+// listReleases returns up to limit of the app's releases which match,
+// newest first, fetching pages of releases until enough match
+func listReleases(client *api.Client, appName string, limit int, match func(api.Release) bool) ([]api.Release, error) {
+	releases := []api.Release{}
+	var after *string
+
+	for {
+		page, pageInfo, err := client.GetAppReleasesPage(appName, releasesPageSize, after)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range page {
+			if !match(release) {
+				continue
+			}
+			releases = append(releases, release)
+			if len(releases) == limit {
+				return releases, nil
+			}
+		}
+
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			return releases, nil
+		}
+		after = api.StringPointer(pageInfo.EndCursor)
+	}
+}
+
+func runReleasesDiff(cmdCtx *cmdctx.CmdContext) error {
+	apiClient := cmdCtx.Client.API()
+
+	var releases [2]*api.Release
+	for i, arg := range cmdCtx.Args {
+		version, err := parseReleaseVersion(arg)
+		if err != nil {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid release %s: %s", arg, err))
+		}
+
+		release, err := apiClient.GetAppRelease(cmdCtx.AppName, version)
+		if err != nil {
+			return err
+		}
+		if release == nil {
+			return flyerr.New(flyerr.NotFound, fmt.Sprintf("%s has no release v%d", cmdCtx.AppName, version))
+		}
+		releases[i] = release
+	}
+
+	diff := deployment.DiffReleases(releases[0], releases[1])
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(diff)
+		return nil
+	}
+
+	printReleaseDiff(cmdCtx.Out, diff)
+
+	return nil
+}
+
+func printReleaseDiff(out io.Writer, diff *deployment.ReleaseDiff) {
+	if !diff.HasChanges() {
+		fmt.Fprintf(out, "No differences between v%d and v%d\n", diff.From, diff.To)
+		return
+	}
+
+	fmt.Fprintf(out, "Changes from v%d to v%d\n", diff.From, diff.To)
+
+	if diff.ImageChanged {
+		fmt.Fprintln(out, "\nImage")
+		fmt.Fprintf(out, "  - %s\n", diff.FromImage)
+		fmt.Fprintf(out, "  + %s\n", diff.ToImage)
+	}
+
+	if len(diff.Config) > 0 {
+		fmt.Fprintln(out, "\nConfig")
+		for _, change := range diff.Config {
+			switch {
+			case change.Added():
+				fmt.Fprintf(out, "  + %s = %s\n", change.Path, formatConfigValue(change.To))
+			case change.Removed():
+				fmt.Fprintf(out, "  - %s = %s\n", change.Path, formatConfigValue(change.From))
+			default:
+				fmt.Fprintf(out, "  ~ %s: %s -> %s\n", change.Path, formatConfigValue(change.From), formatConfigValue(change.To))
+			}
+		}
+	}
+
+	if len(diff.SecretsAdded) > 0 || len(diff.SecretsRemoved) > 0 {
+		fmt.Fprintln(out, "\nSecrets")
+		for _, name := range diff.SecretsAdded {
+			fmt.Fprintf(out, "  + %s\n", name)
+		}
+		for _, name := range diff.SecretsRemoved {
+			fmt.Fprintf(out, "  - %s\n", name)
+		}
+	}
+}
+
+// formatConfigValue shows a config value as JSON, quoting strings
+func formatConfigValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// bisectReleaseLimit - how far back in an app's history releases can be bisected
+const bisectReleaseLimit = 200
+
+func runReleasesBisect(cmdCtx *cmdctx.CmdContext) error {
+	apiClient := cmdCtx.Client.API()
+	checkCommand := cmdCtx.Config.GetString("check")
+
+	if checkCommand == "" && !cmdCtx.IO.CanPrompt() {
+		return fmt.Errorf("--check is required when not running interactively")
+	}
+
+	good, err := parseReleaseVersion(cmdCtx.Config.GetString("good"))
+	if err != nil {
+		return fmt.Errorf("invalid --good release: %s", err)
+	}
+
+	releases, err := apiClient.GetAppReleases(cmdCtx.AppName, bisectReleaseLimit)
+	if err != nil {
+		return err
+	}
+
+	images := map[int]string{}
+	versions := []int{}
+	bad := 0
+	for _, r := range releases {
+		if r.Version > bad {
+			bad = r.Version
+		}
+		if r.ImageRef != "" {
+			images[r.Version] = r.ImageRef
+			versions = append(versions, r.Version)
+		}
+	}
+
+	if badArg := cmdCtx.Config.GetString("bad"); badArg != "" {
+		if bad, err = parseReleaseVersion(badArg); err != nil {
+			return fmt.Errorf("invalid --bad release: %s", err)
+		}
+	}
+
+	bisect, err := deployment.NewBisect(versions, good, bad)
+	if err != nil {
+		return err
+	}
+
+	app, err := apiClient.GetApp(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+
+	appConfig, err := apiClient.GetConfig(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+
+	canaryName := cmdCtx.Config.GetString("canary-app")
+	if canaryName == "" {
+		canaryName = cmdCtx.AppName + "-bisect"
+	}
+
+	canary, err := apiClient.GetApp(canaryName)
+	if err != nil && !api.IsNotFoundError(err) && err.Error() != "Could not resolve App" {
+		return err
+	}
+	canaryExists := err == nil
+
+	// candidates that need a secret the canary doesn't have fail to deploy or
+	// misbehave whether or not they're bad
+	if !cmdCtx.Config.GetBool("allow-missing-secrets") {
+		missing, err := missingCanarySecrets(apiClient, cmdCtx.AppName, canaryName, canaryExists)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s has secrets the canary app %s doesn't: %s. Create it with 'flyctl apps create %s', set them with 'flyctl secrets set -a %s', then bisect with --canary-app %s, or pass --allow-missing-secrets", cmdCtx.AppName, canaryName, strings.Join(missing, ", "), canaryName, canaryName, canaryName))
+		}
+	}
+
+	if !canaryExists {
+		cmdCtx.Status("bisect", cmdctx.SBEGIN, "Creating canary app", canaryName)
+		if canary, err = apiClient.CreateApp(canaryName, app.Organization.ID, nil); err != nil {
+			return err
+		}
+
+		if !cmdCtx.Config.GetBool("keep") {
+			defer func() {
+				cmdCtx.Status("bisect", cmdctx.SBEGIN, "Destroying canary app", canaryName)
+				if err := apiClient.DeleteApp(canaryName); err != nil {
+					cmdCtx.Status("bisect", cmdctx.SERROR, "Failed to destroy canary app:", err)
+				}
+			}()
+		}
+	}
+
+	if canary.Organization.ID != app.Organization.ID {
+		return fmt.Errorf("canary app %s is not in the same organization as %s", canaryName, cmdCtx.AppName)
+	}
+
+	// a candidate's release command could run its migrations against the
+	// app's databases
+	definition := withoutReleaseCommand(appConfig.Definition)
+
+	canaryCtx := *cmdCtx
+	canaryCtx.AppName = canaryName
+
+	ctx := createCancellableContext()
+
+	cmdCtx.Statusf("bisect", cmdctx.STITLE, "Bisecting %s between v%d (good) and v%d (bad)\n", cmdCtx.AppName, good, bad)
+
+	for {
+		version, ok := bisect.Next()
+		if !ok {
+			break
+		}
+
+		cmdCtx.Statusf("bisect", cmdctx.SINFO, "Testing v%d, roughly %d steps left\n", version, bisect.StepsLeft())
+
+		release, _, err := apiClient.DeployImage(api.DeployImageInput{
+			AppID:      canaryName,
+			Image:      images[version],
+			Definition: api.DefinitionPtr(definition),
+		})
+		if err != nil {
+			return err
+		}
+
+		cmdCtx.Statusf("bisect", cmdctx.SBEGIN, "Deploying v%d to %s as v%d\n", version, canaryName, release.Version)
+
+		var result deployment.BisectResult
+
+		if err := watchDeployment(ctx, &canaryCtx); err != nil {
+			if !isDeploymentFailure(err) {
+				return err
+			}
+			// a deploy can fail for reasons that have nothing to do with the
+			// regression, so it's only marked bad when the user says so
+			if checkCommand != "" {
+				cmdCtx.Statusf("bisect", cmdctx.SWARN, "v%d failed to deploy, skipping it\n", version)
+				result = deployment.BisectSkip
+			} else if result, err = promptBisectResult(fmt.Sprintf("v%d failed to deploy to %s. Is it good or bad?", version, canaryName)); err != nil {
+				return err
+			}
+		} else if checkCommand != "" {
+			result, err = runBisectCheck(ctx, checkCommand, canaryName, version)
+			if err != nil {
+				return err
+			}
+		} else {
+			result, err = promptBisectResult(fmt.Sprintf("v%d is running on %s.fly.dev. Is it good or bad?", version, canaryName))
+			if err != nil {
+				return err
+			}
+		}
+
+		bisect.Mark(version, result)
+	}
+
+	suspects := bisect.Suspects()
+	if len(suspects) == 1 {
+		cmdCtx.Statusf("bisect", cmdctx.SDONE, "v%d is the first bad release\n", suspects[0])
+		return nil
+	}
+
+	versionNames := []string{}
+	for _, v := range suspects {
+		versionNames = append(versionNames, fmt.Sprintf("v%d", v))
+	}
+	cmdCtx.Statusf("bisect", cmdctx.SDONE, "The first bad release is one of %s\n", strings.Join(versionNames, ", "))
+
+	return nil
+}
+
+func parseReleaseVersion(value string) (int, error) {
+	if value == "" {
+		return 0, fmt.Errorf("a release version is required")
+	}
+
+	return strconv.Atoi(strings.TrimPrefix(strings.ToLower(value), "v"))
+}
+
+// runBisectCheck runs the check command against a deployed candidate, following git bisect run's exit codes
+func runBisectCheck(ctx context.Context, command string, canaryName string, version int) (deployment.BisectResult, error) {
+	shell, flag := "/bin/sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "powershell.exe", "-Command"
+	}
+
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Env = append(os.Environ(),
+		"FLY_BISECT_APP="+canaryName,
+		"FLY_BISECT_HOSTNAME="+canaryName+".fly.dev",
+		fmt.Sprintf("FLY_BISECT_VERSION=%d", version),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return deployment.BisectGood, nil
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if exitErr.ExitCode() == 125 {
+			return deployment.BisectSkip, nil
+		}
+		return deployment.BisectBad, nil
+	}
+
+	return deployment.BisectBad, err
+}
+
+func promptBisectResult(message string) (deployment.BisectResult, error) {
+	options := []string{"good", "bad", "skip"}
+
+	selected := 0
+	prompt := &survey.Select{
+		Message: message,
+		Options: options,
+	}
+	if err := ask(prompt, &selected); err != nil {
+		return deployment.BisectSkip, err
+	}
+
+	return []deployment.BisectResult{deployment.BisectGood, deployment.BisectBad, deployment.BisectSkip}[selected], nil
+}
+
+// missingCanarySecrets - the names of the app's secrets that the canary app,
+// if it exists, doesn't have
+func missingCanarySecrets(apiClient *api.Client, appName, canaryName string, canaryExists bool) ([]string, error) {
+	secrets, err := apiClient.GetAppSecrets(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	canarySecrets := map[string]bool{}
+	if canaryExists {
+		existing, err := apiClient.GetAppSecrets(canaryName)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range existing {
+			canarySecrets[secret.Name] = true
+		}
+	}
+
+	missing := []string{}
+	for _, secret := range secrets {
+		if !canarySecrets[secret.Name] {
+			missing = append(missing, secret.Name)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// withoutReleaseCommand copies definition without its deploy section's
+// release_command
+func withoutReleaseCommand(definition map[string]interface{}) map[string]interface{} {
+	deploy, ok := definition["deploy"].(map[string]interface{})
+	if !ok {
+		return definition
+	}
+
+	copied := map[string]interface{}{}
+	for k, v := range definition {
+		copied[k] = v
+	}
+	copiedDeploy := map[string]interface{}{}
+	for k, v := range deploy {
+		if k != "release_command" {
+			copiedDeploy[k] = v
+		}
+	}
+	copied["deploy"] = copiedDeploy
+
+	return copied
+}
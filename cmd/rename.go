@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// renamePlan - how an app is copied to a new name. Apps can't be renamed in
+// place, so a new app is created with the old one's config and resources,
+// leaving the old app running until it's destroyed.
+type renamePlan struct {
+	app       *api.App
+	newName   string
+	inventory *api.AppInventory
+	config    *api.AppConfig
+	image     string
+	secrets   []string
+	// snapshots - the latest snapshot of each volume, by volume ID, to
+	// restore the new app's volumes from
+	snapshots map[string]api.VolumeSnapshot
+}
+
+func planRename(client *api.Client, appName, newName string) (*renamePlan, error) {
+	app, err := client.GetApp(appName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error fetching app")
+	}
+
+	plan := &renamePlan{app: app, newName: newName, snapshots: map[string]api.VolumeSnapshot{}}
+
+	if plan.inventory, err = client.GetAppInventory(appName); err != nil {
+		return nil, errors.Wrap(err, "Error listing the app's resources")
+	}
+	if plan.config, err = client.GetConfig(appName); err != nil {
+		return nil, errors.Wrap(err, "Error fetching the app's config")
+	}
+
+	if release, err := client.GetAppCurrentRelease(appName); err == nil && release != nil {
+		plan.image = release.ImageRef
+	}
+
+	secrets, err := client.GetAppSecrets(appName)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error listing the app's secrets")
+	}
+	for _, secret := range secrets {
+		plan.secrets = append(plan.secrets, secret.Name)
+	}
+	sort.Strings(plan.secrets)
+
+	for _, volume := range plan.inventory.Volumes {
+		snapshots, err := client.GetVolumeSnapshots(volume.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error listing the snapshots of volume %s", volume.ID)
+		}
+		for _, snapshot := range snapshots {
+			if latest, ok := plan.snapshots[volume.ID]; !ok || snapshot.CreatedAt.After(latest.CreatedAt) {
+				plan.snapshots[volume.ID] = snapshot
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// deploys is true when the new app can be deployed straight away, which it
+// can't until secrets, which can't be read, are set again
+func (p *renamePlan) deploys() bool {
+	return p.image != "" && len(p.secrets) == 0
+}
+
+func (p *renamePlan) report() *transferReport {
+	report := &transferReport{}
+	oldName := p.app.Name
+
+	report.automatic("%s is created in %s", p.newName, p.app.Organization.Slug)
+	if p.deploys() {
+		report.automatic("%s's current image, %s, is deployed to %s with %s's config", oldName, p.image, p.newName, oldName)
+	}
+
+	for _, volume := range p.inventory.Volumes {
+		if snapshot, ok := p.snapshots[volume.ID]; ok {
+			report.automatic("volume %s is created in %s, restored from its snapshot of %s", volume.Name, volume.Region, snapshot.CreatedAt.Format("2006-01-02 15:04 MST"))
+		} else {
+			report.automatic("volume %s is created in %s", volume.Name, volume.Region)
+			report.attention("volume %s (%s) has no snapshot, so %s's copy starts empty", volume.Name, volume.ID, p.newName)
+		}
+	}
+	if len(p.snapshots) > 0 {
+		report.attention("data written to %s's volumes after their latest snapshots isn't copied", oldName)
+	}
+
+	if n := len(p.allocatedIPs()); n > 0 {
+		report.automatic("%s of the same types are allocated to %s", pluralize(n, "new IP address", "new IP addresses"), p.newName)
+		report.attention("IP addresses can't move between apps: point DNS at %s's new addresses", p.newName)
+	}
+
+	// a hostname's certificate can only belong to one app, and the old app
+	// keeps its certificates until they're removed
+	for _, cert := range p.inventory.Certificates {
+		report.attention("the certificate for %s stays with %s, move it with 'flyctl certs remove %s -a %s' then 'flyctl certs add %s -a %s'", cert.Hostname, oldName, cert.Hostname, oldName, cert.Hostname, p.newName)
+	}
+
+	if len(p.secrets) > 0 {
+		report.attention("secrets can't be read to copy them, set them with 'flyctl secrets set -a %s': %s", p.newName, strings.Join(p.secrets, ", "))
+		if p.image != "" {
+			report.attention("then deploy with 'flyctl deploy -a %s --image %s', which applies the config in fly.toml, as %s's config is only copied when it's deployed", p.newName, p.image, oldName)
+		}
+	}
+	for _, secret := range p.inventory.DatabaseSecrets {
+		report.attention("%s connects to an attached database, attach it to %s too", secret, p.newName)
+	}
+
+	report.attention("%s.fly.dev becomes %s.fly.dev", oldName, p.newName)
+	report.attention("%s keeps running until it's destroyed with 'flyctl apps destroy %s'", oldName, oldName)
+
+	return report
+}
+
+// allocatedIPs - the types of the public addresses allocated to the new
+// app, one for each of the old app's. Private addresses are allocated
+// automatically.
+func (p *renamePlan) allocatedIPs() []string {
+	types := []string{}
+	for _, ip := range p.inventory.IPAddresses {
+		switch ip.Type {
+		case "v4", "v6", api.IPAddressSharedV4:
+			types = append(types, ip.Type)
+		}
+	}
+	return types
+}
+
+// apply creates the new app and copies what it can. When its volumes or IP
+// addresses can't all be created the new app is destroyed again, so the
+// rename can be retried.
+func (p *renamePlan) apply(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	if _, err := client.CreateApp(p.newName, p.app.Organization.ID, nil); err != nil {
+		return errors.Wrapf(err, "Error creating %s", p.newName)
+	}
+	ctx.Statusf("rename", cmdctx.SDONE, "Created %s\n", p.newName)
+
+	if err := p.copyResources(ctx); err != nil {
+		return p.rollback(ctx, err)
+	}
+
+	if p.deploys() {
+		release, _, err := client.DeployImage(api.DeployImageInput{
+			AppID:      p.newName,
+			Image:      p.image,
+			Definition: api.DefinitionPtr(p.config.Definition),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Error deploying %s to %s, deploy it with 'flyctl deploy -a %s --image %s'", p.image, p.newName, p.newName, p.image)
+		}
+		ctx.Statusf("rename", cmdctx.SDONE, "Release v%d created for %s\n", release.Version, p.newName)
+	}
+
+	return nil
+}
+
+// copyResources creates the new app's volumes and IP addresses
+func (p *renamePlan) copyResources(ctx *cmdctx.CmdContext) error {
+	client := ctx.Client.API()
+
+	for _, volume := range p.inventory.Volumes {
+		var snapshotID *string
+		if snapshot, ok := p.snapshots[volume.ID]; ok {
+			snapshotID = api.StringPointer(snapshot.ID)
+		}
+		created, err := client.CreateVolume(p.newName, volume.Name, volume.Region, volume.SizeGb, volume.Encrypted, snapshotID)
+		if err != nil {
+			return errors.Wrapf(err, "Error creating volume %s for %s", volume.Name, p.newName)
+		}
+		ctx.Statusf("rename", cmdctx.SDONE, "Created volume %s (%s) in %s\n", created.Name, created.ID, created.Region)
+	}
+
+	for _, ipType := range p.allocatedIPs() {
+		ip, err := client.AllocateIPAddress(p.newName, ipType, "")
+		if err != nil {
+			return errors.Wrapf(err, "Error allocating an IP address for %s", p.newName)
+		}
+		ctx.Statusf("rename", cmdctx.SDONE, "Allocated %s (%s)\n", ip.Address, ip.Type)
+	}
+
+	return nil
+}
+
+// rollback destroys the new app, with the volumes and IP addresses created
+// for it, after copying them failed with err
+func (p *renamePlan) rollback(ctx *cmdctx.CmdContext, err error) error {
+	ctx.Statusf("rename", cmdctx.SWARN, "%v, destroying %s\n", err, p.newName)
+
+	if destroyErr := ctx.Client.API().DeleteApp(p.newName); destroyErr != nil {
+		return errors.Wrapf(err, "%s couldn't be destroyed (%v), destroy it and the volumes and IP addresses created above with 'flyctl apps destroy %s'", p.newName, destroyErr, p.newName)
+	}
+	ctx.Statusf("rename", cmdctx.SDONE, "Destroyed %s\n", p.newName)
+
+	return errors.Wrapf(err, "%s wasn't copied", p.app.Name)
+}
+
+func runAppsRename(ctx *cmdctx.CmdContext) error {
+	newName := ctx.Args[0]
+	if newName == ctx.AppName {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("%s is already named %s", ctx.AppName, newName))
+	}
+
+	available, err := ctx.Client.API().AppNameAvailable(newName)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return flyerr.New(flyerr.NameTaken, fmt.Sprintf("%s is already taken", newName))
+	}
+
+	plan, err := planRename(ctx.Client.API(), ctx.AppName, newName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Out, "Apps can't be renamed in place, so %s is copied to a new app, %s.\n", aurora.Bold(ctx.AppName), aurora.Bold(newName))
+	plan.report().print(ctx.Out)
+
+	if ctx.Config.GetBool("dry-run") {
+		return nil
+	}
+
+	if !confirm(fmt.Sprintf("Copy %s to %s?", ctx.AppName, newName)) {
+		return nil
+	}
+
+	if err := plan.apply(ctx); err != nil {
+		return err
+	}
+
+	if ctx.AppConfig != nil && ctx.AppConfig.AppName == ctx.AppName {
+		ctx.AppConfig.AppName = newName
+		if err := writeAppConfig(ctx.ConfigFile, ctx.AppConfig); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(ctx.Out, "Copied %s to %s. Destroy %s with 'flyctl apps destroy %s' once %s is serving\n", ctx.AppName, newName, ctx.AppName, ctx.AppName, newName)
+	return nil
+}
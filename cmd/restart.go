@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/internal/flyerr"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/docstrings"
@@ -16,11 +21,20 @@ func newRestartCommand(client *client.Client) *Command {
 	restartStrings := docstrings.Get("restart")
 	restartCmd := BuildCommandKS(nil, runRestart, restartStrings, client, requireSession, requireAppNameAsArg)
 	restartCmd.Args = cobra.RangeArgs(0, 1)
+	restartCmd.AddBoolFlag(BoolFlagOpts{Name: "rolling", Description: "Restart instances a batch at a time rather than all at once"})
+	restartCmd.AddIntFlag(IntFlagOpts{Name: "max-unavailable", Description: "How many instances a rolling restart restarts at once", Default: 1})
+	restartCmd.AddIntFlag(IntFlagOpts{Name: "max-surge", Description: "How many extra instances each process group runs while a rolling restart runs"})
+	restartCmd.AddBoolFlag(BoolFlagOpts{Name: "wait-healthy", Description: "Wait for each batch's health checks to pass before restarting the next"})
+	restartCmd.AddStringFlag(StringFlagOpts{Name: "batch-timeout", Description: "How long each batch of a rolling restart has to come back", Default: "5m"})
 
 	return restartCmd
 }
 
 func runRestart(cmdctx *cmdctx.CmdContext) error {
+	if cmdctx.Config.GetBool("rolling") {
+		return runRollingRestart(cmdctx)
+	}
+
 	app, err := cmdctx.Client.API().RestartApp(cmdctx.AppName)
 	if err != nil {
 		return err
@@ -29,3 +43,87 @@ func runRestart(cmdctx *cmdctx.CmdContext) error {
 	fmt.Printf("%s is being restarted\n", app.Name)
 	return nil
 }
+
+func runRollingRestart(cmdCtx *cmdctx.CmdContext) error {
+	apiClient := cmdCtx.Client.API()
+
+	maxUnavailable := cmdCtx.Config.GetInt("max-unavailable")
+	if maxUnavailable < 1 {
+		return flyerr.New(flyerr.InvalidArgument, "--max-unavailable must be at least 1")
+	}
+
+	maxSurge := cmdCtx.Config.GetInt("max-surge")
+	if maxSurge < 0 {
+		return flyerr.New(flyerr.InvalidArgument, "--max-surge can't be negative")
+	}
+
+	timeout, err := time.ParseDuration(cmdCtx.Config.GetString("batch-timeout"))
+	if err != nil {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid --batch-timeout: %s", err))
+	}
+
+	status, err := apiClient.GetAppStatus(cmdCtx.AppName, false)
+	if err != nil {
+		return err
+	}
+
+	var allocs []*api.AllocationStatus
+	for _, alloc := range status.Allocations {
+		if alloc.Status == "running" {
+			allocs = append(allocs, alloc)
+		}
+	}
+	if len(allocs) == 0 {
+		return fmt.Errorf("%s has no running instances to restart", cmdCtx.AppName)
+	}
+
+	cmdCtx.Statusf("restart", cmdctx.STITLE, "Restarting %d instances of %s, %d at a time\n", len(allocs), cmdCtx.AppName, maxUnavailable)
+
+	opts := deployment.RollingRestartOptions{
+		MaxUnavailable: maxUnavailable,
+		MaxSurge:       maxSurge,
+		WaitHealthy:    cmdCtx.Config.GetBool("wait-healthy"),
+		Timeout:        timeout,
+	}
+
+	ctx := createCancellableContext()
+
+	if maxSurge > 0 {
+		cmdCtx.Statusf("restart", cmdctx.SBEGIN, "Adding %d surge instances to each process group\n", maxSurge)
+		restore, err := deployment.Surge(ctx, apiClient, cmdCtx.AppName, maxSurge, timeout, 0)
+		if restore != nil {
+			defer func() {
+				if err := restore(); err != nil {
+					cmdCtx.Statusf("restart", cmdctx.SERROR, "Could not remove the surge instances, scale %s back with flyctl scale count: %v\n", cmdCtx.AppName, err)
+					return
+				}
+				cmdCtx.Statusf("restart", cmdctx.SDONE, "Removed the surge instances\n")
+			}()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = deployment.RollingRestart(ctx, apiClient, cmdCtx.AppName, allocs, opts, func(batch []*api.AllocationStatus, done bool) {
+		ids := make([]string, 0, len(batch))
+		for _, alloc := range batch {
+			ids = append(ids, fmt.Sprintf("%s (%s)", alloc.IDShort, alloc.Region))
+		}
+		if done {
+			cmdCtx.Statusf("restart", cmdctx.SDONE, "Restarted %s\n", strings.Join(ids, ", "))
+		} else {
+			cmdCtx.Statusf("restart", cmdctx.SBEGIN, "Restarting %s\n", strings.Join(ids, ", "))
+		}
+	})
+	if err != nil {
+		if restartErr, ok := err.(*deployment.RollingRestartError); ok && len(restartErr.Remaining) > 0 {
+			cmdCtx.Statusf("restart", cmdctx.SERROR, "Stopped the rolling restart, not restarted: %s\n", strings.Join(restartErr.Remaining, ", "))
+		}
+		return err
+	}
+
+	cmdCtx.Statusf("restart", cmdctx.SDONE, "Restarted all %d instances of %s\n", len(allocs), cmdCtx.AppName)
+
+	return nil
+}
@@ -6,8 +6,12 @@ import (
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/deployment"
+	"github.com/superfly/flyctl/terminal"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/docstrings"
@@ -25,6 +29,11 @@ func newResumeCommand(client *client.Client) *Command {
 }
 
 func runResume(cmdctx *cmdctx.CmdContext) error {
+	scale, err := deployment.LoadSuspendedScale(flyctl.ConfigDir(), cmdctx.AppName)
+	if err != nil {
+		return err
+	}
+
 	app, err := cmdctx.Client.API().ResumeApp(cmdctx.AppName)
 	if err != nil {
 		return err
@@ -36,6 +45,7 @@ func runResume(cmdctx *cmdctx.CmdContext) error {
 	s.Start()
 
 	for app.Status != "running" {
+		time.Sleep(time.Second)
 		app, err = cmdctx.Client.API().GetApp(cmdctx.AppName)
 		if err != nil {
 			return err
@@ -45,5 +55,43 @@ func runResume(cmdctx *cmdctx.CmdContext) error {
 	s.FinalMSG = fmt.Sprintf("Resume complete - %s is now %s with 1 running instance\n", cmdctx.AppName, app.Status)
 	s.Stop()
 
+	// the scale is only saved on the machine the app was suspended from
+	if scale == nil {
+		fmt.Fprintf(cmdctx.Out, "Warning: the scale %s was suspended with wasn't saved on this machine, so it runs 1 instance. Raise it with 'flyctl scale count'\n", cmdctx.AppName)
+		return nil
+	}
+	return restoreSuspendedScale(cmdctx, scale)
+}
+
+// restoreSuspendedScale scales the resumed app back to the VM counts it had
+// when it was suspended
+func restoreSuspendedScale(cmdctx *cmdctx.CmdContext, scale *deployment.SuspendedScale) error {
+	total := 0
+	for _, group := range scale.Counts {
+		total += group.Count
+	}
+	if total == 0 {
+		return deployment.ClearSuspendedScale(flyctl.ConfigDir(), cmdctx.AppName)
+	}
+
+	counts := make([]api.VMCountInput, 0, len(scale.Counts))
+	for _, group := range scale.Counts {
+		counts = append(counts, api.VMCountInput{Group: group.Name, Count: group.Count})
+	}
+
+	restored, warnings, err := cmdctx.Client.API().SetAppVMGroupCounts(cmdctx.AppName, counts)
+	if err != nil {
+		return fmt.Errorf("could not restore the scale %s was suspended with: %w", cmdctx.AppName, err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(cmdctx.Out, "Warning:", warning)
+	}
+
+	fmt.Fprintf(cmdctx.Out, "Restored %s's scale to %s\n", cmdctx.AppName, formatTaskGroupCounts(restored))
+
+	if err := deployment.ClearSuspendedScale(flyctl.ConfigDir(), cmdctx.AppName); err != nil {
+		terminal.Debug("could not clear suspended scale:", err)
+	}
+
 	return nil
 }
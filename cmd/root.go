@@ -9,14 +9,21 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/ghactions"
+	"github.com/superfly/flyctl/pkg/iostreams"
+	"github.com/superfly/flyctl/terminal"
 )
 
-// ErrAbort - Error generated when application aborts
-var ErrAbort = errors.New("abort")
+// ErrAbort - Error generated when application aborts. It exits with 130, as
+// other cancelled commands do, rather than 1.
+var ErrAbort = flyerr.New(flyerr.Cancelled, "abort")
 
 func NewRootCmd(client *client.Client) *cobra.Command {
 	rootStrings := docstrings.Get("flyctl")
@@ -25,18 +32,43 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 			Use:   rootStrings.Usage,
 			Short: rootStrings.Short,
 			Long:  rootStrings.Long,
-			PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 				cmd.SilenceUsage = true
 				cmd.SilenceErrors = true
+
+				if err := configureHTTPTracing(); err != nil {
+					return err
+				}
+
+				progress, err := iostreams.ParseProgressMode(viper.GetString(flyctl.ConfigProgress))
+				if err != nil {
+					return flyerr.Wrap(flyerr.InvalidArgument, err)
+				}
+				client.IO.SetProgressMode(progress)
+
+				switch format := viper.GetString(flyctl.ConfigOutputFormat); format {
+				case "", "table", "json", "csv":
+					return nil
+				default:
+					return flyerr.Wrap(flyerr.InvalidArgument, fmt.Errorf("unknown output format %q, expected table, json or csv", format))
+				}
 			},
 		},
 	}
 
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return flyerr.Wrap(flyerr.InvalidArgument, err)
+	})
+
 	rootCmd.PersistentFlags().StringP("access-token", "t", "", "Fly API Access Token")
 	err := viper.BindPFlag(flyctl.ConfigAPIToken, rootCmd.PersistentFlags().Lookup("access-token"))
 	checkErr(err)
 
-	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().String("profile", "", "Use the credentials of a named profile, also set with FLY_PROFILE")
+	err = viper.BindPFlag(flyctl.ConfigProfile, rootCmd.PersistentFlags().Lookup("profile"))
+	checkErr(err)
+
+	rootCmd.PersistentFlags().CountP("verbose", "v", "verbose output, repeat for more detail (-vv)")
 	err = viper.BindPFlag(flyctl.ConfigVerboseOutput, rootCmd.PersistentFlags().Lookup("verbose"))
 	checkErr(err)
 
@@ -44,6 +76,44 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 	err = viper.BindPFlag(flyctl.ConfigJSONOutput, rootCmd.PersistentFlags().Lookup("json"))
 	checkErr(err)
 
+	rootCmd.PersistentFlags().String("output", "table", "output format: table, json or csv")
+	err = viper.BindPFlag(flyctl.ConfigOutputFormat, rootCmd.PersistentFlags().Lookup("output"))
+	checkErr(err)
+
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Accept all confirmations, also set with FLY_AUTO_CONFIRM")
+	err = viper.BindPFlag(flyctl.ConfigAutoConfirm, rootCmd.PersistentFlags().Lookup("yes"))
+	checkErr(err)
+
+	// --auto-confirm is another name for --yes
+	rootCmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "auto-confirm" {
+			name = "yes"
+		}
+		return pflag.NormalizedName(name)
+	})
+
+	// prompts fail rather than wait for input in CI, where nobody answers them
+	promptIO = client.IO
+	if runningInCI() {
+		client.IO.SetNeverPrompt(true)
+	}
+
+	rootCmd.PersistentFlags().String("progress", "auto", "How progress is shown: auto, tty, plain, json or none, also set with FLY_PROGRESS")
+	err = viper.BindPFlag(flyctl.ConfigProgress, rootCmd.PersistentFlags().Lookup("progress"))
+	checkErr(err)
+
+	rootCmd.PersistentFlags().Bool("wg-websockets", false, "Tunnel WireGuard over WebSockets, for networks that block UDP, also set with FLY_WG_WEBSOCKETS")
+	err = viper.BindPFlag(flyctl.ConfigWireGuardWebsockets, rootCmd.PersistentFlags().Lookup("wg-websockets"))
+	checkErr(err)
+
+	rootCmd.PersistentFlags().Bool("debug-http", false, "Trace API requests, their timing and sanitized variables to stderr")
+	err = viper.BindPFlag(flyctl.ConfigDebugHTTP, rootCmd.PersistentFlags().Lookup("debug-http"))
+	checkErr(err)
+
+	rootCmd.PersistentFlags().String("debug-http-file", "", "Trace API requests to a file instead of stderr")
+	err = viper.BindPFlag(flyctl.ConfigDebugHTTPFile, rootCmd.PersistentFlags().Lookup("debug-http-file"))
+	checkErr(err)
+
 	rootCmd.PersistentFlags().String("builtinsfile", "", "Load builtins from named file")
 	err = viper.BindPFlag(flyctl.ConfigBuiltinsfile, rootCmd.PersistentFlags().Lookup("builtinsfile"))
 	checkErr(err)
@@ -52,35 +122,57 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 	checkErr(err)
 
 	rootCmd.AddCommand(
+		newAlertsCommand(client),
+		newAliasCommand(client),
 		newAppsCommand(client),
+		newAuditCommand(client),
 		newAuthCommand(client),
+		newBuildersCommand(client),
+		newBuildCommand(client),
 		newBuildsCommand(client),
+		newCompletionCommand(client),
 		newCurlCommand(client),
 		newCertificatesCommand(client),
 		newConfigCommand(client),
+		newConsoleCommand(client),
+		newCronCommand(client),
 		newDashboardCommand(client),
 		newDeployCommand(client),
 		newDestroyCommand(client),
 		newDocsCommand(client),
+		newDoctorCommand(client),
 		newHistoryCommand(client),
+		newImageCommand(client),
+		newImportCommand(client),
 		newInfoCommand(client),
 		newInitCommand(client),
+		newInteractiveCommand(client),
 		newIPAddressesCommand(client),
+		newJobsCommand(client),
 		newListCommand(client),
 		newLogsCommand(client),
+		newMachineCommand(client),
+		newMetricsCommand(client),
 		newMonitorCommand(client),
 		newMoveCommand(client),
 		newOpenCommand(client),
 		newPlatformCommand(client),
+		newProxyCommand(client),
 		newRegionsCommand(client),
+		newRegistryCommand(client),
+		newReconcileCommand(client),
+		newRedisCommand(client),
 		newReleasesCommand(client),
 		newRestartCommand(client),
 		newResumeCommand(client),
 		newScaleCommand(client),
 		newAutoscaleCommand(client),
 		newSecretsCommand(client),
+		newServicesCommand(client),
 		newStatusCommand(client),
 		newSuspendCommand(client),
+		newTemplatesCommand(client),
+		newTokensCommand(client),
 		newVersionCommand(client),
 		newDNSCommand(client),
 		newDomainsCommand(client),
@@ -94,27 +186,62 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 		newLaunchCommand(client),
 	)
 
+	registerCompletions(rootCmd.Command, client)
+
 	return rootCmd.Command
 }
 
+// configureHTTPTracing turns on API request tracing with --debug-http,
+// --debug-http-file or LOG_LEVEL=trace
+func configureHTTPTracing() error {
+	if path := viper.GetString(flyctl.ConfigDebugHTTPFile); path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return flyerr.Wrap(flyerr.InvalidArgument, fmt.Errorf("can't open trace file: %w", err))
+		}
+		api.SetTraceWriter(f)
+		return nil
+	}
+
+	if viper.GetBool(flyctl.ConfigDebugHTTP) || terminal.IsTrace() {
+		api.SetTraceWriter(os.Stderr)
+	}
+
+	return nil
+}
+
 func checkErr(err error) {
 	if err == nil {
 		return
 	}
 
+	ReportError(err)
+
+	safeExit(flyerr.ExitCode(err))
+}
+
+// ReportError prints err, along with its code as JSON when --json is set.
+// Cancellations are only reported as JSON.
+func ReportError(err error) {
+	if viper.GetBool(flyctl.ConfigJSONOutput) || viper.GetString(flyctl.ConfigOutputFormat) == "json" {
+		flyerr.WriteJSON(os.Stdout, err)
+		return
+	}
+
 	if !isCancelledError(err) {
 		fmt.Println(aurora.Red("Error"), err)
+		if !ghactions.IsAnnotated(err) {
+			ghactions.Error(os.Stdout, ghactions.Annotation{Message: err.Error()})
+		}
 	}
-
-	safeExit()
 }
 
 func isCancelledError(err error) bool {
-	if err == ErrAbort {
+	if errors.Is(err, ErrAbort) {
 		return true
 	}
 
-	if err == context.Canceled {
+	if errors.Is(err, context.Canceled) {
 		return true
 	}
 
@@ -127,8 +254,8 @@ func isCancelledError(err error) bool {
 	return false
 }
 
-func safeExit() {
+func safeExit(code int) {
 	flyctl.BackgroundTaskWG.Wait()
 
-	os.Exit(1)
+	os.Exit(code)
 }
@@ -3,10 +3,15 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/cmdutil"
 
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/docstrings"
@@ -27,6 +32,19 @@ func newScaleCommand(client *client.Client) *Command {
 		Description: "Memory in MB for the VM",
 		Default:     0,
 	})
+	vmCmd.AddStringFlag(StringFlagOpts{
+		Name:        "gpu",
+		Description: "GPU model to attach to each VM (e.g. a100)",
+	})
+	vmCmd.AddStringFlag(StringFlagOpts{
+		Name:        "group",
+		Description: "Process group to size. Defaults to all groups",
+	})
+	vmCmd.AddIntFlag(IntFlagOpts{
+		Name:        "gpu-count",
+		Description: "Number of GPUs to attach to each VM",
+		Default:     1,
+	})
 
 	memoryCmdStrings := docstrings.Get("scale.memory")
 	memoryCmd := BuildCommandKS(cmd, runScaleMemory, memoryCmdStrings, client, requireSession, requireAppName)
@@ -34,7 +52,17 @@ func newScaleCommand(client *client.Client) *Command {
 
 	countCmdStrings := docstrings.Get("scale.count")
 	countCmd := BuildCommand(cmd, runScaleCount, countCmdStrings.Usage, countCmdStrings.Short, countCmdStrings.Long, client, requireSession, requireAppName)
-	countCmd.Args = cobra.ExactArgs(1)
+	countCmd.Args = cobra.MaximumNArgs(1)
+	countCmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "region",
+		Shorthand:   "r",
+		Description: "Counts per region, as REGION=COUNT pairs (e.g. ams=2,lhr=3)",
+	})
+	countCmd.AddStringSliceFlag(StringSliceFlagOpts{
+		Name:        "group",
+		Shorthand:   "g",
+		Description: "Counts per process group, as GROUP=COUNT pairs (e.g. worker=5)",
+	})
 
 	showCmdStrings := docstrings.Get("scale.show")
 	BuildCommand(cmd, runScaleShow, showCmdStrings.Usage, showCmdStrings.Short, showCmdStrings.Long, client, requireSession, requireAppName)
@@ -45,9 +73,38 @@ func newScaleCommand(client *client.Client) *Command {
 func runScaleVM(commandContext *cmdctx.CmdContext) error {
 	sizeName := commandContext.Args[0]
 
-	memoryMB := int64(commandContext.Config.GetInt("memory"))
+	input := api.SetVMSizeInput{
+		AppID:    commandContext.AppName,
+		SizeName: sizeName,
+		MemoryMb: int64(commandContext.Config.GetInt("memory")),
+		Group:    commandContext.Config.GetString("group"),
+	}
+
+	if input.Group != "" {
+		currentGroups, err := commandContext.Client.API().GetAppVMCount(commandContext.AppName)
+		if err != nil {
+			return err
+		}
+		if err := validateScaleGroups(commandContext, currentGroups, map[string]int{input.Group: 0}); err != nil {
+			return err
+		}
+	}
 
-	size, err := commandContext.Client.API().SetAppVMSize(commandContext.AppName, sizeName, memoryMB)
+	if gpuKind := commandContext.Config.GetString("gpu"); gpuKind != "" {
+		gpuCount := commandContext.Config.GetInt("gpu-count")
+		if gpuCount < 1 {
+			return fmt.Errorf("--gpu-count must be at least 1")
+		}
+
+		if err := validateGPURegions(commandContext.Client.API(), commandContext.AppName, gpuKind); err != nil {
+			return err
+		}
+
+		input.GPUKind = gpuKind
+		input.GPUCount = gpuCount
+	}
+
+	size, err := commandContext.Client.API().SetAppVMSize(input)
 	if err != nil {
 		return err
 	}
@@ -55,10 +112,116 @@ func runScaleVM(commandContext *cmdctx.CmdContext) error {
 	fmt.Println("Scaled VM Type to", size.Name)
 	fmt.Printf("%15s: %s\n", "CPU Cores", formatCores(size))
 	fmt.Printf("%15s: %s\n", "Memory", formatMemory(size))
+	if size.GPUKind != "" {
+		fmt.Printf("%15s: %s\n", "GPUs", formatGPUs(size))
+	}
 	return nil
 }
 
 func runScaleCount(commandContext *cmdctx.CmdContext) error {
+	groupArgs := commandContext.Config.GetStringSlice("group")
+	regionArgs := commandContext.Config.GetStringSlice("region")
+
+	if len(groupArgs) == 0 && len(regionArgs) == 0 {
+		if len(commandContext.Args) == 0 {
+			return fmt.Errorf("a count, --group or --region is required")
+		}
+		return scaleAppGroupCount(commandContext)
+	}
+
+	groupCounts, err := parseScaleCounts(groupArgs)
+	if err != nil {
+		return errors.Wrap(err, "invalid group")
+	}
+	if len(commandContext.Args) > 0 {
+		if _, ok := groupCounts["app"]; ok {
+			return fmt.Errorf("set the app group count with either the count argument or --group app=N, not both")
+		}
+		count, err := strconv.Atoi(commandContext.Args[0])
+		if err != nil {
+			return err
+		}
+		groupCounts["app"] = count
+	}
+
+	regionCounts, err := parseScaleCounts(regionArgs)
+	if err != nil {
+		return errors.Wrap(err, "invalid region")
+	}
+
+	client := commandContext.Client.API()
+
+	currentGroups, err := client.GetAppVMCount(commandContext.AppName)
+	if err != nil {
+		return err
+	}
+	if err := validateScaleGroups(commandContext, currentGroups, groupCounts); err != nil {
+		return err
+	}
+
+	currentRegions := map[string]int{}
+	if len(regionCounts) > 0 {
+		if err := validateScaleRegions(client, commandContext.AppName, regionCounts); err != nil {
+			return err
+		}
+
+		status, err := client.GetAppStatus(commandContext.AppName, false)
+		if err != nil {
+			return err
+		}
+		for _, alloc := range status.Allocations {
+			if alloc.DesiredStatus == "run" {
+				currentRegions[alloc.Region]++
+			}
+		}
+	}
+
+	printScalePlan(commandContext, currentGroups, groupCounts, currentRegions, regionCounts)
+
+	if !confirm("Apply this scaling plan?") {
+		return nil
+	}
+
+	if len(groupCounts) > 0 {
+		input := []api.VMCountInput{}
+		for _, name := range sortedCountKeys(groupCounts) {
+			input = append(input, api.VMCountInput{Group: name, Count: groupCounts[name]})
+		}
+
+		counts, warnings, err := client.SetAppVMGroupCounts(commandContext.AppName, input)
+		if err != nil {
+			return err
+		}
+		printScaleWarnings(warnings)
+
+		for _, tg := range counts {
+			if _, ok := groupCounts[tg.Name]; ok {
+				fmt.Printf("%s count changed to %d\n", tg.Name, tg.Count)
+			}
+		}
+	}
+
+	if len(regionCounts) > 0 {
+		input := []api.ScaleRegionInput{}
+		for _, region := range sortedCountKeys(regionCounts) {
+			input = append(input, api.ScaleRegionInput{Region: region, Count: regionCounts[region]})
+		}
+
+		delta, err := client.ScaleApp(commandContext.AppName, input)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range delta {
+			fmt.Printf("%s count changed from %d to %d\n", change.Region, change.FromCount, change.ToCount)
+		}
+	}
+
+	return nil
+}
+
+// scaleAppGroupCount sets the count of the default "app" process group
+func scaleAppGroupCount(commandContext *cmdctx.CmdContext) error {
 	count, err := strconv.Atoi(commandContext.Args[0])
 	if err != nil {
 		return err
@@ -69,12 +232,7 @@ func runScaleCount(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	if len(warnings) > 0 {
-		for _, warning := range warnings {
-			fmt.Println("Warning:", warning)
-		}
-		fmt.Println()
-	}
+	printScaleWarnings(warnings)
 
 	// only use the "app" tg right now
 	var appCount int
@@ -89,11 +247,146 @@ func runScaleCount(commandContext *cmdctx.CmdContext) error {
 	return nil
 }
 
+func printScaleWarnings(warnings []string) {
+	if len(warnings) > 0 {
+		for _, warning := range warnings {
+			fmt.Println("Warning:", warning)
+		}
+		fmt.Println()
+	}
+}
+
+// parseScaleCounts parses NAME=COUNT pairs
+func parseScaleCounts(args []string) (map[string]int, error) {
+	kv, err := cmdutil.ParseKVStringsToMap(args)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for name, value := range kv {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("'%s=%s': count must be a whole number", name, value)
+		}
+		counts[name] = count
+	}
+
+	return counts, nil
+}
+
+// validateScaleGroups checks that each group is running or defined in the
+// [processes] section of the app config
+func validateScaleGroups(commandContext *cmdctx.CmdContext, current []api.TaskGroupCount, counts map[string]int) error {
+	known := map[string]bool{"app": true}
+	for _, tg := range current {
+		known[tg.Name] = true
+	}
+	if commandContext.AppConfig != nil {
+		for _, name := range commandContext.AppConfig.ProcessNames() {
+			known[name] = true
+		}
+	}
+
+	for name := range counts {
+		if !known[name] {
+			names := []string{}
+			for group := range known {
+				names = append(names, group)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("process group %s is not defined for %s. Known groups: %s", name, commandContext.AppName, strings.Join(names, ", "))
+		}
+	}
+
+	return nil
+}
+
+// validateScaleRegions checks that each region is one of the app's regions or backup regions
+func validateScaleRegions(client *api.Client, appName string, counts map[string]int) error {
+	regions, backupRegions, err := client.ListAppRegions(appName)
+	if err != nil {
+		return err
+	}
+
+	configured := map[string]bool{}
+	for _, region := range append(regions, backupRegions...) {
+		configured[region.Code] = true
+	}
+
+	for region := range counts {
+		if !configured[region] {
+			return fmt.Errorf("region %s is not configured for %s. Add it with `flyctl regions add %s`", region, appName, region)
+		}
+	}
+
+	return nil
+}
+
+func printScalePlan(commandContext *cmdctx.CmdContext, currentGroups []api.TaskGroupCount, groupCounts map[string]int, currentRegions map[string]int, regionCounts map[string]int) {
+	fmt.Fprintf(commandContext.Out, "Scaling plan for %s\n\n", commandContext.AppName)
+
+	if len(groupCounts) > 0 {
+		current := map[string]int{}
+		for _, tg := range currentGroups {
+			current[tg.Name] = tg.Count
+		}
+
+		table := helpers.MakeSimpleTable(commandContext.Out, []string{"Group", "Current", "New"})
+		for _, name := range sortedCountKeys(mergeCounts(current, groupCounts)) {
+			table.Append(scalePlanRow(name, current, groupCounts))
+		}
+		table.Render()
+		fmt.Fprintln(commandContext.Out)
+	}
+
+	if len(regionCounts) > 0 {
+		table := helpers.MakeSimpleTable(commandContext.Out, []string{"Region", "Current", "New"})
+		for _, region := range sortedCountKeys(mergeCounts(currentRegions, regionCounts)) {
+			table.Append(scalePlanRow(region, currentRegions, regionCounts))
+		}
+		table.Render()
+		fmt.Fprintln(commandContext.Out)
+	}
+}
+
+func scalePlanRow(name string, current map[string]int, planned map[string]int) []string {
+	newCount := current[name]
+	if count, ok := planned[name]; ok {
+		newCount = count
+	}
+	return []string{name, strconv.Itoa(current[name]), strconv.Itoa(newCount)}
+}
+
+func sortedCountKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mergeCounts(a map[string]int, b map[string]int) map[string]int {
+	merged := map[string]int{}
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
 func runScaleShow(commandContext *cmdctx.CmdContext) error {
 	size, tgCounts, err := commandContext.Client.API().AppVMResources(commandContext.AppName)
 	if err != nil {
 		return err
 	}
+	if len(tgCounts) > 1 || (len(tgCounts) == 1 && tgCounts[0].Name != "app") {
+		return printProcessGroupResources(commandContext)
+	}
+
 	fmt.Printf("VM Resources for %s\n", commandContext.AppName)
 
 	// only use the "app" tg right now
@@ -109,6 +402,28 @@ func runScaleShow(commandContext *cmdctx.CmdContext) error {
 	return nil
 }
 
+func printProcessGroupResources(commandContext *cmdctx.CmdContext) error {
+	groups, err := commandContext.Client.API().AppProcessGroups(commandContext.AppName)
+	if err != nil {
+		return err
+	}
+
+	if commandContext.OutputJSON() {
+		commandContext.WriteJSON(groups)
+		return nil
+	}
+
+	fmt.Fprintf(commandContext.Out, "VM Resources for %s\n", commandContext.AppName)
+
+	table := helpers.MakeSimpleTable(commandContext.Out, []string{"Process", "Command", "Count", "VM Size", "VM Memory"})
+	for _, group := range groups {
+		table.Append([]string{group.Name, group.Command, strconv.Itoa(group.Count), group.VMSize.Name, formatMemory(group.VMSize)})
+	}
+	table.Render()
+
+	return nil
+}
+
 func printVMResources(commandContext *cmdctx.CmdContext, vmSize api.VMSize, count int) {
 	if commandContext.OutputJSON() {
 		out := struct {
@@ -126,6 +441,9 @@ func printVMResources(commandContext *cmdctx.CmdContext, vmSize api.VMSize, coun
 
 	fmt.Fprintf(commandContext.Out, "%15s: %s\n", "VM Size", vmSize.Name)
 	fmt.Fprintf(commandContext.Out, "%15s: %s\n", "VM Memory", formatMemory(vmSize))
+	if vmSize.GPUKind != "" {
+		fmt.Fprintf(commandContext.Out, "%15s: %s\n", "GPUs", formatGPUs(vmSize))
+	}
 	fmt.Fprintf(commandContext.Out, "%15s: %d\n", "Count", count)
 }
 
@@ -141,7 +459,13 @@ func runScaleMemory(commandContext *cmdctx.CmdContext) error {
 		return err
 	}
 
-	size, err := commandContext.Client.API().SetAppVMSize(commandContext.AppName, currentsize.Name, memoryMB)
+	size, err := commandContext.Client.API().SetAppVMSize(api.SetVMSizeInput{
+		AppID:    commandContext.AppName,
+		SizeName: currentsize.Name,
+		MemoryMb: memoryMB,
+		GPUKind:  currentsize.GPUKind,
+		GPUCount: currentsize.GPUCount,
+	})
 	if err != nil {
 		return err
 	}
@@ -167,3 +491,50 @@ func formatMemory(size api.VMSize) string {
 	}
 	return fmt.Sprintf("%d GB", int(size.MemoryGB))
 }
+
+func formatGPUs(size api.VMSize) string {
+	return fmt.Sprintf("%d x %s", size.GPUCount, size.GPUKind)
+}
+
+// validateGPURegions checks that every region the app runs in, including
+// backup regions, offers the GPU kind
+func validateGPURegions(client *api.Client, appName string, gpuKind string) error {
+	platformRegions, err := client.PlatformRegionsCapacity()
+	if err != nil {
+		return err
+	}
+
+	available := []string{}
+	offered := map[string]bool{}
+	for _, region := range platformRegions {
+		for _, kind := range region.GPUKinds {
+			if strings.EqualFold(kind, gpuKind) {
+				offered[region.Code] = true
+				available = append(available, region.Code)
+				break
+			}
+		}
+	}
+
+	if len(available) == 0 {
+		return fmt.Errorf("GPU kind %s is not available in any region", gpuKind)
+	}
+
+	regions, backupRegions, err := client.ListAppRegions(appName)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, region := range append(regions, backupRegions...) {
+		if !offered[region.Code] {
+			missing = append(missing, region.Code)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("GPU kind %s is not available in %s. Regions offering it: %s", gpuKind, strings.Join(missing, ", "), strings.Join(available, ", "))
+	}
+
+	return nil
+}
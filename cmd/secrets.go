@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
 	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/secretgen"
 
 	"github.com/superfly/flyctl/docstrings"
 
@@ -47,6 +49,21 @@ func newSecretsCommand(client *client.Client) *Command {
 		Description: "Return immediately instead of monitoring deployment progress",
 	})
 
+	secretsGenerateStrings := docstrings.Get("secrets.generate")
+	generate := BuildCommandKS(cmd, runGenerateSecrets, secretsGenerateStrings, client, requireSession, requireAppName)
+	generate.Command.Example = `flyctl secrets generate SECRET_KEY_BASE --type hex64
+	flyctl secrets generate JWT_SIGNING_KEY --type rsa`
+	generate.Command.Args = cobra.MinimumNArgs(1)
+	generate.AddStringFlag(StringFlagOpts{
+		Name:        "type",
+		Description: fmt.Sprintf("Type of value to generate: %s", strings.Join(secretgen.Types(), ", ")),
+		Default:     "hex64",
+	})
+	generate.AddBoolFlag(BoolFlagOpts{
+		Name:        "detach",
+		Description: "Return immediately instead of monitoring deployment progress",
+	})
+
 	secretsUnsetStrings := docstrings.Get("secrets.unset")
 	unset := BuildCommandKS(cmd, runSecretsUnset, secretsUnsetStrings, client, requireSession, requireAppName)
 	unset.Command.Args = cobra.MinimumNArgs(1)
@@ -113,6 +130,64 @@ func runSetSecrets(cc *cmdctx.CmdContext) error {
 	return watchDeployment(ctx, cc)
 }
 
+func runGenerateSecrets(cc *cmdctx.CmdContext) error {
+	ctx := createCancellableContext()
+
+	app, err := cc.Client.API().GetApp(cc.AppName)
+	if err != nil {
+		return err
+	}
+
+	kind := cc.Config.GetString("type")
+	secrets := map[string]string{}
+
+	for _, name := range cc.Args {
+		if strings.Contains(name, "=") {
+			return fmt.Errorf("pass only the name of the secret to generate, not %s", name)
+		}
+
+		values, err := secretgen.Generate(name, kind)
+		if err != nil {
+			return err
+		}
+		for k, v := range values {
+			secrets[k] = v
+		}
+	}
+
+	release, err := cc.Client.API().SetSecrets(cc.AppName, secrets)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for k := range secrets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	cc.Statusf("secrets", cmdctx.SINFO, "Generated %s secrets %s\n", kind, strings.Join(names, ", "))
+
+	// public keys aren't sensitive and are usually needed elsewhere
+	for _, name := range names {
+		if strings.HasSuffix(name, secretgen.PublicKeySuffix) {
+			fmt.Fprintf(cc.Out, "%s:\n%s", name, secrets[name])
+		}
+	}
+
+	if !app.Deployed {
+		cc.Statusf("secrets", cmdctx.SINFO, "Secrets are staged for the first deployment\n")
+		return nil
+	}
+
+	cc.Statusf("secrets", cmdctx.SINFO, "Release v%d created\n", release.Version)
+
+	if cc.Config.GetBool("detach") {
+		return nil
+	}
+
+	return watchDeployment(ctx, cc)
+}
+
 func runImportSecrets(cc *cmdctx.CmdContext) error {
 	ctx := createCancellableContext()
 
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newServicesCommand(client *client.Client) *Command {
+	servicesStrings := docstrings.Get("services")
+	cmd := BuildCommandKS(nil, runServices, servicesStrings, client, requireSession, requireAppName)
+	return cmd
+}
+
+// servicePortReport - an edge port of the deployed config, and whether it's
+// reachable from the internet
+type servicePortReport struct {
+	Service      int      `json:"service"`
+	Protocol     string   `json:"protocol"`
+	Port         int      `json:"port"`
+	InternalPort int      `json:"internal_port"`
+	Handlers     []string `json:"handlers"`
+	Processes    []string `json:"processes,omitempty"`
+	Concurrency  string   `json:"concurrency,omitempty"`
+	Exposed      bool     `json:"exposed"`
+	Reason       string   `json:"reason,omitempty"`
+}
+
+type servicesReport struct {
+	Ports    []servicePortReport     `json:"ports"`
+	Problems []flyctl.ServiceProblem `json:"problems"`
+}
+
+func runServices(cmdCtx *cmdctx.CmdContext) error {
+	apiClient := cmdCtx.Client.API()
+
+	cfg, err := apiClient.GetConfig(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+	appConfig := flyctl.NewAppConfig()
+	appConfig.Definition = cfg.Definition
+
+	services, err := appConfig.ServiceConfigs()
+	if err != nil {
+		return err
+	}
+
+	app, err := apiClient.GetAppCompact(cmdCtx.AppName)
+	if err != nil {
+		return err
+	}
+
+	report := servicesReport{
+		Ports:    servicePortReports(services, app),
+		Problems: flyctl.CheckServices(services),
+	}
+
+	if cmdCtx.OutputJSON() {
+		cmdCtx.WriteJSON(report)
+		return nil
+	}
+
+	if len(services) == 0 {
+		fmt.Fprintf(cmdCtx.Out, "%s has no services, so nothing is exposed to the internet\n", cmdCtx.AppName)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(cmdCtx.Out, []string{"Service", "Protocol", "Port", "Internal Port", "Handlers", "Processes", "Concurrency", "Exposed"})
+	for _, port := range report.Ports {
+		exposed := "yes"
+		if !port.Exposed {
+			exposed = "no, " + port.Reason
+		}
+		portText := "-"
+		if port.Port != 0 {
+			portText = strconv.Itoa(port.Port)
+		}
+		table.Append([]string{
+			strconv.Itoa(port.Service),
+			port.Protocol,
+			portText,
+			strconv.Itoa(port.InternalPort),
+			strings.Join(port.Handlers, ", "),
+			strings.Join(port.Processes, ", "),
+			port.Concurrency,
+			exposed,
+		})
+	}
+	table.Render()
+
+	if len(report.Problems) > 0 {
+		fmt.Fprintln(cmdCtx.Out)
+		cmdCtx.Status("services", cmdctx.SWARN, "Possible problems with the services config:")
+		for _, problem := range report.Problems {
+			fmt.Fprintf(cmdCtx.Out, "  %s\n", problem)
+		}
+	}
+
+	return nil
+}
+
+// warnServiceProblems reports likely mistakes in services before they're
+// deployed, including UDP services the app has no dedicated IPv4 address for
+func warnServiceProblems(cmdCtx *cmdctx.CmdContext, services []flyctl.ServiceConfig) {
+	for _, problem := range flyctl.CheckServices(services) {
+		cmdCtx.Statusf("services", cmdctx.SWARN, "%s\n", problem)
+	}
+
+	if !flyctl.HasUDP(services) {
+		return
+	}
+
+	ipAddresses, err := cmdCtx.Client.API().GetIPAddresses(cmdCtx.AppName)
+	if err != nil {
+		terminal.Debugf("error getting IP addresses to check UDP services: %v\n", err)
+		return
+	}
+	if !hasDedicatedIPv4(ipAddresses) {
+		cmdCtx.Status("services", cmdctx.SWARN, "UDP services are only reachable on a dedicated IPv4 address, allocate one with flyctl ips allocate-v4")
+	}
+	cmdCtx.Status("services", cmdctx.SDETAIL, "UDP packets arrive on the fly-global-services address, so UDP servers must bind to it rather than 0.0.0.0")
+}
+
+// servicePortReports lists each port of services, checking it against the
+// services the platform is running for app and the app's IP addresses
+func servicePortReports(services []flyctl.ServiceConfig, app *api.AppCompact) []servicePortReport {
+	active := map[string]bool{}
+	for _, service := range app.Services {
+		for _, port := range service.Ports {
+			active[fmt.Sprintf("%s/%d", strings.ToLower(service.Protocol), port.Port)] = true
+		}
+	}
+
+	var reports []servicePortReport
+	for i, service := range services {
+		base := servicePortReport{
+			Service:      i,
+			Protocol:     service.Protocol,
+			InternalPort: service.InternalPort,
+			Processes:    service.Processes,
+		}
+		if c := service.Concurrency; c != nil {
+			base.Concurrency = fmt.Sprintf("%s %d/%d", c.Type, c.SoftLimit, c.HardLimit)
+		}
+
+		if len(service.Ports) == 0 {
+			base.Reason = "no ports"
+			reports = append(reports, base)
+			continue
+		}
+
+		for _, port := range service.Ports {
+			report := base
+			report.Port = port.Port
+			report.Handlers = port.Handlers
+
+			switch {
+			case len(app.IPAddresses.Nodes) == 0:
+				report.Reason = "no IP addresses, allocate one with flyctl ips allocate-v4"
+			case service.IsUDP() && !hasDedicatedIPv4(app.IPAddresses.Nodes):
+				report.Reason = "UDP needs a dedicated IPv4 address, allocate one with flyctl ips allocate-v4"
+			case !active[fmt.Sprintf("%s/%d", strings.ToLower(service.Protocol), port.Port)]:
+				report.Reason = "not active on the platform"
+			default:
+				report.Exposed = true
+			}
+
+			reports = append(reports, report)
+		}
+	}
+
+	return reports
+}
@@ -104,7 +104,7 @@ func runSSHConsole(ctx *cmdctx.CmdContext) error {
 			PageSize: 15,
 		}
 
-		if err := survey.AskOne(prompt, &selected); err != nil {
+		if err := ask(prompt, &selected); err != nil {
 			return fmt.Errorf("selecting instance: %w", err)
 		}
 
@@ -206,24 +206,24 @@ type SSHParams struct {
 	Cmd    string
 }
 
-func sshConnect(p *SSHParams, addr string) error {
+func newSSHClient(p *SSHParams, addr string) (*ssh.Client, error) {
 	terminal.Debugf("Fetching certificate for %s\n", addr)
 
 	cert, err := singleUseSSHCertificate(p.Ctx, p.Org)
 	if err != nil {
-		return fmt.Errorf("create ssh certificate: %w (if you haven't created a key for your org yet, try `flyctl ssh establish`)", err)
+		return nil, fmt.Errorf("create ssh certificate: %w (if you haven't created a key for your org yet, try `flyctl ssh establish`)", err)
 	}
 
 	pk, err := parsePrivateKey(cert.Key)
 	if err != nil {
-		return fmt.Errorf("parse ssh certificate: %w", err)
+		return nil, fmt.Errorf("parse ssh certificate: %w", err)
 	}
 
 	pemkey := MarshalED25519PrivateKey(pk, "single-use certificate")
 
 	terminal.Debugf("Keys for %s configured; connecting...\n", addr)
 
-	sshClient := &ssh.Client{
+	return &ssh.Client{
 		Addr: addr + ":22",
 		User: "root",
 
@@ -231,6 +231,28 @@ func sshConnect(p *SSHParams, addr string) error {
 
 		Certificate: cert.Certificate,
 		PrivateKey:  string(pemkey),
+	}, nil
+}
+
+// sshOutput runs p.Cmd on addr and returns its output rather than attaching a terminal
+func sshOutput(p *SSHParams, addr string) ([]byte, error) {
+	sshClient, err := newSSHClient(p, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sshClient.Connect(context.Background()); err != nil {
+		return nil, fmt.Errorf("connect to SSH server: %w", err)
+	}
+	defer sshClient.Close()
+
+	return sshClient.Output(context.Background(), p.Cmd)
+}
+
+func sshConnect(p *SSHParams, addr string) error {
+	sshClient, err := newSSHClient(p, addr)
+	if err != nil {
+		return err
 	}
 
 	endSpin := spin(fmt.Sprintf("Connecting to %s...", addr),
@@ -8,6 +8,7 @@ import (
 	"github.com/inancgumus/screen"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/monitor"
 
 	"github.com/segmentio/textio"
 	"github.com/spf13/cobra"
@@ -25,8 +26,8 @@ func newStatusCommand(client *client.Client) *Command {
 	//TODO: Move flag descriptions to docstrings
 	cmd.AddBoolFlag(BoolFlagOpts{Name: "all", Description: "Show completed instances"})
 	cmd.AddBoolFlag(BoolFlagOpts{Name: "deployment", Description: "Always show deployment status"})
-	cmd.AddBoolFlag(BoolFlagOpts{Name: "watch", Description: "Refresh details"})
-	cmd.AddIntFlag(IntFlagOpts{Name: "rate", Description: "Refresh Rate for --watch", Default: 5})
+	cmd.AddBoolFlag(BoolFlagOpts{Name: "watch", Description: "Refresh details, highlighting instances that changed"})
+	cmd.AddIntFlag(IntFlagOpts{Name: "rate", Description: "Refresh rate in seconds for --watch", Default: 5})
 	cmd.Command.Flags().String("wtf", "defaultwtf", "wtf usage")
 
 	// cmd.Command.Flag()
@@ -38,119 +39,191 @@ func newStatusCommand(client *client.Client) *Command {
 }
 
 func runStatus(ctx *cmdctx.CmdContext) error {
+	if ctx.Config.GetBool("watch") {
+		if ctx.OutputJSON() {
+			return fmt.Errorf("--watch and --json are not supported together")
+		}
+		return runStatusWatch(ctx)
+	}
 
-	watch := ctx.Config.GetBool("watch")
-	refreshRate := ctx.Config.GetInt("rate")
-	refreshCount := 1
-	showDeploymentStatus := ctx.Config.GetBool("deployment")
+	app, backupRegions, err := fetchAppStatus(ctx)
+	if err != nil {
+		return err
+	}
 
-	if watch && ctx.OutputJSON() {
-		return fmt.Errorf("--watch and --json are not supported together")
+	err = ctx.Frender(cmdctx.PresenterOption{Presentable: &presenters.AppStatus{AppStatus: *app}, HideHeader: true, Vertical: true, Title: "App"})
+	if err != nil {
+		return err
 	}
 
-	for {
-		var app *api.AppStatus
-		var backupregions []api.Region
-		var err error
-		if watch {
-			refreshCount = refreshCount - 1
-			if refreshCount == 0 {
-				refreshCount = refreshRate
-				app, err = ctx.Client.API().GetAppStatus(ctx.AppName, ctx.Config.GetBool("all"))
+	// If JSON output, everything has been printed, so return
+	if ctx.OutputJSON() {
+		return nil
+	}
 
-				if err != nil {
-					return err
-				}
+	if !app.Deployed {
+		fmt.Fprintln(ctx.Out, `App has not been deployed yet.`)
+		return nil
+	}
 
-				if app.Deployed {
-					_, backupregions, err = ctx.Client.API().ListAppRegions(ctx.AppName)
+	return renderStatusDetails(ctx, app, backupRegions, nil)
+}
 
-					if err != nil {
-						return err
-					}
+func fetchAppStatus(ctx *cmdctx.CmdContext) (*api.AppStatus, []api.Region, error) {
+	app, err := ctx.Client.API().GetAppStatus(ctx.AppName, ctx.Config.GetBool("all"))
+	if err != nil {
+		return nil, nil, err
+	}
 
-				}
-				screen.Clear()
-				screen.MoveTopLeft()
-				fmt.Printf("%s %s %s\n\n", aurora.Bold(app.Name), aurora.Italic("at:"), aurora.Bold(time.Now().UTC().Format("15:04:05")))
-			} else {
-				screen.MoveTopLeft()
-				if app != nil {
-					fmt.Printf("%s %s %s\n\n", aurora.Bold(app.Name), aurora.Italic("at:"), aurora.Bold(time.Now().UTC().Format("15:04:05")))
-				} else {
-					fmt.Printf("%s %s %s\n\n", aurora.Bold(ctx.AppName), aurora.Italic("at:"), aurora.Bold(time.Now().UTC().Format("15:04:05")))
-				}
-				time.Sleep(time.Second)
-				continue
-			}
-		} else {
-			app, err = ctx.Client.API().GetAppStatus(ctx.AppName, ctx.Config.GetBool("all"))
+	var backupRegions []api.Region
+	if app.Deployed {
+		_, backupRegions, err = ctx.Client.API().ListAppRegions(ctx.AppName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
+	return app, backupRegions, nil
+}
+
+func renderStatusDetails(ctx *cmdctx.CmdContext, app *api.AppStatus, backupRegions []api.Region, changed map[string]bool) error {
+	if app.DeploymentStatus != nil {
+		if (app.DeploymentStatus.Version == app.Version && app.DeploymentStatus.Status != "cancelled") || ctx.Config.GetBool("deployment") {
+			err := ctx.Frender(cmdctx.PresenterOption{
+				Presentable: &presenters.DeploymentStatus{Status: app.DeploymentStatus},
+				Vertical:    true,
+				Title:       "Deployment Status",
+			})
 			if err != nil {
 				return err
 			}
+		}
+	}
+
+	return ctx.Frender(cmdctx.PresenterOption{
+		Presentable: &presenters.Allocations{Allocations: app.Allocations, BackupRegions: backupRegions, Changed: changed},
+		Title:       "Instances",
+	})
+}
 
-			if app.Deployed {
-				_, backupregions, err = ctx.Client.API().ListAppRegions(ctx.AppName)
+// maxStatusEvents - number of changes kept in the recent events list of the --watch dashboard
+const maxStatusEvents = 10
 
-				if err != nil {
-					return err
-				}
+func runStatusWatch(ctx *cmdctx.CmdContext) error {
+	cancelCtx := createCancellableContext()
 
-			}
-			if err != nil {
+	refreshRate := ctx.Config.GetInt("rate")
+	if refreshRate < 1 {
+		refreshRate = 1
+	}
+
+	ticker := time.NewTicker(time.Duration(refreshRate) * time.Second)
+	defer ticker.Stop()
+
+	var prev *api.AppStatus
+	var events []monitor.StatusChange
+	connection := &monitor.ConnectionTracker{}
+
+	wait := func() bool {
+		select {
+		case <-cancelCtx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	}
+
+	for {
+		app, backupRegions, err := fetchAppStatus(ctx)
+		if err != nil {
+			// keep the last status on screen through network drops, and report
+			// what changed during the drop once it's back
+			if prev == nil || monitor.IsFatalError(err) {
 				return err
 			}
+			if connection.Failed(err, time.Now()) {
+				fmt.Fprintln(ctx.Out, aurora.Yellow(fmt.Sprintf("Connection lost at %s, retrying: %s", time.Now().UTC().Format("15:04:05"), err)))
+			}
+			if !wait() {
+				return nil
+			}
+			continue
+		}
+
+		if gap := connection.Recovered(time.Now()); gap != nil {
+			events = append(events, monitor.StatusChange{Time: gap.End, Field: "connection", To: gap.String() + ", changes during it are listed as of reconnecting"})
+		}
+
+		changed := map[string]bool{}
+		if prev != nil {
+			diff := monitor.DiffAllocations(prev.Allocations, app.Allocations, time.Now())
+			events = append(events, diff...)
+
+			for _, alloc := range app.Allocations {
+				for _, change := range diff {
+					if change.AllocID == alloc.IDShort {
+						changed[alloc.ID] = true
+					}
+				}
+			}
+		}
+		if len(events) > maxStatusEvents {
+			events = events[len(events)-maxStatusEvents:]
 		}
+		prev = app
+
+		screen.Clear()
+		screen.MoveTopLeft()
+		fmt.Fprintf(ctx.Out, "%s %s %s %s\n\n", aurora.Bold(app.Name), aurora.Italic("at:"), aurora.Bold(time.Now().UTC().Format("15:04:05")),
+			aurora.Faint(fmt.Sprintf("(refreshing every %ds, ctrl-c to exit)", refreshRate)))
 
 		err = ctx.Frender(cmdctx.PresenterOption{Presentable: &presenters.AppStatus{AppStatus: *app}, HideHeader: true, Vertical: true, Title: "App"})
 		if err != nil {
 			return err
 		}
 
-		// If JSON output, everything has been printed, so return
-		if !watch && ctx.OutputJSON() {
-			return nil
-		}
-
-		// Continue formatted output
 		if !app.Deployed {
-			fmt.Println(`App has not been deployed yet.`)
-			// exit if not watching, stay looping if we are
-			if !watch {
-				return nil
+			fmt.Fprintln(ctx.Out, `App has not been deployed yet.`)
+		} else {
+			if err := renderStatusDetails(ctx, app, backupRegions, changed); err != nil {
+				return err
 			}
-		}
-
-		if app.DeploymentStatus != nil {
-			if (app.DeploymentStatus.Version == app.Version && app.DeploymentStatus.Status != "cancelled") || showDeploymentStatus {
 
+			if checks := failingChecks(app.Allocations); len(checks) > 0 {
 				err = ctx.Frender(cmdctx.PresenterOption{
-					Presentable: &presenters.DeploymentStatus{Status: app.DeploymentStatus},
-					Vertical:    true,
-					Title:       "Deployment Status",
+					Presentable: &presenters.AllocationChecks{Checks: checks},
+					Title:       "Failing Health Checks",
 				})
-
 				if err != nil {
 					return err
 				}
 			}
 		}
 
-		err = ctx.Frender(cmdctx.PresenterOption{
-			Presentable: &presenters.Allocations{Allocations: app.Allocations, BackupRegions: backupregions},
-			Title:       "Instances",
-		})
-
-		if err != nil {
-			return err
+		if len(events) > 0 {
+			fmt.Fprintln(ctx.Out, aurora.Bold("Recent Events"))
+			for i := len(events) - 1; i >= 0; i-- {
+				fmt.Fprintf(ctx.Out, "%s %s\n", aurora.Faint(events[i].Time.UTC().Format("15:04:05")), events[i])
+			}
 		}
 
-		if !watch {
+		if !wait() {
 			return nil
 		}
 	}
+}
 
+func failingChecks(allocs []*api.AllocationStatus) []api.CheckState {
+	checks := []api.CheckState{}
+	for _, alloc := range allocs {
+		for _, check := range alloc.Checks {
+			if check.Status != "passing" {
+				check.Name = alloc.IDShort + "/" + check.Name
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
 }
 
 func runAllocStatus(ctx *cmdctx.CmdContext) error {
@@ -177,6 +250,12 @@ func runAllocStatus(ctx *cmdctx.CmdContext) error {
 				Events: alloc.Events,
 			},
 		},
+		cmdctx.PresenterOption{
+			Title: "Recent Exits",
+			Presentable: &presenters.AllocationExits{
+				Events: alloc.Events,
+			},
+		},
 		cmdctx.PresenterOption{
 			Title: "Checks",
 			Presentable: &presenters.AllocationChecks{
@@ -192,7 +271,7 @@ func runAllocStatus(ctx *cmdctx.CmdContext) error {
 	var pw *textio.PrefixWriter
 
 	if !ctx.OutputJSON() {
-		fmt.Println(aurora.Bold("Recent Logs"))
+		fmt.Fprintln(ctx.Out, aurora.Bold("Recent Logs"))
 		pw = textio.NewPrefixWriter(ctx.Out, "  ")
 		p = pw
 	} else {
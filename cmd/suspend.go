@@ -3,10 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/deployment"
 
 	"github.com/briandowns/spinner"
 	"github.com/spf13/cobra"
@@ -33,6 +37,10 @@ func runSuspend(ctx *cmdctx.CmdContext) error {
 	// }
 	appName := ctx.AppName
 
+	if err := saveSuspendedScale(ctx); err != nil {
+		return err
+	}
+
 	_, err := ctx.Client.API().SuspendApp(appName)
 	if err != nil {
 		return err
@@ -58,6 +66,7 @@ func runSuspend(ctx *cmdctx.CmdContext) error {
 			plural = "s"
 		}
 		s.Prefix = fmt.Sprintf("Suspending %s with %d instance%s to stop ", appstatus.Name, allocount, plural)
+		time.Sleep(time.Second)
 		appstatus, err = ctx.Client.API().GetAppStatus(ctx.AppName, false)
 		if err != nil {
 			return err
@@ -70,3 +79,30 @@ func runSuspend(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+// saveSuspendedScale records the app's VM counts so resume can restore them
+func saveSuspendedScale(ctx *cmdctx.CmdContext) error {
+	counts, err := ctx.Client.API().GetAppVMCount(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	// saved even when nothing is running, so resume only warns about apps
+	// whose scale it doesn't know
+	scale := &deployment.SuspendedScale{App: ctx.AppName, Counts: counts, SuspendedAt: time.Now()}
+	if err := deployment.SaveSuspendedScale(flyctl.ConfigDir(), scale); err != nil {
+		return fmt.Errorf("could not save %s's scale to restore on resume: %w", ctx.AppName, err)
+	}
+
+	fmt.Fprintf(ctx.Out, "Saved %s's scale of %s to restore on resume\n", ctx.AppName, formatTaskGroupCounts(counts))
+
+	return nil
+}
+
+func formatTaskGroupCounts(counts []api.TaskGroupCount) string {
+	parts := make([]string, 0, len(counts))
+	for _, group := range counts {
+		parts = append(parts, fmt.Sprintf("%s=%d", group.Name, group.Count))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/apptemplate"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/secretgen"
+)
+
+func newTemplatesCommand(client *client.Client) *Command {
+	templatesStrings := docstrings.Get("templates")
+	cmd := BuildCommandKS(nil, nil, templatesStrings, client, requireSession)
+	cmd.Aliases = []string{"template"}
+
+	publishStrings := docstrings.Get("templates.publish")
+	publishCmd := BuildCommandKS(cmd, runPublishTemplate, publishStrings, client, requireSession)
+	publishCmd.Args = cobra.MaximumNArgs(1)
+	publishCmd.AddStringFlag(StringFlagOpts{Name: "org", Description: "the organization publishing the template"})
+
+	searchStrings := docstrings.Get("templates.search")
+	searchCmd := BuildCommandKS(cmd, runSearchTemplates, searchStrings, client, requireSession)
+	searchCmd.Args = cobra.MaximumNArgs(1)
+
+	return cmd
+}
+
+func runPublishTemplate(ctx *cmdctx.CmdContext) error {
+	dir := "."
+	if len(ctx.Args) > 0 {
+		dir = ctx.Args[0]
+	}
+
+	tmpl, err := apptemplate.Load(dir)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Validate(); err != nil {
+		return err
+	}
+
+	org, err := selectOrganization(ctx.Client.API(), ctx.Config.GetString("org"), nil)
+	if err != nil {
+		return err
+	}
+
+	input := api.PublishAppTemplateInput{
+		OrganizationID: org.ID,
+		Name:           tmpl.Manifest.Name,
+		Description:    tmpl.Manifest.Description,
+	}
+	for _, name := range tmpl.FileNames() {
+		input.Files = append(input.Files, api.AppTemplateFile{Path: name, Content: tmpl.Files[name]})
+	}
+
+	published, err := ctx.Client.API().PublishAppTemplate(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Published template %s v%d with %d files\n", published.Name, published.Version, len(input.Files))
+	fmt.Printf("Launch an app from it with flyctl launch --template %s\n", published.Name)
+
+	return nil
+}
+
+func runSearchTemplates(ctx *cmdctx.CmdContext) error {
+	search := ""
+	if len(ctx.Args) > 0 {
+		search = ctx.Args[0]
+	}
+
+	templates, err := ctx.Client.API().SearchAppTemplates(search)
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(templates)
+		return nil
+	}
+
+	if len(templates) == 0 {
+		fmt.Println("No templates found")
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"Name", "Description", "Author", "Version", "Downloads", "Updated"})
+	for _, t := range templates {
+		table.Append([]string{t.Name, t.Description, t.Author, "v" + strconv.Itoa(t.Version), strconv.Itoa(t.Downloads), humanize.Time(t.UpdatedAt)})
+	}
+	table.Render()
+
+	return nil
+}
+
+// installTemplate downloads a template into dir and returns its manifest, if it has one
+func installTemplate(ctx *cmdctx.CmdContext, name string, dir string) (*apptemplate.Manifest, error) {
+	tmpl, err := ctx.Client.API().GetAppTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return nil, fmt.Errorf("template %s not found, try flyctl templates search", name)
+	}
+
+	files := map[string]string{}
+	for _, f := range tmpl.Files {
+		files[f.Path] = f.Content
+	}
+
+	if err := apptemplate.Install(dir, files); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Installed template %s v%d into %s\n", tmpl.Name, tmpl.Version, dir)
+
+	manifest := &apptemplate.Manifest{}
+	if content, ok := files[apptemplate.ManifestFile]; ok {
+		if manifest, err = apptemplate.ParseManifest(content); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// provisionTemplate sets up the secrets and volumes a template's manifest asks for
+func provisionTemplate(ctx *cmdctx.CmdContext, manifest *apptemplate.Manifest, app *api.App, region string) error {
+	secrets := map[string]string{}
+
+	for _, s := range manifest.Secrets {
+		if s.Generate != "" {
+			values, err := secretgen.Generate(s.Name, s.Generate)
+			if err != nil {
+				return err
+			}
+			for k, v := range values {
+				secrets[k] = v
+			}
+			continue
+		}
+
+		val := ""
+		ask(&survey.Password{
+			Message: fmt.Sprintf("Set secret %s:", s.Name),
+			Help:    s.Description,
+		}, &val)
+
+		if val != "" {
+			secrets[s.Name] = val
+		}
+	}
+
+	if len(secrets) > 0 {
+		if _, err := ctx.Client.API().SetSecrets(app.Name, secrets); err != nil {
+			return err
+		}
+		fmt.Printf("Set %d secrets on %s\n", len(secrets), app.Name)
+	}
+
+	for _, v := range manifest.Volumes {
+		volume, err := ctx.Client.API().CreateVolume(app.ID, v.Name, region, v.SizeGb, true, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %dGB volume %s in %s\n", volume.SizeGb, volume.Name, volume.Region)
+	}
+
+	return nil
+}
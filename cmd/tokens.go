@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
+	"github.com/superfly/flyctl/cmdctx"
+	"github.com/superfly/flyctl/docstrings"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func newTokensCommand(client *client.Client) *Command {
+	tokensStrings := docstrings.Get("tokens")
+	cmd := BuildCommandKS(nil, nil, tokensStrings, client, requireSession)
+
+	createStrings := docstrings.Get("tokens.create")
+	create := BuildCommandKS(cmd, nil, createStrings, client, requireSession)
+
+	deployStrings := docstrings.Get("tokens.create.deploy")
+	deploy := BuildCommandKS(create, runTokensCreateDeploy, deployStrings, client, requireSession, requireAppName)
+	deploy.Args = cobra.NoArgs
+	addTokenCreateFlags(deploy)
+
+	readOnlyStrings := docstrings.Get("tokens.create.readonly")
+	readOnly := BuildCommandKS(create, runTokensCreateReadOnly, readOnlyStrings, client, requireSession)
+	readOnly.Args = cobra.MaximumNArgs(1)
+	addTokenCreateFlags(readOnly)
+
+	builderStrings := docstrings.Get("tokens.create.builder")
+	builder := BuildCommandKS(create, runTokensCreateBuilder, builderStrings, client, requireSession)
+	builder.Args = cobra.MaximumNArgs(1)
+	addTokenCreateFlags(builder)
+
+	listStrings := docstrings.Get("tokens.list")
+	list := BuildCommandKS(cmd, runTokensList, listStrings, client, requireSession)
+	list.Args = cobra.MaximumNArgs(1)
+	list.AddStringFlag(StringFlagOpts{Name: "app", Shorthand: "a", Description: "Only list the deploy tokens of this app"})
+
+	revokeStrings := docstrings.Get("tokens.revoke")
+	revoke := BuildCommandKS(cmd, runTokensRevoke, revokeStrings, client, requireSession)
+	revoke.Aliases = []string{"delete"}
+	revoke.Args = cobra.RangeArgs(1, 2)
+
+	return cmd
+}
+
+func addTokenCreateFlags(cmd *Command) {
+	cmd.AddStringFlag(StringFlagOpts{Name: "name", Shorthand: "n", Description: "Name of the token. Defaults to its type and the date"})
+	cmd.AddStringFlag(StringFlagOpts{Name: "expiry", Shorthand: "x", Description: "How long until the token expires, e.g. 12h, 30d", Default: "90d"})
+}
+
+func runTokensCreateDeploy(ctx *cmdctx.CmdContext) error {
+	app, err := ctx.Client.API().GetApp(ctx.AppName)
+	if err != nil {
+		return err
+	}
+
+	return createAccessToken(ctx, &app.Organization, app.Name, api.AccessTokenTypeDeploy)
+}
+
+func runTokensCreateReadOnly(ctx *cmdctx.CmdContext) error {
+	org, err := orgByArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	return createAccessToken(ctx, org, "", api.AccessTokenTypeReadOnly)
+}
+
+func runTokensCreateBuilder(ctx *cmdctx.CmdContext) error {
+	org, err := orgByArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	return createAccessToken(ctx, org, "", api.AccessTokenTypeBuilder)
+}
+
+func createAccessToken(ctx *cmdctx.CmdContext, org *api.Organization, appName string, tokenType string) error {
+	expiry, err := parseTokenExpiry(ctx.Config.GetString("expiry"))
+	if err != nil {
+		return err
+	}
+
+	name := ctx.Config.GetString("name")
+	if name == "" {
+		name = fmt.Sprintf("%s %s", tokenType, time.Now().Format("2006-01-02"))
+		if appName != "" {
+			name = fmt.Sprintf("%s %s %s", tokenType, appName, time.Now().Format("2006-01-02"))
+		}
+	}
+
+	token, err := ctx.Client.API().CreateAccessToken(api.CreateAccessTokenInput{
+		OrganizationID: org.ID,
+		AppID:          appName,
+		Name:           name,
+		Type:           tokenType,
+		ExpiresIn:      int(expiry.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(token)
+		return nil
+	}
+
+	scope := "organization " + org.Slug
+	if appName != "" {
+		scope = "app " + appName
+	}
+
+	fmt.Fprintf(ctx.Out, "Created %s token %s for %s, %s. It cannot be shown again.\n\n", formatTokenType(tokenType), aurora.Bold(token.Name), scope, formatTokenExpiry(token.ExpiresAt))
+	fmt.Fprintf(ctx.Out, "FLY_API_TOKEN=%s\n\n", token.Token)
+
+	switch tokenType {
+	case api.AccessTokenTypeDeploy:
+		fmt.Fprintf(ctx.Out, "Set it as FLY_API_TOKEN in CI to run flyctl deploy for %s. It can't deploy or change other apps.\n", appName)
+	case api.AccessTokenTypeReadOnly:
+		fmt.Fprintf(ctx.Out, "It can read the apps, logs, status and metrics of %s, but not change them.\n", org.Slug)
+	case api.AccessTokenTypeBuilder:
+		fmt.Fprintf(ctx.Out, "It can build and push images with the remote builders of %s.\n", org.Slug)
+	}
+
+	return nil
+}
+
+func runTokensList(ctx *cmdctx.CmdContext) error {
+	var org *api.Organization
+	appName := ctx.Config.GetString("app")
+
+	if appName != "" && len(ctx.Args) == 0 {
+		app, err := ctx.Client.API().GetApp(appName)
+		if err != nil {
+			return err
+		}
+		org = &app.Organization
+	} else {
+		var err error
+		if org, err = orgByArg(ctx); err != nil {
+			return err
+		}
+	}
+
+	tokens, err := ctx.Client.API().GetAccessTokens(org.Slug)
+	if err != nil {
+		return err
+	}
+
+	active := []api.AccessToken{}
+	for _, token := range tokens {
+		if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+			continue
+		}
+		if appName != "" && token.AppName != appName {
+			continue
+		}
+		active = append(active, token)
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(active)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"ID", "Name", "Type", "App", "Created", "Expires", "Last Used"})
+	for _, token := range active {
+		lastUsed := "never"
+		if token.LastUsedAt != nil {
+			lastUsed = presenters.FormatRelativeTime(*token.LastUsedAt)
+		}
+		table.Append([]string{
+			token.ID,
+			token.Name,
+			formatTokenType(token.Type),
+			token.AppName,
+			presenters.FormatRelativeTime(token.CreatedAt),
+			formatTokenExpiry(token.ExpiresAt),
+			lastUsed,
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func runTokensRevoke(ctx *cmdctx.CmdContext) error {
+	id := ctx.Args[len(ctx.Args)-1]
+
+	// tokens are revoked by ID, or by name within an organization
+	if len(ctx.Args) == 2 {
+		tokens, err := ctx.Client.API().GetAccessTokens(ctx.Args[0])
+		if err != nil {
+			return err
+		}
+
+		id = ""
+		for _, token := range tokens {
+			if token.ID == ctx.Args[1] || token.Name == ctx.Args[1] {
+				id = token.ID
+				break
+			}
+		}
+		if id == "" {
+			return flyerr.New(flyerr.NotFound, fmt.Sprintf("no token %s in %s", ctx.Args[1], ctx.Args[0]))
+		}
+	}
+
+	if !confirm(fmt.Sprintf("Revoke token %s? Anything using it will stop working.", id)) {
+		return nil
+	}
+
+	if err := ctx.Client.API().DeleteAccessToken(id); err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(map[string]string{"RevokedTokenId": id})
+		return nil
+	}
+
+	fmt.Fprintf(ctx.Out, "Revoked token %s\n", id)
+
+	return nil
+}
+
+// parseTokenExpiry accepts a duration, or a number of days like 30d
+func parseTokenExpiry(value string) (time.Duration, error) {
+	var expiry time.Duration
+	var err error
+
+	if strings.HasSuffix(value, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(value, "d"))
+		expiry = time.Duration(days) * 24 * time.Hour
+	} else {
+		expiry, err = time.ParseDuration(value)
+	}
+
+	if err != nil || expiry <= 0 {
+		return 0, flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("invalid expiry %q, use a duration like 12h or a number of days like 30d", value))
+	}
+
+	return expiry, nil
+}
+
+func formatTokenType(tokenType string) string {
+	return strings.Replace(tokenType, "_", "-", -1)
+}
+
+func formatTokenExpiry(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return "never expires"
+	}
+	return "expires " + expiresAt.Local().Format("2006-01-02 15:04")
+}
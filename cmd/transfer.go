@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/superfly/flyctl/api"
+)
+
+// transferReport - what happens to an app's resources when it's moved or
+// renamed, reported before anything is changed
+type transferReport struct {
+	// Automatic - what flyctl and the platform take care of
+	Automatic []string
+	// Attention - what can't be done automatically, and how to do it
+	Attention []string
+}
+
+func (r *transferReport) automatic(format string, args ...interface{}) {
+	r.Automatic = append(r.Automatic, fmt.Sprintf(format, args...))
+}
+
+func (r *transferReport) attention(format string, args ...interface{}) {
+	r.Attention = append(r.Attention, fmt.Sprintf(format, args...))
+}
+
+func (r *transferReport) print(out io.Writer) {
+	if len(r.Automatic) > 0 {
+		fmt.Fprintln(out, "Handled automatically:")
+		for _, line := range r.Automatic {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+	if len(r.Attention) > 0 {
+		fmt.Fprintln(out, aurora.Yellow("Needs attention, as it can't be done automatically:"))
+		for _, line := range r.Attention {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+}
+
+// moveReport - what happens to an app's resources when it's moved from one
+// organization to another
+func moveReport(appName, fromOrg, toOrg string, inventory *api.AppInventory) *transferReport {
+	report := &transferReport{}
+
+	if n := len(inventory.Volumes); n > 0 {
+		report.automatic("%s move with the app", pluralize(n, "volume", "volumes"))
+	}
+	if n := len(inventory.IPAddresses); n > 0 {
+		report.automatic("%s move with the app, so DNS doesn't change", pluralize(n, "IP address", "IP addresses"))
+	}
+	if n := len(inventory.Certificates); n > 0 {
+		report.automatic("%s move with the app", pluralize(n, "certificate", "certificates"))
+	}
+	report.automatic("secrets move with the app")
+
+	for _, secret := range inventory.DatabaseSecrets {
+		report.attention("%s connects to a database in %s, which stays there and can't be reached over %s's private network. Attach a database in %s and update %s", secret, fromOrg, toOrg, toOrg, secret)
+	}
+	if len(inventory.Databases) > 0 {
+		report.attention("%s is a Postgres cluster: apps in %s attached to it can't reach it over the private network once it's moved", appName, fromOrg)
+	}
+	report.attention("other apps in %s can't be reached from %s over the private network, at .internal addresses", fromOrg, appName)
+
+	return report
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// hostnames - the hostnames of certificates
+func hostnames(certs []api.AppCertificate) string {
+	names := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		names = append(names, cert.Hostname)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/wireguard"
+	"github.com/superfly/flyctl/pkg/wg"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// privateProxy forwards local ports over WireGuard to services on an
+// organization's private network, for tools that can't use the userspace tunnel
+type privateProxy struct {
+	tunnel    *wg.Tunnel
+	listeners []net.Listener
+}
+
+// connectPrivateProxy connects to the organization's private network, ready to forward ports
+func connectPrivateProxy(client *api.Client, org *api.Organization) (*privateProxy, error) {
+	state, err := wireguard.StateForOrg(client, org, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("create wireguard config: %w", err)
+	}
+
+	terminal.Debugf("Establishing WireGuard connection (%s)\n", state.Name)
+
+	tunnel, err := wg.Connect(*state.TunnelConfig())
+	if err != nil {
+		return nil, fmt.Errorf("connect wireguard: %w", err)
+	}
+
+	return &privateProxy{tunnel: tunnel}, nil
+}
+
+// openPrivateProxy forwards a random local port to host:port on the organization's private network
+func openPrivateProxy(client *api.Client, org *api.Organization, host string, port string) (*privateProxy, error) {
+	proxy, err := connectPrivateProxy(client, org)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		proxy.Close()
+		return nil, err
+	}
+
+	if _, err := proxy.Forward(listener, host, port); err != nil {
+		listener.Close()
+		proxy.Close()
+		return nil, err
+	}
+
+	return proxy, nil
+}
+
+// Forward proxies connections accepted by listener to host:port on the
+// private network, returning the resolved remote address. The listener is
+// closed with the proxy.
+func (p *privateProxy) Forward(listener net.Listener, host string, port string) (string, error) {
+	addrs, err := p.tunnel.Resolver().LookupHost(context.Background(), host)
+	if err != nil {
+		return "", fmt.Errorf("look up %s: %w", host, err)
+	}
+	remote := net.JoinHostPort(addrs[0], port)
+
+	p.listeners = append(p.listeners, listener)
+
+	go proxyConnections(listener, func() (net.Conn, error) {
+		return p.tunnel.DialContext(context.Background(), "tcp", remote)
+	})
+
+	terminal.Debugf("Proxying %s to %s\n", listener.Addr(), remote)
+
+	return remote, nil
+}
+
+// Port is the local port of the first forward, accepting connections on 127.0.0.1
+func (p *privateProxy) Port() string {
+	return strconv.Itoa(p.listeners[0].Addr().(*net.TCPAddr).Port)
+}
+
+func (p *privateProxy) Close() {
+	for _, listener := range p.listeners {
+		listener.Close()
+	}
+	p.tunnel.Close()
+}
+
+// proxyConnections forwards each connection accepted by listener to a connection made with dial
+func proxyConnections(listener net.Listener, dial func() (net.Conn, error)) {
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer local.Close()
+
+			remote, err := dial()
+			if err != nil {
+				terminal.Debug("proxy dial failed:", err)
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(remote, local)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(local, remote)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/docstrings"
 	"github.com/superfly/flyctl/internal/client"
@@ -14,9 +17,11 @@ func newVMCommand(client *client.Client) *Command {
 
 	vmRestartCmd := BuildCommandKS(vmCmd, runVMRestart, docstrings.Get("vm.restart"), client, requireSession, requireAppName)
 	vmRestartCmd.Args = cobra.ExactArgs(1)
+	addVMWaitFlags(vmRestartCmd, "running again with no critical health checks")
 
 	vmStopCmd := BuildCommandKS(vmCmd, runVMStop, docstrings.Get("vm.stop"), client, requireSession, requireAppName)
 	vmStopCmd.Args = cobra.ExactArgs(1)
+	addVMWaitFlags(vmStopCmd, "stopped")
 
 	vmStatusCmd := BuildCommandKS(vmCmd, runAllocStatus, docstrings.Get("vm.status"), client, requireSession, requireAppName)
 	vmStatusCmd.Args = cobra.ExactArgs(1)
@@ -24,17 +29,33 @@ func newVMCommand(client *client.Client) *Command {
 	return vmCmd
 }
 
+func addVMWaitFlags(cmd *Command, until string) {
+	cmd.AddBoolFlag(BoolFlagOpts{Name: "wait", Description: "Wait until the VM is " + until})
+	cmd.AddStringFlag(StringFlagOpts{Name: "wait-timeout", Description: "How long to wait with --wait", Default: "5m"})
+}
+
 func runVMRestart(cmdctx *cmdctx.CmdContext) error {
 	appName := cmdctx.AppName
 	allocID := cmdctx.Args[0]
+	requested := time.Now()
 
 	err := cmdctx.Client.API().RestartAllocation(appName, allocID)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("VM %s is being restarted\n", allocID)
-	return nil
+	fmt.Fprintf(cmdctx.Out, "VM %s is being restarted\n", allocID)
+
+	if !cmdctx.Config.GetBool("wait") {
+		return nil
+	}
+
+	return waitForAllocation(cmdctx, allocID, "restarted", func(alloc *api.AllocationStatus) (bool, error) {
+		if alloc.Status == "failed" || alloc.Status == "lost" {
+			return false, fmt.Errorf("VM %s is %s", allocID, alloc.Status)
+		}
+		return alloc.Status == "running" && alloc.UpdatedAt.After(requested) && alloc.CriticalCheckCount == 0, nil
+	})
 }
 
 func runVMStop(cmdctx *cmdctx.CmdContext) error {
@@ -46,6 +67,53 @@ func runVMStop(cmdctx *cmdctx.CmdContext) error {
 		return err
 	}
 
-	fmt.Printf("VM %s is being stopped\n", allocID)
-	return nil
+	fmt.Fprintf(cmdctx.Out, "VM %s is being stopped\n", allocID)
+
+	if !cmdctx.Config.GetBool("wait") {
+		return nil
+	}
+
+	return waitForAllocation(cmdctx, allocID, "stopped", func(alloc *api.AllocationStatus) (bool, error) {
+		return alloc.Status == "complete" || alloc.Status == "failed" || alloc.Status == "lost", nil
+	})
+}
+
+// waitForAllocation polls the allocation's status until done, or the
+// --wait-timeout passes
+func waitForAllocation(cmdCtx *cmdctx.CmdContext, allocID string, action string, done func(*api.AllocationStatus) (bool, error)) error {
+	timeout, err := time.ParseDuration(cmdCtx.Config.GetString("wait-timeout"))
+	if err != nil {
+		return fmt.Errorf("invalid --wait-timeout: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(createCancellableContext(), timeout)
+	defer cancel()
+
+	for {
+		alloc, err := cmdCtx.Client.API().GetAllocationStatus(cmdCtx.AppName, allocID, 0)
+		if err != nil {
+			return err
+		}
+		if alloc == nil {
+			return api.ErrNotFound
+		}
+
+		ok, err := done(alloc)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Fprintf(cmdCtx.Out, "VM %s %s, it's %s\n", allocID, action, alloc.Status)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("VM %s wasn't %s after %s, it's %s", allocID, action, timeout, alloc.Status)
+			}
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
 }
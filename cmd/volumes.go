@@ -3,13 +3,18 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/client"
+	"github.com/superfly/flyctl/internal/wireguard"
+	"github.com/superfly/flyctl/pkg/wg"
 
 	"github.com/superfly/flyctl/docstrings"
 )
@@ -43,6 +48,11 @@ func newVolumesCommand(client *client.Client) *Command {
 		Default:     true,
 	})
 
+	createCmd.AddStringFlag(StringFlagOpts{
+		Name:        "snapshot-id",
+		Description: "Create the volume from the specified snapshot",
+	})
+
 	deleteStrings := docstrings.Get("volumes.delete")
 	deleteCmd := BuildCommandKS(volumesCmd, runDestroyVolume, deleteStrings, client, requireSession)
 	deleteCmd.Args = cobra.ExactArgs(1)
@@ -51,6 +61,32 @@ func newVolumesCommand(client *client.Client) *Command {
 	showCmd := BuildCommandKS(volumesCmd, runShowVolume, showStrings, client, requireSession)
 	showCmd.Args = cobra.ExactArgs(1)
 
+	extendStrings := docstrings.Get("volumes.extend")
+	extendCmd := BuildCommandKS(volumesCmd, runExtendVolume, extendStrings, client, requireAppName, requireSession)
+	extendCmd.Args = cobra.ExactArgs(1)
+
+	extendCmd.AddIntFlag(IntFlagOpts{
+		Name:        "size",
+		Shorthand:   "s",
+		Description: "Target volume size in gigabytes",
+	})
+
+	extendCmd.AddBoolFlag(BoolFlagOpts{
+		Name:        "restart",
+		Description: "Restart the attached VM if needed and verify the filesystem grew",
+	})
+
+	snapshotsStrings := docstrings.Get("volumes.snapshots")
+	snapshotsCmd := BuildCommandKS(volumesCmd, nil, snapshotsStrings, client, requireSession)
+
+	snapshotsListStrings := docstrings.Get("volumes.snapshots.list")
+	snapshotsListCmd := BuildCommandKS(snapshotsCmd, runListVolumeSnapshots, snapshotsListStrings, client, requireSession)
+	snapshotsListCmd.Args = cobra.ExactArgs(1)
+
+	snapshotsCreateStrings := docstrings.Get("volumes.snapshots.create")
+	snapshotsCreateCmd := BuildCommandKS(snapshotsCmd, runCreateVolumeSnapshot, snapshotsCreateStrings, client, requireSession)
+	snapshotsCreateCmd.Args = cobra.ExactArgs(1)
+
 	return volumesCmd
 }
 
@@ -67,24 +103,9 @@ func runListVolumes(ctx *cmdctx.CmdContext) error {
 		return nil
 	}
 
-	if ctx.OutputJSON() {
-		ctx.WriteJSON(volumes)
-		return nil
-	}
-
-	table := helpers.MakeSimpleTable(ctx.Out, []string{"ID", "Name", "Size", "Region", "Attached VM", "Created At"})
-
-	for _, v := range volumes {
-		var attachedAllocID string
-		if v.AttachedAllocation != nil {
-			attachedAllocID = v.AttachedAllocation.IDShort
-		}
-		table.Append([]string{v.ID, v.Name, strconv.Itoa(v.SizeGb) + "GB", v.Region, attachedAllocID, humanize.Time(v.CreatedAt)})
-	}
-
-	table.Render()
-
-	return nil
+	return ctx.Frender(cmdctx.PresenterOption{
+		Presentable: &presenters.Volumes{Volumes: volumes},
+	})
 }
 
 func runCreateVolume(ctx *cmdctx.CmdContext) error {
@@ -107,7 +128,12 @@ func runCreateVolume(ctx *cmdctx.CmdContext) error {
 
 	sizeGb := ctx.Config.GetInt("size")
 
-	volume, err := ctx.Client.API().CreateVolume(appid, volName, region, sizeGb, ctx.Config.GetBool("encrypted"))
+	var snapshotID *string
+	if id := ctx.Config.GetString("snapshot-id"); id != "" {
+		snapshotID = &id
+	}
+
+	volume, err := ctx.Client.API().CreateVolume(appid, volName, region, sizeGb, ctx.Config.GetBool("encrypted"), snapshotID)
 
 	if err != nil {
 		return err
@@ -161,3 +187,272 @@ func runShowVolume(ctx *cmdctx.CmdContext) error {
 
 	return nil
 }
+
+func runListVolumeSnapshots(ctx *cmdctx.CmdContext) error {
+	volID := ctx.Args[0]
+
+	snapshots, err := ctx.Client.API().GetVolumeSnapshots(volID)
+
+	if err != nil {
+		return err
+	}
+
+	if ctx.OutputJSON() {
+		ctx.WriteJSON(snapshots)
+		return nil
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots available for volume %s\n", volID)
+		return nil
+	}
+
+	table := helpers.MakeSimpleTable(ctx.Out, []string{"ID", "Size", "Created At"})
+
+	for _, s := range snapshots {
+		size, err := strconv.ParseUint(s.Size, 10, 64)
+		sizeStr := s.Size
+		if err == nil {
+			sizeStr = humanize.IBytes(size)
+		}
+		table.Append([]string{s.ID, sizeStr, humanize.Time(s.CreatedAt)})
+	}
+
+	table.Render()
+
+	return nil
+}
+
+func runCreateVolumeSnapshot(ctx *cmdctx.CmdContext) error {
+	volID := ctx.Args[0]
+
+	if err := ctx.Client.API().CreateVolumeSnapshot(volID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Scheduled a snapshot of volume %s. Run flyctl volumes snapshots list %s to see when it completes\n", volID, volID)
+
+	return nil
+}
+
+func runExtendVolume(ctx *cmdctx.CmdContext) error {
+	volID := ctx.Args[0]
+	client := ctx.Client.API()
+
+	sizeGb := ctx.Config.GetInt("size")
+
+	volume, err := client.GetVolume(volID)
+	if err != nil {
+		return err
+	}
+
+	if sizeGb <= volume.SizeGb {
+		return fmt.Errorf("--size must be larger than the current size of %dGB, volumes can't be shrunk", volume.SizeGb)
+	}
+
+	var fs *volumeFilesystem
+	if ctx.Config.GetBool("restart") && volume.AttachedAllocation != nil {
+		fs, err = newVolumeFilesystem(ctx, volume)
+		if err != nil {
+			return err
+		}
+
+		before, err := fs.size()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Filesystem at %s on %s is %s\n", fs.path, volume.AttachedAllocation.IDShort, humanize.IBytes(before))
+	}
+
+	fmt.Printf("Extending volume %s from %dGB to %dGB\n", volume.ID, volume.SizeGb, sizeGb)
+
+	extended, needsRestart, err := client.ExtendVolume(volID, sizeGb)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%10s: %s\n", "ID", extended.ID)
+	fmt.Printf("%10s: %s\n", "Name", extended.Name)
+	fmt.Printf("%10s: %s\n", "Region", extended.Region)
+	fmt.Printf("%10s: %d -> %d\n", "Size GB", volume.SizeGb, extended.SizeGb)
+
+	if volume.AttachedAllocation == nil {
+		return nil
+	}
+
+	if fs == nil {
+		if needsRestart {
+			fmt.Printf("Restart VM %s to use the new space, or run this command with --restart\n", volume.AttachedAllocation.IDShort)
+		}
+		return nil
+	}
+
+	if needsRestart {
+		if err := restartAndWait(ctx, volume.AttachedAllocation.ID); err != nil {
+			return err
+		}
+	}
+
+	after, err := fs.size()
+	if err != nil {
+		return err
+	}
+
+	if after < uint64(sizeGb)*(1<<30)*9/10 {
+		return fmt.Errorf("filesystem at %s is %s after extending, expected about %dGB", fs.path, humanize.IBytes(after), sizeGb)
+	}
+
+	fmt.Printf("Filesystem at %s on %s is now %s\n", fs.path, volume.AttachedAllocation.IDShort, humanize.IBytes(after))
+
+	return nil
+}
+
+// restartAndWait restarts a VM and waits for it to come back up
+func restartAndWait(ctx *cmdctx.CmdContext, allocID string) error {
+	client := ctx.Client.API()
+
+	alloc, err := client.GetAllocationStatus(ctx.AppName, allocID, 0)
+	if err != nil {
+		return err
+	}
+	restarts := alloc.Restarts
+
+	fmt.Printf("Restarting VM %s\n", alloc.IDShort)
+	if err := client.RestartAllocation(ctx.AppName, allocID); err != nil {
+		return err
+	}
+
+	timeout := time.After(5 * time.Minute)
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for VM %s to restart", alloc.IDShort)
+		case <-time.After(2 * time.Second):
+		}
+
+		alloc, err = client.GetAllocationStatus(ctx.AppName, allocID, 0)
+		if err != nil {
+			return err
+		}
+		if alloc.Restarts > restarts && alloc.Status == "running" {
+			return nil
+		}
+	}
+}
+
+// volumeFilesystem checks the size of a volume's filesystem from inside its VM
+type volumeFilesystem struct {
+	ssh  *SSHParams
+	addr string
+	path string
+}
+
+func newVolumeFilesystem(ctx *cmdctx.CmdContext, volume *api.Volume) (*volumeFilesystem, error) {
+	client := ctx.Client.API()
+
+	app, err := client.GetApp(ctx.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig, err := client.GetConfig(ctx.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	path := volumeMountPath(appConfig.Definition, volume.Name)
+	if path == "" {
+		return nil, fmt.Errorf("volume %s is not mounted in the configuration of %s", volume.Name, ctx.AppName)
+	}
+
+	alloc, err := client.GetAllocationStatus(ctx.AppName, volume.AttachedAllocation.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := wireguard.StateForOrg(client, &app.Organization, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("create wireguard config: %w", err)
+	}
+
+	tunnel, err := wg.Connect(*state.TunnelConfig())
+	if err != nil {
+		return nil, fmt.Errorf("connect wireguard: %w", err)
+	}
+
+	return &volumeFilesystem{
+		ssh: &SSHParams{
+			Ctx:    ctx,
+			Org:    &app.Organization,
+			App:    ctx.AppName,
+			Tunnel: tunnel,
+			Cmd:    fmt.Sprintf("df -Pk %s", path),
+		},
+		addr: fmt.Sprintf("[%s]", alloc.PrivateIP),
+		path: path,
+	}, nil
+}
+
+// size returns the filesystem size in bytes, retrying while the VM finishes booting
+func (fs *volumeFilesystem) size() (uint64, error) {
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			time.Sleep(3 * time.Second)
+		}
+
+		var out []byte
+		out, err = sshOutput(fs.ssh, fs.addr)
+		if err != nil {
+			continue
+		}
+
+		return parseDfSize(string(out))
+	}
+
+	return 0, err
+}
+
+// parseDfSize reads the filesystem size from the output of df -Pk
+func parseDfSize(out string) (uint64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %s", out)
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %s", out)
+	}
+
+	kb, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected df output: %s", out)
+	}
+
+	return kb * 1024, nil
+}
+
+// volumeMountPath finds where a volume is mounted from the app's [mounts] configuration
+func volumeMountPath(definition api.Definition, volumeName string) string {
+	var mounts []interface{}
+	switch m := definition["mounts"].(type) {
+	case map[string]interface{}:
+		mounts = []interface{}{m}
+	case []interface{}:
+		mounts = m
+	}
+
+	for _, m := range mounts {
+		mount, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if source, _ := mount["source"].(string); source == volumeName {
+			destination, _ := mount["destination"].(string)
+			return destination
+		}
+	}
+
+	return ""
+}
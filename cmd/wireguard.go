@@ -51,7 +51,7 @@ func argOrPromptImpl(ctx *cmdctx.CmdContext, nth int, prompt string, first bool)
 	}
 
 	val := ""
-	err := survey.AskOne(&survey.Input{
+	err := ask(&survey.Input{
 		Message: prompt,
 	}, &val)
 
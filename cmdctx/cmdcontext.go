@@ -37,6 +37,7 @@ type CmdContext struct {
 type PresenterOption struct {
 	Presentable presenters.Presentable
 	AsJSON      bool
+	AsCSV       bool
 	Vertical    bool
 	HideHeader  bool
 	Title       string
@@ -53,6 +54,14 @@ const SBEGIN = "begin"
 const SDONE = "done"
 const SERROR = "error"
 
+// Output verbosity levels. Quiet is only available to commands with a --quiet flag.
+const (
+	VerbosityQuiet = iota - 1
+	VerbosityNormal
+	VerbosityVerbose
+	VerbosityDebug
+)
+
 func NewCmdContext(flyctlClient *client.Client, ns string, args []string) (*CmdContext, error) {
 	ctx := &CmdContext{
 		IO:           flyctlClient.IO,
@@ -80,6 +89,7 @@ func (commandContext *CmdContext) Render(presentable presenters.Presentable) err
 		Out:  os.Stdout,
 		Opts: presenters.Options{
 			AsJSON: commandContext.OutputJSON(),
+			AsCSV:  commandContext.OutputCSV(),
 		},
 	}
 
@@ -96,6 +106,7 @@ func (commandContext *CmdContext) render(out io.Writer, views ...PresenterOption
 				HideHeader: v.HideHeader,
 				Title:      v.Title,
 				AsJSON:     v.AsJSON,
+				AsCSV:      v.AsCSV,
 			},
 		}
 
@@ -109,11 +120,15 @@ func (commandContext *CmdContext) render(out io.Writer, views ...PresenterOption
 
 // Frender - render a view to a Writer
 func (commandContext *CmdContext) Frender(views ...PresenterOption) error {
-	// If JSON output wanted, set in all views
+	// If JSON or CSV output wanted, set in all views
 	if commandContext.OutputJSON() {
 		for i := range views {
 			views[i].AsJSON = true
 		}
+	} else if commandContext.OutputCSV() {
+		for i := range views {
+			views[i].AsCSV = true
+		}
 	}
 
 	return commandContext.render(commandContext.IO.Out, views...)
@@ -128,6 +143,12 @@ func (commandContext *CmdContext) FrenderPrefix(prefix string, views ...Presente
 		for i := range views {
 			views[i].AsJSON = true
 		}
+	} else if commandContext.OutputCSV() {
+		// CSV is for machines, so skip the prefix
+		for i := range views {
+			views[i].AsCSV = true
+		}
+		return commandContext.render(commandContext.IO.Out, views...)
 	}
 
 	return commandContext.render(p, views...)
@@ -143,6 +164,10 @@ type JSON struct {
 func (commandContext *CmdContext) StatusLn() {
 	outputJSON := commandContext.OutputJSON()
 
+	if commandContext.Verbosity() == VerbosityQuiet {
+		return
+	}
+
 	if outputJSON {
 		// Do nothing for JSON
 		return
@@ -154,6 +179,10 @@ func (commandContext *CmdContext) StatusLn() {
 func (commandContext *CmdContext) Status(source string, status string, args ...interface{}) {
 	outputJSON := commandContext.OutputJSON()
 
+	if !commandContext.showStatus(status) {
+		return
+	}
+
 	var message strings.Builder
 
 	for i, v := range args {
@@ -200,6 +229,10 @@ func statusToEffect(status string, message string) string {
 func (commandContext *CmdContext) Statusf(source string, status string, format string, args ...interface{}) {
 	outputJSON := commandContext.OutputJSON()
 
+	if !commandContext.showStatus(status) {
+		return
+	}
+
 	message := fmt.Sprintf(format, args...)
 
 	if outputJSON {
@@ -220,5 +253,33 @@ func (commandContext *CmdContext) WriteJSON(myData interface{}) {
 }
 
 func (commandContext *CmdContext) OutputJSON() bool {
-	return commandContext.GlobalConfig.GetBool(flyctl.ConfigJSONOutput)
+	return commandContext.GlobalConfig.GetBool(flyctl.ConfigJSONOutput) ||
+		commandContext.GlobalConfig.GetString(flyctl.ConfigOutputFormat) == "json"
+}
+
+func (commandContext *CmdContext) OutputCSV() bool {
+	return commandContext.GlobalConfig.GetString(flyctl.ConfigOutputFormat) == "csv" && !commandContext.OutputJSON()
+}
+
+// Verbosity - the output level requested with --quiet or one or more --verbose flags
+func (commandContext *CmdContext) Verbosity() int {
+	if commandContext.Config.GetBool("quiet") {
+		return VerbosityQuiet
+	}
+
+	level := commandContext.GlobalConfig.GetInt(flyctl.ConfigVerboseOutput)
+	if level == 0 && commandContext.GlobalConfig.GetBool(flyctl.ConfigVerboseOutput) {
+		// FLY_VERBOSE=true
+		level = VerbosityVerbose
+	}
+	if level > VerbosityDebug {
+		level = VerbosityDebug
+	}
+
+	return level
+}
+
+// showStatus - quiet output is limited to errors
+func (commandContext *CmdContext) showStatus(status string) bool {
+	return status == SERROR || commandContext.Verbosity() != VerbosityQuiet
 }
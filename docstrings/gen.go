@@ -3,24 +3,97 @@ package docstrings
 // Get - Get a document string
 func Get(key string) KeyStrings {
 	switch key {
+	case "alerts":
+		return KeyStrings{"alerts", "Manage alerts on an app",
+			`Manage threshold alerts on an app. Alerts are evaluated by the 
+platform and notify the organization's health check handlers, posting to Slack 
+or paging through PagerDuty, so there's no alerting stack to run. Create 
+handlers with flyctl checks handlers create.`,
+		}
+	case "alerts.create":
+		return KeyStrings{"create <name>", "Create an alert",
+			`Create an alert which notifies the health check handlers given 
+with --notify. --kind is what to alert on:
+
+  instance-down   --threshold instances down for --for (default 1 for 5m)
+  restart-loop    an instance restarts --threshold times within --for 
+                  (default 3 in 10m)
+  check-flapping  a health check changes status --threshold times within 
+                  --for (default 4 in 10m)
+  memory          an instance uses over --threshold percent of its memory 
+                  for --for (default 90 for 5m)
+
+For example:
+
+  flyctl alerts create restarts --kind restart-loop --notify oncall
+  flyctl alerts create memory --kind memory --threshold 80 --for 15m --notify ops`,
+		}
+	case "alerts.delete":
+		return KeyStrings{"delete <name>", "Delete an alert",
+			`Delete an alert from the app.`,
+		}
+	case "alerts.list":
+		return KeyStrings{"list", "List the app's alerts",
+			`List the app's alerts, their conditions, who they notify and 
+whether they're firing.`,
+		}
+	case "alias":
+		return KeyStrings{"alias", "Manage command aliases",
+			`Manage command aliases. An alias is a name that runs a longer command
+line, for example "flyctl shipit" for "flyctl deploy --strategy canary".
+Aliases are saved in ~/.fly/config.yml; an app can also define them in the
+[cli.aliases] section of fly.toml. Aliases never replace built-in commands.`,
+		}
+	case "alias.list":
+		return KeyStrings{"list", "List aliases",
+			`List aliases from ~/.fly/config.yml and the fly.toml in the working directory`,
+		}
+	case "alias.remove":
+		return KeyStrings{"remove <name>", "Remove an alias",
+			`Remove an alias from ~/.fly/config.yml`,
+		}
+	case "alias.set":
+		return KeyStrings{"set <name> <command>", "Create or replace an alias",
+			`Create or replace an alias in ~/.fly/config.yml. The command is
+quoted as one argument, for example:
+
+flyctl alias set shipit "deploy --strategy canary"
+
+Arguments after the alias name are appended to the command.`,
+		}
 	case "apps":
 		return KeyStrings{"apps", "Manage apps",
 			`The APPS commands focus on managing your Fly applications.
 Start with the CREATE command to register your application.
 The LIST command will list all currently registered applications.`,
 		}
+	case "apps.check-name":
+		return KeyStrings{"check-name <APPNAME>", "Check whether an app name is valid and available",
+			`Check whether an app name can be used for a new application.
+Exits successfully if the name is available. Otherwise fails with the 
+FLY_INVALID_NAME error code if the name isn't valid, or FLY_NAME_TAKEN 
+if another application already has it.`,
+		}
 	case "apps.create":
 		return KeyStrings{"create [APPNAME]", "Create a new application",
 			`The APPS CREATE command will both register a new application 
 with the Fly platform and create the fly.toml file which controls how 
 the application will be deployed. The --builder flag allows a cloud native 
 buildpack to be specified which will be used instead of a Dockerfile to 
-create the application image when it is deployed.`,
+create the application image when it is deployed. The --generate-name 
+flag picks a random name which isn't already taken.
+
+Invalid names fail with the FLY_INVALID_NAME error code and names which 
+are already taken with FLY_NAME_TAKEN.`,
 		}
 	case "apps.destroy":
 		return KeyStrings{"destroy [APPNAME]", "Permanently destroys an app",
 			`The APPS DESTROY command will remove an application 
-from the Fly platform.`,
+from the Fly platform.
+Volumes and the data on them, IP addresses, certificates and, for Postgres 
+clusters, databases are destroyed with the app, and are listed first. The 
+app's name must be typed to confirm, unless --yes is passed. Use --dry-run 
+to only list what would be destroyed.`,
 		}
 	case "apps.list":
 		return KeyStrings{"list", "List applications",
@@ -32,7 +105,24 @@ be shown with its name, owner and when it was last deployed.`,
 	case "apps.move":
 		return KeyStrings{"move [APPNAME]", "Move an app to another organization",
 			`The APPS MOVE command will move an application to another 
-organization the current user belongs to.`,
+organization the current user belongs to.
+Before moving, reports what moves with the app, such as volumes, IP 
+addresses, certificates and secrets, and what needs attention, such as 
+attached databases left in the old organization's private network.`,
+		}
+	case "apps.rename":
+		return KeyStrings{"rename <NEW-NAME>", "Copy an app to a new name",
+			`Apps can't be renamed in place, so APPS RENAME creates an app 
+with the new name in the same organization, with volumes restored from their 
+latest snapshots and new IP addresses of the same types. The current image is 
+deployed to it with the app's config when the app has no secrets, which can't 
+be read to copy them. If a volume or IP address can't be created, the new app 
+is destroyed again. The fly.toml is updated to the new name. The old app keeps 
+running until it's destroyed, and keeps its certificates until they're moved, 
+as a hostname's certificate can only belong to one app.
+
+What's copied and what needs attention, such as secrets and DNS, is reported 
+first. Use --dry-run to only report it.`,
 		}
 	case "apps.restart":
 		return KeyStrings{"restart [APPNAME]", "Restart an application",
@@ -42,15 +132,37 @@ organization the current user belongs to.`,
 		return KeyStrings{"resume [APPNAME]", "Resume an application",
 			`The APPS RESUME command will restart a previously suspended application. 
 The application will resume with its original region pool and a min count of one
-meaning there will be one running instance once restarted. Use SCALE SET MIN= to raise
-the number of configured instances.`,
+meaning there will be one running instance once restarted. It's then scaled 
+back to the number of VMs each process group ran before, which SUSPEND saves 
+on the machine it's run on. When that wasn't saved on this machine, the app 
+is left with one instance and a warning is shown. Then use SCALE COUNT to 
+raise the number of configured instances.`,
 		}
 	case "apps.suspend":
 		return KeyStrings{"suspend [APPNAME]", "Suspend an application",
 			`The APPS SUSPEND command will suspend an application. 
 All instances will be halted leaving the application running nowhere.
-It will continue to consume networking resources (IP address). See APPS RESUME
-for details on restarting it.`,
+It will continue to consume networking resources (IP address). Volumes and 
+config are kept too. The number of VMs each process group runs is saved locally 
+so APPS RESUME brings the same scale back. See APPS RESUME for details on 
+restarting it.`,
+		}
+	case "audit":
+		return KeyStrings{"audit", "Show an organization's audit log",
+			`Show the audit log of an organization: deploys, secret changes, 
+scaling and member changes, with when they happened, who made them and from 
+which IP address.
+
+Entries from the past 24 hours are shown, newest first. Use --since and 
+--until with RFC3339 times or durations ago to choose another range, --type 
+to only show some kinds of entry and --limit to show more or fewer, 0 for all 
+of them:
+
+  flyctl audit --org my-org --since 2021-06-01T00:00:00Z --type deploy,secrets
+
+Use --json to export entries, including their metadata, e.g. into a SIEM:
+
+  flyctl audit --org my-org --since 168h --limit 0 --json > audit.json`,
 		}
 	case "auth":
 		return KeyStrings{"auth", "Manage authentication",
@@ -68,12 +180,38 @@ the docker cli.`,
 		return KeyStrings{"login", "Log in a user",
 			`Logs a user into the Fly platform. Supports browser-based, 
 email/password and one-time-password authentication. Defaults to using 
-browser-based authentication.`,
+browser-based authentication.
+
+Use --device on machines without a browser, such as over SSH. A code is 
+printed to enter at a URL in a browser on any other device.
+
+Use --profile to log in to a named profile rather than replacing the default 
+credentials, e.g. flyctl auth login --profile work. Commands use a profile's 
+credentials when it's selected with --profile or FLY_PROFILE.
+
+Access tokens are stored in the OS keyring (the macOS Keychain, Windows 
+Credential Manager or the Secret Service on Linux) when there is one, 
+otherwise in the config file. Set credential_store in the config file or 
+FLY_CREDENTIAL_STORE to keyring to require the keyring, or to file to keep 
+tokens in the config file. A token already in the config file is moved to 
+the keyring.
+
+When the API rejects the access token part way through a command, as when 
+it has expired or been revoked, flyctl offers to log in again in a terminal 
+rather than failing. Tokens from FLY_ACCESS_TOKEN, FLY_API_TOKEN or 
+--access-token are left for you to replace.`,
 		}
 	case "auth.logout":
 		return KeyStrings{"logout", "Logs out the currently logged in user",
 			`Log the currently logged-in user out of the Fly platform. 
-To continue interacting with Fly, the user will need to log in again.`,
+To continue interacting with Fly, the user will need to log in again.
+
+With --profile the named profile is removed instead.`,
+		}
+	case "auth.profiles":
+		return KeyStrings{"profiles", "List profiles",
+			`Lists the named profiles logged in to with flyctl auth login --profile, 
+marking the one selected with --profile or FLY_PROFILE.`,
 		}
 	case "auth.signup":
 		return KeyStrings{"signup", "Create a new fly account",
@@ -82,8 +220,11 @@ and sends the user to a form to provide appropriate credentials.`,
 		}
 	case "auth.token":
 		return KeyStrings{"token", "Show the current auth token",
-			`Shows the authentication token that is currently in use. 
-This can be used as an authentication token with API services, 
+			`Shows the authentication token that is currently in use, 
+and where it comes from. On a terminal the token is masked unless --reveal 
+is passed, which asks to confirm first, or doesn't with --yes. When the 
+output is piped or redirected, as in $(flyctl auth token), the whole token 
+is printed. It can be used as an authentication token with API services, 
 independent of flyctl.`,
 		}
 	case "auth.whoami":
@@ -100,7 +241,11 @@ authenticated and in use.`,
 			`Configure the app to balance regions based on traffic with given parameters:
 
 min=int - minimum number of instances to be allocated from region pool. 
-max=int - maximum number of instances to be allocated from region pool.`,
+max=int - maximum number of instances to be allocated from region pool.
+target=int - target concurrency per instance to scale on.
+type=connections|requests - what target concurrency counts.
+REGION.min=int - minimum number of instances in a region, e.g. ams.min=2.
+REGION.weight=int - share of instances placed in a region, e.g. ams.weight=3.`,
 		}
 	case "autoscale.disable":
 		return KeyStrings{"disable", "Disable autoscaling",
@@ -108,21 +253,84 @@ max=int - maximum number of instances to be allocated from region pool.`,
 		}
 	case "autoscale.set":
 		return KeyStrings{"set", "Set current models autoscaling parameters",
-			`Allows the setting of the current models autoscaling parameters:
+			`Allows the setting of the current models autoscaling parameters.
+Without parameters, applies the [autoscaling] section of fly.toml, replacing
+any per-region settings not listed there, after asking to confirm, or not
+with --yes:
 
 min=int - minimum number of instances to be allocated from region pool. 
-max=int - maximum number of instances to be allocated from region pool.`,
+max=int - maximum number of instances to be allocated from region pool.
+target=int - target concurrency per instance to scale on.
+type=connections|requests - what target concurrency counts.
+REGION.min=int - minimum number of instances in a region, e.g. ams.min=2.
+REGION.weight=int - share of instances placed in a region, e.g. ams.weight=3.`,
 		}
 	case "autoscale.show":
 		return KeyStrings{"show", "Show current autoscaling configuration",
-			`Show current autoscaling configuration`,
+			`Show current autoscaling configuration. With --toml, prints it as an
+[autoscaling] section to add to fly.toml.`,
 		}
 	case "autoscale.standard":
 		return KeyStrings{"standard", "Configure a standard balanced app with params (min=int max=int)",
 			`Configure the app without traffic balancing with the given parameters:
 
 min=int - minimum number of instances to be allocated from region pool. 
-max=int - maximum number of instances to be allocated from region pool.`,
+max=int - maximum number of instances to be allocated from region pool.
+target=int - target concurrency per instance to scale on.
+type=connections|requests - what target concurrency counts.
+REGION.min=int - minimum number of instances in a region, e.g. ams.min=2.
+REGION.weight=int - share of instances placed in a region, e.g. ams.weight=3.`,
+		}
+	case "build":
+		return KeyStrings{"build", "Inspect how apps are built",
+			`Commands for inspecting how an app's image is built from source.`,
+		}
+	case "build.plan":
+		return KeyStrings{"plan [<workingdirectory>]", "Show the cached build plan for a directory",
+			`Shows the build plan flyctl deploy uses for a directory: the builder 
+chosen (buildpacks, a Dockerfile or a builtin), the Dockerfile's hash, the 
+build args and the target stage.
+
+Plans are cached per directory. Deploys reuse the cached plan while the 
+[build] section, the Dockerfile's contents and the build args are unchanged, 
+and resolve and cache a new plan when any of them change.
+
+Use --explain to show why the builder was chosen and what it builds with, 
+and --refresh to discard the cached plan.`,
+		}
+	case "builders":
+		return KeyStrings{"builders", "Work with remote builders",
+			`Work with the remote builders that build images for an organization`,
+		}
+	case "builders.keep-warm":
+		return KeyStrings{"keep-warm", "Keep a remote builder from scaling to zero",
+			`Keep an organization's remote builder running for the --duration of a
+work session, 2h by default and up to 12h, so deploys made one after another
+don't wait for the builder to start. The builder is the one builds are
+pinned to, or --builder-app, or else the organization's default builder.
+Once the duration is up the builder scales to zero when it's idle, as usual.
+Use --stop to let it scale to zero straight away.`,
+		}
+	case "builders.pin":
+		return KeyStrings{"pin [<builder app>]", "Pin an organization's builds to a builder app",
+			`Pin an organization's remote builds to a builder app of its own, such
+as one with a bigger disk or in a region closer to its developers, in place of
+the builder flyctl picks. Without a builder app, shows the builder builds are
+pinned to. The builder must be in the same organization as the apps it
+builds. A deploy's --remote-builder-app flag overrides the pinned builder.`,
+		}
+	case "builders.stats":
+		return KeyStrings{"stats", "Show remote builder usage",
+			`Show daily usage of an organization's remote builders over the
+past month, or the number of days given by --days: builds per day, average
+build duration, layer cache hit rate and average time builds spent queued
+waiting for a builder. Long queue waits suggest keeping builders warm, long
+durations with high cache hit rates suggest a larger builder.`,
+		}
+	case "builders.unpin":
+		return KeyStrings{"unpin", "Unpin an organization's builds",
+			`Unpin an organization's remote builds, so they use the builder flyctl
+picks again.`,
 		}
 	case "builds":
 		return KeyStrings{"builds", "Work with Fly builds",
@@ -166,12 +374,19 @@ certificates issued for the hostname/domain by Let's Encrypt.`,
 	case "certs.add":
 		return KeyStrings{"add <hostname>", "Add a certificate for an app.",
 			`Add a certificate for an application. Takes a hostname 
-as a parameter for the certificate.`,
+as a parameter for the certificate.
+
+Prints the DNS records to add for the hostname: a CNAME to the app for
+subdomains, A and AAAA records for apex and wildcard domains, and the
+_acme-challenge CNAME used to validate ownership. Wildcard certificates
+require the _acme-challenge record. Use --watch to wait until the
+certificate has been issued.`,
 		}
 	case "certs.check":
 		return KeyStrings{"check <hostname>", "Checks DNS configuration",
 			`Checks the DNS configuration for the specified hostname. 
-Displays results in the same format as the SHOW command.`,
+Lists the DNS records needed for the certificate and whether each one is 
+configured. Use --watch to wait until the certificate has been issued.`,
 		}
 	case "certs.list":
 		return KeyStrings{"list", "List certificates for an app.",
@@ -185,36 +400,77 @@ as a parameter to locate the certificate.`,
 	case "certs.show":
 		return KeyStrings{"show <hostname>", "Shows certificate information",
 			`Shows certificate information for an application. 
-Takes hostname as a parameter to locate the certificate.`,
+Takes hostname as a parameter to locate the certificate. Certificates that 
+haven't been issued yet also list the DNS records still to be added.`,
 		}
 	case "checks":
 		return KeyStrings{"checks", "Manage health checks",
-			`Manage health checks`,
+			`Show the state of an app's health checks and manage the handlers 
+which notify an organization when checks fail.`,
 		}
 	case "checks.handlers":
 		return KeyStrings{"handlers", "Manage health check handlers",
-			`Manage health check handlers`,
+			`Manage health check handlers, which post to Slack or trigger 
+PagerDuty incidents when an organization's health checks fail.`,
 		}
 	case "checks.handlers.create":
 		return KeyStrings{"create", "Create a health check handler",
-			`Create a health check handler`,
+			`Create a health check handler of --type slack or pagerduty. 
+Anything not given with flags is prompted for:
+
+  flyctl checks handlers create --type slack -o my-org --name ops \
+    --webhook-url https://hooks.slack.com/services/... --slack-channel '#ops'
+
+  flyctl checks handlers create --type pagerduty -o my-org --name oncall \
+    --pagerduty-token <integration key>`,
 		}
 	case "checks.handlers.delete":
 		return KeyStrings{"delete <organization> <handler-name>", "Delete a health check handler",
 			`Delete a health check handler`,
 		}
 	case "checks.handlers.list":
-		return KeyStrings{"list", "List health check handlers",
-			`List health check handlers`,
+		return KeyStrings{"list [<organization>]", "List health check handlers",
+			`List an organization's health check handlers. The organization 
+is given as an argument or with --organization, or chosen from a list.`,
 		}
 	case "checks.list":
 		return KeyStrings{"list", "List app health checks",
-			`List app health checks`,
+			`List the live state of the app's TCP, HTTP and script health 
+checks, grouped by instance: their status, how long they've had it, when they 
+last ran and a summary of their last output. Use --full-output for the 
+complete output, --status to only list checks with a status, e.g. critical, 
+and --check-name to only list checks with a name.`,
+		}
+	case "completion":
+		return KeyStrings{"completion <bash|zsh|fish|powershell>", "Generate shell completion scripts",
+			`Generate a completion script for bash, zsh, fish or PowerShell. Besides
+commands and flags, app names and organizations are completed from the API, and
+regions and VM sizes from the cached platform catalog, so they complete offline.
+
+To load completions in the current bash session:
+
+  source <(flyctl completion bash)
+
+For zsh, add the script to a directory in your $fpath:
+
+  flyctl completion zsh > "${fpath[1]}/_flyctl"`,
 		}
 	case "config":
 		return KeyStrings{"config", "Manage an app's configuration",
 			`The CONFIG commands allow you to work with an application's configuration.`,
 		}
+	case "config.convert":
+		return KeyStrings{"convert", "Convert an app's config file to TOML, YAML or JSON",
+			`Convert an application's config file to another format, given with 
+--to: toml, yaml or json. fly.yaml, fly.yml and fly.json are read anywhere 
+fly.toml is, and hold the same sections, so a config converts back and forth 
+without changes.
+
+The new file is written next to the existing one, with the same name and the 
+new format's extension, unless --file is given. In a directory with more 
+than one, fly.toml is read first, then fly.yaml, fly.yml and fly.json, so 
+remove the old file once the new one is in place.`,
+		}
 	case "config.display":
 		return KeyStrings{"display", "Display an app's configuration",
 			`Display an application's configuration. The configuration is presented 
@@ -225,15 +481,75 @@ in JSON format. The configuration data is retrieved from the Fly service.`,
 			`Display an app's runtime environment variables. It displays a section for
 secrets and another for config file defined environment variables.`,
 		}
+	case "config.list":
+		return KeyStrings{"list", "List the app config files in this directory tree",
+			`Find fly.toml and other fly*.toml, fly*.yaml and fly*.json config files
+under the working directory, showing the app each one targets, how its image is built and the
+processes it defines. Pass a file to other commands with --config, such as
+'flyctl deploy -c fly.worker.toml', to use its app name and build settings.`,
+		}
 	case "config.save":
 		return KeyStrings{"save", "Save an app's config file",
 			`Save an application's configuration locally. The configuration data is 
-retrieved from the Fly service and saved in TOML format.`,
+retrieved from the Fly service and saved in TOML format, or in YAML or JSON 
+when the app's config file is fly.yaml or fly.json.
+
+The file is canonical, so saving the same config always writes the same file: 
+keys are sorted and settings left at the platform's defaults are commented 
+with # default. Use it to adopt config-as-code for apps created in the 
+dashboard or with the API. The [build] section, which isn't deployed, is kept 
+from an existing file.`,
+		}
+	case "config.show":
+		return KeyStrings{"show", "Show an app's configuration as fly.toml",
+			`Show an application's configuration, as retrieved from the Fly service, in
+fly.toml format, followed by the [[statics]] Fly's proxy serves from the
+image. Use --json to show it as JSON, like config display.`,
 		}
 	case "config.validate":
 		return KeyStrings{"validate", "Validate an app's config file",
 			`Validates an application's config file against the Fly platform to 
-ensure it is correct and meaningful to the platform.`,
+ensure it is correct and meaningful to the platform. 
+
+Entries in the [env] section and ENV or ARG lines in the Dockerfile that look 
+like secrets, by their name or a random looking value, are reported as 
+warnings. Set those with flyctl secrets set instead.`,
+		}
+	case "console":
+		return KeyStrings{"console", "Run a temporary machine with the app's image and open a shell",
+			`Boot a temporary machine from the image of the app's current release,
+or the image given with --image, and open an interactive shell in it over
+WireGuard. The machine runs alongside the app's instances without receiving
+traffic, and is destroyed when the shell exits. Use --command to run something
+other than /bin/sh, such as a language REPL.`,
+		}
+	case "cron":
+		return KeyStrings{"cron <command>", "Run the app's image on a schedule",
+			`Commands that manage scheduled machines, which start on a cron schedule,
+run a command in the app's image to completion and stop until the next run.`,
+		}
+	case "cron.create":
+		return KeyStrings{"create <schedule>", "Create a machine that runs a command on a schedule",
+			`Create a machine that runs --command on the given cron schedule, using
+the image of the app's current release unless --image is given. The schedule
+is a five field cron expression of minute, hour, day of month, month and day
+of week, in UTC, or one of @hourly, @daily, @weekly, @monthly and @yearly.
+
+e.g. flyctl cron create "0 3 * * *" --command "bin/backup"`,
+		}
+	case "cron.delete":
+		return KeyStrings{"delete <id>", "Delete a scheduled machine",
+			`Delete a scheduled machine, stopping any run in progress.`,
+		}
+	case "cron.history":
+		return KeyStrings{"history <id>", "Show the recent runs of a scheduled machine",
+			`Show when a scheduled machine recently ran, for how long and whether
+its command succeeded. Use --logs to also print its recent logs.`,
+		}
+	case "cron.list":
+		return KeyStrings{"list", "List scheduled machines",
+			`List the app's scheduled machines with their schedule, command, next
+run and the status of their last run.`,
 		}
 	case "curl":
 		return KeyStrings{"curl <url>", "Run a performance test against a url",
@@ -244,8 +560,12 @@ ensure it is correct and meaningful to the platform.`,
 			`Open web browser on Fly Web UI for this application`,
 		}
 	case "dashboard.metrics":
-		return KeyStrings{"metrics", "Open web browser on Fly Web UI for this app's metrics",
-			`Open web browser on Fly Web UI for this application's metrics`,
+		return KeyStrings{"metrics", "Chart this app's CPU, memory and network metrics",
+			`Chart per instance CPU, memory (RSS) and network usage for this 
+application in the terminal. The time window charted is set with --window.
+
+Use --json to export the raw series and --web to open the metrics page of 
+the Fly Web UI instead.`,
 		}
 	case "deploy":
 		return KeyStrings{"deploy [<workingdirectory>]", "Deploy an app to the Fly platform",
@@ -256,20 +576,254 @@ Use the --config/-c flag to select a specific toml configuration file.
 
 Use the --image/-i flag to specify a local or remote image to deploy.
 
+Local builds pull private base images with the credentials docker has for
+each registry: those saved by docker login in ~/.docker/config.json, the
+credsStore, and credHelpers for particular registries, such as ecr-login for
+ECR or gcloud for GCR. Docker Hub credentials can also be given with
+DOCKER_HUB_USERNAME and DOCKER_HUB_PASSWORD, which take precedence.
+
+Remote builders only get credentials for Fly's registry and
+DOCKER_HUB_USERNAME, unless registries are listed with
+--forward-registry-auth, or comma separated in FLY_FORWARD_REGISTRY_AUTH:
+
+  flyctl deploy --forward-registry-auth ghcr.io
+
+Forwarded credentials are sent over WireGuard with the build and answer the
+builder's requests only while the build runs. They aren't stored on the
+builder.
+
+Use --verify-signature with the path of a cosign public key to deploy a
+pre-built image only when it has been signed with that key, e.g.
+
+  flyctl deploy --image registry.example/app@sha256:... --verify-signature cosign.pub
+
+The image's digest is resolved from its registry, the cosign signature stored
+alongside it is verified, and the release uses the image by digest. Pass the
+image by digest so the signed image is the one released.
+
+Use --sign-with with the path of a cosign private key to sign the image once
+it's pushed, storing the signature in the registry where cosign and
+--verify-signature look for it. Keys encrypted by cosign generate-key-pair are
+decrypted with $COSIGN_PASSWORD, or a password prompted for.
+
+Use --sbom to generate a software bill of materials for the image once it's
+pushed and attach it to the image in the registry, in CycloneDX or, with
+--sbom-format spdx, SPDX JSON. SBOMs are generated by syft
+(https://github.com/anchore/syft), which needs to be installed locally. Show
+an image's SBOM with flyctl image sbom.
+
 Use the --detach flag to return immediately from starting the deployment rather
 than monitoring the deployment progress.
 
+In GitHub Actions workflows, where GITHUB_ACTIONS is true, the image build
+and deployment logs are grouped, and failures are annotated on the run,
+with configuration errors annotated on the line of fly.toml they refer to.
+The release is written to $GITHUB_OUTPUT as the step outputs release_id,
+release_version, image, image_digest and app_url, for later steps to use.
+
+Use --plan to review a deploy before it's made. Nothing is built, pushed or
+released; instead the API mutations the deploy would make are printed to
+stdout as a JSON plan, e.g.
+
+  flyctl deploy --plan > plan.json
+  flyctl deploy --apply plan.json
+
+Pre-built images are pinned to their digest in the plan. Images built from
+source are built when the plan is applied, from the git commit it was made
+at. --apply refuses plans made before the app's latest release or a change to
+its secrets or configuration.
+
+The [deploy] section can run commands with the new image around a deployment:
+
+  [deploy]
+    release_command = "bin/migrate"
+    post_deploy = "bin/notify-deploy"
+
+The release command runs in a one-off VM before any instances are replaced,
+with its output streamed to the terminal. If it fails the release is aborted,
+leaving the running instances as they were. The post-deploy command runs in a
+one-off machine once the new instances are deployed, with FLY_RELEASE_VERSION
+set to the release's version. It doesn't run with --detach, and if it fails
+flyctl exits with FLY_POST_DEPLOY_FAILED, though the release stays deployed.
+
+Deploy several apps at once with a comma separated list, --app api,worker, or
+the apps listed in a workspace file with --workspace fly.workspace.toml:
+
+  concurrency = 2
+
+  [[apps]]
+    config = "services/api/fly.toml"
+
+  [[apps]]
+    name = "web-staging"
+    config = "services/web/fly.toml"
+
+Each app is deployed with its own config, relative to the workspace file, and
+its name defaults to the app in it. Apps built from the same directory,
+Dockerfile, [build] section and build args share one image, built once and
+deployed to each of them. Apps are deployed concurrently, concurrency at a
+time if it's set, with each line of output prefixed by its app and a summary
+once they're all done.
+
+Apps built with Cloud Native Buildpacks set the builder image and buildpacks
+in the [build] section, with environment variables for the build in
+[build.env], e.g.
+
+  [build]
+    builder = "paketobuildpacks/builder:base"
+    buildpacks = ["gcr.io/paketo-buildpacks/go"]
+    [build.env]
+      BP_GO_TARGETS = "./cmd/server"
+
+Set builder = "nixpacks" to build apps without a Dockerfile or buildpacks
+using nixpacks (https://nixpacks.com), which needs to be installed locally. It
+generates a Dockerfile for the app, which is built on the local docker daemon
+or a remote builder, with [build.env] passed to nixpacks.
+
+Files in the image can be served directly by Fly's proxy, without reaching
+the app, with [[statics]] sections mapping URL prefixes to paths in the image:
+
+  [[statics]]
+    guest_path = "/app/public"
+    url_prefix = "/public"
+
+Deploys check each guest_path exists in the image once it's built, failing
+before the release is created when one doesn't. Images pulled straight from
+a registry aren't on a docker daemon, so their paths can't be checked.
+
+Use --dockerfile to build with a Dockerfile other than the one in the working
+directory, --build-target to build one stage of a multi-stage Dockerfile, and
+--build-context to send another directory, such as the root of a monorepo, to
+the builder as the build context. The [build] section sets the same with
+dockerfile, build-target and context, with paths relative to fly.toml.
+
+Use --builder and --buildpack to build with others for one deploy.
+
+Files matching .dockerignore, or .flyignore when there's no .dockerignore, are
+left out of the build context. Deploys print the size of the context and warn
+when it's over 200MB, listing what's making it large. Set
+build_context_warn_size in the flyctl config, or FLY_BUILD_CONTEXT_WARN_SIZE,
+to the size in megabytes to warn at, or 0 to not warn.
+
+Builders with BuildKit, including remote builders, keep the context from the
+last build of the directory, so later builds only send the files which have
+changed since.
+
+Build args are passed to buildpacks as environment variables too. Buildpacks run on the
+local docker daemon or a remote builder, the same as Dockerfiles.
+
+Releases record the git commit, branch and whether there were uncommitted 
+changes, along with who deployed them: the user's email, where the API token 
+came from (FLY_ACCESS_TOKEN, FLY_API_TOKEN, a profile or the saved config) and 
+the CI provider, if any. These are shown by flyctl releases.
+
+Use 
+--only-if-changed with paths relative to the working directory to skip the 
+deploy when nothing under them, or in the app config, has changed since the 
+current release's commit, e.g. in a monorepo:
+
+  flyctl deploy --only-if-changed services/api,lib
+
+Uncommitted and untracked files count as changes. Skipped deploys exit 0 
+with a message saying nothing changed. Deploys go ahead when the current 
+release has no recorded commit, or the commit isn't in the local repository, 
+such as in a shallow clone.
+
+Use the --record-to flag to write a JSON manifest of the release (image, image 
+digest, config hash, release version and git commit) to a directory or file 
+once the release is created. A git:<branch> destination commits the manifest 
+to that branch of the repository instead, optionally under a directory, as in 
+git:deployments:apps/web, without touching the work tree or the checked out 
+branch. The branch is created if need be, and isn't pushed.
+
+--build-mode picks where the image is built: local with the local docker
+daemon, remote on a remote builder, or auto, the default, which builds
+locally when docker is running and remotely otherwise. In auto mode, if the
+remote builder doesn't start in time and docker has started locally since,
+the build falls back to the local daemon rather than failing the deploy.
+--local-only and --remote-only are the same as --build-mode local and remote.
+
+Remote builders get 5 minutes to start, or the time given with
+--remote-builder-timeout or FLY_REMOTE_BUILDER_TIMEOUT. When a builder fails
+to start, the last 50 lines of its logs are shown with the error. When a
+running builder doesn't answer for 30 seconds, the connection to it is
+diagnosed: the WireGuard handshake, the builder's .internal DNS name, then
+its docker port. The deploy stops at the first broken layer, with a hint
+on how to fix it, rather than waiting out the timeout.
+
+Remote builds use the builder the app's organization is pinned to with
+flyctl builders pin, or else the builder flyctl picks for the app. Use
+--remote-builder-app to build on another builder app in the organization,
+which implies --remote-only.
+
+Heavy builds can ask for a bigger remote builder with --remote-builder-size,
+--remote-builder-memory (MB) and --remote-builder-disk (GB), or with size,
+memory and disk in a [build.remote_builder] section of fly.toml, which the
+flags override. The builder is resized before the build when it differs,
+and each change is reported. Its volume is only ever grown.
+
+Use the --resume flag to retry the release after a deploy failed once its 
+image had been pushed, for example on a health check timeout. The build and 
+push are skipped and the already pushed image is released again.
+
+Image layers are pushed concurrently, with a single progress line for the
+whole image and a summary of the bytes uploaded and throughput once it's
+pushed. Docker retries each layer that fails to upload, and if the push
+still fails it's made again, up to 3 times, uploading only the layers that
+aren't already in the registry.
+
+When a local docker daemon uses the containerd image store, which keeps
+layers compressed, those retries are uploaded by flyctl in 16MB chunks, so a
+dropped connection resumes the layer from the last chunk the registry
+received rather than starting it over. Layers already pushed for another
+app are mounted from it rather than uploaded again.
+
+Use --quiet/-q to only print the result of the deployment and any errors, 
+or -v for extra detail such as image IDs and every instance status change. 
+-vv adds debug logging from the build, push and release phases.
+
+Process groups in the [processes] section of fly.toml can be given as a table
+with a command and the group's count, vm_size and memory, which are applied
+once the release is created, e.g.
+
+  [processes.worker]
+    command = "bin/worker"
+    count   = 3
+    vm_size = "shared-cpu-2x"
+
+Before building, env entries and ENV or ARG lines in the Dockerfile that look 
+like secrets are reported as warnings, as they'd be stored in plain text in 
+the config or image. Set those with flyctl secrets set instead.
+
+The builder chosen for the working directory is cached as a build plan and 
+reused while its inputs are unchanged. See flyctl build plan.
+
 Use flyctl monitor to restart monitoring deployment progress`,
 		}
 	case "destroy":
 		return KeyStrings{"destroy [APPNAME]", "Permanently destroys an app",
 			`The DESTROY command will remove an application 
-from the Fly platform.`,
+from the Fly platform.
+Volumes and the data on them, IP addresses, certificates and, for Postgres 
+clusters, databases are destroyed with the app, and are listed first. The 
+app's name must be typed to confirm, unless --yes is passed. Use --dry-run 
+to only list what would be destroyed.`,
 		}
 	case "dns-records":
 		return KeyStrings{"dns-records", "Manage DNS records",
 			`Manage DNS records within a domain`,
 		}
+	case "dns-records.create":
+		return KeyStrings{"create <domain> <type> <name> <value>", "Create a DNS record",
+			`Create an A, AAAA, CNAME, MX or TXT record in a domain. Use @ as the
+name for the domain itself. MX values are a priority and a hostname, like
+"10 mail.example.com". TXT values are quoted when they aren't already.`,
+		}
+	case "dns-records.delete":
+		return KeyStrings{"delete <domain> <record>", "Delete a DNS record",
+			`Delete a DNS record. The record is given by its ID or its name, with
+--type when the name has records of several types.`,
+		}
 	case "dns-records.export":
 		return KeyStrings{"export <domain> [<filename>]", "Export DNS records",
 			`Export DNS records. Will write to a file if a filename is given, otherwise
@@ -278,28 +832,67 @@ writers to StdOut.`,
 	case "dns-records.import":
 		return KeyStrings{"import <domain> [<filename>]", "Import DNS records",
 			`Import DNS records. Will import from a file is a filename is given, otherwise
-imports from StdIn.`,
+imports from StdIn.
+
+Files are in BIND zone file format, as written by export, so a zone kept in
+source control can be synced by importing it. The report lists the records
+created, updated and deleted to match the file.`,
 		}
 	case "dns-records.list":
 		return KeyStrings{"list <domain>", "List DNS records",
 			`List DNS records within a domain`,
 		}
+	case "dns-records.update":
+		return KeyStrings{"update <domain> <record>", "Update a DNS record",
+			`Update the value or TTL of a DNS record. The record is given by its ID or
+its name, with --type when the name has records of several types.`,
+		}
 	case "docs":
 		return KeyStrings{"docs", "View Fly documentation",
 			`View Fly documentation on the Fly.io website. This command will open a 
 browser to view the content.`,
 		}
+	case "doctor":
+		return KeyStrings{"doctor", "Check that flyctl can reach everything it needs",
+			`Run diagnostic checks of local Docker, IPv4 and IPv6 routes, the HTTPS
+proxy, reaching the Fly API, the access token, WireGuard connectivity,
+resolving an app's .internal address and the organization's remote builder.
+Each check passes or fails with a hint on how to fix it. Use --json for a
+report to attach to support requests.
+
+The checks don't create anything: WireGuard is checked with the peer already
+set up for the organization, and the remote builder only when it has one.
+Each is skipped when there's none yet.
+
+On IPv6-only networks the network check looks for the NAT64 prefix used to
+reach IPv4 WireGuard gateways. The proxy check connects to the proxy
+HTTPS_PROXY or HTTP_PROXY sets for the API, unless NO_PROXY excludes it.`,
+		}
+	case "doctor.bundle":
+		return KeyStrings{"bundle", "Collect diagnostics into a bundle for support requests",
+			`Collect version information, the results of the doctor checks, the
+log of recent commands, flyctl's config, fly.toml and FLY_ environment variables
+into a tarball to attach to a support request. Values that look like secrets
+are redacted, and the contents can be reviewed, viewed and trimmed before the
+file is written. Use --yes to write it without reviewing, and --file to choose
+where it's written.`,
+		}
 	case "domains":
 		return KeyStrings{"domains", "Manage domains",
 			`Manage domains`,
 		}
 	case "domains.add":
 		return KeyStrings{"add [org] [name]", "Add a domain",
-			`Add a domain to an organization`,
+			`Add a domain to an organization, creating a zone for it on Fly's DNS.
+Manage the zone's records with the dns-records commands once the domain's
+nameservers, listed by domains show, are delegated to Fly.`,
 		}
 	case "domains.list":
-		return KeyStrings{"list [<org>]", "List domains",
-			`List domains for an organization`,
+		return KeyStrings{"list [<org>]", "List domains and hostnames",
+			`List every hostname in an organization: the app with a certificate
+for it, the certificate's status and expiry, whether its DNS points at the app,
+and the organization's domain it belongs to. Domains without any certificates
+are listed too. The organization can be given as an argument or with --org.`,
 		}
 	case "domains.register":
 		return KeyStrings{"register [org] [name]", "Register a domain",
@@ -322,6 +915,37 @@ Deploy an app with the deploy command
 View a deployed web application with the open command
 Check the status of an application with the status command
 
+Errors flyctl recognizes, such as invalid flags, API errors, and build 
+and deploy failures, carry a stable code, such as FLY_BUILD_TIMEOUT or 
+FLY_HEALTHCHECK_FAILED, which sets the exit code and is written as JSON 
+with --json, so scripts can tell failures apart. Other errors are 
+FLY_UNKNOWN and exit with 1. Interrupted commands and declined 
+confirmations are FLY_CANCELLED and exit with 130, where they used to exit 
+with 1.
+
+Confirmations are accepted with the global --yes flag, or --auto-confirm,
+or by setting FLY_AUTO_CONFIRM=true. flyctl doesn't prompt when CI is true
+or it isn't running in a terminal: commands that would prompt fail with
+FLY_PROMPT_REQUIRED, explaining the flag that answers the prompt instead.
+
+Outside a terminal, long steps such as waiting for a remote builder, pushing
+an image or monitoring a rollout print a heartbeat line with the time taken
+every 15 seconds, so CI logs don't look stalled. --progress, or FLY_PROGRESS,
+picks how progress is shown: tty for spinners, plain for heartbeat lines,
+json for one JSON object per line on stderr, or none.
+
+Remote builds, ssh, console and other commands reach Fly's private network
+over WireGuard, which runs over UDP. When no handshake completes over UDP
+within 5 seconds, as on networks that block it, the tunnel is reconnected
+over WebSockets on the HTTPS port. Use the global --wg-websockets flag, or
+set FLY_WG_WEBSOCKETS=true, to always tunnel over WebSockets.
+
+API requests and WireGuard over WebSockets go through the proxy set with
+HTTPS_PROXY or HTTP_PROXY, except for hosts listed in NO_PROXY, and errors
+name the proxy a failed request went through. On IPv6-only networks IPv4
+WireGuard gateways are reached through the network's NAT64 prefix, found
+with DNS64.
+
 To read more, use the docs command to view Fly's help on the web.`,
 		}
 	case "history":
@@ -329,6 +953,48 @@ To read more, use the docs command to view Fly's help on the web.`,
 			`List the history of changes in the application. Includes autoscaling 
 events and their results.`,
 		}
+	case "image":
+		return KeyStrings{"image", "Inspect app images",
+			`Commands for inspecting the images apps are deployed with.`,
+		}
+	case "image.sbom":
+		return KeyStrings{"sbom", "Show the SBOM attached to an image",
+			`Prints the software bill of materials attached to an image by 
+flyctl deploy --sbom, or writes it to the file given with --file. Shows the 
+SBOM of the image of the app's current release, or the image given with 
+--image, which doesn't need an app.`,
+		}
+	case "import":
+		return KeyStrings{"import", "Import apps from other platforms' configuration",
+			`The IMPORT commands translate configuration from other platforms into
+Fly app configs.`,
+		}
+	case "import.compose":
+		return KeyStrings{"compose [<composefile>]", "Translate a Docker Compose file into Fly apps",
+			`Translate the services of a Docker Compose file into Fly apps, writing
+a config file for each. Without a file, compose.yaml, compose.yml,
+docker-compose.yml or docker-compose.yaml in the working directory is used.
+
+Each service becomes an app named after it, prefixed with --prefix or the
+compose file's directory name. Files are written next to the compose file,
+or to --dir: fly.toml for a single service, otherwise fly.<service>.toml for
+each with a fly.workspace.toml to deploy them together with
+flyctl deploy --workspace.
+
+  - build and image become the [build] section, and command the app's process
+  - published ports become [[services]]. Port 80 gets the http handler and
+    HTTPS on 443; UDP ports need a dedicated IPv4 address
+  - environment becomes [env], apart from variables that look like secrets
+    or have no value, which are listed to set with flyctl secrets set
+  - the first named volume becomes [mounts], to create with flyctl volumes
+    create
+
+Anything that isn't translated, such as bind mounts, port ranges, env_file,
+depends_on, healthcheck or restart, is listed in the report after the files
+are written, with what to do instead. References to other services in
+variables are listed too, as apps reach each other at <app>.internal.
+Existing files aren't replaced without --overwrite.`,
+		}
 	case "info":
 		return KeyStrings{"info", "Show detailed app information",
 			`Shows information about the application on the Fly platform
@@ -346,6 +1012,20 @@ the application will be deployed. The --builder flag allows a cloud native
 buildpack to be specified which will be used instead of a Dockerfile to 
 create the application image when it is deployed.`,
 		}
+	case "interactive":
+		return KeyStrings{"interactive", "Run flyctl commands from an interactive prompt",
+			`Open a prompt that runs flyctl commands without the flyctl prefix, 
+keeping the app and organization between commands. The app starts as the one
+in fly.toml in the working directory, or --app.
+
+  app <name>      set the app commands run against
+  org <slug>      set the organization for commands with an --org flag
+  ? <text>        fuzzy search commands by name and description
+  help <command>  show help for a command
+  exit            leave the prompt
+
+Unknown commands suggest the closest matches.`,
+		}
 	case "ips":
 		return KeyStrings{"ips", "Manage IP addresses for apps",
 			`The IPS commands manage IP addresses for applications. An application 
@@ -355,15 +1035,27 @@ and IPv6 addresses.`,
 		}
 	case "ips.allocate-v4":
 		return KeyStrings{"allocate-v4", "Allocate an IPv4 address",
-			`Allocates an IPv4 address to the application.`,
+			`Allocates an IPv4 address to the application. Addresses are dedicated
+to the app unless --shared is given, which uses a cheaper IPv4 address shared
+with other apps; shared addresses serve HTTP and TLS services only. Use
+--region to announce a dedicated address from a single region.
+
+UDP services, such as game servers, need a dedicated IPv4 address. Their
+packets arrive on the fly-global-services address inside the VM, which UDP
+servers have to bind to.`,
 		}
 	case "ips.allocate-v6":
 		return KeyStrings{"allocate-v6", "Allocate an IPv6 address",
-			`Allocates an IPv6 address to the application.`,
+			`Allocates an IPv6 address to the application. With --private, allocates
+an address on the organization's private 6PN network instead, reachable only
+from within the organization.`,
 		}
 	case "ips.list":
 		return KeyStrings{"list", "List allocated IP addresses",
-			`Lists the IP addresses allocated to the application.`,
+			`Lists the IP addresses allocated to the application, with their type
+and the region they're announced in. Global addresses are announced from every
+region. Apps with UDP services are reminded when they have no dedicated IPv4
+address, as UDP isn't carried on IPv6 or shared addresses.`,
 		}
 	case "ips.private":
 		return KeyStrings{"private", "List instances private IP addresses",
@@ -372,11 +1064,48 @@ Fly network`,
 		}
 	case "ips.release":
 		return KeyStrings{"release [ADDRESS]", "Release an IP address",
-			`Releases an IP address from the application.`,
+			`Releases an IP address from the application. Releasing a shared IPv4
+address stops the app from using it.`,
+		}
+	case "jobs":
+		return KeyStrings{"jobs <command>", "Run batch work on machines",
+			`Commands for running batches of work to completion on machines.`,
+		}
+	case "jobs.submit":
+		return KeyStrings{"submit", "Run a batch of items on machines and report their status",
+			`Run --count items of batch work, each on its own machine running
+--command in the app's current image (or --image). Up to --max-parallel items
+run at once. Each item's machine gets JOB_INDEX, JOB_COUNT and JOB_ATTEMPT
+environment variables so it can pick its share of the work.
+
+An item succeeds when its command exits with code 0. Failed items, including
+those running longer than --timeout, are retried up to --retries times.
+Machines are removed as items finish, and a summary of every item is printed
+at the end. The command fails if any item failed.
+
+e.g. flyctl jobs submit --command "bin/process-shard" --count 10 --max-parallel 3`,
 		}
 	case "launch":
 		return KeyStrings{"launch", "Launch a new app",
-			`Create and configure a new app from source code or an image reference.`,
+			`Create and configure a new app from source code or an image reference.
+
+Use --template to start from a published template: its files are downloaded
+into the app directory, and any secrets or volumes listed in its
+fly-template.toml are set up when the app is created.
+
+Deno, Bun and static HTML sites get a generated Dockerfile, an HTTP health
+check, and suggestions for regions to run in close to users. Static sites are
+served directly by Fly's proxy from the image.
+
+Rails, Phoenix and Django apps get a generated secret key, and are offered a
+Postgres database, which is created next to the app and attached as
+DATABASE_URL. Use --postgres to set one up without asking. Django apps get a
+generated Dockerfile running gunicorn.
+
+Use --now to deploy without asking once the app is created, or --no-deploy
+to only create the app and write its configuration.
+
+Apps that aren't detected are built with nixpacks when it's installed.`,
 		}
 	case "list":
 		return KeyStrings{"list", "Lists your Fly resources",
@@ -411,7 +1140,133 @@ short name of the organization and the long name.`,
 the Fly platform.
 
 Logs can be filtered to a specific instance using the --instance/-i flag or 
-to all instances running in a specific region using the --region/-r flag.`,
+to all instances running in a specific region using the --region/-r flag.
+
+If the connection drops, logs keeps retrying. Once it reconnects, the 
+entries logged in the meantime are shown after a marker for the gap.`,
+		}
+	case "logs.ship":
+		return KeyStrings{"ship", "Ship app logs to external services",
+			`Ship application logs to external services such as Datadog,
+Logtail, S3 or a generic HTTP endpoint.
+
+Logs are shipped by a log shipper app running in the same organization as
+the app. The shipper is created and configured by the SETUP command.`,
+		}
+	case "logs.ship.remove":
+		return KeyStrings{"remove [SINK]", "Remove a sink or the log shipper",
+			`Remove the settings for the named sink from the log shipper. If no
+sink is specified the log shipper app is destroyed.`,
+		}
+	case "logs.ship.setup":
+		return KeyStrings{"setup <SINK> [NAME=VALUE]...", "Ship logs to a sink",
+			`Configure a sink for the app's logs, creating and deploying the log
+shipper app if needed. Sink settings are passed as NAME=VALUE pairs and stored
+as secrets on the log shipper. Missing required settings are prompted for.
+
+The log shipper reads logs with a read-only token for the app's organization,
+created by setup and stored as a secret on it. The token expires after a year;
+run setup again to replace it.
+
+Use the SINKS command to view the available sinks and their settings.`,
+		}
+	case "logs.ship.show":
+		return KeyStrings{"show", "Show configured sinks",
+			`Show the sinks configured on the app's log shipper.`,
+		}
+	case "logs.ship.sinks":
+		return KeyStrings{"sinks", "List available sinks",
+			`List the sinks logs can be shipped to, along with their required and
+optional settings.`,
+		}
+	case "machine":
+		return KeyStrings{"machine", "Run and manage individual machines",
+			`Run and manage individual machines with the Machines API. Machines
+are launched directly from an image and are managed one at a time, separately
+from the VMs created by deploying an app.`,
+		}
+	case "machine.clone":
+		return KeyStrings{"clone <id>", "Launch a copy of a machine",
+			`Launch a new machine with the same image and configuration as an
+existing one, in the region given by --region or the source machine's region.`,
+		}
+	case "machine.exec":
+		return KeyStrings{"exec <id> <command>", "Run a command in a machine",
+			`Run a one-off command in a running machine and print its output.
+The command may be given as separate arguments or as a single quoted string.
+Fails if the command exits with a non-zero status or runs longer than
+--timeout seconds.`,
+		}
+	case "machine.list":
+		return KeyStrings{"list", "List machines",
+			`List an app's machines with their state, region and image. --state
+limits the list to machines in one state.`,
+		}
+	case "machine.remove":
+		return KeyStrings{"remove <id>", "Remove a machine",
+			`Remove a machine. The machine must be stopped unless --force is
+given, which kills it first.`,
+		}
+	case "machine.run":
+		return KeyStrings{"run <image> [command]", "Launch a machine",
+			`Launch a machine running an image, optionally overriding the
+image's command. --cpus, --memory and --cpu-kind size the machine, and
+--region picks where it runs.`,
+		}
+	case "machine.start":
+		return KeyStrings{"start <id>", "Start a stopped machine",
+			`Start a stopped machine`,
+		}
+	case "machine.stop":
+		return KeyStrings{"stop <id>", "Stop a running machine",
+			`Stop a running machine`,
+		}
+	case "machine.update":
+		return KeyStrings{"update --image <image>", "Roll a new image across an app's machines",
+			`Update every machine of an app to a new image, --concurrency
+machines at a time. Each batch must start and pass its health checks within
+--wait-timeout before the next batch is updated; the rollout stops at the
+first machine that doesn't. Stopped machines are updated but not started.`,
+		}
+	case "metrics":
+		return KeyStrings{"metrics", "Query organization metrics and manage metrics tokens",
+			`Commands for querying the Prometheus compatible metrics endpoint of 
+an organization and managing the read-only tokens used to access it from 
+tools such as Grafana.`,
+		}
+	case "metrics.export":
+		return KeyStrings{"export", "Run a PromQL query and print the results",
+			`Run a PromQL query against an organization's metrics and print 
+the results. An instant query is run by default, evaluated now or at --time. 
+Pass --start (and optionally --end and --step) to run a range query instead.
+
+Times can be given as RFC3339 timestamps or as a duration before now, e.g. 1h. 
+Use --json to print the raw series for scripts.`,
+		}
+	case "metrics.gpu":
+		return KeyStrings{"gpu", "Chart GPU utilization and memory of this app's instances",
+			`Chart per instance GPU utilization and GPU memory used for an
+application with GPUs attached, over the time window set with --window.
+Use --json to export the raw series.`,
+		}
+	case "metrics.token":
+		return KeyStrings{"token <command>", "Commands that manage metrics tokens",
+			`Commands that manage read-only tokens for an organization's 
+metrics endpoint.`,
+		}
+	case "metrics.token.create":
+		return KeyStrings{"create [<org>] [<name>]", "Create a metrics token",
+			`Create a read-only token for an organization's metrics 
+endpoint. The token is printed once along with the endpoint URL to use as a 
+Grafana Prometheus data source.`,
+		}
+	case "metrics.token.delete":
+		return KeyStrings{"delete [<org>] [<name>]", "Delete a metrics token",
+			`Delete a metrics token by name, revoking its access.`,
+		}
+	case "metrics.token.list":
+		return KeyStrings{"list [<org>]", "List metrics tokens",
+			`List the metrics tokens of an organization.`,
 		}
 	case "monitor":
 		return KeyStrings{"monitor", "Monitor deployments",
@@ -421,18 +1276,27 @@ to get details of every instance . Control-C to stop output.`,
 	case "move":
 		return KeyStrings{"move [APPNAME]", "Move an app to another organization",
 			`The MOVE command will move an application to another 
-organization the current user belongs to.`,
+organization the current user belongs to.
+Before moving, reports what moves with the app, such as volumes, IP 
+addresses, certificates and secrets, and what needs attention, such as 
+attached databases left in the old organization's private network.`,
 		}
 	case "open":
 		return KeyStrings{"open [PATH]", "Open browser to current deployed application",
 			`Open browser to current deployed application. If an optional path is specified, this is appended to the
-URL for deployed application.`,
+URL for deployed application. A custom domain with a ready certificate is
+preferred over the app's default hostname. The app is checked to respond
+before the browser is opened, unless --no-check is given. Use --print to
+print the URL instead of opening a browser.`,
 		}
 	case "orgs":
 		return KeyStrings{"orgs", "Commands for managing Fly organizations",
 			`Commands for managing Fly organizations. list, create, show and 
 destroy organizations. 
-Organization admins can also invite or remove users from Organizations.`,
+Organization admins can also invite or remove users from Organizations and 
+change their roles.
+
+Every command supports --json output, for scripts auditing membership.`,
 		}
 	case "orgs.create":
 		return KeyStrings{"create <org>", "Create an organization",
@@ -446,7 +1310,9 @@ organization later.`,
 	case "orgs.invite":
 		return KeyStrings{"invite <org> <email>", "Invite user (by email) to organization",
 			`Invite a user, by email, to join organization. The invitation will be
-sent, and the user will be pending until they respond. See also orgs revoke.`,
+sent, and the user will be pending until they respond. See also orgs revoke.
+
+Use --role admin to invite the user as an admin rather than a member.`,
 		}
 	case "orgs.list":
 		return KeyStrings{"list", "Lists organizations for current user",
@@ -462,6 +1328,10 @@ invitation to join (if not, see orgs revoke).`,
 			`Revokes an invitation to join an organization that has been sent to a 
 user by email.`,
 		}
+	case "orgs.set-role":
+		return KeyStrings{"set-role <org> <email> <role>", "Change a member's role in an organization",
+			`Change the role of an organization member to member or admin.`,
+		}
 	case "orgs.show":
 		return KeyStrings{"show <org>", "Show information about an organization",
 			`Shows information about an organization.
@@ -473,9 +1343,20 @@ associated member. Details full list of members and roles.`,
 			`The PLATFORM commands are for users looking for information 
 about the Fly platform.`,
 		}
+	case "platform.refresh":
+		return KeyStrings{"refresh", "Refresh the cached platform catalog",
+			`Fetch the regions and VM sizes again and cache them. Commands that
+look these up use a copy cached in ~/.fly for a day, which is refreshed
+automatically when it's out of date or a region isn't found in it.`,
+		}
 	case "platform.regions":
 		return KeyStrings{"regions", "List regions",
-			`View a list of regions where Fly has edges and/or datacenters`,
+			`View a list of regions where Fly has edges and/or datacenters, along
+with GPU and performance VM availability and a capacity hint for each region.
+
+The capacity hint (high, medium or low) is a soft indicator of how easily new
+VMs can be placed in the region; low capacity regions may still accept VMs.
+Use --json for machine readable output.`,
 		}
 	case "platform.status":
 		return KeyStrings{"status", "Show current platform status",
@@ -483,7 +1364,15 @@ about the Fly platform.`,
 		}
 	case "platform.vmsizes":
 		return KeyStrings{"vm-sizes", "List VM Sizes",
-			`View a list of VM sizes which can be used with the FLYCTL SCALE VM command`,
+			`View a list of VM sizes which can be used with the FLYCTL SCALE VM command,
+along with their GPUs, the regions they can run in and the best capacity hint
+of those regions. GPU sizes only run in regions with their GPU model, and
+performance sizes in regions with performance VMs.
+
+Use --gpu or --performance to only show those sizes, --region to only show
+sizes available in a region, and --available to hide sizes only available in
+regions with low capacity. Sizes come from the cached platform catalog, see
+FLYCTL PLATFORM REFRESH. Use --json for machine readable output.`,
 		}
 	case "postgres":
 		return KeyStrings{"postgres", "Manage postgres clusters",
@@ -493,6 +1382,13 @@ about the Fly platform.`,
 		return KeyStrings{"attach", "Attach a postgres cluster to an app",
 			`Attach a postgres cluster to an app`,
 		}
+	case "postgres.connect":
+		return KeyStrings{"connect <postgres-cluster-name>", "Connect to a postgres cluster with psql",
+			`Open psql against a postgres cluster over a WireGuard tunnel to
+the cluster's organization. psql must be installed locally. Connects as the
+postgres user to the postgres database unless --username and --database are
+given; psql prompts for the password unless --password is set.`,
+		}
 	case "postgres.create":
 		return KeyStrings{"create", "Create a postgres cluster",
 			`Create a postgres cluster`,
@@ -503,7 +1399,7 @@ about the Fly platform.`,
 		}
 	case "postgres.db.create":
 		return KeyStrings{"create <postgres-cluster-name>", "create a database in a cluster",
-			`create a database in a cluster`,
+			`create a database named by --name in a cluster`,
 		}
 	case "postgres.db.list":
 		return KeyStrings{"list <postgres-cluster-name>", "list databases in a cluster",
@@ -513,70 +1409,245 @@ about the Fly platform.`,
 		return KeyStrings{"detach", "Detach a postgres cluster from an app",
 			`Detach a postgres cluster from an app`,
 		}
+	case "postgres.failover":
+		return KeyStrings{"failover <postgres-cluster-name>", "Promote a replica to leader",
+			`Promote a replica to leader, demoting the current leader. The
+healthy replica with the least replication lag is chosen unless --region names
+one. Refuses to fail over to an unhealthy replica or to one lagging further
+behind the leader than --max-lag, which would lose recent writes.`,
+		}
 	case "postgres.list":
 		return KeyStrings{"list", "list postgres clusters",
 			`list postgres clusters`,
 		}
+	case "postgres.replicas":
+		return KeyStrings{"replicas", "manage replicas in a cluster",
+			`manage replicas in a cluster`,
+		}
+	case "postgres.replicas.add":
+		return KeyStrings{"add <postgres-cluster-name>", "add a replica to a cluster",
+			`add a replica in the region given by --region. a volume is
+created for it, sized like the cluster's existing volumes unless --volume-size
+is set, and the cluster is scaled up by one instance`,
+		}
+	case "postgres.replicas.remove":
+		return KeyStrings{"remove <postgres-cluster-name>", "remove a replica from a cluster",
+			`remove the replica in the region given by --region, stopping it
+and deleting its volume. the leader can't be removed, fail over first`,
+		}
+	case "postgres.replication":
+		return KeyStrings{"replication <postgres-cluster-name>", "Show replication status of a cluster",
+			`Show each instance in a postgres cluster with its region, role,
+health and how far it lags behind the leader.`,
+		}
 	case "postgres.users":
 		return KeyStrings{"users", "manage users in a cluster",
 			`manage users in a cluster`,
 		}
 	case "postgres.users.create":
 		return KeyStrings{"create <postgres-cluster-name>", "create a user in a cluster",
-			`create a user in a cluster`,
+			`create a user named by --username in a cluster. a password is
+generated unless --password is set, and --superuser grants superuser privileges`,
 		}
 	case "postgres.users.list":
 		return KeyStrings{"list <postgres-cluster-name>", "list users in a cluster",
 			`list users in a cluster`,
 		}
+	case "proxy":
+		return KeyStrings{"proxy [LOCAL:]HOST:PORT...", "Proxy local ports to services on a private network",
+			`Forward local ports over a WireGuard tunnel to services on an
+organization's private network, such as app.internal:80. Connections are
+accepted on 127.0.0.1 until the command is interrupted.
+
+Use --tls-local LOCAL:HOST:PORT to terminate TLS on the local port in front of a
+plain text service. The certificate for localhost is issued by mkcert's CA when
+mkcert is installed, otherwise by a CA stored in the flyctl config directory
+that can be added to your trust store. Pass --tls-cert and --tls-key to serve
+your own certificate instead.`,
+		}
+	case "reconcile":
+		return KeyStrings{"reconcile", "Keep a live app in sync with its configuration",
+			`Periodically compare the live app against its configuration file and 
+desired image, redeploying when they have drifted apart.
+
+The desired image is read from the deploy manifest given with --manifest 
+(see deploy --record-to) or from the image setting in the [build] section of 
+the configuration file. The configuration file and manifest are re-read on 
+every check, so updating them on disk is enough to roll out a change.
+
+The app is checked every --interval, 5 minutes by default and at least 30 
+seconds. Use --once to check a single time and --dry-run to only report drift.`,
+		}
+	case "redis":
+		return KeyStrings{"redis", "Manage redis databases",
+			`Create and manage redis databases on an organization's private
+network`,
+		}
+	case "redis.connect":
+		return KeyStrings{"connect <name>", "Connect to a redis database with redis-cli",
+			`Open redis-cli against a redis database over a WireGuard tunnel
+to the database's organization. redis-cli must be installed locally.`,
+		}
+	case "redis.create":
+		return KeyStrings{"create", "Create a redis database",
+			`Create a redis database and print its connection URLs. With --app,
+the private URL is also stored as a secret on that app, named REDIS_URL unless
+--variable-name is given.`,
+		}
+	case "redis.list":
+		return KeyStrings{"list", "List redis databases",
+			`List the redis databases in all of your organizations`,
+		}
+	case "redis.status":
+		return KeyStrings{"status <name>", "Show status and connection URLs of a redis database",
+			`Show status and connection URLs of a redis database`,
+		}
 	case "regions":
 		return KeyStrings{"regions", "Manage regions",
-			`Configure the region placement rules for an application.`,
+			`Configure the region placement rules for an application.
+
+Region codes are checked against the platform's regions before they're 
+applied. Use flyctl regions latency to compare them.`,
 		}
 	case "regions.add":
 		return KeyStrings{"add REGION ...", "Allow the app to run in the provided regions",
-			`Allow the app to run in one or more regions`,
+			`Allow the app to run in one or more regions
+
+Use --backup to add them to the backup regions instead.`,
 		}
 	case "regions.backup":
 		return KeyStrings{"backup REGION ...", "Sets the backup region pool with provided regions",
 			`Sets the backup region pool with provided regions`,
 		}
+	case "regions.latency":
+		return KeyStrings{"latency", "Compare regions by estimated latency from your location",
+			`Lists every region grouped by geography, with a round trip time 
+estimated from its distance to the region closest to you, to help choose 
+regions for the region pool and backup regions.`,
+		}
 	case "regions.list":
 		return KeyStrings{"list", "Shows the list of regions the app is allowed to run in",
 			`Shows the list of regions the app is allowed to run in.`,
 		}
 	case "regions.remove":
 		return KeyStrings{"remove REGION ...", "Prevent the app from running in the provided regions",
-			`Prevent the app from running in the provided regions`,
+			`Prevent the app from running in the provided regions
+
+Use --backup to remove them from the backup regions instead.`,
 		}
 	case "regions.set":
 		return KeyStrings{"set REGION ...", "Sets the region pool with provided regions",
 			`Sets the region pool with provided regions`,
 		}
+	case "registry":
+		return KeyStrings{"registry", "Manage an app's images in the Fly registry",
+			`Commands for listing and removing the images in an app's repository 
+on registry.fly.io. Every deploy pushes a new deployment tag, which stays 
+until it's removed.`,
+		}
+	case "registry.rm":
+		return KeyStrings{"rm [<tag|digest>...]", "Remove images from the app's repository",
+			`Removes the images with the tags or digests given, along with 
+their other tags, signatures and SBOMs.
+
+Use --keep-last N instead to remove all but the newest N images. The current 
+release's image is never removed. Use --dry-run to list the images which 
+would be removed.`,
+		}
+	case "registry.tags":
+		return KeyStrings{"tags", "List the app's images",
+			`Lists the images in the app's repository, newest first, with 
+their tags, digest, size and whether they've been signed or have an SBOM 
+attached. The image of the current release is marked (current).`,
+		}
 	case "releases":
 		return KeyStrings{"releases", "List app releases",
 			`List all the releases of the application onto the Fly platform, 
-including type, when, success/fail and which user triggered the release.`,
+including type, when, success/fail and which user triggered the release.
+
+The Commit column shows the git commit and branch each release was deployed 
+from, with a * when it had uncommitted changes. With --json, each release 
+includes all of its annotations: git.sha, git.branch, git.dirty, 
+deployer.user, deployer.token, deployer.ci and flyctl.version.
+
+The newest 25 releases are listed, change this with --limit. Use --before to 
+page back through older releases, e.g. --before v42 lists the releases before 
+v42, and --status to only list releases with a status, e.g. --status failed.
+
+Use flyctl releases diff to compare two releases.`,
+		}
+	case "releases.bisect":
+		return KeyStrings{"bisect", "Find the release that introduced a regression",
+			`Binary search the releases between a known good and a known bad 
+release to find the one that introduced a regression. Each candidate is deployed 
+with the app's current configuration, without its release_command, to a separate 
+canary app (<app>-bisect by default), so the app itself is never touched.
+
+Secrets can't be copied, so bisect refuses to start when the app has secrets the 
+canary app doesn't. Create the canary app, set them on it and pass --canary-app, 
+or pass --allow-missing-secrets to bisect anyway.
+
+After each deploy you are asked whether the candidate is good, bad or should be 
+skipped. Pass --check to automate this with a command instead: it is run with 
+FLY_BISECT_APP, FLY_BISECT_HOSTNAME and FLY_BISECT_VERSION set and should exit 0 
+for good, 125 to skip, or any other code for bad. When a candidate fails to 
+deploy you are asked the same, or with --check it is skipped.
+
+A canary app created by bisect is destroyed when it finishes unless --keep is set.`,
+		}
+	case "releases.diff":
+		return KeyStrings{"diff <from> <to>", "Show the differences between two releases",
+			`Show the differences between two releases of the app: the image 
+deployed, each config setting added, removed or changed, and the names of the 
+secrets added or removed. Secret values are never shown.
+
+  flyctl releases diff v42 v43
+
+Use --json for the differences as JSON.`,
 		}
 	case "restart":
 		return KeyStrings{"restart [APPNAME]", "Restart an application",
-			`The RESTART command will restart all running vms.`,
+			`The RESTART command will restart all running vms. 
+
+Use --rolling to restart them a batch of --max-unavailable at a time (1 by 
+default) instead, waiting for each batch to be running again before the next. 
+With --wait-healthy, each batch's health checks have to pass too. The restart 
+stops, leaving the rest of the instances alone, if an instance fails or has 
+more failing health checks than before it was restarted, or a batch isn't 
+back within --batch-timeout.
+
+With --max-surge, each process group is first scaled up by that many 
+instances, so the app keeps its capacity while instances restart, and scaled 
+back once the restart is over.
+
+  flyctl restart --rolling --max-unavailable 2 --max-surge 1 --wait-healthy`,
 		}
 	case "resume":
 		return KeyStrings{"resume [APPNAME]", "Resume an application",
 			`The RESUME command will restart a previously suspended application. 
 The application will resume with its original region pool and a min count of one
-meaning there will be one running instance once restarted. Use SCALE SET MIN= to raise
-the number of configured instances.`,
+meaning there will be one running instance once restarted. It's then scaled 
+back to the number of VMs each process group ran before, which SUSPEND saves 
+on the machine it's run on. When that wasn't saved on this machine, the app 
+is left with one instance and a warning is shown. Then use SCALE COUNT to 
+raise the number of configured instances.`,
 		}
 	case "scale":
 		return KeyStrings{"scale", "Scale app resources",
 			`Scale application resources`,
 		}
 	case "scale.count":
-		return KeyStrings{"count <count>", "Change an app's VM count to the given value",
+		return KeyStrings{"count [count] [flags]", "Change an app's VM count to the given value",
 			`Change an app's VM count to the given value. 
 
+Counts can also be set per process group with --group and per region with
+--region, as comma separated NAME=COUNT pairs. Groups must be running or
+defined in the [processes] section of fly.toml, and regions must be in the
+app's region pool. The resulting placement plan is shown for confirmation
+before it's applied; pass --yes to skip it.
+
+e.g. flyctl scale count --region ams=2,lhr=3 --group worker=5
+
 For pricing, see https://fly.io/docs/about/pricing/`,
 		}
 	case "scale.memory":
@@ -603,6 +1674,13 @@ For dedicated vms, this should be a multiple of 1024MB.
 
 For shared vms, this can be 256MB or a a multiple of 1024MB.
 
+Use --group to size a single process group, leaving the others unchanged.
+
+GPUs can be attached with --gpu=model and --gpu-count. Every region the app
+runs in must offer the model; see FLYCTL PLATFORM REGIONS --gpu.
+
+e.g. flyctl scale vm dedicated-cpu-4x --gpu=a100 --gpu-count=1
+
 For pricing, see https://fly.io/docs/about/pricing/`,
 		}
 	case "secrets":
@@ -613,6 +1691,15 @@ Secrets are provided to applications at runtime as ENV variables. Names are
 case sensitive and stored as-is, so ensure names are appropriate for
 the application and vm environment.`,
 		}
+	case "secrets.generate":
+		return KeyStrings{"generate [flags] NAME NAME ...", "Generate random values for secrets and set them",
+			`Generate cryptographically random values for one or more secrets and
+set them on the application, so the values never appear on the command line or
+in shell history. Use --type to choose the kind of value: hex32 or hex64 (hex
+strings of that many characters), base64 (32 random bytes), uuid, or rsa and
+rsa4096 keypairs. Keypairs store the PEM private key in the named secret and
+the public key in a second secret with _PUBLIC appended, which is also printed.`,
+		}
 	case "secrets.import":
 		return KeyStrings{"import [flags]", "Read secrets in name=value from stdin",
 			`Set one or more encrypted secrets for an application. Values
@@ -639,6 +1726,30 @@ Any value that equals "-" will be assigned from STDIN instead of args.`,
 			`Remove encrypted secrets from the application. Unsetting a 
 secret removes its availability to the application.`,
 		}
+	case "services":
+		return KeyStrings{"services", "Show an app's services and the ports they expose",
+			`Show the services of the app's deployed configuration, with each edge
+port's handlers, internal port, process groups and concurrency limits, and
+whether it's exposed to the internet. Ports aren't exposed until the app has
+an IP address, or when the platform isn't running the service.
+
+Common mistakes are listed after the table, such as the http handler on port
+443 without tls, the tls handler on port 80, the same port in more than one
+service, unknown handlers or a concurrency soft_limit over its hard_limit.
+Deploys and config validate warn about the same mistakes.
+
+Services set protocol = "udp" to receive UDP packets, e.g. for a game server:
+
+  [[services]]
+    internal_port = 27015
+    protocol = "udp"
+
+    [[services.ports]]
+      port = 27015
+
+UDP ports take no handlers, and are only exposed on a dedicated IPv4 address,
+allocated with flyctl ips allocate-v4.`,
+		}
 	case "ssh":
 		return KeyStrings{"ssh <command>", "Commands that manage SSH credentials",
 			`Commands that manage SSH credentials`,
@@ -672,7 +1783,13 @@ WireGuard region to use for the connection.`,
 		return KeyStrings{"status", "Show app status",
 			`Show the application's current status including application 
 details, tasks, most recent deployment details and in which regions it is 
-currently allocated.`,
+currently allocated.
+
+With --watch the status is refreshed every few seconds (see --rate). Instances 
+which changed since the last refresh are highlighted, failing health checks 
+are listed and the changes observed are shown as recent events. If the 
+connection drops the last status stays on screen while it retries, and the 
+gap is listed with the recent events once it reconnects.`,
 		}
 	case "status.instance":
 		return KeyStrings{"instance [instance-id]", "Show instance status",
@@ -683,8 +1800,82 @@ and events.`,
 		return KeyStrings{"suspend [APPNAME]", "Suspend an application",
 			`The SUSPEND command will suspend an application. 
 All instances will be halted leaving the application running nowhere.
-It will continue to consume networking resources (IP address). See RESUME
-for details on restarting it.`,
+It will continue to consume networking resources (IP address). Volumes and 
+config are kept too. The number of VMs each process group runs is saved locally 
+so RESUME brings the same scale back. See RESUME for details on restarting it.`,
+		}
+	case "templates":
+		return KeyStrings{"templates <command>", "Publish and find app templates",
+			`Commands for sharing app templates. A template is a directory with a
+fly.toml, a Dockerfile and an optional fly-template.toml manifest. Launch an app
+from a published template with flyctl launch --template <name>.`,
+		}
+	case "templates.publish":
+		return KeyStrings{"publish [<directory>]", "Publish a template",
+			`Publish the template in a directory, the current directory by default.
+fly-template.toml names and describes the template, can list extra files to
+include, and can describe secrets and volumes to provision at launch:
+
+    name = "rails-sqlite"
+    description = "Rails on SQLite with a persistent volume"
+    files = ["config/litestream.yml"]
+
+    [[secrets]]
+    name = "SECRET_KEY_BASE"
+    generate = "hex64"
+
+    [[volumes]]
+    name = "data"
+    size_gb = 1
+
+Secrets with a generate type (see flyctl secrets generate) are generated, the
+rest are prompted for. Publishing again under the same name creates a new version.`,
+		}
+	case "templates.search":
+		return KeyStrings{"search [<keyword>]", "Search published templates",
+			`List published templates, optionally filtered by a keyword matched
+against their names and descriptions.`,
+		}
+	case "tokens":
+		return KeyStrings{"tokens <command>", "Manage scoped API tokens",
+			`Create, list and revoke tokens limited to deploying one app, reading 
+an organization, or using its builders. Use them in CI in place of a 
+personal access token, which can do anything your account can.
+
+Tokens are used by setting FLY_API_TOKEN.`,
+		}
+	case "tokens.create":
+		return KeyStrings{"create <type>", "Create a scoped token",
+			`Create a scoped token. The token is printed once and can't be 
+shown again.
+
+Use --expiry to set how long the token is valid for, as a duration like 12h or 
+a number of days like 30d. Tokens expire after 90 days by default.`,
+		}
+	case "tokens.create.builder":
+		return KeyStrings{"builder [<org>]", "Create a token that can use an organization's builders",
+			`Create a token that can build and push images with an 
+organization's remote builders.`,
+		}
+	case "tokens.create.deploy":
+		return KeyStrings{"deploy", "Create a token that can only deploy one app",
+			`Create a token that can deploy and manage the secrets, scale and 
+releases of a single app, and nothing else.`,
+		}
+	case "tokens.create.readonly":
+		return KeyStrings{"readonly [<org>]", "Create a read-only organization token",
+			`Create a token that can read the apps, logs, status and metrics 
+of an organization, but not change them.`,
+		}
+	case "tokens.list":
+		return KeyStrings{"list [<org>]", "List active tokens",
+			`List the unexpired scoped tokens of an organization, with when they 
+were last used. Use --app to list only an app's deploy tokens.`,
+		}
+	case "tokens.revoke":
+		return KeyStrings{"revoke [<org>] <id|name>", "Revoke a token",
+			`Revoke a scoped token by its ID, or by name when an organization is 
+given. Anything using the token stops working immediately.`,
 		}
 	case "version":
 		return KeyStrings{"version", "Show version information for the flyctl command",
@@ -702,15 +1893,20 @@ command to update the application.`,
 		}
 	case "vm.restart":
 		return KeyStrings{"restart <vm-id>", "Restart a VM",
-			`Request for a VM to be asynchronously restarted.`,
+			`Request for a VM to be asynchronously restarted. Use --wait to 
+wait until it's running again with no critical health checks, for up to 
+--wait-timeout.`,
 		}
 	case "vm.status":
 		return KeyStrings{"status <vm-id>", "Show a VM's status",
-			`Show a VM's current status including logs, checks, and events.`,
+			`Show a VM's current status including logs, checks, and events. 
+Recent exits list the exit codes of the VM's process each time it exited, and 
+checks show how long they've had their current state.`,
 		}
 	case "vm.stop":
 		return KeyStrings{"stop <vm-id>", "Stop a VM",
-			`Request for a VM to be asynchronously stopped.`,
+			`Request for a VM to be asynchronously stopped. Use --wait to 
+wait until it has stopped, for up to --wait-timeout.`,
 		}
 	case "volumes":
 		return KeyStrings{"volumes <command>", "Volume management commands",
@@ -720,13 +1916,21 @@ command to update the application.`,
 		return KeyStrings{"create <volumename>", "Create new volume for app",
 			`Create new volume for app. --region flag must be included to specify
 region the volume exists in. --size flag is optional, defaults to 10,
-sets the size as the number of gigabytes the volume will consume.`,
+sets the size as the number of gigabytes the volume will consume.
+--snapshot-id restores the new volume from a snapshot of an existing volume.`,
 		}
 	case "volumes.delete":
 		return KeyStrings{"delete <id>", "Delete a volume from the app",
 			`Delete a volume from the application. Requires the volume's ID
 number to operate. This can be found through the volumes list command`,
 		}
+	case "volumes.extend":
+		return KeyStrings{"extend <id>", "Extend a volume to a larger size",
+			`Extend a volume to the size in gigabytes given by --size. Volumes
+can only grow. The attached VM may need a restart before it sees the new space;
+pass --restart to restart it when needed and check from inside the VM that the
+filesystem grew, reporting its size before and after.`,
+		}
 	case "volumes.list":
 		return KeyStrings{"list", "List the volumes for app",
 			`List all the volumes associated with this application.`,
@@ -736,6 +1940,21 @@ number to operate. This can be found through the volumes list command`,
 			`Show details of an app's volume. Requires the volume's ID
 number to operate. This can be found through the volumes list command`,
 		}
+	case "volumes.snapshots":
+		return KeyStrings{"snapshots <command>", "Manage volume snapshots",
+			`Commands for listing and creating snapshots of volumes. Restore a
+snapshot with volumes create --snapshot-id.`,
+		}
+	case "volumes.snapshots.create":
+		return KeyStrings{"create <volume-id>", "Snapshot a volume",
+			`Take a snapshot of a volume. Snapshots are taken in the
+background and appear in volumes snapshots list once complete.`,
+		}
+	case "volumes.snapshots.list":
+		return KeyStrings{"list <volume-id>", "List snapshots associated with the specified volume",
+			`List the snapshots of a volume, newest first. Snapshot IDs can
+be passed to volumes create --snapshot-id to restore them to a new volume.`,
+		}
 	case "wireguard":
 		return KeyStrings{"wireguard <command>", "Commands that manage WireGuard peer connections",
 			`Commands that manage WireGuard peer connections`,
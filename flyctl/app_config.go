@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -19,24 +20,105 @@ type ConfigFormat string
 
 const (
 	TOMLFormat        ConfigFormat = ".toml"
+	YAMLFormat        ConfigFormat = ".yaml"
+	JSONFormat        ConfigFormat = ".json"
 	UnsupportedFormat              = ""
 )
 
 type AppConfig struct {
-	AppName    string
-	Build      *Build
-	Definition map[string]interface{}
+	AppName     string
+	Build       *Build
+	CLI         *CLIConfig
+	Autoscaling *Autoscaling
+	Processes   map[string]*ProcessGroup
+	Definition  map[string]interface{}
 }
 
 type Build struct {
 	Builder    string
 	Args       map[string]string
 	Buildpacks []string
+	// Env - environment variables set while buildpacks build the app
+	Env map[string]string
 	// Or...
 	Builtin  string
 	Settings map[string]interface{}
 	// Or...
 	Image string
+	// Dockerfile is relative to the directory containing the config file
+	Dockerfile string
+	// Target - the stage of a multi-stage Dockerfile to build
+	Target string
+	// Context - the build context directory, relative to the directory
+	// containing the config file. Defaults to the working directory.
+	Context string
+	// RemoteBuilder - the size of remote builder the app's builds need
+	RemoteBuilder *RemoteBuilderSize
+}
+
+// RemoteBuilderSize - the [build.remote_builder] section. The remote builder
+// is resized to it before building, zero values leave the builder as it is.
+type RemoteBuilderSize struct {
+	// VMSize - the builder's VM size, such as performance-2x
+	VMSize   string
+	MemoryMB int
+	// DiskGB - the size of the builder's volume, which is only ever grown
+	DiskGB int
+}
+
+// IsZero is true when no size is set
+func (s RemoteBuilderSize) IsZero() bool {
+	return s == RemoteBuilderSize{}
+}
+
+// Merge returns s with the sizes set in other in place of its own
+func (s RemoteBuilderSize) Merge(other RemoteBuilderSize) RemoteBuilderSize {
+	if other.VMSize != "" {
+		s.VMSize = other.VMSize
+	}
+	if other.MemoryMB > 0 {
+		s.MemoryMB = other.MemoryMB
+	}
+	if other.DiskGB > 0 {
+		s.DiskGB = other.DiskGB
+	}
+	return s
+}
+
+func unmarshalRemoteBuilderSize(data map[string]interface{}) (*RemoteBuilderSize, error) {
+	size := &RemoteBuilderSize{}
+	if v, ok := data["size"]; ok {
+		size.VMSize = fmt.Sprint(v)
+	}
+	memory, err := optionalInt(data, "memory")
+	if err != nil {
+		return nil, fmt.Errorf("build.remote_builder: %w", err)
+	}
+	if memory != nil {
+		size.MemoryMB = *memory
+	}
+	disk, err := optionalInt(data, "disk")
+	if err != nil {
+		return nil, fmt.Errorf("build.remote_builder: %w", err)
+	}
+	if disk != nil {
+		size.DiskGB = *disk
+	}
+	return size, nil
+}
+
+func marshalRemoteBuilderSize(size *RemoteBuilderSize) map[string]interface{} {
+	data := map[string]interface{}{}
+	if size.VMSize != "" {
+		data["size"] = size.VMSize
+	}
+	if size.MemoryMB > 0 {
+		data["memory"] = size.MemoryMB
+	}
+	if size.DiskGB > 0 {
+		data["disk"] = size.DiskGB
+	}
+	return data
 }
 
 func NewAppConfig() *AppConfig {
@@ -64,6 +146,10 @@ func LoadAppConfig(configFile string) (*AppConfig, error) {
 	switch ConfigFormatFromPath(fullConfigFilePath) {
 	case TOMLFormat:
 		err = appConfig.unmarshalTOML(file)
+	case YAMLFormat:
+		err = appConfig.unmarshalYAML(file)
+	case JSONFormat:
+		err = appConfig.unmarshalJSON(file)
 	default:
 		return nil, errors.New("Unsupported config file format")
 	}
@@ -75,8 +161,24 @@ func (ac *AppConfig) HasDefinition() bool {
 	return len(ac.Definition) > 0
 }
 
+// NixpacksBuilder - the [build] builder which builds the app with nixpacks
+// instead of buildpacks
+const NixpacksBuilder = "nixpacks"
+
+// RemoteBuilderSize - the size of remote builder from [build.remote_builder]
+func (ac *AppConfig) RemoteBuilderSize() RemoteBuilderSize {
+	if ac.Build == nil || ac.Build.RemoteBuilder == nil {
+		return RemoteBuilderSize{}
+	}
+	return *ac.Build.RemoteBuilder
+}
+
 func (ac *AppConfig) HasBuilder() bool {
-	return ac.Build != nil && ac.Build.Builder != ""
+	return ac.Build != nil && ac.Build.Builder != "" && ac.Build.Builder != NixpacksBuilder
+}
+
+func (ac *AppConfig) HasNixpacks() bool {
+	return ac.Build != nil && ac.Build.Builder == NixpacksBuilder
 }
 
 func (ac *AppConfig) HasBuiltin() bool {
@@ -94,6 +196,10 @@ func (ac *AppConfig) WriteTo(w io.Writer, format ConfigFormat) error {
 	switch format {
 	case TOMLFormat:
 		return ac.marshalTOML(w)
+	case YAMLFormat:
+		return ac.marshalYAML(w)
+	case JSONFormat:
+		return ac.marshalJSON(w)
 	}
 
 	return fmt.Errorf("Unsupported format: %s", format)
@@ -120,6 +226,7 @@ func (ac *AppConfig) unmarshalNativeMap(data map[string]interface{}) error {
 			Args:       map[string]string{},
 			Settings:   map[string]interface{}{},
 			Buildpacks: []string{},
+			Env:        map[string]string{},
 		}
 		for k, v := range buildConfig {
 			switch k {
@@ -140,6 +247,13 @@ func (ac *AppConfig) unmarshalNativeMap(data map[string]interface{}) error {
 					}
 				}
 				insection = true
+			case "env":
+				if envMap, ok := v.(map[string]interface{}); ok {
+					for envK, envV := range envMap {
+						b.Env[envK] = fmt.Sprint(envV)
+					}
+				}
+				insection = true
 			case "builtin":
 				b.Builtin = fmt.Sprint(v)
 				insection = true
@@ -153,38 +267,115 @@ func (ac *AppConfig) unmarshalNativeMap(data map[string]interface{}) error {
 			case "image":
 				b.Image = fmt.Sprint(v)
 				insection = true
+			case "dockerfile":
+				b.Dockerfile = fmt.Sprint(v)
+				insection = true
+			case "build-target":
+				b.Target = fmt.Sprint(v)
+				insection = true
+			case "context":
+				b.Context = fmt.Sprint(v)
+				insection = true
+			case "remote_builder":
+				sizeMap, ok := v.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("build.remote_builder must be a table")
+				}
+				size, err := unmarshalRemoteBuilderSize(sizeMap)
+				if err != nil {
+					return err
+				}
+				b.RemoteBuilder = size
+				insection = true
 			default:
 				if !insection {
 					b.Args[k] = fmt.Sprint(v)
 				}
 			}
 		}
-		if b.Builder != "" || b.Builtin != "" || b.Image != "" || len(b.Args) > 0 {
+		if b.Builder != "" || len(b.Buildpacks) > 0 || b.Builtin != "" || b.Image != "" || b.Dockerfile != "" || b.Target != "" || b.Context != "" || b.RemoteBuilder != nil || len(b.Args) > 0 || len(b.Env) > 0 {
 			ac.Build = &b
 		}
 	}
 
 	delete(data, "build")
 
+	if cliConfig, ok := (data["cli"]).(map[string]interface{}); ok {
+		ac.CLI = unmarshalCLIConfig(cliConfig)
+	}
+	delete(data, "cli")
+
+	if autoscalingConfig, ok := (data["autoscaling"]).(map[string]interface{}); ok {
+		autoscaling, err := unmarshalAutoscaling(autoscalingConfig)
+		if err != nil {
+			return err
+		}
+		ac.Autoscaling = autoscaling
+	}
+	delete(data, "autoscaling")
+
+	// the platform only takes each group's command, scaling settings are applied on deploy
+	if processesConfig, ok := (data["processes"]).(map[string]interface{}); ok {
+		processes, err := unmarshalProcesses(processesConfig)
+		if err != nil {
+			return err
+		}
+		ac.Processes = processes
+		data["processes"] = processCommands(processes)
+	}
+
 	ac.Definition = data
 
 	return nil
 }
 
 func (ac AppConfig) marshalTOML(w io.Writer) error {
-	encoder := toml.NewEncoder(w)
-
 	fmt.Fprintf(w, "# fly.toml file generated for %s on %s\n\n", ac.AppName, time.Now().Format(time.RFC3339))
 
-	rawData := map[string]interface{}{
-		"app": ac.AppName,
-	}
+	return ac.marshalTOMLBody(w)
+}
+
+func (ac AppConfig) marshalYAML(w io.Writer) error {
+	fmt.Fprintf(w, "# fly.yaml file generated for %s on %s\n\n", ac.AppName, time.Now().Format(time.RFC3339))
+
+	return ac.marshalYAMLBody(w)
+}
 
-	if err := encoder.Encode(rawData); err != nil {
+// marshalTOMLBody writes the config without a header, tables' keys sorted
+func (ac AppConfig) marshalTOMLBody(w io.Writer) error {
+	encoder := toml.NewEncoder(w)
+
+	if err := encoder.Encode(map[string]interface{}{"app": ac.AppName}); err != nil {
 		return err
 	}
 
-	rawData = ac.Definition
+	rawData := ac.nativeMap()
+	if len(rawData) > 0 {
+		// roundtrip through json encoder to convert float64 numbers to json.Number, otherwise numbers are floats in toml
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(rawData)
+		d := json.NewDecoder(&buf)
+		d.UseNumber()
+		if err := d.Decode(&rawData); err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(rawData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nativeMap - the config, other than the app name, as it's written in any
+// format: the definition with the sections flyctl parses itself added back
+func (ac AppConfig) nativeMap() map[string]interface{} {
+	// copy the definition so the sections added below don't leak into it
+	rawData := map[string]interface{}{}
+	for k, v := range ac.Definition {
+		rawData[k] = v
+	}
 
 	if ac.Build != nil {
 		buildData := map[string]interface{}{}
@@ -197,6 +388,9 @@ func (ac AppConfig) marshalTOML(w io.Writer) error {
 		if len(ac.Build.Args) > 0 {
 			buildData["args"] = ac.Build.Args
 		}
+		if len(ac.Build.Env) > 0 {
+			buildData["env"] = ac.Build.Env
+		}
 		if ac.Build.Builtin != "" {
 			buildData["builtin"] = ac.Build.Builtin
 			if len(ac.Build.Settings) > 0 {
@@ -206,25 +400,36 @@ func (ac AppConfig) marshalTOML(w io.Writer) error {
 		if ac.Build.Image != "" {
 			buildData["image"] = ac.Build.Image
 		}
+		if ac.Build.Dockerfile != "" {
+			buildData["dockerfile"] = ac.Build.Dockerfile
+		}
+		if ac.Build.Target != "" {
+			buildData["build-target"] = ac.Build.Target
+		}
+		if ac.Build.Context != "" {
+			buildData["context"] = ac.Build.Context
+		}
+		if ac.Build.RemoteBuilder != nil {
+			buildData["remote_builder"] = marshalRemoteBuilderSize(ac.Build.RemoteBuilder)
+		}
 		rawData["build"] = buildData
 	}
 
-	if len(ac.Definition) > 0 {
-		// roundtrip through json encoder to convert float64 numbers to json.Number, otherwise numbers are floats in toml
-		var buf bytes.Buffer
-		json.NewEncoder(&buf).Encode(ac.Definition)
-		d := json.NewDecoder(&buf)
-		d.UseNumber()
-		if err := d.Decode(&ac.Definition); err != nil {
-			return err
+	if ac.CLI != nil {
+		if cliData := marshalCLIConfig(ac.CLI); len(cliData) > 0 {
+			rawData["cli"] = cliData
 		}
+	}
 
-		if err := encoder.Encode(ac.Definition); err != nil {
-			return err
-		}
+	if ac.Autoscaling != nil {
+		rawData["autoscaling"] = marshalAutoscaling(ac.Autoscaling)
 	}
 
-	return nil
+	if len(ac.Processes) > 0 {
+		rawData["processes"] = marshalProcesses(ac.Processes)
+	}
+
+	return rawData
 }
 
 func (ac *AppConfig) WriteToFile(filename string) error {
@@ -263,6 +468,92 @@ func (ac *AppConfig) SetInternalPort(port int) bool {
 	return false
 }
 
+// SetHTTPCheck - adds an HTTP health check requesting path to the first service
+func (ac *AppConfig) SetHTTPCheck(path string) bool {
+	if services, ok := ac.Definition["services"].([]interface{}); ok {
+		if len(services) == 0 {
+			return false
+		}
+
+		if service, ok := services[0].(map[string]interface{}); ok {
+			service["http_checks"] = []map[string]interface{}{
+				{
+					"interval":     "10s",
+					"grace_period": "5s",
+					"method":       "get",
+					"path":         path,
+					"protocol":     "http",
+					"timeout":      "2s",
+				},
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// Static - files in the image served directly by Fly's proxy
+type Static struct {
+	GuestPath string
+	URLPrefix string
+}
+
+// SetStatics - sets the [[statics]] served from the image
+func (ac *AppConfig) SetStatics(statics []Static) {
+	values := []map[string]interface{}{}
+	for _, static := range statics {
+		values = append(values, map[string]interface{}{
+			"guest_path": static.GuestPath,
+			"url_prefix": static.URLPrefix,
+		})
+	}
+	ac.Definition["statics"] = values
+}
+
+// Statics - returns the [[statics]] served from the image
+func (ac *AppConfig) Statics() ([]Static, error) {
+	var entries []map[string]interface{}
+	switch raw := ac.Definition["statics"].(type) {
+	case nil:
+		return nil, nil
+	case []map[string]interface{}:
+		entries = raw
+	case []interface{}:
+		for _, item := range raw {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("statics must be a list of [[statics]] sections")
+			}
+			entries = append(entries, entry)
+		}
+	default:
+		return nil, errors.New("statics must be a list of [[statics]] sections")
+	}
+
+	statics := make([]Static, 0, len(entries))
+	for i, entry := range entries {
+		guestPath, _ := entry["guest_path"].(string)
+		urlPrefix, _ := entry["url_prefix"].(string)
+
+		if !strings.HasPrefix(guestPath, "/") {
+			return nil, fmt.Errorf("statics[%d].guest_path must be an absolute path in the image", i)
+		}
+		if !strings.HasPrefix(urlPrefix, "/") {
+			return nil, fmt.Errorf("statics[%d].url_prefix must start with /", i)
+		}
+		for _, other := range statics {
+			if other.URLPrefix == urlPrefix {
+				return nil, fmt.Errorf("statics[%d].url_prefix %s is used more than once", i, urlPrefix)
+			}
+		}
+
+		statics = append(statics, Static{GuestPath: guestPath, URLPrefix: urlPrefix})
+	}
+
+	return statics, nil
+}
+
 func (ac *AppConfig) GetInternalPort() (int, error) {
 	tmpservices, ok := ac.Definition["services"]
 
@@ -285,6 +576,97 @@ func (ac *AppConfig) GetInternalPort() (int, error) {
 	return 8080, nil
 }
 
+// GPU - GPUs requested in the [vm] section of the config
+type GPU struct {
+	Kind  string
+	Count int
+}
+
+// GPU - returns the GPUs requested by the config, or nil if it doesn't request any
+func (ac *AppConfig) GPU() (*GPU, error) {
+	vm, ok := ac.Definition["vm"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	kind, ok := vm["gpu_kind"]
+	if !ok {
+		if _, ok := vm["gpu_count"]; ok {
+			return nil, errors.New("vm.gpu_count requires vm.gpu_kind")
+		}
+		return nil, nil
+	}
+
+	gpu := &GPU{Kind: fmt.Sprint(kind), Count: 1}
+
+	count, err := optionalInt(vm, "gpu_count")
+	if err != nil {
+		return nil, fmt.Errorf("vm: %w", err)
+	}
+	if count != nil {
+		if *count < 1 {
+			return nil, errors.New("vm.gpu_count must be at least 1")
+		}
+		gpu.Count = *count
+	}
+
+	return gpu, nil
+}
+
+// DeployHooks - commands from the [deploy] section run with the new image
+// around a deployment
+type DeployHooks struct {
+	// ReleaseCommand - run before instances are replaced, a failure aborts the release
+	ReleaseCommand string
+	// PostDeploy - run once the new instances are deployed
+	PostDeploy string
+}
+
+// DeployHooks - returns the hooks in the [deploy] section, or nil if there are none
+func (ac *AppConfig) DeployHooks() (*DeployHooks, error) {
+	deploy, ok := ac.Definition["deploy"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	hooks := &DeployHooks{}
+	for key, dest := range map[string]*string{"release_command": &hooks.ReleaseCommand, "post_deploy": &hooks.PostDeploy} {
+		value, ok := deploy[key]
+		if !ok {
+			continue
+		}
+		command, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("deploy.%s must be a string", key)
+		}
+		*dest = strings.TrimSpace(command)
+	}
+
+	if hooks.ReleaseCommand == "" && hooks.PostDeploy == "" {
+		return nil, nil
+	}
+	return hooks, nil
+}
+
+// EnvVariables - the env section, with values as strings whether the config
+// was loaded from a file or built up in code
+func (ac *AppConfig) EnvVariables() map[string]string {
+	env := map[string]string{}
+
+	switch rawEnv := ac.Definition["env"].(type) {
+	case map[string]string:
+		for k, v := range rawEnv {
+			env[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range rawEnv {
+			env[k] = fmt.Sprint(v)
+		}
+	}
+
+	return env
+}
+
 func (ac *AppConfig) SetEnvVariables(vals map[string]string) {
 	var env map[string]string
 
@@ -323,6 +705,22 @@ func (ac *AppConfig) SetEnvVariable(name, value string) {
 
 const defaultConfigFileName = "fly.toml"
 
+// configFileNames - the names an app's config file can have, in the order
+// they're looked for in a directory
+var configFileNames = []string{defaultConfigFileName, "fly.yaml", "fly.yml", "fly.json"}
+
+// findConfigFileInDir - the first of configFileNames in dir, or fly.toml
+// when there's none
+func findConfigFileInDir(dir string) string {
+	for _, name := range configFileNames {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(dir, defaultConfigFileName)
+}
+
 func ResolveConfigFileFromPath(p string) (string, error) {
 	p, err := filepath.Abs(p)
 	if err != nil {
@@ -341,7 +739,7 @@ func ResolveConfigFileFromPath(p string) (string, error) {
 
 	// Ok, something exists. Is it a file - yes? return the path
 	if pd.IsDir() {
-		return path.Join(p, defaultConfigFileName), nil
+		return findConfigFileInDir(p), nil
 	}
 
 	return p, nil
@@ -351,6 +749,10 @@ func ConfigFormatFromPath(p string) ConfigFormat {
 	switch path.Ext(p) {
 	case ".toml":
 		return TOMLFormat
+	case ".yaml", ".yml":
+		return YAMLFormat
+	case ".json":
+		return JSONFormat
 	}
 	return UnsupportedFormat
 }
@@ -363,3 +765,35 @@ func ConfigFileExistsAtPath(p string) (bool, error) {
 	_, err = os.Stat(p)
 	return !os.IsNotExist(err), nil
 }
+
+// FindConfigFiles - walks the tree under root for fly*.toml, fly*.yaml,
+// fly*.yml and fly*.json app config files,
+// skipping hidden directories and node_modules
+func FindConfigFiles(root string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			name := info.Name()
+			if p != root && (name == "node_modules" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		for _, pattern := range []string{"fly*.toml", "fly*.yaml", "fly*.yml", "fly*.json"} {
+			if match, _ := filepath.Match(pattern, info.Name()); match {
+				files = append(files, p)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -1,10 +1,12 @@
 package flyctl
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadTOMLAppConfigWithAppName(t *testing.T) {
@@ -28,6 +30,18 @@ func TestLoadTOMLAppConfigWithBuilderNameAndArgs(t *testing.T) {
 	assert.Equal(t, p.Build.Args, map[string]string{"A": "B", "C": "D"})
 }
 
+func TestLoadTOMLAppConfigWithBuildpacksAndEnv(t *testing.T) {
+	path := "./testdata/build-with-env.toml"
+	p, err := LoadAppConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gcr.io/paketo-buildpacks/go"}, p.Build.Buildpacks)
+	assert.Equal(t, map[string]string{"BP_GO_TARGETS": "./cmd/server"}, p.Build.Env)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteTo(&buf, TOMLFormat))
+	assert.Contains(t, buf.String(), `BP_GO_TARGETS = "./cmd/server"`)
+}
+
 func TestLoadTOMLAppConfigWithServices(t *testing.T) {
 	path := "./testdata/services.toml"
 	p, err := LoadAppConfig(path)
@@ -40,3 +54,188 @@ func TestLoadTOMLAppConfigWithServices(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, p.Definition, rawData)
 }
+
+func TestLoadTOMLAppConfigWithDockerfile(t *testing.T) {
+	path := "./testdata/multi/fly.worker.toml"
+	p, err := LoadAppConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "multi-worker", p.AppName)
+	assert.Equal(t, "Dockerfile.worker", p.Build.Dockerfile)
+	assert.Empty(t, p.Build.Args)
+}
+
+func TestLoadTOMLAppConfigWithBuildTargetAndContext(t *testing.T) {
+	path := "./testdata/build-context.toml"
+	p, err := LoadAppConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "production", p.Build.Target)
+	assert.Equal(t, "../..", p.Build.Context)
+	assert.Empty(t, p.Build.Args)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteTo(&buf, TOMLFormat))
+	assert.Contains(t, buf.String(), `build-target = "production"`)
+}
+
+func TestFindConfigFiles(t *testing.T) {
+	files, err := FindConfigFiles("testdata/multi")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"testdata/multi/fly.toml",
+		"testdata/multi/fly.worker.toml",
+		"testdata/multi/worker/fly.toml",
+	}, files)
+}
+
+func TestLoadTOMLAppConfigWithGPU(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/gpu.toml")
+	assert.NoError(t, err)
+
+	gpu, err := p.GPU()
+	assert.NoError(t, err)
+	assert.Equal(t, &GPU{Kind: "a100", Count: 2}, gpu)
+}
+
+func TestAppConfigGPU(t *testing.T) {
+	cases := []struct {
+		vm      map[string]interface{}
+		gpu     *GPU
+		wantErr bool
+	}{
+		{vm: nil, gpu: nil},
+		{vm: map[string]interface{}{"gpu_kind": "a100"}, gpu: &GPU{Kind: "a100", Count: 1}},
+		{vm: map[string]interface{}{"gpu_count": int64(1)}, wantErr: true},
+		{vm: map[string]interface{}{"gpu_kind": "a100", "gpu_count": int64(0)}, wantErr: true},
+		{vm: map[string]interface{}{"gpu_kind": "a100", "gpu_count": "two"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		ac := NewAppConfig()
+		if c.vm != nil {
+			ac.Definition["vm"] = c.vm
+		}
+
+		gpu, err := ac.GPU()
+		if c.wantErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, c.gpu, gpu)
+	}
+}
+
+func TestAppConfigStatics(t *testing.T) {
+	cfg := NewAppConfig()
+	require.NoError(t, cfg.unmarshalTOML(bytes.NewBufferString(`
+[[statics]]
+  guest_path = "/app/public"
+  url_prefix = "/"
+
+[[statics]]
+  guest_path = "/app/assets"
+  url_prefix = "/assets"
+`)))
+	statics, err := cfg.Statics()
+	require.NoError(t, err)
+	assert.Equal(t, []Static{
+		{GuestPath: "/app/public", URLPrefix: "/"},
+		{GuestPath: "/app/assets", URLPrefix: "/assets"},
+	}, statics)
+
+	cfg = NewAppConfig()
+	cfg.SetStatics([]Static{{GuestPath: "/srv", URLPrefix: "/"}})
+	statics, err = cfg.Statics()
+	require.NoError(t, err)
+	assert.Equal(t, []Static{{GuestPath: "/srv", URLPrefix: "/"}}, statics)
+
+	for _, invalid := range []interface{}{
+		[]interface{}{map[string]interface{}{"guest_path": "public", "url_prefix": "/"}},
+		[]interface{}{map[string]interface{}{"guest_path": "/srv", "url_prefix": "assets"}},
+		[]interface{}{
+			map[string]interface{}{"guest_path": "/a", "url_prefix": "/"},
+			map[string]interface{}{"guest_path": "/b", "url_prefix": "/"},
+		},
+		"/srv",
+	} {
+		cfg.Definition["statics"] = invalid
+		_, err := cfg.Statics()
+		assert.Error(t, err)
+	}
+}
+
+func TestAppConfigDeployHooks(t *testing.T) {
+	cfg := NewAppConfig()
+	require.NoError(t, cfg.unmarshalTOML(bytes.NewBufferString(`
+[deploy]
+  strategy = "rolling"
+  release_command = "bin/migrate"
+  post_deploy = "bin/notify --channel deploys"
+`)))
+	hooks, err := cfg.DeployHooks()
+	require.NoError(t, err)
+	assert.Equal(t, &DeployHooks{ReleaseCommand: "bin/migrate", PostDeploy: "bin/notify --channel deploys"}, hooks)
+
+	cfg = NewAppConfig()
+	cfg.Definition["deploy"] = map[string]interface{}{"strategy": "canary"}
+	hooks, err = cfg.DeployHooks()
+	assert.NoError(t, err)
+	assert.Nil(t, hooks)
+
+	cfg.Definition["deploy"] = map[string]interface{}{"post_deploy": []interface{}{"bin/notify"}}
+	_, err = cfg.DeployHooks()
+	assert.Error(t, err)
+}
+
+func TestSetStatics(t *testing.T) {
+	cfg := NewAppConfig()
+	cfg.SetStatics([]Static{{GuestPath: "/srv/http", URLPrefix: "/"}})
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteTo(&buf, TOMLFormat))
+
+	loaded := NewAppConfig()
+	require.NoError(t, loaded.unmarshalTOML(&buf))
+
+	statics, ok := loaded.Definition["statics"].([]map[string]interface{})
+	require.True(t, ok, "statics should be an array of tables")
+	assert.Equal(t, "/srv/http", statics[0]["guest_path"])
+	assert.Equal(t, "/", statics[0]["url_prefix"])
+}
+
+func TestEnvVariables(t *testing.T) {
+	cfg := NewAppConfig()
+	require.NoError(t, cfg.unmarshalTOML(bytes.NewBufferString(`
+[env]
+  LOG_LEVEL = "info"
+  WORKERS = 4
+`)))
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "WORKERS": "4"}, cfg.EnvVariables())
+
+	cfg = NewAppConfig()
+	cfg.SetEnvVariable("PORT", "8080")
+	assert.Equal(t, map[string]string{"PORT": "8080"}, cfg.EnvVariables())
+}
+
+func TestLoadTOMLAppConfigWithRemoteBuilderSize(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/remote-builder.toml")
+	require.NoError(t, err)
+	assert.Equal(t, RemoteBuilderSize{VMSize: "performance-4x", MemoryMB: 16384, DiskGB: 100}, p.RemoteBuilderSize())
+	assert.Empty(t, p.Build.Args)
+
+	merged := p.RemoteBuilderSize().Merge(RemoteBuilderSize{MemoryMB: 32768})
+	assert.Equal(t, RemoteBuilderSize{VMSize: "performance-4x", MemoryMB: 32768, DiskGB: 100}, merged)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteTo(&buf, TOMLFormat))
+	assert.Contains(t, buf.String(), `size = "performance-4x"`)
+	assert.Contains(t, buf.String(), "disk = 100")
+}
+
+func TestLoadTOMLAppConfigWithInvalidRemoteBuilderSize(t *testing.T) {
+	cfg := NewAppConfig()
+	err := cfg.unmarshalNativeMap(map[string]interface{}{
+		"build": map[string]interface{}{"remote_builder": map[string]interface{}{"memory": "lots"}},
+	})
+	assert.EqualError(t, err, "build.remote_builder: memory must be a whole number")
+}
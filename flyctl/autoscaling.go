@@ -0,0 +1,152 @@
+package flyctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Autoscaling - the [autoscaling] section of fly.toml. Unset fields leave the
+// app's current setting unchanged when applied.
+type Autoscaling struct {
+	MinCount          *int
+	MaxCount          *int
+	BalanceRegions    *bool
+	TargetConcurrency *int
+	ConcurrencyType   string
+	Regions           map[string]AutoscalingRegion
+}
+
+type AutoscalingRegion struct {
+	MinCount *int
+	Weight   *int
+}
+
+func unmarshalAutoscaling(data map[string]interface{}) (*Autoscaling, error) {
+	as := &Autoscaling{Regions: map[string]AutoscalingRegion{}}
+
+	var err error
+	if as.MinCount, err = optionalInt(data, "min_count"); err != nil {
+		return nil, fmt.Errorf("autoscaling: %w", err)
+	}
+	if as.MaxCount, err = optionalInt(data, "max_count"); err != nil {
+		return nil, fmt.Errorf("autoscaling: %w", err)
+	}
+	if as.TargetConcurrency, err = optionalInt(data, "target_concurrency"); err != nil {
+		return nil, fmt.Errorf("autoscaling: %w", err)
+	}
+
+	if v, ok := data["balance_regions"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("autoscaling.balance_regions must be true or false")
+		}
+		as.BalanceRegions = &b
+	}
+
+	if v, ok := data["concurrency_type"]; ok {
+		as.ConcurrencyType = fmt.Sprint(v)
+		if as.ConcurrencyType != "connections" && as.ConcurrencyType != "requests" {
+			return nil, fmt.Errorf("autoscaling.concurrency_type must be connections or requests")
+		}
+	}
+
+	if regions, ok := data["regions"].(map[string]interface{}); ok {
+		for code, v := range regions {
+			regionData, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("autoscaling.regions.%s must be a table", code)
+			}
+
+			var region AutoscalingRegion
+			if region.MinCount, err = optionalInt(regionData, "min_count"); err != nil {
+				return nil, fmt.Errorf("autoscaling.regions.%s: %w", code, err)
+			}
+			if region.Weight, err = optionalInt(regionData, "weight"); err != nil {
+				return nil, fmt.Errorf("autoscaling.regions.%s: %w", code, err)
+			}
+			as.Regions[code] = region
+		}
+	}
+
+	return as, nil
+}
+
+func marshalAutoscaling(as *Autoscaling) map[string]interface{} {
+	data := map[string]interface{}{}
+
+	if as.MinCount != nil {
+		data["min_count"] = *as.MinCount
+	}
+	if as.MaxCount != nil {
+		data["max_count"] = *as.MaxCount
+	}
+	if as.BalanceRegions != nil {
+		data["balance_regions"] = *as.BalanceRegions
+	}
+	if as.TargetConcurrency != nil {
+		data["target_concurrency"] = *as.TargetConcurrency
+	}
+	if as.ConcurrencyType != "" {
+		data["concurrency_type"] = as.ConcurrencyType
+	}
+
+	if len(as.Regions) > 0 {
+		regions := map[string]interface{}{}
+		for code, region := range as.Regions {
+			regionData := map[string]interface{}{}
+			if region.MinCount != nil {
+				regionData["min_count"] = *region.MinCount
+			}
+			if region.Weight != nil {
+				regionData["weight"] = *region.Weight
+			}
+			regions[code] = regionData
+		}
+		data["regions"] = regions
+	}
+
+	return data
+}
+
+// WriteTOML - writes the settings as an [autoscaling] section for fly.toml
+func (as *Autoscaling) WriteTOML(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(map[string]interface{}{
+		"autoscaling": marshalAutoscaling(as),
+	})
+}
+
+// optionalInt reads a whole number, as decoded from TOML, or from JSON as in
+// the definitions the API returns
+func optionalInt(data map[string]interface{}, key string) (*int, error) {
+	v, ok := data[key]
+	if !ok {
+		return nil, nil
+	}
+
+	var i int
+	switch n := v.(type) {
+	case int64:
+		i = int(n)
+	case int:
+		i = n
+	case float64:
+		if n != math.Trunc(n) {
+			return nil, fmt.Errorf("%s must be a whole number", key)
+		}
+		i = int(n)
+	case json.Number:
+		parsed, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", key)
+		}
+		i = int(parsed)
+	default:
+		return nil, fmt.Errorf("%s must be a whole number", key)
+	}
+
+	return &i, nil
+}
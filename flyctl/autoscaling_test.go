@@ -0,0 +1,74 @@
+package flyctl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTOMLAppConfigWithAutoscaling(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/autoscaling.toml")
+	assert.NoError(t, err)
+	assert.NotContains(t, p.Definition, "autoscaling")
+
+	as := p.Autoscaling
+	assert.Equal(t, 1, *as.MinCount)
+	assert.Equal(t, 10, *as.MaxCount)
+	assert.Equal(t, true, *as.BalanceRegions)
+	assert.Equal(t, 25, *as.TargetConcurrency)
+	assert.Equal(t, "requests", as.ConcurrencyType)
+	assert.Equal(t, 2, *as.Regions["ams"].MinCount)
+	assert.Equal(t, 3, *as.Regions["ams"].Weight)
+}
+
+func TestAutoscalingRoundTrip(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/autoscaling.toml")
+	assert.NoError(t, err)
+
+	var buf strings.Builder
+	assert.NoError(t, p.WriteTo(&buf, TOMLFormat))
+
+	reloaded := NewAppConfig()
+	assert.NoError(t, reloaded.unmarshalTOML(strings.NewReader(buf.String())))
+	assert.Equal(t, p.Autoscaling, reloaded.Autoscaling)
+}
+
+func TestAutoscalingValidation(t *testing.T) {
+	_, err := unmarshalAutoscaling(map[string]interface{}{"min_count": "one"})
+	assert.Error(t, err)
+
+	_, err = unmarshalAutoscaling(map[string]interface{}{"concurrency_type": "bytes"})
+	assert.Error(t, err)
+
+	_, err = unmarshalAutoscaling(map[string]interface{}{
+		"regions": map[string]interface{}{"ams": map[string]interface{}{"weight": 1.5}},
+	})
+	assert.Error(t, err)
+}
+
+func TestOptionalInt(t *testing.T) {
+	data := map[string]interface{}{
+		"toml":     int64(2),
+		"api":      float64(3),
+		"json":     json.Number("4"),
+		"fraction": 1.5,
+		"string":   "5",
+	}
+
+	for key, want := range map[string]int{"toml": 2, "api": 3, "json": 4} {
+		n, err := optionalInt(data, key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, want, *n, key)
+	}
+
+	for _, key := range []string{"fraction", "string"} {
+		_, err := optionalInt(data, key)
+		assert.Error(t, err, key)
+	}
+
+	n, err := optionalInt(data, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, n)
+}
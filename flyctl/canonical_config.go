@@ -0,0 +1,121 @@
+package flyctl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/superfly/flyctl/helpers"
+)
+
+// platformDefaults - the values the platform uses for settings that are left
+// out, as written in TOML, keyed by their path with array indexes dropped
+var platformDefaults = map[string]string{
+	"kill_signal":                     `"SIGINT"`,
+	"kill_timeout":                    "5",
+	"services.protocol":               `"tcp"`,
+	"services.concurrency.type":       `"connections"`,
+	"services.concurrency.hard_limit": "25",
+	"services.concurrency.soft_limit": "20",
+	"services.tcp_checks.interval":    "10000",
+	"services.tcp_checks.timeout":     "2000",
+}
+
+// AppConfigFromDefinition - the config of a deployed app from its
+// definition, with sections flyctl reads itself, such as [build], parsed as
+// they are from fly.toml
+func AppConfigFromDefinition(appName string, definition map[string]interface{}) (*AppConfig, error) {
+	data := make(map[string]interface{}, len(definition))
+	for k, v := range definition {
+		data[k] = v
+	}
+
+	ac := NewAppConfig()
+	if err := ac.unmarshalNativeMap(data); err != nil {
+		return nil, err
+	}
+	ac.AppName = appName
+
+	return ac, nil
+}
+
+// WriteCanonicalTo writes the config as a canonical fly.toml: the same config
+// is always written the same way, with keys sorted, no timestamp, and
+// settings left at the platform's defaults commented as such
+func (ac *AppConfig) WriteCanonicalTo(w io.Writer) error {
+	var body bytes.Buffer
+	if err := ac.marshalTOMLBody(&body); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "# fly.toml for %s, saved from its deployed config\n\n", ac.AppName)
+	_, err := io.WriteString(w, commentDefaults(body.String()))
+	return err
+}
+
+// WriteCanonicalYAMLTo writes the config as a canonical fly.yaml, with keys
+// sorted and no timestamp
+func (ac *AppConfig) WriteCanonicalYAMLTo(w io.Writer) error {
+	fmt.Fprintf(w, "# fly.yaml for %s, saved from its deployed config\n\n", ac.AppName)
+	return ac.marshalYAMLBody(w)
+}
+
+// WriteCanonicalToFile writes the config to filename in the format its
+// extension names, with WriteCanonicalTo for fly.toml
+func (ac *AppConfig) WriteCanonicalToFile(filename string) error {
+	if err := helpers.MkdirAll(filename); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch ConfigFormatFromPath(filename) {
+	case YAMLFormat:
+		return ac.WriteCanonicalYAMLTo(file)
+	case JSONFormat:
+		return ac.marshalJSON(file)
+	}
+	return ac.WriteCanonicalTo(file)
+}
+
+var (
+	tomlTablePattern = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*$`)
+	tomlKeyPattern   = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=\s*(.+?)\s*$`)
+)
+
+// commentDefaults adds a "# default" comment to the lines of data, TOML as
+// written by the encoder, setting a platform default
+func commentDefaults(data string) string {
+	var out strings.Builder
+	table := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil {
+			table = match[1]
+		} else if match := tomlKeyPattern.FindStringSubmatch(line); match != nil {
+			path := match[1]
+			if table != "" {
+				path = table + "." + path
+			}
+			if value, ok := platformDefaults[path]; ok && value == match[2] {
+				line += " # default"
+			}
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
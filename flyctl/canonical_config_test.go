@@ -0,0 +1,81 @@
+package flyctl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deployedDefinition - an app definition as the API returns it, with
+// numbers decoded from JSON
+func deployedDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"kill_timeout": float64(5),
+		"kill_signal":  "SIGTERM",
+		"env":          map[string]interface{}{"PORT": "8080", "LOG_LEVEL": "info"},
+		"build":        map[string]interface{}{"image": "flyio/hellofly:latest"},
+		"services": []interface{}{
+			map[string]interface{}{
+				"internal_port": float64(8080),
+				"protocol":      "tcp",
+				"concurrency":   map[string]interface{}{"hard_limit": float64(25), "soft_limit": float64(10)},
+				"ports": []interface{}{
+					map[string]interface{}{"port": float64(443), "handlers": []interface{}{"tls", "http"}},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteCanonicalTo(t *testing.T) {
+	ac, err := AppConfigFromDefinition("hello", deployedDefinition())
+	require.NoError(t, err)
+	require.NotNil(t, ac.Build)
+	assert.Equal(t, "flyio/hellofly:latest", ac.Build.Image)
+
+	var first, second bytes.Buffer
+	require.NoError(t, ac.WriteCanonicalTo(&first))
+	require.NoError(t, ac.WriteCanonicalTo(&second))
+	assert.Equal(t, first.String(), second.String(), "the same config is written the same way")
+
+	out := first.String()
+	assert.Contains(t, out, "kill_timeout = 5 # default\n")
+	assert.Contains(t, out, "kill_signal = \"SIGTERM\"\n")
+	assert.Contains(t, out, "hard_limit = 25 # default\n")
+	assert.Contains(t, out, "soft_limit = 10\n")
+	assert.Contains(t, out, "protocol = \"tcp\" # default\n")
+	assert.Less(t, bytes.Index(first.Bytes(), []byte("LOG_LEVEL")), bytes.Index(first.Bytes(), []byte("PORT")), "keys are sorted")
+}
+
+func TestCanonicalConfigRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	saved, err := AppConfigFromDefinition("hello", deployedDefinition())
+	require.NoError(t, err)
+	path := filepath.Join(dir, "fly.toml")
+	require.NoError(t, saved.WriteCanonicalToFile(path))
+
+	loaded, err := LoadAppConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", loaded.AppName)
+	assert.Equal(t, saved.Build, loaded.Build)
+
+	services, err := loaded.ServiceConfigs()
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, 8080, services[0].InternalPort)
+	assert.Equal(t, 10, services[0].Concurrency.SoftLimit)
+
+	var resaved bytes.Buffer
+	require.NoError(t, loaded.WriteCanonicalTo(&resaved))
+	original, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), resaved.String(), "saving a loaded config doesn't change it")
+}
@@ -0,0 +1,139 @@
+package flyctl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/spf13/viper"
+)
+
+const (
+	ConfigCLIFlags   = "cli.flags"
+	ConfigCLIAliases = "cli.aliases"
+)
+
+// CLIConfig - the [cli] section of fly.toml or cli key of ~/.fly/config.yml.
+// Flags are default values for the commands that take a default for a flag
+// of that name, aliases are command lines run in place of the alias name.
+type CLIConfig struct {
+	Flags   map[string]string
+	Aliases map[string]string
+}
+
+func NewCLIConfig() *CLIConfig {
+	return &CLIConfig{
+		Flags:   map[string]string{},
+		Aliases: map[string]string{},
+	}
+}
+
+// UserCLIConfig - the cli settings from ~/.fly/config.yml
+func UserCLIConfig() *CLIConfig {
+	return &CLIConfig{
+		Flags:   viper.GetStringMapString(ConfigCLIFlags),
+		Aliases: viper.GetStringMapString(ConfigCLIAliases),
+	}
+}
+
+// Merge - returns a config with the settings of both, preferring other's
+func (c *CLIConfig) Merge(other *CLIConfig) *CLIConfig {
+	merged := NewCLIConfig()
+
+	for _, cfg := range []*CLIConfig{c, other} {
+		if cfg == nil {
+			continue
+		}
+		for k, v := range cfg.Flags {
+			merged.Flags[k] = v
+		}
+		for k, v := range cfg.Aliases {
+			merged.Aliases[k] = v
+		}
+	}
+
+	return merged
+}
+
+func unmarshalCLIConfig(data map[string]interface{}) *CLIConfig {
+	cfg := NewCLIConfig()
+
+	if flags, ok := data["flags"].(map[string]interface{}); ok {
+		for k, v := range flags {
+			cfg.Flags[k] = flagValueString(v)
+		}
+	}
+
+	if aliases, ok := data["aliases"].(map[string]interface{}); ok {
+		for k, v := range aliases {
+			cfg.Aliases[k] = fmt.Sprint(v)
+		}
+	}
+
+	return cfg
+}
+
+func marshalCLIConfig(cfg *CLIConfig) map[string]interface{} {
+	data := map[string]interface{}{}
+	if len(cfg.Flags) > 0 {
+		data["flags"] = cfg.Flags
+	}
+	if len(cfg.Aliases) > 0 {
+		data["aliases"] = cfg.Aliases
+	}
+	return data
+}
+
+// flagValueString converts a toml value to the form pflag's Set expects,
+// joining arrays with commas for slice flags
+func flagValueString(v interface{}) string {
+	if values, ok := v.([]interface{}); ok {
+		parts := make([]string, len(values))
+		for i, value := range values {
+			parts[i] = fmt.Sprint(value)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// ExpandAlias - replaces an alias at the start of args with its command line.
+// Names of existing commands are never expanded.
+func ExpandAlias(args []string, aliases map[string]string, isCommand func(string) bool) ([]string, error) {
+	if len(args) == 0 || isCommand(args[0]) {
+		return args, nil
+	}
+
+	expansion, ok := aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+
+	expanded, err := shlex.Split(expansion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias %s: %w", args[0], err)
+	}
+
+	return append(expanded, args[1:]...), nil
+}
+
+// SetAlias - saves an alias to ~/.fly/config.yml
+func SetAlias(name string, expansion string) error {
+	aliases := viper.GetStringMapString(ConfigCLIAliases)
+	aliases[name] = expansion
+	viper.Set(ConfigCLIAliases, aliases)
+
+	return SaveConfig()
+}
+
+// RemoveAlias - removes an alias from ~/.fly/config.yml
+func RemoveAlias(name string) error {
+	aliases := viper.GetStringMapString(ConfigCLIAliases)
+	if _, ok := aliases[name]; !ok {
+		return fmt.Errorf("alias %s not found", name)
+	}
+	delete(aliases, name)
+	viper.Set(ConfigCLIAliases, aliases)
+
+	return SaveConfig()
+}
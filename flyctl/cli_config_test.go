@@ -0,0 +1,55 @@
+package flyctl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTOMLAppConfigWithCLI(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/cli.toml")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"remote-only": "true", "region": "ord", "env": "A=B,C=D"}, p.CLI.Flags)
+	assert.Equal(t, map[string]string{"shipit": "deploy --strategy canary"}, p.CLI.Aliases)
+	assert.NotContains(t, p.Definition, "cli")
+}
+
+func TestCLIConfigMerge(t *testing.T) {
+	user := &CLIConfig{
+		Flags:   map[string]string{"region": "ams", "remote-only": "true"},
+		Aliases: map[string]string{"shipit": "deploy"},
+	}
+	app := &CLIConfig{
+		Flags: map[string]string{"region": "ord"},
+	}
+
+	merged := user.Merge(app)
+	assert.Equal(t, map[string]string{"region": "ord", "remote-only": "true"}, merged.Flags)
+	assert.Equal(t, map[string]string{"shipit": "deploy"}, merged.Aliases)
+
+	assert.Equal(t, user.Flags, user.Merge(nil).Flags)
+}
+
+func TestExpandAlias(t *testing.T) {
+	aliases := map[string]string{
+		"shipit": `deploy --strategy canary --image-label "release candidate"`,
+		"deploy": "status",
+	}
+	isCommand := func(name string) bool { return name == "deploy" }
+
+	args, err := ExpandAlias([]string{"shipit", "-a", "myapp"}, aliases, isCommand)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "--strategy", "canary", "--image-label", "release candidate", "-a", "myapp"}, args)
+
+	args, err = ExpandAlias([]string{"deploy"}, aliases, isCommand)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deploy"}, args)
+
+	args, err = ExpandAlias([]string{"other"}, aliases, isCommand)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other"}, args)
+
+	args, err = ExpandAlias(nil, aliases, isCommand)
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+}
@@ -6,18 +6,43 @@ import (
 
 const (
 	ConfigAPIToken        = "access_token"
+	ConfigProfile         = "profile"
 	ConfigAPIBaseURL      = "api_base_url"
 	ConfigAppName         = "app"
 	ConfigVerboseOutput   = "verbose"
 	ConfigJSONOutput      = "json"
+	ConfigOutputFormat    = "output"
+	ConfigAutoConfirm     = "auto_confirm"
+	ConfigProgress        = "progress"
 	ConfigBuiltinsfile    = "builtins_file"
 	ConfigGQLErrorLogging = "gqlerrorlogging"
 	ConfigInstaller       = "installer"
 	BuildKitNodeID        = "buildkit_node_id"
 
+	// ConfigAPITokenInKeyring - the access token is in the OS keyring rather
+	// than the config file
+	ConfigAPITokenInKeyring = "access_token_in_keyring"
+	// ConfigCredentialStore - where access tokens are stored: auto, keyring or file
+	ConfigCredentialStore = "credential_store"
+	// ConfigKeyringUnavailable - storing a token in the OS keyring failed,
+	// so the token in the config file isn't moved there on every run
+	ConfigKeyringUnavailable = "keyring_unavailable"
+
 	ConfigWireGuardState = "wire_guard_state"
+	// ConfigWireGuardWebsockets - tunnel WireGuard over WebSockets rather than UDP
+	ConfigWireGuardWebsockets = "wg_websockets"
 
 	ConfigRegistryHost = "registry_host"
+
+	ConfigAPIMaxRetries     = "api_max_retries"
+	ConfigAPITimeout        = "api_timeout"
+	ConfigAPIAttemptTimeout = "api_attempt_timeout"
+
+	ConfigDebugHTTP     = "debug_http"
+	ConfigDebugHTTPFile = "debug_http_file"
+
+	// ConfigBuildContextWarnSize - in megabytes, 0 turns the warning off
+	ConfigBuildContextWarnSize = "build_context_warn_size"
 )
 
 const NSRoot = "flyctl"
@@ -76,3 +101,36 @@ func ConfigNS(ns string) Config {
 }
 
 var FlyConfig Config = ConfigNS(NSRoot)
+
+// overrideConfig - a config with some string and bool values replaced
+type overrideConfig struct {
+	Config
+	values map[string]interface{}
+}
+
+// ConfigWithOverrides returns base with the values of the keys in values,
+// strings or bools, replaced
+func ConfigWithOverrides(base Config, values map[string]interface{}) Config {
+	return &overrideConfig{Config: base, values: values}
+}
+
+func (cfg *overrideConfig) GetString(key string) string {
+	if v, ok := cfg.values[key].(string); ok {
+		return v
+	}
+	return cfg.Config.GetString(key)
+}
+
+func (cfg *overrideConfig) GetBool(key string) bool {
+	if v, ok := cfg.values[key].(bool); ok {
+		return v
+	}
+	return cfg.Config.GetBool(key)
+}
+
+func (cfg *overrideConfig) IsSet(key string) bool {
+	if _, ok := cfg.values[key]; ok {
+		return true
+	}
+	return cfg.Config.IsSet(key)
+}
@@ -0,0 +1,142 @@
+package flyctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fly.yaml and fly.json are read into the same native map as fly.toml, so
+// every section is parsed the same way whichever format it's written in.
+// Their values are normalized to the types the TOML decoder produces: maps
+// keyed by strings, and whole numbers as int64.
+
+func (ac *AppConfig) unmarshalYAML(r io.Reader) error {
+	var data map[interface{}]interface{}
+
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil && err != io.EOF {
+		return err
+	}
+
+	native, ok := normalizeConfigValue(data).(map[string]interface{})
+	if !ok {
+		native = map[string]interface{}{}
+	}
+
+	return ac.unmarshalNativeMap(native)
+}
+
+func (ac *AppConfig) unmarshalJSON(r io.Reader) error {
+	var data map[string]interface{}
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&data); err != nil && err != io.EOF {
+		return err
+	}
+
+	native, ok := normalizeConfigValue(data).(map[string]interface{})
+	if !ok {
+		native = map[string]interface{}{}
+	}
+
+	return ac.unmarshalNativeMap(native)
+}
+
+// marshalYAMLBody writes the config as YAML without a header, app first and
+// the other keys sorted
+func (ac AppConfig) marshalYAMLBody(w io.Writer) error {
+	out, err := yaml.Marshal(map[string]interface{}{"app": ac.AppName})
+	if err != nil {
+		return err
+	}
+
+	if rawData := ac.nativeMap(); len(rawData) > 0 {
+		body, err := yaml.Marshal(normalizeConfigValue(rawData))
+		if err != nil {
+			return err
+		}
+		out = append(out, body...)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// marshalJSON writes the config as indented JSON. JSON has no comments, so
+// unlike the other formats it's written without a header.
+func (ac AppConfig) marshalJSON(w io.Writer) error {
+	rawData := ac.nativeMap()
+	rawData["app"] = ac.AppName
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(normalizeConfigValue(rawData))
+}
+
+// normalizeConfigValue converts v, decoded from YAML or JSON or taken from a
+// definition returned by the API, to the types the TOML decoder produces
+func normalizeConfigValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = normalizeConfigValue(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = normalizeConfigValue(val)
+		}
+		return m
+	case []map[string]interface{}:
+		s := make([]map[string]interface{}, len(v))
+		for i, val := range v {
+			s[i] = normalizeConfigValue(val).(map[string]interface{})
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = normalizeConfigValue(val)
+		}
+		return tableArray(s)
+	case int:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
+
+// tableArray - s as an array of tables, like TOML's [[services]], when every
+// item in it is a table
+func tableArray(s []interface{}) interface{} {
+	if len(s) == 0 {
+		return s
+	}
+	tables := make([]map[string]interface{}, len(s))
+	for i, item := range s {
+		table, ok := item.(map[string]interface{})
+		if !ok {
+			return s
+		}
+		tables[i] = table
+	}
+	return tables
+}
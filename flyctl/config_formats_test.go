@@ -0,0 +1,68 @@
+package flyctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadYAMLAppConfigWithServices(t *testing.T) {
+	fromTOML, err := LoadAppConfig("./testdata/services.toml")
+	require.NoError(t, err)
+
+	fromYAML, err := LoadAppConfig("./testdata/services.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "services", fromYAML.AppName)
+	assert.Equal(t, fromTOML.Definition, fromYAML.Definition)
+}
+
+func TestConvertAppConfig(t *testing.T) {
+	for _, file := range []string{"processes.toml", "autoscaling.toml", "build-with-env.toml", "remote-builder.toml", "cli.toml"} {
+		t.Run(file, func(t *testing.T) {
+			original, err := LoadAppConfig(filepath.Join("testdata", file))
+			require.NoError(t, err)
+
+			dir := t.TempDir()
+			path := filepath.Join("testdata", file)
+			for _, name := range []string{"fly.yaml", "fly.json", "fly.toml"} {
+				prev := path
+				path = filepath.Join(dir, name)
+
+				loaded, err := LoadAppConfig(prev)
+				require.NoError(t, err)
+				require.NoError(t, loaded.WriteToFile(path))
+
+				converted, err := LoadAppConfig(path)
+				require.NoError(t, err, name)
+				assert.Equal(t, original, converted, name)
+			}
+		})
+	}
+}
+
+func TestResolveConfigFileFromPath(t *testing.T) {
+	dir := t.TempDir()
+
+	resolve := func(p string) string {
+		resolved, err := ResolveConfigFileFromPath(p)
+		require.NoError(t, err)
+		return filepath.Base(resolved)
+	}
+
+	assert.Equal(t, "fly.toml", resolve(dir), "fly.toml is the default when there's no config")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.json"), []byte(`{"app": "json"}`), 0644))
+	assert.Equal(t, "fly.json", resolve(dir))
+	assert.Equal(t, "fly.toml", resolve(filepath.Join(dir, "fly.toml")), "a file that's given isn't replaced")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.yaml"), []byte("app: yaml\n"), 0644))
+	assert.Equal(t, "fly.yaml", resolve(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte(`app = "toml"`), 0644))
+	assert.Equal(t, "fly.toml", resolve(dir))
+	assert.Equal(t, "fly.json", resolve(filepath.Join(dir, "fly.json")), "an existing file is used as is")
+}
@@ -0,0 +1,26 @@
+package flyctl
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigWithOverrides(t *testing.T) {
+	viper.Set("test.image", "base")
+	viper.Set("test.sbom", true)
+	viper.Set("test.strategy", "canary")
+	defer viper.Reset()
+
+	cfg := ConfigWithOverrides(ConfigNS("test"), map[string]interface{}{
+		"image": "registry.fly.io/api:deployment-1",
+		"sbom":  false,
+	})
+
+	assert.Equal(t, "registry.fly.io/api:deployment-1", cfg.GetString("image"))
+	assert.False(t, cfg.GetBool("sbom"))
+	assert.Equal(t, "canary", cfg.GetString("strategy"))
+	assert.True(t, cfg.IsSet("image"))
+	assert.False(t, cfg.IsSet("missing"))
+}
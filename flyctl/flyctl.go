@@ -68,8 +68,29 @@ func initViper() {
 	viper.SetEnvPrefix("FLY")
 	viper.AutomaticEnv()
 
+	migrateAPITokenToKeyring()
+
 	api.SetBaseURL(viper.GetString(ConfigAPIBaseURL))
 	api.SetErrorLog(viper.GetBool(ConfigGQLErrorLogging))
+	api.SetRetryConfig(apiRetryConfig())
+}
+
+// apiRetryConfig - the API retry settings from config.yml or FLY_API_MAX_RETRIES,
+// FLY_API_TIMEOUT and FLY_API_ATTEMPT_TIMEOUT
+func apiRetryConfig() api.RetryConfig {
+	config := api.DefaultRetryConfig()
+
+	if viper.IsSet(ConfigAPIMaxRetries) {
+		config.MaxRetries = viper.GetInt(ConfigAPIMaxRetries)
+	}
+	if viper.IsSet(ConfigAPITimeout) {
+		config.Timeout = viper.GetDuration(ConfigAPITimeout)
+	}
+	if viper.IsSet(ConfigAPIAttemptTimeout) {
+		config.AttemptTimeout = viper.GetDuration(ConfigAPIAttemptTimeout)
+	}
+
+	return config
 }
 
 func loadConfig() error {
@@ -115,13 +136,43 @@ func GetAPIToken() string {
 		return apiToken
 	}
 
-	viperAuth := viper.GetString(ConfigAPIToken)
+	// a selected profile only uses its own credentials
+	if profile := CurrentProfile(); profile != "" {
+		return profileAccessToken(profile)
+	}
+
+	if viperAuth := viper.GetString(ConfigAPIToken); viperAuth != "" {
+		return viperAuth
+	}
 
-	return viperAuth
+	if viper.GetBool(ConfigAPITokenInKeyring) {
+		return loadSecret(keyringAPITokenKey)
+	}
+
+	return ""
+}
+
+// GetAPITokenSource describes where GetAPIToken's token comes from: the
+// environment variable, "profile:<name>", "keyring" or "config"
+func GetAPITokenSource() string {
+	for _, name := range []string{"FLY_ACCESS_TOKEN", "FLY_API_TOKEN"} {
+		if _, lookup := os.LookupEnv(name); lookup {
+			return name
+		}
+	}
+
+	if profile := CurrentProfile(); profile != "" {
+		return "profile:" + profile
+	}
+
+	if viper.GetString(ConfigAPIToken) == "" && viper.GetBool(ConfigAPITokenInKeyring) {
+		return "keyring"
+	}
 
+	return "config"
 }
 
-var writeableConfigKeys = []string{ConfigAPIToken, ConfigInstaller, ConfigWireGuardState, BuildKitNodeID}
+var writeableConfigKeys = []string{ConfigAPIToken, ConfigAPITokenInKeyring, ConfigKeyringUnavailable, ConfigInstaller, ConfigWireGuardState, BuildKitNodeID, "cli"}
 
 func SaveConfig() error {
 	BackgroundTaskWG.Add(1)
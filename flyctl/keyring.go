@@ -0,0 +1,193 @@
+package flyctl
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/superfly/flyctl/terminal"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// Where access tokens are stored, set with credential_store in config.yml
+// or FLY_CREDENTIAL_STORE
+const (
+	// CredentialStoreAuto - the OS keyring when there is one, otherwise the
+	// config file
+	CredentialStoreAuto = "auto"
+	// CredentialStoreKeyring - only the OS keyring, failing without one
+	CredentialStoreKeyring = "keyring"
+	// CredentialStoreFile - the config file, in plain text
+	CredentialStoreFile = "file"
+)
+
+// keyringService - the service flyctl's secrets are stored under in the
+// keyring, each keyed by keyringAPITokenKey or profileKeyringKey
+const keyringService = "flyctl"
+
+const keyringAPITokenKey = "access_token"
+
+func profileKeyringKey(name string) string {
+	return "profile:" + name
+}
+
+// keyringStore - an OS credential store: the macOS Keychain, Windows
+// Credential Manager or the Secret Service, such as GNOME Keyring, on Linux
+type keyringStore interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+type osKeyring struct{}
+
+func (osKeyring) Get(key string) (string, error) {
+	return gokeyring.Get(keyringService, key)
+}
+
+func (osKeyring) Set(key, secret string) error {
+	return gokeyring.Set(keyringService, key, secret)
+}
+
+func (osKeyring) Delete(key string) error {
+	return gokeyring.Delete(keyringService, key)
+}
+
+// keyring - where tokens are stored, replaced in tests
+var keyring keyringStore = osKeyring{}
+
+// keyringSecrets caches secrets read from the keyring, which can be slow
+// and is read for every API client
+var keyringSecrets = struct {
+	sync.Mutex
+	values map[string]string
+}{values: map[string]string{}}
+
+// CredentialStore - where access tokens are stored, from credential_store
+// in config.yml or FLY_CREDENTIAL_STORE
+func CredentialStore() string {
+	switch store := viper.GetString(ConfigCredentialStore); store {
+	case CredentialStoreKeyring, CredentialStoreFile:
+		return store
+	}
+	return CredentialStoreAuto
+}
+
+// storeSecret stores secret in the keyring under key, returning whether it
+// was. With the auto store, secrets are left for the config file when
+// there's no keyring.
+func storeSecret(key, secret string) (bool, error) {
+	store := CredentialStore()
+	if store == CredentialStoreFile {
+		return false, nil
+	}
+
+	if err := keyring.Set(key, secret); err != nil {
+		if store == CredentialStoreKeyring {
+			return false, fmt.Errorf("can't store the access token in the OS keyring: %w", err)
+		}
+		terminal.Debugf("No OS keyring, storing the access token in the config file: %v\n", err)
+		return false, nil
+	}
+
+	keyringSecrets.Lock()
+	keyringSecrets.values[key] = secret
+	keyringSecrets.Unlock()
+	return true, nil
+}
+
+// loadSecret reads key from the keyring, empty when it can't be read
+func loadSecret(key string) string {
+	keyringSecrets.Lock()
+	defer keyringSecrets.Unlock()
+
+	if secret, ok := keyringSecrets.values[key]; ok {
+		return secret
+	}
+
+	secret, err := keyring.Get(key)
+	if err != nil {
+		terminal.Debugf("Error reading %s from the OS keyring: %v\n", key, err)
+		return ""
+	}
+	keyringSecrets.values[key] = secret
+	return secret
+}
+
+// deleteSecret removes key from the keyring
+func deleteSecret(key string) error {
+	keyringSecrets.Lock()
+	delete(keyringSecrets.values, key)
+	keyringSecrets.Unlock()
+
+	if err := keyring.Delete(key); err != nil && err != gokeyring.ErrNotFound {
+		return fmt.Errorf("can't remove the access token from the OS keyring: %w", err)
+	}
+	return nil
+}
+
+// StoreAPIToken saves token as the default credentials, in the OS keyring
+// or the config file, returning which it was saved in
+func StoreAPIToken(token string) (string, error) {
+	inKeyring, err := storeSecret(keyringAPITokenKey, token)
+	if err != nil {
+		return "", err
+	}
+
+	where := "the config file"
+	if inKeyring {
+		viper.Set(ConfigAPIToken, "")
+		where = "the OS keyring"
+	} else {
+		viper.Set(ConfigAPIToken, token)
+	}
+	viper.Set(ConfigAPITokenInKeyring, inKeyring)
+	viper.Set(ConfigKeyringUnavailable, !inKeyring && CredentialStore() != CredentialStoreFile)
+
+	return where, SaveConfig()
+}
+
+// ClearAPIToken removes the default credentials, from the OS keyring and
+// the config file
+func ClearAPIToken() error {
+	if viper.GetBool(ConfigAPITokenInKeyring) {
+		if err := deleteSecret(keyringAPITokenKey); err != nil {
+			return err
+		}
+	}
+
+	viper.Set(ConfigAPIToken, "")
+	viper.Set(ConfigAPITokenInKeyring, false)
+	return SaveConfig()
+}
+
+// migrateAPITokenToKeyring moves a token in the config file to the OS
+// keyring, so it's no longer kept in plain text. Where there's no keyring,
+// as on headless Linux, that's remembered so it's only tried once, until the
+// next login.
+func migrateAPITokenToKeyring() {
+	if !viper.InConfig(ConfigAPIToken) || CredentialStore() == CredentialStoreFile || viper.GetBool(ConfigKeyringUnavailable) {
+		return
+	}
+	token := viper.GetString(ConfigAPIToken)
+	if token == "" {
+		return
+	}
+
+	inKeyring, err := storeSecret(keyringAPITokenKey, token)
+	if err != nil || !inKeyring {
+		viper.Set(ConfigKeyringUnavailable, true)
+		if err := SaveConfig(); err != nil {
+			terminal.Debug("error writing flyctl config", err)
+		}
+		return
+	}
+
+	viper.Set(ConfigAPIToken, "")
+	viper.Set(ConfigAPITokenInKeyring, true)
+	if err := SaveConfig(); err != nil {
+		terminal.Debug("error writing flyctl config", err)
+		return
+	}
+	terminal.Debug("Moved the access token from the config file to the OS keyring")
+}
@@ -0,0 +1,165 @@
+package flyctl
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// fakeKeyring keeps secrets in memory, or fails like a system without a
+// keyring when unavailable
+type fakeKeyring struct {
+	secrets     map[string]string
+	unavailable bool
+}
+
+var errNoKeyring = errors.New("The name org.freedesktop.secrets was not provided by any .service files")
+
+func (k *fakeKeyring) Get(key string) (string, error) {
+	if k.unavailable {
+		return "", errNoKeyring
+	}
+	secret, ok := k.secrets[key]
+	if !ok {
+		return "", gokeyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *fakeKeyring) Set(key, secret string) error {
+	if k.unavailable {
+		return errNoKeyring
+	}
+	k.secrets[key] = secret
+	return nil
+}
+
+func (k *fakeKeyring) Delete(key string) error {
+	if k.unavailable {
+		return errNoKeyring
+	}
+	if _, ok := k.secrets[key]; !ok {
+		return gokeyring.ErrNotFound
+	}
+	delete(k.secrets, key)
+	return nil
+}
+
+// withTestKeyring points the config dir at a temporary directory and
+// replaces the OS keyring, for the test
+func withTestKeyring(t *testing.T) *fakeKeyring {
+	if os.Getenv("FLY_API_TOKEN") != "" || os.Getenv("FLY_ACCESS_TOKEN") != "" {
+		t.Skip("tokens in the environment take precedence")
+	}
+
+	dir, err := ioutil.TempDir("", "flyctl")
+	require.NoError(t, err)
+
+	fake := &fakeKeyring{secrets: map[string]string{}}
+	prevConfigDir, prevKeyring := configDir, keyring
+	configDir, keyring = dir, fake
+	viper.SetConfigFile(configFilePath())
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		configDir, keyring = prevConfigDir, prevKeyring
+		keyringSecrets.values = map[string]string{}
+		for _, key := range []string{ConfigAPIToken, ConfigAPITokenInKeyring, ConfigCredentialStore, ConfigKeyringUnavailable} {
+			viper.Set(key, nil)
+		}
+	})
+	return fake
+}
+
+func readConfigFile(t *testing.T) string {
+	data, err := ioutil.ReadFile(filepath.Join(configDir, "config.yml"))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestStoreAPITokenInKeyring(t *testing.T) {
+	fake := withTestKeyring(t)
+
+	where, err := StoreAPIToken("secret-token")
+	require.NoError(t, err)
+	assert.Equal(t, "the OS keyring", where)
+	assert.Equal(t, "secret-token", fake.secrets[keyringAPITokenKey])
+	assert.NotContains(t, readConfigFile(t), "secret-token")
+
+	// read from the keyring rather than the cache
+	keyringSecrets.values = map[string]string{}
+	assert.Equal(t, "secret-token", GetAPIToken())
+	assert.Equal(t, "keyring", GetAPITokenSource())
+
+	require.NoError(t, ClearAPIToken())
+	assert.Empty(t, fake.secrets)
+	assert.Equal(t, "", GetAPIToken())
+}
+
+func TestStoreAPITokenWithoutKeyring(t *testing.T) {
+	fake := withTestKeyring(t)
+	fake.unavailable = true
+
+	where, err := StoreAPIToken("secret-token")
+	require.NoError(t, err)
+	assert.Equal(t, "the config file", where)
+	assert.Contains(t, readConfigFile(t), "secret-token")
+	assert.Equal(t, "secret-token", GetAPIToken())
+	assert.Equal(t, "config", GetAPITokenSource())
+
+	viper.Set(ConfigCredentialStore, CredentialStoreKeyring)
+	_, err = StoreAPIToken("other-token")
+	assert.Error(t, err, "the keyring store doesn't fall back to the config file")
+}
+
+func TestStoreAPITokenInFile(t *testing.T) {
+	fake := withTestKeyring(t)
+	viper.Set(ConfigCredentialStore, CredentialStoreFile)
+
+	where, err := StoreAPIToken("secret-token")
+	require.NoError(t, err)
+	assert.Equal(t, "the config file", where)
+	assert.Empty(t, fake.secrets)
+	assert.Contains(t, readConfigFile(t), "secret-token")
+}
+
+func TestMigrateAPITokenToKeyring(t *testing.T) {
+	fake := withTestKeyring(t)
+
+	require.NoError(t, ioutil.WriteFile(configFilePath(), []byte("access_token: plaintext-token\n"), 0600))
+	require.NoError(t, viper.ReadInConfig())
+
+	migrateAPITokenToKeyring()
+	assert.Equal(t, "plaintext-token", fake.secrets[keyringAPITokenKey])
+	assert.NotContains(t, readConfigFile(t), "plaintext-token")
+	assert.Equal(t, "plaintext-token", GetAPIToken())
+}
+
+func TestMigrateAPITokenToKeyringOnlyTriesOnce(t *testing.T) {
+	fake := withTestKeyring(t)
+	fake.unavailable = true
+
+	require.NoError(t, ioutil.WriteFile(configFilePath(), []byte("access_token: plaintext-token\n"), 0600))
+	require.NoError(t, viper.ReadInConfig())
+
+	migrateAPITokenToKeyring()
+	assert.Contains(t, readConfigFile(t), "plaintext-token")
+	assert.Contains(t, readConfigFile(t), ConfigKeyringUnavailable+": true")
+
+	// a keyring turning up later is only used from the next login
+	fake.unavailable = false
+	migrateAPITokenToKeyring()
+	assert.Empty(t, fake.secrets)
+
+	where, err := StoreAPIToken("new-token")
+	require.NoError(t, err)
+	assert.Equal(t, "the OS keyring", where)
+	assert.False(t, viper.GetBool(ConfigKeyringUnavailable))
+}
@@ -0,0 +1,139 @@
+package flyctl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProcessGroup - an entry of the [processes] section of fly.toml. An entry is
+// either the group's command, or a table of the command and how the group is
+// scaled. Unset scaling fields leave the group's current setting unchanged.
+type ProcessGroup struct {
+	Command  string
+	Count    *int
+	VMSize   string
+	MemoryMB *int
+}
+
+// HasScaling - whether the group sets its count or VM size
+func (pg *ProcessGroup) HasScaling() bool {
+	return pg.Count != nil || pg.VMSize != "" || pg.MemoryMB != nil
+}
+
+func unmarshalProcesses(data map[string]interface{}) (map[string]*ProcessGroup, error) {
+	groups := map[string]*ProcessGroup{}
+
+	for name, v := range data {
+		switch value := v.(type) {
+		case string:
+			groups[name] = &ProcessGroup{Command: value}
+		case map[string]interface{}:
+			group := &ProcessGroup{}
+
+			command, ok := value["command"].(string)
+			if !ok || command == "" {
+				return nil, fmt.Errorf("processes.%s.command is required", name)
+			}
+			group.Command = command
+
+			var err error
+			if group.Count, err = optionalInt(value, "count"); err != nil {
+				return nil, fmt.Errorf("processes.%s: %w", name, err)
+			}
+			if group.MemoryMB, err = optionalInt(value, "memory"); err != nil {
+				return nil, fmt.Errorf("processes.%s: %w", name, err)
+			}
+			if vmSize, ok := value["vm_size"]; ok {
+				group.VMSize = fmt.Sprint(vmSize)
+			}
+			if group.MemoryMB != nil && group.VMSize == "" {
+				return nil, fmt.Errorf("processes.%s.memory requires vm_size", name)
+			}
+
+			groups[name] = group
+		default:
+			return nil, fmt.Errorf("processes.%s must be a command or a table", name)
+		}
+	}
+
+	return groups, nil
+}
+
+func marshalProcesses(groups map[string]*ProcessGroup) map[string]interface{} {
+	data := map[string]interface{}{}
+
+	for name, group := range groups {
+		if !group.HasScaling() {
+			data[name] = group.Command
+			continue
+		}
+
+		groupData := map[string]interface{}{"command": group.Command}
+		if group.Count != nil {
+			groupData["count"] = *group.Count
+		}
+		if group.VMSize != "" {
+			groupData["vm_size"] = group.VMSize
+		}
+		if group.MemoryMB != nil {
+			groupData["memory"] = *group.MemoryMB
+		}
+		data[name] = groupData
+	}
+
+	return data
+}
+
+// processCommands - the name to command map the platform expects for [processes]
+func processCommands(groups map[string]*ProcessGroup) map[string]interface{} {
+	commands := map[string]interface{}{}
+	for name, group := range groups {
+		commands[name] = group.Command
+	}
+	return commands
+}
+
+// ProcessNames - the names of the config's process groups, sorted
+func (ac *AppConfig) ProcessNames() []string {
+	names := make([]string, 0, len(ac.Processes))
+	for name := range ac.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateProcesses - checks that services only reference defined process groups
+func (ac *AppConfig) ValidateProcesses() error {
+	var services []map[string]interface{}
+	switch v := ac.Definition["services"].(type) {
+	case []map[string]interface{}:
+		services = v
+	case []interface{}:
+		for _, service := range v {
+			if service, ok := service.(map[string]interface{}); ok {
+				services = append(services, service)
+			}
+		}
+	}
+
+	for i, service := range services {
+		processes, ok := service["processes"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, process := range processes {
+			name := fmt.Sprint(process)
+			// without [processes] the app runs a single group named app
+			if len(ac.Processes) == 0 && name == "app" {
+				continue
+			}
+			if _, ok := ac.Processes[name]; !ok {
+				return fmt.Errorf("services[%d] references process group %s, which is not defined in [processes]", i, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package flyctl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTOMLAppConfigWithProcesses(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/processes.toml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"web", "worker"}, p.ProcessNames())
+	assert.Equal(t, map[string]interface{}{
+		"web":    "bin/rails server",
+		"worker": "bin/sidekiq",
+	}, p.Definition["processes"])
+
+	assert.False(t, p.Processes["web"].HasScaling())
+
+	worker := p.Processes["worker"]
+	assert.Equal(t, "bin/sidekiq", worker.Command)
+	assert.Equal(t, 3, *worker.Count)
+	assert.Equal(t, "shared-cpu-2x", worker.VMSize)
+	assert.Equal(t, 1024, *worker.MemoryMB)
+
+	assert.NoError(t, p.ValidateProcesses())
+}
+
+func TestProcessesRoundTrip(t *testing.T) {
+	p, err := LoadAppConfig("./testdata/processes.toml")
+	assert.NoError(t, err)
+
+	var buf strings.Builder
+	assert.NoError(t, p.WriteTo(&buf, TOMLFormat))
+
+	reloaded := NewAppConfig()
+	assert.NoError(t, reloaded.unmarshalTOML(strings.NewReader(buf.String())))
+	assert.Equal(t, p.Processes, reloaded.Processes)
+	assert.Equal(t, p.Definition["processes"], reloaded.Definition["processes"])
+}
+
+func TestProcessesValidation(t *testing.T) {
+	_, err := unmarshalProcesses(map[string]interface{}{"worker": map[string]interface{}{"count": int64(1)}})
+	assert.Error(t, err)
+
+	_, err = unmarshalProcesses(map[string]interface{}{"worker": map[string]interface{}{"command": "bin/worker", "memory": int64(512)}})
+	assert.Error(t, err)
+
+	_, err = unmarshalProcesses(map[string]interface{}{"worker": int64(1)})
+	assert.Error(t, err)
+
+	ac := NewAppConfig()
+	ac.Processes = map[string]*ProcessGroup{"web": {Command: "bin/web"}}
+	ac.Definition["services"] = []map[string]interface{}{
+		{"processes": []interface{}{"worker"}},
+	}
+	assert.Error(t, ac.ValidateProcesses())
+
+	ac = NewAppConfig()
+	ac.Definition["services"] = []interface{}{
+		map[string]interface{}{"processes": []interface{}{"app"}},
+	}
+	assert.NoError(t, ac.ValidateProcesses())
+}
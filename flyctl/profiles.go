@@ -0,0 +1,142 @@
+package flyctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Profile - credentials for one of several accounts, selected with --profile
+// or FLY_PROFILE
+type Profile struct {
+	AccessToken string `yaml:"access_token"`
+	Email       string `yaml:"email,omitempty"`
+	// Keyring - the access token is in the OS keyring rather than the
+	// profiles file
+	Keyring bool `yaml:"keyring,omitempty"`
+}
+
+var validProfileName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateProfileName - profile names are used as keys in the profiles file
+func ValidateProfileName(name string) error {
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, numbers, - and _", name)
+	}
+	return nil
+}
+
+// CurrentProfile - the profile selected with --profile or FLY_PROFILE, empty
+// for the default credentials
+func CurrentProfile() string {
+	return viper.GetString(ConfigProfile)
+}
+
+func profilesFilePath() string {
+	return filepath.Join(configDir, "profiles.yml")
+}
+
+// LoadProfiles - every saved profile by name
+func LoadProfiles() (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+
+	data, err := ioutil.ReadFile(profilesFilePath())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", profilesFilePath(), err)
+	}
+
+	return profiles, nil
+}
+
+// ProfileNames - the names of the saved profiles, sorted
+func ProfileNames(profiles map[string]Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveProfile - adds or replaces a profile, with its access token in the OS
+// keyring when there is one
+func SaveProfile(name string, profile Profile) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	if !profile.Keyring && profile.AccessToken != "" {
+		inKeyring, err := storeSecret(profileKeyringKey(name), profile.AccessToken)
+		if err != nil {
+			return err
+		}
+		if inKeyring {
+			profile.AccessToken = ""
+			profile.Keyring = true
+		}
+	}
+
+	profiles[name] = profile
+
+	return writeProfiles(profiles)
+}
+
+// RemoveProfile - deletes a profile, returning false if there wasn't one
+func RemoveProfile(name string) (bool, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return false, err
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return false, nil
+	}
+	if profile.Keyring {
+		if err := deleteSecret(profileKeyringKey(name)); err != nil {
+			return false, err
+		}
+	}
+	delete(profiles, name)
+
+	return true, writeProfiles(profiles)
+}
+
+func writeProfiles(profiles map[string]Profile) error {
+	data, err := yaml.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(profilesFilePath(), data, 0600)
+}
+
+func profileAccessToken(name string) string {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return ""
+	}
+	profile := profiles[name]
+	if profile.Keyring {
+		return loadSecret(profileKeyringKey(name))
+	}
+	return profile.AccessToken
+}
@@ -0,0 +1,34 @@
+package flyctl
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	fake := withTestKeyring(t)
+
+	require.NoError(t, SaveProfile("work", Profile{AccessToken: "work-token", Email: "me@work.example"}))
+	require.NoError(t, SaveProfile("client-a", Profile{AccessToken: "client-token"}))
+	assert.Error(t, SaveProfile("has.dot", Profile{}))
+
+	profiles, err := LoadProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client-a", "work"}, ProfileNames(profiles))
+	assert.Equal(t, Profile{Email: "me@work.example", Keyring: true}, profiles["work"])
+	assert.Equal(t, "work-token", fake.secrets[profileKeyringKey("work")])
+
+	viper.Set(ConfigProfile, "work")
+	defer viper.Set(ConfigProfile, "")
+	assert.Equal(t, "work-token", GetAPIToken())
+	assert.Equal(t, "profile:work", GetAPITokenSource())
+
+	removed, err := RemoveProfile("work")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, "", GetAPIToken(), "a missing profile has no credentials")
+	assert.NotContains(t, fake.secrets, profileKeyringKey("work"))
+}
@@ -0,0 +1,236 @@
+package flyctl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// knownHandlers - the handlers Fly's proxy can apply to a port
+var knownHandlers = []string{"http", "tls", "pg_tls", "proxy_proto"}
+
+// ServiceConfig - a [[services]] section of the config
+type ServiceConfig struct {
+	Protocol     string
+	InternalPort int
+	Processes    []string
+	Ports        []ServicePortConfig
+	Concurrency  *ServiceConcurrency
+}
+
+// ServicePortConfig - an edge port of a service, and the handlers applied
+// to connections to it
+type ServicePortConfig struct {
+	Port     int
+	Handlers []string
+}
+
+// ServiceConcurrency - a service's concurrency limits
+type ServiceConcurrency struct {
+	Type      string
+	SoftLimit int
+	HardLimit int
+}
+
+// IsUDP - true for services of UDP packets rather than TCP connections
+func (s ServiceConfig) IsUDP() bool {
+	return strings.EqualFold(s.Protocol, "udp")
+}
+
+// HasUDP - true when any of services is UDP. UDP services are only reachable
+// on a dedicated IPv4 address.
+func HasUDP(services []ServiceConfig) bool {
+	for _, service := range services {
+		if service.IsUDP() {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceProblem - a likely mistake in the services config. Port is 0 for
+// problems with the service as a whole.
+type ServiceProblem struct {
+	Service int    `json:"service"`
+	Port    int    `json:"port,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p ServiceProblem) String() string {
+	if p.Port == 0 {
+		return fmt.Sprintf("services[%d]: %s", p.Service, p.Message)
+	}
+	return fmt.Sprintf("services[%d] port %d: %s", p.Service, p.Port, p.Message)
+}
+
+// ServiceConfigs - returns the config's [[services]] sections, whether it was
+// loaded from a file or returned by the API
+func (ac *AppConfig) ServiceConfigs() ([]ServiceConfig, error) {
+	entries, err := sectionList(ac.Definition["services"])
+	if err != nil {
+		return nil, fmt.Errorf("services: %w", err)
+	}
+
+	services := make([]ServiceConfig, 0, len(entries))
+	for i, entry := range entries {
+		service := ServiceConfig{}
+		service.Protocol, _ = entry["protocol"].(string)
+		service.InternalPort, _ = numberValue(entry["internal_port"])
+
+		if processes, ok := entry["processes"].([]interface{}); ok {
+			for _, process := range processes {
+				service.Processes = append(service.Processes, fmt.Sprint(process))
+			}
+		}
+
+		ports, err := sectionList(entry["ports"])
+		if err != nil {
+			return nil, fmt.Errorf("services[%d].ports: %w", i, err)
+		}
+		for j, port := range ports {
+			number, ok := numberValue(port["port"])
+			if !ok {
+				return nil, fmt.Errorf("services[%d].ports[%d].port must be a whole number", i, j)
+			}
+			portConfig := ServicePortConfig{Port: number}
+			if handlers, ok := port["handlers"].([]interface{}); ok {
+				for _, handler := range handlers {
+					portConfig.Handlers = append(portConfig.Handlers, fmt.Sprint(handler))
+				}
+			}
+			service.Ports = append(service.Ports, portConfig)
+		}
+
+		if concurrency, ok := entry["concurrency"].(map[string]interface{}); ok {
+			service.Concurrency = &ServiceConcurrency{}
+			service.Concurrency.Type, _ = concurrency["type"].(string)
+			service.Concurrency.SoftLimit, _ = numberValue(concurrency["soft_limit"])
+			service.Concurrency.HardLimit, _ = numberValue(concurrency["hard_limit"])
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+// CheckServices looks for common mistakes in services, such as the http
+// handler on 443 without tls, or the same edge port in more than one place
+func CheckServices(services []ServiceConfig) []ServiceProblem {
+	var problems []ServiceProblem
+
+	// services[i] using each protocol and port, to find duplicates
+	used := map[string]int{}
+
+	for i, service := range services {
+		switch strings.ToLower(service.Protocol) {
+		case "tcp", "udp":
+		case "":
+			problems = append(problems, ServiceProblem{Service: i, Message: "protocol isn't set, use tcp or udp"})
+		default:
+			problems = append(problems, ServiceProblem{Service: i, Message: fmt.Sprintf("unknown protocol %s, use tcp or udp", service.Protocol)})
+		}
+		if service.InternalPort == 0 {
+			problems = append(problems, ServiceProblem{Service: i, Message: "internal_port isn't set"})
+		}
+		if len(service.Ports) == 0 {
+			problems = append(problems, ServiceProblem{Service: i, Message: "no ports are exposed"})
+		}
+		if c := service.Concurrency; c != nil && c.SoftLimit > 0 && c.HardLimit > 0 && c.SoftLimit > c.HardLimit {
+			problems = append(problems, ServiceProblem{Service: i, Message: fmt.Sprintf("concurrency soft_limit %d is over hard_limit %d", c.SoftLimit, c.HardLimit)})
+		}
+
+		for _, port := range service.Ports {
+			key := fmt.Sprintf("%s/%d", strings.ToLower(service.Protocol), port.Port)
+			if other, ok := used[key]; ok {
+				if other == i {
+					problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: "listed more than once"})
+				} else {
+					problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: fmt.Sprintf("also exposed by services[%d]", other)})
+				}
+			} else {
+				used[key] = i
+			}
+
+			if port.Port < 1 || port.Port > 65535 {
+				problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: "not a valid port number"})
+			}
+
+			if service.IsUDP() {
+				if len(port.Handlers) > 0 {
+					problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: "handlers only apply to tcp services, remove them from udp ports"})
+				}
+				continue
+			}
+
+			for _, handler := range port.Handlers {
+				if !isKnownHandler(handler) {
+					problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: fmt.Sprintf("unknown handler %s, use %s", handler, strings.Join(knownHandlers, ", "))})
+				}
+			}
+
+			switch {
+			case port.Port == 443 && hasHandler(port, "http") && !hasHandler(port, "tls"):
+				problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: "has the http handler without tls, so HTTPS requests will fail"})
+			case port.Port == 80 && hasHandler(port, "tls"):
+				problems = append(problems, ServiceProblem{Service: i, Port: port.Port, Message: "has the tls handler, so plain HTTP requests will fail"})
+			}
+		}
+	}
+
+	return problems
+}
+
+func hasHandler(port ServicePortConfig, name string) bool {
+	for _, handler := range port.Handlers {
+		if handler == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownHandler(name string) bool {
+	for _, known := range knownHandlers {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionList converts a list of TOML tables, or of JSON objects, to maps
+func sectionList(value interface{}) ([]map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		entries := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("expected a list of sections")
+			}
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	}
+	return nil, errors.New("expected a list of sections")
+}
+
+// numberValue reads a whole number decoded from TOML or JSON
+func numberValue(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
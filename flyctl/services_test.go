@@ -0,0 +1,133 @@
+package flyctl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceConfigs(t *testing.T) {
+	cfg := NewAppConfig()
+	require.NoError(t, cfg.unmarshalTOML(bytes.NewBufferString(`
+[[services]]
+  internal_port = 8080
+  protocol = "tcp"
+  processes = ["web"]
+
+  [services.concurrency]
+    type = "requests"
+    soft_limit = 20
+    hard_limit = 25
+
+  [[services.ports]]
+    handlers = ["http"]
+    port = 80
+
+  [[services.ports]]
+    handlers = ["tls", "http"]
+    port = 443
+`)))
+
+	services, err := cfg.ServiceConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, []ServiceConfig{{
+		Protocol:     "tcp",
+		InternalPort: 8080,
+		Processes:    []string{"web"},
+		Ports: []ServicePortConfig{
+			{Port: 80, Handlers: []string{"http"}},
+			{Port: 443, Handlers: []string{"tls", "http"}},
+		},
+		Concurrency: &ServiceConcurrency{Type: "requests", SoftLimit: 20, HardLimit: 25},
+	}}, services)
+	assert.Empty(t, CheckServices(services))
+
+	// the API returns numbers as floats
+	cfg = NewAppConfig()
+	cfg.Definition["services"] = []interface{}{map[string]interface{}{
+		"internal_port": 8080.0,
+		"protocol":      "tcp",
+		"ports":         []interface{}{map[string]interface{}{"port": 443.0, "handlers": []interface{}{"tls"}}},
+	}}
+	services, err = cfg.ServiceConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, 8080, services[0].InternalPort)
+	assert.Equal(t, 443, services[0].Ports[0].Port)
+}
+
+func TestCheckServices(t *testing.T) {
+	services := []ServiceConfig{
+		{
+			Protocol:     "tcp",
+			InternalPort: 8080,
+			Ports: []ServicePortConfig{
+				{Port: 80, Handlers: []string{"tls"}},
+				{Port: 443, Handlers: []string{"http"}},
+			},
+			Concurrency: &ServiceConcurrency{SoftLimit: 50, HardLimit: 25},
+		},
+		{
+			Protocol: "tcp",
+			Ports: []ServicePortConfig{
+				{Port: 443, Handlers: []string{"tls", "htp"}},
+			},
+		},
+		{
+			Protocol:     "udp",
+			InternalPort: 5000,
+		},
+		{
+			Protocol:     "udp",
+			InternalPort: 27015,
+			Ports:        []ServicePortConfig{{Port: 27015, Handlers: []string{"tls"}}},
+		},
+		{
+			Protocol:     "sctp",
+			InternalPort: 9000,
+			Ports:        []ServicePortConfig{{Port: 9000}},
+		},
+	}
+
+	var messages []string
+	for _, problem := range CheckServices(services) {
+		messages = append(messages, problem.String())
+	}
+	assert.Equal(t, []string{
+		"services[0]: concurrency soft_limit 50 is over hard_limit 25",
+		"services[0] port 80: has the tls handler, so plain HTTP requests will fail",
+		"services[0] port 443: has the http handler without tls, so HTTPS requests will fail",
+		"services[1]: internal_port isn't set",
+		"services[1] port 443: also exposed by services[0]",
+		"services[1] port 443: unknown handler htp, use http, tls, pg_tls, proxy_proto",
+		"services[2]: no ports are exposed",
+		"services[3] port 27015: handlers only apply to tcp services, remove them from udp ports",
+		"services[4]: unknown protocol sctp, use tcp or udp",
+	}, messages)
+
+	assert.True(t, HasUDP(services))
+	assert.False(t, HasUDP(services[:2]))
+}
+
+func TestServiceConfigsUDP(t *testing.T) {
+	cfg := NewAppConfig()
+	require.NoError(t, cfg.unmarshalTOML(bytes.NewBufferString(`
+[[services]]
+  internal_port = 27015
+  protocol = "udp"
+
+  [[services.ports]]
+    port = 27015
+`)))
+
+	services, err := cfg.ServiceConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, []ServiceConfig{{
+		Protocol:     "udp",
+		InternalPort: 27015,
+		Ports:        []ServicePortConfig{{Port: 27015}},
+	}}, services)
+	assert.True(t, services[0].IsUDP())
+	assert.Empty(t, CheckServices(services))
+}
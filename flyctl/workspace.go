@@ -0,0 +1,80 @@
+package flyctl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// WorkspaceFileName - the file listing the apps of a workspace
+const WorkspaceFileName = "fly.workspace.toml"
+
+// Workspace - apps deployed together, such as the services of a monorepo,
+// listed in fly.workspace.toml:
+//
+//	concurrency = 2
+//
+//	[[apps]]
+//	  config = "services/api/fly.toml"
+//
+//	[[apps]]
+//	  name = "web-staging"
+//	  config = "services/web/fly.toml"
+type Workspace struct {
+	// Path - the workspace file, which app configs are relative to
+	Path string `toml:"-"`
+	// Concurrency - how many apps are deployed at once, 0 for all of them
+	Concurrency int            `toml:"concurrency,omitzero"`
+	Apps        []WorkspaceApp `toml:"apps"`
+}
+
+// WorkspaceApp - an app in a workspace
+type WorkspaceApp struct {
+	// Name - the app deployed, defaulting to the app in its config
+	Name string `toml:"name,omitempty"`
+	// Config - the app's config file, or directory containing one
+	Config string `toml:"config"`
+}
+
+// LoadWorkspace reads the workspace file at path, or fly.workspace.toml in
+// the directory at path
+func LoadWorkspace(path string) (*Workspace, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, WorkspaceFileName)
+	}
+
+	var ws Workspace
+	if _, err := toml.DecodeFile(path, &ws); err != nil {
+		return nil, fmt.Errorf("invalid workspace %s: %w", path, err)
+	}
+	ws.Path = path
+
+	if len(ws.Apps) == 0 {
+		return nil, fmt.Errorf("workspace %s has no [[apps]]", path)
+	}
+	for i, app := range ws.Apps {
+		if app.Config == "" {
+			return nil, fmt.Errorf("app %d in workspace %s has no config", i+1, path)
+		}
+	}
+	if ws.Concurrency < 0 {
+		return nil, fmt.Errorf("workspace %s has a negative concurrency", path)
+	}
+
+	return &ws, nil
+}
+
+// ConfigPath - the config file of app, resolved relative to the workspace
+func (ws *Workspace) ConfigPath(app WorkspaceApp) (string, error) {
+	path := app.Config
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(ws.Path), path)
+	}
+	return ResolveConfigFileFromPath(path)
+}
@@ -0,0 +1,40 @@
+package flyctl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	ws, err := LoadWorkspace("./testdata/workspace")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, ws.Concurrency)
+	assert.Equal(t, []WorkspaceApp{
+		{Config: "api"},
+		{Name: "web-staging", Config: "web/fly.toml"},
+	}, ws.Apps)
+
+	dir, err := filepath.Abs("./testdata/workspace")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, WorkspaceFileName), ws.Path)
+
+	path, err := ws.ConfigPath(ws.Apps[0])
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "api", "fly.toml"), path)
+
+	path, err = ws.ConfigPath(ws.Apps[1])
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "web", "fly.toml"), path)
+}
+
+func TestLoadWorkspaceWithoutApps(t *testing.T) {
+	_, err := LoadWorkspace("./testdata/build.toml")
+	assert.Error(t, err)
+
+	_, err = LoadWorkspace("./testdata/missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+// Package appname validates app names and generates new ones. App names are
+// used as hostnames under fly.dev, so they follow the rules of DNS labels.
+package appname
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// MaxLength - app names are a single DNS label
+const MaxLength = 63
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Validate returns why name can't be used as an app name, or nil if it can
+func Validate(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("app name can't be empty")
+	case len(name) > MaxLength:
+		return fmt.Errorf("app name %q is longer than %d characters", name, MaxLength)
+	case !validName.MatchString(name):
+		return fmt.Errorf("app name %q must start with a letter and only contain lowercase letters, numbers and dashes", name)
+	case name[len(name)-1] == '-':
+		return fmt.Errorf("app name %q can't end with a dash", name)
+	}
+	return nil
+}
+
+var adjectives = []string{
+	"autumn", "billowing", "bold", "brave", "calm", "crimson", "damp", "dawn",
+	"divine", "dry", "empty", "falling", "fragrant", "frosty", "gentle", "green",
+	"hidden", "holy", "icy", "late", "lingering", "little", "lively", "long",
+	"misty", "morning", "muddy", "nameless", "old", "patient", "polished", "proud",
+	"purple", "quiet", "red", "restless", "rough", "shy", "silent", "small",
+	"snowy", "solitary", "sparkling", "spring", "still", "summer", "sweet", "twilight",
+	"wandering", "weathered", "white", "wild", "winter", "withered", "young",
+}
+
+var nouns = []string{
+	"bird", "breeze", "brook", "bush", "butterfly", "cherry", "cloud", "darkness",
+	"dawn", "dew", "dream", "dust", "feather", "field", "fire", "firefly",
+	"flower", "fog", "forest", "frog", "frost", "glade", "glitter", "grass",
+	"haze", "hill", "lake", "leaf", "meadow", "moon", "morning", "mountain",
+	"night", "paper", "pine", "pond", "rain", "resonance", "river", "sea",
+	"shadow", "shape", "silence", "sky", "smoke", "snow", "sound", "star",
+	"sun", "sunset", "surf", "thunder", "tree", "violet", "voice", "water",
+	"waterfall", "wave", "wildflower", "wind", "wood",
+}
+
+// Generate returns a random name like "misty-river-4821". With prefix, the
+// name starts with it instead of an adjective, like "api-river-4821".
+func Generate(prefix string) string {
+	first := prefix
+	if first == "" {
+		first = adjectives[randomInt(len(adjectives))]
+	}
+	return fmt.Sprintf("%s-%s-%d", first, nouns[randomInt(len(nouns))], 1000+randomInt(9000))
+}
+
+func randomInt(max int) int {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0
+	}
+	return int(n.Int64())
+}
@@ -0,0 +1,28 @@
+package appname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"web", "my-app-2", "a"} {
+		assert.NoError(t, Validate(name), name)
+	}
+
+	for _, name := range []string{"", "My-App", "2fast", "-web", "web-", "web_app", "web.app", strings.Repeat("a", 64)} {
+		assert.Error(t, Validate(name), name)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		name := Generate("")
+		assert.NoError(t, Validate(name), name)
+		assert.Len(t, strings.Split(name, "-"), 3, name)
+	}
+
+	assert.True(t, strings.HasPrefix(Generate("api"), "api-"))
+}
@@ -0,0 +1,167 @@
+// Package apptemplate loads and installs app templates: a fly.toml, a Dockerfile and an
+// optional manifest describing what to provision when an app is launched from the template
+package apptemplate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ManifestFile - the optional file describing a template and what it provisions
+const ManifestFile = "fly-template.toml"
+
+// maxFileSize - templates are small text files, anything larger is probably a mistake
+const maxFileSize = 256 * 1024
+
+var requiredFiles = []string{"fly.toml", "Dockerfile"}
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+// Manifest - the contents of fly-template.toml
+type Manifest struct {
+	Name        string           `toml:"name" json:"name"`
+	Description string           `toml:"description" json:"description"`
+	Files       []string         `toml:"files" json:"files,omitempty"`
+	Secrets     []SecretManifest `toml:"secrets" json:"secrets,omitempty"`
+	Volumes     []VolumeManifest `toml:"volumes" json:"volumes,omitempty"`
+}
+
+// SecretManifest - a secret the app needs. Secrets with a Generate type are created
+// with secretgen, the rest are prompted for.
+type SecretManifest struct {
+	Name        string `toml:"name" json:"name"`
+	Description string `toml:"description" json:"description,omitempty"`
+	Generate    string `toml:"generate" json:"generate,omitempty"`
+}
+
+// VolumeManifest - a volume to create for the app
+type VolumeManifest struct {
+	Name   string `toml:"name" json:"name"`
+	SizeGb int    `toml:"size_gb" json:"size_gb"`
+}
+
+// Template - a template read from disk
+type Template struct {
+	Manifest Manifest
+	Files    map[string]string
+}
+
+// Load reads a template from dir. fly.toml and a Dockerfile are required, along with any
+// extra files listed in the manifest.
+func Load(dir string) (*Template, error) {
+	t := &Template{Files: map[string]string{}}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile)); err == nil {
+		manifest, err := ParseManifest(string(data))
+		if err != nil {
+			return nil, err
+		}
+		t.Manifest = *manifest
+		t.Files[ManifestFile] = ""
+	}
+
+	files := append(append([]string{}, requiredFiles...), t.Manifest.Files...)
+	for _, name := range files {
+		if err := checkPath(name); err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("template is missing %s", name)
+		}
+		if info.Size() > maxFileSize {
+			return nil, fmt.Errorf("template file %s is larger than %dKB", name, maxFileSize/1024)
+		}
+	}
+
+	for name := range t.Files {
+		files = append(files, name)
+	}
+
+	for _, name := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		t.Files[filepath.ToSlash(name)] = string(data)
+	}
+
+	return t, nil
+}
+
+// ParseManifest parses the contents of fly-template.toml
+func ParseManifest(content string) (*Manifest, error) {
+	var m Manifest
+	if _, err := toml.Decode(content, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	return &m, nil
+}
+
+// Validate checks the template can be published
+func (t *Template) Validate() error {
+	if !nameRegexp.MatchString(t.Manifest.Name) {
+		return fmt.Errorf("template name %q must be 2-63 lowercase letters, numbers or dashes", t.Manifest.Name)
+	}
+	if t.Manifest.Description == "" {
+		return fmt.Errorf("template %s needs a description", t.Manifest.Name)
+	}
+	for _, v := range t.Manifest.Volumes {
+		if v.Name == "" || v.SizeGb < 1 {
+			return fmt.Errorf("volumes in %s need a name and a size_gb of at least 1", ManifestFile)
+		}
+	}
+	return nil
+}
+
+// FileNames returns the template's files in a stable order
+func (t *Template) FileNames() []string {
+	names := []string{}
+	for name := range t.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Install writes the template's files into dir, refusing to overwrite existing files
+func Install(dir string, files map[string]string) error {
+	for name := range files {
+		if err := checkPath(name); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			return fmt.Errorf("%s already exists in %s", name, dir)
+		}
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPath makes sure a template file can't be read or written outside of the template directory
+func checkPath(name string) error {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("template file %s must be a relative path inside the template", name)
+	}
+	return nil
+}
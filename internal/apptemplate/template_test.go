@@ -0,0 +1,61 @@
+package apptemplate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadAndInstall(t *testing.T) {
+	src, err := ioutil.TempDir("", "template")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "fly.toml"), []byte("kill_signal = \"SIGINT\"\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM nginx\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, ManifestFile), []byte(`
+name = "static-site"
+description = "nginx serving static files"
+
+[[secrets]]
+name = "SECRET_KEY"
+generate = "hex64"
+`), 0644))
+
+	tmpl, err := Load(src)
+	assert.NoError(t, err)
+	assert.NoError(t, tmpl.Validate())
+	assert.Equal(t, []string{"Dockerfile", ManifestFile, "fly.toml"}, tmpl.FileNames())
+	assert.Equal(t, "hex64", tmpl.Manifest.Secrets[0].Generate)
+
+	dest, err := ioutil.TempDir("", "template-install")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	assert.NoError(t, Install(dest, tmpl.Files))
+	assert.FileExists(t, filepath.Join(dest, "Dockerfile"))
+	assert.Error(t, Install(dest, tmpl.Files), "existing files are not overwritten")
+}
+
+func TestLoadRequiresFiles(t *testing.T) {
+	src, err := ioutil.TempDir("", "template")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "fly.toml"), []byte(""), 0644))
+
+	_, err = Load(src)
+	assert.Error(t, err)
+}
+
+func TestInstallRejectsEscapingPaths(t *testing.T) {
+	dest, err := ioutil.TempDir("", "template-install")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dest)
+
+	assert.Error(t, Install(dest, map[string]string{"../evil": "x"}))
+	assert.Error(t, Install(dest, map[string]string{"/etc/evil": "x"}))
+}
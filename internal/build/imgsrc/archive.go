@@ -2,9 +2,11 @@ package imgsrc
 
 import (
 	"archive/tar"
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/builder/dockerignore"
@@ -17,15 +19,15 @@ type archiveOptions struct {
 	exclusions []string
 	compressed bool
 	additions  map[string][]byte
+	// progress wraps the uncompressed archive, so progress is reported
+	// against the context's size rather than its compressed size
+	progress func(io.ReadCloser) io.ReadCloser
 }
 
 func archiveDirectory(options archiveOptions) (io.ReadCloser, error) {
 	opts := &archive.TarOptions{
 		ExcludePatterns: options.exclusions,
 	}
-	if options.compressed && len(options.additions) == 0 {
-		opts.Compression = archive.Gzip
-	}
 
 	r, err := archive.TarWithOptions(options.sourcePath, opts)
 	if err != nil {
@@ -48,19 +50,126 @@ func archiveDirectory(options archiveOptions) (io.ReadCloser, error) {
 		r = archive.ReplaceFileTarWrapper(r, mods)
 	}
 
+	if options.progress != nil {
+		r = options.progress(r)
+	}
+
+	if options.compressed && len(options.additions) == 0 {
+		r = gzipStream(r)
+	}
+
 	return r, nil
 }
 
+func gzipStream(r io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer r.Close()
+
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, r)
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// readDockerignore reads the exclusions from .dockerignore, or .flyignore
+// when there's no .dockerignore, so apps can keep files out of fly builds
+// without changing their docker builds
 func readDockerignore(workingDir string) ([]string, error) {
-	file, err := os.Open(filepath.Join(workingDir, ".dockerignore"))
-	if os.IsNotExist(err) {
-		return []string{}, nil
-	} else if err != nil {
+	for _, name := range []string{".dockerignore", ".flyignore"} {
+		file, err := os.Open(filepath.Join(workingDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return parseDockerignore(file)
+	}
+
+	return []string{}, nil
+}
+
+// contextSize - how much of a directory is sent as the build context
+type contextSize struct {
+	Files int
+	Bytes int64
+	// Entries - bytes sent from each file or directory at the top of the context
+	Entries map[string]int64
+}
+
+// measureContext adds up the size of the files archiveDirectory would send
+func measureContext(sourcePath string, exclusions []string) (*contextSize, error) {
+	pm, err := fileutils.NewPatternMatcher(exclusions)
+	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	return parseDockerignore(file)
+	size := &contextSize{Entries: map[string]int64{}}
+
+	err = filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		excluded, err := pm.Matches(rel)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			// files in an excluded directory may be included again by a later pattern
+			if info.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		size.Files++
+		size.Bytes += info.Size()
+		size.Entries[strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]] += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return size, nil
+}
+
+// Largest - the n largest top level files and directories, biggest first
+func (s *contextSize) Largest(n int) []string {
+	names := make([]string, 0, len(s.Entries))
+	for name := range s.Entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Entries[names[i]] == s.Entries[names[j]] {
+			return names[i] < names[j]
+		}
+		return s.Entries[names[i]] > s.Entries[names[j]]
+	})
+
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
 }
 
 func parseDockerignore(r io.Reader) ([]string, error) {
@@ -69,8 +178,10 @@ func parseDockerignore(r io.Reader) ([]string, error) {
 		return nil, err
 	}
 
-	if match, _ := fileutils.Matches("fly.toml", excludes); !match {
-		excludes = append(excludes, "fly.toml")
+	for _, configFile := range []string{"fly.toml", "fly.yaml", "fly.yml", "fly.json"} {
+		if match, _ := fileutils.Matches(configFile, excludes); !match {
+			excludes = append(excludes, configFile)
+		}
 	}
 
 	if match, _ := fileutils.Matches(".dockerignore", excludes); match {
@@ -160,10 +160,10 @@ func TestArchiverNoCompressionWithAdditions(t *testing.T) {
 
 func TestParseDockerignore(t *testing.T) {
 	cases := map[string][]string{
-		"node_modules\n*.jpg":                {"node_modules", "*.jpg", "fly.toml"},
-		"node_modules\n*.jpg\nDockerfile":    {"node_modules", "*.jpg", "Dockerfile", "fly.toml", "![Dd]ockerfile"},
-		"node_modules\n*.jpg\ndockerfile":    {"node_modules", "*.jpg", "dockerfile", "fly.toml", "![Dd]ockerfile"},
-		"node_modules\n*.jpg\n.dockerignore": {"node_modules", "*.jpg", ".dockerignore", "fly.toml", "!.dockerignore"},
+		"node_modules\n*.jpg":                {"node_modules", "*.jpg", "fly.toml", "fly.yaml", "fly.yml", "fly.json"},
+		"node_modules\n*.jpg\nDockerfile":    {"node_modules", "*.jpg", "Dockerfile", "fly.toml", "fly.yaml", "fly.yml", "fly.json", "![Dd]ockerfile"},
+		"node_modules\n*.jpg\ndockerfile":    {"node_modules", "*.jpg", "dockerfile", "fly.toml", "fly.yaml", "fly.yml", "fly.json", "![Dd]ockerfile"},
+		"node_modules\n*.jpg\n.dockerignore": {"node_modules", "*.jpg", ".dockerignore", "fly.toml", "fly.yaml", "fly.yml", "fly.json", "!.dockerignore"},
 	}
 
 	for input, expected := range cases {
@@ -194,3 +194,72 @@ func TestIsPathInRoot(t *testing.T) {
 	}
 
 }
+
+func TestReadDockerignoreFallsBackToFlyignore(t *testing.T) {
+	testDir, err := newTestDir("a.jpg")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	excludes, err := readDockerignore(testDir)
+	assert.NoError(t, err)
+	assert.Empty(t, excludes)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(testDir, ".flyignore"), []byte("node_modules"), 0644))
+	excludes, err = readDockerignore(testDir)
+	assert.NoError(t, err)
+	assert.Contains(t, excludes, "node_modules")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(testDir, ".dockerignore"), []byte("*.jpg"), 0644))
+	excludes, err = readDockerignore(testDir)
+	assert.NoError(t, err)
+	assert.Contains(t, excludes, "*.jpg")
+	assert.NotContains(t, excludes, "node_modules")
+}
+
+func TestMeasureContext(t *testing.T) {
+	testDir, err := newTestDir("a.jpg", "content/foo.md", "node_modules/left-pad/index.js", "node_modules/left-pad/package.json")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	size, err := measureContext(testDir, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, size.Files)
+	assert.Equal(t, []string{"node_modules", "content"}, size.Largest(2))
+
+	size, err = measureContext(testDir, []string{"node_modules", "*.jpg"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size.Files)
+	assert.Equal(t, int64(len("content/foo.md")), size.Bytes)
+}
+
+func TestArchiverProgressBeforeCompression(t *testing.T) {
+	testDir, err := newTestDir("a.jpg", "content/foo.md")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	var wrapped *recordingReader
+	r, err := archiveDirectory(archiveOptions{sourcePath: testDir, compressed: true, progress: func(r io.ReadCloser) io.ReadCloser {
+		wrapped = &recordingReader{ReadCloser: r}
+		return wrapped
+	}})
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, archive.Gzip, archive.DetectCompression(data))
+
+	// the wrapper sees the whole uncompressed tar
+	names, _, err := unpackTar(io.NopCloser(strings.NewReader(wrapped.data.String())))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.jpg", "content/foo.md"}, names)
+}
+
+type recordingReader struct {
+	io.ReadCloser
+	data strings.Builder
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.data.Write(p[:n])
+	return n, err
+}
@@ -0,0 +1,92 @@
+package imgsrc
+
+import (
+	"fmt"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/pkg/iostreams"
+)
+
+// volumeExtension - a builder volume to grow to SizeGB
+type volumeExtension struct {
+	Volume api.Volume
+	SizeGB int
+}
+
+// builderSizeChanges returns the VM size to set and the volumes to grow for
+// a builder with the VM size current and volumes to be the size want. VM
+// size is nil when it's already right. Volumes are never shrunk.
+func builderSizeChanges(builderApp string, current api.VMSize, volumes []api.Volume, want flyctl.RemoteBuilderSize) (*api.SetVMSizeInput, []volumeExtension) {
+	var sizeInput *api.SetVMSizeInput
+	if (want.VMSize != "" && want.VMSize != current.Name) || (want.MemoryMB > 0 && want.MemoryMB != current.MemoryMB) {
+		input := api.SetVMSizeInput{AppID: builderApp, SizeName: current.Name, MemoryMb: int64(current.MemoryMB)}
+		if want.VMSize != "" && want.VMSize != current.Name {
+			// a new size brings its own memory, unless memory is set too
+			input.SizeName = want.VMSize
+			input.MemoryMb = 0
+		}
+		if want.MemoryMB > 0 {
+			input.MemoryMb = int64(want.MemoryMB)
+		}
+		sizeInput = &input
+	}
+
+	var extensions []volumeExtension
+	if want.DiskGB > 0 {
+		for _, volume := range volumes {
+			if volume.SizeGb < want.DiskGB {
+				extensions = append(extensions, volumeExtension{Volume: volume, SizeGB: want.DiskGB})
+			}
+		}
+	}
+
+	return sizeInput, extensions
+}
+
+// reconcileBuilderSize resizes builderApp's VM and grows its volumes to
+// size, reporting each change made. Builders restart when they're resized,
+// which the wait for the builder covers.
+func reconcileBuilderSize(apiClient *api.Client, builderApp string, size flyctl.RemoteBuilderSize, streams *iostreams.IOStreams) error {
+	current, _, err := apiClient.AppVMResources(builderApp)
+	if err != nil {
+		return fmt.Errorf("error fetching the size of remote builder %s: %w", builderApp, err)
+	}
+
+	var volumes []api.Volume
+	if size.DiskGB > 0 {
+		if volumes, err = apiClient.GetVolumes(builderApp); err != nil {
+			return fmt.Errorf("error fetching the volumes of remote builder %s: %w", builderApp, err)
+		}
+	}
+
+	sizeInput, extensions := builderSizeChanges(builderApp, current, volumes, size)
+
+	if sizeInput != nil {
+		resized, err := apiClient.SetAppVMSize(*sizeInput)
+		if err != nil {
+			return fmt.Errorf("error resizing remote builder %s: %w", builderApp, err)
+		}
+		fmt.Fprintf(streams.ErrOut, "Resized remote builder %s from %s with %d MB to %s with %d MB\n", builderApp, current.Name, current.MemoryMB, resized.Name, resized.MemoryMB)
+	}
+
+	restart := false
+	for _, extension := range extensions {
+		_, needsRestart, err := apiClient.ExtendVolume(extension.Volume.ID, extension.SizeGB)
+		if err != nil {
+			return fmt.Errorf("error extending remote builder %s's volume %s: %w", builderApp, extension.Volume.ID, err)
+		}
+		fmt.Fprintf(streams.ErrOut, "Extended remote builder %s's volume %s from %d GB to %d GB\n", builderApp, extension.Volume.ID, extension.Volume.SizeGb, extension.SizeGB)
+		restart = restart || needsRestart
+	}
+
+	// resizing the VM restarts the builder already
+	if restart && sizeInput == nil {
+		if _, err := apiClient.RestartApp(builderApp); err != nil {
+			return fmt.Errorf("error restarting remote builder %s to use its extended volume: %w", builderApp, err)
+		}
+		fmt.Fprintf(streams.ErrOut, "Restarted remote builder %s to use its extended volume\n", builderApp)
+	}
+
+	return nil
+}
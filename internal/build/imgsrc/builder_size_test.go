@@ -0,0 +1,34 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flyctl"
+)
+
+var sharedBuilder = api.VMSize{Name: "shared-cpu-1x", MemoryMB: 2048}
+
+func TestBuilderSizeChangesNothingToDo(t *testing.T) {
+	volumes := []api.Volume{{ID: "vol_1", SizeGb: 100}}
+	sizeInput, extensions := builderSizeChanges("fly-builder", sharedBuilder, volumes, flyctl.RemoteBuilderSize{VMSize: "shared-cpu-1x", MemoryMB: 2048, DiskGB: 50})
+
+	assert.Nil(t, sizeInput)
+	assert.Empty(t, extensions)
+}
+
+func TestBuilderSizeChangesNewSize(t *testing.T) {
+	sizeInput, _ := builderSizeChanges("fly-builder", sharedBuilder, nil, flyctl.RemoteBuilderSize{VMSize: "performance-4x"})
+	assert.Equal(t, &api.SetVMSizeInput{AppID: "fly-builder", SizeName: "performance-4x"}, sizeInput)
+
+	sizeInput, _ = builderSizeChanges("fly-builder", sharedBuilder, nil, flyctl.RemoteBuilderSize{MemoryMB: 4096})
+	assert.Equal(t, &api.SetVMSizeInput{AppID: "fly-builder", SizeName: "shared-cpu-1x", MemoryMb: 4096}, sizeInput)
+}
+
+func TestBuilderSizeChangesGrowsSmallVolumes(t *testing.T) {
+	volumes := []api.Volume{{ID: "vol_1", SizeGb: 50}, {ID: "vol_2", SizeGb: 200}}
+	_, extensions := builderSizeChanges("fly-builder", sharedBuilder, volumes, flyctl.RemoteBuilderSize{DiskGB: 100})
+
+	assert.Equal(t, []volumeExtension{{Volume: volumes[0], SizeGB: 100}}, extensions)
+}
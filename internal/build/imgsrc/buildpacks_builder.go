@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/buildpacks/pack"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/cmdfmt"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/pkg/iostreams"
 	"github.com/superfly/flyctl/terminal"
 )
@@ -25,6 +25,10 @@ func (s *buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClient
 		return nil, nil
 	}
 
+	if err := validateBuildpacksConfig(opts.AppConfig); err != nil {
+		return nil, err
+	}
+
 	if !opts.AppConfig.HasBuilder() {
 		terminal.Debug("no buildpack builder configured, skipping")
 		return nil, nil
@@ -45,14 +49,18 @@ func (s *buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClient
 		return nil, err
 	}
 
-	cmdfmt.PrintBegin(streams.ErrOut, "Building image with Buildpacks")
+	if dockerFactory.mode.IsRemote() {
+		cmdfmt.PrintBegin(streams.ErrOut, fmt.Sprintf("Building image with Buildpacks on a remote builder using %s", builder))
+	} else {
+		cmdfmt.PrintBegin(streams.ErrOut, fmt.Sprintf("Building image with Buildpacks using %s", builder))
+	}
 
 	err = packClient.Build(ctx, pack.BuildOptions{
 		AppPath:        opts.WorkingDir,
 		Builder:        builder,
 		Image:          newCacheTag(opts.AppName),
 		Buildpacks:     buildpacks,
-		Env:            normalizeBuildArgs(opts.AppConfig, opts.ExtraBuildArgs),
+		Env:            buildpacksEnv(opts.AppConfig, opts.ExtraBuildArgs),
 		TrustBuilder:   true,
 		AdditionalTags: []string{opts.Tag},
 	})
@@ -85,10 +93,25 @@ func (s *buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClient
 	}, nil
 }
 
-func normalizeBuildArgs(appConfig *flyctl.AppConfig, extra map[string]string) map[string]string {
+// validateBuildpacksConfig catches buildpacks configured without a builder
+// to run them, which would otherwise be ignored
+func validateBuildpacksConfig(appConfig *flyctl.AppConfig) error {
+	if appConfig.Build != nil && appConfig.Build.Builder == "" && len(appConfig.Build.Buildpacks) > 0 {
+		return flyerr.New(flyerr.InvalidConfig, "the [build] section lists buildpacks but no builder to run them, set builder too. See https://fly.io/docs/reference/configuration/#the-build-section")
+	}
+	return nil
+}
+
+// buildpacksEnv - the environment buildpacks build with: the [build.env]
+// section, then build args, with args given on the command line taking
+// precedence over both
+func buildpacksEnv(appConfig *flyctl.AppConfig, extra map[string]string) map[string]string {
 	var out = map[string]string{}
 
 	if appConfig.Build != nil {
+		for k, v := range appConfig.Build.Env {
+			out[k] = v
+		}
 		for k, v := range appConfig.Build.Args {
 			out[k] = v
 		}
@@ -117,7 +140,7 @@ func newPackLogger(out io.Writer) *packLogger {
 			Writer: packW,
 			src:    out,
 		},
-		debug: os.Getenv("LOG_LEVEL") == "debug",
+		debug: terminal.IsDebug(),
 	}
 }
 
@@ -57,6 +57,12 @@ func (ds *builtinBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 	}
 	archiveOpts.exclusions = excludes
 
+	size, err := reportContextSize(streams, opts.WorkingDir, excludes, opts.ContextWarnSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "error measuring build context")
+	}
+	archiveOpts.progress = contextProgress(streams, size.Bytes)
+
 	// copy dockerfile into the archive if it's outside the context dir
 	archiveOpts.additions = map[string][]byte{
 		"Dockerfile": []byte(vdockerfile),
@@ -73,7 +79,8 @@ func (ds *builtinBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 	cmdfmt.PrintBegin(streams.ErrOut, "Building image with Docker")
 
 	buildArgs := normalizeBuildArgsForDocker(opts.AppConfig, opts.ExtraBuildArgs)
-	imageID, err = runClassicBuild(ctx, streams, docker, r, opts, "", buildArgs)
+	auths := authConfigs(dockerFactory.mode.IsRemote(), opts.ForwardRegistryAuth, streams)
+	imageID, err = runClassicBuild(ctx, streams, docker, r, opts, "", buildArgs, auths)
 	if err != nil {
 		return nil, errors.Wrap(err, "error building")
 	}
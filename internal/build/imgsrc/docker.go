@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -20,6 +21,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/internal/monitor"
 	"github.com/superfly/flyctl/internal/wireguard"
 	"github.com/superfly/flyctl/pkg/iostreams"
@@ -33,7 +35,7 @@ type dockerClientFactory struct {
 	buildFn func(ctx context.Context) (*dockerclient.Client, error)
 }
 
-func newDockerClientFactory(daemonType DockerDaemonType, apiClient *api.Client, appName string, streams *iostreams.IOStreams) *dockerClientFactory {
+func newDockerClientFactory(daemonType DockerDaemonType, apiClient *api.Client, appName string, builder RemoteBuilderOptions, streams *iostreams.IOStreams) *dockerClientFactory {
 	if daemonType.AllowLocal() {
 		terminal.Debug("trying local docker daemon")
 		c, err := newLocalDockerClient()
@@ -55,20 +57,30 @@ func newDockerClientFactory(daemonType DockerDaemonType, apiClient *api.Client,
 		terminal.Debug("trying remote docker daemon")
 		var cachedDocker *dockerclient.Client
 
-		return &dockerClientFactory{
-			mode: DockerDaemonTypeRemote,
-			buildFn: func(ctx context.Context) (*dockerclient.Client, error) {
-				if cachedDocker != nil {
-					return cachedDocker, nil
+		factory := &dockerClientFactory{mode: DockerDaemonTypeRemote}
+		factory.buildFn = func(ctx context.Context) (*dockerclient.Client, error) {
+			if cachedDocker != nil {
+				return cachedDocker, nil
+			}
+			c, err := newRemoteDockerClient(ctx, apiClient, appName, builder, streams)
+			if err != nil {
+				if !daemonType.AllowLocal() || errors.Is(err, context.Canceled) {
+					return nil, err
 				}
-				c, err := newRemoteDockerClient(ctx, apiClient, appName, streams)
-				if err != nil {
+				// the local daemon may have started since it was tried
+				local, localErr := newLocalDockerClient()
+				if localErr != nil {
+					terminal.Debug("Local docker daemon still unavailable:", localErr)
 					return nil, err
 				}
-				cachedDocker = c
-				return cachedDocker, nil
-			},
+				fmt.Fprintf(streams.ErrOut, "Remote builder unavailable (%v), building with the local docker daemon instead\n", err)
+				factory.mode = DockerDaemonTypeLocal
+				c = local
+			}
+			cachedDocker = c
+			return cachedDocker, nil
 		}
+		return factory
 	}
 
 	return &dockerClientFactory{
@@ -89,6 +101,31 @@ func isRetyableError(err error) bool {
 	return !isUnauthorized(err)
 }
 
+// Build modes accepted by ParseBuildMode
+const (
+	// BuildModeAuto - build with the local docker daemon when it's running,
+	// otherwise remotely, falling back to the local daemon if the remote
+	// builder doesn't start and the local daemon has started since
+	BuildModeAuto = "auto"
+	// BuildModeLocal - only build with the local docker daemon
+	BuildModeLocal = "local"
+	// BuildModeRemote - only build on a remote builder
+	BuildModeRemote = "remote"
+)
+
+// ParseBuildMode returns the docker daemons a build mode allows
+func ParseBuildMode(mode string) (DockerDaemonType, error) {
+	switch mode {
+	case BuildModeAuto, "":
+		return NewDockerDaemonType(true, true), nil
+	case BuildModeLocal:
+		return NewDockerDaemonType(true, false), nil
+	case BuildModeRemote:
+		return NewDockerDaemonType(false, true), nil
+	}
+	return DockerDaemonTypeNone, fmt.Errorf("unknown build mode %q, expected auto, local or remote", mode)
+}
+
 func NewDockerDaemonType(allowLocal, allowRemote bool) DockerDaemonType {
 	daemonType := DockerDaemonTypeNone
 	if allowLocal {
@@ -153,6 +190,23 @@ func newLocalDockerClient() (*dockerclient.Client, error) {
 	return c, nil
 }
 
+// LocalDockerVersion - the version of the local Docker daemon, or an error
+// if it isn't running or can't be reached
+func LocalDockerVersion(ctx context.Context) (string, error) {
+	c, err := newLocalDockerClient()
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	version, err := c.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return version.Version, nil
+}
+
 type remoteBuilderError struct {
 	RemoteBuilderName string
 	Err               error
@@ -162,25 +216,36 @@ func (e *remoteBuilderError) Error() string {
 	return fmt.Sprintf("remote builder %s error %s", e.RemoteBuilderName, e.Err)
 }
 
-func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName string, streams *iostreams.IOStreams) (*dockerclient.Client, error) {
-	host, remoteBuilderAppName, err := remoteBuilderURL(apiClient, appName)
+func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName string, builder RemoteBuilderOptions, streams *iostreams.IOStreams) (*dockerclient.Client, error) {
+	host, remoteBuilderAppName, err := remoteBuilderURL(apiClient, appName, builder.App)
 	if err != nil {
 		return nil, err
 	}
 
+	if remoteBuilderAppName != "" && !builder.Size.IsZero() {
+		if err := reconcileBuilderSize(apiClient, remoteBuilderAppName, builder.Size, streams); err != nil {
+			return nil, err
+		}
+	}
+
 	terminal.Debugf("Remote Docker builder host: %s\n", host)
 
-	if streams.IsInteractive() {
-		streams.StartProgressIndicatorMsg(fmt.Sprintf("Waiting for remote builder %s... starting", remoteBuilderAppName))
-	} else {
-		fmt.Fprintf(streams.ErrOut, "Waiting for remote builder %s...\n", remoteBuilderAppName)
-	}
+	streams.StartProgressIndicatorMsg(fmt.Sprintf("Waiting for remote builder %s... starting", remoteBuilderAppName))
+	phase := streams.StartPhase(fmt.Sprintf("Waiting for remote builder %s", remoteBuilderAppName))
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	timeout := builder.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRemoteBuilderTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	eg, errCtx := errgroup.WithContext(ctx)
 
+	// closed once the builder's VM is running, as there's no point
+	// diagnosing the connection to a builder that's still starting
+	vmRunning := make(chan struct{})
+
 	eg.Go(func() error {
 		defer streams.ChangeProgressIndicatorMsg(fmt.Sprintf("Waiting for remote builder %s... connecting", remoteBuilderAppName))
 
@@ -189,6 +254,7 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 				return errors.Wrap(err, "Error waiting for remote builder app")
 			}
 		}
+		close(vmRunning)
 		return nil
 	})
 
@@ -200,6 +266,8 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 			dockerclient.WithHost(host),
 		}
 
+		var diagnose func(ctx context.Context) error
+
 		if os.Getenv("FLY_REMOTE_BUILDER_HOST_WG") == "" {
 			app, err := apiClient.GetApp(appName)
 			if err != nil {
@@ -220,6 +288,17 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 			}
 
 			opts = append(opts, dockerclient.WithDialContext(tunnel.DialContext))
+
+			if remoteBuilderAppName != "" {
+				diagnose = func(ctx context.Context) error {
+					select {
+					case <-vmRunning:
+						return diagnoseBuilder(ctx, wgBuilderTunnel{tunnel}, remoteBuilderAppName, streams.ErrOut)
+					default:
+						return nil
+					}
+				}
+			}
 		} else {
 			terminal.Debug("connecting to remote docker daemon over host wireguard tunnel")
 		}
@@ -229,7 +308,7 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 			return errors.Wrap(err, "Error creating docker client")
 		}
 
-		if err := waitForDaemon(errCtx, client); err != nil {
+		if err := waitForDaemon(errCtx, client, diagnose); err != nil {
 			return errors.Wrap(err, "error waiting for docker daemon")
 		}
 
@@ -241,6 +320,14 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 	if err = eg.Wait(); err != nil {
 		captureRemoteBuilderError(err, remoteBuilderAppName)
 
+		streams.StopProgressIndicator()
+		phase.End(err)
+		if ctx.Err() == nil || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			printBuilderLogs(apiClient, remoteBuilderAppName, streams)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, remoteBuilderTimeoutError(remoteBuilderAppName, timeout)
+		}
 		return nil, err
 	}
 
@@ -248,19 +335,68 @@ func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName s
 		captureRemoteBuilderError(err, remoteBuilderAppName)
 
 		streams.StopProgressIndicator()
+		phase.End(err)
 		if errors.Is(err, context.DeadlineExceeded) {
-			terminal.Warnf("Remote builder did not start on time. Check remote builder logs with `flyctl logs -a %s`\n", remoteBuilderAppName)
-			return nil, errors.New("remote builder app unavailable")
+			printBuilderLogs(apiClient, remoteBuilderAppName, streams)
+			return nil, remoteBuilderTimeoutError(remoteBuilderAppName, timeout)
 		}
 
 		return nil, err
 	}
 
 	streams.StopProgressIndicatorMsg(fmt.Sprintf("Remote builder %s ready", remoteBuilderAppName))
+	phase.End(nil)
 
 	return <-clientCh, nil
 }
 
+// DefaultRemoteBuilderTimeout - how long to wait for a remote builder to
+// start when RemoteBuilderOptions.Timeout isn't set
+const DefaultRemoteBuilderTimeout = 5 * time.Minute
+
+// builderLogLines - how many lines of a remote builder's logs are shown when
+// it fails to start
+const builderLogLines = 50
+
+func remoteBuilderTimeoutError(builderApp string, timeout time.Duration) error {
+	return flyerr.New(flyerr.BuildTimeout, fmt.Sprintf("remote builder %s didn't start within %s. Wait longer with --remote-builder-timeout, or build locally with --build-mode local", builderApp, timeout))
+}
+
+// printBuilderLogs prints the last lines of builderApp's logs, so why it
+// failed to start is shown alongside the failure
+func printBuilderLogs(apiClient *api.Client, builderApp string, streams *iostreams.IOStreams) {
+	if builderApp == "" {
+		return
+	}
+
+	entries, _, err := apiClient.GetAppLogs(builderApp, "", "", "")
+	if err != nil {
+		terminal.Debugf("Error fetching remote builder logs: %v\n", err)
+		fmt.Fprintf(streams.ErrOut, "Check remote builder logs with `flyctl logs -a %s`\n", builderApp)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(streams.ErrOut, "Remote builder %s has no recent logs\n", builderApp)
+		return
+	}
+
+	fmt.Fprint(streams.ErrOut, formatBuilderLogs(builderApp, entries))
+}
+
+// formatBuilderLogs formats the last builderLogLines of entries
+func formatBuilderLogs(builderApp string, entries []api.LogEntry) string {
+	if len(entries) > builderLogLines {
+		entries = entries[len(entries)-builderLogLines:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Last %d lines of remote builder %s's logs:\n", len(entries), builderApp)
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "  %s [%s] %s\n", entry.Timestamp, entry.Instance, strings.TrimRight(entry.Message, "\n"))
+	}
+	return b.String()
+}
+
 func captureRemoteBuilderError(err error, builderAppName string) {
 	if errors.Is(err, context.Canceled) {
 		return
@@ -269,20 +405,96 @@ func captureRemoteBuilderError(err error, builderAppName string) {
 	sentry.CaptureException(&remoteBuilderError{RemoteBuilderName: builderAppName, Err: err})
 }
 
-func remoteBuilderURL(apiClient *api.Client, appName string) (string, string, error) {
+// remoteBuilderURL returns the docker host of the builder for appName's
+// builds, and the builder's app name. builderApp, or the builder the app's
+// organization is pinned to, is used in place of the one
+// ensureRemoteBuilder picks.
+func remoteBuilderURL(apiClient *api.Client, appName string, builderApp string) (string, string, error) {
 	if v := os.Getenv("FLY_REMOTE_BUILDER_HOST"); v != "" {
 		return v, "", nil
 	}
 
+	builder, err := selectedRemoteBuilder(apiClient, appName, builderApp)
+	if err != nil {
+		return "", "", err
+	}
+	if builder != "" {
+		terminal.Debugf("Using pinned remote builder %s\n", builder)
+		return builderHost(builder), builder, nil
+	}
+
 	_, app, err := apiClient.EnsureRemoteBuilderForApp(appName)
 	if err != nil {
 		return "", "", errors.Errorf("could not create remote builder: %v", err)
 	}
 
-	return "tcp://" + net.JoinHostPort(app.Name+".internal", "2375"), app.Name, nil
+	return builderHost(app.Name), app.Name, nil
+}
+
+// selectedRemoteBuilder returns builderApp, or the builder appName's
+// organization is pinned to, checking it's in the same organization as the
+// app so it can be reached over the organization's network and resuming it
+// when it's suspended. It returns an empty string when no builder is
+// selected, or when the pinned builder can't be looked up, so the default
+// builder is used.
+func selectedRemoteBuilder(apiClient *api.Client, appName string, builderApp string) (string, error) {
+	app, err := apiClient.GetApp(appName)
+	if err != nil {
+		return "", errors.Wrap(err, "error fetching target app")
+	}
+
+	if builderApp == "" {
+		pinned, err := apiClient.GetPinnedRemoteBuilder(app.Organization.Slug)
+		if err != nil {
+			terminal.Debugf("error fetching the organization's pinned remote builder, using the default builder: %v\n", err)
+			return "", nil
+		}
+		if pinned == nil {
+			return "", nil
+		}
+		builderApp = pinned.Name
+	}
+
+	builder, err := apiClient.GetApp(builderApp)
+	if err != nil {
+		return "", flyerr.Wrap(flyerr.NotFound, errors.Wrapf(err, "remote builder app %s", builderApp))
+	}
+	if builder.Organization.Slug != app.Organization.Slug {
+		return "", flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("remote builder app %s is in the %s organization, but %s is in %s. Builders must be in the app's organization", builderApp, builder.Organization.Slug, appName, app.Organization.Slug))
+	}
+
+	if err := ensureBuilderRunning(apiClient, builder); err != nil {
+		return "", err
+	}
+
+	return builderApp, nil
+}
+
+// ensureBuilderRunning resumes builder when it's suspended. A builder that
+// was never deployed can't be started, so building on it is an error.
+func ensureBuilderRunning(apiClient *api.Client, builder *api.App) error {
+	if builder.Status == "suspended" {
+		terminal.Debugf("Resuming suspended remote builder %s\n", builder.Name)
+		if _, err := apiClient.ResumeApp(builder.Name); err != nil {
+			return errors.Wrapf(err, "error resuming remote builder %s", builder.Name)
+		}
+		return nil
+	}
+
+	if !builder.Deployed {
+		return flyerr.New(flyerr.InvalidArgument, fmt.Sprintf("remote builder app %s has never been deployed, so it can't build. Unpin it with `flyctl builders unpin`", builder.Name))
+	}
+	return nil
 }
 
-func waitForDaemon(ctx context.Context, client *dockerclient.Client) error {
+func builderHost(builderApp string) string {
+	return "tcp://" + net.JoinHostPort(builderApp+".internal", "2375")
+}
+
+// waitForDaemon pings the docker daemon until it has answered for a second.
+// When pings have failed for diagnoseAfter, diagnose is called, if given, and
+// waiting stops with its error when it finds the connection broken.
+func waitForDaemon(ctx context.Context, client *dockerclient.Client, diagnose func(ctx context.Context) error) error {
 	b := &backoff.Backoff{
 		//These are the defaults
 		Min:    200 * time.Millisecond,
@@ -293,6 +505,7 @@ func waitForDaemon(ctx context.Context, client *dockerclient.Client) error {
 
 	consecutiveSuccesses := 0
 	var healthyStart time.Time
+	var failingSince time.Time
 
 	for {
 		checkErr := make(chan error, 1)
@@ -311,6 +524,7 @@ func waitForDaemon(ctx context.Context, client *dockerclient.Client) error {
 					b.Reset()
 				}
 				consecutiveSuccesses++
+				failingSince = time.Time{}
 
 				if time.Since(healthyStart) > 1*time.Second {
 					terminal.Debug("Remote builder is ready to build!")
@@ -325,6 +539,16 @@ func waitForDaemon(ctx context.Context, client *dockerclient.Client) error {
 					return err
 				}
 				consecutiveSuccesses = 0
+
+				if failingSince.IsZero() {
+					failingSince = time.Now()
+				} else if diagnose != nil && time.Since(failingSince) > diagnoseAfter {
+					if err := diagnose(ctx); err != nil {
+						return err
+					}
+					failingSince = time.Now()
+				}
+
 				dur := b.Duration()
 				terminal.Debugf("Remote builder unavailable, retrying in %s (err: %v)\n", dur, err)
 				time.Sleep(dur)
@@ -363,24 +587,6 @@ func registryAuth(token string) types.AuthConfig {
 	}
 }
 
-func authConfigs() map[string]types.AuthConfig {
-	authConfigs := map[string]types.AuthConfig{}
-
-	dockerhubUsername := os.Getenv("DOCKER_HUB_USERNAME")
-	dockerhubPassword := os.Getenv("DOCKER_HUB_PASSWORD")
-
-	if dockerhubUsername != "" && dockerhubPassword != "" {
-		cfg := types.AuthConfig{
-			Username:      dockerhubUsername,
-			Password:      dockerhubPassword,
-			ServerAddress: "index.docker.io",
-		}
-		authConfigs["https://index.docker.io/v1/"] = cfg
-	}
-
-	return authConfigs
-}
-
 func flyRegistryAuth() string {
 	accessToken := flyctl.GetAPIToken()
 	authConfig := registryAuth(accessToken)
@@ -1,9 +1,12 @@
 package imgsrc
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
 )
 
 func TestAllowedDockerDaemonMode(t *testing.T) {
@@ -23,3 +26,37 @@ func TestAllowedDockerDaemonMode(t *testing.T) {
 		assert.Equal(t, test.expected, m)
 	}
 }
+
+func TestParseBuildMode(t *testing.T) {
+	auto, err := ParseBuildMode(BuildModeAuto)
+	assert.NoError(t, err)
+	assert.True(t, auto.AllowLocal())
+	assert.True(t, auto.AllowRemote())
+
+	remote, err := ParseBuildMode(BuildModeRemote)
+	assert.NoError(t, err)
+	assert.False(t, remote.AllowLocal())
+	assert.True(t, remote.AllowRemote())
+
+	local, err := ParseBuildMode(BuildModeLocal)
+	assert.NoError(t, err)
+	assert.True(t, local.AllowLocal())
+	assert.False(t, local.AllowRemote())
+
+	_, err = ParseBuildMode("cloud")
+	assert.Error(t, err)
+}
+
+func TestFormatBuilderLogs(t *testing.T) {
+	var entries []api.LogEntry
+	for i := 0; i < 60; i++ {
+		entries = append(entries, api.LogEntry{Timestamp: fmt.Sprintf("t%d", i), Instance: "abc123", Message: fmt.Sprintf("line %d\n", i)})
+	}
+
+	logs := formatBuilderLogs("fly-builder-1", entries)
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+
+	assert.Equal(t, "Last 50 lines of remote builder fly-builder-1's logs:", lines[0])
+	assert.Equal(t, "  t10 [abc123] line 10", lines[1])
+	assert.Equal(t, "  t59 [abc123] line 59", lines[50])
+}
@@ -9,6 +9,8 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/containerd/console"
 	"github.com/docker/docker/api/types"
@@ -17,14 +19,18 @@ import (
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
+	"github.com/dustin/go-humanize"
+	"github.com/moby/buildkit/session/filesync"
 	"github.com/moby/buildkit/util/progress/progressui"
 	"github.com/moby/term"
 	"github.com/pkg/errors"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/cmdfmt"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/pkg/iostreams"
 	"github.com/superfly/flyctl/terminal"
+	fstypes "github.com/tonistiigi/fsutil/types"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -50,6 +56,45 @@ func (out *lastProgressOutput) WriteProgress(prog progress.Progress) error {
 	return out.output.WriteProgress(prog)
 }
 
+// DefaultContextWarnSize - build contexts larger than this get a warning
+// about what's making them large
+const DefaultContextWarnSize = 200 * 1024 * 1024
+
+// reportContextSize prints the size of the build context, warning when it's
+// over warnSize
+func reportContextSize(streams *iostreams.IOStreams, sourcePath string, excludes []string, warnSize int64) (*contextSize, error) {
+	size, err := measureContext(sourcePath, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(streams.ErrOut, "Build context: %s in %d files\n", humanize.Bytes(uint64(size.Bytes)), size.Files)
+
+	if warnSize > 0 && size.Bytes > warnSize {
+		largest := []string{}
+		for _, name := range size.Largest(3) {
+			largest = append(largest, fmt.Sprintf("%s (%s)", name, humanize.Bytes(uint64(size.Entries[name]))))
+		}
+		fmt.Fprintf(streams.ErrOut, "Warning: the build context is over %s, which is slow to send to remote builders. The largest parts are %s. Add what the image doesn't need to .dockerignore\n",
+			humanize.Bytes(uint64(warnSize)), strings.Join(largest, ", "))
+	}
+
+	return size, nil
+}
+
+// contextProgress returns a wrapper showing the progress of sending a build
+// context of size bytes
+func contextProgress(streams *iostreams.IOStreams, size int64) func(io.ReadCloser) io.ReadCloser {
+	return func(r io.ReadCloser) io.ReadCloser {
+		progressOutput := streamformatter.NewProgressOutput(streams.Out)
+		if !streams.IsStdoutTTY() {
+			progressOutput = &lastProgressOutput{output: progressOutput}
+		}
+
+		return progress.NewProgressReader(r, progressOutput, size, "", "Sending build context to Docker daemon")
+	}
+}
+
 func (ds *dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions) (*DeploymentImage, error) {
 	if !dockerFactory.mode.IsAvailable() {
 		terminal.Debug("docker daemon not available, skipping")
@@ -79,70 +124,72 @@ func (ds *dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClien
 
 	defer clearDeploymentTags(ctx, docker, opts.Tag)
 
-	cmdfmt.PrintBegin(streams.ErrOut, "Creating build context")
-	archiveOpts := archiveOptions{
-		sourcePath: opts.WorkingDir,
-		compressed: dockerFactory.mode.IsRemote(),
-	}
-
 	excludes, err := readDockerignore(opts.WorkingDir)
 	if err != nil {
 		return nil, errors.Wrap(err, "error reading .dockerignore")
 	}
-	archiveOpts.exclusions = excludes
-
-	var relativedockerfilePath string
-
-	// copy dockerfile into the archive if it's outside the context dir
-	if !isPathInRoot(dockerfile, opts.WorkingDir) {
-		dockerfileData, err := os.ReadFile(dockerfile)
-		if err != nil {
-			return nil, errors.Wrap(err, "error reading Dockerfile")
-		}
-		archiveOpts.additions = map[string][]byte{
-			"Dockerfile": dockerfileData,
-		}
-	} else {
-		// pass the relative path to Dockerfile within the context
-		p, err := filepath.Rel(opts.WorkingDir, dockerfile)
-		if err != nil {
-			return nil, err
-		}
-		relativedockerfilePath = p
-	}
 
-	r, err := archiveDirectory(archiveOpts)
+	size, err := reportContextSize(streams, opts.WorkingDir, excludes, opts.ContextWarnSize)
 	if err != nil {
-		return nil, errors.Wrap(err, "error archiving build context")
-	}
-	cmdfmt.PrintDone(streams.ErrOut, "Creating build context done")
-
-	// Setup an upload progress bar
-	progressOutput := streamformatter.NewProgressOutput(streams.Out)
-	if !streams.IsStdoutTTY() {
-		progressOutput = &lastProgressOutput{output: progressOutput}
+		return nil, errors.Wrap(err, "error measuring build context")
 	}
 
-	r = progress.NewProgressReader(r, progressOutput, 0, "", "Sending build context to Docker daemon")
-
-	var imageID string
-
-	cmdfmt.PrintBegin(streams.ErrOut, "Building image with Docker")
-
 	buildArgs := normalizeBuildArgsForDocker(opts.AppConfig, opts.ExtraBuildArgs)
+	auths := authConfigs(dockerFactory.mode.IsRemote(), opts.ForwardRegistryAuth, streams)
 
 	buildkitEnabled, err := buildkitEnabled(docker)
 	terminal.Debugf("buildkitEnabled", buildkitEnabled)
 	if err != nil {
 		return nil, errors.Wrap(err, "error checking for buildkit support")
 	}
+
+	var imageID string
+
 	if buildkitEnabled {
-		imageID, err = runBuildKitBuild(ctx, streams, docker, r, opts, relativedockerfilePath, buildArgs)
+		cmdfmt.PrintBegin(streams.ErrOut, "Building image with Docker")
+
+		imageID, err = runBuildKitBuild(ctx, streams, docker, opts, dockerfile, excludes, buildArgs, auths)
 		if err != nil {
 			return nil, errors.Wrap(err, "error building")
 		}
 	} else {
-		imageID, err = runClassicBuild(ctx, streams, docker, r, opts, relativedockerfilePath, buildArgs)
+		cmdfmt.PrintBegin(streams.ErrOut, "Creating build context")
+		archiveOpts := archiveOptions{
+			sourcePath: opts.WorkingDir,
+			compressed: dockerFactory.mode.IsRemote(),
+			exclusions: excludes,
+			progress:   contextProgress(streams, size.Bytes),
+		}
+
+		var relativedockerfilePath string
+
+		// copy dockerfile into the archive if it's outside the context dir
+		if !isPathInRoot(dockerfile, opts.WorkingDir) {
+			dockerfileData, err := os.ReadFile(dockerfile)
+			if err != nil {
+				return nil, errors.Wrap(err, "error reading Dockerfile")
+			}
+			archiveOpts.additions = map[string][]byte{
+				"Dockerfile": dockerfileData,
+			}
+		} else {
+			// pass the relative path to Dockerfile within the context
+			p, err := filepath.Rel(opts.WorkingDir, dockerfile)
+			if err != nil {
+				return nil, err
+			}
+			relativedockerfilePath = p
+		}
+
+		r, err := archiveDirectory(archiveOpts)
+		if err != nil {
+			return nil, errors.Wrap(err, "error archiving build context")
+		}
+		cmdfmt.PrintDone(streams.ErrOut, "Creating build context done")
+
+		cmdfmt.PrintBegin(streams.ErrOut, "Building image with Docker")
+
+		imageID, err = runClassicBuild(ctx, streams, docker, r, opts, relativedockerfilePath, buildArgs, auths)
 		if err != nil {
 			return nil, errors.Wrap(err, "error building")
 		}
@@ -192,11 +239,11 @@ func normalizeBuildArgsForDocker(appConfig *flyctl.AppConfig, extra map[string]s
 	return out
 }
 
-func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *dockerclient.Client, r io.ReadCloser, opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (imageID string, err error) {
+func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *dockerclient.Client, r io.ReadCloser, opts ImageOptions, dockerfilePath string, buildArgs map[string]*string, auths map[string]types.AuthConfig) (imageID string, err error) {
 	options := types.ImageBuildOptions{
 		Tags:        []string{opts.Tag},
 		BuildArgs:   buildArgs,
-		AuthConfigs: authConfigs(),
+		AuthConfigs: auths,
 		Platform:    "linux/amd64",
 		Dockerfile:  dockerfilePath,
 		Target:      opts.Target,
@@ -224,17 +271,26 @@ func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *
 	return imageID, nil
 }
 
-const uploadRequestRemote = "upload-request"
+// clientSessionRemote has BuildKit fetch the build context and Dockerfile
+// through the build session
+const clientSessionRemote = "client-session"
 
-func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker *dockerclient.Client, r io.ReadCloser, opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (imageID string, err error) {
+// runBuildKitBuild syncs the build context to the builder through the build
+// session rather than uploading it as a tarball. The builder keeps the last
+// context synced for the session's shared key, which is the same for each
+// build of a directory, and compares files against it so only the files which
+// changed since the last build are sent.
+func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker *dockerclient.Client, opts ImageOptions, dockerfile string, excludes []string, buildArgs map[string]*string, auths map[string]types.AuthConfig) (imageID string, err error) {
 	s, err := createBuildSession(opts.WorkingDir)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
-	if s == nil {
-		panic("buildkit not supported")
-	}
+	s.Allow(filesync.NewFSSyncProvider([]filesync.SyncedDir{
+		{Name: "context", Dir: opts.WorkingDir, Excludes: excludes, Map: resetUIDAndGID},
+		{Name: "dockerfile", Dir: filepath.Dir(dockerfile)},
+	}))
+	s.Allow(newSessionAuthProvider(auths))
 
 	eg, errCtx := errgroup.WithContext(ctx)
 
@@ -246,19 +302,6 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 	})
 
 	buildID := stringid.GenerateRandomID()
-	eg.Go(func() error {
-		buildOptions := types.ImageBuildOptions{
-			Version: types.BuilderBuildKit,
-			BuildID: uploadRequestRemote + ":" + buildID,
-		}
-
-		response, err := docker.ImageBuild(context.Background(), r, buildOptions)
-		if err != nil {
-			return err
-		}
-		defer response.Body.Close()
-		return nil
-	})
 
 	eg.Go(func() error {
 		defer s.Close()
@@ -267,12 +310,12 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 			Tags:          []string{opts.Tag},
 			BuildArgs:     buildArgs,
 			Version:       types.BuilderBuildKit,
-			AuthConfigs:   authConfigs(),
+			AuthConfigs:   auths,
 			SessionID:     s.ID(),
-			RemoteContext: uploadRequestRemote,
+			RemoteContext: clientSessionRemote,
 			BuildID:       buildID,
 			Platform:      "linux/amd64",
-			Dockerfile:    dockerfilePath,
+			Dockerfile:    filepath.Base(dockerfile),
 			Target:        opts.Target,
 			NoCache:       opts.NoCache,
 		}
@@ -341,26 +384,125 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 	return imageID, nil
 }
 
+// resetUIDAndGID gives synced files the same owner as files in a tarball context
+func resetUIDAndGID(_ string, s *fstypes.Stat) bool {
+	s.Uid = 0
+	s.Gid = 0
+	return true
+}
+
+// pushAttempts - how many times a push is made before giving up. Docker
+// retries each layer itself and remembers the layers an earlier attempt
+// pushed, so later attempts only upload the layers that failed. Those are
+// uploaded in resumable chunks when the daemon is local and keeps its layers
+// compressed.
+const pushAttempts = 3
+
 func pushToFly(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, tag string) error {
-	pushResp, err := docker.ImagePush(ctx, tag, types.ImagePushOptions{
-		RegistryAuth: flyRegistryAuth(),
-	})
-	if err != nil {
-		return errors.Wrap(err, "error pushing image to registry")
+	mode := streams.ProgressMode()
+	progress := newPushProgress()
+	phase := streams.StartPhase("Pushing image " + tag)
+
+	var err error
+	for attempt := 1; attempt <= pushAttempts; attempt++ {
+		err = pushImage(ctx, docker, streams, tag, progress, phase, attempt > 1)
+		if err == nil || !retryablePushError(ctx, err) || attempt == pushAttempts {
+			break
+		}
+		if mode != iostreams.ProgressNone && mode != iostreams.ProgressJSON {
+			fmt.Fprintf(streams.ErrOut, "Push failed, retrying the layers that didn't upload: %v\n", err)
+		}
 	}
-	defer pushResp.Close()
 
-	err = jsonmessage.DisplayJSONMessagesStream(pushResp, streams.ErrOut, streams.StderrFd(), streams.IsStderrTTY(), nil)
+	phase.SetDetail(progress.String())
+	phase.End(err)
 	if err != nil {
 		var msgerr *jsonmessage.JSONError
 
 		if errors.As(err, &msgerr) {
 			if msgerr.Message == "denied: requested access to the resource is denied" {
-				return &RegistryUnauthorizedError{Tag: tag}
+				return flyerr.Wrap(flyerr.UnauthorizedBuilder, &RegistryUnauthorizedError{Tag: tag})
+			}
+		}
+		return errors.Wrap(err, "error pushing image to registry")
+	}
+
+	if mode == iostreams.ProgressTTY || mode == iostreams.ProgressPlain {
+		fmt.Fprintln(streams.ErrOut, progress.summary())
+	}
+
+	return nil
+}
+
+// pushImage makes a single attempt at pushing tag. A retry from a local
+// daemon that keeps its layers compressed uploads it straight to the
+// registry so uploads can resume, which means saving the image first, so
+// first attempts use docker push. The progress of all its layers is shown
+// on one line on terminals, and as the phase's detail otherwise.
+func pushImage(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, tag string, progress *pushProgress, phase *iostreams.Phase, retry bool) error {
+	var pushResp io.ReadCloser
+	if retry && isLocalDaemon(docker) && storesCompressedLayers(ctx, docker) {
+		pushResp = uploadImageStream(ctx, docker, tag)
+	} else {
+		resp, err := docker.ImagePush(ctx, tag, types.ImagePushOptions{
+			RegistryAuth: flyRegistryAuth(),
+		})
+		if err != nil {
+			return err
+		}
+		pushResp = resp
+	}
+	defer pushResp.Close()
+
+	mode := streams.ProgressMode()
+	showBar := mode == iostreams.ProgressTTY && streams.IsStderrTTY()
+	var drawn time.Time
+
+	decoder := json.NewDecoder(pushResp)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			if showBar {
+				fmt.Fprintln(streams.ErrOut)
+			}
+			return msg.Error
+		}
+
+		line := progress.update(msg)
+		if line != "" && mode != iostreams.ProgressNone && mode != iostreams.ProgressJSON {
+			if showBar {
+				fmt.Fprint(streams.ErrOut, "\r\x1b[K")
 			}
+			fmt.Fprintln(streams.ErrOut, line)
+		}
+		phase.SetDetail(progress.String())
+
+		if showBar && time.Since(drawn) > 100*time.Millisecond {
+			fmt.Fprintf(streams.ErrOut, "\r\x1b[KPushing image: %s", progress)
+			drawn = time.Now()
 		}
-		return errors.Wrap(err, "error rendering push status stream")
 	}
 
+	if showBar {
+		fmt.Fprint(streams.ErrOut, "\r\x1b[K")
+	}
 	return nil
 }
+
+// retryablePushError is true for push failures worth another attempt, which
+// excludes the registry refusing the push and the push being cancelled
+func retryablePushError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var msgerr *jsonmessage.JSONError
+	if errors.As(err, &msgerr) && (strings.HasPrefix(msgerr.Message, "denied:") || strings.HasPrefix(msgerr.Message, "unauthorized:")) {
+		return false
+	}
+	return !dockerclient.IsErrUnauthorized(err) && !dockerclient.IsErrNotFound(err)
+}
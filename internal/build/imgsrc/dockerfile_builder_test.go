@@ -14,7 +14,7 @@ import (
 
 func TestBuildDockerfileApp(t *testing.T) {
 	t.Skip()
-	df := newDockerClientFactory(DockerDaemonTypeLocal, nil, "test-app", nil)
+	df := newDockerClientFactory(DockerDaemonTypeLocal, nil, "test-app", RemoteBuilderOptions{}, nil)
 
 	dfStrategy := dockerfileBuilder{}
 	testStreams, _, _, _ := iostreams.Test()
@@ -0,0 +1,47 @@
+package imgsrc
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// MissingPaths returns those of paths that don't exist in img's filesystem.
+// The image is looked up on the docker daemon it was built or found on, by
+// creating a container from it without starting it.
+func (r *Resolver) MissingPaths(ctx context.Context, img *DeploymentImage, paths []string) ([]string, error) {
+	if !r.dockerFactory.mode.IsAvailable() {
+		return nil, errors.New("no docker daemon available to inspect the image")
+	}
+
+	docker, err := r.dockerFactory.buildFn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := img.ID
+	if ref == "" {
+		ref = img.Tag
+	}
+
+	created, err := docker.ContainerCreate(ctx, &container.Config{Image: ref}, nil, nil, nil, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating a container to inspect the image")
+	}
+	defer docker.ContainerRemove(context.Background(), created.ID, types.ContainerRemoveOptions{Force: true})
+
+	var missing []string
+	for _, path := range paths {
+		if _, err := docker.ContainerStatPath(ctx, created.ID, path); err != nil {
+			if !dockerclient.IsErrNotFound(err) {
+				return nil, errors.Wrapf(err, "error looking up %s in the image", path)
+			}
+			missing = append(missing, path)
+		}
+	}
+
+	return missing, nil
+}
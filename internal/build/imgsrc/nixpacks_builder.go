@@ -0,0 +1,95 @@
+package imgsrc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/cli/safeexec"
+	"github.com/superfly/flyctl/internal/cmdfmt"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/pkg/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// nixpacksDir - where nixpacks writes the Dockerfile it generates, inside the working directory
+const nixpacksDir = ".nixpacks"
+
+// nixpacksBuilder has nixpacks generate a Dockerfile for the app, which is
+// then built like any other Dockerfile, on the local docker daemon or a
+// remote builder
+type nixpacksBuilder struct{}
+
+func (*nixpacksBuilder) Name() string {
+	return "Nixpacks"
+}
+
+// NixpacksAvailable is true when nixpacks is installed
+func NixpacksAvailable() bool {
+	_, err := safeexec.LookPath("nixpacks")
+	return err == nil
+}
+
+func (*nixpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions) (*DeploymentImage, error) {
+	if !dockerFactory.mode.IsAvailable() {
+		terminal.Debug("docker daemon not available, skipping")
+		return nil, nil
+	}
+
+	if !opts.AppConfig.HasNixpacks() {
+		terminal.Debug("nixpacks not configured, skipping")
+		return nil, nil
+	}
+
+	nixpacks, err := safeexec.LookPath("nixpacks")
+	if err != nil {
+		return nil, flyerr.New(flyerr.InvalidConfig, "the [build] section uses nixpacks, but it isn't installed. See https://nixpacks.com/docs/install")
+	}
+
+	cmdfmt.PrintBegin(streams.ErrOut, "Generating a build plan with nixpacks")
+
+	dockerfile, err := generateNixpacksDockerfile(ctx, nixpacks, streams, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(filepath.Join(opts.WorkingDir, nixpacksDir))
+
+	cmdfmt.PrintDone(streams.ErrOut, "Generating a build plan done")
+
+	opts.DockerfilePath = dockerfile
+	return (&dockerfileBuilder{}).Run(ctx, dockerFactory, streams, opts)
+}
+
+// generateNixpacksDockerfile runs nixpacks over the working directory,
+// returning the path of the Dockerfile it wrote
+func generateNixpacksDockerfile(ctx context.Context, nixpacks string, streams *iostreams.IOStreams, opts ImageOptions) (string, error) {
+	args := []string{"build", opts.WorkingDir, "--out", opts.WorkingDir}
+
+	env := buildpacksEnv(opts.AppConfig, opts.ExtraBuildArgs)
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", name, env[name]))
+	}
+
+	cmd := exec.CommandContext(ctx, nixpacks, args...)
+	cmd.Dir = opts.WorkingDir
+	cmd.Stdout = streams.ErrOut
+	cmd.Stderr = streams.ErrOut
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("nixpacks failed: %w", err)
+	}
+
+	dockerfile := filepath.Join(opts.WorkingDir, nixpacksDir, "Dockerfile")
+	if _, err := os.Stat(dockerfile); err != nil {
+		return "", fmt.Errorf("nixpacks didn't generate a Dockerfile: %w", err)
+	}
+
+	return dockerfile, nil
+}
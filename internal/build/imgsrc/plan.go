@@ -0,0 +1,279 @@
+package imgsrc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// BuildPlan - how the image for a directory is built: which builder was
+// chosen and what it builds with. Plans are cached between deploys so the
+// builder doesn't have to be detected again while its inputs are unchanged.
+type BuildPlan struct {
+	WorkingDir     string            `json:"working_dir"`
+	Strategy       string            `json:"strategy"`
+	Builder        string            `json:"builder,omitempty"`
+	Buildpacks     []string          `json:"buildpacks,omitempty"`
+	Builtin        string            `json:"builtin,omitempty"`
+	Dockerfile     string            `json:"dockerfile,omitempty"`
+	DockerfileHash string            `json:"dockerfile_hash,omitempty"`
+	BuildArgs      map[string]string `json:"build_args,omitempty"`
+	Target         string            `json:"target,omitempty"`
+	Reason         string            `json:"reason"`
+	InputsHash     string            `json:"inputs_hash"`
+	ResolvedAt     time.Time         `json:"resolved_at"`
+}
+
+// ResolvePlan chooses a builder the same way BuildImage tries them:
+// buildpacks or nixpacks, then a Dockerfile, then a builtin
+func ResolvePlan(opts ImageOptions) (*BuildPlan, error) {
+	workingDir, err := filepath.Abs(opts.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &BuildPlan{
+		WorkingDir: workingDir,
+		BuildArgs:  planBuildArgs(opts),
+		Target:     opts.Target,
+		InputsHash: planInputsHash(opts),
+		ResolvedAt: time.Now(),
+	}
+
+	if err := validateBuildpacksConfig(opts.AppConfig); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.AppConfig.HasBuilder():
+		plan.Strategy = (&buildpacksBuilder{}).Name()
+		plan.Builder = opts.AppConfig.Build.Builder
+		plan.Buildpacks = opts.AppConfig.Build.Buildpacks
+		plan.BuildArgs = buildpacksEnv(opts.AppConfig, opts.ExtraBuildArgs)
+		plan.Reason = "the [build] section sets a buildpacks builder"
+	case opts.AppConfig.HasNixpacks():
+		plan.Strategy = (&nixpacksBuilder{}).Name()
+		plan.BuildArgs = buildpacksEnv(opts.AppConfig, opts.ExtraBuildArgs)
+		plan.Reason = "the [build] section sets the nixpacks builder"
+	case opts.DockerfilePath != "":
+		if !helpers.FileExists(opts.DockerfilePath) {
+			return nil, fmt.Errorf("Dockerfile '%s' not found", opts.DockerfilePath)
+		}
+		plan.Strategy = (&dockerfileBuilder{}).Name()
+		plan.Dockerfile = opts.DockerfilePath
+		plan.Reason = "a Dockerfile was given with --dockerfile or in the [build] section"
+	case resolveDockerfile(opts.WorkingDir) != "":
+		plan.Strategy = (&dockerfileBuilder{}).Name()
+		plan.Dockerfile = resolveDockerfile(opts.WorkingDir)
+		plan.Reason = "there is a Dockerfile in the working directory"
+	case opts.AppConfig.HasBuiltin():
+		plan.Strategy = (&builtinBuilder{}).Name()
+		plan.Builtin = opts.AppConfig.Build.Builtin
+		plan.Reason = "the [build] section sets a builtin"
+	default:
+		return nil, flyerr.New(flyerr.InvalidConfig, "app does not have a Dockerfile or buildpacks configured. See https://fly.io/docs/reference/configuration/#the-build-section")
+	}
+
+	if plan.Dockerfile != "" {
+		if plan.DockerfileHash, err = fileHash(plan.Dockerfile); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// Matches is true while the plan's inputs, including the Dockerfile's
+// contents, are the same as when it was resolved
+func (p *BuildPlan) Matches(opts ImageOptions) bool {
+	workingDir, err := filepath.Abs(opts.WorkingDir)
+	if err != nil || workingDir != p.WorkingDir || p.InputsHash != planInputsHash(opts) {
+		return false
+	}
+
+	if p.Dockerfile != "" {
+		hash, err := fileHash(p.Dockerfile)
+		if err != nil || hash != p.DockerfileHash {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Explain - a line by line description of the plan
+func (p *BuildPlan) Explain() []string {
+	lines := []string{
+		fmt.Sprintf("Directory:  %s", p.WorkingDir),
+		fmt.Sprintf("Builder:    %s, because %s", p.Strategy, p.Reason),
+	}
+
+	switch {
+	case p.Builder != "":
+		lines = append(lines, fmt.Sprintf("Image:      %s", p.Builder))
+		if len(p.Buildpacks) > 0 {
+			lines = append(lines, fmt.Sprintf("Buildpacks: %s", strings.Join(p.Buildpacks, ", ")))
+		}
+	case p.Dockerfile != "":
+		lines = append(lines, fmt.Sprintf("Dockerfile: %s (sha256 %s)", p.Dockerfile, shortHash(p.DockerfileHash)))
+	case p.Builtin != "":
+		lines = append(lines, fmt.Sprintf("Builtin:    %s", p.Builtin))
+	}
+
+	if p.Target != "" {
+		lines = append(lines, fmt.Sprintf("Target:     %s", p.Target))
+	}
+
+	names := make([]string, 0, len(p.BuildArgs))
+	for name := range p.BuildArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		label := ""
+		if i == 0 {
+			label = "Build args:"
+		}
+		lines = append(lines, fmt.Sprintf("%-11s %s=%s", label, name, p.BuildArgs[name]))
+	}
+
+	lines = append(lines, fmt.Sprintf("Resolved:   %s", p.ResolvedAt.Format(time.RFC3339)))
+
+	return lines
+}
+
+// PlanPath returns where the build plan for a directory is cached
+func PlanPath(configDir string, workingDir string) string {
+	sum := sha256.Sum256([]byte(workingDir))
+	return filepath.Join(configDir, "build-plans", hex.EncodeToString(sum[:8])+".json")
+}
+
+// SavePlan caches a plan for its directory
+func SavePlan(configDir string, p *BuildPlan) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := PlanPath(configDir, p.WorkingDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadPlan reads the cached plan for a directory, returning nil when there isn't one
+func LoadPlan(configDir string, workingDir string) (*BuildPlan, error) {
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := PlanPath(configDir, workingDir)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p BuildPlan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid build plan %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// ClearPlan forgets the cached plan for a directory
+func ClearPlan(configDir string, workingDir string) error {
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(PlanPath(configDir, workingDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// CachedPlan returns the cached plan for opts when its inputs haven't
+// changed, otherwise resolves and caches a new one
+func CachedPlan(configDir string, opts ImageOptions) (plan *BuildPlan, cached bool, err error) {
+	plan, err = LoadPlan(configDir, opts.WorkingDir)
+	if err != nil {
+		return nil, false, err
+	}
+	if plan != nil && plan.Matches(opts) {
+		return plan, true, nil
+	}
+
+	plan, err = ResolvePlan(opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return plan, false, SavePlan(configDir, plan)
+}
+
+func planBuildArgs(opts ImageOptions) map[string]string {
+	args := map[string]string{}
+	for name, value := range normalizeBuildArgsForDocker(opts.AppConfig, opts.ExtraBuildArgs) {
+		args[name] = *value
+	}
+	return args
+}
+
+// planInputsHash covers everything other than the Dockerfile's contents that
+// the choice of builder depends on
+func planInputsHash(opts ImageOptions) string {
+	inputs := struct {
+		Build          interface{}
+		DockerfilePath string
+		Dockerfile     string
+		BuildArgs      map[string]string
+		Target         string
+	}{
+		Build:          opts.AppConfig.Build,
+		DockerfilePath: opts.DockerfilePath,
+		Dockerfile:     resolveDockerfile(opts.WorkingDir),
+		BuildArgs:      planBuildArgs(opts),
+		Target:         opts.Target,
+	}
+
+	// maps marshal with sorted keys, so the hash is stable
+	data, _ := json.Marshal(inputs)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func fileHash(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
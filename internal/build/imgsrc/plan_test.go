@@ -0,0 +1,89 @@
+package imgsrc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/flyctl"
+)
+
+func TestCachedPlan(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "plans")
+	require.NoError(t, err)
+	defer os.RemoveAll(configDir)
+
+	workingDir, err := ioutil.TempDir("", "app")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+
+	dockerfile := filepath.Join(workingDir, "Dockerfile")
+	require.NoError(t, ioutil.WriteFile(dockerfile, []byte("FROM alpine\n"), 0644))
+
+	opts := ImageOptions{WorkingDir: workingDir, AppConfig: flyctl.NewAppConfig()}
+
+	plan, cached, err := CachedPlan(configDir, opts)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "Dockerfile", plan.Strategy)
+	assert.Equal(t, dockerfile, plan.Dockerfile)
+
+	_, cached, err = CachedPlan(configDir, opts)
+	require.NoError(t, err)
+	assert.True(t, cached)
+
+	// editing the Dockerfile or the build args invalidates the plan
+	require.NoError(t, ioutil.WriteFile(dockerfile, []byte("FROM debian\n"), 0644))
+	_, cached, err = CachedPlan(configDir, opts)
+	require.NoError(t, err)
+	assert.False(t, cached)
+
+	opts.ExtraBuildArgs = map[string]string{"VERSION": "2"}
+	plan, cached, err = CachedPlan(configDir, opts)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, map[string]string{"VERSION": "2"}, plan.BuildArgs)
+}
+
+func TestResolvePlanPrefersBuildpacks(t *testing.T) {
+	cfg := flyctl.NewAppConfig()
+	cfg.Build = &flyctl.Build{Builder: "paketobuildpacks/builder:base"}
+
+	plan, err := ResolvePlan(ImageOptions{WorkingDir: "testdata", AppConfig: cfg})
+	require.NoError(t, err)
+	assert.Equal(t, "Buildpacks", plan.Strategy)
+	assert.Equal(t, "paketobuildpacks/builder:base", plan.Builder)
+
+	_, err = ResolvePlan(ImageOptions{WorkingDir: os.TempDir(), AppConfig: flyctl.NewAppConfig()})
+	assert.Error(t, err)
+}
+
+func TestResolvePlanBuildpacksEnv(t *testing.T) {
+	cfg := flyctl.NewAppConfig()
+	cfg.Build = &flyctl.Build{
+		Builder: "paketobuildpacks/builder:base",
+		Env:     map[string]string{"BP_GO_TARGETS": "./cmd/server", "VERSION": "1"},
+	}
+
+	plan, err := ResolvePlan(ImageOptions{WorkingDir: "testdata", AppConfig: cfg, ExtraBuildArgs: map[string]string{"VERSION": "2"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"BP_GO_TARGETS": "./cmd/server", "VERSION": "2"}, plan.BuildArgs)
+
+	cfg.Build = &flyctl.Build{Buildpacks: []string{"gcr.io/paketo-buildpacks/go"}}
+	_, err = ResolvePlan(ImageOptions{WorkingDir: "testdata", AppConfig: cfg})
+	assert.Error(t, err)
+}
+
+func TestResolvePlanNixpacks(t *testing.T) {
+	cfg := flyctl.NewAppConfig()
+	cfg.Build = &flyctl.Build{Builder: flyctl.NixpacksBuilder, Env: map[string]string{"NIXPACKS_NODE_VERSION": "16"}}
+
+	plan, err := ResolvePlan(ImageOptions{WorkingDir: "testdata", AppConfig: cfg})
+	require.NoError(t, err)
+	assert.Equal(t, "Nixpacks", plan.Strategy)
+	assert.Empty(t, plan.Builder)
+	assert.Equal(t, map[string]string{"NIXPACKS_NODE_VERSION": "16"}, plan.BuildArgs)
+}
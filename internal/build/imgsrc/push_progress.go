@@ -0,0 +1,138 @@
+package imgsrc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/dustin/go-humanize"
+)
+
+// pushProgress - the progress of an image push as a whole, gathered from the
+// per-layer status messages docker streams while it uploads layers
+// concurrently
+type pushProgress struct {
+	started time.Time
+	layers  map[string]*layerProgress
+	order   []string
+	retries int
+}
+
+// layerProgress - the progress of a single layer's upload
+type layerProgress struct {
+	current int64
+	total   int64
+	done    bool
+	existed bool
+}
+
+func newPushProgress() *pushProgress {
+	return &pushProgress{started: time.Now(), layers: map[string]*layerProgress{}}
+}
+
+// update records a status message from docker's push stream, returning a
+// line worth showing on its own, such as a layer being retried
+func (p *pushProgress) update(msg jsonmessage.JSONMessage) string {
+	if msg.ID == "" || msg.Error != nil {
+		return ""
+	}
+
+	layer, ok := p.layers[msg.ID]
+	if !ok {
+		layer = &layerProgress{}
+		p.layers[msg.ID] = layer
+		p.order = append(p.order, msg.ID)
+	}
+
+	switch {
+	case msg.Status == "Pushing":
+		if msg.Progress != nil {
+			layer.current = msg.Progress.Current
+			if msg.Progress.Total > 0 {
+				layer.total = msg.Progress.Total
+			}
+		}
+	case msg.Status == "Pushed":
+		layer.done = true
+		layer.current = layer.total
+	case msg.Status == "Layer already exists", strings.HasPrefix(msg.Status, "Mounted from"):
+		layer.done = true
+		layer.existed = true
+	case strings.HasPrefix(msg.Status, "Retrying"):
+		// docker starts a layer over, resumable uploads carry on from
+		// what was committed
+		layer.current = 0
+		if msg.Progress != nil {
+			layer.current = msg.Progress.Current
+		}
+		p.retries++
+		return fmt.Sprintf("Layer %s failed to upload, %s", msg.ID, strings.ToLower(msg.Status[:1])+msg.Status[1:])
+	}
+
+	return ""
+}
+
+// uploaded returns the bytes uploaded so far, and the bytes to upload, of
+// the layers that weren't already in the registry
+func (p *pushProgress) uploaded() (current int64, total int64) {
+	for _, layer := range p.layers {
+		if layer.existed {
+			continue
+		}
+		current += layer.current
+		total += layer.total
+	}
+	return
+}
+
+// throughput returns the bytes uploaded per second since the push started
+func (p *pushProgress) throughput() int64 {
+	elapsed := time.Since(p.started).Seconds()
+	if elapsed < 1 {
+		return 0
+	}
+	current, _ := p.uploaded()
+	return int64(float64(current) / elapsed)
+}
+
+// String returns a line summarizing the push so far
+func (p *pushProgress) String() string {
+	done := 0
+	for _, layer := range p.layers {
+		if layer.done {
+			done++
+		}
+	}
+	current, total := p.uploaded()
+
+	line := fmt.Sprintf("%d of %d layers, %s of %s", done, len(p.layers), humanize.Bytes(uint64(current)), humanize.Bytes(uint64(total)))
+	if rate := p.throughput(); rate > 0 {
+		line += fmt.Sprintf(", %s/s", humanize.Bytes(uint64(rate)))
+	}
+	return line
+}
+
+// summary returns a line describing the finished push
+func (p *pushProgress) summary() string {
+	existed := 0
+	for _, layer := range p.layers {
+		if layer.existed {
+			existed++
+		}
+	}
+	current, _ := p.uploaded()
+	elapsed := time.Since(p.started).Round(100 * time.Millisecond)
+
+	line := fmt.Sprintf("Pushed %d layers, uploading %s in %s", len(p.layers)-existed, humanize.Bytes(uint64(current)), elapsed)
+	if rate := p.throughput(); rate > 0 {
+		line += fmt.Sprintf(" at %s/s", humanize.Bytes(uint64(rate)))
+	}
+	if existed > 0 {
+		line += fmt.Sprintf(", %d already in the registry", existed)
+	}
+	if p.retries > 0 {
+		line += fmt.Sprintf(", with %d layer retries", p.retries)
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/stretchr/testify/assert"
+)
+
+func pushing(id string, current, total int64) jsonmessage.JSONMessage {
+	return jsonmessage.JSONMessage{ID: id, Status: "Pushing", Progress: &jsonmessage.JSONProgress{Current: current, Total: total}}
+}
+
+func TestPushProgressAggregatesLayers(t *testing.T) {
+	p := newPushProgress()
+
+	for _, msg := range []jsonmessage.JSONMessage{
+		{ID: "aaa", Status: "Preparing"},
+		{ID: "bbb", Status: "Preparing"},
+		{ID: "ccc", Status: "Preparing"},
+		{ID: "ccc", Status: "Layer already exists"},
+		pushing("aaa", 1000, 4000),
+		pushing("bbb", 500, 2000),
+	} {
+		assert.Empty(t, p.update(msg))
+	}
+
+	current, total := p.uploaded()
+	assert.Equal(t, int64(1500), current)
+	assert.Equal(t, int64(6000), total)
+	assert.Equal(t, "1 of 3 layers, 1.5 kB of 6.0 kB", p.String())
+
+	p.update(jsonmessage.JSONMessage{ID: "aaa", Status: "Pushed"})
+	current, _ = p.uploaded()
+	assert.Equal(t, int64(4500), current)
+}
+
+func TestPushProgressRetriedLayer(t *testing.T) {
+	p := newPushProgress()
+
+	p.update(pushing("aaa", 3000, 4000))
+	line := p.update(jsonmessage.JSONMessage{ID: "aaa", Status: "Retrying in 5 seconds"})
+
+	assert.Equal(t, "Layer aaa failed to upload, retrying in 5 seconds", line)
+	current, total := p.uploaded()
+	assert.Equal(t, int64(0), current)
+	assert.Equal(t, int64(4000), total)
+	assert.Contains(t, p.summary(), "with 1 layer retries")
+}
+
+func TestPushProgressIgnoresImageMessages(t *testing.T) {
+	p := newPushProgress()
+
+	p.update(jsonmessage.JSONMessage{Status: "The push refers to repository [registry.fly.io/test-app]"})
+	p.update(jsonmessage.JSONMessage{Status: "deployment-123: digest: sha256:abc size: 1570"})
+
+	assert.Empty(t, p.layers)
+}
+
+func TestPushProgressResumedLayer(t *testing.T) {
+	p := newPushProgress()
+
+	p.update(pushing("aaa", 3000, 4000))
+	retrying := jsonmessage.JSONMessage{ID: "aaa", Status: "Retrying from 3.0 kB after EOF", Progress: &jsonmessage.JSONProgress{Current: 3000, Total: 4000}}
+
+	assert.Equal(t, "Layer aaa failed to upload, retrying from 3.0 kB after EOF", p.update(retrying))
+	current, _ := p.uploaded()
+	assert.Equal(t, int64(3000), current)
+}
@@ -0,0 +1,145 @@
+package imgsrc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/moby/buildkit/session/auth"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/registry"
+	"github.com/superfly/flyctl/pkg/iostreams"
+	"google.golang.org/grpc"
+)
+
+// dockerHubAuthKey - the key docker keeps Docker Hub's credentials under
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// authConfigs returns credentials for the registries a build may pull base
+// images from: Fly's registry, Docker Hub with DOCKER_HUB_USERNAME and
+// DOCKER_HUB_PASSWORD, and those docker has credentials for in
+// ~/.docker/config.json or from credential helpers. Remote builders only
+// get docker's credentials for the registries in forward, which are
+// reported, along with those docker has no credentials for.
+func authConfigs(remote bool, forward []string, streams *iostreams.IOStreams) map[string]types.AuthConfig {
+	authConfigs := map[string]types.AuthConfig{}
+
+	for _, auth := range registry.AllDockerAuths() {
+		if remote && !isForwarded(auth.ServerAddress, forward) {
+			continue
+		}
+		authConfigs[auth.ServerAddress] = types.AuthConfig{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			IdentityToken: auth.IdentityToken,
+			ServerAddress: auth.ServerAddress,
+		}
+	}
+
+	if remote {
+		for _, host := range forward {
+			if _, ok := lookupAuthConfig(authConfigs, host); ok {
+				fmt.Fprintf(streams.ErrOut, "Forwarding credentials for %s to the remote builder for this build\n", host)
+			} else {
+				fmt.Fprintf(streams.ErrOut, "No docker credentials for %s to forward to the remote builder, log in with `docker login %s`\n", host, host)
+			}
+		}
+	}
+
+	if token := flyctl.GetAPIToken(); token != "" {
+		flyRegistry := registryAuth(token)
+		authConfigs[flyRegistry.ServerAddress] = flyRegistry
+	}
+
+	dockerhubUsername := os.Getenv("DOCKER_HUB_USERNAME")
+	dockerhubPassword := os.Getenv("DOCKER_HUB_PASSWORD")
+
+	if dockerhubUsername != "" && dockerhubPassword != "" {
+		cfg := types.AuthConfig{
+			Username:      dockerhubUsername,
+			Password:      dockerhubPassword,
+			ServerAddress: "index.docker.io",
+		}
+		authConfigs[dockerHubAuthKey] = cfg
+	}
+
+	return authConfigs
+}
+
+// registryHostname returns the host of a registry as docker keys its
+// credentials, such as https://ghcr.io or https://index.docker.io/v1/, with
+// Docker Hub's hosts all named docker.io
+func registryHostname(key string) string {
+	host := key
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "/"))
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return host
+}
+
+// isForwarded is true when the registry keyed by key is in forward
+func isForwarded(key string, forward []string) bool {
+	host := registryHostname(key)
+	for _, allowed := range forward {
+		if registryHostname(allowed) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupAuthConfig returns the credentials in authConfigs for host
+func lookupAuthConfig(authConfigs map[string]types.AuthConfig, host string) (types.AuthConfig, bool) {
+	host = registryHostname(host)
+
+	keys := make([]string, 0, len(authConfigs))
+	for key := range authConfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if registryHostname(key) == host {
+			return authConfigs[key], true
+		}
+	}
+	return types.AuthConfig{}, false
+}
+
+// sessionAuthProvider answers BuildKit's requests for registry credentials
+// through the build session, so they're only available to the builder
+// while the build runs. Tokens are fetched by the builder itself.
+type sessionAuthProvider struct {
+	auth.UnimplementedAuthServer
+	authConfigs map[string]types.AuthConfig
+}
+
+func newSessionAuthProvider(authConfigs map[string]types.AuthConfig) *sessionAuthProvider {
+	return &sessionAuthProvider{authConfigs: authConfigs}
+}
+
+func (p *sessionAuthProvider) Register(server *grpc.Server) {
+	auth.RegisterAuthServer(server, p)
+}
+
+func (p *sessionAuthProvider) Credentials(ctx context.Context, req *auth.CredentialsRequest) (*auth.CredentialsResponse, error) {
+	ac, ok := lookupAuthConfig(p.authConfigs, req.Host)
+	if !ok {
+		// anonymous access
+		return &auth.CredentialsResponse{}, nil
+	}
+	if ac.IdentityToken != "" {
+		return &auth.CredentialsResponse{Secret: ac.IdentityToken}, nil
+	}
+	return &auth.CredentialsResponse{Username: ac.Username, Secret: ac.Password}, nil
+}
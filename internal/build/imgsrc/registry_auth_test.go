@@ -0,0 +1,73 @@
+package imgsrc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/moby/buildkit/session/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/pkg/iostreams"
+)
+
+func TestRegistryHostname(t *testing.T) {
+	for key, host := range map[string]string{
+		"ghcr.io":                     "ghcr.io",
+		"https://ghcr.io":             "ghcr.io",
+		"https://index.docker.io/v1/": "docker.io",
+		"registry-1.docker.io":        "docker.io",
+		"Quay.io/":                    "quay.io",
+	} {
+		assert.Equal(t, host, registryHostname(key), key)
+	}
+}
+
+func TestAuthConfigsOnlyForwardsAllowedRegistries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	config := fmt.Sprintf(`{"auths": {"https://ghcr.io": {"auth": %q}, "quay.io": {"auth": %q}}}`, auth, auth)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600))
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Unsetenv("DOCKER_CONFIG")
+
+	streams, _, _, errOut := iostreams.Test()
+
+	local := authConfigs(false, nil, streams)
+	assert.Contains(t, local, "https://ghcr.io")
+	assert.Contains(t, local, "quay.io")
+	assert.Empty(t, errOut.String())
+
+	remote := authConfigs(true, []string{"ghcr.io", "gcr.io"}, streams)
+	assert.Contains(t, remote, "https://ghcr.io")
+	assert.NotContains(t, remote, "quay.io")
+	assert.Contains(t, errOut.String(), "Forwarding credentials for ghcr.io")
+	assert.Contains(t, errOut.String(), "No docker credentials for gcr.io")
+}
+
+func TestSessionAuthProvider(t *testing.T) {
+	provider := newSessionAuthProvider(map[string]types.AuthConfig{
+		dockerHubAuthKey:  {Username: "user", Password: "secret"},
+		"team.azurecr.io": {IdentityToken: "refresh-token"},
+	})
+
+	resp, err := provider.Credentials(context.Background(), &auth.CredentialsRequest{Host: "registry-1.docker.io"})
+	require.NoError(t, err)
+	assert.Equal(t, &auth.CredentialsResponse{Username: "user", Secret: "secret"}, resp)
+
+	resp, err = provider.Credentials(context.Background(), &auth.CredentialsRequest{Host: "team.azurecr.io"})
+	require.NoError(t, err)
+	assert.Equal(t, &auth.CredentialsResponse{Secret: "refresh-token"}, resp)
+
+	resp, err = provider.Credentials(context.Background(), &auth.CredentialsRequest{Host: "ghcr.io"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Secret)
+}
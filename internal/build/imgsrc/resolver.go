@@ -2,11 +2,12 @@ package imgsrc
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/pkg/iostreams"
 	"github.com/superfly/flyctl/terminal"
 )
@@ -23,6 +24,13 @@ type ImageOptions struct {
 	Tag            string
 	Target         string
 	NoCache        bool
+	// ContextWarnSize - warn when the build context is larger than this many bytes, 0 to never warn
+	ContextWarnSize int64
+	// Plan - a resolved build plan. When set only its builder is tried.
+	Plan *BuildPlan
+	// ForwardRegistryAuth - registries whose docker credentials are given to
+	// remote builders for pulling private base images
+	ForwardRegistryAuth []string
 }
 
 type RefOptions struct {
@@ -68,10 +76,10 @@ func (r *Resolver) ResolveReference(ctx context.Context, streams *iostreams.IOSt
 	return nil, fmt.Errorf("could not find image \"%s\"", opts.ImageRef)
 }
 
-// BuildImage converts source code to an image using a Dockerfile, buildpacks, or builtins.
+// BuildImage converts source code to an image using a Dockerfile, buildpacks, nixpacks, or builtins.
 func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams, opts ImageOptions) (img *DeploymentImage, err error) {
 	if !r.dockerFactory.mode.IsAvailable() {
-		return nil, errors.New("docker is unavailable to build the deployment image")
+		return nil, flyerr.New(flyerr.DockerUnavailable, "docker is unavailable to build the deployment image")
 	}
 
 	if opts.Tag == "" {
@@ -80,28 +88,61 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 
 	strategies := []imageBuilder{
 		&buildpacksBuilder{},
+		&nixpacksBuilder{},
 		&dockerfileBuilder{},
 		&builtinBuilder{},
 	}
 
+	if opts.Plan != nil {
+		strategies = planStrategies(strategies, opts.Plan)
+		if opts.Plan.Dockerfile != "" {
+			opts.DockerfilePath = opts.Plan.Dockerfile
+		}
+	}
+
 	for _, s := range strategies {
 		terminal.Debugf("Trying '%s' strategy\n", s.Name())
 		img, err = s.Run(ctx, r.dockerFactory, streams, opts)
 		terminal.Debugf("result image:%+v error:%v\n", img, err)
 		if err != nil {
-			return nil, err
+			return nil, flyerr.Wrap(flyerr.BuildFailed, err)
 		}
 		if img != nil {
 			return img, nil
 		}
 	}
 
-	return nil, errors.New("app does not have a Dockerfile or buildpacks configured. See https://fly.io/docs/reference/configuration/#the-build-section")
+	return nil, flyerr.New(flyerr.InvalidConfig, "app does not have a Dockerfile or buildpacks configured. See https://fly.io/docs/reference/configuration/#the-build-section")
+}
+
+// planStrategies narrows strategies to the one chosen by plan
+func planStrategies(strategies []imageBuilder, plan *BuildPlan) []imageBuilder {
+	for _, s := range strategies {
+		if s.Name() == plan.Strategy {
+			return []imageBuilder{s}
+		}
+	}
+	return strategies
+}
+
+// RemoteBuilderOptions - which remote builder builds an app's images, and
+// the size it's reconciled to before building
+type RemoteBuilderOptions struct {
+	// App - the builder app to use in place of the one the organization is
+	// pinned to, or the one flyctl picks
+	App  string
+	Size flyctl.RemoteBuilderSize
+	// Timeout - how long to wait for the builder to start,
+	// DefaultRemoteBuilderTimeout when it's 0
+	Timeout time.Duration
 }
 
-func NewResolver(daemonType DockerDaemonType, apiClient *api.Client, appName string, iostreams *iostreams.IOStreams) *Resolver {
+// NewResolver returns a resolver building appName's images with a docker
+// daemon of daemonType. Remote builds use builder.App when it's set, or else
+// the builder the app's organization is pinned to, if any.
+func NewResolver(daemonType DockerDaemonType, apiClient *api.Client, appName string, builder RemoteBuilderOptions, iostreams *iostreams.IOStreams) *Resolver {
 	return &Resolver{
-		dockerFactory: newDockerClientFactory(daemonType, apiClient, appName, iostreams),
+		dockerFactory: newDockerClientFactory(daemonType, apiClient, appName, builder, iostreams),
 		apiClient:     apiClient,
 	}
 }
@@ -0,0 +1,126 @@
+package imgsrc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/superfly/flyctl/internal/doctor"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/pkg/wg"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// diagnoseAfter - how long pings of a running remote builder fail before the
+// connection to it is diagnosed, and how long between diagnoses after that
+const diagnoseAfter = 30 * time.Second
+
+// diagnosisTimeout - how long each layer of the diagnosis is given
+const diagnosisTimeout = 10 * time.Second
+
+// builderTunnel - the WireGuard tunnel to a remote builder, as far as
+// diagnosing it goes
+type builderTunnel interface {
+	LastHandshake() time.Time
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+type wgBuilderTunnel struct {
+	*wg.Tunnel
+}
+
+func (t wgBuilderTunnel) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return t.Resolver().LookupHost(ctx, host)
+}
+
+// diagnoseBuilderTunnel checks each layer between flyctl and builderApp's
+// docker daemon in turn: the WireGuard handshake, the builder's internal DNS
+// name, then the docker port. Layers after a failed one are skipped.
+func diagnoseBuilderTunnel(ctx context.Context, tunnel builderTunnel, builderApp string) []doctor.Result {
+	name := builderApp + ".internal"
+	var addrs []string
+
+	checks := []doctor.Check{
+		{
+			Name: "WireGuard handshake",
+			Run: func(ctx context.Context) (string, error) {
+				last := tunnel.LastHandshake()
+				if last.IsZero() {
+					return "", doctor.Fail(errors.New("no handshake with the WireGuard gateway"),
+						"WireGuard runs over UDP port 51820. Check that your network and firewall allow outbound UDP to it, or tunnel over WebSockets with --wg-websockets.")
+				}
+				return fmt.Sprintf("last handshake %s ago", time.Since(last).Round(time.Second)), nil
+			},
+		},
+		{
+			Name:     "Builder DNS",
+			Requires: []string{"WireGuard handshake"},
+			Run: func(ctx context.Context) (string, error) {
+				var err error
+				addrs, err = tunnel.LookupHost(ctx, name)
+				if err == nil && len(addrs) == 0 {
+					err = errors.New("no addresses")
+				}
+				if err != nil {
+					return "", doctor.Fail(fmt.Errorf("can't resolve %s: %w", name, err),
+						fmt.Sprintf("Check that it's running with 'flyctl status -a %s', or restart it with 'flyctl apps restart %s'.", builderApp, builderApp))
+				}
+				return fmt.Sprintf("%s resolves to %s", name, addrs[0]), nil
+			},
+		},
+		{
+			Name:     "Docker port",
+			Requires: []string{"Builder DNS"},
+			Run: func(ctx context.Context) (string, error) {
+				addr := net.JoinHostPort(addrs[0], "2375")
+				conn, err := tunnel.DialContext(ctx, "tcp", addr)
+				if err != nil {
+					return "", doctor.Fail(fmt.Errorf("can't connect to %s: %w", addr, err),
+						fmt.Sprintf("The builder is running but docker isn't listening. Check its logs with 'flyctl logs -a %s', or destroy it and a new one will be created.", builderApp))
+				}
+				conn.Close()
+				return fmt.Sprintf("%s is reachable", addr), nil
+			},
+		},
+	}
+
+	return doctor.Run(ctx, checks, diagnosisTimeout, nil)
+}
+
+// tunnelDiagnosisError returns an error naming the first layer that failed
+// in results, or nil when none did
+func tunnelDiagnosisError(builderApp string, results []doctor.Result) error {
+	for _, result := range results {
+		if result.Status != doctor.StatusFail {
+			continue
+		}
+		message := fmt.Sprintf("can't reach remote builder %s, the %s check failed: %s", builderApp, result.Name, result.Message)
+		if result.Hint != "" {
+			message += ". " + result.Hint
+		}
+		return flyerr.New(flyerr.DockerUnavailable, message)
+	}
+	return nil
+}
+
+// diagnoseBuilder diagnoses the connection to builderApp, printing the
+// results to out when a layer is broken and returning an error naming it
+func diagnoseBuilder(ctx context.Context, tunnel builderTunnel, builderApp string, out io.Writer) error {
+	results := diagnoseBuilderTunnel(ctx, tunnel, builderApp)
+
+	err := tunnelDiagnosisError(builderApp, results)
+	if err == nil {
+		terminal.Debugf("Connection to remote builder %s looks healthy, waiting for docker to respond\n", builderApp)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Remote builder %s isn't responding, diagnosing the connection to it:\n", builderApp)
+	for _, result := range results {
+		fmt.Fprintf(out, "  %-4s %-19s %s\n", result.Status, result.Name, result.Message)
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package imgsrc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/doctor"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+type fakeTunnel struct {
+	handshake time.Time
+	addrs     []string
+	dialErr   error
+}
+
+func (t *fakeTunnel) LastHandshake() time.Time {
+	return t.handshake
+}
+
+func (t *fakeTunnel) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if len(t.addrs) == 0 {
+		return nil, errors.New("no such host")
+	}
+	return t.addrs, nil
+}
+
+func (t *fakeTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t.dialErr != nil {
+		return nil, t.dialErr
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func diagnosisStatuses(results []doctor.Result) []doctor.Status {
+	var statuses []doctor.Status
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	return statuses
+}
+
+func TestDiagnoseBuilderTunnelWithoutHandshake(t *testing.T) {
+	results := diagnoseBuilderTunnel(context.Background(), &fakeTunnel{}, "fly-builder")
+	assert.Equal(t, []doctor.Status{doctor.StatusFail, doctor.StatusSkip, doctor.StatusSkip}, diagnosisStatuses(results))
+
+	err := tunnelDiagnosisError("fly-builder", results)
+	require.Error(t, err)
+	assert.Equal(t, flyerr.DockerUnavailable, flyerr.CodeOf(err))
+	assert.Contains(t, err.Error(), "WireGuard handshake check failed")
+}
+
+func TestDiagnoseBuilderTunnelUnresolved(t *testing.T) {
+	results := diagnoseBuilderTunnel(context.Background(), &fakeTunnel{handshake: time.Now()}, "fly-builder")
+	assert.Equal(t, []doctor.Status{doctor.StatusPass, doctor.StatusFail, doctor.StatusSkip}, diagnosisStatuses(results))
+	assert.Contains(t, tunnelDiagnosisError("fly-builder", results).Error(), "can't resolve fly-builder.internal")
+}
+
+func TestDiagnoseBuilderTunnelPortClosed(t *testing.T) {
+	tunnel := &fakeTunnel{handshake: time.Now(), addrs: []string{"fdaa::2"}, dialErr: errors.New("connection refused")}
+	results := diagnoseBuilderTunnel(context.Background(), tunnel, "fly-builder")
+	assert.Equal(t, []doctor.Status{doctor.StatusPass, doctor.StatusPass, doctor.StatusFail}, diagnosisStatuses(results))
+	assert.Contains(t, tunnelDiagnosisError("fly-builder", results).Error(), "[fdaa::2]:2375")
+}
+
+func TestDiagnoseBuilderTunnelHealthy(t *testing.T) {
+	tunnel := &fakeTunnel{handshake: time.Now(), addrs: []string{"fdaa::2"}}
+	results := diagnoseBuilderTunnel(context.Background(), tunnel, "fly-builder")
+	assert.NoError(t, tunnelDiagnosisError("fly-builder", results))
+}
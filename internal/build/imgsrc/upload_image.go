@@ -0,0 +1,481 @@
+package imgsrc
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/dustin/go-humanize"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/registry"
+	"golang.org/x/sync/errgroup"
+)
+
+// Media types of the docker image manifests flyctl pushes
+const (
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerConfig   = "application/vnd.docker.container.image.v1+json"
+	mediaTypeDockerLayer    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+// uploadConcurrency - how many layers are uploaded at once
+const uploadConcurrency = 3
+
+// isLocalDaemon is true for docker daemons on this machine, rather than
+// remote builders, which push from Fly's network themselves
+func isLocalDaemon(docker *dockerclient.Client) bool {
+	host := docker.DaemonHost()
+	return strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://")
+}
+
+// storesCompressedLayers is true when docker keeps images' layers
+// compressed, as it does with the containerd image store, and pushes them as
+// they are. The classic image store compresses layers as it pushes them, and
+// only docker knows the digests they were pushed with.
+func storesCompressedLayers(ctx context.Context, docker *dockerclient.Client) bool {
+	info, err := docker.Info(ctx)
+	if err != nil {
+		return false
+	}
+	for _, status := range info.DriverStatus {
+		if status[0] == "driver-type" && status[1] == "io.containerd.snapshotter.v1" {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadImageStream uploads tag from docker to its registry, returning the
+// upload's progress as a stream of the JSON messages docker pushes stream
+func uploadImageStream(ctx context.Context, docker *dockerclient.Client, tag string) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(uploadImage(ctx, docker, tag, w))
+	}()
+	return r
+}
+
+// uploadImage saves tag from docker and uploads its layers in resumable
+// chunks, so a failure part way through a layer doesn't start it over.
+// Layers docker already pushed have the same digests, so they're found in
+// the registry rather than uploaded again, and layers pushed to another app
+// before are mounted from it. Saving the whole image is costly, so it's only
+// used to retry a push that docker push couldn't finish.
+func uploadImage(ctx context.Context, docker *dockerclient.Client, tag string, out io.Writer) error {
+	host, repository, reference := splitImageTag(tag)
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(out)
+	send := func(msg jsonmessage.JSONMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		encoder.Encode(msg)
+	}
+
+	dir, err := ioutil.TempDir("", "flyctl-push")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	send(jsonmessage.JSONMessage{Status: "Saving the image's layers"})
+	image, err := saveImage(ctx, docker, tag, dir)
+	if err != nil {
+		return err
+	}
+
+	client := registry.New()
+	client.Credentials = func(h string) (string, string) {
+		if h == host {
+			return "x", flyctl.GetAPIToken()
+		}
+		return registry.DockerCredentials(h)
+	}
+
+	mounts := loadPushedLayers()
+	g, gctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, uploadConcurrency)
+
+	for _, layer := range image.layers {
+		layer := layer
+		id := shortDigest(layer.Digest)
+		send(jsonmessage.JSONMessage{ID: id, Status: "Preparing"})
+
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			f, err := os.Open(layer.path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			blob := &registry.Blob{
+				Digest:    layer.Digest,
+				Size:      layer.Size,
+				Content:   f,
+				MountFrom: mounts.repository(layer.Digest),
+				Progress: func(committed int64) {
+					send(jsonmessage.JSONMessage{ID: id, Status: "Pushing", Progress: &jsonmessage.JSONProgress{Current: committed, Total: layer.Size}})
+				},
+				Retrying: func(err error, committed int64) {
+					send(jsonmessage.JSONMessage{
+						ID:       id,
+						Status:   fmt.Sprintf("Retrying from %s after %v", humanize.Bytes(uint64(committed)), err),
+						Progress: &jsonmessage.JSONProgress{Current: committed, Total: layer.Size},
+					})
+				},
+			}
+
+			status, err := client.UploadBlob(gctx, host, repository, blob)
+			if err != nil {
+				return err
+			}
+			switch status {
+			case registry.BlobExisted:
+				send(jsonmessage.JSONMessage{ID: id, Status: "Layer already exists"})
+			case registry.BlobMounted:
+				send(jsonmessage.JSONMessage{ID: id, Status: "Mounted from " + blob.MountFrom})
+			default:
+				send(jsonmessage.JSONMessage{ID: id, Status: "Pushed"})
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	config, err := client.PutBlob(ctx, host, repository, mediaTypeDockerConfig, image.config)
+	if err != nil {
+		return err
+	}
+
+	manifest := &registry.Manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeDockerManifest,
+		Config:        &config,
+	}
+	for _, layer := range image.layers {
+		manifest.Layers = append(manifest.Layers, layer.Descriptor)
+	}
+	if err := client.PutManifest(ctx, host, repository, reference, manifest); err != nil {
+		return err
+	}
+
+	for _, layer := range image.layers {
+		mounts[layer.Digest] = repository
+	}
+	mounts.save()
+
+	return nil
+}
+
+// savedImage - an image saved from docker, with its layers compressed
+type savedImage struct {
+	config []byte
+	layers []savedLayer
+}
+
+// savedLayer - a compressed layer, and the file it's in
+type savedLayer struct {
+	registry.Descriptor
+	path string
+}
+
+// maxSavedJSON - the largest file in a saved image that's read as JSON,
+// rather than as a layer
+const maxSavedJSON = 1 << 20
+
+// saveImage saves tag from docker, keeping each of its layers in dir
+func saveImage(ctx context.Context, docker *dockerclient.Client, tag string, dir string) (*savedImage, error) {
+	archive, err := docker.ImageSave(ctx, []string{tag})
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return readSavedImage(archive, tag, dir)
+}
+
+// readSavedImage reads an image saved by docker, either in the OCI layout
+// docker 25 and later write, with files named blobs/sha256/<hex>, or with
+// the <id>/layer.tar files earlier versions write. Layers that are already
+// compressed are kept as they are, so they have the digests docker pushed
+// them with. Others are compressed into dir.
+func readSavedImage(archive io.Reader, tag string, dir string) (*savedImage, error) {
+	files := map[string][]byte{}
+	layers := map[string]savedLayer{}
+	// links - the files an archive links to rather than repeat, by name
+	links := map[string]string{}
+
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink {
+			links[hdr.Name] = path.Join(path.Dir(hdr.Name), hdr.Linkname)
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		r := bufio.NewReader(tr)
+		head, _ := r.Peek(2)
+		layerPath := filepath.Join(dir, fmt.Sprintf("layer-%d.tar.gz", len(layers)))
+
+		switch {
+		case bytes.HasPrefix(head, gzipMagic):
+			layer, err := writeLayer(r, layerPath, false)
+			if err != nil {
+				return nil, err
+			}
+			layers[hdr.Name] = layer
+		case hdr.Size <= maxSavedJSON && (strings.HasSuffix(hdr.Name, ".json") || bytes.HasPrefix(head, []byte("{"))):
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			files[hdr.Name] = data
+		case strings.HasPrefix(hdr.Name, "blobs/") || strings.HasSuffix(hdr.Name, ".tar"):
+			layer, err := compressLayer(r, layerPath)
+			if err != nil {
+				return nil, err
+			}
+			layers[hdr.Name] = layer
+		}
+	}
+
+	configName, layerNames, ok := savedManifest(files)
+	if !ok {
+		return nil, fmt.Errorf("docker saved %s without a manifest", tag)
+	}
+
+	image := &savedImage{config: files[configName]}
+	if image.config == nil {
+		return nil, fmt.Errorf("docker saved %s without its config", tag)
+	}
+	for _, name := range layerNames {
+		if target, ok := links[name]; ok {
+			name = target
+		}
+
+		layer, ok := layers[name]
+		if data, isFile := files[name]; !ok && isFile {
+			// a small layer that looked like JSON
+			var err error
+			if layer, err = compressLayer(bytes.NewReader(data), filepath.Join(dir, fmt.Sprintf("layer-%d.tar.gz", len(layers)))); err != nil {
+				return nil, err
+			}
+			layers[name], ok = layer, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("docker saved %s without layer %s", tag, name)
+		}
+		image.layers = append(image.layers, layer)
+	}
+
+	return image, nil
+}
+
+// savedManifest finds the names of a saved image's config and layers, from
+// the OCI index.json when there is one, or docker's manifest.json
+func savedManifest(files map[string][]byte) (config string, layers []string, ok bool) {
+	var index struct {
+		Manifests []ociDescriptor
+	}
+	if err := json.Unmarshal(files["index.json"], &index); err == nil {
+		if config, layers, ok := ociManifest(files, index.Manifests); ok {
+			return config, layers, true
+		}
+	}
+
+	var manifests []struct {
+		Config string
+		Layers []string
+	}
+	if err := json.Unmarshal(files["manifest.json"], &manifests); err != nil || len(manifests) != 1 {
+		return "", nil, false
+	}
+	return manifests[0].Config, manifests[0].Layers, true
+}
+
+type ociDescriptor struct {
+	Digest string
+}
+
+// blobName - the name of the file an OCI layout keeps a blob in
+func (d ociDescriptor) blobName() string {
+	return "blobs/" + strings.Replace(d.Digest, ":", "/", 1)
+}
+
+// ociManifest follows descriptors to the first image manifest the archive
+// has, through image indexes such as those of multi-platform images
+func ociManifest(files map[string][]byte, descriptors []ociDescriptor) (config string, layers []string, ok bool) {
+	for _, descriptor := range descriptors {
+		var manifest struct {
+			Manifests []ociDescriptor
+			Config    *ociDescriptor
+			Layers    []ociDescriptor
+		}
+		if err := json.Unmarshal(files[descriptor.blobName()], &manifest); err != nil {
+			continue
+		}
+
+		if manifest.Config == nil {
+			if config, layers, ok := ociManifest(files, manifest.Manifests); ok {
+				return config, layers, true
+			}
+			continue
+		}
+
+		for _, layer := range manifest.Layers {
+			layers = append(layers, layer.blobName())
+		}
+		return manifest.Config.blobName(), layers, true
+	}
+
+	return "", nil, false
+}
+
+// gzipMagic - the bytes gzipped files start with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressLayer gzips a layer to path, returning its descriptor
+func compressLayer(r io.Reader, path string) (savedLayer, error) {
+	return writeLayer(r, path, true)
+}
+
+// writeLayer writes a layer to path, gzipping it unless it's compressed
+// already, returning its descriptor
+func writeLayer(r io.Reader, path string, compress bool) (savedLayer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return savedLayer{}, err
+	}
+	defer f.Close()
+
+	digest := sha256.New()
+	counter := &countingWriter{}
+	out := io.MultiWriter(f, digest, counter)
+
+	if compress {
+		zw := gzip.NewWriter(out)
+		if _, err := io.Copy(zw, r); err != nil {
+			return savedLayer{}, err
+		}
+		if err := zw.Close(); err != nil {
+			return savedLayer{}, err
+		}
+	} else if _, err := io.Copy(out, r); err != nil {
+		return savedLayer{}, err
+	}
+
+	return savedLayer{
+		Descriptor: registry.Descriptor{
+			MediaType: mediaTypeDockerLayer,
+			Digest:    digestOf(digest),
+			Size:      counter.n,
+		},
+		path: path,
+	}, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func digestOf(h hash.Hash) string {
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+// shortDigest returns the first 12 characters of digest's hash, as docker
+// names layers in its progress
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+// splitImageTag splits a tag such as registry.fly.io/app:deployment-1 into
+// its registry host, repository and tag
+func splitImageTag(tag string) (host, repository, reference string) {
+	host, repository = "docker.io", tag
+	if i := strings.Index(tag, "/"); i > 0 && strings.ContainsAny(tag[:i], ".:") {
+		host, repository = tag[:i], tag[i+1:]
+	}
+
+	reference = "latest"
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, reference = repository[:i], repository[i+1:]
+	}
+	return host, repository, reference
+}
+
+// pushedLayers - the repository each layer was last pushed to, so layers
+// shared between apps are mounted rather than uploaded again
+type pushedLayers map[string]string
+
+// maxPushedLayers - how many layers pushedLayers remembers
+const maxPushedLayers = 2000
+
+func pushedLayersPath() string {
+	return filepath.Join(flyctl.ConfigDir(), "pushed_layers.json")
+}
+
+func loadPushedLayers() pushedLayers {
+	layers := pushedLayers{}
+	if data, err := ioutil.ReadFile(pushedLayersPath()); err == nil {
+		json.Unmarshal(data, &layers)
+	}
+	return layers
+}
+
+func (l pushedLayers) repository(digest string) string {
+	return l[digest]
+}
+
+// save writes the layers, forgetting arbitrary ones once there are too many
+// to remember. Failing to save them only means layers aren't mounted later.
+func (l pushedLayers) save() {
+	for digest := range l {
+		if len(l) <= maxPushedLayers {
+			break
+		}
+		delete(l, digest)
+	}
+	if data, err := json.Marshal(l); err == nil {
+		ioutil.WriteFile(pushedLayersPath(), data, 0600)
+	}
+}
@@ -0,0 +1,162 @@
+package imgsrc
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitImageTag(t *testing.T) {
+	host, repository, reference := splitImageTag("registry.fly.io/test-app:deployment-123")
+	assert.Equal(t, []string{"registry.fly.io", "test-app", "deployment-123"}, []string{host, repository, reference})
+
+	host, repository, reference = splitImageTag("localhost:5000/team/app")
+	assert.Equal(t, []string{"localhost:5000", "team/app", "latest"}, []string{host, repository, reference})
+
+	host, repository, reference = splitImageTag("library/nginx:1.19")
+	assert.Equal(t, []string{"docker.io", "library/nginx", "1.19"}, []string{host, repository, reference})
+}
+
+func TestCompressLayerIsReproducible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("layer content "), 1000)
+
+	first, err := compressLayer(bytes.NewReader(content), filepath.Join(dir, "first.tar.gz"))
+	require.NoError(t, err)
+	second, err := compressLayer(bytes.NewReader(content), filepath.Join(dir, "second.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, first.Digest, second.Digest)
+	assert.Equal(t, mediaTypeDockerLayer, first.MediaType)
+
+	f, err := os.Open(first.path)
+	require.NoError(t, err)
+	defer f.Close()
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), first.Size)
+
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, content, decompressed)
+}
+
+// savedFile - a file in an archive written by docker save, a symlink when
+// link is set
+type savedFile struct {
+	name string
+	data []byte
+	link string
+}
+
+func saveArchive(t *testing.T, files ...savedFile) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, f := range files {
+		if f.link != "" {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeSymlink, Linkname: f.link}))
+			continue
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.data))}))
+		_, err := tw.Write(f.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf
+}
+
+func blobDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func blobFile(data []byte) savedFile {
+	return savedFile{name: fmt.Sprintf("blobs/sha256/%x", sha256.Sum256(data)), data: data}
+}
+
+func TestReadSavedImageOCILayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a layer compressed differently than compressLayer would, as docker's
+	// own are
+	compressed := &bytes.Buffer{}
+	zw, err := gzip.NewWriterLevel(compressed, gzip.BestSpeed)
+	require.NoError(t, err)
+	zw.Write(bytes.Repeat([]byte("compressed layer "), 1000))
+	require.NoError(t, zw.Close())
+	uncompressed := bytes.Repeat([]byte("uncompressed layer "), 1000)
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":%q},"layers":[{"digest":%q},{"digest":%q}]}`,
+		blobDigest(config), blobDigest(compressed.Bytes()), blobDigest(uncompressed)))
+	// docker saves multi-platform images from the containerd store as an index
+	platforms := []byte(fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"digest":"sha256:%064d"},{"digest":%q}]}`, 0, blobDigest(manifest)))
+	index := []byte(fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"digest":%q}]}`, blobDigest(platforms)))
+
+	archive := saveArchive(t,
+		blobFile(compressed.Bytes()),
+		blobFile(uncompressed),
+		blobFile(config),
+		blobFile(manifest),
+		blobFile(platforms),
+		savedFile{name: "index.json", data: index},
+		savedFile{name: "oci-layout", data: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+	)
+
+	image, err := readSavedImage(archive, "registry.fly.io/app:deployment-1", dir)
+	require.NoError(t, err)
+	assert.Equal(t, config, image.config)
+	require.Len(t, image.layers, 2)
+
+	assert.Equal(t, blobDigest(compressed.Bytes()), image.layers[0].Digest)
+	assert.Equal(t, int64(compressed.Len()), image.layers[0].Size)
+	data, err := ioutil.ReadFile(image.layers[0].path)
+	require.NoError(t, err)
+	assert.Equal(t, compressed.Bytes(), data)
+
+	f, err := os.Open(image.layers[1].path)
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, uncompressed, decompressed)
+}
+
+func TestReadSavedImageLegacyLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	layer := bytes.Repeat([]byte("layer "), 1000)
+	archive := saveArchive(t,
+		savedFile{name: "abc/layer.tar", data: layer},
+		savedFile{name: "def/layer.tar", link: "../abc/layer.tar"},
+		savedFile{name: "123.json", data: []byte(`{"os":"linux"}`)},
+		savedFile{name: "manifest.json", data: []byte(`[{"Config":"123.json","Layers":["abc/layer.tar","def/layer.tar"]}]`)},
+	)
+
+	image, err := readSavedImage(archive, "registry.fly.io/app:deployment-1", dir)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"os":"linux"}`), image.config)
+	require.Len(t, image.layers, 2)
+	assert.Equal(t, image.layers[0].Digest, image.layers[1].Digest)
+
+	_, err = readSavedImage(saveArchive(t, savedFile{name: "manifest.json", data: []byte(`[{"Config":"123.json","Layers":["abc/layer.tar"]}]`)}), "app", dir)
+	assert.EqualError(t, err, "docker saved app without its config")
+}
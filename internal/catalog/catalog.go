@@ -0,0 +1,149 @@
+// Package catalog caches rarely changing platform information, such as the
+// regions and VM sizes, on disk so commands don't have to look it up from the
+// API each time they run.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// DefaultTTL - how long the catalog is used before it's fetched again
+const DefaultTTL = 24 * time.Hour
+
+// Catalog - the platform's regions and VM sizes as of FetchedAt
+type Catalog struct {
+	Regions []api.Region `json:"regions"`
+	// RequestRegion - the code of the region closest to where the catalog was fetched from
+	RequestRegion string       `json:"request_region,omitempty"`
+	VMSizes       []api.VMSize `json:"vm_sizes"`
+	FetchedAt     time.Time    `json:"fetched_at"`
+}
+
+// Fresh is true while the catalog is younger than ttl
+func (c *Catalog) Fresh(ttl time.Duration) bool {
+	return time.Since(c.FetchedAt) < ttl
+}
+
+// Region looks up a region by its code
+func (c *Catalog) Region(code string) *api.Region {
+	for i := range c.Regions {
+		if c.Regions[i].Code == code {
+			return &c.Regions[i]
+		}
+	}
+	return nil
+}
+
+// Path - where the catalog is cached
+func Path(configDir string) string {
+	return filepath.Join(configDir, "platform-catalog.json")
+}
+
+// Load reads the cached catalog, returning nil when there isn't one
+func Load(configDir string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(Path(configDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid platform catalog %s: %w", Path(configDir), err)
+	}
+
+	return &c, nil
+}
+
+// Save caches the catalog
+func Save(configDir string, c *Catalog) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(Path(configDir), data, 0600)
+}
+
+// Clear removes the cached catalog
+func Clear(configDir string) error {
+	err := os.Remove(Path(configDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Fetch looks up the catalog from the API
+func Fetch(client *api.Client) (*Catalog, error) {
+	regions, requestRegion, err := client.PlatformRegions()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := client.PlatformVMSizes()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{
+		Regions:   regions,
+		VMSizes:   sizes,
+		FetchedAt: time.Now(),
+	}
+	if requestRegion != nil {
+		c.RequestRegion = requestRegion.Code
+	}
+
+	return c, nil
+}
+
+// Refresh fetches the catalog from the API and caches it
+func Refresh(configDir string, client *api.Client) (*Catalog, error) {
+	c, err := Fetch(client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Save(configDir, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Get returns the cached catalog while it's younger than ttl, otherwise
+// refreshes it. If the API can't be reached, an out of date catalog is used
+// rather than failing.
+func Get(configDir string, client *api.Client, ttl time.Duration) (*Catalog, error) {
+	cached, err := Load(configDir)
+	if err != nil {
+		terminal.Debug("error loading platform catalog:", err)
+		cached = nil
+	}
+
+	if cached != nil && cached.Fresh(ttl) {
+		return cached, nil
+	}
+
+	c, err := Refresh(configDir, client)
+	if err != nil {
+		if cached != nil && !api.IsNotAuthenticatedError(err) {
+			terminal.Debugf("Using platform catalog from %s, refreshing failed: %s\n", cached.FetchedAt.Format(time.RFC3339), err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
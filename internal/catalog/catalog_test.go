@@ -0,0 +1,50 @@
+package catalog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	saved := &Catalog{
+		Regions:       []api.Region{{Code: "ord", Name: "Chicago"}, {Code: "ams", Name: "Amsterdam"}},
+		RequestRegion: "ams",
+		VMSizes:       []api.VMSize{{Name: "shared-cpu-1x", MemoryMB: 256}},
+		FetchedAt:     time.Now().Add(-time.Hour),
+	}
+	require.NoError(t, Save(dir, saved))
+
+	c, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Amsterdam", c.Region(c.RequestRegion).Name)
+	assert.Nil(t, c.Region("xyz"))
+	assert.Len(t, c.VMSizes, 1)
+
+	assert.True(t, c.Fresh(DefaultTTL))
+	assert.False(t, c.Fresh(time.Minute))
+
+	// a fresh catalog is used without the API
+	c, err = Get(dir, nil, DefaultTTL)
+	require.NoError(t, err)
+	assert.Len(t, c.Regions, 2)
+
+	require.NoError(t, Clear(dir))
+	require.NoError(t, Clear(dir))
+	c, err = Load(dir)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
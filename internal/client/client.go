@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 
 	"github.com/superfly/flyctl/api"
@@ -23,6 +24,10 @@ func NewClient() *Client {
 type Client struct {
 	IO *iostreams.IOStreams
 
+	// Reauthenticate - logs in again when the access token is rejected, set
+	// by commands that can ask to
+	Reauthenticate api.ReauthFunc
+
 	api *api.Client
 }
 
@@ -36,9 +41,20 @@ func (c *Client) Authenticated() bool {
 
 func (c *Client) InitApi() bool {
 	apiToken := flyctl.GetAPIToken()
+	c.api = nil
 	if apiToken != "" {
 		apiClient := api.NewClient(apiToken, flyctl.Version)
+		apiClient.SetReauth(c.reauthenticate)
 		c.api = apiClient
 	}
 	return c.Authenticated()
 }
+
+// reauthenticate logs in again when the access token is rejected, for
+// commands that can ask to
+func (c *Client) reauthenticate(ctx context.Context) (string, error) {
+	if c.Reauthenticate == nil {
+		return "", errors.New("the access token was rejected")
+	}
+	return c.Reauthenticate(ctx)
+}
@@ -0,0 +1,38 @@
+package cmdfmt
+
+import (
+	"math"
+	"strings"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a single line bar chart scaled between zero and the largest value.
+// NaN values are rendered as blanks.
+func Sparkline(values []float64) string {
+	max := 0.0
+	for _, v := range values {
+		if !math.IsNaN(v) && v > max {
+			max = v
+		}
+	}
+
+	var b strings.Builder
+
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			b.WriteRune(' ')
+		case max == 0:
+			b.WriteRune(sparkTicks[0])
+		default:
+			idx := int(v / max * float64(len(sparkTicks)-1))
+			if idx < 0 {
+				idx = 0
+			}
+			b.WriteRune(sparkTicks[idx])
+		}
+	}
+
+	return b.String()
+}
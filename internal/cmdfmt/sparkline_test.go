@@ -0,0 +1,32 @@
+package cmdfmt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   string
+	}{
+		{name: "empty", values: nil, want: ""},
+		{name: "zeros", values: []float64{0, 0, 0}, want: "▁▁▁"},
+		{name: "constant", values: []float64{5, 5, 5}, want: "███"},
+		{name: "scaled to the largest value", values: []float64{0, 3.5, 7}, want: "▁▄█"},
+		{name: "negative values at the bottom", values: []float64{-2, 7}, want: "▁█"},
+		{name: "NaN as gaps", values: []float64{nan, 7, nan}, want: " █ "},
+		{name: "only NaN", values: []float64{nan, nan}, want: "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sparkline(tt.values))
+		})
+	}
+}
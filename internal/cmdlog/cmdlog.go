@@ -0,0 +1,124 @@
+// Package cmdlog keeps a log of recently run commands and how they ended, so
+// failures can be looked into after the fact and included in support bundles.
+package cmdlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxEntries - how many entries are kept in the log
+const MaxEntries = 200
+
+// Entry - a command that was run
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Version  string        `json:"version"`
+	Command  string        `json:"command"`
+	Args     []string      `json:"args"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	ExitCode int           `json:"exit_code"`
+}
+
+// Path - where the log is kept
+func Path(configDir string) string {
+	return filepath.Join(configDir, "logs", "commands.log")
+}
+
+// Append adds an entry to the log, dropping the oldest entries once there
+// are more than MaxEntries
+func Append(configDir string, entry Entry) error {
+	entry.Args = RedactArgs(entry.Args)
+
+	entries, err := Recent(configDir, MaxEntries-1)
+	if err != nil {
+		// a corrupt log shouldn't stop commands being logged
+		entries = nil
+	}
+	entries = append(entries, entry)
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := Path(configDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// Recent returns up to the last n entries, oldest first
+func Recent(configDir string, n int) ([]Entry, error) {
+	f, err := os.Open(Path(configDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+
+	return entries, nil
+}
+
+var sensitiveFlag = regexp.MustCompile(`(?i)^--?(access-token|token|password|secret|key)`)
+
+// RedactArgs hides values that may be secrets: the values of NAME=VALUE
+// arguments, such as those given to secrets set, and of credential flags
+func RedactArgs(args []string) []string {
+	redacted := make([]string, 0, len(args))
+	redactNext := false
+
+	for _, arg := range args {
+		switch {
+		case redactNext:
+			arg = "[REDACTED]"
+			redactNext = false
+		case sensitiveFlag.MatchString(arg):
+			if i := strings.Index(arg, "="); i >= 0 {
+				arg = arg[:i+1] + "[REDACTED]"
+			} else {
+				redactNext = true
+			}
+		case !strings.HasPrefix(arg, "-") && strings.Contains(arg, "="):
+			arg = arg[:strings.Index(arg, "=")+1] + "[REDACTED]"
+		}
+		redacted = append(redacted, arg)
+	}
+
+	return redacted
+}
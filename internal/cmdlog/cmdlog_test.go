@@ -0,0 +1,46 @@
+package cmdlog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAndRecent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdlog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	entries, err := Recent(dir, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	for i := 0; i < MaxEntries+5; i++ {
+		require.NoError(t, Append(dir, Entry{
+			Time:    time.Now(),
+			Command: fmt.Sprintf("cmd-%d", i),
+			Args:    []string{"secrets", "set", "DATABASE_URL=postgres://u:p@h/db"},
+		}))
+	}
+
+	entries, err = Recent(dir, MaxEntries+100)
+	require.NoError(t, err)
+	assert.Len(t, entries, MaxEntries)
+	assert.Equal(t, "cmd-5", entries[0].Command)
+
+	entries, err = Recent(dir, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{fmt.Sprintf("cmd-%d", MaxEntries+3), fmt.Sprintf("cmd-%d", MaxEntries+4)}, []string{entries[0].Command, entries[1].Command})
+	assert.Equal(t, []string{"secrets", "set", "DATABASE_URL=[REDACTED]"}, entries[1].Args)
+}
+
+func TestRedactArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"deploy", "--app", "web", "--access-token", "[REDACTED]", "--token=[REDACTED]", "--build-arg", "VERSION=[REDACTED]", "--env=a"},
+		RedactArgs([]string{"deploy", "--app", "web", "--access-token", "abc", "--token=def", "--build-arg", "VERSION=1", "--env=a"}))
+}
@@ -0,0 +1,339 @@
+// Package compose reads the parts of Docker Compose files that can be moved to
+// Fly apps, so flyctl import compose can translate their services.
+package compose
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// File - a Docker Compose file
+type File struct {
+	Services map[string]*Service    `yaml:"services"`
+	Volumes  map[string]interface{} `yaml:"volumes"`
+}
+
+// Service - a service of a compose file. Keys flyctl doesn't translate are
+// kept in Other, so they can be reported.
+type Service struct {
+	Image       string                 `yaml:"image"`
+	Build       *Build                 `yaml:"build"`
+	Command     Command                `yaml:"command"`
+	Entrypoint  Command                `yaml:"entrypoint"`
+	Ports       []Port                 `yaml:"ports"`
+	Environment Environment            `yaml:"environment"`
+	EnvFile     StringList             `yaml:"env_file"`
+	Volumes     []Volume               `yaml:"volumes"`
+	DependsOn   DependsOn              `yaml:"depends_on"`
+	Other       map[string]interface{} `yaml:",inline"`
+}
+
+// Build - how a service's image is built
+type Build struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	Target     string            `yaml:"target"`
+	Args       map[string]string `yaml:"args"`
+}
+
+// UnmarshalYAML accepts the build context alone, or the long syntax
+func (b *Build) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var context string
+	if err := unmarshal(&context); err == nil {
+		b.Context = context
+		return nil
+	}
+
+	var long struct {
+		Context    string      `yaml:"context"`
+		Dockerfile string      `yaml:"dockerfile"`
+		Target     string      `yaml:"target"`
+		Args       Environment `yaml:"args"`
+	}
+	if err := unmarshal(&long); err != nil {
+		return err
+	}
+	b.Context = long.Context
+	b.Dockerfile = long.Dockerfile
+	b.Target = long.Target
+	b.Args = map[string]string{}
+	for name, value := range long.Args {
+		if value != nil {
+			b.Args[name] = *value
+		}
+	}
+	return nil
+}
+
+// Command - a command given as a string, or as a list of arguments
+type Command []string
+
+// UnmarshalYAML accepts either form of command
+func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var command string
+	if err := unmarshal(&command); err == nil {
+		*c = Command{command}
+		return nil
+	}
+
+	var args []string
+	if err := unmarshal(&args); err != nil {
+		return err
+	}
+	*c = args
+	return nil
+}
+
+// String - the command as one line, quoting arguments with spaces
+func (c Command) String() string {
+	if len(c) == 1 {
+		return c[0]
+	}
+	args := make([]string, 0, len(c))
+	for _, arg := range c {
+		if strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// StringList - a single string or a list of them
+type StringList []string
+
+// UnmarshalYAML accepts either form
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*l = StringList{value}
+		return nil
+	}
+
+	var values []string
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+// Environment - a service's environment. Variables without a value, which
+// compose takes from the shell it runs in, are nil.
+type Environment map[string]*string
+
+// UnmarshalYAML accepts a map, or a list of NAME=value entries
+func (e *Environment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	env := Environment{}
+
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, entry := range list {
+			if i := strings.Index(entry, "="); i >= 0 {
+				value := entry[i+1:]
+				env[entry[:i]] = &value
+			} else {
+				env[entry] = nil
+			}
+		}
+		*e = env
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	for name, value := range values {
+		if value == nil {
+			env[name] = nil
+			continue
+		}
+		s := fmt.Sprint(value)
+		env[name] = &s
+	}
+	*e = env
+	return nil
+}
+
+// DependsOn - the services a service depends on
+type DependsOn []string
+
+// UnmarshalYAML accepts a list of services, or a map of them to conditions
+func (d *DependsOn) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*d = list
+		return nil
+	}
+
+	var conditions map[string]interface{}
+	if err := unmarshal(&conditions); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(conditions))
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	*d = names
+	return nil
+}
+
+// Port - a port of a service. Published is 0 when the port isn't published
+// on the host, and Range is set for ranges, which aren't translated.
+type Port struct {
+	Target    int
+	Published int
+	Protocol  string
+	Range     string
+}
+
+// UnmarshalYAML accepts the short syntax, e.g. 127.0.0.1:8080:80/udp, or the
+// long syntax
+func (p *Port) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		return p.parseShort(short)
+	}
+
+	var long struct {
+		Target    int    `yaml:"target"`
+		Published string `yaml:"published"`
+		Protocol  string `yaml:"protocol"`
+	}
+	if err := unmarshal(&long); err != nil {
+		return err
+	}
+	p.Target = long.Target
+	p.Protocol = protocolOrTCP(long.Protocol)
+	if long.Published != "" {
+		published, err := strconv.Atoi(long.Published)
+		if err != nil {
+			p.Range = long.Published
+			return nil
+		}
+		p.Published = published
+	}
+	return nil
+}
+
+func (p *Port) parseShort(short string) error {
+	spec := short
+	p.Protocol = "tcp"
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		p.Protocol = protocolOrTCP(spec[i+1:])
+		spec = spec[:i]
+	}
+
+	if strings.Contains(spec, "-") {
+		p.Range = short
+		return nil
+	}
+	parts := strings.Split(spec, ":")
+
+	target, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return fmt.Errorf("invalid port %s", short)
+	}
+	p.Target = target
+
+	if len(parts) > 1 && parts[len(parts)-2] != "" {
+		published, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			return fmt.Errorf("invalid port %s", short)
+		}
+		p.Published = published
+	}
+	return nil
+}
+
+func protocolOrTCP(protocol string) string {
+	if protocol == "" {
+		return "tcp"
+	}
+	return strings.ToLower(protocol)
+}
+
+// Volume - a volume or bind mount of a service
+type Volume struct {
+	Type     string
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// UnmarshalYAML accepts the short syntax, e.g. data:/var/lib/data:ro, or the
+// long syntax
+func (v *Volume) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err == nil {
+		parts := strings.Split(short, ":")
+		switch len(parts) {
+		case 1:
+			// an anonymous volume
+			v.Type = "volume"
+			v.Target = parts[0]
+		default:
+			v.Source = parts[0]
+			v.Target = parts[1]
+			v.ReadOnly = len(parts) > 2 && strings.Contains(parts[2], "ro")
+			v.Type = "volume"
+			if strings.HasPrefix(v.Source, ".") || strings.HasPrefix(v.Source, "/") || strings.HasPrefix(v.Source, "~") {
+				v.Type = "bind"
+			}
+		}
+		return nil
+	}
+
+	var long struct {
+		Type     string `yaml:"type"`
+		Source   string `yaml:"source"`
+		Target   string `yaml:"target"`
+		ReadOnly bool   `yaml:"read_only"`
+	}
+	if err := unmarshal(&long); err != nil {
+		return err
+	}
+	*v = Volume(long)
+	return nil
+}
+
+// Parse reads a compose file
+func Parse(r io.Reader) (*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid compose file: %w", err)
+	}
+	if len(file.Services) == 0 {
+		return nil, fmt.Errorf("compose file has no services")
+	}
+	for name, service := range file.Services {
+		if service == nil {
+			file.Services[name] = &Service{}
+		}
+	}
+
+	return &file, nil
+}
+
+// ServiceNames - the names of the file's services, sorted
+func (f *File) ServiceNames() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,340 @@
+package compose
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/secretlint"
+)
+
+// Options - how a compose file's services are translated to apps
+type Options struct {
+	// AppPrefix - prefixed to each service's name to name its app
+	AppPrefix string
+	// ComposeDir - the directory of the compose file, which build contexts
+	// are relative to
+	ComposeDir string
+	// OutDir - the directory the fly.toml files are written to, which their
+	// paths are made relative to
+	OutDir string
+}
+
+// App - a Fly app translated from a compose service
+type App struct {
+	Service string
+	Name    string
+	Config  *flyctl.AppConfig
+	// Secrets - variables of the service to set as secrets rather than in [env]
+	Secrets []string
+	// Volume - the volume to create for the app's [mounts], if it has one
+	Volume string
+	// Notes - what wasn't translated, or needs doing by hand
+	Notes []string
+}
+
+func (a *App) notef(format string, args ...interface{}) {
+	a.Notes = append(a.Notes, fmt.Sprintf(format, args...))
+}
+
+// untranslated - advice for compose keys that have no fly.toml equivalent
+var untranslated = map[string]string{
+	"restart":        "Fly restarts instances that exit",
+	"healthcheck":    "add checks to the [[services]] section",
+	"networks":       "apps in an organization share a private network",
+	"deploy":         "scale with flyctl scale",
+	"container_name": "instances are named by Fly",
+	"hostname":       "the app is reachable at its name .internal",
+	"links":          "apps in an organization share a private network",
+	"expose":         "every port is reachable on the private network",
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// AppName - the app name for a service, a valid hostname
+func AppName(prefix, service string) string {
+	name := strings.ToLower(service)
+	if prefix != "" {
+		name = strings.ToLower(prefix) + "-" + name
+	}
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+var invalidVolumeChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// volumeName - a compose volume's name as a Fly volume name, which can only
+// have letters, numbers and underscores, and is at most 30 characters
+func volumeName(name string) string {
+	name = invalidVolumeChars.ReplaceAllString(strings.ToLower(name), "_")
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	return name
+}
+
+// Translate converts each service of file to an app, sorted by service name
+func Translate(file *File, opts Options) ([]*App, error) {
+	names := file.ServiceNames()
+
+	appNames := map[string]string{}
+	for _, name := range names {
+		appNames[name] = AppName(opts.AppPrefix, name)
+	}
+
+	apps := make([]*App, 0, len(names))
+	for _, name := range names {
+		app, err := translateService(name, file.Services[name], appNames, opts)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+func translateService(name string, service *Service, appNames map[string]string, opts Options) (*App, error) {
+	app := &App{
+		Service: name,
+		Name:    appNames[name],
+		Config:  flyctl.NewAppConfig(),
+	}
+	app.Config.AppName = app.Name
+
+	if err := translateBuild(app, service, opts); err != nil {
+		return nil, err
+	}
+
+	if len(service.Command) > 0 {
+		app.Config.Processes = map[string]*flyctl.ProcessGroup{"app": {Command: service.Command.String()}}
+	}
+	if len(service.Entrypoint) > 0 {
+		app.notef("entrypoint isn't translated, set ENTRYPOINT in the Dockerfile instead")
+	}
+
+	translatePorts(app, service.Ports)
+	translateEnvironment(app, service.Environment, appNames)
+	translateVolumes(app, service.Volumes)
+
+	for _, file := range service.EnvFile {
+		app.notef("env_file %s isn't read, set its variables with flyctl secrets import -a %s < %s", file, app.Name, file)
+	}
+	for _, dependency := range service.DependsOn {
+		if appName, ok := appNames[dependency]; ok {
+			app.notef("depends on %s, apps start independently so reach it at %s.internal and retry until it's up", dependency, appName)
+		}
+	}
+
+	other := make([]string, 0, len(service.Other))
+	for key := range service.Other {
+		other = append(other, key)
+	}
+	sort.Strings(other)
+	for _, key := range other {
+		if advice, ok := untranslated[key]; ok {
+			app.notef("%s isn't translated, %s", key, advice)
+		} else {
+			app.notef("%s isn't translated", key)
+		}
+	}
+
+	return app, nil
+}
+
+func translateBuild(app *App, service *Service, opts Options) error {
+	if service.Build == nil {
+		if service.Image == "" {
+			app.notef("has neither build nor image, set one in the [build] section")
+			return nil
+		}
+		app.Config.Build = &flyctl.Build{Image: service.Image}
+		return nil
+	}
+
+	if service.Image != "" {
+		app.notef("image %s names the image compose builds, it's built and pushed to Fly's registry instead", service.Image)
+	}
+
+	build := &flyctl.Build{Target: service.Build.Target, Args: service.Build.Args}
+
+	context := service.Build.Context
+	if context == "" {
+		context = "."
+	}
+	if !filepath.IsAbs(context) {
+		context = filepath.Join(opts.ComposeDir, context)
+	}
+	relContext, err := filepath.Rel(opts.OutDir, context)
+	if err != nil {
+		return err
+	}
+	if relContext != "." {
+		build.Context = filepath.ToSlash(relContext)
+	}
+
+	dockerfile := service.Build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(context, dockerfile)
+	}
+	relDockerfile, err := filepath.Rel(opts.OutDir, dockerfile)
+	if err != nil {
+		return err
+	}
+	if relDockerfile != "Dockerfile" {
+		build.Dockerfile = filepath.ToSlash(relDockerfile)
+	}
+
+	app.Config.Build = build
+	return nil
+}
+
+// translatePorts makes a service of each published container port. Port 80
+// gets the http handler, and HTTPS on 443 unless the service publishes 443.
+func translatePorts(app *App, ports []Port) {
+	publishes443 := false
+	for _, port := range ports {
+		if port.Published == 443 && port.Protocol == "tcp" {
+			publishes443 = true
+		}
+	}
+
+	var services []map[string]interface{}
+	byTarget := map[string]map[string]interface{}{}
+
+	for _, port := range ports {
+		switch {
+		case port.Range != "":
+			app.notef("port range %s isn't translated, list each port in [[services]]", port.Range)
+			continue
+		case port.Published == 0:
+			app.notef("port %d isn't published, so it's only reachable at %s.internal:%d", port.Target, app.Name, port.Target)
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%d", port.Protocol, port.Target)
+		service, ok := byTarget[key]
+		if !ok {
+			service = map[string]interface{}{
+				"internal_port": port.Target,
+				"protocol":      port.Protocol,
+				"ports":         []map[string]interface{}{},
+			}
+			byTarget[key] = service
+			services = append(services, service)
+		}
+
+		edge := []map[string]interface{}{{"port": port.Published}}
+		switch {
+		case port.Protocol == "udp":
+			app.notef("UDP port %d needs a dedicated IPv4 address, allocate one with flyctl ips allocate-v4 -a %s", port.Published, app.Name)
+		case port.Published == 80:
+			edge[0]["handlers"] = []string{"http"}
+			if !publishes443 {
+				edge = append(edge, map[string]interface{}{"port": 443, "handlers": []string{"tls", "http"}})
+				app.notef("port 80 is also served over HTTPS on 443, with TLS terminated by Fly")
+			}
+		case port.Published == 443:
+			app.notef("port 443 is passed through as TCP, so the app terminates TLS itself")
+		}
+
+		service["ports"] = append(service["ports"].([]map[string]interface{}), edge...)
+	}
+
+	if len(services) > 0 {
+		app.Config.Definition["services"] = services
+	}
+}
+
+// translateEnvironment puts variables in [env], apart from those that look
+// like secrets or have no value, which are to be set as secrets
+func translateEnvironment(app *App, environment Environment, appNames map[string]string) {
+	values := map[string]string{}
+	for name, value := range environment {
+		if value == nil {
+			app.Secrets = append(app.Secrets, name)
+			continue
+		}
+		values[name] = *value
+	}
+
+	for _, finding := range secretlint.Check(app.Service+" environment", values) {
+		app.Secrets = append(app.Secrets, finding.Name)
+		delete(values, finding.Name)
+	}
+	sort.Strings(app.Secrets)
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	services := make([]string, 0, len(appNames))
+	for service := range appNames {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, name := range names {
+		value := values[name]
+		if strings.Contains(value, "${") {
+			app.notef("%s is interpolated by compose from %q, set its value in [env]", name, value)
+		}
+		for _, service := range services {
+			if service != app.Service && refersToHost(value, service) {
+				app.notef("%s refers to service %s, which is reachable at %s.internal on Fly", name, service, appNames[service])
+			}
+		}
+	}
+
+	if len(values) > 0 {
+		app.Config.SetEnvVariables(values)
+	}
+}
+
+// refersToHost - whether value uses host as a hostname, e.g. in a URL
+func refersToHost(value, host string) bool {
+	if value == host {
+		return true
+	}
+	for _, prefix := range []string{"//", "@"} {
+		for _, suffix := range []string{":", "/"} {
+			if strings.Contains(value, prefix+host+suffix) || strings.HasSuffix(value, prefix+host) {
+				return true
+			}
+		}
+	}
+	return strings.HasPrefix(value, host+":")
+}
+
+// translateVolumes mounts the service's first named volume. Apps can only
+// mount one volume, and bind mounts have no equivalent.
+func translateVolumes(app *App, volumes []Volume) {
+	for _, volume := range volumes {
+		switch {
+		case volume.Type == "bind":
+			app.notef("bind mount %s isn't translated, copy the files into the image or onto a volume", volume.Source)
+		case volume.Type != "volume":
+			app.notef("%s mount at %s isn't translated", volume.Type, volume.Target)
+		case app.Volume != "":
+			app.notef("volume at %s isn't translated, apps can only mount one volume", volume.Target)
+		default:
+			source := volume.Source
+			if source == "" {
+				source = app.Service + "_data"
+			}
+			app.Volume = volumeName(source)
+			app.Config.Definition["mounts"] = map[string]interface{}{
+				"source":      app.Volume,
+				"destination": volume.Target,
+			}
+			app.notef("create the volume mounted at %s with flyctl volumes create %s -a %s --region <region>", volume.Target, app.Volume, app.Name)
+		}
+	}
+}
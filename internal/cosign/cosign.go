@@ -0,0 +1,135 @@
+// Package cosign verifies image signatures made with cosign
+// (https://github.com/sigstore/cosign) and a key pair, which cosign stores in
+// the image's registry next to the image.
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+// SignatureAnnotation - the annotation on each layer of a signature manifest
+// holding the signature of the layer's payload
+const SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// payloadType - the type of payload cosign signs for images
+const payloadType = "cosign container image signature"
+
+// maxPayloadSize - payloads are small JSON documents, anything larger isn't one
+const maxPayloadSize = 1 << 20
+
+// ErrNoSignatures is returned when an image hasn't been signed
+var ErrNoSignatures = errors.New("the image has no cosign signatures")
+
+// Payload - what cosign signs: the digest of the image it vouches for
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// LoadPublicKey reads a PEM encoded ECDSA public key, as written to cosign.pub
+// by cosign generate-key-pair
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded public key", path)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, only ECDSA keys made by cosign are supported", path, key)
+	}
+
+	return ecdsaKey, nil
+}
+
+// SignatureTag - the tag cosign stores the signatures of the image with digest under
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// VerifyPayload checks signature, base64 encoded, is key's signature of
+// payload, and that the payload vouches for the image with digest
+func VerifyPayload(key *ecdsa.PublicKey, payload []byte, signature string, digest string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(key, sum[:], sig) {
+		return errors.New("the signature wasn't made with the public key")
+	}
+
+	var p Payload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid signature payload: %w", err)
+	}
+	if p.Critical.Type != payloadType {
+		return fmt.Errorf("signature payload has unexpected type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("the signature is for %s, not %s", p.Critical.Image.DockerManifestDigest, digest)
+	}
+
+	return nil
+}
+
+// Verify checks one of the signatures stored for the image with digest, in
+// repository on the registry host, was made with key
+func Verify(ctx context.Context, client *registry.Client, host, repository, digest string, key *ecdsa.PublicKey) error {
+	manifest, err := client.Manifest(ctx, host, repository, SignatureTag(digest))
+	if errors.Is(err, registry.ErrNotFound) {
+		return ErrNoSignatures
+	}
+	if err != nil {
+		return err
+	}
+
+	var lastErr error = ErrNoSignatures
+	for _, layer := range manifest.Layers {
+		signature, ok := layer.Annotations[SignatureAnnotation]
+		if !ok {
+			continue
+		}
+
+		payload, err := client.Blob(ctx, host, repository, layer.Digest, maxPayloadSize)
+		if err != nil {
+			return err
+		}
+
+		if lastErr = VerifyPayload(key, payload, signature, digest); lastErr == nil {
+			return nil
+		}
+	}
+
+	return lastErr
+}
@@ -0,0 +1,114 @@
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+const testDigest = "sha256:bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb"
+
+func testPayload(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.example/app"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, payload []byte) string {
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cosign")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cosign.pub")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+
+	loaded, err := LoadPublicKey(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.Equal(&key.PublicKey))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("not a key"), 0644))
+	_, err = LoadPublicKey(path)
+	assert.Error(t, err)
+}
+
+func TestVerifyPayload(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	payload := testPayload(testDigest)
+
+	assert.NoError(t, VerifyPayload(&key.PublicKey, payload, sign(t, key, payload), testDigest))
+	assert.Error(t, VerifyPayload(&other.PublicKey, payload, sign(t, key, payload), testDigest))
+	assert.Error(t, VerifyPayload(&key.PublicKey, payload, sign(t, key, payload), "sha256:0000"))
+
+	tampered := testPayload("sha256:0000")
+	assert.Error(t, VerifyPayload(&key.PublicKey, tampered, sign(t, key, payload), "sha256:0000"))
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	payload := testPayload(testDigest)
+	payloadDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/app/manifests/" + SignatureTag(testDigest):
+			json.NewEncoder(w).Encode(registry.Manifest{
+				Layers: []registry.Descriptor{{
+					Digest:      payloadDigest,
+					Annotations: map[string]string{SignatureAnnotation: sign(t, key, payload)},
+				}},
+			})
+		case "/v2/app/blobs/" + payloadDigest:
+			w.Write(payload)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := registry.New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	assert.NoError(t, Verify(context.Background(), client, host, "app", testDigest, &key.PublicKey))
+	assert.Equal(t, ErrNoSignatures, Verify(context.Background(), client, host, "other", testDigest, &key.PublicKey))
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	assert.Error(t, Verify(context.Background(), client, host, "app", testDigest, &other.PublicKey))
+}
@@ -0,0 +1,181 @@
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/superfly/flyctl/internal/registry"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// payloadMediaType - the media type of signature layers
+const payloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+// encryptedKey - how cosign generate-key-pair encrypts private keys with a password
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// KeyEncrypted is true when the private key at path needs a password to be loaded
+func KeyEncrypted(path string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	block, _ := pem.Decode(data)
+	return block != nil && isEncrypted(block), nil
+}
+
+func isEncrypted(block *pem.Block) bool {
+	return block.Type == "ENCRYPTED COSIGN PRIVATE KEY" || block.Type == "ENCRYPTED SIGSTORE PRIVATE KEY"
+}
+
+// LoadPrivateKey reads an ECDSA private key, either cosign.key as written by
+// cosign generate-key-pair and decrypted with password, or an unencrypted
+// PEM encoded key
+func LoadPrivateKey(path string, password []byte) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded private key", path)
+	}
+
+	der := block.Bytes
+	if isEncrypted(block) {
+		if der, err = decryptKey(block.Bytes, password); err != nil {
+			return nil, fmt.Errorf("couldn't decrypt %s: %w", path, err)
+		}
+	}
+
+	if block.Type == "EC PRIVATE KEY" {
+		return x509.ParseECPrivateKey(der)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s: %w", path, err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, only ECDSA keys are supported", path, key)
+	}
+
+	return ecdsaKey, nil
+}
+
+func decryptKey(data []byte, password []byte) ([]byte, error) {
+	var enc encryptedKey
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, err
+	}
+	if enc.KDF.Name != "scrypt" || enc.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported encryption %s with %s", enc.Cipher.Name, enc.KDF.Name)
+	}
+	if len(enc.Cipher.Nonce) != 24 {
+		return nil, errors.New("invalid nonce")
+	}
+
+	secret, err := scrypt.Key(password, enc.KDF.Salt, enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	var nonce [24]byte
+	copy(key[:], secret)
+	copy(nonce[:], enc.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, enc.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("incorrect password")
+	}
+	return der, nil
+}
+
+// NewPayload returns the payload vouching for the image with digest,
+// named dockerReference
+func NewPayload(dockerReference, digest string) ([]byte, error) {
+	var p Payload
+	p.Critical.Identity.DockerReference = dockerReference
+	p.Critical.Image.DockerManifestDigest = digest
+	p.Critical.Type = payloadType
+	return json.Marshal(p)
+}
+
+// SignPayload returns key's signature of payload, base64 encoded
+func SignPayload(key *ecdsa.PrivateKey, payload []byte) (string, error) {
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Sign signs the image with digest, in repository on the registry host, and
+// stores the signature where cosign looks for it, alongside any others
+func Sign(ctx context.Context, client *registry.Client, host, repository, digest string, key *ecdsa.PrivateKey) error {
+	payload, err := NewPayload(host+"/"+repository, digest)
+	if err != nil {
+		return err
+	}
+
+	signature, err := SignPayload(key, payload)
+	if err != nil {
+		return err
+	}
+
+	layer, err := client.PutBlob(ctx, host, repository, payloadMediaType, payload)
+	if err != nil {
+		return err
+	}
+	layer.Annotations = map[string]string{SignatureAnnotation: signature}
+
+	config, err := client.PutBlob(ctx, host, repository, registry.MediaTypeConfig, []byte("{}"))
+	if err != nil {
+		return err
+	}
+
+	manifest, err := client.Manifest(ctx, host, repository, SignatureTag(digest))
+	switch {
+	case errors.Is(err, registry.ErrNotFound):
+		manifest = &registry.Manifest{}
+	case err != nil:
+		return err
+	}
+
+	manifest.SchemaVersion = 2
+	manifest.MediaType = registry.MediaTypeManifest
+	manifest.Config = &config
+	manifest.Layers = append(manifest.Layers, layer)
+
+	return client.PutManifest(ctx, host, repository, SignatureTag(digest), manifest)
+}
@@ -0,0 +1,158 @@
+package cosign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/registry"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// memoryRegistry - just enough of the distribution API to push and pull signatures
+type memoryRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (m *memoryRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/app/")
+	switch {
+	case r.Method == http.MethodPost && path == "blobs/uploads/":
+		w.Header().Set("Location", "/v2/app/blobs/uploads/1?state=x")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && path == "blobs/uploads/1":
+		data, _ := ioutil.ReadAll(r.Body)
+		if fmt.Sprintf("sha256:%x", sha256.Sum256(data)) != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.blobs[r.URL.Query().Get("digest")] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "blobs/"):
+		data, ok := m.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+		m.manifests[strings.TrimPrefix(path, "manifests/")], _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "manifests/"):
+		data, ok := m.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSignThenVerify(t *testing.T) {
+	reg := &memoryRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewTLSServer(reg)
+	defer server.Close()
+
+	client := registry.New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	require.NoError(t, Sign(context.Background(), client, host, "app", testDigest, key))
+	assert.NoError(t, Verify(context.Background(), client, host, "app", testDigest, &key.PublicKey))
+	assert.Error(t, Verify(context.Background(), client, host, "app", testDigest, &other.PublicKey))
+
+	// signing again keeps the earlier signature
+	require.NoError(t, Sign(context.Background(), client, host, "app", testDigest, other))
+	assert.NoError(t, Verify(context.Background(), client, host, "app", testDigest, &key.PublicKey))
+	assert.NoError(t, Verify(context.Background(), client, host, "app", testDigest, &other.PublicKey))
+
+	var manifest registry.Manifest
+	require.NoError(t, json.Unmarshal(reg.manifests[SignatureTag(testDigest)], &manifest))
+	assert.Equal(t, 2, manifest.SchemaVersion)
+	assert.Len(t, manifest.Layers, 2)
+	assert.NotNil(t, manifest.Config)
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cosign")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "plain.pem")
+	require.NoError(t, ioutil.WriteFile(plain, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	encrypted, err := KeyEncrypted(plain)
+	require.NoError(t, err)
+	assert.False(t, encrypted)
+
+	loaded, err := LoadPrivateKey(plain, nil)
+	require.NoError(t, err)
+	assert.True(t, loaded.Equal(key))
+
+	// encrypted the way cosign generate-key-pair does
+	var enc encryptedKey
+	enc.KDF.Name = "scrypt"
+	enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P = 1024, 8, 1
+	enc.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	enc.Cipher.Name = "nacl/secretbox"
+	enc.Cipher.Nonce = []byte("0123456789abcdef01234567")
+
+	secret, err := scrypt.Key([]byte("hunter2"), enc.KDF.Salt, 1024, 8, 1, 32)
+	require.NoError(t, err)
+	var box [32]byte
+	var nonce [24]byte
+	copy(box[:], secret)
+	copy(nonce[:], enc.Cipher.Nonce)
+	enc.Ciphertext = secretbox.Seal(nil, der, &nonce, &box)
+
+	data, err := json.Marshal(enc)
+	require.NoError(t, err)
+	cosignKey := filepath.Join(dir, "cosign.key")
+	require.NoError(t, ioutil.WriteFile(cosignKey, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: data}), 0600))
+
+	encrypted, err = KeyEncrypted(cosignKey)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+
+	loaded, err = LoadPrivateKey(cosignKey, []byte("hunter2"))
+	require.NoError(t, err)
+	assert.True(t, loaded.Equal(key))
+
+	_, err = LoadPrivateKey(cosignKey, []byte("wrong"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2021, 6, 15, 10, 30, 20, 0, time.UTC) // a tuesday
+
+	cases := map[string]time.Time{
+		"* * * * *":      time.Date(2021, 6, 15, 10, 31, 0, 0, time.UTC),
+		"0 3 * * *":      time.Date(2021, 6, 16, 3, 0, 0, 0, time.UTC),
+		"*/15 * * * *":   time.Date(2021, 6, 15, 10, 45, 0, 0, time.UTC),
+		"0 9-17 * * 1-5": time.Date(2021, 6, 15, 11, 0, 0, 0, time.UTC),
+		"0 0 * * 0":      time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":      time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * *":      time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1,15 * 3":   time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":     time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"@daily":         time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC),
+		"@hourly":        time.Date(2021, 6, 15, 11, 0, 0, 0, time.UTC),
+	}
+
+	for expr, want := range cases {
+		s, err := Parse(expr)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, want, s.Next(from), expr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package deployment
+
+import (
+	"strconv"
+
+	"github.com/superfly/flyctl/internal/gitinfo"
+)
+
+// Release annotation keys, recorded on each release for auditing changes
+const (
+	AnnotationGitSHA        = "git.sha"
+	AnnotationGitBranch     = "git.branch"
+	AnnotationGitDirty      = "git.dirty"
+	AnnotationDeployer      = "deployer.user"
+	AnnotationTokenSource   = "deployer.token"
+	AnnotationCI            = "deployer.ci"
+	AnnotationFlyctlVersion = "flyctl.version"
+)
+
+// Deployer - who ran a deploy, and with which credentials
+type Deployer struct {
+	// User - the email of the token's user, "" when it couldn't be found
+	User string
+	// TokenSource - where the API token was read from
+	TokenSource string
+	// CI - the CI provider running the deploy, "" outside of CI
+	CI string
+}
+
+// ReleaseAnnotations returns the annotations to record on a release deployed
+// from the work tree described by git, which is nil outside of a repository
+func ReleaseAnnotations(git *gitinfo.Info, deployer Deployer, version string) map[string]string {
+	annotations := map[string]string{}
+
+	set := func(key, value string) {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
+	if git != nil {
+		set(AnnotationGitSHA, git.SHA)
+		set(AnnotationGitBranch, git.Branch)
+		set(AnnotationGitDirty, strconv.FormatBool(git.Dirty))
+	}
+	set(AnnotationDeployer, deployer.User)
+	set(AnnotationTokenSource, deployer.TokenSource)
+	set(AnnotationCI, deployer.CI)
+	set(AnnotationFlyctlVersion, version)
+
+	return annotations
+}
+
+// ciProviders - environment variables set by CI providers, checked in order
+var ciProviders = []struct {
+	env  string
+	name string
+}{
+	{"GITHUB_ACTIONS", "github-actions"},
+	{"GITLAB_CI", "gitlab"},
+	{"CIRCLECI", "circleci"},
+	{"BUILDKITE", "buildkite"},
+	{"TRAVIS", "travis"},
+	{"JENKINS_URL", "jenkins"},
+	{"CI", "ci"},
+}
+
+// DetectCI returns the name of the CI provider running flyctl, or "",
+// reading the environment with getenv
+func DetectCI(getenv func(string) string) string {
+	for _, provider := range ciProviders {
+		if getenv(provider.env) != "" {
+			return provider.name
+		}
+	}
+	return ""
+}
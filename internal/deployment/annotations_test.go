@@ -0,0 +1,38 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/internal/gitinfo"
+)
+
+func TestReleaseAnnotations(t *testing.T) {
+	git := &gitinfo.Info{SHA: "4f2a9c1e", Branch: "main", Dirty: true}
+	deployer := Deployer{User: "me@example.com", TokenSource: "FLY_API_TOKEN", CI: "github-actions"}
+
+	assert.Equal(t, map[string]string{
+		"git.sha":        "4f2a9c1e",
+		"git.branch":     "main",
+		"git.dirty":      "true",
+		"deployer.user":  "me@example.com",
+		"deployer.token": "FLY_API_TOKEN",
+		"deployer.ci":    "github-actions",
+		"flyctl.version": "0.0.200",
+	}, ReleaseAnnotations(git, deployer, "0.0.200"))
+
+	// outside of a repository and with nothing known about the deployer
+	assert.Equal(t, map[string]string{
+		"deployer.token": "config",
+	}, ReleaseAnnotations(nil, Deployer{TokenSource: "config"}, ""))
+}
+
+func TestDetectCI(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	assert.Equal(t, "", DetectCI(env(nil)))
+	assert.Equal(t, "github-actions", DetectCI(env(map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"})))
+	assert.Equal(t, "ci", DetectCI(env(map[string]string{"CI": "1"})))
+}
@@ -0,0 +1,111 @@
+package deployment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// BisectResult - the outcome of testing a release during a bisect
+type BisectResult int
+
+const (
+	BisectGood BisectResult = iota
+	BisectBad
+	BisectSkip
+)
+
+// Bisect - binary searches a range of release versions for the first bad release
+type Bisect struct {
+	versions []int
+	good     int
+	bad      int
+	skipped  map[int]bool
+}
+
+// NewBisect - starts a bisect between a known good and a known bad version. versions
+// are the releases that can be tested and must include both good and bad.
+func NewBisect(versions []int, good, bad int) (*Bisect, error) {
+	if good >= bad {
+		return nil, fmt.Errorf("good release v%d must be older than bad release v%d", good, bad)
+	}
+
+	sorted := append([]int{}, versions...)
+	sort.Ints(sorted)
+
+	b := &Bisect{versions: sorted, good: -1, bad: -1, skipped: map[int]bool{}}
+	for i, v := range sorted {
+		switch v {
+		case good:
+			b.good = i
+		case bad:
+			b.bad = i
+		}
+	}
+
+	if b.good < 0 {
+		return nil, fmt.Errorf("release v%d not found", good)
+	}
+	if b.bad < 0 {
+		return nil, fmt.Errorf("release v%d not found", bad)
+	}
+
+	return b, nil
+}
+
+// Next - returns the next version to test, or false when there is nothing left to test
+func (b *Bisect) Next() (int, bool) {
+	mid := (b.good + b.bad) / 2
+
+	// walk outwards from the middle to find the closest untested version that wasn't skipped
+	for offset := 0; offset < b.bad-b.good; offset++ {
+		for _, i := range []int{mid - offset, mid + offset + 1} {
+			if i > b.good && i < b.bad && !b.skipped[b.versions[i]] {
+				return b.versions[i], true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// Mark - records the result of testing a version
+func (b *Bisect) Mark(version int, result BisectResult) {
+	for i, v := range b.versions {
+		if v != version {
+			continue
+		}
+
+		switch result {
+		case BisectGood:
+			if i > b.good && i < b.bad {
+				b.good = i
+			}
+		case BisectBad:
+			if i > b.good && i < b.bad {
+				b.bad = i
+			}
+		case BisectSkip:
+			b.skipped[version] = true
+		}
+		return
+	}
+}
+
+// Suspects - the versions that may have introduced the regression. Once Next returns
+// false this is the first bad version alone, unless skipped versions hide it.
+func (b *Bisect) Suspects() []int {
+	return append([]int{}, b.versions[b.good+1:b.bad+1]...)
+}
+
+// StepsLeft - roughly how many more versions need testing
+func (b *Bisect) StepsLeft() int {
+	remaining := 0
+	for _, v := range b.versions[b.good+1 : b.bad] {
+		if !b.skipped[v] {
+			remaining++
+		}
+	}
+
+	return int(math.Ceil(math.Log2(float64(remaining + 1))))
+}
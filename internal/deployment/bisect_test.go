@@ -0,0 +1,57 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBisectFindsFirstBadRelease(t *testing.T) {
+	versions := []int{}
+	for v := 40; v <= 52; v++ {
+		versions = append(versions, v)
+	}
+
+	b, err := NewBisect(versions, 40, 52)
+	assert.NoError(t, err)
+
+	steps := 0
+	for {
+		v, ok := b.Next()
+		if !ok {
+			break
+		}
+		steps++
+		if v >= 47 {
+			b.Mark(v, BisectBad)
+		} else {
+			b.Mark(v, BisectGood)
+		}
+	}
+
+	assert.Equal(t, []int{47}, b.Suspects())
+	assert.LessOrEqual(t, steps, 4)
+}
+
+func TestBisectSkip(t *testing.T) {
+	b, err := NewBisect([]int{1, 2, 3, 4}, 1, 4)
+	assert.NoError(t, err)
+
+	for {
+		v, ok := b.Next()
+		if !ok {
+			break
+		}
+		b.Mark(v, BisectSkip)
+	}
+
+	assert.Equal(t, []int{2, 3, 4}, b.Suspects())
+}
+
+func TestNewBisectErrors(t *testing.T) {
+	_, err := NewBisect([]int{1, 2, 3}, 3, 1)
+	assert.Error(t, err)
+
+	_, err = NewBisect([]int{1, 2, 3}, 1, 5)
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package deployment
+
+import (
+	"github.com/superfly/flyctl/api"
+)
+
+// Drift - differences between the desired and live state of an app
+type Drift struct {
+	DesiredImage      string
+	LiveImage         string
+	DesiredConfigHash string
+	LiveConfigHash    string
+	ImageChanged      bool
+	ConfigChanged     bool
+}
+
+// HasDrift - true when the live app does not match the desired state
+func (d *Drift) HasDrift() bool {
+	return d.ImageChanged || d.ConfigChanged
+}
+
+// DetectDrift compares the live state of an app with the desired image and config definition.
+// When the desired image digest is known images are compared by digest, otherwise by reference.
+func DetectDrift(client *api.Client, appName string, desiredImage string, desiredDigest string, definition map[string]interface{}) (*Drift, error) {
+	drift := &Drift{DesiredImage: desiredImage}
+
+	release, err := client.GetAppCurrentRelease(appName)
+	if err != nil {
+		return nil, err
+	}
+	if release != nil {
+		drift.LiveImage = release.ImageRef
+	}
+
+	if drift.LiveImage != desiredImage {
+		drift.ImageChanged = true
+
+		if desiredDigest != "" && drift.LiveImage != "" {
+			if image, err := client.ResolveImageForApp(appName, drift.LiveImage); err == nil && image != nil {
+				drift.ImageChanged = image.Digest != desiredDigest
+			}
+		}
+	}
+
+	liveConfig, err := client.GetConfig(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	if drift.LiveConfigHash, err = ConfigHash(liveConfig.Definition); err != nil {
+		return nil, err
+	}
+	if drift.DesiredConfigHash, err = ConfigHash(definition); err != nil {
+		return nil, err
+	}
+	drift.ConfigChanged = drift.LiveConfigHash != drift.DesiredConfigHash
+
+	return drift, nil
+}
@@ -0,0 +1,133 @@
+package deployment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Manifest - a machine readable record of what was deployed to an app
+type Manifest struct {
+	App            string    `json:"app"`
+	ReleaseID      string    `json:"release_id"`
+	ReleaseVersion int       `json:"release_version"`
+	Image          string    `json:"image"`
+	ImageDigest    string    `json:"image_digest,omitempty"`
+	ConfigHash     string    `json:"config_hash"`
+	Strategy       string    `json:"strategy,omitempty"`
+	GitSHA         string    `json:"git_sha,omitempty"`
+	GitBranch      string    `json:"git_branch,omitempty"`
+	GitDirty       bool      `json:"git_dirty,omitempty"`
+	DeployedAt     time.Time `json:"deployed_at"`
+}
+
+// ConfigHash returns a stable hash of an app config definition
+func ConfigHash(definition map[string]interface{}) (string, error) {
+	// encoding/json sorts map keys so equal definitions produce equal output
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// ManifestPaths returns the files a manifest is written to for the given
+// destination. Destinations ending in .json are used as is, anything else is
+// treated as a directory holding a versioned and a latest manifest per app.
+func ManifestPaths(dest string, m *Manifest) []string {
+	if strings.HasSuffix(dest, ".json") {
+		return []string{dest}
+	}
+
+	return []string{
+		filepath.Join(dest, fmt.Sprintf("%s-v%d.json", m.App, m.ReleaseVersion)),
+		LatestManifestPath(dest, m.App),
+	}
+}
+
+// LatestManifestPath returns the path of the most recent manifest for an app in a directory
+func LatestManifestPath(dir string, appName string) string {
+	return filepath.Join(dir, appName+"-latest.json")
+}
+
+// GitDestination splits a git:<branch>[:<dir>] destination into the branch
+// and the directory in it manifests are written to. ok is false for
+// destinations that are paths.
+func GitDestination(dest string) (branch string, dir string, ok bool) {
+	if !strings.HasPrefix(dest, "git:") {
+		return "", "", false
+	}
+
+	branch = strings.TrimPrefix(dest, "git:")
+	if i := strings.Index(branch, ":"); i >= 0 {
+		branch, dir = branch[:i], strings.Trim(branch[i+1:], "/")
+	}
+	return branch, dir, true
+}
+
+// ManifestFiles returns the manifest's JSON keyed by the slash separated
+// paths it's written to in dir, for committing to a branch
+func ManifestFiles(dir string, m *Manifest) (map[string][]byte, error) {
+	data, err := marshalManifest(m)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, p := range ManifestPaths(dir, m) {
+		files[strings.TrimPrefix(filepath.ToSlash(p), "/")] = data
+	}
+	return files, nil
+}
+
+func marshalManifest(m *Manifest) ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteManifest writes the manifest to dest, returning the files written
+func WriteManifest(dest string, m *Manifest) ([]string, error) {
+	data, err := marshalManifest(m)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := ManifestPaths(dest, m)
+
+	for _, p := range paths {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// LoadManifest reads a manifest from a file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid deploy manifest %s: %w", path, err)
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigHashIsStable(t *testing.T) {
+	a, err := ConfigHash(map[string]interface{}{"env": map[string]interface{}{"A": "1", "B": "2"}, "kill_timeout": 5})
+	assert.NoError(t, err)
+
+	b, err := ConfigHash(map[string]interface{}{"kill_timeout": 5, "env": map[string]interface{}{"B": "2", "A": "1"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{App: "test-app", ReleaseVersion: 3, Image: "registry.fly.io/test-app:deployment-1", DeployedAt: time.Now().UTC().Truncate(time.Second)}
+
+	paths, err := WriteManifest(dir, m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "test-app-v3.json"), filepath.Join(dir, "test-app-latest.json")}, paths)
+
+	loaded, err := LoadManifest(LatestManifestPath(dir, "test-app"))
+	assert.NoError(t, err)
+	assert.Equal(t, m, loaded)
+
+	paths, err = WriteManifest(filepath.Join(dir, "out", "deploy.json"), m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "out", "deploy.json")}, paths)
+}
+
+func TestGitDestination(t *testing.T) {
+	_, _, ok := GitDestination(".fly/deployments")
+	assert.False(t, ok)
+
+	branch, dir, ok := GitDestination("git:deployments")
+	assert.True(t, ok)
+	assert.Equal(t, "deployments", branch)
+	assert.Equal(t, "", dir)
+
+	branch, dir, ok = GitDestination("git:deployments:apps/web/")
+	assert.True(t, ok)
+	assert.Equal(t, "deployments", branch)
+	assert.Equal(t, "apps/web", dir)
+
+	files, err := ManifestFiles(dir, &Manifest{App: "web", ReleaseVersion: 3})
+	assert.NoError(t, err)
+	assert.Contains(t, files, "apps/web/web-v3.json")
+	assert.Contains(t, files, "apps/web/web-latest.json")
+}
@@ -0,0 +1,168 @@
+package deployment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// PlanFormatVersion - the version of the plan file format written by
+// flyctl deploy --plan. Plans of other versions are refused by --apply.
+// Version 2 hashes the [build] section into config_hash.
+const PlanFormatVersion = 2
+
+// The mutations a plan can hold, named after the API calls that make them
+const (
+	MutationDeployImage = "deployImage"
+	MutationSetVMCount  = "setVMCount"
+	MutationSetVMSize   = "setVMSize"
+)
+
+// Plan - what flyctl deploy would do to an app, written by --plan for review
+// and carried out by --apply. The app's release and secrets are recorded so
+// a plan can't be applied once the app has changed under it.
+type Plan struct {
+	FormatVersion  int            `json:"format_version"`
+	App            string         `json:"app"`
+	CreatedAt      time.Time      `json:"created_at"`
+	CurrentRelease int            `json:"current_release"`
+	SecretsDigest  string         `json:"secrets_digest"`
+	ConfigHash     string         `json:"config_hash"`
+	Image          PlanImage      `json:"image"`
+	Mutations      []PlanMutation `json:"mutations"`
+}
+
+// PlanImage - the image a plan releases. Ref is pinned to a digest when the
+// image is already in a registry, otherwise Build describes how it's built
+// from source when the plan is applied.
+type PlanImage struct {
+	Ref    string     `json:"ref,omitempty"`
+	Digest string     `json:"digest,omitempty"`
+	Size   int64      `json:"size,omitempty"`
+	Build  *PlanBuild `json:"build,omitempty"`
+}
+
+// PlanBuild - the source an image is built from when a plan is applied
+type PlanBuild struct {
+	Strategy   string `json:"strategy"`
+	Dockerfile string `json:"dockerfile,omitempty"`
+	GitSHA     string `json:"git_sha,omitempty"`
+	GitDirty   bool   `json:"git_dirty,omitempty"`
+}
+
+// PlanMutation - an API mutation a plan makes, with its input as sent
+type PlanMutation struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Input       json.RawMessage `json:"input"`
+}
+
+// AddMutation appends a mutation to the plan
+func (p *Plan) AddMutation(name, description string, input interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	p.Mutations = append(p.Mutations, PlanMutation{Name: name, Description: description, Input: data})
+	return nil
+}
+
+// MutationInputs decodes the inputs of each mutation called name into a
+// slice pointed to by into, in the order they're made
+func (p *Plan) MutationInputs(name string, into interface{}) error {
+	inputs := []json.RawMessage{}
+	for _, mutation := range p.Mutations {
+		if mutation.Name == name {
+			inputs = append(inputs, mutation.Input)
+		}
+	}
+
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("invalid %s input: %w", name, err)
+	}
+	return nil
+}
+
+// Stale returns why the plan no longer applies to an app at release and with
+// secrets, or an empty string when it still does
+func (p *Plan) Stale(release *api.Release, secrets []api.Secret) string {
+	version := 0
+	if release != nil {
+		version = release.Version
+	}
+	if version != p.CurrentRelease {
+		return fmt.Sprintf("the plan was made against v%d, but the app is now at v%d", p.CurrentRelease, version)
+	}
+	if SecretsDigest(secrets) != p.SecretsDigest {
+		return "the app's secrets have changed since the plan was made"
+	}
+	return ""
+}
+
+// PlanConfigHash returns a stable hash of an app config definition and its
+// [build] section, which flyctl reads itself rather than sending it in the
+// definition, so a plan goes stale when either changes
+func PlanConfigHash(definition map[string]interface{}, build interface{}) (string, error) {
+	return ConfigHash(map[string]interface{}{
+		"definition": definition,
+		"build":      build,
+	})
+}
+
+// SecretsDigest returns a stable digest of an app's secrets, which changes
+// whenever a secret is set or unset. Values aren't known to flyctl, only the
+// digests of them.
+func SecretsDigest(secrets []api.Secret) string {
+	lines := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		lines = append(lines, secret.Name+"="+secret.Digest+"\n")
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// PinnedRef returns ref with its tag replaced by digest, so the image can't
+// change between the plan and its release
+func PinnedRef(ref, digest string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref + "@" + digest
+}
+
+// LoadPlan reads a plan written by flyctl deploy --plan
+func LoadPlan(path string) (*Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid deploy plan %s: %w", path, err)
+	}
+	if p.FormatVersion != PlanFormatVersion {
+		return nil, fmt.Errorf("deploy plan %s has format version %d, this flyctl applies version %d", path, p.FormatVersion, PlanFormatVersion)
+	}
+
+	return &p, nil
+}
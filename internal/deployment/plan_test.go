@@ -0,0 +1,92 @@
+package deployment
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSecretsDigestIgnoresOrder(t *testing.T) {
+	a := SecretsDigest([]api.Secret{{Name: "A", Digest: "1"}, {Name: "B", Digest: "2"}})
+	b := SecretsDigest([]api.Secret{{Name: "B", Digest: "2"}, {Name: "A", Digest: "1"}})
+	assert.Equal(t, a, b)
+
+	assert.NotEqual(t, a, SecretsDigest([]api.Secret{{Name: "A", Digest: "1"}, {Name: "B", Digest: "3"}}))
+	assert.NotEqual(t, a, SecretsDigest([]api.Secret{{Name: "A", Digest: "1"}}))
+}
+
+func TestPlanMutationInputs(t *testing.T) {
+	p := &Plan{}
+	assert.NoError(t, p.AddMutation(MutationDeployImage, "", api.DeployImageInput{AppID: "test-app", Image: "image"}))
+	assert.NoError(t, p.AddMutation(MutationSetVMSize, "", api.SetVMSizeInput{AppID: "test-app", Group: "web", SizeName: "shared-cpu-1x"}))
+	assert.NoError(t, p.AddMutation(MutationSetVMSize, "", api.SetVMSizeInput{AppID: "test-app", Group: "worker", SizeName: "dedicated-cpu-1x"}))
+
+	var deploys []api.DeployImageInput
+	assert.NoError(t, p.MutationInputs(MutationDeployImage, &deploys))
+	assert.Equal(t, []api.DeployImageInput{{AppID: "test-app", Image: "image"}}, deploys)
+
+	var sizes []api.SetVMSizeInput
+	assert.NoError(t, p.MutationInputs(MutationSetVMSize, &sizes))
+	assert.Equal(t, []string{"web", "worker"}, []string{sizes[0].Group, sizes[1].Group})
+
+	var counts []api.SetVMCountInput
+	assert.NoError(t, p.MutationInputs(MutationSetVMCount, &counts))
+	assert.Empty(t, counts)
+}
+
+func TestPinnedRef(t *testing.T) {
+	digest := "sha256:abc"
+	assert.Equal(t, "registry.fly.io/app@sha256:abc", PinnedRef("registry.fly.io/app:deployment-1", digest))
+	assert.Equal(t, "localhost:5000/app@sha256:abc", PinnedRef("localhost:5000/app", digest))
+	assert.Equal(t, "nginx@sha256:abc", PinnedRef("nginx@sha256:def", digest))
+}
+
+func TestPlanStale(t *testing.T) {
+	secrets := []api.Secret{{Name: "A", Digest: "1"}}
+	p := &Plan{CurrentRelease: 4, SecretsDigest: SecretsDigest(secrets)}
+
+	assert.Empty(t, p.Stale(&api.Release{Version: 4}, secrets))
+	assert.Contains(t, p.Stale(&api.Release{Version: 5}, secrets), "now at v5")
+	assert.Contains(t, p.Stale(&api.Release{Version: 4}, nil), "secrets")
+}
+
+func TestLoadPlanChecksFormatVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "plan.json")
+	data, err := json.Marshal(&Plan{FormatVersion: PlanFormatVersion + 1, App: "test-app"})
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	_, err = LoadPlan(path)
+	assert.Error(t, err)
+
+	data, err = json.Marshal(&Plan{FormatVersion: PlanFormatVersion, App: "test-app"})
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	p, err := LoadPlan(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-app", p.App)
+}
+
+func TestPlanConfigHashIncludesBuild(t *testing.T) {
+	definition := map[string]interface{}{"kill_timeout": 5}
+
+	a, err := PlanConfigHash(definition, map[string]string{"dockerfile": "Dockerfile"})
+	assert.NoError(t, err)
+	b, err := PlanConfigHash(definition, map[string]string{"dockerfile": "Dockerfile.prod"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	again, err := PlanConfigHash(definition, map[string]string{"dockerfile": "Dockerfile"})
+	assert.NoError(t, err)
+	assert.Equal(t, a, again)
+}
@@ -0,0 +1,164 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// ReleaseDiff - the differences between two releases of an app
+type ReleaseDiff struct {
+	From           int            `json:"from"`
+	To             int            `json:"to"`
+	FromImage      string         `json:"from_image"`
+	ToImage        string         `json:"to_image"`
+	ImageChanged   bool           `json:"image_changed"`
+	Config         []ConfigChange `json:"config"`
+	SecretsAdded   []string       `json:"secrets_added"`
+	SecretsRemoved []string       `json:"secrets_removed"`
+}
+
+// ConfigChange - a config setting added, removed or changed between releases.
+// Path is dotted, with list items indexed, e.g. services[0].internal_port.
+type ConfigChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// Added is true when the setting is only in the newer release
+func (c ConfigChange) Added() bool {
+	return c.From == nil
+}
+
+// Removed is true when the setting is only in the older release
+func (c ConfigChange) Removed() bool {
+	return c.To == nil
+}
+
+// HasChanges - true when the releases differ
+func (d *ReleaseDiff) HasChanges() bool {
+	return d.ImageChanged || len(d.Config) > 0 || len(d.SecretsAdded) > 0 || len(d.SecretsRemoved) > 0
+}
+
+// DiffReleases compares the image, config and secret names of two releases
+func DiffReleases(from, to *api.Release) *ReleaseDiff {
+	diff := &ReleaseDiff{
+		From:         from.Version,
+		To:           to.Version,
+		FromImage:    from.ImageRef,
+		ToImage:      to.ImageRef,
+		ImageChanged: from.ImageRef != to.ImageRef,
+	}
+
+	diff.Config = diffConfig(releaseDefinition(from), releaseDefinition(to))
+	diff.SecretsAdded, diff.SecretsRemoved = diffNames(secretNames(from), secretNames(to))
+
+	return diff
+}
+
+func releaseDefinition(r *api.Release) map[string]interface{} {
+	if r.Config == nil {
+		return nil
+	}
+	return r.Config.Definition
+}
+
+func secretNames(r *api.Release) []string {
+	names := make([]string, 0, len(r.Secrets))
+	for _, secret := range r.Secrets {
+		names = append(names, secret.Name)
+	}
+	return names
+}
+
+// diffConfig compares two config definitions setting by setting, sorted by path
+func diffConfig(from, to map[string]interface{}) []ConfigChange {
+	fromValues := map[string]interface{}{}
+	flattenConfig("", from, fromValues)
+	toValues := map[string]interface{}{}
+	flattenConfig("", to, toValues)
+
+	paths := make([]string, 0, len(fromValues)+len(toValues))
+	for path := range fromValues {
+		paths = append(paths, path)
+	}
+	for path := range toValues {
+		if _, ok := fromValues[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []ConfigChange
+	for _, path := range paths {
+		a, b := fromValues[path], toValues[path]
+		if sameValue(a, b) {
+			continue
+		}
+		changes = append(changes, ConfigChange{Path: path, From: a, To: b})
+	}
+
+	return changes
+}
+
+// flattenConfig collects the leaf values of value into values, keyed by path
+func flattenConfig(path string, value interface{}, values map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenConfig(childPath, child, values)
+		}
+	case api.Definition:
+		flattenConfig(path, map[string]interface{}(v), values)
+	case []interface{}:
+		for i, child := range v {
+			flattenConfig(fmt.Sprintf("%s[%d]", path, i), child, values)
+		}
+	case nil:
+	default:
+		values[path] = v
+	}
+}
+
+// sameValue compares config values by their JSON, so numbers decoded as
+// different types compare equal
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
+
+// diffNames returns the names only in to, and those only in from, sorted
+func diffNames(from, to []string) (added, removed []string) {
+	inFrom := map[string]bool{}
+	for _, name := range from {
+		inFrom[name] = true
+	}
+	inTo := map[string]bool{}
+	for _, name := range to {
+		inTo[name] = true
+		if !inFrom[name] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range from {
+		if !inTo[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
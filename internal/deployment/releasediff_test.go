@@ -0,0 +1,52 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestDiffReleases(t *testing.T) {
+	from := &api.Release{
+		Version:  42,
+		ImageRef: "registry.fly.io/test-app:deployment-1",
+		Config: &api.AppConfig{Definition: api.Definition{
+			"kill_timeout": 5,
+			"env":          map[string]interface{}{"LOG_LEVEL": "info", "REGION": "syd"},
+			"services":     []interface{}{map[string]interface{}{"internal_port": 8080}},
+		}},
+		Secrets: []api.Secret{{Name: "DATABASE_URL"}, {Name: "OLD_KEY"}},
+	}
+	to := &api.Release{
+		Version:  43,
+		ImageRef: "registry.fly.io/test-app:deployment-2",
+		Config: &api.AppConfig{Definition: api.Definition{
+			"kill_timeout": 5.0,
+			"env":          map[string]interface{}{"LOG_LEVEL": "debug"},
+			"services":     []interface{}{map[string]interface{}{"internal_port": 8080}},
+			"deploy":       map[string]interface{}{"strategy": "canary"},
+		}},
+		Secrets: []api.Secret{{Name: "DATABASE_URL"}, {Name: "NEW_KEY"}},
+	}
+
+	diff := DiffReleases(from, to)
+
+	assert.True(t, diff.HasChanges())
+	assert.True(t, diff.ImageChanged)
+	assert.Equal(t, []ConfigChange{
+		{Path: "deploy.strategy", To: "canary"},
+		{Path: "env.LOG_LEVEL", From: "info", To: "debug"},
+		{Path: "env.REGION", From: "syd"},
+	}, diff.Config)
+	assert.True(t, diff.Config[0].Added())
+	assert.True(t, diff.Config[2].Removed())
+	assert.Equal(t, []string{"NEW_KEY"}, diff.SecretsAdded)
+	assert.Equal(t, []string{"OLD_KEY"}, diff.SecretsRemoved)
+}
+
+func TestDiffReleasesUnchanged(t *testing.T) {
+	release := &api.Release{Version: 7, ImageRef: "registry.fly.io/test-app:deployment-1"}
+
+	assert.False(t, DiffReleases(release, release).HasChanges())
+}
@@ -0,0 +1,244 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// RestartClient - the API calls a rolling restart makes
+type RestartClient interface {
+	RestartAllocation(appName string, allocID string) error
+	GetAllocationStatus(appName string, allocID string, logLimit int) (*api.AllocationStatus, error)
+}
+
+// SurgeClient - the API calls adding surge instances makes
+type SurgeClient interface {
+	GetAppVMCount(appID string) ([]api.TaskGroupCount, error)
+	SetAppVMGroupCounts(appID string, counts []api.VMCountInput) ([]api.TaskGroupCount, []string, error)
+	GetAppStatus(appName string, showCompleted bool) (*api.AppStatus, error)
+}
+
+// RollingRestartOptions - how a rolling restart proceeds
+type RollingRestartOptions struct {
+	// MaxUnavailable - how many instances are restarted at once
+	MaxUnavailable int
+	// MaxSurge - how many instances each process group is scaled up by
+	// while the restart runs, so it keeps its capacity
+	MaxSurge int
+	// WaitHealthy - wait for each batch's health checks to pass, not only
+	// for its instances to be running, before the next batch
+	WaitHealthy bool
+	// Timeout - how long each batch has to come back
+	Timeout time.Duration
+	// PollInterval - how often a batch's status is checked
+	PollInterval time.Duration
+}
+
+// RestartBatches splits allocations into batches of size, keeping their order
+func RestartBatches(allocs []*api.AllocationStatus, size int) [][]*api.AllocationStatus {
+	if size < 1 {
+		size = 1
+	}
+
+	var batches [][]*api.AllocationStatus
+	for len(allocs) > 0 {
+		n := size
+		if n > len(allocs) {
+			n = len(allocs)
+		}
+		batches = append(batches, allocs[:n])
+		allocs = allocs[n:]
+	}
+	return batches
+}
+
+// RollingRestartError - a rolling restart stopped partway, leaving the
+// instances in Remaining unrestarted
+type RollingRestartError struct {
+	Err       error
+	Remaining []string
+}
+
+func (e *RollingRestartError) Error() string {
+	if len(e.Remaining) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s, %d instances were not restarted", e.Err, len(e.Remaining))
+}
+
+func (e *RollingRestartError) Unwrap() error {
+	return e.Err
+}
+
+// RollingRestart restarts allocs a batch at a time, waiting for each batch to
+// be running again, and healthy with WaitHealthy, before the next. It stops
+// when an instance fails or its health checks end up worse than before it
+// was restarted. report is called as each batch starts and finishes.
+func RollingRestart(ctx context.Context, client RestartClient, appName string, allocs []*api.AllocationStatus, opts RollingRestartOptions, report func(batch []*api.AllocationStatus, done bool)) error {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+
+	// the instances not restarted yet, in order
+	remaining := make([]string, 0, len(allocs))
+	for _, alloc := range allocs {
+		remaining = append(remaining, alloc.IDShort)
+	}
+
+	for _, batch := range RestartBatches(allocs, opts.MaxUnavailable) {
+		report(batch, false)
+
+		for _, alloc := range batch {
+			if err := client.RestartAllocation(appName, alloc.ID); err != nil {
+				return &RollingRestartError{Err: fmt.Errorf("could not restart %s: %w", alloc.IDShort, err), Remaining: remaining}
+			}
+			remaining = remaining[1:]
+		}
+
+		if err := waitForBatch(ctx, client, appName, batch, opts); err != nil {
+			return &RollingRestartError{Err: err, Remaining: remaining}
+		}
+
+		report(batch, true)
+	}
+
+	return nil
+}
+
+func waitForBatch(ctx context.Context, client RestartClient, appName string, batch []*api.AllocationStatus, opts RollingRestartOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	pending := map[string]*api.AllocationStatus{}
+	for _, alloc := range batch {
+		pending[alloc.ID] = alloc
+	}
+	latest := map[string]*api.AllocationStatus{}
+
+	for {
+		for id, before := range pending {
+			alloc, err := client.GetAllocationStatus(appName, id, 0)
+			if err != nil {
+				return err
+			}
+			if alloc == nil {
+				return fmt.Errorf("instance %s is gone", before.IDShort)
+			}
+			latest[id] = alloc
+
+			if alloc.Status == "failed" || alloc.Status == "lost" {
+				return fmt.Errorf("instance %s is %s after restarting", before.IDShort, alloc.Status)
+			}
+			if restarted(before, alloc) && (!opts.WaitHealthy || !checksRegressed(before, alloc)) {
+				delete(pending, id)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return ctx.Err()
+			}
+			for id, before := range pending {
+				alloc := latest[id]
+				if alloc != nil && restarted(before, alloc) {
+					return fmt.Errorf("instance %s has %d critical health checks after restarting, it had %d before", before.IDShort, alloc.CriticalCheckCount, before.CriticalCheckCount)
+				}
+				return fmt.Errorf("instance %s wasn't running again after %s", before.IDShort, opts.Timeout)
+			}
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// restarted is true once the instance has restarted and is running again.
+// Only the restart count is relied on: an instance is updated for other
+// reasons, and its update time is the server's clock rather than ours.
+func restarted(before, after *api.AllocationStatus) bool {
+	return after.Status == "running" && after.Restarts > before.Restarts
+}
+
+// Surge scales each of the app's process groups up by n instances and waits,
+// up to timeout, for them to be running. The returned restore scales the
+// groups back to their counts from before, and is to be called once the
+// restart is over, whether it succeeded or not.
+func Surge(ctx context.Context, client SurgeClient, appName string, n int, timeout time.Duration, pollInterval time.Duration) (restore func() error, err error) {
+	if pollInterval == 0 {
+		pollInterval = 2 * time.Second
+	}
+
+	groups, err := client.GetAppVMCount(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := client.GetAppStatus(appName, false)
+	if err != nil {
+		return nil, err
+	}
+	want := runningAllocations(status) + n*len(groups)
+
+	original := make([]api.VMCountInput, 0, len(groups))
+	surged := make([]api.VMCountInput, 0, len(groups))
+	for _, group := range groups {
+		original = append(original, api.VMCountInput{Group: group.Name, Count: group.Count})
+		surged = append(surged, api.VMCountInput{Group: group.Name, Count: group.Count + n})
+	}
+
+	restore = func() error {
+		_, _, err := client.SetAppVMGroupCounts(appName, original)
+		return err
+	}
+
+	if _, _, err := client.SetAppVMGroupCounts(appName, surged); err != nil {
+		return nil, fmt.Errorf("could not add surge instances: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		status, err := client.GetAppStatus(appName, false)
+		if err != nil {
+			return restore, err
+		}
+		if runningAllocations(status) >= want {
+			return restore, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return restore, fmt.Errorf("surge instances weren't running after %s", timeout)
+			}
+			return restore, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func runningAllocations(status *api.AppStatus) int {
+	running := 0
+	for _, alloc := range status.Allocations {
+		if alloc.Status == "running" {
+			running++
+		}
+	}
+	return running
+}
+
+// checksRegressed is true when the instance has more failing health checks
+// than before it was restarted, or its checks haven't all run yet
+func checksRegressed(before, after *api.AllocationStatus) bool {
+	if after.CriticalCheckCount > before.CriticalCheckCount {
+		return true
+	}
+	return after.PassingCheckCount+after.WarningCheckCount+after.CriticalCheckCount < before.PassingCheckCount+before.WarningCheckCount+before.CriticalCheckCount
+}
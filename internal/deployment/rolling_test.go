@@ -0,0 +1,152 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+// fakeRestartClient restarts instances into the states in after
+type fakeRestartClient struct {
+	before    map[string]*api.AllocationStatus
+	after     map[string]*api.AllocationStatus
+	restarted []string
+}
+
+func (c *fakeRestartClient) RestartAllocation(appName string, allocID string) error {
+	if _, ok := c.before[allocID]; !ok {
+		return errors.New("not found")
+	}
+	c.restarted = append(c.restarted, allocID)
+	return nil
+}
+
+func (c *fakeRestartClient) GetAllocationStatus(appName string, allocID string, logLimit int) (*api.AllocationStatus, error) {
+	for _, id := range c.restarted {
+		if id == allocID {
+			return c.after[allocID], nil
+		}
+	}
+	return c.before[allocID], nil
+}
+
+func newFakeRestartClient(ids ...string) (*fakeRestartClient, []*api.AllocationStatus) {
+	client := &fakeRestartClient{before: map[string]*api.AllocationStatus{}, after: map[string]*api.AllocationStatus{}}
+	var allocs []*api.AllocationStatus
+	for _, id := range ids {
+		alloc := &api.AllocationStatus{ID: id, IDShort: id, Status: "running", PassingCheckCount: 1}
+		client.before[id] = alloc
+		client.after[id] = &api.AllocationStatus{ID: id, IDShort: id, Status: "running", Restarts: 1, PassingCheckCount: 1}
+		allocs = append(allocs, alloc)
+	}
+	return client, allocs
+}
+
+func TestRestartBatches(t *testing.T) {
+	_, allocs := newFakeRestartClient("a", "b", "c", "d", "e")
+
+	batches := RestartBatches(allocs, 2)
+
+	assert.Len(t, batches, 3)
+	assert.Equal(t, "e", batches[2][0].ID)
+	assert.Len(t, RestartBatches(allocs, 0), 5)
+}
+
+func TestRollingRestart(t *testing.T) {
+	client, allocs := newFakeRestartClient("a", "b", "c")
+	opts := RollingRestartOptions{MaxUnavailable: 2, WaitHealthy: true, Timeout: time.Second, PollInterval: time.Millisecond}
+
+	var finished [][]string
+	err := RollingRestart(context.Background(), client, "test-app", allocs, opts, func(batch []*api.AllocationStatus, done bool) {
+		if done {
+			var ids []string
+			for _, alloc := range batch {
+				ids = append(ids, alloc.ID)
+			}
+			finished = append(finished, ids)
+		}
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, finished)
+}
+
+func TestRollingRestartAbortsWhenChecksRegress(t *testing.T) {
+	client, allocs := newFakeRestartClient("a", "b", "c")
+	client.after["a"].PassingCheckCount = 0
+	client.after["a"].CriticalCheckCount = 1
+	opts := RollingRestartOptions{MaxUnavailable: 1, WaitHealthy: true, Timeout: 20 * time.Millisecond, PollInterval: time.Millisecond}
+
+	err := RollingRestart(context.Background(), client, "test-app", allocs, opts, func([]*api.AllocationStatus, bool) {})
+
+	var restartErr *RollingRestartError
+	assert.True(t, errors.As(err, &restartErr))
+	assert.Contains(t, err.Error(), "critical health checks")
+	assert.Equal(t, []string{"b", "c"}, restartErr.Remaining)
+	assert.Equal(t, []string{"a"}, client.restarted)
+}
+
+func TestRollingRestartAbortsWhenInstanceFails(t *testing.T) {
+	client, allocs := newFakeRestartClient("a", "b")
+	client.after["a"].Status = "failed"
+	opts := RollingRestartOptions{MaxUnavailable: 1, Timeout: time.Second, PollInterval: time.Millisecond}
+
+	err := RollingRestart(context.Background(), client, "test-app", allocs, opts, func([]*api.AllocationStatus, bool) {})
+
+	assert.EqualError(t, err, "instance a is failed after restarting, 1 instances were not restarted")
+}
+
+func TestRollingRestartIgnoresUpdatesWithoutRestart(t *testing.T) {
+	client, allocs := newFakeRestartClient("a")
+	client.after["a"].Restarts = 0
+	client.after["a"].UpdatedAt = time.Now().Add(time.Hour)
+	opts := RollingRestartOptions{MaxUnavailable: 1, Timeout: 20 * time.Millisecond, PollInterval: time.Millisecond}
+
+	err := RollingRestart(context.Background(), client, "test-app", allocs, opts, func([]*api.AllocationStatus, bool) {})
+
+	assert.EqualError(t, err, "instance a wasn't running again after 20ms")
+}
+
+// fakeSurgeClient runs as many instances as its groups' counts add up to
+type fakeSurgeClient struct {
+	counts []api.TaskGroupCount
+	sets   [][]api.VMCountInput
+}
+
+func (c *fakeSurgeClient) GetAppVMCount(appID string) ([]api.TaskGroupCount, error) {
+	return c.counts, nil
+}
+
+func (c *fakeSurgeClient) SetAppVMGroupCounts(appID string, counts []api.VMCountInput) ([]api.TaskGroupCount, []string, error) {
+	c.sets = append(c.sets, counts)
+	c.counts = nil
+	for _, count := range counts {
+		c.counts = append(c.counts, api.TaskGroupCount{Name: count.Group, Count: count.Count})
+	}
+	return c.counts, nil, nil
+}
+
+func (c *fakeSurgeClient) GetAppStatus(appName string, showCompleted bool) (*api.AppStatus, error) {
+	status := &api.AppStatus{}
+	for _, group := range c.counts {
+		for i := 0; i < group.Count; i++ {
+			status.Allocations = append(status.Allocations, &api.AllocationStatus{Status: "running"})
+		}
+	}
+	return status, nil
+}
+
+func TestSurge(t *testing.T) {
+	client := &fakeSurgeClient{counts: []api.TaskGroupCount{{Name: "web", Count: 2}, {Name: "worker", Count: 1}}}
+
+	restore, err := Surge(context.Background(), client, "test-app", 1, time.Second, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []api.VMCountInput{{Group: "web", Count: 3}, {Group: "worker", Count: 2}}, client.sets[0])
+
+	assert.NoError(t, restore())
+	assert.Equal(t, []api.VMCountInput{{Group: "web", Count: 2}, {Group: "worker", Count: 1}}, client.sets[1])
+}
@@ -0,0 +1,131 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// PendingDeploy - an image that was pushed for an app but hasn't been released successfully yet.
+// It's kept between runs so an interrupted deploy can be resumed without rebuilding.
+type PendingDeploy struct {
+	App         string    `json:"app"`
+	Image       string    `json:"image"`
+	ImageID     string    `json:"image_id,omitempty"`
+	ImageSize   int64     `json:"image_size,omitempty"`
+	ImageDigest string    `json:"image_digest,omitempty"`
+	ConfigHash  string    `json:"config_hash"`
+	PushedAt    time.Time `json:"pushed_at"`
+}
+
+// PendingDeployPath returns where the pending deploy for an app is kept
+func PendingDeployPath(configDir string, appName string) string {
+	return filepath.Join(configDir, "deploys", appName+".json")
+}
+
+// SavePendingDeploy records a pushed image so its release can be retried
+func SavePendingDeploy(configDir string, p *PendingDeploy) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := PendingDeployPath(configDir, p.App)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadPendingDeploy reads the pending deploy for an app, returning nil when there isn't one
+func LoadPendingDeploy(configDir string, appName string) (*PendingDeploy, error) {
+	path := PendingDeployPath(configDir, appName)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p PendingDeploy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid pending deploy %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// ClearPendingDeploy forgets the pending deploy for an app once it has been released
+func ClearPendingDeploy(configDir string, appName string) error {
+	err := os.Remove(PendingDeployPath(configDir, appName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SuspendedScale - how many VMs each of an app's process groups ran before
+// it was suspended, kept so resuming brings the same scale back
+type SuspendedScale struct {
+	App         string               `json:"app"`
+	Counts      []api.TaskGroupCount `json:"counts"`
+	SuspendedAt time.Time            `json:"suspended_at"`
+}
+
+// SuspendedScalePath returns where the scale of a suspended app is kept
+func SuspendedScalePath(configDir string, appName string) string {
+	return filepath.Join(configDir, "suspended", appName+".json")
+}
+
+// SaveSuspendedScale records an app's scale as it's suspended
+func SaveSuspendedScale(configDir string, s *SuspendedScale) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := SuspendedScalePath(configDir, s.App)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadSuspendedScale reads the scale an app was suspended with, returning nil
+// when it wasn't recorded
+func LoadSuspendedScale(configDir string, appName string) (*SuspendedScale, error) {
+	path := SuspendedScalePath(configDir, appName)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s SuspendedScale
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid suspended scale %s: %w", path, err)
+	}
+
+	return &s, nil
+}
+
+// ClearSuspendedScale forgets an app's suspended scale once it's resumed
+func ClearSuspendedScale(configDir string, appName string) error {
+	err := os.Remove(SuspendedScalePath(configDir, appName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+// Package devcert issues TLS certificates for local development, signed by a
+// CA the developer can trust once, in the style of mkcert.
+package devcert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cli/safeexec"
+)
+
+const (
+	caCertFile = "rootCA.pem"
+	caKeyFile  = "rootCA-key.pem"
+)
+
+// CA - a certificate authority used to sign development certificates
+type CA struct {
+	// CertPath - where the CA certificate is stored, for adding it to trust stores
+	CertPath string
+	cert     *x509.Certificate
+	key      interface{}
+}
+
+// LoadOrCreateCA - loads the CA stored in dir, creating one if there isn't one
+func LoadOrCreateCA(dir string) (*CA, error) {
+	ca, err := LoadCA(dir)
+	if err == nil {
+		return ca, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := createCA(dir); err != nil {
+		return nil, err
+	}
+
+	return LoadCA(dir)
+}
+
+// LoadCA - loads a CA from the rootCA.pem and rootCA-key.pem files in dir,
+// the layout mkcert uses
+func LoadCA(dir string) (*CA, error) {
+	certPath := filepath.Join(dir, caCertFile)
+
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := ioutil.ReadFile(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("%s is not a PEM certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("%s is not a PEM key", filepath.Join(dir, caKeyFile))
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{CertPath: certPath, cert: cert, key: key}, nil
+}
+
+// MkcertCA - loads the CA of a local mkcert install, which browsers already
+// trust once `mkcert -install` has been run
+func MkcertCA() (*CA, error) {
+	mkcert, err := safeexec.LookPath("mkcert")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command(mkcert, "-CAROOT").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadCA(strings.TrimSpace(string(out)))
+}
+
+func createCA(dir string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"flyctl development CA"}, CommonName: "flyctl development CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := writePEM(filepath.Join(dir, caKeyFile), "PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+	return writePEM(filepath.Join(dir, caCertFile), "CERTIFICATE", der, 0644)
+}
+
+// Issue - issues a certificate for the hosts, which can be names or IPs
+func (ca *CA) Issue(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"flyctl development certificate"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		// short lived, certificates are issued each time they're needed
+		NotAfter:    time.Now().AddDate(0, 0, 30),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+func randomSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func writePEM(path string, blockType string, der []byte, perm os.FileMode) error {
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &pem.Block{Type: blockType, Bytes: der}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), perm)
+}
@@ -0,0 +1,42 @@
+package devcert
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueVerifiesAgainstCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devcert")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, err := LoadOrCreateCA(dir)
+	require.NoError(t, err)
+
+	// a second load reuses the stored CA
+	reloaded, err := LoadOrCreateCA(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ca.cert.Raw, reloaded.cert.Raw)
+
+	cert, err := reloaded.Issue([]string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	for _, name := range []string{"localhost", "127.0.0.1"} {
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+		assert.NoError(t, err, name)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots})
+	assert.Error(t, err)
+}
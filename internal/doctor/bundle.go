@@ -0,0 +1,118 @@
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"regexp"
+	"time"
+)
+
+// BundleFile - a file in a support bundle
+type BundleFile struct {
+	Name string
+	Data []byte
+	// Redactions - how many values were hidden
+	Redactions int
+}
+
+// Bundle - diagnostic files to attach to a support request. Secrets are
+// redacted from files as they're added.
+type Bundle struct {
+	Files []*BundleFile
+}
+
+// Add redacts data and adds it to the bundle
+func (b *Bundle) Add(name string, data []byte) *BundleFile {
+	redacted, count := Redact(data)
+	file := &BundleFile{Name: name, Data: redacted, Redactions: count}
+	b.Files = append(b.Files, file)
+	return file
+}
+
+// Remove drops a file from the bundle
+func (b *Bundle) Remove(name string) {
+	files := b.Files[:0]
+	for _, file := range b.Files {
+		if file.Name != name {
+			files = append(files, file)
+		}
+	}
+	b.Files = files
+}
+
+// Write writes the bundle as a gzipped tarball
+func (b *Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	for _, file := range b.Files {
+		header := &tar.Header{
+			Name:    file.Name,
+			Mode:    0600,
+			Size:    int64(len(file.Data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.Data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	// key: value, key = "value" and KEY=value, where the key names a credential
+	sensitiveValue = regexp.MustCompile(`(?im)^(\s*["']?[\w.-]*(token|secret|password|passwd|private|privatekey|api_?key|credentials?)[\w.-]*["']?\s*[:=]\s*)(.+)$`)
+	// credentials in URLs
+	urlPassword = regexp.MustCompile(`([a-z][a-z0-9+.-]*://[^:/@\s]+:)([^@\s]+)(@)`)
+	// bearer tokens, such as in logged requests
+	bearerToken = regexp.MustCompile(`(?i)(bearer\s+)([\w.~+/=-]+)`)
+
+	alreadyRedacted = regexp.MustCompile(`^["']?\[REDACTED\]["']?$`)
+)
+
+// Redact hides values that look like secrets, returning how many it hid
+func Redact(data []byte) ([]byte, int) {
+	count := 0
+
+	data = sensitiveValue.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := sensitiveValue.FindSubmatch(match)
+		if isRedacted(groups[3]) {
+			return match
+		}
+		count++
+		return append(append([]byte{}, groups[1]...), redacted...)
+	})
+
+	for _, pattern := range []*regexp.Regexp{urlPassword, bearerToken} {
+		data = pattern.ReplaceAllFunc(data, func(match []byte) []byte {
+			groups := pattern.FindSubmatch(match)
+			if isRedacted(groups[2]) {
+				return match
+			}
+			count++
+			out := append([]byte{}, groups[1]...)
+			out = append(out, redacted...)
+			if len(groups) > 3 {
+				out = append(out, groups[3]...)
+			}
+			return out
+		})
+	}
+
+	return data, count
+}
+
+func isRedacted(value []byte) bool {
+	return alreadyRedacted.Match(value)
+}
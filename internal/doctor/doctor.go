@@ -0,0 +1,178 @@
+// Package doctor runs diagnostic checks of everything flyctl depends on, so
+// problems can be narrowed down to the local machine, the network or Fly, and
+// reported with a hint on how to fix them.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status - the outcome of a check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Check - a named diagnostic. Run returns a short description of what was
+// found, or an error, which may be a *Problem with a remediation hint.
+type Check struct {
+	Name string
+	// Requires - checks that must pass before this one can run
+	Requires []string
+	Run      func(ctx context.Context) (string, error)
+}
+
+// Problem - a failed or degraded check, with a hint on how to fix it
+type Problem struct {
+	Err     error
+	Hint    string
+	Warning bool
+}
+
+func (p *Problem) Error() string {
+	return p.Err.Error()
+}
+
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// Fail - a failed check with a remediation hint
+func Fail(err error, hint string) error {
+	return &Problem{Err: err, Hint: hint}
+}
+
+// Warn - a check that passed with a problem worth fixing
+func Warn(err error, hint string) error {
+	return &Problem{Err: err, Hint: hint, Warning: true}
+}
+
+// Skipped - returned by checks that don't apply, such as app checks when
+// there's no app
+type Skipped struct {
+	Reason string
+}
+
+func (s *Skipped) Error() string {
+	return s.Reason
+}
+
+// Skip - a check that doesn't apply
+func Skip(format string, args ...interface{}) error {
+	return &Skipped{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Result - the outcome of running a check
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Message  string        `json:"message"`
+	Hint     string        `json:"hint,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report - the results of every check, suitable for attaching to support tickets
+type Report struct {
+	Version   string    `json:"version"`
+	Platform  string    `json:"platform"`
+	StartedAt time.Time `json:"started_at"`
+	Results   []Result  `json:"results"`
+}
+
+// Passed is true if no check failed
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Count - the number of results with a status
+func (r *Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Run runs checks in order, skipping those whose requirements didn't pass.
+// Each check is given timeout to finish, and onResult is called as each
+// finishes so progress can be shown.
+func Run(ctx context.Context, checks []Check, timeout time.Duration, onResult func(Result)) []Result {
+	results := []Result{}
+	passed := map[string]bool{}
+
+	for _, check := range checks {
+		result := runCheck(ctx, check, passed, timeout)
+		passed[check.Name] = result.Status == StatusPass || result.Status == StatusWarn
+
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+
+	return results
+}
+
+func runCheck(ctx context.Context, check Check, passed map[string]bool, timeout time.Duration) Result {
+	result := Result{Name: check.Name}
+
+	for _, name := range check.Requires {
+		if !passed[name] {
+			result.Status = StatusSkip
+			result.Message = fmt.Sprintf("requires %s", name)
+			return result
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	message, err := check.Run(ctx)
+	result.Duration = time.Since(start).Round(time.Millisecond)
+
+	if err == nil {
+		result.Status = StatusPass
+		result.Message = message
+		return result
+	}
+
+	var skipped *Skipped
+	var problem *Problem
+
+	switch {
+	case errors.As(err, &skipped):
+		result.Status = StatusSkip
+		result.Message = skipped.Reason
+	case errors.As(err, &problem):
+		result.Status = StatusFail
+		if problem.Warning {
+			result.Status = StatusWarn
+		}
+		result.Message = problem.Error()
+		result.Hint = problem.Hint
+	default:
+		result.Status = StatusFail
+		result.Message = err.Error()
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Message = fmt.Sprintf("timed out after %s: %s", timeout, result.Message)
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "docker", Run: func(ctx context.Context) (string, error) {
+			return "", Warn(errors.New("not running"), "start it")
+		}},
+		{Name: "api", Run: func(ctx context.Context) (string, error) {
+			return "reachable", nil
+		}},
+		{Name: "token", Requires: []string{"api"}, Run: func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("checking: %w", Fail(errors.New("expired"), "log in"))
+		}},
+		{Name: "wireguard", Requires: []string{"token"}, Run: func(ctx context.Context) (string, error) {
+			t.Fatal("ran a check whose requirement failed")
+			return "", nil
+		}},
+		{Name: "builder", Requires: []string{"docker"}, Run: func(ctx context.Context) (string, error) {
+			return "", Skip("no %s", "org")
+		}},
+		{Name: "dns", Run: func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}},
+	}
+
+	shown := 0
+	results := Run(context.Background(), checks, 10*time.Millisecond, func(Result) { shown++ })
+	assert.Equal(t, len(checks), shown)
+
+	statuses := []Status{}
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []Status{StatusWarn, StatusPass, StatusFail, StatusSkip, StatusSkip, StatusFail}, statuses)
+
+	assert.Equal(t, "start it", results[0].Hint)
+	assert.Equal(t, "expired", results[2].Message)
+	assert.Equal(t, "log in", results[2].Hint)
+	assert.Equal(t, "requires token", results[3].Message)
+	assert.Equal(t, "no org", results[4].Message)
+	assert.Contains(t, results[5].Message, "timed out after 10ms")
+
+	report := Report{Results: results}
+	assert.False(t, report.Passed())
+	assert.Equal(t, 2, report.Count(StatusFail))
+}
@@ -0,0 +1,180 @@
+// Package flyerr attaches stable, machine readable codes to the errors
+// surfaced by flyctl, so wrappers can branch on the kind of failure through
+// --json output and exit codes rather than by matching messages.
+//
+// Codes are attached where a failure is recognized: by the commands and
+// build steps that set one with New or Wrap, and by CodeOf for API errors,
+// flag errors and cancellation. Every other error is FLY_UNKNOWN, exit code
+// 1, as all errors were before codes were added. Interrupted commands and
+// declined confirmations exit with 130 rather than 1.
+package flyerr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// Code - identifies a kind of failure. Codes are part of flyctl's interface
+// and must not be renamed or reused once released.
+type Code string
+
+const (
+	Unknown              Code = "FLY_UNKNOWN"
+	Cancelled            Code = "FLY_CANCELLED"
+	InvalidArgument      Code = "FLY_INVALID_ARGUMENT"
+	Unauthorized         Code = "FLY_UNAUTHORIZED"
+	NotFound             Code = "FLY_NOT_FOUND"
+	Timeout              Code = "FLY_TIMEOUT"
+	InvalidConfig        Code = "FLY_INVALID_CONFIG"
+	ServerError          Code = "FLY_SERVER_ERROR"
+	NameTaken            Code = "FLY_NAME_TAKEN"
+	InvalidName          Code = "FLY_INVALID_NAME"
+	DockerUnavailable    Code = "FLY_DOCKER_UNAVAILABLE"
+	BuildFailed          Code = "FLY_BUILD_FAILED"
+	BuildTimeout         Code = "FLY_BUILD_TIMEOUT"
+	UnauthorizedBuilder  Code = "FLY_UNAUTHORIZED_BUILDER"
+	SignatureInvalid     Code = "FLY_SIGNATURE_INVALID"
+	PromptRequired       Code = "FLY_PROMPT_REQUIRED"
+	ReleaseCommandFailed Code = "FLY_RELEASE_COMMAND_FAILED"
+	DeployFailed         Code = "FLY_DEPLOY_FAILED"
+	HealthcheckFailed    Code = "FLY_HEALTHCHECK_FAILED"
+	PostDeployFailed     Code = "FLY_POST_DEPLOY_FAILED"
+)
+
+// Entry - a code in the catalog, with the exit code flyctl terminates with
+type Entry struct {
+	Code        Code
+	ExitCode    int
+	Description string
+}
+
+var catalog = []Entry{
+	{Unknown, 1, "an error flyctl doesn't yet have a more specific code for"},
+	{InvalidArgument, 2, "invalid command line arguments or flags"},
+	{Unauthorized, 3, "not logged in, or the access token was rejected"},
+	{NotFound, 4, "the app or resource does not exist"},
+	{Timeout, 5, "an operation did not finish in time"},
+	{InvalidConfig, 6, "the app configuration is invalid"},
+	{ServerError, 7, "the Fly API failed to handle the request"},
+	{NameTaken, 8, "the name is already in use"},
+	{InvalidName, 9, "the name isn't allowed, such as an app name that isn't a valid hostname"},
+	{DockerUnavailable, 10, "no local or remote docker daemon is available to build with"},
+	{BuildFailed, 11, "the image failed to build"},
+	{BuildTimeout, 12, "the remote builder did not become available in time"},
+	{UnauthorizedBuilder, 13, "the builder is not authorized to push the image"},
+	{SignatureInvalid, 14, "the image isn't signed with the key given to verify it"},
+	{PromptRequired, 15, "an answer was needed, but flyctl can't prompt in CI or without a terminal"},
+	{ReleaseCommandFailed, 20, "the release command failed, so the release was aborted"},
+	{DeployFailed, 21, "the deployment failed"},
+	{HealthcheckFailed, 22, "the deployment failed because instances' health checks did not pass"},
+	{PostDeployFailed, 23, "the post-deploy hook failed after the release was deployed"},
+	{Cancelled, 130, "the command was interrupted or a confirmation was declined"},
+}
+
+// Catalog - every code flyctl reports
+func Catalog() []Entry {
+	return append([]Entry(nil), catalog...)
+}
+
+// Error - an error with a code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New - creates an error with a code
+func New(code Code, message string) error {
+	return &Error{Code: code, Err: errors.New(message)}
+}
+
+// Wrap - attaches a code to err. Errors that already carry a code keep it, so
+// the code set closest to the failure wins.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err
+	}
+
+	return &Error{Code: code, Err: err}
+}
+
+// CodeOf - the code of err, classifying API and context errors that weren't
+// given one explicitly
+func CodeOf(err error) Code {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Cancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, api.ErrNotFound):
+		return NotFound
+	}
+
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Status == 401 || apiErr.Status == 403:
+			return Unauthorized
+		case apiErr.Status == 404:
+			return NotFound
+		case apiErr.Status >= 500:
+			return ServerError
+		}
+	}
+
+	return Unknown
+}
+
+// ExitCode - the process exit code for err
+func ExitCode(err error) int {
+	code := CodeOf(err)
+	for _, entry := range catalog {
+		if entry.Code == code {
+			return entry.ExitCode
+		}
+	}
+	return 1
+}
+
+// Report - how an error is written as JSON
+type Report struct {
+	Code     Code
+	Message  string
+	ExitCode int
+}
+
+// WriteJSON - writes err as a Report
+func WriteJSON(w io.Writer, err error) error {
+	out, jsonErr := json.MarshalIndent(Report{
+		Code:     CodeOf(err),
+		Message:  err.Error(),
+		ExitCode: ExitCode(err),
+	}, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	_, jsonErr = w.Write(append(out, '\n'))
+	return jsonErr
+}
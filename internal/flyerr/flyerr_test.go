@@ -0,0 +1,60 @@
+package flyerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestCatalogIsUnique(t *testing.T) {
+	codes := map[Code]bool{}
+	exitCodes := map[int]bool{}
+
+	for _, entry := range Catalog() {
+		assert.False(t, codes[entry.Code], "duplicate code %s", entry.Code)
+		assert.False(t, exitCodes[entry.ExitCode], "duplicate exit code %d", entry.ExitCode)
+		codes[entry.Code] = true
+		exitCodes[entry.ExitCode] = true
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	cases := map[error]Code{
+		errors.New("boom"): Unknown,
+		New(BuildTimeout, "remote builder app unavailable"):   BuildTimeout,
+		fmt.Errorf("deploy: %w", New(HealthcheckFailed, "x")): HealthcheckFailed,
+		fmt.Errorf("wrapped: %w", context.Canceled):           Cancelled,
+		context.DeadlineExceeded:                              Timeout,
+		api.ErrNotFound:                                       NotFound,
+		&api.ApiError{Status: 401}:                            Unauthorized,
+		&api.ApiError{Status: 502}:                            ServerError,
+	}
+
+	for err, want := range cases {
+		assert.Equal(t, want, CodeOf(err), err.Error())
+	}
+}
+
+func TestWrapKeepsInnermostCode(t *testing.T) {
+	err := Wrap(BuildFailed, New(UnauthorizedBuilder, "denied"))
+	assert.Equal(t, UnauthorizedBuilder, CodeOf(err))
+
+	assert.Nil(t, Wrap(BuildFailed, nil))
+	assert.Equal(t, 11, ExitCode(Wrap(BuildFailed, errors.New("failed"))))
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, New(HealthcheckFailed, "v3 failed")))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, Report{Code: HealthcheckFailed, Message: "v3 failed", ExitCode: 22}, report)
+}
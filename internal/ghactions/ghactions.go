@@ -0,0 +1,256 @@
+// Package ghactions writes GitHub Actions workflow commands, so deploys run in
+// a workflow have grouped logs, annotated errors and step outputs.
+package ghactions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Enabled is true when running in a GitHub Actions workflow
+func Enabled() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// StartGroup starts a collapsible group of log lines titled title
+func StartGroup(w io.Writer, title string) {
+	if Enabled() {
+		fmt.Fprintf(w, "::group::%s\n", escapeData(title))
+	}
+}
+
+// EndGroup ends the group started by StartGroup
+func EndGroup(w io.Writer) {
+	if Enabled() {
+		fmt.Fprintln(w, "::endgroup::")
+	}
+}
+
+// Annotation - an error shown on a workflow run's summary, and on the line of
+// File when it's in the commit
+type Annotation struct {
+	Title   string
+	File    string
+	Line    int
+	Message string
+}
+
+// Error writes an error annotation
+func Error(w io.Writer, a Annotation) {
+	if !Enabled() {
+		return
+	}
+
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeProperty(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.Line))
+		}
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeProperty(a.Title))
+	}
+
+	command := "::error"
+	if len(props) > 0 {
+		command += " " + strings.Join(props, ",")
+	}
+	fmt.Fprintf(w, "%s::%s\n", command, escapeData(a.Message))
+}
+
+// WorkspacePath returns path relative to the checked out repository, as
+// annotations expect, or path itself when it's outside of it
+func WorkspacePath(path string) string {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(workspace, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// AnnotatedError - an error already written as annotations, so it isn't
+// annotated again when it's reported
+type AnnotatedError struct {
+	Err error
+}
+
+func (e *AnnotatedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AnnotatedError) Unwrap() error {
+	return e.Err
+}
+
+// IsAnnotated is true for errors wrapped in an AnnotatedError
+func IsAnnotated(err error) bool {
+	var annotated *AnnotatedError
+	return errors.As(err, &annotated)
+}
+
+// SetOutputs appends outputs to the file in $GITHUB_OUTPUT, for later steps
+// of the job to read. It does nothing outside of a workflow.
+func SetOutputs(outputs map[string]string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if !Enabled() || path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeOutputs(f, outputs)
+}
+
+func writeOutputs(w io.Writer, outputs map[string]string) error {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := outputs[name]
+		if !strings.ContainsAny(value, "\r\n") {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", name, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		delimiter, err := randomDelimiter()
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func randomDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
+
+var keyPathPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\[\d+\])?(?:\.[A-Za-z_][A-Za-z0-9_]*(?:\[\d+\])?)*`)
+
+var keyPathComponent = regexp.MustCompile(`^([A-Za-z0-9_]+)(?:\[(\d+)\])?$`)
+
+// ConfigLine returns the line of config that message refers to by a key
+// path such as services[0].internal_port or kill_timeout, or 0 when none of
+// the keys it mentions are in config
+func ConfigLine(config string, message string) int {
+	lines := strings.Split(config, "\n")
+	for _, path := range keyPathPattern.FindAllString(message, -1) {
+		if !strings.ContainsAny(path, "._[") {
+			continue
+		}
+		if line := keyLine(lines, path); line > 0 {
+			return line
+		}
+	}
+	return 0
+}
+
+// keyLine finds each component of path in turn, as a table or a key of the
+// table found before it, returning the line of the deepest one found
+func keyLine(lines []string, path string) int {
+	line := 0
+	start := 0
+	var names []string
+
+	for i, part := range strings.Split(path, ".") {
+		match := keyPathComponent.FindStringSubmatch(part)
+		if match == nil {
+			break
+		}
+		parent := strings.Join(names, ".")
+		names = append(names, match[1])
+		table := strings.Join(names, ".")
+
+		index := 0
+		fmt.Sscan(match[2], &index)
+
+		if header := findTable(lines, start, table, index); header >= 0 {
+			line = header + 1
+			start = header + 1
+			continue
+		}
+
+		if key := findKey(lines, start, i == 0, parent, match[1]); key >= 0 {
+			return key + 1
+		}
+		break
+	}
+
+	return line
+}
+
+// findTable returns the index of the line of the index-th [table] or
+// [[table]] header from start, or -1
+func findTable(lines []string, start int, table string, index int) int {
+	seen := 0
+	for i := start; i < len(lines); i++ {
+		header := strings.TrimSpace(lines[i])
+		if header == "["+table+"]" || header == "[["+table+"]]" {
+			if seen == index {
+				return i
+			}
+			seen++
+		}
+	}
+	return -1
+}
+
+// findKey returns the index of the line setting key from start until the
+// table ends, or -1. Top level keys end at the first header.
+func findKey(lines []string, start int, topLevel bool, parent string, key string) int {
+	for i := start; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, "[") {
+			header := strings.Trim(text, "[]")
+			if topLevel || !strings.HasPrefix(header, parent+".") {
+				return -1
+			}
+			continue
+		}
+		if name := strings.TrimSpace(strings.SplitN(text, "=", 2)[0]); name == key && strings.Contains(text, "=") {
+			return i
+		}
+	}
+	return -1
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,71 @@
+package ghactions
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const flyToml = `app = "test-app"
+kill_timeout = 5
+
+[env]
+  PORT = "8080"
+
+[[services]]
+  internal_port = 8080
+  protocol = "tcp"
+
+  [[services.ports]]
+    handlers = ["http"]
+    port = 80
+
+[[services]]
+  internal_port = 9090
+  protocol = "udp"
+`
+
+func TestConfigLine(t *testing.T) {
+	assert.Equal(t, 2, ConfigLine(flyToml, "kill_timeout must be a number"))
+	assert.Equal(t, 5, ConfigLine(flyToml, "env.PORT is reserved"))
+	assert.Equal(t, 8, ConfigLine(flyToml, "services[0].internal_port isn't set"))
+	assert.Equal(t, 16, ConfigLine(flyToml, "services[1].internal_port isn't set"))
+	assert.Equal(t, 11, ConfigLine(flyToml, "services[0].ports: expected a list of sections"))
+	assert.Equal(t, 15, ConfigLine(flyToml, "services[1] has an unknown key"))
+	assert.Equal(t, 0, ConfigLine(flyToml, "statics[0].guest_path must be absolute"))
+}
+
+func TestConfigLineIgnoresTopLevelKeysInTables(t *testing.T) {
+	assert.Equal(t, 0, ConfigLine(flyToml, "internal_port isn't set"))
+}
+
+func TestErrorEscapesMessage(t *testing.T) {
+	os.Setenv("GITHUB_ACTIONS", "true")
+	defer os.Unsetenv("GITHUB_ACTIONS")
+
+	var out bytes.Buffer
+	Error(&out, Annotation{Title: "flyctl: deploy", File: "fly.toml", Line: 8, Message: "100% broken\nsee above"})
+	assert.Equal(t, "::error file=fly.toml,line=8,title=flyctl%3A deploy::100%25 broken%0Asee above\n", out.String())
+}
+
+func TestCommandsOutsideActions(t *testing.T) {
+	os.Unsetenv("GITHUB_ACTIONS")
+
+	var out bytes.Buffer
+	StartGroup(&out, "Building image")
+	Error(&out, Annotation{Message: "failed"})
+	EndGroup(&out)
+	assert.Empty(t, out.String())
+}
+
+func TestWriteOutputs(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, writeOutputs(&out, map[string]string{"release_version": "3", "notes": "a\nb"}))
+
+	lines := strings.Split(out.String(), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "notes<<ghadelimiter_"))
+	assert.Equal(t, []string{"a", "b", strings.TrimPrefix(lines[0], "notes<<"), "release_version=3", ""}, lines[1:])
+}
@@ -0,0 +1,196 @@
+package gitinfo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/cli/safeexec"
+)
+
+// ErrNotRepository - Error returned when a directory is not inside a git work tree
+var ErrNotRepository = errors.New("not a git repository")
+
+// Info - the state of a git work tree
+type Info struct {
+	SHA    string
+	Branch string
+	Dirty  bool
+}
+
+// Current returns the commit, branch and dirty state of the work tree containing dir
+func Current(dir string) (*Info, error) {
+	run, err := runner(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+
+	if info.SHA, err = run("rev-parse", "HEAD"); err != nil {
+		return nil, err
+	}
+
+	if branch, err := run("rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+
+	if status, err := run("status", "--porcelain"); err == nil {
+		info.Dirty = status != ""
+	}
+
+	return info, nil
+}
+
+// ErrUnknownCommit - Error returned when a commit isn't in the repository,
+// such as in a shallow clone
+var ErrUnknownCommit = errors.New("commit not found in the repository")
+
+// ChangedFiles lists the files matching paths, git pathspecs relative to dir,
+// which differ in the work tree from commit sha. Uncommitted and untracked
+// files count as changes.
+func ChangedFiles(dir string, sha string, paths []string) ([]string, error) {
+	run, err := runner(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := run("cat-file", "-e", sha+"^{commit}"); err != nil {
+		return nil, ErrUnknownCommit
+	}
+
+	diff, err := run(append([]string{"diff", "--name-only", "--relative", sha, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := run(append([]string{"ls-files", "--others", "--exclude-standard", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, out := range []string{diff, untracked} {
+		for _, line := range strings.Split(out, "\n") {
+			if line != "" {
+				changed = append(changed, line)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// CommitFiles commits files, keyed by their slash separated path in the
+// repository, to branch on top of what the branch already holds, creating it
+// if need be. The work tree, index and checked out branch aren't touched.
+// Returns the new commit.
+func CommitFiles(dir string, branch string, files map[string][]byte, message string) (string, error) {
+	if _, err := runner(dir); err != nil {
+		return "", err
+	}
+	gitExe, err := safeexec.LookPath("git")
+	if err != nil {
+		return "", err
+	}
+
+	index, err := ioutil.TempFile("", "flyctl-index")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	os.Remove(index.Name())
+	defer os.Remove(index.Name())
+
+	run := func(stdin []byte, args ...string) (string, error) {
+		cmd := exec.Command(gitExe, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index.Name())
+		if stdin != nil {
+			cmd.Stdin = bytes.NewReader(stdin)
+		}
+		out, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return strings.TrimSpace(string(out)), err
+	}
+
+	ref := "refs/heads/" + branch
+	if _, err := run(nil, "check-ref-format", ref); err != nil {
+		return "", fmt.Errorf("invalid branch name %q", branch)
+	}
+
+	parent, err := run(nil, "rev-parse", "--verify", "-q", ref+"^{commit}")
+	if err != nil {
+		parent = ""
+		_, err = run(nil, "read-tree", "--empty")
+	} else {
+		_, err = run(nil, "read-tree", parent)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		blob, err := run(files[p], "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", err
+		}
+		if _, err := run(nil, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+p); err != nil {
+			return "", err
+		}
+	}
+
+	tree, err := run(nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	commitArgs := []string{"commit-tree", tree, "-m", message}
+	if parent != "" {
+		commitArgs = append(commitArgs, "-p", parent)
+	}
+	commit, err := run(nil, commitArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	// passing the old value fails the update if the branch moved meanwhile
+	if _, err := run(nil, "update-ref", ref, commit, parent); err != nil {
+		return "", err
+	}
+
+	return commit, nil
+}
+
+// runner returns a function running git in dir, erroring when dir isn't
+// inside a work tree
+func runner(dir string) (func(args ...string) (string, error), error) {
+	gitExe, err := safeexec.LookPath("git")
+	if err != nil {
+		return nil, err
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(gitExe, args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	if inside, err := run("rev-parse", "--is-inside-work-tree"); err != nil || inside != "true" {
+		return nil, ErrNotRepository
+	}
+
+	return run, nil
+}
@@ -0,0 +1,123 @@
+package gitinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitRepo(t *testing.T) (string, func(args ...string)) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "gitinfo")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+
+	return dir, git
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir, git := gitRepo(t)
+
+	writeFile(t, filepath.Join(dir, "api", "main.go"), "package main")
+	writeFile(t, filepath.Join(dir, "web", "index.html"), "<html>")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	info, err := Current(dir)
+	require.NoError(t, err)
+	assert.False(t, info.Dirty)
+
+	changed, err := ChangedFiles(dir, info.SHA, []string{"api"})
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	writeFile(t, filepath.Join(dir, "web", "index.html"), "<html><body>")
+	changed, err = ChangedFiles(dir, info.SHA, []string{"api"})
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	writeFile(t, filepath.Join(dir, "api", "handler.go"), "package main")
+	changed, err = ChangedFiles(dir, info.SHA, []string{"api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api/handler.go"}, changed)
+
+	git("add", ".")
+	git("commit", "-q", "-m", "handler")
+
+	changed, err = ChangedFiles(filepath.Join(dir, "api"), info.SHA, []string{"."})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"handler.go"}, changed)
+
+	_, err = ChangedFiles(dir, "0123456789abcdef0123456789abcdef01234567", []string{"api"})
+	assert.Equal(t, ErrUnknownCommit, err)
+}
+
+func TestNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "gitinfo")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = Current(dir)
+	assert.Equal(t, ErrNotRepository, err)
+}
+
+func TestCommitFiles(t *testing.T) {
+	dir, git := gitRepo(t)
+
+	writeFile(t, filepath.Join(dir, "fly.toml"), "app = \"web\"")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+
+	first, err := CommitFiles(dir, "deployments", map[string][]byte{"web-v1.json": []byte("1")}, "Deploy web v1")
+	require.NoError(t, err)
+
+	second, err := CommitFiles(dir, "deployments", map[string][]byte{"apps/web-v2.json": []byte("2")}, "Deploy web v2")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	show := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	assert.Equal(t, first, show("rev-parse", "deployments^"))
+	assert.Equal(t, "apps/web-v2.json\nweb-v1.json", show("ls-tree", "-r", "--name-only", "deployments"))
+
+	// the checked out branch and work tree are left alone
+	info, err := Current(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, "deployments", info.Branch)
+	assert.False(t, info.Dirty)
+
+	_, err = CommitFiles(dir, "bad..branch", map[string][]byte{"x.json": nil}, "x")
+	assert.Error(t, err)
+}
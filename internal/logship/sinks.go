@@ -0,0 +1,154 @@
+package logship
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ShipperImage is the image deployed as the log shipper app. It runs vector
+// with sinks enabled by the presence of their secrets.
+const ShipperImage = "flyio/log-shipper:latest"
+
+// ShipperAppName returns the name of the log shipper app for an app
+func ShipperAppName(appName string) string {
+	return appName + "-log-shipper"
+}
+
+// Subject returns the NATS subject the shipper subscribes to for an app's logs
+func Subject(appName string) string {
+	return fmt.Sprintf("logs.%s.>", appName)
+}
+
+// Setting is a secret consumed by the shipper to configure a sink
+type Setting struct {
+	Name        string
+	Description string
+	Required    bool
+	Sensitive   bool
+}
+
+// Sink is a log shipping destination supported by the shipper image
+type Sink struct {
+	Name        string
+	Description string
+	Settings    []Setting
+}
+
+var sinks = []Sink{
+	{
+		Name:        "datadog",
+		Description: "Datadog logs",
+		Settings: []Setting{
+			{Name: "DATADOG_API_KEY", Description: "Datadog API key", Required: true, Sensitive: true},
+			{Name: "DATADOG_SITE", Description: "Datadog site, e.g. datadoghq.eu"},
+		},
+	},
+	{
+		Name:        "logtail",
+		Description: "Logtail",
+		Settings: []Setting{
+			{Name: "LOGTAIL_TOKEN", Description: "Logtail source token", Required: true, Sensitive: true},
+		},
+	},
+	{
+		Name:        "s3",
+		Description: "AWS S3 bucket",
+		Settings: []Setting{
+			{Name: "AWS_ACCESS_KEY_ID", Description: "AWS access key ID", Required: true},
+			{Name: "AWS_SECRET_ACCESS_KEY", Description: "AWS secret access key", Required: true, Sensitive: true},
+			{Name: "AWS_BUCKET", Description: "S3 bucket name", Required: true},
+			{Name: "AWS_REGION", Description: "S3 bucket region", Required: true},
+			{Name: "S3_ENDPOINT", Description: "Custom S3 compatible endpoint"},
+		},
+	},
+	{
+		Name:        "http",
+		Description: "Generic HTTP endpoint",
+		Settings: []Setting{
+			{Name: "HTTP_URL", Description: "URL logs are POSTed to", Required: true},
+			{Name: "HTTP_TOKEN", Description: "Bearer token sent with each request", Sensitive: true},
+		},
+	},
+}
+
+// Sinks returns all supported sinks sorted by name
+func Sinks() []Sink {
+	out := make([]Sink, len(sinks))
+	copy(out, sinks)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// SinkNames returns the names of all supported sinks
+func SinkNames() []string {
+	names := []string{}
+	for _, s := range Sinks() {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// FindSink returns the sink with the given name
+func FindSink(name string) (*Sink, error) {
+	for _, s := range sinks {
+		if s.Name == strings.ToLower(name) {
+			sink := s
+			return &sink, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown sink %q, must be one of %s", name, strings.Join(SinkNames(), ", "))
+}
+
+// SettingNames returns the secret names used by the sink
+func (s Sink) SettingNames() []string {
+	names := []string{}
+	for _, setting := range s.Settings {
+		names = append(names, setting.Name)
+	}
+	return names
+}
+
+// Validate checks values only contains settings known to the sink and that all
+// required settings are present
+func (s Sink) Validate(values map[string]string) error {
+	known := map[string]bool{}
+	for _, setting := range s.Settings {
+		known[setting.Name] = true
+		if setting.Required && values[setting.Name] == "" {
+			return fmt.Errorf("%s sink requires %s", s.Name, setting.Name)
+		}
+	}
+
+	for k := range values {
+		if !known[k] {
+			return fmt.Errorf("%s is not a setting of the %s sink, valid settings are %s", k, s.Name, strings.Join(s.SettingNames(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// ConfiguredSinks returns the sinks whose required settings are all present in
+// the given secret names
+func ConfiguredSinks(secretNames []string) []Sink {
+	present := map[string]bool{}
+	for _, name := range secretNames {
+		present[name] = true
+	}
+
+	out := []Sink{}
+	for _, s := range Sinks() {
+		configured := true
+		for _, setting := range s.Settings {
+			if setting.Required && !present[setting.Name] {
+				configured = false
+				break
+			}
+		}
+		if configured {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package logship
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindSink(t *testing.T) {
+	sink, err := FindSink("Datadog")
+	assert.NoError(t, err)
+	assert.Equal(t, "datadog", sink.Name)
+
+	_, err = FindSink("papertrail")
+	assert.Error(t, err)
+}
+
+func TestValidateSink(t *testing.T) {
+	sink, err := FindSink("http")
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Validate(map[string]string{"HTTP_URL": "https://example.com"}))
+	assert.Error(t, sink.Validate(map[string]string{"HTTP_TOKEN": "secret"}))
+	assert.Error(t, sink.Validate(map[string]string{"HTTP_URL": "https://example.com", "LOGTAIL_TOKEN": "x"}))
+}
+
+func TestConfiguredSinks(t *testing.T) {
+	configured := ConfiguredSinks([]string{"ORG", "ACCESS_TOKEN", "LOGTAIL_TOKEN", "AWS_BUCKET"})
+	assert.Len(t, configured, 1)
+	assert.Equal(t, "logtail", configured[0].Name)
+}
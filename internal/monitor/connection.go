@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// Gap - a period the API couldn't be reached while following an app
+type Gap struct {
+	Start time.Time
+	End   time.Time
+	Err   error
+}
+
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start).Round(time.Second)
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("connection lost for %s (%s to %s)", g.Duration(), g.Start.UTC().Format("15:04:05"), g.End.UTC().Format("15:04:05"))
+}
+
+// ConnectionTracker - follows whether polling the API is failing, so commands
+// that follow an app can keep retrying through network drops and mark the gap
+// once they're back, rather than exiting or skipping over it
+type ConnectionTracker struct {
+	offlineSince time.Time
+	lastErr      error
+}
+
+// Failed records a failed poll, returning true if the connection was up until now
+func (t *ConnectionTracker) Failed(err error, at time.Time) bool {
+	t.lastErr = err
+	if !t.offlineSince.IsZero() {
+		return false
+	}
+	t.offlineSince = at
+	return true
+}
+
+// Recovered records a successful poll, returning the gap it ends if the
+// connection was down
+func (t *ConnectionTracker) Recovered(at time.Time) *Gap {
+	if t.offlineSince.IsZero() {
+		return nil
+	}
+
+	gap := &Gap{Start: t.offlineSince, End: at, Err: t.lastErr}
+	t.offlineSince = time.Time{}
+	t.lastErr = nil
+	return gap
+}
+
+// Offline - whether the last poll failed, and since when
+func (t *ConnectionTracker) Offline() (bool, time.Time) {
+	return !t.offlineSince.IsZero(), t.offlineSince
+}
+
+// IsFatalError is true for errors retrying won't fix
+func IsFatalError(err error) bool {
+	return api.IsNotAuthenticatedError(err) || api.IsNotFoundError(err)
+}
@@ -2,10 +2,12 @@ package monitor
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
 	"github.com/jpillora/backoff"
+	"github.com/logrusorgru/aurora"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/cmd/presenters"
 	"github.com/superfly/flyctl/cmdctx"
@@ -16,14 +18,22 @@ type LogOptions struct {
 	W io.Writer
 
 	MaxBackoff time.Duration
+	// MaxOutage - how long the API can be unreachable before following stops,
+	// defaultMaxOutage when zero
+	MaxOutage  time.Duration
 	AppName    string
 	VMID       string
 	RegionCode string
 }
 
-func WatchLogs(cc *cmdctx.CmdContext, w io.Writer, opts LogOptions) error {
-	errorCount := 0
+// defaultMaxOutage - how long WatchLogs retries an unreachable API for
+const defaultMaxOutage = 10 * time.Minute
 
+// WatchLogs follows an app's logs until ctx is done. When the API can't be
+// reached it keeps retrying, backing off, for up to MaxOutage, and once it's
+// back the entries logged in the meantime are backfilled from where the
+// stream left off after a marker for the gap.
+func WatchLogs(ctx context.Context, cc *cmdctx.CmdContext, w io.Writer, opts LogOptions) error {
 	b := &backoff.Backoff{
 		Min:    250 * time.Millisecond,
 		Max:    5 * time.Second,
@@ -34,8 +44,22 @@ func WatchLogs(cc *cmdctx.CmdContext, w io.Writer, opts LogOptions) error {
 	if opts.MaxBackoff != 0 {
 		b.Max = opts.MaxBackoff
 	}
+	if opts.MaxOutage == 0 {
+		opts.MaxOutage = defaultMaxOutage
+	}
+
+	// wait is false once ctx is done
+	wait := func(d time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
 
 	nextToken := ""
+	connection := &ConnectionTracker{}
 
 	logPresenter := presenters.LogPresenter{}
 
@@ -44,22 +68,29 @@ func WatchLogs(cc *cmdctx.CmdContext, w io.Writer, opts LogOptions) error {
 
 		if err != nil {
 			terminal.Debugf("error getting app logs: %v\n", err)
-			if api.IsNotAuthenticatedError(err) {
+			if IsFatalError(err) {
 				return err
-			} else if api.IsNotFoundError(err) {
-				return err
-			} else {
-				errorCount++
-				if errorCount > 10 {
-					return err
-				}
-				time.Sleep(b.Duration())
 			}
+			if connection.Failed(err, time.Now()) {
+				fmt.Fprintln(w, aurora.Faint(fmt.Sprintf("--- connection lost at %s, retrying: %s ---", time.Now().UTC().Format("15:04:05"), err)))
+			}
+			if _, since := connection.Offline(); time.Since(since) > opts.MaxOutage {
+				return fmt.Errorf("no connection to the API for %s, giving up: %w", opts.MaxOutage, err)
+			}
+			if !wait(b.Duration()) {
+				return nil
+			}
+			continue
+		}
+
+		if gap := connection.Recovered(time.Now()); gap != nil {
+			fmt.Fprintln(w, aurora.Faint(fmt.Sprintf("--- reconnected, %s. Entries logged since follow ---", gap)))
 		}
-		errorCount = 0
 
 		if len(entries) == 0 {
-			time.Sleep(b.Duration())
+			if !wait(b.Duration()) {
+				return nil
+			}
 		} else {
 			b.Reset()
 
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// StatusChange - a change to an allocation observed between two status snapshots
+type StatusChange struct {
+	Time    time.Time
+	AllocID string
+	Field   string
+	From    string
+	To      string
+}
+
+func (c StatusChange) String() string {
+	switch {
+	case c.AllocID == "":
+		return c.To
+	case c.From == "":
+		return fmt.Sprintf("%s %s", c.AllocID, c.To)
+	case c.To == "":
+		return fmt.Sprintf("%s %s", c.AllocID, c.From)
+	}
+	return fmt.Sprintf("%s %s %s -> %s", c.AllocID, c.Field, c.From, c.To)
+}
+
+// DiffAllocations - compares two allocation snapshots and returns the changes between them
+func DiffAllocations(prev, next []*api.AllocationStatus, at time.Time) []StatusChange {
+	changes := []StatusChange{}
+
+	prevByID := map[string]*api.AllocationStatus{}
+	for _, alloc := range prev {
+		prevByID[alloc.ID] = alloc
+	}
+
+	nextByID := map[string]bool{}
+
+	for _, alloc := range next {
+		nextByID[alloc.ID] = true
+
+		old, ok := prevByID[alloc.ID]
+		if !ok {
+			changes = append(changes, StatusChange{Time: at, AllocID: alloc.IDShort, Field: "instance", To: "added in " + alloc.Region})
+			continue
+		}
+
+		for _, field := range allocFields {
+			from, to := field.value(old), field.value(alloc)
+			if from != to {
+				changes = append(changes, StatusChange{Time: at, AllocID: alloc.IDShort, Field: field.name, From: from, To: to})
+			}
+		}
+	}
+
+	for _, alloc := range prev {
+		if !nextByID[alloc.ID] {
+			changes = append(changes, StatusChange{Time: at, AllocID: alloc.IDShort, Field: "instance", From: "removed"})
+		}
+	}
+
+	return changes
+}
+
+type allocField struct {
+	name  string
+	value func(*api.AllocationStatus) string
+}
+
+var allocFields = []allocField{
+	{"status", func(a *api.AllocationStatus) string { return a.Status }},
+	{"desired", func(a *api.AllocationStatus) string { return a.DesiredStatus }},
+	{"version", func(a *api.AllocationStatus) string { return "v" + strconv.Itoa(a.Version) }},
+	{"restarts", func(a *api.AllocationStatus) string { return strconv.Itoa(a.Restarts) }},
+	{"checks", func(a *api.AllocationStatus) string {
+		return fmt.Sprintf("%d passing/%d warning/%d critical", a.PassingCheckCount, a.WarningCheckCount, a.CriticalCheckCount)
+	}},
+}
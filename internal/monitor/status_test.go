@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func alloc(id string, status string, passing int, critical int) *api.AllocationStatus {
+	return &api.AllocationStatus{
+		ID:                 id + "-full",
+		IDShort:            id,
+		Region:             "ord",
+		Status:             status,
+		DesiredStatus:      "run",
+		Version:            3,
+		PassingCheckCount:  passing,
+		CriticalCheckCount: critical,
+	}
+}
+
+func TestDiffAllocations(t *testing.T) {
+	at := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		prev []*api.AllocationStatus
+		next []*api.AllocationStatus
+		want []string
+	}{
+		{
+			name: "unchanged",
+			prev: []*api.AllocationStatus{alloc("a1", "running", 1, 0)},
+			next: []*api.AllocationStatus{alloc("a1", "running", 1, 0)},
+			want: []string{},
+		},
+		{
+			name: "added",
+			prev: []*api.AllocationStatus{alloc("a1", "running", 1, 0)},
+			next: []*api.AllocationStatus{alloc("a1", "running", 1, 0), alloc("b2", "pending", 0, 0)},
+			want: []string{"b2 added in ord"},
+		},
+		{
+			name: "removed",
+			prev: []*api.AllocationStatus{alloc("a1", "running", 1, 0), alloc("b2", "running", 1, 0)},
+			next: []*api.AllocationStatus{alloc("b2", "running", 1, 0)},
+			want: []string{"a1 removed"},
+		},
+		{
+			name: "state changed",
+			prev: []*api.AllocationStatus{alloc("a1", "pending", 0, 0)},
+			next: []*api.AllocationStatus{alloc("a1", "running", 0, 0)},
+			want: []string{"a1 status pending -> running"},
+		},
+		{
+			name: "health changed",
+			prev: []*api.AllocationStatus{alloc("a1", "running", 1, 0)},
+			next: []*api.AllocationStatus{alloc("a1", "running", 0, 1)},
+			want: []string{"a1 checks 1 passing/0 warning/0 critical -> 0 passing/0 warning/1 critical"},
+		},
+		{
+			name: "replaced",
+			prev: []*api.AllocationStatus{alloc("a1", "running", 1, 0)},
+			next: []*api.AllocationStatus{alloc("b2", "pending", 0, 0)},
+			want: []string{"b2 added in ord", "a1 removed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := DiffAllocations(tt.prev, tt.next, at)
+
+			got := []string{}
+			for _, change := range changes {
+				assert.Equal(t, at, change.Time)
+				got = append(got, change.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
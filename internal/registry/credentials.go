@@ -0,0 +1,235 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dockerHubAuthKey - the key docker keeps Docker Hub's credentials under
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// identityTokenUsername - the username credential helpers return with an
+// identity token, rather than a password
+const identityTokenUsername = "<token>"
+
+// DockerAuth - credentials docker has for a registry
+type DockerAuth struct {
+	// ServerAddress - the registry, as docker keys its credentials
+	ServerAddress string
+	Username      string
+	Password      string
+	// IdentityToken - a token exchanged for access tokens, such as ACR's,
+	// used in place of a username and password
+	IdentityToken string
+}
+
+// dockerConfig - the parts of ~/.docker/config.json with credentials
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	// CredsStore - the credential helper keeping every registry's
+	// credentials, such as osxkeychain or desktop
+	CredsStore string `json:"credsStore"`
+	// CredHelpers - the credential helper for particular registries, such
+	// as ecr-login for ECR or gcloud for GCR
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// credentialHelper runs docker-credential-<helper> with action and input
+var credentialHelper = func(helper, action, input string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(string(out))
+		if message == "" {
+			message = strings.TrimSpace(stderr.String())
+		}
+		return nil, fmt.Errorf("docker-credential-%s %s: %v %s", helper, action, err, message)
+	}
+	return out, nil
+}
+
+func loadDockerConfig() *dockerConfig {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil
+	}
+
+	config := &dockerConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil
+	}
+	return config
+}
+
+// DockerCredentials returns the username and password docker login stored
+// for host, in ~/.docker/config.json or with a credential helper. Identity
+// tokens aren't returned, as they can't be used as a password.
+func DockerCredentials(host string) (string, string) {
+	auth, ok := LookupDockerAuth(host)
+	if !ok || auth.IdentityToken != "" {
+		return "", ""
+	}
+	return auth.Username, auth.Password
+}
+
+// LookupDockerAuth returns the credentials docker has for host, from the
+// credential helper configured for it, the credentials store, or
+// credentials kept in ~/.docker/config.json, in that order
+func LookupDockerAuth(host string) (DockerAuth, bool) {
+	config := loadDockerConfig()
+	if config == nil {
+		return DockerAuth{}, false
+	}
+
+	keys := []string{host, "https://" + host}
+	if isDockerHub(host) {
+		keys = append(keys, dockerHubAuthKey)
+	}
+
+	for _, key := range keys {
+		if helper, ok := config.CredHelpers[key]; ok {
+			return helperAuth(helper, key)
+		}
+	}
+
+	if config.CredsStore != "" {
+		for _, key := range keys {
+			if auth, ok := helperAuth(config.CredsStore, key); ok {
+				return auth, true
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if auth, ok := config.inlineAuth(key); ok {
+			return auth, true
+		}
+	}
+
+	return DockerAuth{}, false
+}
+
+// AllDockerAuths returns the credentials docker has for every registry, for
+// builds, which may pull base images from any of them. Registries whose
+// credential helper fails are left out.
+func AllDockerAuths() []DockerAuth {
+	config := loadDockerConfig()
+	if config == nil {
+		return nil
+	}
+
+	auths := map[string]DockerAuth{}
+	for key := range config.Auths {
+		if auth, ok := config.inlineAuth(key); ok {
+			auths[key] = auth
+		}
+	}
+
+	if config.CredsStore != "" {
+		if out, err := credentialHelper(config.CredsStore, "list", ""); err == nil {
+			var servers map[string]string
+			if err := json.Unmarshal(out, &servers); err == nil {
+				for server := range servers {
+					if auth, ok := helperAuth(config.CredsStore, server); ok {
+						auths[server] = auth
+					}
+				}
+			}
+		}
+	}
+
+	for server, helper := range config.CredHelpers {
+		if auth, ok := helperAuth(helper, server); ok {
+			auths[server] = auth
+		}
+	}
+
+	servers := make([]string, 0, len(auths))
+	for server := range auths {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+
+	result := make([]DockerAuth, 0, len(servers))
+	for _, server := range servers {
+		result = append(result, auths[server])
+	}
+	return result
+}
+
+// inlineAuth returns the credentials kept for key in the config itself
+func (c *dockerConfig) inlineAuth(key string) (DockerAuth, bool) {
+	entry, ok := c.Auths[key]
+	if !ok {
+		return DockerAuth{}, false
+	}
+
+	auth := DockerAuth{ServerAddress: key, IdentityToken: entry.IdentityToken}
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return DockerAuth{}, false
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return DockerAuth{}, false
+		}
+		auth.Username, auth.Password = parts[0], parts[1]
+	}
+
+	if auth.Username == "" && auth.IdentityToken == "" {
+		// with a credentials store, entries are only placeholders
+		return DockerAuth{}, false
+	}
+	return auth, true
+}
+
+// helperAuth gets server's credentials from a credential helper
+func helperAuth(helper, server string) (DockerAuth, bool) {
+	out, err := credentialHelper(helper, "get", server)
+	if err != nil {
+		return DockerAuth{}, false
+	}
+
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out, &creds); err != nil || creds.Secret == "" {
+		return DockerAuth{}, false
+	}
+
+	auth := DockerAuth{ServerAddress: server, Username: creds.Username, Password: creds.Secret}
+	if creds.Username == identityTokenUsername {
+		auth = DockerAuth{ServerAddress: server, IdentityToken: creds.Secret}
+	}
+	return auth, true
+}
+
+func isDockerHub(host string) bool {
+	return host == "docker.io" || host == "index.docker.io" || host == "registry-1.docker.io"
+}
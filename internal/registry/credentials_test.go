@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withDockerConfig writes config as the docker config for the test, with
+// credential helpers answering from helpers, keyed by helper then server
+func withDockerConfig(t *testing.T, config string, helpers map[string]map[string]string) {
+	dir, err := ioutil.TempDir("", "docker")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600))
+
+	os.Setenv("DOCKER_CONFIG", dir)
+	original := credentialHelper
+	credentialHelper = func(helper, action, input string) ([]byte, error) {
+		servers, ok := helpers[helper]
+		if !ok {
+			return nil, fmt.Errorf("docker-credential-%s not found", helper)
+		}
+		switch action {
+		case "list":
+			list := "{"
+			for server := range servers {
+				if len(list) > 1 {
+					list += ","
+				}
+				list += fmt.Sprintf("%q: \"user\"", server)
+			}
+			return []byte(list + "}"), nil
+		case "get":
+			if secret, ok := servers[input]; ok {
+				return []byte(secret), nil
+			}
+		}
+		return nil, errors.New("credentials not found in native keychain")
+	}
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		os.Unsetenv("DOCKER_CONFIG")
+		credentialHelper = original
+	})
+}
+
+func TestLookupDockerAuthPrefersCredHelpers(t *testing.T) {
+	withDockerConfig(t, `{
+		"auths": {"ghcr.io": {}, "123.dkr.ecr.us-east-1.amazonaws.com": {}},
+		"credsStore": "desktop",
+		"credHelpers": {"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}
+	}`, map[string]map[string]string{
+		"desktop":   {"ghcr.io": `{"Username": "octocat", "Secret": "ghp_token"}`},
+		"ecr-login": {"123.dkr.ecr.us-east-1.amazonaws.com": `{"Username": "AWS", "Secret": "ecr-password"}`},
+	})
+
+	auth, ok := LookupDockerAuth("123.dkr.ecr.us-east-1.amazonaws.com")
+	require.True(t, ok)
+	assert.Equal(t, "AWS", auth.Username)
+	assert.Equal(t, "ecr-password", auth.Password)
+
+	username, password := DockerCredentials("ghcr.io")
+	assert.Equal(t, "octocat", username)
+	assert.Equal(t, "ghp_token", password)
+
+	_, ok = LookupDockerAuth("quay.io")
+	assert.False(t, ok)
+}
+
+func TestLookupDockerAuthIdentityToken(t *testing.T) {
+	withDockerConfig(t, `{"credHelpers": {"team.azurecr.io": "acr"}}`, map[string]map[string]string{
+		"acr": {"team.azurecr.io": `{"Username": "<token>", "Secret": "refresh-token"}`},
+	})
+
+	auth, ok := LookupDockerAuth("team.azurecr.io")
+	require.True(t, ok)
+	assert.Equal(t, DockerAuth{ServerAddress: "team.azurecr.io", IdentityToken: "refresh-token"}, auth)
+
+	username, _ := DockerCredentials("team.azurecr.io")
+	assert.Empty(t, username)
+}
+
+func TestAllDockerAuths(t *testing.T) {
+	withDockerConfig(t, `{
+		"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"}, "gcr.io": {}},
+		"credHelpers": {"gcr.io": "gcloud", "broken.example.com": "missing"}
+	}`, map[string]map[string]string{
+		"gcloud": {"gcr.io": `{"Username": "oauth2accesstoken", "Secret": "ya29.token"}`},
+	})
+
+	auths := AllDockerAuths()
+	assert.Equal(t, []DockerAuth{
+		{ServerAddress: "gcr.io", Username: "oauth2accesstoken", Password: "ya29.token"},
+		{ServerAddress: "https://index.docker.io/v1/", Username: "user", Password: "pass"},
+	}, auths)
+}
@@ -0,0 +1,438 @@
+// Package registry reads manifests and blobs from container registries with
+// the distribution API, for checking images without pulling them through a
+// docker daemon.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestTypes - the manifest media types accepted, newest first
+var ManifestTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Media types of the OCI manifests and configs flyctl pushes to store
+// artifacts, such as signatures, alongside images
+const (
+	MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+)
+
+// ErrNotFound is returned for manifests and blobs that don't exist
+var ErrNotFound = errors.New("not found in registry")
+
+// Client - a registry client, authenticating with the credentials docker
+// has stored for each registry or anonymously
+type Client struct {
+	HTTP *http.Client
+	// Credentials returns the username and password for a registry host, if any
+	Credentials func(host string) (username, password string)
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// New returns a client using docker's stored credentials
+func New() *Client {
+	return &Client{
+		HTTP:        http.DefaultClient,
+		Credentials: DockerCredentials,
+		tokens:      map[string]string{},
+	}
+}
+
+// Manifest - an image manifest, or an index of manifests for each platform
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion,omitempty"`
+	MediaType     string       `json:"mediaType"`
+	Config        *Descriptor  `json:"config,omitempty"`
+	Layers        []Descriptor `json:"layers,omitempty"`
+	Manifests     []Descriptor `json:"manifests,omitempty"`
+}
+
+// Descriptor - content referenced by a manifest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Digest resolves a tag or digest to the digest of its manifest
+func (c *Client) Digest(ctx context.Context, host, repository, reference string) (string, error) {
+	resp, err := c.do(ctx, http.MethodHead, host, repository, c.endpoint(host, repository, "manifests/"+reference), acceptManifests, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("%s didn't return the digest of %s:%s", host, repository, reference)
+	}
+	return digest, nil
+}
+
+// Manifest fetches the manifest for a tag or digest
+func (c *Client) Manifest(ctx context.Context, host, repository, reference string) (*Manifest, error) {
+	m, _, err := c.manifest(ctx, host, repository, reference)
+	return m, err
+}
+
+func (c *Client) manifest(ctx context.Context, host, repository, reference string) (*Manifest, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, host, repository, c.endpoint(host, repository, "manifests/"+reference), acceptManifests, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest for %s:%s: %w", repository, reference, err)
+	}
+	return &m, resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// Image - what's known about an image from its manifest and config
+type Image struct {
+	Digest  string
+	Created time.Time
+	// Size - the compressed size of the image's layers. For images with a
+	// manifest per platform, the size of the first platform's.
+	Size int64
+}
+
+// Describe returns the digest, creation time and size of the image with
+// a tag or digest
+func (c *Client) Describe(ctx context.Context, host, repository, reference string) (*Image, error) {
+	m, digest, err := c.manifest(ctx, host, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	image := &Image{Digest: digest}
+
+	if len(m.Manifests) > 0 {
+		if m, _, err = c.manifest(ctx, host, repository, m.Manifests[0].Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, layer := range m.Layers {
+		image.Size += layer.Size
+	}
+
+	if m.Config != nil {
+		data, err := c.Blob(ctx, host, repository, m.Config.Digest, maxConfigSize)
+		if err != nil {
+			return nil, err
+		}
+		var config struct {
+			Created time.Time `json:"created"`
+		}
+		if err := json.Unmarshal(data, &config); err == nil {
+			image.Created = config.Created
+		}
+	}
+
+	return image, nil
+}
+
+// maxConfigSize - image configs are small JSON documents
+const maxConfigSize = 4 << 20
+
+// Tags lists the repository's tags
+func (c *Client) Tags(ctx context.Context, host, repository string) ([]string, error) {
+	var tags []string
+
+	next := c.endpoint(host, repository, "tags/list")
+	for next != "" {
+		resp, err := c.do(ctx, http.MethodGet, host, repository, next, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag list for %s: %w", repository, err)
+		}
+		tags = append(tags, page.Tags...)
+
+		next = nextLink(next, resp.Header.Get("Link"))
+	}
+
+	return tags, nil
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+
+// nextLink returns the URL of the next page from a Link header, resolved
+// against the current page's
+func nextLink(current, header string) string {
+	match := linkNext.FindStringSubmatch(header)
+	if match == nil {
+		return ""
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	link, err := url.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(link).String()
+}
+
+// Delete deletes the manifest with digest, and so every tag of it
+func (c *Client) Delete(ctx context.Context, host, repository, digest string) error {
+	resp, err := c.do(ctx, http.MethodDelete, host, repository, c.endpoint(host, repository, "manifests/"+digest), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Blob fetches a blob, up to maxSize bytes
+func (c *Client) Blob(ctx context.Context, host, repository, digest string, maxSize int64) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, host, repository, c.endpoint(host, repository, "blobs/"+digest), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxSize))
+}
+
+// PutBlob uploads data as a blob, unless the registry already has it,
+// returning its descriptor
+func (c *Client) PutBlob(ctx context.Context, host, repository, mediaType string, data []byte) (Descriptor, error) {
+	desc := Descriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", sha256.Sum256(data)),
+		Size:      int64(len(data)),
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, host, repository, c.endpoint(host, repository, "blobs/"+desc.Digest), nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		return desc, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return desc, err
+	}
+
+	uploads := c.endpoint(host, repository, "blobs/uploads/")
+	resp, err = c.do(ctx, http.MethodPost, host, repository, uploads, nil, nil)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+
+	location, err := uploadLocation(uploads, resp)
+	if err != nil {
+		return desc, fmt.Errorf("%s didn't return where to upload the blob to", host)
+	}
+
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+
+	header := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	resp, err = c.do(ctx, http.MethodPut, host, repository, location.String(), header, data)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+
+	return desc, nil
+}
+
+// PutManifest uploads manifest and tags it with reference
+func (c *Client) PutManifest(ctx context.Context, host, repository, reference string, manifest *Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": []string{manifest.MediaType}}
+	resp, err := c.do(ctx, http.MethodPut, host, repository, c.endpoint(host, repository, "manifests/"+reference), header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+var acceptManifests = http.Header{"Accept": []string{strings.Join(ManifestTypes, ", ")}}
+
+func (c *Client) endpoint(host, repository, path string) string {
+	return fmt.Sprintf("https://%s/v2/%s/%s", apiHost(host), repository, path)
+}
+
+// do sends a request, authenticating when the registry asks to. GET and
+// HEAD requests are authenticated to pull, DELETE for every action and
+// others to push.
+func (c *Client) do(ctx context.Context, method, host, repository, endpoint string, header http.Header, body []byte) (*http.Response, error) {
+	scope := "pull,push"
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		scope = "pull"
+	case http.MethodDelete:
+		scope = "*"
+	}
+	return c.doScoped(ctx, method, scope, host, repository, endpoint, header, body)
+}
+
+// doScoped sends a request authenticated for scope, for requests such as
+// checking on an upload that need more access than their method suggests
+func (c *Client) doScoped(ctx context.Context, method, scope, host, repository, endpoint string, header http.Header, body []byte) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if token := c.token(host, repository, scope); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if username, password := c.credentials(host); username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		return c.HTTP.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, host, repository, scope, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = send(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %w", method, endpoint, ErrNotFound)
+	case resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: registry returned %s", method, endpoint, resp.Status)
+	}
+
+	return resp, nil
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate fetches a bearer token for scope, pull or pull,push, on
+// repository, as asked for by the registry's challenge
+func (c *Client) authenticate(ctx context.Context, host, repository, scope, challenge string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("%s: unauthorized", host)
+	}
+
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("%s: authentication challenge without a realm", host)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:%s", repository, scope))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if username, password := c.credentials(host); username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: authentication failed: %s", host, resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[host+"/"+repository+":"+scope] = token
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Client) token(host, repository, scope string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[host+"/"+repository+":"+scope]
+}
+
+func (c *Client) credentials(host string) (string, string) {
+	if c.Credentials == nil {
+		return "", ""
+	}
+	return c.Credentials(host)
+}
+
+// apiHost - where a registry's API is served. Docker Hub's images are named
+// docker.io but its API is on another host.
+func apiHost(host string) string {
+	if host == "docker.io" || host == "index.docker.io" {
+		return "registry-1.docker.io"
+	}
+	return host
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestWithTokenAuth(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:team/app:pull", r.URL.Query().Get("scope"))
+			w.Write([]byte(`{"token": "secret-token"}`))
+		case r.Header.Get("Authorization") != "Bearer secret-token":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/team/app/manifests/latest":
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	digest, err := client.Digest(context.Background(), host, "team/app", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", digest)
+
+	_, err = client.Digest(context.Background(), host, "team/app", "missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestDockerCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass:word"))
+	config := fmt.Sprintf(`{"auths": {"https://index.docker.io/v1/": {"auth": %q}, "ghcr.io": {"auth": %q}}}`, auth, auth)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600))
+
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Unsetenv("DOCKER_CONFIG")
+
+	for _, host := range []string{"docker.io", "ghcr.io"} {
+		username, password := DockerCredentials(host)
+		assert.Equal(t, "user", username, host)
+		assert.Equal(t, "pass:word", password, host)
+	}
+
+	username, _ := DockerCredentials("quay.io")
+	assert.Empty(t, username)
+}
+
+func TestTagsFollowsPages(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/app/tags/list?last=b&n=2>; rel="next"`)
+			w.Write([]byte(`{"name": "app", "tags": ["a", "b"]}`))
+			return
+		}
+		w.Write([]byte(`{"name": "app", "tags": ["c"]}`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+
+	tags, err := client.Tags(context.Background(), strings.TrimPrefix(server.URL, "https://"), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, tags)
+}
+
+func TestDescribe(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/app/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", "sha256:index")
+			w.Write([]byte(`{"mediaType": "application/vnd.oci.image.index.v1+json", "manifests": [{"digest": "sha256:amd64"}]}`))
+		case "/v2/app/manifests/sha256:amd64":
+			w.Write([]byte(`{"config": {"digest": "sha256:config"}, "layers": [{"size": 100}, {"size": 20}]}`))
+		case "/v2/app/blobs/sha256:config":
+			w.Write([]byte(`{"created": "2021-06-01T12:00:00Z", "architecture": "amd64"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+
+	image, err := client.Describe(context.Background(), strings.TrimPrefix(server.URL, "https://"), "app", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:index", image.Digest)
+	assert.Equal(t, int64(120), image.Size)
+	assert.Equal(t, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), image.Created)
+}
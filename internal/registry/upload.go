@@ -0,0 +1,233 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChunkSize - how much of a blob UploadBlob sends in each request. A failed
+// upload resumes from the last chunk the registry committed.
+var ChunkSize int64 = 16 << 20
+
+// chunkAttempts - how many times in a row an upload is resumed after failing
+// without progress before giving up
+const chunkAttempts = 5
+
+// retryDelay - how long to wait before resuming an upload after the first
+// failure, which grows with each failure in a row
+var retryDelay = time.Second
+
+// BlobStatus - how a blob got into the registry
+type BlobStatus int
+
+const (
+	// BlobUploaded - the blob was uploaded
+	BlobUploaded BlobStatus = iota
+	// BlobExisted - the repository already had the blob
+	BlobExisted
+	// BlobMounted - the blob was mounted from another repository
+	BlobMounted
+)
+
+// Blob - a blob to upload with UploadBlob
+type Blob struct {
+	Digest  string
+	Size    int64
+	Content io.ReaderAt
+	// MountFrom - a repository on the same registry that may have the blob,
+	// which is mounted from instead of uploading it when it does
+	MountFrom string
+	// Progress is called with the bytes committed by the registry so far
+	Progress func(committed int64)
+	// Retrying is called when a chunk fails, before the upload resumes
+	Retrying func(err error, committed int64)
+}
+
+// UploadBlob uploads blob to repository in chunks, unless the repository
+// already has it or it can be mounted from blob.MountFrom. When a chunk
+// fails the upload resumes from what the registry committed, rather than
+// starting over.
+func (c *Client) UploadBlob(ctx context.Context, host, repository string, blob *Blob) (BlobStatus, error) {
+	resp, err := c.do(ctx, http.MethodHead, host, repository, c.endpoint(host, repository, "blobs/"+blob.Digest), nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		return BlobExisted, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return BlobUploaded, err
+	}
+
+	location, mounted, err := c.startUpload(ctx, host, repository, blob)
+	if err != nil {
+		return BlobUploaded, err
+	}
+	if mounted {
+		return BlobMounted, nil
+	}
+
+	var offset int64
+	failures := 0
+	for offset < blob.Size {
+		end := offset + ChunkSize
+		if end > blob.Size {
+			end = blob.Size
+		}
+
+		next, err := c.uploadChunk(ctx, host, repository, location, blob.Content, offset, end)
+		if err == nil {
+			location, offset, failures = next, end, 0
+			if blob.Progress != nil {
+				blob.Progress(offset)
+			}
+			continue
+		}
+
+		failures++
+		if ctx.Err() != nil || failures >= chunkAttempts {
+			return BlobUploaded, err
+		}
+		if blob.Retrying != nil {
+			blob.Retrying(err, offset)
+		}
+		select {
+		case <-ctx.Done():
+			return BlobUploaded, ctx.Err()
+		case <-time.After(retryDelay * time.Duration(failures)):
+		}
+
+		committed, next, err := c.uploadStatus(ctx, host, repository, location)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// the registry dropped the upload, so it has to start over
+			if location, _, err = c.startUpload(ctx, host, repository, &Blob{Digest: blob.Digest}); err != nil {
+				return BlobUploaded, err
+			}
+			offset = 0
+		case err == nil:
+			location, offset = next, committed
+		}
+	}
+
+	return BlobUploaded, c.finishUpload(ctx, host, repository, location, blob.Digest)
+}
+
+// startUpload starts an upload session, or mounts the blob from
+// blob.MountFrom when the registry can
+func (c *Client) startUpload(ctx context.Context, host, repository string, blob *Blob) (*url.URL, bool, error) {
+	uploads := c.endpoint(host, repository, "blobs/uploads/")
+	endpoint := uploads
+	if blob.MountFrom != "" && blob.MountFrom != repository {
+		endpoint += "?" + url.Values{"mount": {blob.Digest}, "from": {blob.MountFrom}}.Encode()
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, host, repository, endpoint, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated {
+		return nil, true, nil
+	}
+
+	location, err := uploadLocation(uploads, resp)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s didn't return where to upload the blob to", host)
+	}
+	return location, false, nil
+}
+
+// uploadChunk sends the bytes of content from start to end, returning where
+// to send the next chunk
+func (c *Client) uploadChunk(ctx context.Context, host, repository string, location *url.URL, content io.ReaderAt, start, end int64) (*url.URL, error) {
+	chunk := make([]byte, end-start)
+	if _, err := content.ReadAt(chunk, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	header := http.Header{
+		"Content-Type":  []string{"application/octet-stream"},
+		"Content-Range": []string{fmt.Sprintf("%d-%d", start, end-1)},
+	}
+	resp, err := c.do(ctx, http.MethodPatch, host, repository, location.String(), header, chunk)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return uploadLocation(location.String(), resp)
+}
+
+// uploadStatus returns how many bytes the registry has committed to an
+// upload, and where to send the next chunk
+func (c *Client) uploadStatus(ctx context.Context, host, repository string, location *url.URL) (int64, *url.URL, error) {
+	resp, err := c.doScoped(ctx, http.MethodGet, "pull,push", host, repository, location.String(), nil, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp.Body.Close()
+
+	next, err := uploadLocation(location.String(), resp)
+	if err != nil {
+		next = location
+	}
+
+	// Range is the inclusive range of bytes committed, such as 0-1023.
+	// Registries report an empty upload as 0-0 too, which is taken as empty,
+	// as chunks are never a single byte.
+	committed := int64(0)
+	if r := resp.Header.Get("Range"); r != "" {
+		parts := strings.SplitN(strings.TrimPrefix(r, "bytes="), "-", 2)
+		if len(parts) == 2 {
+			end, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				return 0, nil, fmt.Errorf("%s returned an invalid upload range %q", host, r)
+			}
+			if end > 0 {
+				committed = end + 1
+			}
+		}
+	}
+
+	return committed, next, nil
+}
+
+// finishUpload completes an upload, which the registry checks against digest
+func (c *Client) finishUpload(ctx context.Context, host, repository string, location *url.URL, digest string) error {
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	header := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	resp, err := c.do(ctx, http.MethodPut, host, repository, location.String(), header, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// uploadLocation resolves the Location of resp, which may be relative to
+// the endpoint the request was sent to
+func uploadLocation(endpoint string, resp *http.Response) (*url.URL, error) {
+	header := resp.Header.Get("Location")
+	if header == "" {
+		return nil, errors.New("no upload location")
+	}
+	location, err := url.Parse(header)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return base.ResolveReference(location), nil
+}
@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadRegistry - a registry that accepts chunked uploads, failing the
+// chunks in failChunks without committing them
+type uploadRegistry struct {
+	mu         sync.Mutex
+	uploads    map[string][]byte
+	blobs      map[string][]byte
+	mountable  map[string]bool
+	failChunks map[int]bool
+	chunks     int
+	resumes    int
+}
+
+func (r *uploadRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	const uploads = "/v2/team/app/blobs/uploads/"
+	switch {
+	case req.Method == http.MethodHead && strings.HasPrefix(req.URL.Path, "/v2/team/app/blobs/sha256:"):
+		if _, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/team/app/blobs/")]; !ok {
+			http.NotFound(w, req)
+		}
+	case req.Method == http.MethodPost && req.URL.Path == uploads:
+		if mount := req.URL.Query().Get("mount"); mount != "" && r.mountable[mount] {
+			r.blobs[mount] = nil
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		id := fmt.Sprintf("upload-%d", len(r.uploads))
+		r.uploads[id] = nil
+		w.Header().Set("Location", uploads+id)
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPatch:
+		id := strings.TrimPrefix(req.URL.Path, uploads)
+		body, _ := ioutil.ReadAll(req.Body)
+		r.chunks++
+		if r.failChunks[r.chunks] {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var start int
+		fmt.Sscanf(req.Header.Get("Content-Range"), "%d-", &start)
+		if start != len(r.uploads[id]) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		r.uploads[id] = append(r.uploads[id], body...)
+		w.Header().Set("Location", uploads+id)
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, uploads):
+		r.resumes++
+		id := strings.TrimPrefix(req.URL.Path, uploads)
+		w.Header().Set("Location", uploads+id)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.uploads[id])-1))
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodPut && strings.HasPrefix(req.URL.Path, uploads):
+		data := r.uploads[strings.TrimPrefix(req.URL.Path, uploads)]
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+		if digest != req.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = data
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func newUploadTest(t *testing.T, failChunks ...int) (*uploadRegistry, *Client, string) {
+	reg := &uploadRegistry{uploads: map[string][]byte{}, blobs: map[string][]byte{}, mountable: map[string]bool{}, failChunks: map[int]bool{}}
+	for _, chunk := range failChunks {
+		reg.failChunks[chunk] = true
+	}
+	server := httptest.NewTLSServer(reg)
+	t.Cleanup(server.Close)
+
+	client := New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+	return reg, client, strings.TrimPrefix(server.URL, "https://")
+}
+
+func testBlob(data []byte) *Blob {
+	return &Blob{Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(data)), Size: int64(len(data)), Content: bytes.NewReader(data)}
+}
+
+func TestUploadBlobResumesFailedChunk(t *testing.T) {
+	defer func(size int64) { ChunkSize = size }(ChunkSize)
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	ChunkSize, retryDelay = 4, 0
+
+	reg, client, host := newUploadTest(t, 2)
+	data := []byte("0123456789")
+	blob := testBlob(data)
+
+	var retried []int64
+	blob.Retrying = func(err error, committed int64) { retried = append(retried, committed) }
+
+	status, err := client.UploadBlob(context.Background(), host, "team/app", blob)
+	require.NoError(t, err)
+	assert.Equal(t, BlobUploaded, status)
+	assert.Equal(t, data, reg.blobs[blob.Digest])
+	assert.Equal(t, []int64{4}, retried)
+	assert.Equal(t, 1, reg.resumes)
+	assert.Equal(t, 4, reg.chunks)
+}
+
+func TestUploadBlobGivesUp(t *testing.T) {
+	defer func(size int64) { ChunkSize = size }(ChunkSize)
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	ChunkSize, retryDelay = 4, 0
+
+	_, client, host := newUploadTest(t, 1, 2, 3, 4, 5)
+
+	_, err := client.UploadBlob(context.Background(), host, "team/app", testBlob([]byte("0123456789")))
+	assert.Error(t, err)
+}
+
+func TestUploadBlobExistingAndMounted(t *testing.T) {
+	reg, client, host := newUploadTest(t)
+
+	existing := testBlob([]byte("existing"))
+	reg.blobs[existing.Digest] = []byte("existing")
+	status, err := client.UploadBlob(context.Background(), host, "team/app", existing)
+	require.NoError(t, err)
+	assert.Equal(t, BlobExisted, status)
+
+	mounted := testBlob([]byte("mounted"))
+	mounted.MountFrom = "team/other"
+	reg.mountable[mounted.Digest] = true
+	status, err = client.UploadBlob(context.Background(), host, "team/app", mounted)
+	require.NoError(t, err)
+	assert.Equal(t, BlobMounted, status)
+	assert.Zero(t, reg.chunks)
+}
@@ -0,0 +1,131 @@
+// Package sbom generates software bills of materials for images with syft
+// (https://github.com/anchore/syft) and stores them in the image's registry,
+// tagged the way cosign attach sbom does.
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cli/safeexec"
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+// maxSize - SBOMs of large images run to a few megabytes, anything much larger isn't one
+const maxSize = 64 << 20
+
+// Format - an SBOM format, as syft writes it and as it's stored in registries
+type Format struct {
+	Name      string
+	SyftName  string
+	MediaType string
+}
+
+// Formats - the SBOM formats which can be generated, the first is the default
+var Formats = []Format{
+	{Name: "cyclonedx", SyftName: "cyclonedx-json", MediaType: "application/vnd.cyclonedx+json"},
+	{Name: "spdx", SyftName: "spdx-json", MediaType: "text/spdx+json"},
+}
+
+// FormatNamed returns the format with name
+func FormatNamed(name string) (Format, error) {
+	names := make([]string, 0, len(Formats))
+	for _, format := range Formats {
+		if format.Name == name {
+			return format, nil
+		}
+		names = append(names, format.Name)
+	}
+	return Format{}, fmt.Errorf("unknown SBOM format %q, use one of %s", name, strings.Join(names, ", "))
+}
+
+// Available is true when syft is installed
+func Available() bool {
+	_, err := safeexec.LookPath("syft")
+	return err == nil
+}
+
+// Credentials - registry credentials passed to syft to pull the image
+type Credentials struct {
+	Host     string
+	Username string
+	Password string
+}
+
+// Generate runs syft over the image in the registry, returning its SBOM
+func Generate(ctx context.Context, imageRef string, format Format, creds *Credentials) ([]byte, error) {
+	syft, err := safeexec.LookPath("syft")
+	if err != nil {
+		return nil, fmt.Errorf("syft isn't installed. See https://github.com/anchore/syft#installation")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, syft, "registry:"+imageRef, "-o", format.SyftName, "-q")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	if creds != nil {
+		cmd.Env = append(cmd.Env,
+			"SYFT_REGISTRY_AUTH_AUTHORITY="+creds.Host,
+			"SYFT_REGISTRY_AUTH_USERNAME="+creds.Username,
+			"SYFT_REGISTRY_AUTH_PASSWORD="+creds.Password,
+		)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("syft failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Tag - the tag the SBOM of the image with digest is stored under
+func Tag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sbom"
+}
+
+// Attach stores sbom in the registry alongside the image with digest,
+// replacing any attached before
+func Attach(ctx context.Context, client *registry.Client, host, repository, digest string, format Format, sbom []byte) error {
+	layer, err := client.PutBlob(ctx, host, repository, format.MediaType, sbom)
+	if err != nil {
+		return err
+	}
+
+	config, err := client.PutBlob(ctx, host, repository, registry.MediaTypeConfig, []byte("{}"))
+	if err != nil {
+		return err
+	}
+
+	manifest := &registry.Manifest{
+		SchemaVersion: 2,
+		MediaType:     registry.MediaTypeManifest,
+		Config:        &config,
+		Layers:        []registry.Descriptor{layer},
+	}
+
+	return client.PutManifest(ctx, host, repository, Tag(digest), manifest)
+}
+
+// Fetch returns the SBOM attached to the image with digest, and its media type
+func Fetch(ctx context.Context, client *registry.Client, host, repository, digest string) ([]byte, string, error) {
+	manifest, err := client.Manifest(ctx, host, repository, Tag(digest))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, "", fmt.Errorf("the SBOM for %s has no content", digest)
+	}
+
+	layer := manifest.Layers[0]
+	data, err := client.Blob(ctx, host, repository, layer.Digest, maxSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, layer.MediaType, nil
+}
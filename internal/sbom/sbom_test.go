@@ -0,0 +1,76 @@
+package sbom
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+const testDigest = "sha256:bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb"
+
+func TestFormatNamed(t *testing.T) {
+	format, err := FormatNamed("spdx")
+	require.NoError(t, err)
+	assert.Equal(t, "spdx-json", format.SyftName)
+
+	_, err = FormatNamed("csv")
+	assert.EqualError(t, err, `unknown SBOM format "csv", use one of cyclonedx, spdx`)
+}
+
+func TestTag(t *testing.T) {
+	assert.Equal(t, "sha256-bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb.sbom", Tag(testDigest))
+}
+
+func TestAttachThenFetch(t *testing.T) {
+	var mu sync.Mutex
+	content := map[string][]byte{}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", "/v2/app/blobs/uploads/1")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/app/blobs/uploads/1":
+			content["/v2/app/blobs/"+r.URL.Query().Get("digest")], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut:
+			content[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			data, ok := content[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	client := registry.New()
+	client.HTTP = server.Client()
+	client.Credentials = nil
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	_, _, err := Fetch(context.Background(), client, host, "app", testDigest)
+	assert.ErrorIs(t, err, registry.ErrNotFound)
+
+	doc := []byte(`{"bomFormat": "CycloneDX", "components": []}`)
+	require.NoError(t, Attach(context.Background(), client, host, "app", testDigest, Formats[0], doc))
+
+	data, mediaType, err := Fetch(context.Background(), client, host, "app", testDigest)
+	require.NoError(t, err)
+	assert.Equal(t, doc, data)
+	assert.Equal(t, "application/vnd.cyclonedx+json", mediaType)
+}
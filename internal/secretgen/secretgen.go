@@ -0,0 +1,114 @@
+// Package secretgen generates random values for common kinds of credentials
+package secretgen
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PublicKeySuffix - appended to a secret's name to hold the public half of a generated keypair
+const PublicKeySuffix = "_PUBLIC"
+
+type generator func(name string) (map[string]string, error)
+
+var generators = map[string]generator{
+	"hex32":   randomHex(16),
+	"hex64":   randomHex(32),
+	"base64":  randomBase64(32),
+	"uuid":    randomUUID,
+	"rsa":     rsaKeypair(2048),
+	"rsa4096": rsaKeypair(4096),
+}
+
+// Types - the kinds of secret that can be generated
+func Types() []string {
+	types := []string{}
+	for t := range generators {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Generate - generates a value of the given type for the named secret. Keypairs produce a
+// second secret holding the public key, named with PublicKeySuffix.
+func Generate(name string, kind string) (map[string]string, error) {
+	gen, ok := generators[strings.ToLower(kind)]
+	if !ok {
+		return nil, fmt.Errorf("unknown secret type %s, must be one of %s", kind, strings.Join(Types(), ", "))
+	}
+
+	return gen(name)
+}
+
+// RandomHex - returns n random bytes encoded as hex
+func RandomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func randomHex(n int) generator {
+	return func(name string) (map[string]string, error) {
+		value, err := RandomHex(n)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{name: value}, nil
+	}
+}
+
+func randomBase64(n int) generator {
+	return func(name string) (map[string]string, error) {
+		buf := make([]byte, n)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		return map[string]string{name: base64.StdEncoding.EncodeToString(buf)}, nil
+	}
+}
+
+func randomUUID(name string) (map[string]string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	// version 4, variant 10
+	buf[6] = (buf[6] & 0x0f) | 0x40
+	buf[8] = (buf[8] & 0x3f) | 0x80
+
+	value := fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
+	return map[string]string{name: value}, nil
+}
+
+func rsaKeypair(bits int) generator {
+	return func(name string) (map[string]string, error) {
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+
+		private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+		return map[string]string{
+			name:                   string(private),
+			name + PublicKeySuffix: string(public),
+		}, nil
+	}
+}
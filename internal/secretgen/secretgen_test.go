@@ -0,0 +1,46 @@
+package secretgen
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	values, err := Generate("SECRET_KEY_BASE", "hex64")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{64}$`), values["SECRET_KEY_BASE"])
+
+	values, err = Generate("TOKEN", "BASE64")
+	assert.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(values["TOKEN"])
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 32)
+
+	values, err = Generate("ID", "uuid")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), values["ID"])
+
+	_, err = Generate("X", "md5")
+	assert.Error(t, err)
+}
+
+func TestGenerateRSA(t *testing.T) {
+	values, err := Generate("JWT_KEY", "rsa")
+	assert.NoError(t, err)
+	assert.Len(t, values, 2)
+
+	block, _ := pem.Decode([]byte(values["JWT_KEY"]))
+	assert.NotNil(t, block)
+	_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	assert.NoError(t, err)
+
+	block, _ = pem.Decode([]byte(values["JWT_KEY"+PublicKeySuffix]))
+	assert.NotNil(t, block)
+	_, err = x509.ParsePKIXPublicKey(block.Bytes)
+	assert.NoError(t, err)
+}
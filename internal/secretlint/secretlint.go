@@ -0,0 +1,251 @@
+// Package secretlint finds environment variables that look like secrets in
+// Dockerfiles and app config, where they'd be baked into images or stored in
+// plain text instead of being set with `flyctl secrets set`.
+package secretlint
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding - a variable that looks like a secret
+type Finding struct {
+	// Source - where the variable is set, such as Dockerfile:12
+	Source string
+	Name   string
+	Reason string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s", f.Source, f.Name, f.Reason)
+}
+
+var secretName = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|PASSPHRASE|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?|AUTH_?KEY|SIGNING_?KEY)`)
+
+// names ending in these hold references to secrets rather than secrets
+var referenceName = regexp.MustCompile(`(?i)(_FILE|_PATH|_DIR|_NAME|_ID|_HEADER|_ENABLED|_URL_NAME)$`)
+
+var secretPrefixes = map[string]string{
+	"sk_live_":   "a Stripe live key",
+	"rk_live_":   "a Stripe restricted key",
+	"ghp_":       "a GitHub token",
+	"github_pat": "a GitHub token",
+	"glpat-":     "a GitLab token",
+	"xoxb-":      "a Slack token",
+	"xoxp-":      "a Slack token",
+	"AKIA":       "an AWS access key",
+	"AIza":       "a Google API key",
+	"-----BEGIN": "a private key",
+}
+
+// Check - the variables in env that look like secrets
+func Check(source string, env map[string]string) []Finding {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	findings := []Finding{}
+	for _, name := range names {
+		if reason := secretReason(name, env[name]); reason != "" {
+			findings = append(findings, Finding{Source: source, Name: name, Reason: reason})
+		}
+	}
+	return findings
+}
+
+// CheckDockerfile - the ENV and ARG defaults in a Dockerfile that look like secrets
+func CheckDockerfile(path string) ([]Finding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	findings := []Finding{}
+	source := displayName(path)
+
+	scanner := bufio.NewScanner(file)
+	lineNo, startLine := 0, 0
+	instruction := ""
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		if instruction == "" {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			startLine = lineNo
+		}
+
+		// join continued lines into one instruction
+		if strings.HasSuffix(line, "\\") {
+			instruction += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		instruction += line
+
+		for _, v := range instructionVariables(instruction) {
+			if reason := secretReason(v.name, v.value); reason != "" {
+				findings = append(findings, Finding{
+					Source: fmt.Sprintf("%s:%d", source, startLine),
+					Name:   v.name,
+					Reason: reason,
+				})
+			}
+		}
+		instruction = ""
+	}
+
+	return findings, scanner.Err()
+}
+
+type variable struct {
+	name  string
+	value string
+}
+
+// instructionVariables parses ENV KEY=VALUE..., the legacy ENV KEY VALUE
+// and ARG KEY=DEFAULT
+func instructionVariables(instruction string) []variable {
+	fields := strings.SplitN(instruction, " ", 2)
+	if len(fields) != 2 {
+		return nil
+	}
+
+	keyword, rest := strings.ToUpper(fields[0]), strings.TrimSpace(fields[1])
+	if keyword != "ENV" && keyword != "ARG" {
+		return nil
+	}
+
+	words := splitWords(rest)
+	if len(words) == 0 {
+		return nil
+	}
+
+	if keyword == "ENV" && !strings.Contains(words[0], "=") {
+		return []variable{{name: words[0], value: strings.Join(words[1:], " ")}}
+	}
+
+	vars := []variable{}
+	for _, word := range words {
+		parts := strings.SplitN(word, "=", 2)
+		if len(parts) == 2 {
+			vars = append(vars, variable{name: parts[0], value: parts[1]})
+		}
+	}
+	return vars
+}
+
+// splitWords splits on spaces outside of quotes, removing the quotes
+func splitWords(s string) []string {
+	words := []string{}
+	var current strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inWord = true
+		case quote == 0 && (r == ' ' || r == '\t'):
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+
+	return words
+}
+
+// secretReason explains why a variable looks like a secret, or is empty
+func secretReason(name string, value string) string {
+	value = strings.TrimSpace(value)
+	if isPlaceholder(value) {
+		return ""
+	}
+
+	for prefix, kind := range secretPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return fmt.Sprintf("looks like %s", kind)
+		}
+	}
+
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && !isPlaceholder(password) {
+			return "is a URL with a password in it"
+		}
+	}
+
+	if secretName.MatchString(name) && !referenceName.MatchString(name) {
+		return "is named like a secret"
+	}
+
+	if len(value) >= 20 && !strings.ContainsAny(value, " /.") && entropy(value) >= 4.0 {
+		return "has a random looking value"
+	}
+
+	return ""
+}
+
+// isPlaceholder is true for values that can't be secrets: empty values,
+// variable references, booleans and numbers
+func isPlaceholder(value string) bool {
+	if value == "" || strings.HasPrefix(value, "$") {
+		return true
+	}
+
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "none", "null", "changeme", "xxx":
+		return true
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+
+	return false
+}
+
+// entropy - Shannon entropy in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var bits float64
+	n := float64(len([]rune(s)))
+	for _, c := range counts {
+		p := float64(c) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+func displayName(path string) string {
+	if cwd, err := os.Getwd(); err == nil && strings.HasPrefix(path, cwd+string(os.PathSeparator)) {
+		return strings.TrimPrefix(path, cwd+string(os.PathSeparator))
+	}
+	return path
+}
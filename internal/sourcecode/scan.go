@@ -1,9 +1,14 @@
 package sourcecode
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 
+	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/helpers"
 )
 
@@ -12,16 +17,49 @@ type SourceInfo struct {
 	DockerfilePath string
 	Builder        string
 	Buildpacks     []string
-	Secrets        map[string]string
+	// Secrets - secrets to ask for, with a description of each
+	Secrets map[string]string
+	// GeneratedSecrets - secrets to set to random values, by name, with the
+	// kind of value to generate as understood by secretgen
+	GeneratedSecrets map[string]string
+	// Env - environment variables for fly.toml
+	Env map[string]string
+	// Files - generated files, such as a Dockerfile, to write to the source directory
+	Files []SourceFile
+	// Port - the port the app listens on, passed to it as PORT
+	Port int
+	// HTTPCheckPath - a path the app answers with a 200, for health checks
+	HTTPCheckPath string
+	Statics       []flyctl.Static
+	// EdgeRegions - regions to suggest running in, for apps that benefit from
+	// being close to users
+	EdgeRegions []string
+	// DatabaseDesired - the framework expects a Postgres database, passed to
+	// it as DATABASE_URL
+	DatabaseDesired bool
 }
 
+type SourceFile struct {
+	Path     string
+	Contents string
+}
+
+// edgeRegions spread an app across continents
+var edgeRegions = []string{"iad", "lhr", "sin", "syd"}
+
 func Scan(sourceDir string) (*SourceInfo, error) {
 	scanners := []sourceScanner{
 		configureDockerfile,
+		configureRails,
 		configureRuby,
 		configureGo,
+		configurePhoenix,
 		configureElixir,
+		configureDjango,
+		configureDeno,
+		configureBun,
 		configureNode,
+		configureStatic,
 	}
 
 	for _, scanner := range scanners {
@@ -58,6 +96,18 @@ func fileExists(filenames ...string) checkFn {
 	}
 }
 
+// fileContains checks a file's contents match pattern
+func fileContains(filename string, pattern string) checkFn {
+	re := regexp.MustCompile(pattern)
+	return func(dir string) bool {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			return false
+		}
+		return re.Match(data)
+	}
+}
+
 type checkFn func(dir string) bool
 
 func checksPass(sourceDir string, checks ...checkFn) bool {
@@ -82,6 +132,27 @@ func configureDockerfile(sourceDir string) (*SourceInfo, error) {
 	return s, nil
 }
 
+func configureRails(sourceDir string) (*SourceInfo, error) {
+	if !checksPass(sourceDir, fileContains("Gemfile", `gem\s+['"]rails['"]`)) {
+		return nil, nil
+	}
+
+	s := &SourceInfo{
+		Builder: "heroku/buildpacks:20",
+		Family:  "Rails",
+		GeneratedSecrets: map[string]string{
+			"SECRET_KEY_BASE": "hex64",
+		},
+		Env: map[string]string{
+			"RAILS_LOG_TO_STDOUT":      "enabled",
+			"RAILS_SERVE_STATIC_FILES": "enabled",
+		},
+		DatabaseDesired: true,
+	}
+
+	return s, nil
+}
+
 func configureRuby(sourceDir string) (*SourceInfo, error) {
 	if !checksPass(sourceDir, fileExists("Gemfile")) {
 		return nil, nil
@@ -122,6 +193,27 @@ func configureNode(sourceDir string) (*SourceInfo, error) {
 	return s, nil
 }
 
+func configurePhoenix(sourceDir string) (*SourceInfo, error) {
+	if !checksPass(sourceDir, fileContains("mix.exs", `\{:phoenix,`)) {
+		return nil, nil
+	}
+
+	s := &SourceInfo{
+		Builder: "heroku/buildpacks:18",
+		Buildpacks: []string{
+			"https://cnb-shim.herokuapp.com/v1/hashnuke/elixir",
+			"https://cnb-shim.herokuapp.com/v1/gjaldon/phoenix-static",
+		},
+		Family: "Phoenix",
+		GeneratedSecrets: map[string]string{
+			"SECRET_KEY_BASE": "hex64",
+		},
+		DatabaseDesired: true,
+	}
+
+	return s, nil
+}
+
 func configureElixir(sourceDir string) (*SourceInfo, error) {
 	if !helpers.FileExists(filepath.Join(sourceDir, "mix.exs")) {
 		return nil, nil
@@ -138,3 +230,178 @@ func configureElixir(sourceDir string) (*SourceInfo, error) {
 
 	return s, nil
 }
+
+func configureDjango(sourceDir string) (*SourceInfo, error) {
+	if !checksPass(sourceDir, fileExists("manage.py")) || !checksPass(sourceDir, fileContains("requirements.txt", `(?im)^django\b`)) {
+		return nil, nil
+	}
+
+	// the project package is the one holding the WSGI application
+	matches, err := filepath.Glob(filepath.Join(sourceDir, "*", "wsgi.py"))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("found a Django app in %s, but no wsgi.py file in its project package", sourceDir)
+	}
+	project := filepath.Base(filepath.Dir(matches[0]))
+
+	s := &SourceInfo{
+		Family: "Django",
+		Files: []SourceFile{
+			{Path: "Dockerfile", Contents: fmt.Sprintf(djangoDockerfile, project)},
+		},
+		Port: 8080,
+		GeneratedSecrets: map[string]string{
+			"SECRET_KEY": "base64",
+		},
+		DatabaseDesired: true,
+	}
+
+	return s, nil
+}
+
+func configureDeno(sourceDir string) (*SourceInfo, error) {
+	if !checksPass(sourceDir, fileExists("deno.json", "deno.jsonc", "deps.ts")) {
+		return nil, nil
+	}
+
+	entrypoint := firstExisting(sourceDir, "main.ts", "mod.ts", "server.ts", "main.js")
+	if entrypoint == "" {
+		entrypoint = "main.ts"
+	}
+
+	s := &SourceInfo{
+		Family: "Deno",
+		Files: []SourceFile{
+			{Path: "Dockerfile", Contents: fmt.Sprintf(denoDockerfile, entrypoint, entrypoint)},
+		},
+		Port:          8080,
+		HTTPCheckPath: "/",
+		EdgeRegions:   edgeRegions,
+	}
+
+	return s, nil
+}
+
+func configureBun(sourceDir string) (*SourceInfo, error) {
+	if !helpers.FileExists(filepath.Join(sourceDir, "package.json")) || !checksPass(sourceDir, fileExists("bun.lockb", "bunfig.toml")) {
+		return nil, nil
+	}
+
+	var pkg struct {
+		Main    string
+		Scripts map[string]string
+	}
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parse package.json: %w", err)
+	}
+
+	command := `"bun", "run", "start"`
+	if _, ok := pkg.Scripts["start"]; !ok {
+		entrypoint := pkg.Main
+		if entrypoint == "" {
+			entrypoint = firstExisting(sourceDir, "index.ts", "index.js", "src/index.ts")
+		}
+		if entrypoint == "" {
+			entrypoint = "index.ts"
+		}
+		command = fmt.Sprintf(`"bun", "run", %q`, entrypoint)
+	}
+
+	s := &SourceInfo{
+		Family: "Bun",
+		Files: []SourceFile{
+			{Path: "Dockerfile", Contents: fmt.Sprintf(bunDockerfile, command)},
+		},
+		Port:          8080,
+		HTTPCheckPath: "/",
+		EdgeRegions:   edgeRegions,
+	}
+
+	return s, nil
+}
+
+// configureStatic serves sites of only static files from Fly's proxy, with a
+// small web server in the image for anything the statics don't cover
+func configureStatic(sourceDir string) (*SourceInfo, error) {
+	if !helpers.FileExists(filepath.Join(sourceDir, "index.html")) {
+		return nil, nil
+	}
+
+	s := &SourceInfo{
+		Family: "Static",
+		Files: []SourceFile{
+			{Path: "Dockerfile", Contents: staticDockerfile},
+		},
+		Port:          8080,
+		HTTPCheckPath: "/",
+		Statics: []flyctl.Static{
+			{GuestPath: "/srv/http", URLPrefix: "/"},
+		},
+		EdgeRegions: edgeRegions,
+	}
+
+	return s, nil
+}
+
+func firstExisting(dir string, filenames ...string) string {
+	for _, filename := range filenames {
+		if helpers.FileExists(filepath.Join(dir, filename)) {
+			return filename
+		}
+	}
+	return ""
+}
+
+const denoDockerfile = `FROM denoland/deno:latest
+
+WORKDIR /app
+USER deno
+
+COPY . .
+RUN deno cache %s
+
+EXPOSE 8080
+CMD ["run", "--allow-net", "--allow-env", "--allow-read", "%s"]
+`
+
+const bunDockerfile = `FROM oven/bun:latest
+
+WORKDIR /app
+
+COPY package.json bun.lockb* ./
+RUN bun install --production
+
+COPY . .
+
+ENV NODE_ENV=production
+EXPOSE 8080
+CMD [%s]
+`
+
+const djangoDockerfile = `FROM python:3.9-slim
+
+ENV PYTHONDONTWRITEBYTECODE=1 PYTHONUNBUFFERED=1
+WORKDIR /app
+
+COPY requirements.txt ./
+RUN pip install --no-cache-dir -r requirements.txt gunicorn
+
+COPY . .
+
+EXPOSE 8080
+CMD ["gunicorn", "--bind", ":8080", "--workers", "2", "%s.wsgi"]
+`
+
+const staticDockerfile = `FROM pierrezemb/gostatic
+
+COPY . /srv/http/
+
+EXPOSE 8080
+CMD ["-port", "8080", "-https-promote", "-enable-logging"]
+`
@@ -0,0 +1,111 @@
+package sourcecode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanFiles(t *testing.T, files map[string]string) *SourceInfo {
+	dir, err := ioutil.TempDir("", "scan")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+
+	si, err := Scan(dir)
+	require.NoError(t, err)
+	return si
+}
+
+func TestScanDeno(t *testing.T) {
+	si := scanFiles(t, map[string]string{"deno.json": "{}", "server.ts": ""})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Deno", si.Family)
+	assert.Contains(t, si.Files[0].Contents, `"server.ts"`)
+	assert.Equal(t, 8080, si.Port)
+}
+
+func TestScanBunBeforeNode(t *testing.T) {
+	si := scanFiles(t, map[string]string{
+		"package.json": `{"scripts": {"start": "bun server.ts"}}`,
+		"bun.lockb":    "",
+	})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Bun", si.Family)
+	assert.Contains(t, si.Files[0].Contents, `CMD ["bun", "run", "start"]`)
+}
+
+func TestScanBunEntrypoint(t *testing.T) {
+	si := scanFiles(t, map[string]string{
+		"package.json": `{"main": "app.ts"}`,
+		"bunfig.toml":  "",
+	})
+
+	require.NotNil(t, si)
+	assert.Contains(t, si.Files[0].Contents, `CMD ["bun", "run", "app.ts"]`)
+}
+
+func TestScanStatic(t *testing.T) {
+	si := scanFiles(t, map[string]string{"index.html": "<html></html>"})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Static", si.Family)
+	assert.Equal(t, "/srv/http", si.Statics[0].GuestPath)
+	assert.NotEmpty(t, si.EdgeRegions)
+}
+
+func TestScanNodeWithIndexHTML(t *testing.T) {
+	si := scanFiles(t, map[string]string{"package.json": "{}", "index.html": ""})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "NodeJS", si.Family)
+}
+
+func TestScanRails(t *testing.T) {
+	si := scanFiles(t, map[string]string{"Gemfile": "source 'https://rubygems.org'\ngem 'rails', '~> 6.1'\n"})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Rails", si.Family)
+	assert.Equal(t, "hex64", si.GeneratedSecrets["SECRET_KEY_BASE"])
+	assert.True(t, si.DatabaseDesired)
+
+	si = scanFiles(t, map[string]string{"Gemfile": "gem 'sinatra'\n"})
+	require.NotNil(t, si)
+	assert.Equal(t, "Ruby", si.Family)
+}
+
+func TestScanDjango(t *testing.T) {
+	si := scanFiles(t, map[string]string{
+		"manage.py":        "",
+		"requirements.txt": "Django==3.2\npsycopg2-binary\n",
+		"mysite/wsgi.py":   "",
+	})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Django", si.Family)
+	assert.Contains(t, si.Files[0].Contents, `"mysite.wsgi"`)
+	assert.True(t, si.DatabaseDesired)
+}
+
+func TestScanPhoenix(t *testing.T) {
+	si := scanFiles(t, map[string]string{"mix.exs": `defp deps do [{:phoenix, "~> 1.5.9"}] end`})
+
+	require.NotNil(t, si)
+	assert.Equal(t, "Phoenix", si.Family)
+	assert.Len(t, si.Buildpacks, 2)
+
+	si = scanFiles(t, map[string]string{"mix.exs": `defp deps do [{:jason, "~> 1.0"}] end`})
+	require.NotNil(t, si)
+	assert.Equal(t, "Elixir", si.Family)
+}
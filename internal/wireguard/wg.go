@@ -20,53 +20,11 @@ import (
 )
 
 func StateForOrg(apiClient *api.Client, org *api.Organization, regionCode string, name string) (*wg.WireGuardState, error) {
-	var (
-		svm map[string]interface{}
-		ok  bool
-	)
-
-	sv := viper.Get(flyctl.ConfigWireGuardState)
-	if sv != nil {
-		terminal.Debugf("Found WireGuard state in local configuration\n")
-
-		svm, ok = sv.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("garbage stored in wireguard_state in config")
-		}
-
-		// no state saved for this org
-		savedStatev, ok := svm[org.Slug]
-		if !ok {
-			goto NEW_CONNECTION
-		}
-
-		savedPeerv, ok := savedStatev.(map[string]interface{})["peer"]
-		if !ok {
-			return nil, fmt.Errorf("garbage stored in wireguard_state in config (under peer)")
-		}
-
-		savedState := savedStatev.(map[string]interface{})
-		savedPeer := savedPeerv.(map[string]interface{})
-
-		// if we get this far and the config is garbled, i'm fine
-		// with a panic
-		return &wg.WireGuardState{
-			Org:          org.Slug,
-			Name:         savedState["name"].(string),
-			Region:       savedState["region"].(string),
-			LocalPublic:  savedState["localpublic"].(string),
-			LocalPrivate: savedState["localprivate"].(string),
-			Peer: api.CreatedWireGuardPeer{
-				Peerip:     savedPeer["peerip"].(string),
-				Endpointip: savedPeer["endpointip"].(string),
-				Pubkey:     savedPeer["pubkey"].(string),
-			},
-		}, nil
-	} else {
-		svm = map[string]interface{}{}
+	state, err := SavedStateForOrg(org)
+	if err != nil || state != nil {
+		return state, err
 	}
 
-NEW_CONNECTION:
 	terminal.Debugf("Can't find matching WireGuard configuration; creating new one\n")
 
 	stateb, err := Create(apiClient, org, regionCode, name)
@@ -74,6 +32,10 @@ NEW_CONNECTION:
 		return nil, err
 	}
 
+	svm, _ := viper.Get(flyctl.ConfigWireGuardState).(map[string]interface{})
+	if svm == nil {
+		svm = map[string]interface{}{}
+	}
 	svm[stateb.Org] = stateb
 
 	viper.Set(flyctl.ConfigWireGuardState, &svm)
@@ -84,6 +46,52 @@ NEW_CONNECTION:
 	return stateb, err
 }
 
+// SavedStateForOrg returns the WireGuard peer saved in the local
+// configuration for org, or nil when there isn't one. Unlike StateForOrg it
+// never creates a peer.
+func SavedStateForOrg(org *api.Organization) (*wg.WireGuardState, error) {
+	sv := viper.Get(flyctl.ConfigWireGuardState)
+	if sv == nil {
+		return nil, nil
+	}
+
+	terminal.Debugf("Found WireGuard state in local configuration\n")
+
+	svm, ok := sv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("garbage stored in wireguard_state in config")
+	}
+
+	// no state saved for this org
+	savedStatev, ok := svm[org.Slug]
+	if !ok {
+		return nil, nil
+	}
+
+	savedPeerv, ok := savedStatev.(map[string]interface{})["peer"]
+	if !ok {
+		return nil, fmt.Errorf("garbage stored in wireguard_state in config (under peer)")
+	}
+
+	savedState := savedStatev.(map[string]interface{})
+	savedPeer := savedPeerv.(map[string]interface{})
+
+	// if we get this far and the config is garbled, i'm fine
+	// with a panic
+	return &wg.WireGuardState{
+		Org:          org.Slug,
+		Name:         savedState["name"].(string),
+		Region:       savedState["region"].(string),
+		LocalPublic:  savedState["localpublic"].(string),
+		LocalPrivate: savedState["localprivate"].(string),
+		Peer: api.CreatedWireGuardPeer{
+			Peerip:     savedPeer["peerip"].(string),
+			Endpointip: savedPeer["endpointip"].(string),
+			Pubkey:     savedPeer["pubkey"].(string),
+		},
+	}, nil
+}
+
 func Create(apiClient *api.Client, org *api.Organization, regionCode, name string) (*wg.WireGuardState, error) {
 	var (
 		err error
@@ -9,13 +9,13 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
-	"github.com/hashicorp/go-multierror"
 	"github.com/logrusorgru/aurora"
 	"github.com/superfly/flyctl/cmd"
 	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/flyname"
 	"github.com/superfly/flyctl/internal/client"
 	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/internal/update"
 	"github.com/superfly/flyctl/terminal"
 )
@@ -87,7 +87,11 @@ func main() {
 		fmt.Fprintln(os.Stderr, aurora.Yellow(fmt.Sprintf("Run \"%s\" to upgrade", aurora.Bold(flyname.Name()+" version update"))))
 	}
 
-	_, err := root.ExecuteC()
+	args, err := cmd.ExpandAliases(root, os.Args[1:])
+	checkErr(err)
+	root.SetArgs(args)
+
+	_, err = root.ExecuteC()
 	checkErr(err)
 }
 
@@ -96,35 +100,15 @@ func checkErr(err error) {
 		return
 	}
 
-	if !isCancelledError(err) {
-		fmt.Println(aurora.Red("Error"), err)
-	}
-
-	safeExit()
-}
-
-func isCancelledError(err error) bool {
-	if err == cmd.ErrAbort {
-		return true
-	}
-
-	if err == context.Canceled {
-		return true
-	}
-
-	if merr, ok := err.(*multierror.Error); ok {
-		if len(merr.Errors) == 1 && merr.Errors[0] == context.Canceled {
-			return true
-		}
-	}
+	cmd.ReportError(err)
 
-	return false
+	safeExit(flyerr.ExitCode(err))
 }
 
-func safeExit() {
+func safeExit(code int) {
 	flyctl.BackgroundTaskWG.Wait()
 
-	os.Exit(1)
+	os.Exit(code)
 }
 
 func checkForUpdate(currentVersion string) (*update.Release, error) {
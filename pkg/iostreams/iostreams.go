@@ -35,6 +35,7 @@ type IOStreams struct {
 
 	progressIndicatorEnabled bool
 	progressIndicator        *spinner.Spinner
+	progressMode             ProgressMode
 
 	stdinTTYOverride  bool
 	stdinIsTTY        bool
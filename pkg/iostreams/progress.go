@@ -0,0 +1,190 @@
+package iostreams
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProgressMode - how the progress of long running phases, such as waiting
+// for a remote builder or pushing an image, is shown
+type ProgressMode string
+
+const (
+	// ProgressAuto - spinners on terminals, plain lines otherwise
+	ProgressAuto ProgressMode = "auto"
+	// ProgressTTY - spinners and progress bars, for terminals
+	ProgressTTY ProgressMode = "tty"
+	// ProgressPlain - a line as each phase starts and ends, and a heartbeat
+	// line with the time elapsed while it runs
+	ProgressPlain ProgressMode = "plain"
+	// ProgressJSON - a JSON object for each line of plain progress
+	ProgressJSON ProgressMode = "json"
+	// ProgressNone - no progress at all
+	ProgressNone ProgressMode = "none"
+)
+
+// ProgressModes - the modes accepted by ParseProgressMode
+var ProgressModes = []ProgressMode{ProgressAuto, ProgressTTY, ProgressPlain, ProgressJSON, ProgressNone}
+
+// ParseProgressMode parses a --progress value, where "" means auto
+func ParseProgressMode(value string) (ProgressMode, error) {
+	if value == "" {
+		return ProgressAuto, nil
+	}
+	for _, mode := range ProgressModes {
+		if ProgressMode(value) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown progress mode %q, expected auto, tty, plain, json or none", value)
+}
+
+// heartbeatInterval - how often a running phase is reported in plain and
+// JSON progress
+var heartbeatInterval = 15 * time.Second
+
+// newHeartbeat returns a channel ticking every interval and a function
+// stopping it, replaced in tests
+var newHeartbeat = func(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// SetProgressMode sets how progress is shown. Spinners are only shown in tty
+// mode, or in auto mode on a terminal.
+func (s *IOStreams) SetProgressMode(mode ProgressMode) {
+	s.progressMode = mode
+	switch mode {
+	case ProgressTTY:
+		s.progressIndicatorEnabled = true
+	case ProgressPlain, ProgressJSON, ProgressNone:
+		s.progressIndicatorEnabled = false
+	}
+}
+
+// ProgressMode returns how progress is shown, resolving auto to tty on
+// interactive terminals and plain otherwise
+func (s *IOStreams) ProgressMode() ProgressMode {
+	if s.progressMode != "" && s.progressMode != ProgressAuto {
+		return s.progressMode
+	}
+	if s.IsInteractive() && s.IsStderrTTY() {
+		return ProgressTTY
+	}
+	return ProgressPlain
+}
+
+// Phase - a long running step of a command, reported by StartPhase
+type Phase struct {
+	streams *IOStreams
+	name    string
+	started time.Time
+	detail  string
+	done    chan struct{}
+	// stopped - closed once heartbeats have stopped, so none follow the end
+	stopped chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+// phaseEvent - a line of JSON progress
+type phaseEvent struct {
+	Phase          string `json:"phase"`
+	Status         string `json:"status"`
+	ElapsedSeconds int    `json:"elapsed_seconds"`
+	Detail         string `json:"detail,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// StartPhase reports name as running until End is called. Terminals are left
+// to the caller's own progress display, so that phases aren't silent on other
+// streams, plain and JSON progress report the phase as it starts, at each
+// heartbeat and as it ends.
+func (s *IOStreams) StartPhase(name string) *Phase {
+	p := &Phase{streams: s, name: name, started: time.Now(), done: make(chan struct{}), stopped: make(chan struct{})}
+
+	mode := s.ProgressMode()
+	if mode != ProgressPlain && mode != ProgressJSON {
+		close(p.done)
+		close(p.stopped)
+		return p
+	}
+
+	p.report("started", nil)
+	go func() {
+		defer close(p.stopped)
+		ticks, stop := newHeartbeat(heartbeatInterval)
+		defer stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticks:
+				p.report("running", nil)
+			}
+		}
+	}()
+
+	return p
+}
+
+// SetDetail sets how far the phase has got, such as the bytes pushed so far,
+// which is reported with the following heartbeats and the phase's end
+func (p *Phase) SetDetail(detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.detail = detail
+}
+
+// End reports the phase as done, or as failed with err. Only the first call
+// is reported.
+func (p *Phase) End(err error) {
+	p.once.Do(func() {
+		mode := p.streams.ProgressMode()
+		if mode != ProgressPlain && mode != ProgressJSON {
+			return
+		}
+		close(p.done)
+		<-p.stopped
+		if err != nil {
+			p.report("failed", err)
+		} else {
+			p.report("done", nil)
+		}
+	})
+}
+
+func (p *Phase) report(status string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := time.Since(p.started).Round(time.Second)
+
+	if p.streams.ProgressMode() == ProgressJSON {
+		event := phaseEvent{Phase: p.name, Status: status, ElapsedSeconds: int(elapsed.Seconds()), Detail: p.detail}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		data, _ := json.Marshal(event)
+		fmt.Fprintln(p.streams.ErrOut, string(data))
+		return
+	}
+
+	detail := ""
+	if p.detail != "" {
+		detail = " (" + p.detail + ")"
+	}
+
+	switch status {
+	case "started":
+		fmt.Fprintf(p.streams.ErrOut, "%s...\n", p.name)
+	case "running":
+		fmt.Fprintf(p.streams.ErrOut, "%s... still running after %s%s\n", p.name, elapsed, detail)
+	case "done":
+		fmt.Fprintf(p.streams.ErrOut, "%s done in %s%s\n", p.name, elapsed, detail)
+	case "failed":
+		fmt.Fprintf(p.streams.ErrOut, "%s failed after %s%s: %v\n", p.name, elapsed, detail, err)
+	}
+}
@@ -0,0 +1,80 @@
+package iostreams
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgressMode(t *testing.T) {
+	mode, err := ParseProgressMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, ProgressAuto, mode)
+
+	mode, err = ParseProgressMode("json")
+	assert.NoError(t, err)
+	assert.Equal(t, ProgressJSON, mode)
+
+	_, err = ParseProgressMode("fancy")
+	assert.Error(t, err)
+}
+
+func TestAutoProgressWithoutTerminal(t *testing.T) {
+	streams, _, _, _ := Test()
+	assert.Equal(t, ProgressPlain, streams.ProgressMode())
+
+	streams.SetProgressMode(ProgressNone)
+	assert.Equal(t, ProgressNone, streams.ProgressMode())
+}
+
+func TestPlainPhaseHeartbeats(t *testing.T) {
+	ticks := make(chan time.Time)
+	defer func(prev func(time.Duration) (<-chan time.Time, func())) { newHeartbeat = prev }(newHeartbeat)
+	newHeartbeat = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+
+	streams, _, _, errOut := Test()
+	phase := streams.StartPhase("Pushing image")
+	ticks <- time.Now()
+	ticks <- time.Now()
+	phase.End(nil)
+	phase.End(errors.New("ignored"))
+
+	lines := strings.Split(strings.TrimSpace(errOut.String()), "\n")
+	assert.Equal(t, "Pushing image...", lines[0])
+	assert.Contains(t, lines[1], "Pushing image... still running after")
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "Pushing image done in"))
+}
+
+func TestJSONPhase(t *testing.T) {
+	streams, _, _, errOut := Test()
+	streams.SetProgressMode(ProgressJSON)
+
+	streams.StartPhase("Monitoring deployment").End(errors.New("v3 failed"))
+
+	assert.Equal(t, `{"phase":"Monitoring deployment","status":"started","elapsed_seconds":0}
+{"phase":"Monitoring deployment","status":"failed","elapsed_seconds":0,"error":"v3 failed"}
+`, errOut.String())
+}
+
+func TestNoPhaseOutput(t *testing.T) {
+	streams, _, _, errOut := Test()
+	streams.SetProgressMode(ProgressNone)
+
+	streams.StartPhase("Pushing image").End(nil)
+	assert.Empty(t, errOut.String())
+}
+
+func TestPhaseDetail(t *testing.T) {
+	streams, _, _, errOut := Test()
+
+	phase := streams.StartPhase("Pushing image")
+	phase.SetDetail("3 of 7 layers")
+	phase.End(nil)
+
+	assert.Contains(t, errOut.String(), "Pushing image done in 0s (3 of 7 layers)\n")
+}
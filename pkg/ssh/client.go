@@ -91,3 +91,20 @@ func (c *Client) Shell(ctx context.Context, term *Terminal, cmd string) error {
 
 	return term.attach(ctx, sess, cmd)
 }
+
+// Output runs cmd without a terminal and returns its standard output
+func (c *Client) Output(ctx context.Context, cmd string) ([]byte, error) {
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	sess, err := c.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+
+	return sess.Output(cmd)
+}
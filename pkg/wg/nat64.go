@@ -0,0 +1,97 @@
+package wg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+// nat64Probe - the name IPv6-only networks with DNS64 answer with their
+// NAT64 prefix, as described in RFC 7050
+const nat64Probe = "ipv4only.arpa"
+
+// the IPv4 addresses ipv4only.arpa resolves to
+var nat64WellKnownIPs = []net.IP{net.IPv4(192, 0, 0, 170).To4(), net.IPv4(192, 0, 0, 171).To4()}
+
+// HasIPv4Route is true when this host can route to the IPv4 internet
+func HasIPv4Route() bool {
+	return hasRoute("udp4", "192.0.2.1:53")
+}
+
+// HasIPv6Route is true when this host can route to the IPv6 internet
+func HasIPv6Route() bool {
+	return hasRoute("udp6", "[2001:db8::1]:53")
+}
+
+// hasRoute is true when there's a route to addr. Connecting a UDP socket
+// only looks up the route, so nothing is sent.
+func hasRoute(network, addr string) bool {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// NAT64Prefix returns the /96 prefix an IPv6-only network's NAT64 gateway
+// reaches IPv4 addresses through, found with DNS64
+func NAT64Prefix(ctx context.Context) (*net.IPNet, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, nat64Probe)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return nat64PrefixFrom(ips)
+}
+
+// nat64PrefixFrom returns the prefix of the first of ips that's an address
+// of ipv4only.arpa synthesized by DNS64
+func nat64PrefixFrom(ips []net.IP) (*net.IPNet, error) {
+	for _, ip := range ips {
+		if ip.To4() != nil || len(ip) != net.IPv6len {
+			continue
+		}
+		for _, known := range nat64WellKnownIPs {
+			if bytes.Equal(ip[12:], known) {
+				prefix := make(net.IP, net.IPv6len)
+				copy(prefix, ip[:12])
+				return &net.IPNet{IP: prefix, Mask: net.CIDRMask(96, 128)}, nil
+			}
+		}
+	}
+	return nil, errors.New("no NAT64 prefix, DNS64 isn't answering for " + nat64Probe)
+}
+
+// synthesizeNAT64 returns the address of ip through prefix
+func synthesizeNAT64(prefix *net.IPNet, ip net.IP) net.IP {
+	synthesized := make(net.IP, net.IPv6len)
+	copy(synthesized, prefix.IP.To16())
+	copy(synthesized[12:], ip.To4())
+	return synthesized
+}
+
+// reachableIP returns ip, or its NAT64 address when ip is IPv4 and this
+// host only routes IPv6
+func reachableIP(ip net.IP) net.IP {
+	if ip.To4() == nil || HasIPv4Route() || !HasIPv6Route() {
+		return ip
+	}
+
+	prefix, err := NAT64Prefix(context.Background())
+	if err != nil {
+		terminal.Debugf("No IPv4 route to %s, and %v\n", ip, err)
+		return ip
+	}
+
+	synthesized := synthesizeNAT64(prefix, ip)
+	terminal.Debugf("No IPv4 route, reaching %s through NAT64 as %s\n", ip, synthesized)
+	return synthesized
+}
@@ -0,0 +1,27 @@
+package wg
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNAT64PrefixFrom(t *testing.T) {
+	prefix, err := nat64PrefixFrom([]net.IP{net.ParseIP("192.0.0.170"), net.ParseIP("64:ff9b::c000:aa")})
+	require.NoError(t, err)
+	assert.Equal(t, "64:ff9b::/96", prefix.String())
+
+	prefix, err = nat64PrefixFrom([]net.IP{net.ParseIP("2001:db8:64::c000:ab")})
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8:64::/96", prefix.String())
+
+	_, err = nat64PrefixFrom([]net.IP{net.ParseIP("2001:db8::1")})
+	assert.Error(t, err)
+}
+
+func TestSynthesizeNAT64(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("64:ff9b::/96")
+	assert.Equal(t, "64:ff9b::cb00:7101", synthesizeNAT64(prefix, net.ParseIP("203.0.113.1")).String())
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flyctl"
 )
 
 type WireGuardState struct {
@@ -63,6 +65,7 @@ func (s *WireGuardState) TunnelConfig() *Config {
 		RemoteNetwork:   &wgr,
 		Endpoint:        s.Peer.Endpointip + ":51820",
 		DNS:             dns,
+		WebSockets:      viper.GetBool(flyctl.ConfigWireGuardWebsockets),
 		// LogLevel:        9999999,
 	}
 }
@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/superfly/flyctl/terminal"
 	"golang.zx2c4.com/wireguard/device"
 	"golang.zx2c4.com/wireguard/tun"
 	"golang.zx2c4.com/wireguard/tun/netstack"
@@ -19,9 +23,52 @@ type Tunnel struct {
 	net *netstack.Net
 
 	resolv *net.Resolver
+	relay  *websocketRelay
 }
 
+// udpProbeTimeout - how long a tunnel over UDP gets to complete a handshake
+// before Connect falls back to WebSockets
+var udpProbeTimeout = 5 * time.Second
+
+// Connect establishes a tunnel over UDP, or over WebSockets when
+// cfg.WebSockets is set. When the UDP tunnel can't complete a handshake,
+// as on networks that block UDP, it's reconnected over WebSockets.
 func Connect(cfg Config) (*Tunnel, error) {
+	return ConnectContext(context.Background(), cfg)
+}
+
+// ConnectContext is Connect, giving up on the UDP handshake and the
+// fallback to WebSockets when ctx is done
+func ConnectContext(ctx context.Context, cfg Config) (*Tunnel, error) {
+	if cfg.WebSockets {
+		return connect(cfg, true)
+	}
+
+	t, err := connect(cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	if t.probe(ctx) {
+		return t, nil
+	}
+	if err := ctx.Err(); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	terminal.Debugf("No WireGuard handshake over UDP within %s, connecting over WebSockets\n", udpProbeTimeout)
+	ws, err := connect(cfg, true)
+	if err != nil {
+		// the handshake may only be slow, so UDP is still worth a try
+		terminal.Debugf("%v, staying on UDP\n", err)
+		return t, nil
+	}
+	t.Close()
+
+	return ws, nil
+}
+
+func connect(cfg Config, websockets bool) (*Tunnel, error) {
 	localIPs := []net.IP{cfg.LocalNetwork.IP}
 	dnsIP := cfg.DNS
 
@@ -45,9 +92,17 @@ func Connect(cfg Config) (*Tunnel, error) {
 		return nil, err
 	}
 
-	endpointIP := endpointIPs[rand.Intn(len(endpointIPs))]
+	endpointIP := reachableIP(endpointIPs[rand.Intn(len(endpointIPs))])
 	endpointAddr := net.JoinHostPort(endpointIP.String(), endpointPort)
 
+	var relay *websocketRelay
+	if websockets {
+		if relay, err = newWebsocketRelay(websocketURL(endpointIP.String())); err != nil {
+			return nil, fmt.Errorf("can't connect to the WireGuard gateway over WebSockets: %w", err)
+		}
+		endpointAddr = relay.Addr()
+	}
+
 	wgDev := device.NewDevice(tunDev, device.NewLogger(cfg.LogLevel, "(fly-ssh) "))
 
 	wgConf := bytes.NewBuffer(nil)
@@ -58,6 +113,9 @@ func Connect(cfg Config) (*Tunnel, error) {
 	fmt.Fprintf(wgConf, "persistent_keepalive_interval=%d\n", cfg.KeepAlive)
 
 	if err := wgDev.IpcSetOperation(bufio.NewReader(wgConf)); err != nil {
+		if relay != nil {
+			relay.Close()
+		}
 		return nil, err
 	}
 	wgDev.Up()
@@ -67,6 +125,8 @@ func Connect(cfg Config) (*Tunnel, error) {
 		tun: tunDev,
 		net: gNet,
 
+		relay: relay,
+
 		resolv: &net.Resolver{
 			PreferGo: true,
 			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
@@ -80,8 +140,11 @@ func (t *Tunnel) Close() error {
 	if t.dev != nil {
 		t.dev.Close()
 	}
+	if t.relay != nil {
+		t.relay.Close()
+	}
 
-	t.dev, t.net, t.tun = nil, nil, nil
+	t.dev, t.net, t.tun, t.relay = nil, nil, nil, nil
 	return nil
 }
 
@@ -92,3 +155,54 @@ func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Con
 func (t *Tunnel) Resolver() *net.Resolver {
 	return t.resolv
 }
+
+// WebSockets is true when the tunnel runs over WebSockets rather than UDP
+func (t *Tunnel) WebSockets() bool {
+	return t.relay != nil
+}
+
+// probe sends a DNS query through the tunnel, which has it handshake with
+// the gateway, returning whether the handshake completed in time
+func (t *Tunnel) probe(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, udpProbeTimeout)
+	defer cancel()
+
+	if _, err := t.resolv.LookupTXT(ctx, "_apps.internal"); err == nil {
+		return true
+	}
+	return !t.LastHandshake().IsZero()
+}
+
+// LastHandshake returns when the tunnel last completed a handshake with its
+// peer, or the zero time if it never has
+func (t *Tunnel) LastHandshake() time.Time {
+	if t.dev == nil {
+		return time.Time{}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := bufio.NewWriter(buf)
+	if err := t.dev.IpcGetOperation(w); err != nil {
+		return time.Time{}
+	}
+	w.Flush()
+
+	var sec, nsec int64
+	for _, line := range strings.Split(buf.String(), "\n") {
+		key, value := line, ""
+		if i := strings.Index(line, "="); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "last_handshake_time_sec":
+			sec, _ = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			nsec, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+
+	if sec == 0 && nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, nsec)
+}
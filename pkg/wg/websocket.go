@@ -0,0 +1,117 @@
+package wg
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketPath - where WireGuard gateways accept WebSocket connections.
+// Each binary message carries one WireGuard datagram.
+const websocketPath = "/wg"
+
+// websocketHandshakeTimeout - how long connecting the WebSocket may take
+const websocketHandshakeTimeout = 15 * time.Second
+
+// websocketURL returns the WebSocket URL of the gateway at endpointHost,
+// which listens on the HTTPS port so it gets through networks that only
+// allow web traffic
+func websocketURL(endpointHost string) string {
+	return (&url.URL{Scheme: "wss", Host: net.JoinHostPort(endpointHost, "443"), Path: websocketPath}).String()
+}
+
+// websocketRelay relays datagrams between the WireGuard device, over a UDP
+// socket on the loopback interface, and the gateway, over a WebSocket, for
+// networks that block UDP
+type websocketRelay struct {
+	udp *net.UDPConn
+	ws  *websocket.Conn
+
+	mu     sync.Mutex
+	device *net.UDPAddr
+}
+
+func newWebsocketRelay(wsURL string) (*websocketRelay, error) {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocketHandshakeTimeout,
+		// WireGuard authenticates the gateway by its public key, so TLS only
+		// has to make the connection look like any other HTTPS traffic.
+		// Gateways are addressed by IP and have no certificate for it.
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	ws, _, err := dialer.Dial(wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		// IPv6-only hosts may not have IPv4 loopback
+		udp, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv6loopback})
+	}
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	r := &websocketRelay{udp: udp, ws: ws}
+	go r.toGateway()
+	go r.fromGateway()
+	return r, nil
+}
+
+// Addr - the address the WireGuard device sends to in place of the gateway
+func (r *websocketRelay) Addr() string {
+	return r.udp.LocalAddr().String()
+}
+
+func (r *websocketRelay) Close() error {
+	r.ws.Close()
+	return r.udp.Close()
+}
+
+func (r *websocketRelay) toGateway() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := r.udp.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		r.device = addr
+		r.mu.Unlock()
+
+		if err := r.ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+			return
+		}
+	}
+}
+
+func (r *websocketRelay) fromGateway() {
+	for {
+		_, msg, err := r.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		device := r.device
+		r.mu.Unlock()
+
+		// the gateway only ever answers the device, so it has sent already
+		if device == nil {
+			continue
+		}
+		if _, err := r.udp.WriteToUDP(msg, device); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package wg
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoGateway echoes each message back, with its path checked
+func echoGateway(t *testing.T) string {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != websocketPath {
+			http.NotFound(w, r)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			kind, msg, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := ws.WriteMessage(kind, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "wss://" + strings.TrimPrefix(server.URL, "https://") + websocketPath
+}
+
+func TestWebsocketRelay(t *testing.T) {
+	relay, err := newWebsocketRelay(echoGateway(t))
+	require.NoError(t, err)
+	defer relay.Close()
+
+	relayAddr, err := net.ResolveUDPAddr("udp", relay.Addr())
+	require.NoError(t, err)
+	device, err := net.DialUDP("udp", nil, relayAddr)
+	require.NoError(t, err)
+	defer device.Close()
+
+	for _, datagram := range []string{"handshake initiation", "transport data"} {
+		_, err = device.Write([]byte(datagram))
+		require.NoError(t, err)
+
+		device.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 1500)
+		n, err := device.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, datagram, string(buf[:n]))
+	}
+}
+
+func TestWebsocketURL(t *testing.T) {
+	assert.Equal(t, "wss://1.2.3.4:443/wg", websocketURL("1.2.3.4"))
+	assert.Equal(t, "wss://[2604:1380::1]:443/wg", websocketURL("2604:1380::1"))
+}
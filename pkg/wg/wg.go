@@ -20,6 +20,9 @@ type Config struct {
 	KeepAlive int    `toml:"keepalive"`
 	MTU       int    `toml:"mtu"`
 	LogLevel  int    `toml:"log_level"`
+
+	// WebSockets - tunnel over WebSockets rather than UDP
+	WebSockets bool `toml:"websockets"`
 }
 
 type IPNet net.IPNet
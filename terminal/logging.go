@@ -11,7 +11,8 @@ import (
 type LogLevel int
 
 const (
-	LevelDebug LogLevel = iota
+	LevelTrace LogLevel = iota
+	LevelDebug
 	LevelInfo
 	LevelWarn
 	LevelError
@@ -19,6 +20,8 @@ const (
 
 func init() {
 	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "trace":
+		SetLogLevel(LevelTrace)
 	case "debug":
 		SetLogLevel(LevelDebug)
 	case "info":
@@ -38,6 +41,16 @@ func SetLogLevel(lvl LogLevel) {
 	level = lvl
 }
 
+// IsTrace - whether API requests should be traced
+func IsTrace() bool {
+	return level <= LevelTrace
+}
+
+// IsDebug - whether debug messages are being logged
+func IsDebug() bool {
+	return level <= LevelDebug
+}
+
 func Debug(v ...interface{}) {
 	if level > LevelDebug {
 		return